@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels_config"
@@ -642,6 +643,24 @@ func (hs *HTTPServer) NotificationTest(c *models.ReqContext) response.Response {
 		Type:           dto.Type,
 		Settings:       dto.Settings,
 		SecureSettings: dto.SecureSettings,
+		DryRun:         dto.DryRun,
+	}
+
+	if dto.DashboardUID != "" {
+		evalContext, err := hs.AlertEngine.NotificationTestEvalContext(c.Req.Context(), c.OrgID, dto.DashboardUID, dto.PanelID, dto.RenderImage, c.SignedInUser)
+		if err != nil {
+			if errors.Is(err, dashboards.ErrDashboardNotFound) {
+				return response.Error(404, "Dashboard not found", err)
+			}
+			if errors.Is(err, alerting.ErrNotificationTestAccessDenied) {
+				return response.Error(403, "Access denied to this dashboard", err)
+			}
+			if errors.Is(err, datasources.ErrDataSourceAccessDenied) {
+				return response.Error(403, "Access denied to datasource", err)
+			}
+			return response.Error(400, "Failed to evaluate panel for test notification", err)
+		}
+		cmd.EvalContext = evalContext
 	}
 
 	if err := hs.AlertNotificationService.HandleNotificationTestCommand(c.Req.Context(), cmd); err != nil {
@@ -656,6 +675,12 @@ func (hs *HTTPServer) NotificationTest(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to send alert notifications", err)
 	}
 
+	if cmd.DryRun {
+		return response.JSON(http.StatusOK, util.DynMap{
+			"message":  "Test notification rendered",
+			"requests": cmd.Result,
+		})
+	}
 	return response.Success("Test notification sent")
 }
 
@@ -125,6 +125,15 @@ type NotificationTestCommand struct {
 	Frequency             string            `json:"frequency"`
 	Settings              *simplejson.Json  `json:"settings"`
 	SecureSettings        map[string]string `json:"secureSettings"`
+	// DashboardUID and PanelID optionally select a panel whose alert is evaluated
+	// to fill the test notification with real data.
+	DashboardUID string `json:"dashboardUid,omitempty"`
+	PanelID      int64  `json:"panelId,omitempty"`
+	// RenderImage renders the selected panel instead of using a sample image.
+	RenderImage bool `json:"renderImage,omitempty"`
+	// DryRun renders the notification and returns the requests of the notifier without
+	// sending them.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 type PauseAlertCommand struct {
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"crypto/tls"
 	"errors"
+	"net/http"
+	"net/url"
 
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -26,6 +29,8 @@ type SendEmailCommand struct {
 	ReplyTo       []string
 	EmbeddedFiles []string
 	AttachedFiles []*SendEmailAttachFile
+	// Headers are added to the headers of the email, such as its priority.
+	Headers map[string]string
 }
 
 // SendEmailCommandSync is the command for sending emails synchronously
@@ -42,6 +47,16 @@ type SendWebhookSync struct {
 	HttpHeader  map[string]string
 	ContentType string
 	Validation  func(body []byte, statusCode int) error
+	// TLSConfig and ProxyURL replace the default TLS configuration and the proxies of the
+	// environment, when set.
+	TLSConfig *tls.Config
+	ProxyURL  *url.URL
+	// Proxy replaces the proxies of the environment when set, like ProxyURL, but returns the
+	// proxy of each request, or nil to send it directly.
+	Proxy func(*http.Request) (*url.URL, error)
+	// ResponseHeaders is called with the headers of the response before its validation, when set,
+	// e.g. to read the Retry-After header of the rate-limited requests.
+	ResponseHeaders func(header http.Header)
 }
 
 type SendResetPasswordEmailCommand struct {
@@ -219,6 +219,7 @@ var wireBasicSet = wire.NewSet(
 	jwt.ProvideService,
 	wire.Bind(new(models.JWTService), new(*jwt.AuthService)),
 	ngstore.ProvideDBStore,
+	wire.Bind(new(ngstore.NotificationHistoryStore), new(*ngstore.DBstore)),
 	ngimage.ProvideDeleteExpiredService,
 	ngalert.ProvideService,
 	librarypanels.ProvideService,
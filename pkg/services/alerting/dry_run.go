@@ -0,0 +1,59 @@
+package alerting
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// DryRunRequest is a request that a notifier would have sent in a test notification.
+type DryRunRequest struct {
+	// Target is the scheme and the host of the webhook, or the recipients of the email. The
+	// path and the query of the webhooks are not returned, as they often contain credentials.
+	Target string `json:"target"`
+	Body   string `json:"body"`
+}
+
+// dryRunNotificationService records the webhooks and the emails of the notifiers instead of
+// sending them.
+type dryRunNotificationService struct {
+	mtx      sync.Mutex
+	requests []DryRunRequest
+}
+
+var _ notifications.Service = &dryRunNotificationService{}
+
+func (s *dryRunNotificationService) record(target, body string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.requests = append(s.requests, DryRunRequest{Target: target, Body: body})
+}
+
+func (s *dryRunNotificationService) Requests() []DryRunRequest {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]DryRunRequest{}, s.requests...)
+}
+
+func (s *dryRunNotificationService) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	target := ""
+	if u, err := url.Parse(cmd.Url); err == nil {
+		target = u.Scheme + "://" + u.Host
+	}
+	s.record(target, cmd.Body)
+	return nil
+}
+
+func (s *dryRunNotificationService) SendEmailCommandHandlerSync(_ context.Context, cmd *models.SendEmailCommandSync) error {
+	s.record("mailto:"+strings.Join(cmd.To, ","), cmd.Subject)
+	return nil
+}
+
+func (s *dryRunNotificationService) SendEmailCommandHandler(_ context.Context, cmd *models.SendEmailCommand) error {
+	s.record("mailto:"+strings.Join(cmd.To, ","), cmd.Subject)
+	return nil
+}
@@ -0,0 +1,208 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// webexMaxMessageLength is the maximum length in bytes of the markdown of a message.
+const webexMaxMessageLength = 7439
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "webex",
+		Name:        "Cisco Webex",
+		Description: "Sends notifications to a Cisco Webex space using an incoming webhook",
+		Heading:     "Webex settings",
+		Factory:     NewWebexNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:        "Webhook URL",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "https://webexapis.com/v1/webhooks/incoming/...",
+				PropertyName: "url",
+				Required:     true,
+				Secure:       true,
+			},
+			{
+				Label:        "Message Content",
+				Description:  "Additional markdown appended to the alert message, e.g. mentions or a runbook link",
+				Element:      alerting.ElementTypeTextArea,
+				PropertyName: "content",
+			},
+		},
+	})
+}
+
+// NewWebexNotifier is the constructor for the Webex notifier.
+func NewWebexNotifier(model *models.AlertNotification, fn alerting.GetDecryptedValueFn, ns notifications.Service) (alerting.Notifier, error) {
+	url := fn(context.Background(), model.SecureSettings, "url", model.Settings.Get("url").MustString(), setting.SecretKey)
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find webhook url property in settings"}
+	}
+
+	return &WebexNotifier{
+		NotifierBase: NewNotifierBase(model, ns),
+		URL:          url,
+		Content:      model.Settings.Get("content").MustString(),
+		log:          log.New("alerting.notifier.webex"),
+	}, nil
+}
+
+// WebexNotifier is responsible for sending
+// alert notifications to Cisco Webex.
+type WebexNotifier struct {
+	NotifierBase
+	URL     string
+	Content string
+	log     log.Logger
+}
+
+// Notify sends an alert notification to Webex.
+func (wn *WebexNotifier) Notify(evalContext *alerting.EvalContext) error {
+	wn.log.Info("Executing webex notification", "ruleId", evalContext.Rule.ID, "notification", wn.Name)
+
+	ruleURL, err := evalContext.GetRuleURL()
+	if err != nil {
+		wn.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("markdown", wn.buildMessage(evalContext, ruleURL))
+
+	// Webex renders a single publicly reachable file URL as an inline attachment.
+	if wn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		bodyJSON.Set("files", []string{evalContext.ImagePublicURL})
+	}
+
+	body, err := bodyJSON.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        wn.URL,
+		Body:       string(body),
+		HttpMethod: "POST",
+	}
+
+	if err := wn.NotificationService.SendWebhookSync(evalContext.Ctx, cmd); err != nil {
+		wn.log.Error("Failed to send notification to Webex", "error", err, "webhook", wn.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (wn *WebexNotifier) buildMessage(evalContext *alerting.EvalContext, ruleURL string) string {
+	// The names of the rules and of the metrics, such as node_cpu_seconds_total, are escaped so
+	// that they are not rendered as markdown. The message and the content are markdown.
+	var head strings.Builder
+	fmt.Fprintf(&head, "**[%s] %s**\n\n", evalContext.GetStateModel().Text, escapeWebexMarkdown(evalContext.Rule.Name))
+
+	if evalContext.Rule.Message != "" {
+		fmt.Fprintf(&head, "%s\n\n", evalContext.Rule.Message)
+	}
+
+	if evalContext.Error != nil {
+		fmt.Fprintf(&head, "Error: %s\n\n", escapeWebexMarkdown(evalContext.Error.Error()))
+	}
+
+	lines := make([]string, 0, len(evalContext.EvalMatches))
+	for _, evt := range evalContext.EvalMatches {
+		lines = append(lines, fmt.Sprintf("- %s: %s\n", escapeWebexMarkdown(evt.Metric), evt.Value))
+	}
+
+	var tail strings.Builder
+	if wn.Content != "" {
+		fmt.Fprintf(&tail, "%s\n\n", wn.Content)
+	}
+
+	if ruleURL != "" {
+		fmt.Fprintf(&tail, "[View in Grafana](%s)\n", ruleURL)
+	}
+
+	if wn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		fmt.Fprintf(&tail, "[Panel image](%s)\n", evalContext.ImagePublicURL)
+	}
+
+	// message returns the message with the first n matches, and the number of the others.
+	message := func(n int) string {
+		var b strings.Builder
+		b.WriteString(head.String())
+		if len(lines) > 0 {
+			b.WriteString(triggMetrString)
+			for _, line := range lines[:n] {
+				b.WriteString(line)
+			}
+			if n < len(lines) {
+				fmt.Fprintf(&b, "- +%d more alerts\n", len(lines)-n)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(tail.String())
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	// Webex rejects the messages over the limit, so the matches that do not fit are counted
+	// instead of listed.
+	n := len(lines)
+	msg := message(n)
+	for excess := len(msg) - webexMaxMessageLength; excess > 0 && n > 0; n-- {
+		excess -= len(lines[n-1])
+	}
+	msg = message(n)
+	for len(msg) > webexMaxMessageLength && n > 0 {
+		n--
+		msg = message(n)
+	}
+	if n < len(lines) {
+		wn.log.Debug("Webex message too long, omitting matches", "notification", wn.Name, "omitted", len(lines)-n)
+	}
+	return truncateWebexMessage(msg)
+}
+
+// webexMarkdownEscaper escapes the characters of the inline formatting of markdown, like the
+// escapeMarkdown template function of the contact points.
+var webexMarkdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`~`, `\~`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`|`, `\|`,
+)
+
+// escapeWebexMarkdown escapes the markdown formatting of the text, so that it is shown as is.
+func escapeWebexMarkdown(s string) string {
+	return webexMarkdownEscaper.Replace(s)
+}
+
+// truncateWebexMessage truncates the message to the limit of Webex, without splitting a character,
+// when its other parts are too long, such as a long rule message.
+func truncateWebexMessage(msg string) string {
+	if len(msg) <= webexMaxMessageLength {
+		return msg
+	}
+	const ellipsis = "…"
+	i := webexMaxMessageLength - len(ellipsis)
+	for i > 0 && !utf8.RuneStart(msg[i]) {
+		i--
+	}
+	return msg[:i] + ellipsis
+}
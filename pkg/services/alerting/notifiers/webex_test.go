@@ -0,0 +1,237 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	encryptionservice "github.com/grafana/grafana/pkg/services/encryption/service"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/validations"
+	"github.com/grafana/grafana/pkg/setting"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebexNotifier(t *testing.T) {
+	encryptionService := encryptionservice.SetupTestService(t)
+
+	t.Run("Parsing alert notification from settings", func(t *testing.T) {
+		t.Run("empty settings should return error", func(t *testing.T) {
+			json := `{ }`
+
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			_, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, nil)
+			require.Error(t, err)
+		})
+
+		t.Run("from settings", func(t *testing.T) {
+			json := `
+				{
+					"url": "https://webexapis.com/v1/webhooks/incoming/abcd",
+					"content": "<@all>"
+				}`
+
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, nil)
+			webexNotifier := not.(*WebexNotifier)
+
+			require.Nil(t, err)
+			require.Equal(t, "webex_testing", webexNotifier.Name)
+			require.Equal(t, "webex", webexNotifier.Type)
+			require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/abcd", webexNotifier.URL)
+			require.Equal(t, "<@all>", webexNotifier.Content)
+		})
+	})
+
+	t.Run("Notify", func(t *testing.T) {
+		origAppURL := setting.AppUrl
+		t.Cleanup(func() { setting.AppUrl = origAppURL })
+		setting.AppUrl = "http://localhost:3000/"
+
+		newEvalContext := func() *alerting.EvalContext {
+			evalContext := alerting.NewEvalContext(context.Background(), &alerting.Rule{
+				ID:      1,
+				Name:    "someRule",
+				Message: "someMessage",
+				State:   models.AlertStateAlerting,
+			}, &validations.OSSPluginRequestValidator{}, nil, nil, nil)
+			evalContext.IsTestRun = true
+			evalContext.EvalMatches = []*alerting.EvalMatch{
+				{Metric: "High value", Value: null.FloatFrom(100)},
+			}
+			return evalContext
+		}
+
+		t.Run("should include rule URL and image URL", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			evalContext := newEvalContext()
+			evalContext.ImagePublicURL = "https://www.grafana.com/static/assets/img/blog/mixed_styles.png"
+
+			require.NoError(t, not.Notify(evalContext))
+			require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/abcd", notificationService.Webhook.Url)
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			require.Equal(t, "**[Alerting] someRule**\n\nsomeMessage\n\nTriggered metrics:\n\n- High value: 100.000\n\n"+
+				"[View in Grafana](http://localhost:3000/)\n"+
+				"[Panel image](https://www.grafana.com/static/assets/img/blog/mixed_styles.png)", body.Get("markdown").MustString())
+			require.Equal(t, []string{"https://www.grafana.com/static/assets/img/blog/mixed_styles.png"}, body.Get("files").MustStringArray())
+		})
+
+		t.Run("should build message without content or image", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "uploadImage": false}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			evalContext := newEvalContext()
+			evalContext.ImagePublicURL = "https://www.grafana.com/static/assets/img/blog/mixed_styles.png"
+
+			require.NoError(t, not.Notify(evalContext))
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			require.Equal(t, "**[Alerting] someRule**\n\nsomeMessage\n\nTriggered metrics:\n\n- High value: 100.000\n\n"+
+				"[View in Grafana](http://localhost:3000/)", body.Get("markdown").MustString())
+			_, hasFiles := body.CheckGet("files")
+			require.False(t, hasFiles)
+		})
+
+		t.Run("should append content", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "content": "<@all>"}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			require.NoError(t, not.Notify(newEvalContext()))
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			require.Equal(t, "**[Alerting] someRule**\n\nsomeMessage\n\nTriggered metrics:\n\n- High value: 100.000\n\n"+
+				"<@all>\n\n[View in Grafana](http://localhost:3000/)", body.Get("markdown").MustString())
+		})
+
+		t.Run("should escape the names of the rule and the metrics", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "uploadImage": false}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			evalContext := newEvalContext()
+			evalContext.Rule.Name = "cpu_usage [prod]"
+			evalContext.Rule.Message = "See the *runbook*"
+			evalContext.EvalMatches = []*alerting.EvalMatch{{Metric: "node_cpu_seconds_total", Value: null.FloatFrom(100)}}
+			require.NoError(t, not.Notify(evalContext))
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			require.Equal(t, "**[Alerting] cpu\\_usage \\[prod\\]**\n\nSee the *runbook*\n\nTriggered metrics:\n\n- node\\_cpu\\_seconds\\_total: 100.000\n\n"+
+				"[View in Grafana](http://localhost:3000/)", body.Get("markdown").MustString())
+		})
+
+		t.Run("should count the matches over the length limit", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "content": "<@all>"}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			evalContext := newEvalContext()
+			evalContext.EvalMatches = nil
+			for i := 0; i < 500; i++ {
+				evalContext.EvalMatches = append(evalContext.EvalMatches, &alerting.EvalMatch{Metric: fmt.Sprintf("instance-%03d", i), Value: null.FloatFrom(100)})
+			}
+			require.NoError(t, not.Notify(evalContext))
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			markdown := body.Get("markdown").MustString()
+			require.LessOrEqual(t, len(markdown), webexMaxMessageLength)
+			require.Contains(t, markdown, "- instance-000: 100.000\n")
+			require.Regexp(t, `- instance-\d+: 100.000\n- \+\d+ more alerts\n\n<@all>\n\n\[View in Grafana\]\(http://localhost:3000/\)$`, markdown)
+			require.NotContains(t, markdown, "instance-499")
+		})
+
+		t.Run("should truncate a message over the length limit", func(t *testing.T) {
+			json := `{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}`
+			settingsJSON, _ := simplejson.NewJson([]byte(json))
+			model := &models.AlertNotification{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			notificationService := notifications.MockNotificationService()
+			not, err := NewWebexNotifier(model, encryptionService.GetDecryptedValue, notificationService)
+			require.NoError(t, err)
+
+			evalContext := newEvalContext()
+			evalContext.Rule.Message = strings.Repeat("é", webexMaxMessageLength)
+			require.NoError(t, not.Notify(evalContext))
+
+			body, err := simplejson.NewJson([]byte(notificationService.Webhook.Body))
+			require.NoError(t, err)
+			markdown := body.Get("markdown").MustString()
+			require.LessOrEqual(t, len(markdown), webexMaxMessageLength)
+			require.True(t, utf8.ValidString(markdown))
+			require.True(t, strings.HasSuffix(markdown, "é…"))
+		})
+	})
+}
@@ -123,7 +123,7 @@ func (s *AlertNotificationService) GetAlertNotificationsWithUidToSend(ctx contex
 	return s.SQLStore.GetAlertNotificationsWithUidToSend(ctx, query)
 }
 
-func (s *AlertNotificationService) createNotifier(ctx context.Context, model *models.AlertNotification, secureSettings map[string]string) (Notifier, error) {
+func (s *AlertNotificationService) createNotifier(ctx context.Context, model *models.AlertNotification, secureSettings map[string]string, ns notifications.Service) (Notifier, error) {
 	secureSettingsMap := map[string]string{}
 
 	if model.Id > 0 {
@@ -158,7 +158,7 @@ func (s *AlertNotificationService) createNotifier(ctx context.Context, model *mo
 		return nil, err
 	}
 
-	notifier, err := InitNotifier(model, s.EncryptionService.GetDecryptedValue, s.NotificationService)
+	notifier, err := InitNotifier(model, s.EncryptionService.GetDecryptedValue, ns)
 	if err != nil {
 		logger.Error("Failed to create notifier", "error", err.Error())
 		return nil, err
@@ -168,6 +168,6 @@ func (s *AlertNotificationService) createNotifier(ctx context.Context, model *mo
 }
 
 func (s *AlertNotificationService) validateAlertNotification(ctx context.Context, model *models.AlertNotification, secureSettings map[string]string) error {
-	_, err := s.createNotifier(ctx, model, secureSettings)
+	_, err := s.createNotifier(ctx, model, secureSettings, s.NotificationService)
 	return err
 }
@@ -2,6 +2,7 @@ package alerting
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -10,6 +11,10 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // NotificationTestCommand initiates an test
@@ -22,10 +27,23 @@ type NotificationTestCommand struct {
 	Type           string
 	Settings       *simplejson.Json
 	SecureSettings map[string]string
+
+	// EvalContext optionally replaces the synthetic evaluation context of the test,
+	// e.g. with one created by AlertEngine.NotificationTestEvalContext.
+	EvalContext *EvalContext
+
+	// DryRun renders the notification without sending it. The requests that the notifier
+	// would have sent are returned in Result.
+	DryRun bool
+	Result []DryRunRequest
 }
 
 var (
 	logger = log.New("alerting.testnotification")
+
+	// ErrNotificationTestAccessDenied is returned when the user cannot view the dashboard
+	// of the panel a test notification is evaluated for.
+	ErrNotificationTestAccessDenied = errors.New("access denied to dashboard")
 )
 
 func (s *AlertNotificationService) HandleNotificationTestCommand(ctx context.Context, cmd *NotificationTestCommand) error {
@@ -39,12 +57,92 @@ func (s *AlertNotificationService) HandleNotificationTestCommand(ctx context.Con
 		Settings: cmd.Settings,
 	}
 
-	notifier, err := s.createNotifier(ctx, &model, cmd.SecureSettings)
+	var ns notifications.Service = s.NotificationService
+	dryRun := &dryRunNotificationService{}
+	if cmd.DryRun {
+		ns = dryRun
+	}
+	notifier, err := s.createNotifier(ctx, &model, cmd.SecureSettings, ns)
 	if err != nil {
 		return err
 	}
 
-	return notificationSvc.sendNotifications(createTestEvalContext(cmd), notifierStateSlice{{notifier: notifier}})
+	evalContext := cmd.EvalContext
+	if evalContext == nil {
+		evalContext = createTestEvalContext(cmd)
+	}
+
+	if err := notificationSvc.sendNotifications(evalContext, notifierStateSlice{{notifier: notifier}}); err != nil {
+		return err
+	}
+	if cmd.DryRun {
+		cmd.Result = dryRun.Requests()
+	}
+	return nil
+}
+
+// NotificationTestEvalContext evaluates the alert of a dashboard panel and returns an
+// evaluation context that can be used to send a realistic test notification. If
+// renderImage is true the panel is rendered and uploaded like for real notifications.
+func (e *AlertEngine) NotificationTestEvalContext(ctx context.Context, orgID int64, dashboardUID string, panelID int64,
+	renderImage bool, user *user.SignedInUser) (*EvalContext, error) {
+	query := &models.GetDashboardQuery{Uid: dashboardUID, OrgId: orgID}
+	if err := e.dashboardService.GetDashboard(ctx, query); err != nil {
+		return nil, err
+	}
+	dash := query.Result
+
+	canView, err := guardian.New(ctx, dash.Id, orgID, user).CanView()
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrNotificationTestAccessDenied
+	}
+
+	alerts, err := e.dashAlertExtractor.GetAlerts(ctx, DashAlertInfo{
+		User:  user,
+		Dash:  dash,
+		OrgID: orgID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, alert := range alerts {
+		if alert.PanelId != panelID {
+			continue
+		}
+		rule, err := NewRuleFromDBAlert(ctx, e.AlertStore, alert, true)
+		if err != nil {
+			return nil, err
+		}
+
+		evalContext := NewEvalContext(ctx, rule, e.RequestValidator, e.AlertStore, e.dashboardService, e.datasourceService)
+		evalContext.IsTestRun = true
+		evalContext.dashboardRef = &models.DashboardRef{Uid: dash.Uid, Slug: dash.Slug}
+
+		e.evalHandler.Eval(evalContext)
+
+		// Test notifications are always sent as firing so that the channel formatting can be
+		// verified, but they carry the series of the real evaluation.
+		evalContext.Rule.State = models.AlertStateAlerting
+		evalContext.Firing = true
+		if len(evalContext.EvalMatches) == 0 {
+			evalContext.EvalMatches = evalContext.AllMatches
+		}
+
+		if renderImage {
+			n := newNotificationService(e.RenderService, e.AlertStore, nil, nil)
+			if err := n.renderAndUploadImage(evalContext, setting.AlertingNotificationTimeout/2); err != nil {
+				logger.Error("Failed to render and upload panel image for test notification", "dashboardUID", dashboardUID, "panelId", panelID, "error", err)
+			}
+		}
+
+		return evalContext, nil
+	}
+
+	return nil, fmt.Errorf("could not find alert with panel ID %d", panelID)
 }
 
 func createTestEvalContext(cmd *NotificationTestCommand) *EvalContext {
@@ -0,0 +1,139 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	encryptionprovider "github.com/grafana/grafana/pkg/services/encryption/provider"
+	encryptionservice "github.com/grafana/grafana/pkg/services/encryption/service"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeDashAlertExtractor struct {
+	alerts []*models.Alert
+}
+
+func (e *fakeDashAlertExtractor) GetAlerts(_ context.Context, _ DashAlertInfo) ([]*models.Alert, error) {
+	return e.alerts, nil
+}
+
+func (e *fakeDashAlertExtractor) ValidateAlerts(_ context.Context, _ DashAlertInfo) error {
+	return nil
+}
+
+type fakeMatchesEvalHandler struct {
+	matches []*EvalMatch
+}
+
+func (h *fakeMatchesEvalHandler) Eval(evalContext *EvalContext) {
+	evalContext.AllMatches = h.matches
+}
+
+func TestNotificationTestEvalContext(t *testing.T) {
+	RegisterCondition("test", func(model *simplejson.Json, index int) (Condition, error) {
+		return &FakeCondition{}, nil
+	})
+
+	dashboardService := &dashboards.FakeDashboardService{}
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*models.GetDashboardQuery")).Run(func(args mock.Arguments) {
+		q := args.Get(1).(*models.GetDashboardQuery)
+		q.Result = &models.Dashboard{Id: 1, Uid: q.Uid, Slug: "my-dashboard", OrgId: q.OrgId}
+	}).Return(nil)
+
+	settings, err := simplejson.NewJson([]byte(`{"conditions": [{"type": "test"}]}`))
+	require.NoError(t, err)
+
+	origNewGuardian := guardian.New
+	t.Cleanup(func() {
+		guardian.New = origNewGuardian
+	})
+	guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+
+	matches := []*EvalMatch{{Metric: "cpu", Value: null.FloatFrom(42)}}
+	engine := &AlertEngine{
+		AlertStore:       &AlertStoreMock{},
+		dashboardService: dashboardService,
+		dashAlertExtractor: &fakeDashAlertExtractor{alerts: []*models.Alert{
+			{Id: 1, OrgId: 1, DashboardId: 1, PanelId: 2, Name: "High CPU", Message: "CPU is high", State: models.AlertStateOK, Settings: settings},
+		}},
+		evalHandler: &fakeMatchesEvalHandler{matches: matches},
+	}
+
+	t.Run("should evaluate the panel alert", func(t *testing.T) {
+		evalContext, err := engine.NotificationTestEvalContext(context.Background(), 1, "dash-uid", 2, false, nil)
+		require.NoError(t, err)
+
+		require.True(t, evalContext.IsTestRun)
+		require.True(t, evalContext.Firing)
+		require.Equal(t, "High CPU", evalContext.Rule.Name)
+		require.Equal(t, models.AlertStateAlerting, evalContext.Rule.State)
+		require.Equal(t, matches, evalContext.EvalMatches)
+		require.Empty(t, evalContext.ImagePublicURL)
+
+		ref, err := evalContext.GetDashboardUID()
+		require.NoError(t, err)
+		require.Equal(t, &models.DashboardRef{Uid: "dash-uid", Slug: "my-dashboard"}, ref)
+	})
+
+	t.Run("should return error for unknown panel", func(t *testing.T) {
+		_, err := engine.NotificationTestEvalContext(context.Background(), 1, "dash-uid", 3, false, nil)
+		require.EqualError(t, err, "could not find alert with panel ID 3")
+	})
+
+	t.Run("should return error if the user cannot view the dashboard", func(t *testing.T) {
+		fakeGuardian := &guardian.FakeDashboardGuardian{CanViewValue: false}
+		guardian.MockDashboardGuardian(fakeGuardian)
+		t.Cleanup(func() {
+			guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+		})
+
+		_, err := engine.NotificationTestEvalContext(context.Background(), 1, "dash-uid", 2, false, nil)
+		require.ErrorIs(t, err, ErrNotificationTestAccessDenied)
+		require.Equal(t, int64(1), fakeGuardian.DashId)
+	})
+}
+
+// webhookTestNotifier sends the name of the rule to a webhook.
+type webhookTestNotifier struct {
+	testNotifier
+	url string
+	ns  notifications.Service
+}
+
+func (n *webhookTestNotifier) Notify(evalCtx *EvalContext) error {
+	return n.ns.SendWebhookSync(evalCtx.Ctx, &models.SendWebhookSync{Url: n.url, Body: evalCtx.Rule.Name})
+}
+
+func TestHandleNotificationTestCommand_DryRun(t *testing.T) {
+	RegisterNotifier(&NotifierPlugin{
+		Type: "webhook_test",
+		Factory: func(model *models.AlertNotification, _ GetDecryptedValueFn, ns notifications.Service) (Notifier, error) {
+			return &webhookTestNotifier{url: model.Settings.Get("url").MustString(), ns: ns}, nil
+		},
+	})
+
+	encService, err := encryptionservice.ProvideEncryptionService(encryptionprovider.ProvideEncryptionProvider(),
+		&usagestats.UsageStatsMock{T: t}, &setting.OSSImpl{Cfg: setting.NewCfg()})
+	require.NoError(t, err)
+	// The notification service is not set, so the test fails if the notification is sent.
+	s := &AlertNotificationService{EncryptionService: encService}
+
+	cmd := &NotificationTestCommand{
+		OrgID:    1,
+		Type:     "webhook_test",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "https://hooks.example.com/services/secret?token=secret"}),
+		DryRun:   true,
+	}
+	require.NoError(t, s.HandleNotificationTestCommand(context.Background(), cmd))
+	require.Equal(t, []DryRunRequest{{Target: "https://hooks.example.com", Body: "Test notification"}}, cmd.Result)
+}
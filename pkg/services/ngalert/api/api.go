@@ -39,6 +39,7 @@ type Alertmanager interface {
 	SaveAndApplyConfig(ctx context.Context, config *apimodels.PostableUserConfig) error
 	SaveAndApplyDefaultConfig(ctx context.Context) error
 	GetStatus() apimodels.GettableStatus
+	GetReceiverProfiles(limit int) apimodels.ReceiverProfiles
 
 	// Silences
 	CreateSilence(ps *apimodels.PostableSilence) (string, error)
@@ -52,6 +53,10 @@ type Alertmanager interface {
 
 	// Testing
 	TestReceivers(ctx context.Context, c apimodels.TestReceiversConfigBodyParams) (*notifier.TestReceiversResult, error)
+
+	// Dead letters
+	GetDeadLetters(ctx context.Context, receiver string, limit int) (apimodels.GettableNotificationDeadLetters, error)
+	ReplayDeadLetter(ctx context.Context, id int64) error
 }
 
 type AlertingStore interface {
@@ -73,6 +78,9 @@ type API struct {
 	InstanceStore        store.InstanceStore
 	AlertingStore        AlertingStore
 	AdminConfigStore     store.AdminConfigurationStore
+	AMCredentialsStore   store.ExternalAlertmanagerCredentialsStore
+	NotificationHistory  store.NotificationHistoryStore
+	NotificationAudit    store.NotificationAuditStore
 	DataProxy            *datasourceproxy.DataSourceProxyService
 	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 	StateManager         *state.Manager
@@ -81,9 +89,11 @@ type API struct {
 	Policies             *provisioning.NotificationPolicyService
 	ContactPointService  *provisioning.ContactPointService
 	Templates            *provisioning.TemplateService
+	TemplatePacks        *provisioning.TemplatePackService
 	MuteTimings          *provisioning.MuteTimingService
 	AlertRules           *provisioning.AlertRuleService
 	AlertsRouter         *sender.AlertsRouter
+	ReceiverOwners       *notifier.ReceiverOwnerStore
 }
 
 // RegisterAPIEndpoints registers API handlers
@@ -98,7 +108,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 	api.RegisterAlertmanagerApiEndpoints(NewForkingAM(
 		api.DatasourceCache,
 		NewLotexAM(proxy, logger),
-		&AlertmanagerSrv{crypto: api.MultiOrgAlertmanager.Crypto, log: logger, ac: api.AccessControl, mam: api.MultiOrgAlertmanager},
+		&AlertmanagerSrv{crypto: api.MultiOrgAlertmanager.Crypto, log: logger, ac: api.AccessControl, mam: api.MultiOrgAlertmanager, receiverOwners: api.ReceiverOwners, ruleStore: api.RuleStore},
 	), m)
 	// Register endpoints for proxying to Prometheus-compatible backends.
 	api.RegisterPrometheusApiEndpoints(NewForkingProm(
@@ -134,6 +144,12 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		&ConfigSrv{
 			datasourceService:    api.DatasourceService,
 			store:                api.AdminConfigStore,
+			credentialsStore:     api.AMCredentialsStore,
+			historyStore:         api.NotificationHistory,
+			historyRetention:     api.Cfg.UnifiedAlerting.NotificationHistoryRetention,
+			auditStore:           api.NotificationAudit,
+			auditRetention:       api.Cfg.UnifiedAlerting.NotificationAuditRetention,
+			secretsService:       api.SecretsService,
 			log:                  logger,
 			alertmanagerProvider: api.AlertsRouter,
 		},
@@ -144,7 +160,10 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		policies:            api.Policies,
 		contactPointService: api.ContactPointService,
 		templates:           api.Templates,
+		templatePacks:       api.TemplatePacks,
 		muteTimings:         api.MuteTimings,
 		alertRules:          api.AlertRules,
 	}), m)
+
+	api.RegisterEmailTrackingEndpoints(EmailTrackingSrv{tracker: api.MultiOrgAlertmanager, log: logger})
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
@@ -23,13 +24,17 @@ import (
 const (
 	defaultTestReceiversTimeout = 15 * time.Second
 	maxTestReceiversTimeout     = 30 * time.Second
+
+	defaultReceiverProfilesLimit = 10
 )
 
 type AlertmanagerSrv struct {
-	log    log.Logger
-	ac     accesscontrol.AccessControl
-	mam    *notifier.MultiOrgAlertmanager
-	crypto notifier.Crypto
+	log            log.Logger
+	ac             accesscontrol.AccessControl
+	mam            *notifier.MultiOrgAlertmanager
+	crypto         notifier.Crypto
+	receiverOwners *notifier.ReceiverOwnerStore
+	ruleStore      store.RuleStore
 }
 
 type UnknownReceiverError struct {
@@ -49,6 +54,74 @@ func (srv AlertmanagerSrv) RouteGetAMStatus(c *models.ReqContext) response.Respo
 	return response.JSON(http.StatusOK, am.GetStatus())
 }
 
+func (srv AlertmanagerSrv) RouteGetReceiverProfiles(c *models.ReqContext) response.Response {
+	limit := c.QueryInt("limit")
+	if limit < 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("limit must be a positive number"), "")
+	}
+	if limit == 0 {
+		limit = defaultReceiverProfilesLimit
+	}
+
+	am, errResp := srv.AlertmanagerFor(c.OrgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	return response.JSON(http.StatusOK, am.GetReceiverProfiles(limit))
+}
+
+func (srv AlertmanagerSrv) RouteGetDeadLetters(c *models.ReqContext) response.Response {
+	limit := c.QueryInt("limit")
+	if limit < 0 || limit > maxNotificationDeliveriesLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("limit must be a number between 1 and %d", maxNotificationDeliveriesLimit), "")
+	}
+	if limit == 0 {
+		limit = defaultNotificationDeliveriesLimit
+	}
+
+	am, errResp := srv.AlertmanagerFor(c.OrgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	letters, err := am.GetDeadLetters(c.Req.Context(), c.Query("receiver"), limit)
+	if err != nil {
+		if errors.Is(err, notifier.ErrNotificationDeadLettersDisabled) {
+			return ErrResp(http.StatusNotFound, err, "see notification_dead_letter_retention in the unified_alerting section of the configuration")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the dead letters")
+	}
+	return response.JSON(http.StatusOK, letters)
+}
+
+func (srv AlertmanagerSrv) RoutePostDeadLetterReplay(c *models.ReqContext, id string) response.Response {
+	letterID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid dead letter ID")
+	}
+
+	am, errResp := srv.AlertmanagerFor(c.OrgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	if err := am.ReplayDeadLetter(c.Req.Context(), letterID); err != nil {
+		switch {
+		case errors.Is(err, notifier.ErrNotificationDeadLettersDisabled):
+			return ErrResp(http.StatusNotFound, err, "see notification_dead_letter_retention in the unified_alerting section of the configuration")
+		case errors.Is(err, ngmodels.ErrNotificationDeadLetterNotFound):
+			return ErrResp(http.StatusNotFound, err, "")
+		case errors.Is(err, notifier.ErrDeadLetterIntegrationNotFound):
+			return ErrResp(http.StatusConflict, err, "")
+		case errors.Is(err, notifier.ErrDeadLetterReplayFailed):
+			return ErrResp(http.StatusBadGateway, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to replay the dead letter")
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"message": "notification delivered"})
+}
+
 func (srv AlertmanagerSrv) RouteCreateSilence(c *models.ReqContext, postableSilence apimodels.PostableSilence) response.Response {
 	err := postableSilence.Validate(strfmt.Default)
 	if err != nil {
@@ -128,6 +201,54 @@ func (srv AlertmanagerSrv) RouteGetAlertingConfig(c *models.ReqContext) response
 	return response.JSON(http.StatusOK, config)
 }
 
+func (srv AlertmanagerSrv) RouteGetPolicyLint(c *models.ReqContext) response.Response {
+	config, err := srv.mam.GetAlertmanagerConfiguration(c.Req.Context(), c.OrgID)
+	if err != nil {
+		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, err.Error())
+	}
+	receivers := make(map[string]int, len(config.AlertmanagerConfig.Receivers))
+	for _, r := range config.AlertmanagerConfig.Receivers {
+		receivers[r.Name] = len(r.GrafanaManagedReceivers)
+	}
+	return srv.lintPolicies(c, config.AlertmanagerConfig.Route, receivers)
+}
+
+func (srv AlertmanagerSrv) RoutePostPolicyLint(c *models.ReqContext, body apimodels.PostableUserConfig) response.Response {
+	if body.AlertmanagerConfig.Route == nil {
+		return ErrResp(http.StatusBadRequest, errors.New("the configuration has no root route"), "")
+	}
+	receivers := make(map[string]int, len(body.AlertmanagerConfig.Receivers))
+	for _, r := range body.AlertmanagerConfig.Receivers {
+		receivers[r.Name] = len(r.GrafanaManagedReceivers)
+	}
+	return srv.lintPolicies(c, body.AlertmanagerConfig.Route, receivers)
+}
+
+// lintPolicies lints the policy tree and the contact points against the alert rules of the org.
+func (srv AlertmanagerSrv) lintPolicies(c *models.ReqContext, route *apimodels.Route, receivers map[string]int) response.Response {
+	q := ngmodels.ListAlertRulesQuery{OrgID: c.OrgID}
+	if err := srv.ruleStore.ListAlertRules(c.Req.Context(), &q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
+	}
+	folders, err := srv.ruleStore.GetUserVisibleNamespaces(c.Req.Context(), c.OrgID, c.SignedInUser)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get namespaces")
+	}
+	folderTitles := make(map[string]string, len(folders))
+	for uid, folder := range folders {
+		folderTitles[uid] = folder.Title
+	}
+
+	warnings, err := notifier.LintPolicies(route, receivers, q.Result, folderTitles)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	return response.JSON(http.StatusOK, apimodels.PolicyLintResult{Warnings: warnings})
+}
+
 func (srv AlertmanagerSrv) RouteGetAMAlertGroups(c *models.ReqContext) response.Response {
 	am, errResp := srv.AlertmanagerFor(c.OrgID)
 	if errResp != nil {
@@ -222,8 +343,29 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 			return ErrResp(http.StatusBadRequest, err, "")
 		}
 	}
+	// Users that can only manage the notifications of their teams are restricted to the contact
+	// points and notification policies owned by these teams.
+	var changes *teamChanges
+	if srv.isTeamScoped(c) {
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get current configuration")
+		}
+		changes, err = srv.teamGuard(c, currentConfig, body)
+		if err != nil {
+			if errors.Is(err, ErrAuthorization) {
+				return ErrResp(http.StatusUnauthorized, err, "")
+			}
+			return ErrResp(http.StatusInternalServerError, err, "")
+		}
+	}
 	err = srv.mam.ApplyAlertmanagerConfiguration(c.Req.Context(), c.OrgID, body)
 	if err == nil {
+		// The integrations are given their UID when the configuration is applied.
+		if changes != nil {
+			if err := changes.apply(c.Req.Context(), srv, c.OrgID, body); err != nil {
+				return ErrResp(http.StatusInternalServerError, err, "failed to update the owners of contact points")
+			}
+		}
 		return response.JSON(http.StatusAccepted, util.DynMap{"message": "configuration created"})
 	}
 	var unknownReceiverError notifier.UnknownReceiverError
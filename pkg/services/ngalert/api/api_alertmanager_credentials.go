@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (srv ConfigSrv) RouteGetAlertmanagerCredentialsList(c *models.ReqContext) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+
+	list, err := srv.credentialsStore.ListExternalAlertmanagerCredentials(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to fetch the external Alertmanager credentials")
+	}
+	resp := make(apimodels.GettableAlertmanagerCredentialsList, 0, len(list))
+	for _, creds := range list {
+		resp = append(resp, gettableAlertmanagerCredentials(creds))
+	}
+	return response.JSON(http.StatusOK, resp)
+}
+
+func (srv ConfigSrv) RouteGetAlertmanagerCredentials(c *models.ReqContext, uid string) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+
+	creds, err := srv.credentialsStore.GetExternalAlertmanagerCredentials(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return credentialsErrResp(err)
+	}
+	return response.JSON(http.StatusOK, gettableAlertmanagerCredentials(creds))
+}
+
+func (srv ConfigSrv) RoutePostAlertmanagerCredentials(c *models.ReqContext, body apimodels.PostableAlertmanagerCredentials) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+	if err := validateAlertmanagerCredentials(body.Name, body.SecureSettings); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid external Alertmanager credentials")
+	}
+
+	secureSettings, err := srv.encryptSecureSettings(c.Req.Context(), c.OrgID, body.SecureSettings)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to encrypt the secure settings")
+	}
+	creds := &ngmodels.ExternalAlertmanagerCredentials{
+		OrgID:          c.OrgID,
+		Name:           body.Name,
+		BasicAuthUser:  body.BasicAuthUser,
+		TLSServerName:  body.TLSServerName,
+		TLSSkipVerify:  body.TLSSkipVerify,
+		SecureSettings: secureSettings,
+	}
+	if err := srv.credentialsStore.SaveExternalAlertmanagerCredentials(c.Req.Context(), creds); err != nil {
+		return credentialsErrResp(err)
+	}
+	return response.JSON(http.StatusCreated, gettableAlertmanagerCredentials(creds))
+}
+
+func (srv ConfigSrv) RoutePutAlertmanagerCredentials(c *models.ReqContext, body apimodels.PostableAlertmanagerCredentials, uid string) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+	if err := validateAlertmanagerCredentials(body.Name, body.SecureSettings); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid external Alertmanager credentials")
+	}
+
+	creds, err := srv.credentialsStore.GetExternalAlertmanagerCredentials(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return credentialsErrResp(err)
+	}
+	secureSettings, err := srv.encryptSecureSettings(c.Req.Context(), c.OrgID, body.SecureSettings)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to encrypt the secure settings")
+	}
+	// The secrets that are not sent are kept.
+	if creds.SecureSettings == nil {
+		creds.SecureSettings = make(map[string][]byte, len(secureSettings))
+	}
+	for k, v := range secureSettings {
+		creds.SecureSettings[k] = v
+	}
+	creds.Name = body.Name
+	creds.BasicAuthUser = body.BasicAuthUser
+	creds.TLSServerName = body.TLSServerName
+	creds.TLSSkipVerify = body.TLSSkipVerify
+	if err := srv.credentialsStore.SaveExternalAlertmanagerCredentials(c.Req.Context(), creds); err != nil {
+		return credentialsErrResp(err)
+	}
+	return response.JSON(http.StatusOK, gettableAlertmanagerCredentials(creds))
+}
+
+func (srv ConfigSrv) RouteDeleteAlertmanagerCredentials(c *models.ReqContext, uid string) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+
+	names, err := srv.alertmanagersWithCredentials(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	if len(names) > 0 {
+		return ErrResp(http.StatusConflict, fmt.Errorf("the credentials are used by the data sources %v", names), "")
+	}
+	if err := srv.credentialsStore.DeleteExternalAlertmanagerCredentials(c.Req.Context(), c.OrgID, uid); err != nil {
+		return credentialsErrResp(err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"message": "external Alertmanager credentials deleted"})
+}
+
+func (srv ConfigSrv) RoutePostRotateAlertmanagerCredentials(c *models.ReqContext, body apimodels.RotateAlertmanagerCredentials, uid string) response.Response {
+	if c.OrgRole != org.RoleAdmin {
+		return accessForbiddenResp()
+	}
+	if err := ngmodels.ValidateSecureSettings(body.SecureSettings); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid external Alertmanager credentials")
+	}
+
+	creds, err := srv.credentialsStore.GetExternalAlertmanagerCredentials(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return credentialsErrResp(err)
+	}
+	secureSettings, err := srv.encryptSecureSettings(c.Req.Context(), c.OrgID, body.SecureSettings)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to encrypt the secure settings")
+	}
+	creds.SecureSettings = secureSettings
+	creds.Version++
+	creds.RotatedAt = time.Now().Unix()
+	if err := srv.credentialsStore.SaveExternalAlertmanagerCredentials(c.Req.Context(), creds); err != nil {
+		return credentialsErrResp(err)
+	}
+	return response.JSON(http.StatusOK, gettableAlertmanagerCredentials(creds))
+}
+
+// encryptSecureSettings encrypts the secrets with a key of the organization. It must not be
+// called in a database transaction.
+func (srv ConfigSrv) encryptSecureSettings(ctx context.Context, orgID int64, secureSettings map[string]string) (map[string][]byte, error) {
+	return srv.secretsService.EncryptJsonData(ctx, secureSettings, secrets.WithScope(fmt.Sprintf("org:%d", orgID)))
+}
+
+// alertmanagersWithCredentials returns the names of the Alertmanager data sources that
+// reference the credentials.
+func (srv ConfigSrv) alertmanagersWithCredentials(ctx context.Context, orgID int64, uid string) ([]string, error) {
+	query := &datasources.GetDataSourcesByTypeQuery{
+		OrgId: orgID,
+		Type:  datasources.DS_ALERTMANAGER,
+	}
+	if err := srv.datasourceService.GetDataSourcesByType(ctx, query); err != nil {
+		return nil, fmt.Errorf("failed to fetch datasources for org: %w", err)
+	}
+	var names []string
+	for _, ds := range query.Result {
+		if ds.JsonData != nil && ds.JsonData.Get(apimodels.AlertmanagerCredentialsUID).MustString() == uid {
+			names = append(names, ds.Name)
+		}
+	}
+	return names, nil
+}
+
+func validateAlertmanagerCredentials(name string, secureSettings map[string]string) error {
+	if name == "" {
+		return errors.New("the name is required")
+	}
+	return ngmodels.ValidateSecureSettings(secureSettings)
+}
+
+func credentialsErrResp(err error) response.Response {
+	switch {
+	case errors.Is(err, ngmodels.ErrExternalAlertmanagerCredentialsNotFound):
+		return ErrResp(http.StatusNotFound, err, "")
+	case errors.Is(err, ngmodels.ErrExternalAlertmanagerCredentialsConflict):
+		return ErrResp(http.StatusConflict, err, "")
+	}
+	return ErrResp(http.StatusInternalServerError, err, "failed to save the external Alertmanager credentials")
+}
+
+func gettableAlertmanagerCredentials(creds *ngmodels.ExternalAlertmanagerCredentials) apimodels.GettableAlertmanagerCredentials {
+	resp := apimodels.GettableAlertmanagerCredentials{
+		UID:           creds.UID,
+		Name:          creds.Name,
+		BasicAuthUser: creds.BasicAuthUser,
+		TLSServerName: creds.TLSServerName,
+		TLSSkipVerify: creds.TLSSkipVerify,
+		SecureFields:  creds.SecureFields(),
+		Version:       creds.Version,
+	}
+	if creds.RotatedAt > 0 {
+		rotatedAt := time.Unix(creds.RotatedAt, 0).UTC()
+		resp.RotatedAt = &rotatedAt
+	}
+	return resp
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestAlertmanagerCredentials(t *testing.T) {
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	credsStore := store.NewFakeExternalAlertmanagerCredentialsStore(t)
+	dsService := &fakeDatasources.FakeDataSourceService{}
+	sut := ConfigSrv{
+		datasourceService: dsService,
+		credentialsStore:  credsStore,
+		secretsService:    secretsService,
+	}
+
+	ctx := createRequestCtxInOrg(1)
+	ctx.OrgRole = org.RoleAdmin
+
+	decrypt := func(t *testing.T, uid string) map[string]string {
+		t.Helper()
+		creds, err := credsStore.GetExternalAlertmanagerCredentials(context.Background(), 1, uid)
+		require.NoError(t, err)
+		secrets, err := secretsService.DecryptJsonData(context.Background(), creds.SecureSettings)
+		require.NoError(t, err)
+		return secrets
+	}
+
+	var created definitions.GettableAlertmanagerCredentials
+	resp := sut.RoutePostAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{
+		Name:          "mimir",
+		BasicAuthUser: "grafana",
+		SecureSettings: map[string]string{
+			ngmodels.SecureBasicAuthPassword:              "secret",
+			ngmodels.SecureHeaderPrefix + "X-Scope-OrgID": "tenant",
+		},
+	})
+	require.Equal(t, http.StatusCreated, resp.Status())
+	require.NoError(t, json.Unmarshal(resp.Body(), &created))
+	require.NotEmpty(t, created.UID)
+	require.NotContains(t, string(resp.Body()), "secret")
+	require.Equal(t, map[string]bool{ngmodels.SecureBasicAuthPassword: true, ngmodels.SecureHeaderPrefix + "X-Scope-OrgID": true}, created.SecureFields)
+
+	t.Run("the secrets are encrypted", func(t *testing.T) {
+		creds, err := credsStore.GetExternalAlertmanagerCredentials(context.Background(), 1, created.UID)
+		require.NoError(t, err)
+		require.NotEqual(t, []byte("secret"), creds.SecureSettings[ngmodels.SecureBasicAuthPassword])
+		require.Equal(t, "secret", decrypt(t, created.UID)[ngmodels.SecureBasicAuthPassword])
+	})
+
+	t.Run("invalid credentials are rejected", func(t *testing.T) {
+		resp := sut.RoutePostAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{Name: "other", SecureSettings: map[string]string{"password": "secret"}})
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+		resp = sut.RoutePostAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{})
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+		resp = sut.RoutePostAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{Name: "mimir"})
+		require.Equal(t, http.StatusConflict, resp.Status())
+	})
+
+	t.Run("the secrets that are not sent are kept on update", func(t *testing.T) {
+		resp := sut.RoutePutAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{
+			Name:           "mimir",
+			BasicAuthUser:  "admin",
+			SecureSettings: map[string]string{ngmodels.SecureBasicAuthPassword: "other"},
+		}, created.UID)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.Equal(t, map[string]string{
+			ngmodels.SecureBasicAuthPassword:              "other",
+			ngmodels.SecureHeaderPrefix + "X-Scope-OrgID": "tenant",
+		}, decrypt(t, created.UID))
+
+		resp = sut.RoutePutAlertmanagerCredentials(ctx, definitions.PostableAlertmanagerCredentials{Name: "mimir"}, "unknown")
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+
+	t.Run("rotation replaces all the secrets", func(t *testing.T) {
+		var rotated definitions.GettableAlertmanagerCredentials
+		resp := sut.RoutePostRotateAlertmanagerCredentials(ctx, definitions.RotateAlertmanagerCredentials{
+			SecureSettings: map[string]string{ngmodels.SecureBasicAuthPassword: "rotated"},
+		}, created.UID)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.NoError(t, json.Unmarshal(resp.Body(), &rotated))
+		require.Equal(t, int64(1), rotated.Version)
+		require.NotNil(t, rotated.RotatedAt)
+		require.Equal(t, map[string]string{ngmodels.SecureBasicAuthPassword: "rotated"}, decrypt(t, created.UID))
+	})
+
+	t.Run("credentials of other organizations are not visible", func(t *testing.T) {
+		other := createRequestCtxInOrg(2)
+		other.OrgRole = org.RoleAdmin
+		require.Equal(t, http.StatusNotFound, sut.RouteGetAlertmanagerCredentials(other, created.UID).Status())
+
+		var list definitions.GettableAlertmanagerCredentialsList
+		resp := sut.RouteGetAlertmanagerCredentialsList(other)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.NoError(t, json.Unmarshal(resp.Body(), &list))
+		require.Empty(t, list)
+	})
+
+	t.Run("only admins manage the credentials", func(t *testing.T) {
+		editor := createRequestCtxInOrg(1)
+		editor.OrgRole = org.RoleEditor
+		require.Equal(t, http.StatusForbidden, sut.RouteGetAlertmanagerCredentialsList(editor).Status())
+		require.Equal(t, http.StatusForbidden, sut.RouteDeleteAlertmanagerCredentials(editor, created.UID).Status())
+	})
+
+	t.Run("credentials used by a data source are not deleted", func(t *testing.T) {
+		dsService.DataSources = []*datasources.DataSource{{
+			OrgId:    1,
+			Name:     "Mimir Alertmanager",
+			Type:     datasources.DS_ALERTMANAGER,
+			JsonData: simplejson.NewFromAny(map[string]interface{}{definitions.AlertmanagerCredentialsUID: created.UID}),
+		}}
+		require.Equal(t, http.StatusConflict, sut.RouteDeleteAlertmanagerCredentials(ctx, created.UID).Status())
+
+		dsService.DataSources = nil
+		require.Equal(t, http.StatusOK, sut.RouteDeleteAlertmanagerCredentials(ctx, created.UID).Status())
+		require.Equal(t, http.StatusNotFound, sut.RouteGetAlertmanagerCredentials(ctx, created.UID).Status())
+	})
+}
@@ -81,43 +81,55 @@ func checkContactPoints(currReceivers []*apimodels.GettableApiReceiver, newRecei
 			if !present {
 				return fmt.Errorf("cannot delete provisioned contact point '%s'", contactPoint.Name)
 			}
-			editErr := fmt.Errorf("cannot save provisioned contact point '%s'", contactPoint.Name)
-			if contactPoint.DisableResolveMessage != postedContactPoint.DisableResolveMessage {
-				return editErr
-			}
-			if contactPoint.Name != postedContactPoint.Name {
-				return editErr
-			}
-			if contactPoint.Type != postedContactPoint.Type {
-				return editErr
-			}
-			for key := range contactPoint.SecureFields {
-				if value, present := postedContactPoint.SecureSettings[key]; present && value != "" {
-					return editErr
-				}
-			}
-			existingSettings, err := contactPoint.Settings.Map()
-			if err != nil {
-				return err
-			}
-			newSettings, err := postedContactPoint.Settings.Map()
+			changed, err := contactPointChanged(contactPoint, postedContactPoint)
 			if err != nil {
 				return err
 			}
-			for key, val := range existingSettings {
-				if newVal, present := newSettings[key]; present {
-					if val != newVal {
-						return editErr
-					}
-				} else {
-					return editErr
-				}
+			if changed {
+				return fmt.Errorf("cannot save provisioned contact point '%s'", contactPoint.Name)
 			}
 		}
 	}
 	return nil
 }
 
+// contactPointChanged returns whether the posted contact point changes the existing one. Secure
+// settings are changed if they are posted with a value, otherwise the existing value is kept.
+func contactPointChanged(contactPoint *apimodels.GettableGrafanaReceiver, postedContactPoint *apimodels.PostableGrafanaReceiver) (bool, error) {
+	if contactPoint.DisableResolveMessage != postedContactPoint.DisableResolveMessage {
+		return true, nil
+	}
+	if contactPoint.Name != postedContactPoint.Name {
+		return true, nil
+	}
+	if contactPoint.Type != postedContactPoint.Type {
+		return true, nil
+	}
+	for key := range contactPoint.SecureFields {
+		if value, present := postedContactPoint.SecureSettings[key]; present && value != "" {
+			return true, nil
+		}
+	}
+	existingSettings, err := contactPoint.Settings.Map()
+	if err != nil {
+		return false, err
+	}
+	newSettings, err := postedContactPoint.Settings.Map()
+	if err != nil {
+		return false, err
+	}
+	for key, val := range existingSettings {
+		if newVal, present := newSettings[key]; present {
+			if val != newVal {
+				return true, nil
+			}
+		} else {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func checkMuteTimes(currentConfig apimodels.GettableUserConfig, newConfig apimodels.PostableUserConfig) error {
 	newMTs := make(map[string]amConfig.MuteTimeInterval)
 	for _, newMuteTime := range newConfig.AlertmanagerConfig.MuteTimeIntervals {
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// teamChanges are the contact points whose owner changes when a configuration posted by a
+// team-scoped user is applied.
+type teamChanges struct {
+	teamID  int64
+	created []string
+	deleted []string
+	// owned are the owning teams of the contact points of the team once the configuration is
+	// applied, by name.
+	owned map[string]int64
+}
+
+// isTeamScoped returns whether the user is only allowed to manage the notifications of some teams.
+// Without access control, users that can change the configuration can change all of it.
+func (srv AlertmanagerSrv) isTeamScoped(c *models.ReqContext) bool {
+	if srv.ac.IsDisabled() {
+		return false
+	}
+	return !accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqOrgAdminOrEditor, accesscontrol.EvalPermission(accesscontrol.ActionAlertingNotificationsWrite))
+}
+
+// teamGuard checks that a user that is only allowed to manage the notifications of some teams
+// changes nothing but the contact points owned by these teams and the notification policies
+// that only use them. A contact point is owned by a team if all its integrations are. The
+// top-level policies whose receivers are all owned by the teams are considered owned by the
+// teams, and the default policy cannot be changed.
+func (srv AlertmanagerSrv) teamGuard(c *models.ReqContext, currentConfig apimodels.GettableUserConfig, newConfig apimodels.PostableUserConfig) (*teamChanges, error) {
+	owners, err := srv.receiverOwners.GetOwners(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	canManage := func(teamID int64) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqOrgAdmin, accesscontrol.EvalPermission(
+			accesscontrol.ActionAlertingNotificationsTeamWrite,
+			accesscontrol.Scope("teams", "id", strconv.FormatInt(teamID, 10)),
+		))
+	}
+	changes := &teamChanges{owned: map[string]int64{}}
+	currentReceivers := make(map[string]*apimodels.GettableApiReceiver, len(currentConfig.AlertmanagerConfig.Receivers))
+	for _, r := range currentConfig.AlertmanagerConfig.Receivers {
+		currentReceivers[r.Name] = r
+		if teamID, ok := receiverOwner(r, owners); ok && canManage(teamID) {
+			changes.owned[r.Name] = teamID
+		}
+	}
+	owned := func(receiver string) bool {
+		_, ok := changes.owned[receiver]
+		return ok
+	}
+	newReceivers := make(map[string]*apimodels.PostableApiReceiver, len(newConfig.AlertmanagerConfig.Receivers))
+	for _, r := range newConfig.AlertmanagerConfig.Receivers {
+		newReceivers[r.Name] = r
+	}
+
+	for name, current := range currentReceivers {
+		posted, ok := newReceivers[name]
+		if owned(name) {
+			if !ok {
+				changes.deleted = append(changes.deleted, name)
+			}
+			continue
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w to delete contact point '%s' because it is not owned by the team", ErrAuthorization, name)
+		}
+		changed, err := receiverChanged(current, posted)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			return nil, fmt.Errorf("%w to change contact point '%s' because it is not owned by the team", ErrAuthorization, name)
+		}
+	}
+	for name := range newReceivers {
+		if _, ok := currentReceivers[name]; !ok {
+			changes.created = append(changes.created, name)
+		}
+	}
+
+	if len(changes.created) > 0 {
+		changes.teamID, err = srv.ownerTeam(c, canManage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Contact points created in this request are owned by the team as well.
+	teamReceivers := make(map[string]bool, len(currentReceivers)+len(changes.created))
+	for name := range currentReceivers {
+		teamReceivers[name] = owned(name)
+	}
+	for _, name := range changes.created {
+		teamReceivers[name] = true
+		changes.owned[name] = changes.teamID
+	}
+	for _, name := range changes.deleted {
+		delete(changes.owned, name)
+	}
+
+	// The integrations of the team cannot take the UID of integrations of other contact points,
+	// as they would then be owned by the team.
+	currentIntegrations := map[string]string{}
+	for _, r := range currentConfig.AlertmanagerConfig.Receivers {
+		for _, integration := range r.GrafanaManagedReceivers {
+			currentIntegrations[integration.UID] = r.Name
+		}
+	}
+	for name := range changes.owned {
+		for _, integration := range newReceivers[name].GrafanaManagedReceivers {
+			if current, ok := currentIntegrations[integration.UID]; ok && !teamReceivers[current] {
+				return nil, fmt.Errorf("%w to use the integration '%s' of contact point '%s' because it is not owned by the team", ErrAuthorization, integration.UID, current)
+			}
+		}
+	}
+
+	if err := checkTeamRoutes(currentConfig.AlertmanagerConfig.Route, newConfig.AlertmanagerConfig.Route, teamReceivers); err != nil {
+		return nil, err
+	}
+
+	options := []cmp.Option{cmpopts.EquateEmpty(), cmpopts.IgnoreUnexported(labels.Matcher{})}
+	if !cmp.Equal(currentConfig.TemplateFiles, newConfig.TemplateFiles, options...) {
+		return nil, fmt.Errorf("%w to change templates", ErrAuthorization)
+	}
+	if !cmp.Equal(currentConfig.AlertmanagerConfig.MuteTimeIntervals, newConfig.AlertmanagerConfig.MuteTimeIntervals, options...) {
+		return nil, fmt.Errorf("%w to change mute timings", ErrAuthorization)
+	}
+	if !cmp.Equal(currentConfig.AlertmanagerConfig.InhibitRules, newConfig.AlertmanagerConfig.InhibitRules, options...) {
+		return nil, fmt.Errorf("%w to change inhibition rules", ErrAuthorization)
+	}
+
+	return changes, nil
+}
+
+// ownerTeam returns the team that owns the contact points created by the user. It is set
+// with the teamId query parameter and can be omitted if the user can manage a single team.
+func (srv AlertmanagerSrv) ownerTeam(c *models.ReqContext, canManage func(teamID int64) bool) (int64, error) {
+	if teamID := c.QueryInt64("teamId"); teamID > 0 {
+		if !canManage(teamID) {
+			return 0, fmt.Errorf("%w to create contact points for team %d", ErrAuthorization, teamID)
+		}
+		return teamID, nil
+	}
+
+	var teamIDs []int64
+	for _, scope := range c.SignedInUser.Permissions[c.OrgID][accesscontrol.ActionAlertingNotificationsTeamWrite] {
+		if teamID, err := strconv.ParseInt(strings.TrimPrefix(scope, "teams:id:"), 10, 64); err == nil {
+			teamIDs = append(teamIDs, teamID)
+		}
+	}
+	if len(teamIDs) != 1 {
+		return 0, fmt.Errorf("%w to create contact points without the teamId query parameter", ErrAuthorization)
+	}
+	return teamIDs[0], nil
+}
+
+// checkTeamRoutes checks that only the top-level policies that use team receivers change. The
+// policies of the team before the policies of other teams keep their position and the alerts they
+// match, so that they cannot catch the alerts of the other teams. The new policies and the ones
+// whose matchers change are added after all the other policies, and must match a subset of the
+// alerts so that they do not catch all the alerts of the default policy.
+func checkTeamRoutes(currentRoute, newRoute *apimodels.Route, teamReceivers map[string]bool) error {
+	if currentRoute == nil || newRoute == nil {
+		if currentRoute != newRoute {
+			return fmt.Errorf("%w to change the default policy", ErrAuthorization)
+		}
+		return nil
+	}
+
+	options := []cmp.Option{cmpopts.EquateEmpty(), cmpopts.IgnoreUnexported(labels.Matcher{}), cmpopts.IgnoreFields(apimodels.Route{}, "Provenance")}
+	currentRoot, newRoot := *currentRoute, *newRoute
+	currentRoot.Routes, newRoot.Routes = nil, nil
+	if !cmp.Equal(currentRoot, newRoot, options...) {
+		return fmt.Errorf("%w to change the default policy", ErrAuthorization)
+	}
+
+	// A policy without receiver uses the one of the default policy, so it is not owned by the team.
+	isTeamRoute := func(r *apimodels.Route) bool {
+		if r.Receiver == "" {
+			return false
+		}
+		for _, receiver := range routeReceivers(r) {
+			if !teamReceivers[receiver] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// The relative order of the policies that are not owned by the team must be kept. The team
+	// policies are placed in the slots between them, the slot being the number of other policies
+	// before the team policy.
+	var currentOther, newOther, currentTeam []*apimodels.Route
+	currentSlots := map[int]bool{}
+	for _, r := range currentRoute.Routes {
+		if isTeamRoute(r) {
+			currentSlots[len(currentOther)] = true
+			currentTeam = append(currentTeam, r)
+		} else {
+			currentOther = append(currentOther, r)
+		}
+	}
+	var newSlots []int
+	var newTeam []*apimodels.Route
+	for _, r := range newRoute.Routes {
+		if isTeamRoute(r) {
+			newSlots = append(newSlots, len(newOther))
+			newTeam = append(newTeam, r)
+		} else {
+			newOther = append(newOther, r)
+		}
+	}
+	if !cmp.Equal(currentOther, newOther, options...) {
+		return fmt.Errorf("%w to change notification policies that use contact points not owned by the team", ErrAuthorization)
+	}
+
+	for i, r := range newTeam {
+		if slot := newSlots[i]; slot != len(newOther) {
+			// A policy before the policies of other teams could match their alerts, even with a
+			// matcher of the team, as the labels of the alerts are set by their rules.
+			if !currentSlots[slot] || !matchesSameAlerts(currentTeam, r, options) {
+				return fmt.Errorf("%w to add or change the matchers of notification policies of the team before the policies of other teams", ErrAuthorization)
+			}
+			continue
+		}
+		unchanged := false
+		for _, current := range currentTeam {
+			if cmp.Equal(current, r, options...) {
+				unchanged = true
+				break
+			}
+		}
+		if !unchanged && isCatchAllRoute(r) {
+			return fmt.Errorf("%w to add notification policies of the team that match all the alerts", ErrAuthorization)
+		}
+	}
+	return nil
+}
+
+// matchesSameAlerts returns whether one of the routes has the same matchers and continue option as
+// r, that is whether r does not catch more alerts than it.
+func matchesSameAlerts(routes []*apimodels.Route, r *apimodels.Route, options []cmp.Option) bool {
+	matching := func(r *apimodels.Route) apimodels.Route {
+		return apimodels.Route{Match: r.Match, MatchRE: r.MatchRE, Matchers: r.Matchers, ObjectMatchers: r.ObjectMatchers, Continue: r.Continue}
+	}
+	for _, current := range routes {
+		if cmp.Equal(matching(current), matching(r), options...) {
+			return true
+		}
+	}
+	return false
+}
+
+// catchAllProbe is a label value that the matchers of a policy are not expected to match, unless
+// they match any value.
+const catchAllProbe = "\x00catch-all-probe\x00"
+
+// isCatchAllRoute returns whether the route has no matcher that restricts the alerts it matches.
+// The matchers that match alerts without the label, such as team!="a", or with any value of the
+// label, such as team=~".+", do not restrict them.
+func isCatchAllRoute(r *apimodels.Route) bool {
+	restricts := func(matches func(string) bool) bool {
+		return !matches("") && !matches(catchAllProbe)
+	}
+	for _, v := range r.Match {
+		if restricts(func(s string) bool { return s == v }) {
+			return false
+		}
+	}
+	for _, re := range r.MatchRE {
+		if re.Regexp != nil && restricts(re.MatchString) {
+			return false
+		}
+	}
+	for _, m := range append(labels.Matchers(r.Matchers), r.ObjectMatchers...) {
+		if restricts(m.Matches) {
+			return false
+		}
+	}
+	return true
+}
+
+// receiverOwner returns the team that owns all the integrations of the contact point, if any.
+func receiverOwner(r *apimodels.GettableApiReceiver, owners map[string]int64) (int64, bool) {
+	if len(r.GrafanaManagedReceivers) == 0 {
+		return 0, false
+	}
+	teamID, ok := owners[r.GrafanaManagedReceivers[0].UID]
+	if !ok {
+		return 0, false
+	}
+	for _, integration := range r.GrafanaManagedReceivers[1:] {
+		if owner, ok := owners[integration.UID]; !ok || owner != teamID {
+			return 0, false
+		}
+	}
+	return teamID, true
+}
+
+// routeReceivers returns the receivers used by the route and all its nested routes.
+func routeReceivers(r *apimodels.Route) []string {
+	var receivers []string
+	if r.Receiver != "" {
+		receivers = append(receivers, r.Receiver)
+	}
+	for _, child := range r.Routes {
+		receivers = append(receivers, routeReceivers(child)...)
+	}
+	return receivers
+}
+
+// receiverChanged returns whether any of the integrations of the receiver is changed, added or removed.
+func receiverChanged(current *apimodels.GettableApiReceiver, posted *apimodels.PostableApiReceiver) (bool, error) {
+	if len(current.GrafanaManagedReceivers) != len(posted.GrafanaManagedReceivers) {
+		return true, nil
+	}
+	postedByUID := make(map[string]*apimodels.PostableGrafanaReceiver, len(posted.GrafanaManagedReceivers))
+	for _, cp := range posted.GrafanaManagedReceivers {
+		postedByUID[cp.UID] = cp
+	}
+	for _, cp := range current.GrafanaManagedReceivers {
+		postedCP, ok := postedByUID[cp.UID]
+		if !ok {
+			return true, nil
+		}
+		changed, err := contactPointChanged(cp, postedCP)
+		if err != nil || changed {
+			return changed, err
+		}
+	}
+	return false, nil
+}
+
+// apply records the owner of the integrations of the contact points of the team in the applied
+// configuration, the integrations created in the request having their UID by then.
+func (changes *teamChanges) apply(ctx context.Context, srv AlertmanagerSrv, orgID int64, applied apimodels.PostableUserConfig) error {
+	for _, r := range applied.AlertmanagerConfig.Receivers {
+		teamID, ok := changes.owned[r.Name]
+		if !ok {
+			continue
+		}
+		for _, integration := range r.GrafanaManagedReceivers {
+			if err := srv.receiverOwners.SetOwner(ctx, orgID, integration.UID, teamID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
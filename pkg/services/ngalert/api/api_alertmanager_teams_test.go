@@ -0,0 +1,540 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const teamsCurrentConfig = `{
+	"template_files": {"a": "template"},
+	"alertmanager_config": {
+		"route": {
+			"receiver": "default",
+			"routes": [
+				{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+				{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+			]
+		},
+		"receivers": [
+			{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+			{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+			{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+		]
+	}
+}`
+
+func TestTeamGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		newConfig  string
+		query      string
+		expCreated []string
+		expDeleted []string
+		expTeamID  int64
+		expError   string
+	}{
+		{
+			name:      "unchanged configuration",
+			newConfig: teamsCurrentConfig,
+		},
+		{
+			name: "change a team contact point and policy",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+							{"receiver": "team-a", "object_matchers": [["team", "=~", "a|c"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "oncall-a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+		},
+		{
+			name: "create and delete team contact points",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a-slack", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a-slack", "grafana_managed_receiver_configs": [{"uid": "4", "name": "team-a-slack", "type": "slack", "settings": {"recipient": "#team-a"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expCreated: []string{"team-a-slack"},
+			expDeleted: []string{"team-a"},
+			expTeamID:  1,
+		},
+		{
+			name: "create contact points for a team that cannot be managed",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]},
+						{"name": "team-b", "grafana_managed_receiver_configs": [{"uid": "4", "name": "team-b", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			query:    "teamId=2",
+			expError: "user is not authorized to create contact points for team 2",
+		},
+		{
+			name: "change a contact point of another team",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "a@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to change contact point 'other' because it is not owned by the team",
+		},
+		{
+			name: "delete a contact point of another team",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to delete contact point 'other' because it is not owned by the team",
+		},
+		{
+			name: "change a policy of another team",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "c"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to change notification policies that use contact points not owned by the team",
+		},
+		{
+			name: "team inserts a catch-all route first",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "continue": false},
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to add or change the matchers of notification policies of the team before the policies of other teams",
+		},
+		{
+			name: "team adds a catch-all route last",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+							{"receiver": "team-a", "object_matchers": [["team", "!=", "b"], ["severity", "=~", ".+"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to add notification policies of the team that match all the alerts",
+		},
+		{
+			name: "team adds a route last",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+							{"receiver": "team-a", "object_matchers": [["service", "=~", "a-.*"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+		},
+		{
+			name: "team adds a receiverless route",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"object_matchers": [["team", "=", "a"], ["severity", "=", "critical"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to change notification policies that use contact points not owned by the team",
+		},
+		{
+			name: "change the default policy",
+			newConfig: `{
+				"template_files": {"a": "template"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "team-a",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to change the default policy",
+		},
+		{
+			name: "change templates",
+			newConfig: `{
+				"template_files": {"a": "changed"},
+				"alertmanager_config": {
+					"route": {
+						"receiver": "default",
+						"routes": [
+							{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+							{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+						]
+					},
+					"receivers": [
+						{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+						{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+						{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+					]
+				}
+			}`,
+			expError: "user is not authorized to change templates",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owners := notifier.NewReceiverOwnerStore(notifier.NewFakeKVStore(t))
+			// The owners are kept by integration UID.
+			require.NoError(t, owners.SetOwner(context.Background(), 1, "2", 1))
+			require.NoError(t, owners.SetOwner(context.Background(), 1, "3", 2))
+			srv := AlertmanagerSrv{ac: acMock.New(), receiverOwners: owners}
+
+			var currentConfig apimodels.GettableUserConfig
+			require.NoError(t, json.Unmarshal([]byte(teamsCurrentConfig), &currentConfig))
+			var newConfig apimodels.PostableUserConfig
+			require.NoError(t, newConfig.UnmarshalJSON([]byte(test.newConfig)))
+
+			changes, err := srv.teamGuard(createTeamRequestCtx(test.query, 1), currentConfig, newConfig)
+			if test.expError != "" {
+				require.ErrorIs(t, err, ErrAuthorization)
+				require.EqualError(t, err, test.expError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expCreated, changes.created)
+			require.Equal(t, test.expDeleted, changes.deleted)
+			require.Equal(t, test.expTeamID, changes.teamID)
+		})
+	}
+}
+
+func TestTeamGuard_RecreatedReceiver(t *testing.T) {
+	ctx := context.Background()
+	owners := notifier.NewReceiverOwnerStore(notifier.NewFakeKVStore(t))
+	require.NoError(t, owners.SetOwner(ctx, 1, "2", 1))
+	srv := AlertmanagerSrv{ac: acMock.New(), receiverOwners: owners}
+
+	// An admin deleted the contact point of the team, and then created one with the same name.
+	recreated := `{
+		"template_files": {"a": "template"},
+		"alertmanager_config": {
+			"route": {
+				"receiver": "default",
+				"routes": [
+					{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+					{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+				]
+			},
+			"receivers": [
+				{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+				{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "4", "name": "team-a", "type": "email", "settings": {"addresses": "admin@example.com"}}]},
+				{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+			]
+		}
+	}`
+	var currentConfig apimodels.GettableUserConfig
+	require.NoError(t, json.Unmarshal([]byte(recreated), &currentConfig))
+	changed := strings.Replace(recreated, "admin@example.com", "a@example.com", 1)
+	var newConfig apimodels.PostableUserConfig
+	require.NoError(t, newConfig.UnmarshalJSON([]byte(changed)))
+
+	for _, name := range []string{"the owner of the deleted contact point is kept", "the owner of the deleted contact point is forgotten"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := srv.teamGuard(createTeamRequestCtx("", 1), currentConfig, newConfig)
+			require.EqualError(t, err, "user is not authorized to change contact point 'team-a' because it is not owned by the team")
+		})
+		// The Alertmanager forgets the owners of the integrations that are not in the applied configuration.
+		require.NoError(t, owners.DeleteOwnersExcept(ctx, 1, map[string]struct{}{"1": {}, "3": {}, "4": {}}))
+	}
+
+	t.Run("a new integration cannot take the UID of an integration of another contact point", func(t *testing.T) {
+		stolen := strings.Replace(recreated, `"receivers": [`, `"receivers": [
+				{"name": "team-a-slack", "grafana_managed_receiver_configs": [{"uid": "4", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},`, 1)
+		var newConfig apimodels.PostableUserConfig
+		require.NoError(t, newConfig.UnmarshalJSON([]byte(stolen)))
+		_, err := srv.teamGuard(createTeamRequestCtx("", 1), currentConfig, newConfig)
+		require.EqualError(t, err, "user is not authorized to use the integration '4' of contact point 'team-a' because it is not owned by the team")
+	})
+}
+
+func TestTeamChangesApply(t *testing.T) {
+	ctx := context.Background()
+	owners := notifier.NewReceiverOwnerStore(notifier.NewFakeKVStore(t))
+	require.NoError(t, owners.SetOwner(ctx, 1, "2", 1))
+	srv := AlertmanagerSrv{ac: acMock.New(), receiverOwners: owners}
+
+	var currentConfig apimodels.GettableUserConfig
+	require.NoError(t, json.Unmarshal([]byte(teamsCurrentConfig), &currentConfig))
+	// The contact point of the team is renamed, and a new one is created.
+	var newConfig apimodels.PostableUserConfig
+	require.NoError(t, newConfig.UnmarshalJSON([]byte(`{
+		"template_files": {"a": "template"},
+		"alertmanager_config": {
+			"route": {
+				"receiver": "default",
+				"routes": [
+					{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+					{"receiver": "team-a-email", "object_matchers": [["team", "=", "a"]]},
+					{"receiver": "team-a-slack", "object_matchers": [["team", "=", "a"]]}
+				]
+			},
+			"receivers": [
+				{"name": "default", "grafana_managed_receiver_configs": [{"uid": "1", "name": "default", "type": "email", "settings": {"addresses": "default@example.com"}}]},
+				{"name": "team-a-email", "grafana_managed_receiver_configs": [{"uid": "2", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]},
+				{"name": "team-a-slack", "grafana_managed_receiver_configs": [{"name": "team-a-slack", "type": "slack", "settings": {"recipient": "#team-a"}}]},
+				{"name": "other", "grafana_managed_receiver_configs": [{"uid": "3", "name": "other", "type": "email", "settings": {"addresses": "b@example.com"}}]}
+			]
+		}
+	}`)))
+
+	changes, err := srv.teamGuard(createTeamRequestCtx("", 1), currentConfig, newConfig)
+	require.NoError(t, err)
+	// The UIDs of the new integrations are set when the configuration is applied.
+	newConfig.AlertmanagerConfig.Receivers[2].GrafanaManagedReceivers[0].UID = "5"
+	require.NoError(t, changes.apply(ctx, srv, 1, newConfig))
+
+	got, err := owners.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"2": 1, "5": 1}, got)
+}
+
+func TestCheckTeamRoutes(t *testing.T) {
+	route := func(t *testing.T, routes string) *apimodels.Route {
+		var r apimodels.Route
+		require.NoError(t, json.Unmarshal([]byte(`{"receiver": "default", "routes": `+routes+`}`), &r))
+		return &r
+	}
+	teamReceivers := map[string]bool{"team-a": true}
+	current := route(t, `[
+		{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+		{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}
+	]`)
+
+	t.Run("team inserts a route before the policies of other teams", func(t *testing.T) {
+		err := checkTeamRoutes(current, route(t, `[
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"], ["severity", "=", "critical"]]},
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}
+		]`), teamReceivers)
+		require.EqualError(t, err, "user is not authorized to add or change the matchers of notification policies of the team before the policies of other teams")
+	})
+
+	t.Run("team changes a route before the policies of other teams", func(t *testing.T) {
+		current := route(t, `[
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+		]`)
+		// The policy would catch the critical alerts of the other team.
+		err := checkTeamRoutes(current, route(t, `[
+			{"receiver": "team-a", "object_matchers": [["severity", "=", "critical"]], "continue": false},
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+		]`), teamReceivers)
+		require.EqualError(t, err, "user is not authorized to add or change the matchers of notification policies of the team before the policies of other teams")
+
+		// Policies keep their position if they match the same alerts.
+		require.NoError(t, checkTeamRoutes(current, route(t, `[
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]], "group_by": ["alertname"], "routes": [{"receiver": "team-a", "object_matchers": [["severity", "=", "critical"]]}]},
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]}
+		]`), teamReceivers))
+
+		require.NoError(t, checkTeamRoutes(current, route(t, `[
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+			{"receiver": "team-a", "object_matchers": [["severity", "=", "critical"]], "continue": false}
+		]`), teamReceivers))
+	})
+
+	t.Run("team inserts a catch-all route first", func(t *testing.T) {
+		err := checkTeamRoutes(current, route(t, `[
+			{"receiver": "team-a"},
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}
+		]`), teamReceivers)
+		require.ErrorIs(t, err, ErrAuthorization)
+	})
+
+	t.Run("team adds a receiverless route", func(t *testing.T) {
+		err := checkTeamRoutes(current, route(t, `[
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+			{"object_matchers": [["team", "=", "a"]], "routes": [{"receiver": "team-a"}]}
+		]`), teamReceivers)
+		require.EqualError(t, err, "user is not authorized to change notification policies that use contact points not owned by the team")
+	})
+
+	t.Run("team adds routes after its own", func(t *testing.T) {
+		require.NoError(t, checkTeamRoutes(current, route(t, `[
+			{"receiver": "other", "object_matchers": [["team", "=", "b"]]},
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"], ["severity", "=", "critical"]]},
+			{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}
+		]`), teamReceivers))
+	})
+}
+
+func TestOwnerTeam(t *testing.T) {
+	srv := AlertmanagerSrv{ac: acMock.New()}
+
+	t.Run("single team is used without query parameter", func(t *testing.T) {
+		c := createTeamRequestCtx("", 3)
+		teamID, err := srv.ownerTeam(c, func(int64) bool { return true })
+		require.NoError(t, err)
+		require.Equal(t, int64(3), teamID)
+	})
+
+	t.Run("query parameter is required with multiple teams", func(t *testing.T) {
+		c := createTeamRequestCtx("", 3, 4)
+		_, err := srv.ownerTeam(c, func(int64) bool { return true })
+		require.ErrorIs(t, err, ErrAuthorization)
+
+		c = createTeamRequestCtx("teamId=4", 3, 4)
+		teamID, err := srv.ownerTeam(c, func(int64) bool { return true })
+		require.NoError(t, err)
+		require.Equal(t, int64(4), teamID)
+	})
+}
+
+func createTeamRequestCtx(query string, teamIDs ...int64) *models.ReqContext {
+	var scopes []string
+	for _, teamID := range teamIDs {
+		scopes = append(scopes, accesscontrol.Scope("teams", "id", strconv.FormatInt(teamID, 10)))
+	}
+	return &models.ReqContext{
+		Context: &web.Context{
+			Req: &http.Request{URL: &url.URL{RawQuery: query}},
+		},
+		SignedInUser: &user.SignedInUser{
+			OrgID: 1,
+			Permissions: map[int64]map[string][]string{
+				1: {accesscontrol.ActionAlertingNotificationsTeamWrite: scopes},
+			},
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
@@ -290,6 +292,113 @@ func TestAlertmanagerConfig(t *testing.T) {
 	})
 }
 
+func TestRouteGetReceiverProfiles(t *testing.T) {
+	sut := createSut(t, nil)
+
+	t.Run("assert 200 with the profiles of the org", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = &http.Request{URL: &url.URL{RawQuery: "limit=5"}}
+
+		response := sut.RouteGetReceiverProfiles(rc)
+
+		require.Equal(t, 200, response.Status())
+		require.JSONEq(t, "[]", string(response.Body()))
+	})
+
+	t.Run("assert 400 on negative limit", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = &http.Request{URL: &url.URL{RawQuery: "limit=-1"}}
+
+		response := sut.RouteGetReceiverProfiles(rc)
+
+		require.Equal(t, 400, response.Status())
+	})
+
+	t.Run("assert 404 Not Found for nonexistent org", func(t *testing.T) {
+		rc := createRequestCtxInOrg(12)
+		rc.Req = &http.Request{URL: &url.URL{}}
+
+		response := sut.RouteGetReceiverProfiles(rc)
+
+		require.Equal(t, 404, response.Status())
+	})
+}
+
+func TestRouteDeadLetters(t *testing.T) {
+	sut := createSut(t, nil)
+
+	t.Run("assert 404 when the dead letters are disabled", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = &http.Request{URL: &url.URL{}}
+
+		require.Equal(t, 404, sut.RouteGetDeadLetters(rc).Status())
+		require.Equal(t, 404, sut.RoutePostDeadLetterReplay(rc, "1").Status())
+	})
+
+	t.Run("assert 400 on invalid parameters", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = &http.Request{URL: &url.URL{RawQuery: "limit=-1"}}
+
+		require.Equal(t, 400, sut.RouteGetDeadLetters(rc).Status())
+		require.Equal(t, 400, sut.RoutePostDeadLetterReplay(rc, "first").Status())
+	})
+
+	t.Run("assert 404 Not Found for nonexistent org", func(t *testing.T) {
+		rc := createRequestCtxInOrg(12)
+		rc.Req = &http.Request{URL: &url.URL{}}
+
+		require.Equal(t, 404, sut.RouteGetDeadLetters(rc).Status())
+		require.Equal(t, 404, sut.RoutePostDeadLetterReplay(rc, "1").Status())
+	})
+}
+
+func TestRoutePolicyLint(t *testing.T) {
+	sut := createSut(t, nil)
+
+	t.Run("assert 200 with the warnings of the current configuration", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+
+		response := sut.RouteGetPolicyLint(rc)
+
+		require.Equal(t, 200, response.Status())
+		result := apimodels.PolicyLintResult{}
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, apimodels.PolicyLintMissingSeverityRoute, result.Warnings[0].Code)
+	})
+
+	t.Run("assert 200 with the warnings of the posted configuration", func(t *testing.T) {
+		rule := ngmodels.AlertRuleGen(ngmodels.WithOrgID(1))()
+		rule.Title = "HighCPU"
+		rule.Labels = map[string]string{"severity": "critical"}
+		sut.ruleStore.(*store.FakeRuleStore).PutRule(context.Background(), rule)
+
+		request := createAmConfigRequest(t)
+		request.AlertmanagerConfig.Route.Routes = []*apimodels.Route{
+			{Receiver: "grafana-default-email", Match: map[string]string{"alertname": "DatabaseDown"}},
+		}
+		rc := createRequestCtxInOrg(1)
+
+		response := sut.RoutePostPolicyLint(rc, request)
+
+		require.Equal(t, 200, response.Status())
+		result := apimodels.PolicyLintResult{}
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, []apimodels.PolicyLintWarning{
+			{Code: apimodels.PolicyLintUnmatchedRoute, Message: `the matchers {alertname="DatabaseDown"} cannot match the labels of any alert rule`, Path: "route.routes[0]", Receiver: "grafana-default-email"},
+			{Code: apimodels.PolicyLintMissingSeverityRoute, Message: "no notification policy matches on the severity label, alerts of all severities are handled alike"},
+		}, result.Warnings)
+	})
+
+	t.Run("assert 404 Not Found for nonexistent org", func(t *testing.T) {
+		rc := createRequestCtxInOrg(12)
+
+		response := sut.RouteGetPolicyLint(rc)
+
+		require.Equal(t, 404, response.Status())
+	})
+}
+
 func TestSilenceCreate(t *testing.T) {
 	makeSilence := func(comment string, createdBy string,
 		startsAt, endsAt strfmt.DateTime, matchers amv2.Matchers) amv2.Silence {
@@ -491,10 +600,12 @@ func createSut(t *testing.T, accessControl accesscontrol.AccessControl) Alertman
 	}
 	log := log.NewNopLogger()
 	return AlertmanagerSrv{
-		mam:    mam,
-		crypto: mam.Crypto,
-		ac:     accessControl,
-		log:    log,
+		mam:            mam,
+		crypto:         mam.Crypto,
+		ac:             accessControl,
+		log:            log,
+		receiverOwners: notifier.NewReceiverOwnerStore(notifier.NewFakeKVStore(t)),
+		ruleStore:      store.NewFakeRuleStore(t),
 	}
 }
 
@@ -534,7 +645,7 @@ func createMultiOrgAlertmanager(t *testing.T) *notifier.MultiOrgAlertmanager {
 		}, // do not poll in tests.
 	}
 
-	mam, err := notifier.NewMultiOrgAlertmanager(cfg, &configStore, &orgStore, kvStore, provStore, decryptFn, m.GetMultiOrgAlertmanagerMetrics(), nil, log.New("testlogger"), secretsService)
+	mam, err := notifier.NewMultiOrgAlertmanager(cfg, &configStore, &orgStore, kvStore, provStore, decryptFn, m.GetMultiOrgAlertmanagerMetrics(), nil, nil, nil, log.New("testlogger"), secretsService)
 	require.NoError(t, err)
 	err = mam.LoadAndSyncAlertmanagersForOrgs(context.Background())
 	require.NoError(t, err)
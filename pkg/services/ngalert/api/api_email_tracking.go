@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// trackingPixel is a transparent 1x1 GIF image.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// EmailTracker records the opens of the alert emails and the clicks on their links.
+type EmailTracker interface {
+	TrackEmailOpen(orgID int64, dispatchID, sig string) error
+	TrackEmailClick(orgID int64, dispatchID, target, sig string) error
+}
+
+// EmailTrackingSrv serves the tracking pixel and links of the alert emails. The requests come
+// from email clients, so they are not authenticated: the URLs are signed instead.
+type EmailTrackingSrv struct {
+	tracker EmailTracker
+	log     log.Logger
+}
+
+// RegisterEmailTrackingEndpoints registers the endpoints of the tracking pixel and links of the alert emails.
+func (api *API) RegisterEmailTrackingEndpoints(srv EmailTrackingSrv) {
+	api.RouteRegister.Get("/api/alerting/email-tracking/:OrgID/:DispatchID/open.gif", routing.Wrap(srv.RouteGetEmailOpen))
+	api.RouteRegister.Get("/api/alerting/email-tracking/:OrgID/:DispatchID/click", routing.Wrap(srv.RouteGetEmailClick))
+}
+
+// RouteGetEmailOpen records that an alert email was opened. The pixel is returned even if the
+// open is not recorded, so that the email renders the same way.
+func (srv EmailTrackingSrv) RouteGetEmailOpen(c *models.ReqContext) response.Response {
+	orgID, dispatchID := trackingParams(c)
+	if err := srv.tracker.TrackEmailOpen(orgID, dispatchID, c.Query("sig")); err != nil {
+		srv.log.Debug("failed to track email open", "org", orgID, "dispatch", dispatchID, "err", err)
+	}
+	return response.Respond(http.StatusOK, trackingPixel).
+		SetHeader("Content-Type", "image/gif").
+		SetHeader("Cache-Control", "no-store, no-cache, must-revalidate")
+}
+
+// RouteGetEmailClick records that a link of an alert email was clicked and redirects to it.
+// Links that were not signed by Grafana are not followed, so that the endpoint cannot be used
+// to redirect to other sites.
+func (srv EmailTrackingSrv) RouteGetEmailClick(c *models.ReqContext) response.Response {
+	orgID, dispatchID := trackingParams(c)
+	target := c.Query("url")
+	if err := srv.tracker.TrackEmailClick(orgID, dispatchID, target, c.Query("sig")); err != nil {
+		srv.log.Debug("failed to track email click", "org", orgID, "dispatch", dispatchID, "err", err)
+		return ErrResp(http.StatusNotFound, errors.New("invalid tracking link"), "")
+	}
+	return response.Redirect(target)
+}
+
+func trackingParams(c *models.ReqContext) (int64, string) {
+	params := web.Params(c.Req)
+	// An invalid organization ID is 0, which never has an Alertmanager.
+	orgID, _ := strconv.ParseInt(params[":OrgID"], 10, 64)
+	return orgID, params[":DispatchID"]
+}
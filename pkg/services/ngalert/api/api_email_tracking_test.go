@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type fakeEmailTracker struct {
+	opens  []string
+	clicks []string
+}
+
+func (f *fakeEmailTracker) TrackEmailOpen(orgID int64, dispatchID, sig string) error {
+	if orgID != 1 || sig != "valid" {
+		return notifier.ErrInvalidEmailTrackingSignature
+	}
+	f.opens = append(f.opens, dispatchID)
+	return nil
+}
+
+func (f *fakeEmailTracker) TrackEmailClick(orgID int64, dispatchID, target, sig string) error {
+	if orgID != 1 || sig != "valid" {
+		return notifier.ErrInvalidEmailTrackingSignature
+	}
+	f.clicks = append(f.clicks, dispatchID+" "+target)
+	return nil
+}
+
+func trackingRequest(orgID, query string) *models.ReqContext {
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	req = web.SetURLParams(req, map[string]string{":OrgID": orgID, ":DispatchID": "abc"})
+	return &models.ReqContext{Context: &web.Context{Req: req}}
+}
+
+func TestEmailTrackingSrv(t *testing.T) {
+	tracker := &fakeEmailTracker{}
+	srv := EmailTrackingSrv{tracker: tracker, log: log.NewNopLogger()}
+
+	t.Run("the pixel is returned whether the open is recorded or not", func(t *testing.T) {
+		for _, rc := range []*models.ReqContext{
+			trackingRequest("1", "sig=valid"),
+			trackingRequest("1", "sig=forged"),
+			trackingRequest("invalid", "sig=valid"),
+		} {
+			resp := srv.RouteGetEmailOpen(rc)
+			require.Equal(t, http.StatusOK, resp.Status())
+			require.Equal(t, trackingPixel, resp.Body())
+		}
+		require.Equal(t, []string{"abc"}, tracker.opens)
+	})
+
+	t.Run("signed links redirect to their target", func(t *testing.T) {
+		resp := srv.RouteGetEmailClick(trackingRequest("1", "sig=valid&url=http%3A%2F%2Flocalhost%2Falerting%2Flist"))
+		require.Equal(t, http.StatusFound, resp.Status())
+		require.Equal(t, []string{"abc http://localhost/alerting/list"}, tracker.clicks)
+	})
+
+	t.Run("forged links are not followed", func(t *testing.T) {
+		resp := srv.RouteGetEmailClick(trackingRequest("1", "sig=forged&url=https%3A%2F%2Fevil.example.com"))
+		require.Equal(t, http.StatusNotFound, resp.Status())
+		require.Len(t, tracker.clicks, 1)
+	})
+}
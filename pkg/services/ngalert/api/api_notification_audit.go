@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// RouteGetNotificationAudit returns the notifications sent for the organization recorded in the
+// audit log, with their rendered title and the fingerprints of their alerts.
+func (srv ConfigSrv) RouteGetNotificationAudit(c *models.ReqContext) response.Response {
+	if srv.auditStore == nil || srv.auditRetention <= 0 {
+		return ErrResp(http.StatusNotFound, errors.New("the notification audit log is disabled"), "see notification_audit_retention in the unified_alerting section of the configuration")
+	}
+
+	limit := c.QueryInt("limit")
+	if limit < 0 || limit > maxNotificationDeliveriesLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("limit must be a number between 1 and %d", maxNotificationDeliveriesLimit), "")
+	}
+	if limit == 0 {
+		limit = defaultNotificationDeliveriesLimit
+	}
+	fingerprint := c.Query("fingerprint")
+	if fingerprint != "" {
+		fp, err := model.ParseFingerprint(fingerprint)
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "invalid fingerprint")
+		}
+		// The fingerprints are recorded with their leading zeros.
+		fingerprint = fp.String()
+	}
+	now := timeNow()
+	to, err := parseNotificationDeliveriesTime(c.Query("to"), now)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid to")
+	}
+	from, err := parseNotificationDeliveriesTime(c.Query("from"), to.Add(-defaultNotificationDeliveriesRange))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid from")
+	}
+	if !from.Before(to) {
+		return ErrResp(http.StatusBadRequest, errors.New("from must be before to"), "")
+	}
+
+	entries, err := srv.auditStore.GetNotificationAuditEntries(c.Req.Context(), &ngmodels.GetNotificationAuditQuery{
+		OrgID:       c.OrgID,
+		From:        from,
+		To:          to,
+		Receiver:    c.Query("receiver"),
+		Fingerprint: fingerprint,
+		Limit:       limit,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to read the notification audit log")
+	}
+
+	resp := make(apimodels.GettableNotificationAuditEntries, 0, len(entries))
+	for _, e := range entries {
+		fingerprints := []string{}
+		if e.Fingerprints != "" {
+			fingerprints = strings.Split(e.Fingerprints, ",")
+		}
+		resp = append(resp, apimodels.GettableNotificationAuditEntry{
+			Receiver:     e.Receiver,
+			Integration:  e.Integration,
+			Index:        e.IntegrationIndex,
+			SentAt:       time.UnixMilli(e.SentAt).UTC(),
+			Title:        e.Title,
+			Fingerprints: fingerprints,
+			Result:       e.Result,
+			Error:        e.Error,
+		})
+	}
+	return response.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// fakeNotificationAuditStore returns its entries and records the last query.
+type fakeNotificationAuditStore struct {
+	entries []*ngmodels.NotificationAuditEntry
+	query   *ngmodels.GetNotificationAuditQuery
+}
+
+func (f *fakeNotificationAuditStore) SaveNotificationAuditEntries(context.Context, []ngmodels.NotificationAuditEntry) error {
+	return nil
+}
+
+func (f *fakeNotificationAuditStore) GetNotificationAuditEntries(_ context.Context, q *ngmodels.GetNotificationAuditQuery) ([]*ngmodels.NotificationAuditEntry, error) {
+	f.query = q
+	return f.entries, nil
+}
+
+func (f *fakeNotificationAuditStore) DeleteNotificationAuditEntriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRouteGetNotificationAudit(t *testing.T) {
+	now := time.Unix(1_600_000_000, 0).UTC()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	audit := &fakeNotificationAuditStore{entries: []*ngmodels.NotificationAuditEntry{
+		{Receiver: "on-call", Integration: "email", IntegrationIndex: 1, SentAt: now.Add(-time.Minute).UnixMilli(), Title: "[FIRING:2] HighLatency", Fingerprints: "0000000000000001,00000000000000ff", Alerts: 2, Result: ngmodels.NotificationAuditDelivered},
+		{Receiver: "on-call", Integration: "pagerduty", SentAt: now.Add(-2 * time.Minute).UnixMilli(), Title: "[FIRING:1] HighLatency", Fingerprints: "0000000000000001", Alerts: 1, Result: ngmodels.NotificationAuditFailed, Error: "webhook response status 503"},
+	}}
+	sut := ConfigSrv{auditStore: audit, auditRetention: time.Hour}
+	request := func(query string) *http.Request {
+		return &http.Request{URL: &url.URL{RawQuery: query}}
+	}
+
+	t.Run("the most recent entries of the last hour by default", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("receiver=on-call")
+
+		resp := sut.RouteGetNotificationAudit(rc)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.Equal(t, &ngmodels.GetNotificationAuditQuery{
+			OrgID:    1,
+			From:     now.Add(-time.Hour),
+			To:       now,
+			Receiver: "on-call",
+			Limit:    defaultNotificationDeliveriesLimit,
+		}, audit.query)
+		require.JSONEq(t, `[
+			{"receiver": "on-call", "integration": "email", "index": 1, "sentAt": "2020-09-13T12:25:40Z", "title": "[FIRING:2] HighLatency", "fingerprints": ["0000000000000001", "00000000000000ff"], "result": "delivered"},
+			{"receiver": "on-call", "integration": "pagerduty", "index": 0, "sentAt": "2020-09-13T12:24:40Z", "title": "[FIRING:1] HighLatency", "fingerprints": ["0000000000000001"], "result": "failed", "error": "webhook response status 503"}
+		]`, string(resp.Body()))
+	})
+
+	t.Run("fingerprints are filtered with their leading zeros", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("fingerprint=ff&from=2020-09-13T10:00:00Z&limit=5")
+
+		resp := sut.RouteGetNotificationAudit(rc)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.Equal(t, "00000000000000ff", audit.query.Fingerprint)
+		require.True(t, time.Date(2020, 9, 13, 10, 0, 0, 0, time.UTC).Equal(audit.query.From))
+		require.Equal(t, 5, audit.query.Limit)
+	})
+
+	t.Run("assert 400 on invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=-1", "limit=5000", "fingerprint=alert", "from=yesterday", "from=1600000000000&to=1500000000000"} {
+			rc := createRequestCtxInOrg(1)
+			rc.Req = request(query)
+			require.Equal(t, http.StatusBadRequest, sut.RouteGetNotificationAudit(rc).Status(), query)
+		}
+	})
+
+	t.Run("assert 404 when the audit log is disabled", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("")
+		disabled := ConfigSrv{auditStore: audit}
+		require.Equal(t, http.StatusNotFound, disabled.RouteGetNotificationAudit(rc).Status())
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	defaultNotificationDeliveriesLimit = 100
+	maxNotificationDeliveriesLimit     = 1000
+	// defaultNotificationDeliveriesRange is the time range of the deliveries if none is given.
+	defaultNotificationDeliveriesRange = time.Hour
+)
+
+// RouteGetNotificationDeliveries returns the deliveries of the notifications of the organization
+// recorded in the notification history, so that operators can check whether a notification was
+// delivered without searching the logs.
+func (srv ConfigSrv) RouteGetNotificationDeliveries(c *models.ReqContext) response.Response {
+	if srv.historyStore == nil || srv.historyRetention <= 0 {
+		return ErrResp(http.StatusNotFound, errors.New("the notification history is disabled"), "see notification_history_retention in the unified_alerting section of the configuration")
+	}
+
+	limit := c.QueryInt("limit")
+	if limit < 0 || limit > maxNotificationDeliveriesLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("limit must be a number between 1 and %d", maxNotificationDeliveriesLimit), "")
+	}
+	if limit == 0 {
+		limit = defaultNotificationDeliveriesLimit
+	}
+	now := timeNow()
+	to, err := parseNotificationDeliveriesTime(c.Query("to"), now)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid to")
+	}
+	from, err := parseNotificationDeliveriesTime(c.Query("from"), to.Add(-defaultNotificationDeliveriesRange))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid from")
+	}
+	if !from.Before(to) {
+		return ErrResp(http.StatusBadRequest, errors.New("from must be before to"), "")
+	}
+
+	deliveries, err := srv.historyStore.GetNotificationDeliveries(c.Req.Context(), &ngmodels.GetNotificationDeliveriesQuery{
+		OrgID:       c.OrgID,
+		From:        from,
+		To:          to,
+		Receiver:    c.Query("receiver"),
+		Integration: c.Query("integration"),
+		Limit:       limit,
+		Latest:      true,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to read the notification history")
+	}
+
+	resp := make(apimodels.GettableNotificationDeliveries, 0, len(deliveries))
+	for _, d := range deliveries {
+		status := apimodels.NotificationDeliveryDelivered
+		if d.Error != "" {
+			status = apimodels.NotificationDeliveryFailed
+		}
+		resp = append(resp, apimodels.GettableNotificationDelivery{
+			Receiver:    d.Receiver,
+			Integration: d.Integration,
+			Index:       d.IntegrationIndex,
+			StartedAt:   time.UnixMilli(d.StartedAt).UTC(),
+			DurationMs:  d.DurationMs,
+			Alerts:      d.Alerts,
+			Attempts:    d.Attempts,
+			Status:      status,
+			StatusCode:  d.StatusCode,
+			Error:       d.Error,
+			Canceled:    d.Canceled,
+			Failover:    d.Failover,
+		})
+	}
+	return response.JSON(http.StatusOK, resp)
+}
+
+// parseNotificationDeliveriesTime parses an RFC3339 date or a number of milliseconds since the
+// epoch, and returns def if v is empty.
+func parseNotificationDeliveriesTime(v string, def time.Time) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q must be an RFC3339 date or milliseconds since the epoch", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// fakeNotificationHistoryStore returns its deliveries and records the last query.
+type fakeNotificationHistoryStore struct {
+	deliveries []*ngmodels.NotificationDelivery
+	query      *ngmodels.GetNotificationDeliveriesQuery
+}
+
+func (f *fakeNotificationHistoryStore) SaveNotificationDeliveries(context.Context, []ngmodels.NotificationDelivery) error {
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) GetNotificationDeliveries(_ context.Context, q *ngmodels.GetNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error) {
+	f.query = q
+	return f.deliveries, nil
+}
+
+func (f *fakeNotificationHistoryStore) TrackNotificationDeliveryEmail(context.Context, *ngmodels.TrackNotificationDeliveryEmailCommand) error {
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) DeleteNotificationDeliveriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRouteGetNotificationDeliveries(t *testing.T) {
+	now := time.Unix(1_600_000_000, 0).UTC()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	history := &fakeNotificationHistoryStore{deliveries: []*ngmodels.NotificationDelivery{
+		{Receiver: "on-call", Integration: "email", IntegrationIndex: 1, StartedAt: now.Add(-time.Minute).UnixMilli(), DurationMs: 12, Alerts: 1, Attempts: 1, Failover: true},
+		{Receiver: "on-call", Integration: "pagerduty", StartedAt: now.Add(-2 * time.Minute).UnixMilli(), DurationMs: 30, Alerts: 1, Attempts: 3, StatusCode: 503, Error: "webhook response status 503"},
+	}}
+	sut := ConfigSrv{historyStore: history, historyRetention: time.Hour}
+	request := func(query string) *http.Request {
+		return &http.Request{URL: &url.URL{RawQuery: query}}
+	}
+
+	t.Run("the most recent deliveries of the last hour by default", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("receiver=on-call")
+
+		resp := sut.RouteGetNotificationDeliveries(rc)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.Equal(t, &ngmodels.GetNotificationDeliveriesQuery{
+			OrgID:    1,
+			From:     now.Add(-time.Hour),
+			To:       now,
+			Receiver: "on-call",
+			Limit:    defaultNotificationDeliveriesLimit,
+			Latest:   true,
+		}, history.query)
+		require.JSONEq(t, `[
+			{"receiver": "on-call", "integration": "email", "index": 1, "startedAt": "2020-09-13T12:25:40Z", "durationMs": 12, "alerts": 1, "attempts": 1, "status": "delivered", "failover": true},
+			{"receiver": "on-call", "integration": "pagerduty", "index": 0, "startedAt": "2020-09-13T12:24:40Z", "durationMs": 30, "alerts": 1, "attempts": 3, "status": "failed", "statusCode": 503, "error": "webhook response status 503"}
+		]`, string(resp.Body()))
+	})
+
+	t.Run("time range as dates or milliseconds", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("from=2020-09-13T10:00:00Z&to=1600000000000&integration=email&limit=5")
+
+		resp := sut.RouteGetNotificationDeliveries(rc)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.True(t, time.Date(2020, 9, 13, 10, 0, 0, 0, time.UTC).Equal(history.query.From))
+		require.True(t, now.Equal(history.query.To))
+		require.Equal(t, "email", history.query.Integration)
+		require.Equal(t, 5, history.query.Limit)
+	})
+
+	t.Run("assert 400 on invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=-1", "limit=5000", "from=yesterday", "from=1600000000000&to=1500000000000"} {
+			rc := createRequestCtxInOrg(1)
+			rc.Req = request(query)
+			require.Equal(t, http.StatusBadRequest, sut.RouteGetNotificationDeliveries(rc).Status(), query)
+		}
+	})
+
+	t.Run("assert 404 when the history is disabled", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req = request("")
+		disabled := ConfigSrv{historyStore: history}
+		require.Equal(t, http.StatusNotFound, disabled.RouteGetNotificationDeliveries(rc).Status())
+	})
+}
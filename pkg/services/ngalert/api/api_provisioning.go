@@ -20,6 +20,7 @@ type ProvisioningSrv struct {
 	policies            NotificationPolicyService
 	contactPointService ContactPointService
 	templates           TemplateService
+	templatePacks       TemplatePackService
 	muteTimings         MuteTimingService
 	alertRules          AlertRuleService
 }
@@ -37,6 +38,10 @@ type TemplateService interface {
 	DeleteTemplate(ctx context.Context, orgID int64, name string) error
 }
 
+type TemplatePackService interface {
+	ImportTemplatePack(ctx context.Context, orgID int64, imp definitions.TemplatePackImport, dryRun bool) (definitions.TemplatePackImportResult, error)
+}
+
 type NotificationPolicyService interface {
 	GetPolicyTree(ctx context.Context, orgID int64) (definitions.Route, error)
 	UpdatePolicyTree(ctx context.Context, orgID int64, tree definitions.Route, p alerting_models.Provenance) error
@@ -188,6 +193,28 @@ func (srv *ProvisioningSrv) RouteDeleteTemplate(c *models.ReqContext, name strin
 	return response.JSON(http.StatusNoContent, nil)
 }
 
+func (srv *ProvisioningSrv) RoutePostTemplatePackPreview(c *models.ReqContext, body definitions.TemplatePackImport) response.Response {
+	return srv.importTemplatePack(c, body, true)
+}
+
+func (srv *ProvisioningSrv) RoutePostTemplatePackImport(c *models.ReqContext, body definitions.TemplatePackImport) response.Response {
+	return srv.importTemplatePack(c, body, false)
+}
+
+func (srv *ProvisioningSrv) importTemplatePack(c *models.ReqContext, body definitions.TemplatePackImport, dryRun bool) response.Response {
+	result, err := srv.templatePacks.ImportTemplatePack(c.Req.Context(), c.OrgID, body, dryRun)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrTemplatePackConflict) {
+			return response.JSON(http.StatusConflict, result)
+		}
+		if errors.Is(err, provisioning.ErrValidation) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 func (srv *ProvisioningSrv) RouteGetMuteTiming(c *models.ReqContext, name string) response.Response {
 	timings, err := srv.muteTimings.GetMuteTimings(c.Req.Context(), c.OrgID)
 	if err != nil {
@@ -189,6 +189,43 @@ func TestProvisioningApi(t *testing.T) {
 		})
 	})
 
+	t.Run("template packs", func(t *testing.T) {
+		pack := `{"name": "pack", "templates": [{"name": "a", "template": "other"}, {"name": "b", "template": "new"}]}`
+
+		t.Run("preview returns 200", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			imp := definitions.TemplatePackImport{Bundle: definitions.TemplatePackBundle{Payload: pack}, Conflicts: definitions.TemplatePackConflictsSkip}
+
+			response := sut.RoutePostTemplatePackPreview(&rc, imp)
+
+			require.Equal(t, 200, response.Status())
+			require.Contains(t, string(response.Body()), `"action":"create"`)
+		})
+
+		t.Run("conflicts return 409", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			imp := definitions.TemplatePackImport{Bundle: definitions.TemplatePackBundle{Payload: pack}}
+
+			response := sut.RoutePostTemplatePackImport(&rc, imp)
+
+			require.Equal(t, 409, response.Status())
+			require.Contains(t, string(response.Body()), `"action":"conflict"`)
+		})
+
+		t.Run("invalid packs return 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			imp := definitions.TemplatePackImport{Bundle: definitions.TemplatePackBundle{Payload: `{"name": "pack"}`}}
+
+			response := sut.RoutePostTemplatePackImport(&rc, imp)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "the template pack has no templates")
+		})
+	})
+
 	t.Run("mute timings", func(t *testing.T) {
 		t.Run("are invalid", func(t *testing.T) {
 			t.Run("POST returns 400", func(t *testing.T) {
@@ -397,6 +434,7 @@ func createProvisioningSrvSutFromEnv(t *testing.T, env *testEnvironment) Provisi
 		policies:            newFakeNotificationPolicyService(),
 		contactPointService: provisioning.NewContactPointService(env.configs, env.secrets, env.prov, env.xact, env.log),
 		templates:           provisioning.NewTemplateService(env.configs, env.prov, env.xact, env.log),
+		templatePacks:       provisioning.NewTemplatePackService(env.configs, env.prov, env.xact, setting.UnifiedAlertingSettings{TemplatePackAllowUnsigned: true}, env.log),
 		muteTimings:         provisioning.NewMuteTimingService(env.configs, env.prov, env.xact, env.log),
 		alertRules:          provisioning.NewAlertRuleService(env.store, env.prov, env.quotas, env.xact, 60, 10, env.log),
 	}
@@ -151,14 +151,24 @@ func (api *API) authorize(method, path string) web.Handler {
 	case http.MethodGet + "/api/alertmanager/grafana/config/api/v1/alerts":
 		fallback = middleware.ReqEditorRole
 		eval = ac.EvalPermission(ac.ActionAlertingNotificationsRead)
-	case http.MethodGet + "/api/alertmanager/grafana/api/v2/status":
+	case http.MethodGet + "/api/alertmanager/grafana/api/v2/status",
+		http.MethodGet + "/api/v1/notifications/deliveries":
 		eval = ac.EvalPermission(ac.ActionAlertingNotificationsRead)
 	case http.MethodPost + "/api/alertmanager/grafana/config/api/v1/alerts":
 		// additional authorization is done in the request handler
-		eval = ac.EvalAny(ac.EvalPermission(ac.ActionAlertingNotificationsWrite))
+		eval = ac.EvalAny(ac.EvalPermission(ac.ActionAlertingNotificationsWrite), ac.EvalPermission(ac.ActionAlertingNotificationsTeamWrite))
 	case http.MethodPost + "/api/alertmanager/grafana/config/api/v1/receivers/test":
 		fallback = middleware.ReqEditorRole
 		eval = ac.EvalPermission(ac.ActionAlertingNotificationsRead)
+	case http.MethodGet + "/api/alertmanager/grafana/config/api/v1/lint",
+		http.MethodPost + "/api/alertmanager/grafana/config/api/v1/lint":
+		fallback = middleware.ReqEditorRole
+		eval = ac.EvalPermission(ac.ActionAlertingNotificationsRead)
+	case http.MethodGet + "/api/alertmanager/grafana/config/api/v1/dead-letters":
+		fallback = middleware.ReqEditorRole
+		eval = ac.EvalPermission(ac.ActionAlertingNotificationsRead)
+	case http.MethodPost + "/api/alertmanager/grafana/config/api/v1/dead-letters/{ID}/replay":
+		eval = ac.EvalPermission(ac.ActionAlertingNotificationsWrite)
 
 	// External Alertmanager Paths
 	case http.MethodDelete + "/api/alertmanager/{DatasourceUID}/config/api/v1/alerts":
@@ -176,7 +186,15 @@ func (api *API) authorize(method, path string) web.Handler {
 	case http.MethodDelete + "/api/v1/ngalert/admin_config",
 		http.MethodGet + "/api/v1/ngalert/admin_config",
 		http.MethodPost + "/api/v1/ngalert/admin_config",
-		http.MethodGet + "/api/v1/ngalert/alertmanagers":
+		http.MethodGet + "/api/v1/ngalert/alertmanagers",
+		http.MethodGet + "/api/v1/ngalert/alertmanager_credentials",
+		http.MethodPost + "/api/v1/ngalert/alertmanager_credentials",
+		http.MethodGet + "/api/v1/ngalert/alertmanager_credentials/{UID}",
+		http.MethodPut + "/api/v1/ngalert/alertmanager_credentials/{UID}",
+		http.MethodDelete + "/api/v1/ngalert/alertmanager_credentials/{UID}",
+		http.MethodPost + "/api/v1/ngalert/alertmanager_credentials/{UID}/rotate",
+		http.MethodGet + "/api/alertmanager/grafana/config/api/v1/receivers/profiles",
+		http.MethodGet + "/api/v1/notifications/audit":
 		return middleware.ReqOrgAdmin
 
 	// Grafana-only Provisioning Read Paths
@@ -198,6 +216,8 @@ func (api *API) authorize(method, path string) web.Handler {
 		http.MethodDelete + "/api/v1/provisioning/contact-points/{UID}",
 		http.MethodPut + "/api/v1/provisioning/templates/{name}",
 		http.MethodDelete + "/api/v1/provisioning/templates/{name}",
+		http.MethodPost + "/api/v1/provisioning/templates/import/preview",
+		http.MethodPost + "/api/v1/provisioning/templates/import",
 		http.MethodPost + "/api/v1/provisioning/mute-timings",
 		http.MethodPut + "/api/v1/provisioning/mute-timings/{name}",
 		http.MethodDelete + "/api/v1/provisioning/mute-timings/{name}",
@@ -32,3 +32,35 @@ func (f *ConfigurationApiHandler) handleRoutePostNGalertConfig(c *models.ReqCont
 func (f *ConfigurationApiHandler) handleRouteDeleteNGalertConfig(c *models.ReqContext) response.Response {
 	return f.grafana.RouteDeleteNGalertConfig(c)
 }
+
+func (f *ConfigurationApiHandler) handleRouteGetAlertmanagerCredentialsList(c *models.ReqContext) response.Response {
+	return f.grafana.RouteGetAlertmanagerCredentialsList(c)
+}
+
+func (f *ConfigurationApiHandler) handleRouteGetAlertmanagerCredentials(c *models.ReqContext, uid string) response.Response {
+	return f.grafana.RouteGetAlertmanagerCredentials(c, uid)
+}
+
+func (f *ConfigurationApiHandler) handleRoutePostAlertmanagerCredentials(c *models.ReqContext, body apimodels.PostableAlertmanagerCredentials) response.Response {
+	return f.grafana.RoutePostAlertmanagerCredentials(c, body)
+}
+
+func (f *ConfigurationApiHandler) handleRoutePutAlertmanagerCredentials(c *models.ReqContext, body apimodels.PostableAlertmanagerCredentials, uid string) response.Response {
+	return f.grafana.RoutePutAlertmanagerCredentials(c, body, uid)
+}
+
+func (f *ConfigurationApiHandler) handleRouteDeleteAlertmanagerCredentials(c *models.ReqContext, uid string) response.Response {
+	return f.grafana.RouteDeleteAlertmanagerCredentials(c, uid)
+}
+
+func (f *ConfigurationApiHandler) handleRouteGetNotificationAudit(c *models.ReqContext) response.Response {
+	return f.grafana.RouteGetNotificationAudit(c)
+}
+
+func (f *ConfigurationApiHandler) handleRouteGetNotificationDeliveries(c *models.ReqContext) response.Response {
+	return f.grafana.RouteGetNotificationDeliveries(c)
+}
+
+func (f *ConfigurationApiHandler) handleRoutePostRotateAlertmanagerCredentials(c *models.ReqContext, body apimodels.RotateAlertmanagerCredentials, uid string) response.Response {
+	return f.grafana.RoutePostRotateAlertmanagerCredentials(c, body, uid)
+}
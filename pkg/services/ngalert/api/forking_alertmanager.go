@@ -168,6 +168,22 @@ func (f *AlertmanagerApiHandler) handleRouteGetGrafanaAlertingConfig(ctx *models
 	return f.GrafanaSvc.RouteGetAlertingConfig(ctx)
 }
 
+func (f *AlertmanagerApiHandler) handleRouteGetGrafanaPolicyLint(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetPolicyLint(ctx)
+}
+
+func (f *AlertmanagerApiHandler) handleRouteGetGrafanaDeadLetters(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetDeadLetters(ctx)
+}
+
+func (f *AlertmanagerApiHandler) handleRoutePostGrafanaDeadLetterReplay(ctx *models.ReqContext, id string) response.Response {
+	return f.GrafanaSvc.RoutePostDeadLetterReplay(ctx, id)
+}
+
+func (f *AlertmanagerApiHandler) handleRouteGetGrafanaReceiverProfiles(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetReceiverProfiles(ctx)
+}
+
 func (f *AlertmanagerApiHandler) handleRouteGetGrafanaSilence(ctx *models.ReqContext, id string) response.Response {
 	return f.GrafanaSvc.RouteGetSilence(ctx, id)
 }
@@ -187,6 +203,10 @@ func (f *AlertmanagerApiHandler) handleRoutePostGrafanaAlertingConfig(ctx *model
 	return f.GrafanaSvc.RoutePostAlertingConfig(ctx, conf)
 }
 
+func (f *AlertmanagerApiHandler) handleRoutePostGrafanaPolicyLint(ctx *models.ReqContext, conf apimodels.PostableUserConfig) response.Response {
+	return f.GrafanaSvc.RoutePostPolicyLint(ctx, conf)
+}
+
 func (f *AlertmanagerApiHandler) handleRoutePostTestGrafanaReceivers(ctx *models.ReqContext, conf apimodels.TestReceiversConfigBodyParams) response.Response {
 	return f.GrafanaSvc.RoutePostTestReceivers(ctx, conf)
 }
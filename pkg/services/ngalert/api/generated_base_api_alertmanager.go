@@ -33,6 +33,9 @@ type AlertmanagerApi interface {
 	RouteGetGrafanaAMAlerts(*models.ReqContext) response.Response
 	RouteGetGrafanaAMStatus(*models.ReqContext) response.Response
 	RouteGetGrafanaAlertingConfig(*models.ReqContext) response.Response
+	RouteGetGrafanaDeadLetters(*models.ReqContext) response.Response
+	RouteGetGrafanaPolicyLint(*models.ReqContext) response.Response
+	RouteGetGrafanaReceiverProfiles(*models.ReqContext) response.Response
 	RouteGetGrafanaSilence(*models.ReqContext) response.Response
 	RouteGetGrafanaSilences(*models.ReqContext) response.Response
 	RouteGetSilence(*models.ReqContext) response.Response
@@ -41,6 +44,8 @@ type AlertmanagerApi interface {
 	RoutePostAlertingConfig(*models.ReqContext) response.Response
 	RoutePostGrafanaAMAlerts(*models.ReqContext) response.Response
 	RoutePostGrafanaAlertingConfig(*models.ReqContext) response.Response
+	RoutePostGrafanaDeadLetterReplay(*models.ReqContext) response.Response
+	RoutePostGrafanaPolicyLint(*models.ReqContext) response.Response
 	RoutePostTestGrafanaReceivers(*models.ReqContext) response.Response
 	RoutePostTestReceivers(*models.ReqContext) response.Response
 }
@@ -114,6 +119,15 @@ func (f *AlertmanagerApiHandler) RouteGetGrafanaAMStatus(ctx *models.ReqContext)
 func (f *AlertmanagerApiHandler) RouteGetGrafanaAlertingConfig(ctx *models.ReqContext) response.Response {
 	return f.handleRouteGetGrafanaAlertingConfig(ctx)
 }
+func (f *AlertmanagerApiHandler) RouteGetGrafanaDeadLetters(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetGrafanaDeadLetters(ctx)
+}
+func (f *AlertmanagerApiHandler) RouteGetGrafanaPolicyLint(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetGrafanaPolicyLint(ctx)
+}
+func (f *AlertmanagerApiHandler) RouteGetGrafanaReceiverProfiles(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetGrafanaReceiverProfiles(ctx)
+}
 func (f *AlertmanagerApiHandler) RouteGetGrafanaSilence(ctx *models.ReqContext) response.Response {
 	// Parse Path Parameters
 	silenceIdParam := web.Params(ctx.Req)[":SilenceId"]
@@ -169,6 +183,19 @@ func (f *AlertmanagerApiHandler) RoutePostGrafanaAlertingConfig(ctx *models.ReqC
 	}
 	return f.handleRoutePostGrafanaAlertingConfig(ctx, conf)
 }
+func (f *AlertmanagerApiHandler) RoutePostGrafanaDeadLetterReplay(ctx *models.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRoutePostGrafanaDeadLetterReplay(ctx, iDParam)
+}
+func (f *AlertmanagerApiHandler) RoutePostGrafanaPolicyLint(ctx *models.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.PostableUserConfig{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostGrafanaPolicyLint(ctx, conf)
+}
 func (f *AlertmanagerApiHandler) RoutePostTestGrafanaReceivers(ctx *models.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.TestReceiversConfigBodyParams{}
@@ -330,6 +357,36 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/grafana/config/api/v1/dead-letters"),
+			api.authorize(http.MethodGet, "/api/alertmanager/grafana/config/api/v1/dead-letters"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/grafana/config/api/v1/dead-letters",
+				srv.RouteGetGrafanaDeadLetters,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/grafana/config/api/v1/lint"),
+			api.authorize(http.MethodGet, "/api/alertmanager/grafana/config/api/v1/lint"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/grafana/config/api/v1/lint",
+				srv.RouteGetGrafanaPolicyLint,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/grafana/config/api/v1/receivers/profiles"),
+			api.authorize(http.MethodGet, "/api/alertmanager/grafana/config/api/v1/receivers/profiles"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/grafana/config/api/v1/receivers/profiles",
+				srv.RouteGetGrafanaReceiverProfiles,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/alertmanager/grafana/api/v2/silence/{SilenceId}"),
 			api.authorize(http.MethodGet, "/api/alertmanager/grafana/api/v2/silence/{SilenceId}"),
@@ -410,6 +467,26 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/alertmanager/grafana/config/api/v1/dead-letters/{ID}/replay"),
+			api.authorize(http.MethodPost, "/api/alertmanager/grafana/config/api/v1/dead-letters/{ID}/replay"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/alertmanager/grafana/config/api/v1/dead-letters/{ID}/replay",
+				srv.RoutePostGrafanaDeadLetterReplay,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/alertmanager/grafana/config/api/v1/lint"),
+			api.authorize(http.MethodPost, "/api/alertmanager/grafana/config/api/v1/lint"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/alertmanager/grafana/config/api/v1/lint",
+				srv.RoutePostGrafanaPolicyLint,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/alertmanager/grafana/config/api/v1/receivers/test"),
 			api.authorize(http.MethodPost, "/api/alertmanager/grafana/config/api/v1/receivers/test"),
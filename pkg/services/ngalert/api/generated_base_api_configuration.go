@@ -19,21 +19,56 @@ import (
 )
 
 type ConfigurationApi interface {
+	RouteDeleteAlertmanagerCredentials(*models.ReqContext) response.Response
 	RouteDeleteNGalertConfig(*models.ReqContext) response.Response
+	RouteGetAlertmanagerCredentials(*models.ReqContext) response.Response
+	RouteGetAlertmanagerCredentialsList(*models.ReqContext) response.Response
 	RouteGetAlertmanagers(*models.ReqContext) response.Response
 	RouteGetNGalertConfig(*models.ReqContext) response.Response
+	RouteGetNotificationAudit(*models.ReqContext) response.Response
+	RouteGetNotificationDeliveries(*models.ReqContext) response.Response
+	RoutePostAlertmanagerCredentials(*models.ReqContext) response.Response
 	RoutePostNGalertConfig(*models.ReqContext) response.Response
+	RoutePostRotateAlertmanagerCredentials(*models.ReqContext) response.Response
+	RoutePutAlertmanagerCredentials(*models.ReqContext) response.Response
 }
 
+func (f *ConfigurationApiHandler) RouteDeleteAlertmanagerCredentials(ctx *models.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	return f.handleRouteDeleteAlertmanagerCredentials(ctx, uIDParam)
+}
 func (f *ConfigurationApiHandler) RouteDeleteNGalertConfig(ctx *models.ReqContext) response.Response {
 	return f.handleRouteDeleteNGalertConfig(ctx)
 }
+func (f *ConfigurationApiHandler) RouteGetAlertmanagerCredentials(ctx *models.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	return f.handleRouteGetAlertmanagerCredentials(ctx, uIDParam)
+}
+func (f *ConfigurationApiHandler) RouteGetAlertmanagerCredentialsList(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetAlertmanagerCredentialsList(ctx)
+}
 func (f *ConfigurationApiHandler) RouteGetAlertmanagers(ctx *models.ReqContext) response.Response {
 	return f.handleRouteGetAlertmanagers(ctx)
 }
 func (f *ConfigurationApiHandler) RouteGetNGalertConfig(ctx *models.ReqContext) response.Response {
 	return f.handleRouteGetNGalertConfig(ctx)
 }
+func (f *ConfigurationApiHandler) RouteGetNotificationAudit(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetNotificationAudit(ctx)
+}
+func (f *ConfigurationApiHandler) RouteGetNotificationDeliveries(ctx *models.ReqContext) response.Response {
+	return f.handleRouteGetNotificationDeliveries(ctx)
+}
+func (f *ConfigurationApiHandler) RoutePostAlertmanagerCredentials(ctx *models.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.PostableAlertmanagerCredentials{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostAlertmanagerCredentials(ctx, conf)
+}
 func (f *ConfigurationApiHandler) RoutePostNGalertConfig(ctx *models.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.PostableNGalertConfig{}
@@ -42,9 +77,39 @@ func (f *ConfigurationApiHandler) RoutePostNGalertConfig(ctx *models.ReqContext)
 	}
 	return f.handleRoutePostNGalertConfig(ctx, conf)
 }
+func (f *ConfigurationApiHandler) RoutePostRotateAlertmanagerCredentials(ctx *models.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	// Parse Request Body
+	conf := apimodels.RotateAlertmanagerCredentials{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostRotateAlertmanagerCredentials(ctx, conf, uIDParam)
+}
+func (f *ConfigurationApiHandler) RoutePutAlertmanagerCredentials(ctx *models.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	// Parse Request Body
+	conf := apimodels.PostableAlertmanagerCredentials{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePutAlertmanagerCredentials(ctx, conf, uIDParam)
+}
 
 func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metrics.API) {
 	api.RouteRegister.Group("", func(group routing.RouteRegister) {
+		group.Delete(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			api.authorize(http.MethodDelete, "/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/ngalert/alertmanager_credentials/{UID}",
+				srv.RouteDeleteAlertmanagerCredentials,
+				m,
+			),
+		)
 		group.Delete(
 			toMacaronPath("/api/v1/ngalert/admin_config"),
 			api.authorize(http.MethodDelete, "/api/v1/ngalert/admin_config"),
@@ -55,6 +120,26 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metri
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/alertmanager_credentials/{UID}",
+				srv.RouteGetAlertmanagerCredentials,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials"),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/alertmanager_credentials"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/alertmanager_credentials",
+				srv.RouteGetAlertmanagerCredentialsList,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/ngalert/alertmanagers"),
 			api.authorize(http.MethodGet, "/api/v1/ngalert/alertmanagers"),
@@ -75,6 +160,36 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metri
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/notifications/audit"),
+			api.authorize(http.MethodGet, "/api/v1/notifications/audit"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/notifications/audit",
+				srv.RouteGetNotificationAudit,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/notifications/deliveries"),
+			api.authorize(http.MethodGet, "/api/v1/notifications/deliveries"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/notifications/deliveries",
+				srv.RouteGetNotificationDeliveries,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials"),
+			api.authorize(http.MethodPost, "/api/v1/ngalert/alertmanager_credentials"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/ngalert/alertmanager_credentials",
+				srv.RoutePostAlertmanagerCredentials,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/ngalert/admin_config"),
 			api.authorize(http.MethodPost, "/api/v1/ngalert/admin_config"),
@@ -85,5 +200,25 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metri
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials/{UID}/rotate"),
+			api.authorize(http.MethodPost, "/api/v1/ngalert/alertmanager_credentials/{UID}/rotate"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/ngalert/alertmanager_credentials/{UID}/rotate",
+				srv.RoutePostRotateAlertmanagerCredentials,
+				m,
+			),
+		)
+		group.Put(
+			toMacaronPath("/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			api.authorize(http.MethodPut, "/api/v1/ngalert/alertmanager_credentials/{UID}"),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/v1/ngalert/alertmanager_credentials/{UID}",
+				srv.RoutePutAlertmanagerCredentials,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
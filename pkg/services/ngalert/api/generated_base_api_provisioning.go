@@ -34,6 +34,8 @@ type ProvisioningApi interface {
 	RoutePostAlertRule(*models.ReqContext) response.Response
 	RoutePostContactpoints(*models.ReqContext) response.Response
 	RoutePostMuteTiming(*models.ReqContext) response.Response
+	RoutePostTemplatePackImport(*models.ReqContext) response.Response
+	RoutePostTemplatePackPreview(*models.ReqContext) response.Response
 	RoutePutAlertRule(*models.ReqContext) response.Response
 	RoutePutAlertRuleGroup(*models.ReqContext) response.Response
 	RoutePutContactpoint(*models.ReqContext) response.Response
@@ -120,6 +122,22 @@ func (f *ProvisioningApiHandler) RoutePostMuteTiming(ctx *models.ReqContext) res
 	}
 	return f.handleRoutePostMuteTiming(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePostTemplatePackImport(ctx *models.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.TemplatePackImport{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostTemplatePackImport(ctx, conf)
+}
+func (f *ProvisioningApiHandler) RoutePostTemplatePackPreview(ctx *models.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.TemplatePackImport{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostTemplatePackPreview(ctx, conf)
+}
 func (f *ProvisioningApiHandler) RoutePutAlertRule(ctx *models.ReqContext) response.Response {
 	// Parse Path Parameters
 	uIDParam := web.Params(ctx.Req)[":UID"]
@@ -335,6 +353,26 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/templates/import"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/templates/import"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/templates/import",
+				srv.RoutePostTemplatePackImport,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/templates/import/preview"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/templates/import/preview"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/templates/import/preview",
+				srv.RoutePostTemplatePackPreview,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/alert-rules/{UID}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/alert-rules/{UID}"),
@@ -56,6 +56,14 @@ func (f *ProvisioningApiHandler) handleRouteDeleteTemplate(ctx *models.ReqContex
 	return f.svc.RouteDeleteTemplate(ctx, name)
 }
 
+func (f *ProvisioningApiHandler) handleRoutePostTemplatePackPreview(ctx *models.ReqContext, body apimodels.TemplatePackImport) response.Response {
+	return f.svc.RoutePostTemplatePackPreview(ctx, body)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostTemplatePackImport(ctx *models.ReqContext, body apimodels.TemplatePackImport) response.Response {
+	return f.svc.RoutePostTemplatePackImport(ctx, body)
+}
+
 func (f *ProvisioningApiHandler) handleRouteGetMuteTiming(ctx *models.ReqContext, name string) response.Response {
 	return f.svc.RouteGetMuteTiming(ctx, name)
 }
@@ -1,6 +1,8 @@
 package definitions
 
 import (
+	"time"
+
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
 
@@ -48,6 +50,83 @@ import (
 //       200: Ack
 //       500: Failure
 
+// swagger:route GET /api/v1/ngalert/alertmanager_credentials configuration RouteGetAlertmanagerCredentialsList
+//
+// Get the external Alertmanager credentials of the user's organization. The secrets are never returned.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableAlertmanagerCredentialsList
+//       500: Failure
+
+// swagger:route POST /api/v1/ngalert/alertmanager_credentials configuration RoutePostAlertmanagerCredentials
+//
+// Creates external Alertmanager credentials in the user's organization.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       201: GettableAlertmanagerCredentials
+//       400: ValidationError
+//       409: Failure
+
+// swagger:route GET /api/v1/ngalert/alertmanager_credentials/{UID} configuration RouteGetAlertmanagerCredentials
+//
+// Get external Alertmanager credentials of the user's organization. The secrets are never returned.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableAlertmanagerCredentials
+//       404: Failure
+
+// swagger:route PUT /api/v1/ngalert/alertmanager_credentials/{UID} configuration RoutePutAlertmanagerCredentials
+//
+// Updates external Alertmanager credentials. The secrets that are not sent are kept.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableAlertmanagerCredentials
+//       400: ValidationError
+//       404: Failure
+//       409: Failure
+
+// swagger:route DELETE /api/v1/ngalert/alertmanager_credentials/{UID} configuration RouteDeleteAlertmanagerCredentials
+//
+// Deletes external Alertmanager credentials, unless an Alertmanager data source references them.
+//
+//     Responses:
+//       200: Ack
+//       404: Failure
+//       409: Failure
+
+// swagger:route POST /api/v1/ngalert/alertmanager_credentials/{UID}/rotate configuration RoutePostRotateAlertmanagerCredentials
+//
+// Replaces all the secrets of external Alertmanager credentials. The alerts are sent with the new secrets at the next synchronization of the Alertmanagers.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableAlertmanagerCredentials
+//       400: ValidationError
+//       404: Failure
+
 // swagger:parameters RoutePostNGalertConfig
 type NGalertConfig struct {
 	// in:body
@@ -62,6 +141,9 @@ const (
 	InternalAlertmanager       AlertmanagersChoice = "internal"
 	ExternalAlertmanagers      AlertmanagersChoice = "external"
 	HandleGrafanaManagedAlerts                     = "handleGrafanaManagedAlerts"
+	// AlertmanagerCredentialsUID is the setting of the Alertmanager data sources that references
+	// the external Alertmanager credentials that Grafana uses to send alerts to them.
+	AlertmanagerCredentialsUID = "alertmanagerCredentialsUid"
 )
 
 // swagger:model
@@ -81,3 +163,56 @@ type GettableAlertmanagers struct {
 	Status string                 `json:"status"`
 	Data   v1.AlertManagersResult `json:"data"`
 }
+
+// swagger:parameters RouteGetAlertmanagerCredentials RoutePutAlertmanagerCredentials RouteDeleteAlertmanagerCredentials RoutePostRotateAlertmanagerCredentials
+type AlertmanagerCredentialsUIDReference struct {
+	// UID is the unique identifier of the credentials
+	// in:path
+	UID string
+}
+
+// swagger:parameters RoutePostAlertmanagerCredentials RoutePutAlertmanagerCredentials
+type AlertmanagerCredentialsPayload struct {
+	// in:body
+	Body PostableAlertmanagerCredentials
+}
+
+// swagger:parameters RoutePostRotateAlertmanagerCredentials
+type RotateAlertmanagerCredentialsPayload struct {
+	// in:body
+	Body RotateAlertmanagerCredentials
+}
+
+// swagger:model
+type PostableAlertmanagerCredentials struct {
+	// required: true
+	Name          string `json:"name"`
+	BasicAuthUser string `json:"basicAuthUser,omitempty"`
+	TLSServerName string `json:"tlsServerName,omitempty"`
+	TLSSkipVerify bool   `json:"tlsSkipVerify,omitempty"`
+	// SecureSettings are the secrets: basicAuthPassword, tlsCACert, tlsClientCert, tlsClientKey,
+	// and the values of the HTTP headers with keys prefixed by "httpHeader:".
+	SecureSettings map[string]string `json:"secureSettings,omitempty"`
+}
+
+// swagger:model
+type RotateAlertmanagerCredentials struct {
+	SecureSettings map[string]string `json:"secureSettings"`
+}
+
+// swagger:model
+type GettableAlertmanagerCredentials struct {
+	UID           string `json:"uid"`
+	Name          string `json:"name"`
+	BasicAuthUser string `json:"basicAuthUser,omitempty"`
+	TLSServerName string `json:"tlsServerName,omitempty"`
+	TLSSkipVerify bool   `json:"tlsSkipVerify,omitempty"`
+	// SecureFields are the keys of the secrets that are set.
+	SecureFields map[string]bool `json:"secureFields"`
+	// Version is incremented each time the secrets are rotated.
+	Version   int64      `json:"version"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+}
+
+// swagger:model
+type GettableAlertmanagerCredentialsList []GettableAlertmanagerCredentials
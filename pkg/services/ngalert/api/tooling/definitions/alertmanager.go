@@ -156,6 +156,31 @@ import (
 //       408: Failure
 //       409: AlertManagerNotReady
 
+// swagger:route GET /api/alertmanager/grafana/config/api/v1/receivers/profiles alertmanager RouteGetGrafanaReceiverProfiles
+//
+// Get the time breakdown of the last notifications sent by Grafana managed receivers.
+//
+//     Responses:
+//       200: ReceiverProfiles
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route GET /api/alertmanager/grafana/config/api/v1/lint alertmanager RouteGetGrafanaPolicyLint
+//
+// Statically analyze the notification policies and contact points of the current configuration.
+//
+//     Responses:
+//       200: PolicyLintResult
+//       404: NotFound
+
+// swagger:route POST /api/alertmanager/grafana/config/api/v1/lint alertmanager RoutePostGrafanaPolicyLint
+//
+// Statically analyze the notification policies and contact points of a configuration without saving it.
+//
+//     Responses:
+//       200: PolicyLintResult
+//       400: ValidationError
+
 // swagger:route POST /api/alertmanager/{DatasourceUID}/config/api/v1/receivers/test alertmanager RoutePostTestReceivers
 //
 // Test Grafana managed receivers without saving them.
@@ -288,6 +313,95 @@ type TestReceiverConfigResult struct {
 	Error  string `json:"error,omitempty"`
 }
 
+// swagger:parameters RouteGetGrafanaReceiverProfiles
+type ReceiverProfilesParams struct {
+	// in:query
+	// default:10
+	Limit int `json:"limit"`
+}
+
+// swagger:model
+type ReceiverProfiles []ReceiverProfile
+
+// swagger:model
+type ReceiverProfile struct {
+	Name       string            `json:"name"`
+	Dispatches []DispatchProfile `json:"dispatches"`
+}
+
+// DispatchProfile is the time breakdown of the delivery of a notification by an integration,
+// including all its retries. Durations are in milliseconds.
+// swagger:model
+type DispatchProfile struct {
+	ID          string    `json:"id"`
+	Integration string    `json:"integration"`
+	Index       int       `json:"index"`
+	StartedAt   time.Time `json:"startedAt"`
+	Alerts      int       `json:"alerts"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  float64   `json:"durationMs"`
+	TemplateMs  float64   `json:"templateMs"`
+	ImageMs     float64   `json:"imageMs"`
+	SendMs      float64   `json:"sendMs"`
+	// StatusCode is the status code of the response to the last webhook sent by the
+	// integration, if any.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Canceled is set when the notification failed because it was cancelled or timed out, such
+	// as when Grafana shuts down, rather than rejected by the integration.
+	Canceled bool `json:"canceled,omitempty"`
+	// Failover is set when the integration was tried because the previous integrations of a
+	// receiver in first_success delivery mode failed to deliver the notification.
+	Failover bool `json:"failover,omitempty"`
+	// DryRun is set when the notification was rendered but not sent, the notifications that
+	// would have been sent are in Requests.
+	DryRun   bool            `json:"dryRun,omitempty"`
+	Requests []DryRunRequest `json:"requests,omitempty"`
+	// EmailOpens and EmailClicks count the opens of the email sent by the dispatch and the
+	// clicks on its links, when the organization tracks its alert emails. EmailOpenedAt is
+	// the first time the email was opened.
+	EmailOpens    int        `json:"emailOpens,omitempty"`
+	EmailClicks   int        `json:"emailClicks,omitempty"`
+	EmailOpenedAt *time.Time `json:"emailOpenedAt,omitempty"`
+}
+
+// DryRunRequest is a notification rendered by an integration during a dry run. The target is
+// the address it would have been sent to, without the credentials it may contain.
+// swagger:model
+type DryRunRequest struct {
+	Target string `json:"target"`
+	Body   string `json:"body"`
+}
+
+// The codes of the warnings of the policy linter.
+const (
+	// PolicyLintUnreachableRoute is a route that an earlier sibling route without continue
+	// always matches first.
+	PolicyLintUnreachableRoute = "unreachable-route"
+	// PolicyLintEmptyReceiver is a contact point without integrations.
+	PolicyLintEmptyReceiver = "empty-receiver"
+	// PolicyLintUnmatchedRoute is a route whose matchers cannot match the labels of any alert rule.
+	PolicyLintUnmatchedRoute = "unmatched-route"
+	// PolicyLintMissingSeverityRoute is a severity of the alert rules that no route matches.
+	PolicyLintMissingSeverityRoute = "missing-severity-route"
+)
+
+// swagger:model
+type PolicyLintResult struct {
+	Warnings []PolicyLintWarning `json:"warnings"`
+}
+
+// PolicyLintWarning is a problem found in the notification policies or the contact points.
+// swagger:model
+type PolicyLintWarning struct {
+	// Code is one of unreachable-route, empty-receiver, unmatched-route and missing-severity-route.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Path is the path of the route in the policy tree, such as route.routes[1].routes[0].
+	Path     string `json:"path,omitempty"`
+	Receiver string `json:"receiver,omitempty"`
+}
+
 // swagger:parameters RouteCreateSilence RouteCreateGrafanaSilence
 type CreateSilenceParams struct {
 	// in:body
@@ -441,7 +555,7 @@ type PostableAlerts struct {
 	PostableAlerts []amv2.PostableAlert `yaml:"" json:""`
 }
 
-// swagger:parameters RoutePostAlertingConfig RoutePostGrafanaAlertingConfig
+// swagger:parameters RoutePostAlertingConfig RoutePostGrafanaAlertingConfig RoutePostGrafanaPolicyLint
 type BodyAlertingConfig struct {
 	// in:body
 	Body PostableUserConfig
@@ -723,6 +837,13 @@ type Route struct {
 	GroupInterval  *model.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
 	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
 
+	// TimeZone overrides the timezone of the organization preferences in the notifications of the
+	// policy and of its nested policies.
+	TimeZone string `yaml:"time_zone,omitempty" json:"time_zone,omitempty"`
+	// Locale overrides the locale of the organization preferences in the notifications of the
+	// policy and of its nested policies.
+	Locale string `yaml:"locale,omitempty" json:"locale,omitempty"`
+
 	Provenance models.Provenance `yaml:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
@@ -896,6 +1017,12 @@ func (c *PostableApiAlertingConfig) validate() error {
 		switch r.Type() {
 		case GrafanaReceiverType:
 			hasGrafReceivers = true
+			if err := r.DeliveryMode.Validate(); err != nil {
+				return fmt.Errorf("receiver %s: %w", r.Name, err)
+			}
+			if err := r.Digest.Validate(); err != nil {
+				return fmt.Errorf("receiver %s: %w", r.Name, err)
+			}
 		case AlertmanagerReceiverType:
 			hasAMReceivers = true
 		default:
@@ -1129,10 +1256,37 @@ func (r *PostableApiReceiver) Type() ReceiverType {
 
 type GettableGrafanaReceivers struct {
 	GrafanaManagedReceivers []*GettableGrafanaReceiver `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+	DeliveryMode            DeliveryMode               `yaml:"grafana_delivery_mode,omitempty" json:"grafana_delivery_mode,omitempty"`
+	Digest                  *DigestConfig              `yaml:"grafana_digest,omitempty" json:"grafana_digest,omitempty"`
 }
 
 type PostableGrafanaReceivers struct {
 	GrafanaManagedReceivers []*PostableGrafanaReceiver `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+	DeliveryMode            DeliveryMode               `yaml:"grafana_delivery_mode,omitempty" json:"grafana_delivery_mode,omitempty"`
+	Digest                  *DigestConfig              `yaml:"grafana_digest,omitempty" json:"grafana_digest,omitempty"`
+}
+
+// DeliveryMode is how a notification is delivered to the integrations of a Grafana receiver.
+type DeliveryMode string
+
+const (
+	// DeliveryModeParallel sends the notification to all the integrations at the same time. It is
+	// the default.
+	DeliveryModeParallel DeliveryMode = "parallel"
+	// DeliveryModeFirstSuccess tries the integrations in the order they are listed and stops after
+	// the first one that delivers the notification, for example to only send an SMS when the chat
+	// message could not be sent.
+	DeliveryModeFirstSuccess DeliveryMode = "first_success"
+)
+
+// DigestConfig is the digest mode of a Grafana receiver. The alerts of the receiver are
+// accumulated over the window and sent as a single summarized notification, with the number of
+// alerts by severity and the rules with the most alerts, instead of a notification per group.
+type DigestConfig struct {
+	// Window is how long the alerts are accumulated before the digest is sent.
+	Window model.Duration `yaml:"window" json:"window"`
+	// TopRules is the number of rules with the most alerts listed in the digest.
+	TopRules int `yaml:"top_rules,omitempty" json:"top_rules,omitempty"`
 }
 
 type EncryptFn func(ctx context.Context, payload []byte, scope secrets.EncryptionOptions) ([]byte, error)
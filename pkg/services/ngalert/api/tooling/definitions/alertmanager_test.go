@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/common/model"
@@ -195,6 +196,92 @@ func Test_ApiAlertingConfig_Marshaling(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "success graf first success delivery mode",
+			input: PostableApiAlertingConfig{
+				Config: Config{
+					Route: &Route{
+						Receiver: "graf",
+					},
+				},
+				Receivers: []*PostableApiReceiver{
+					{
+						Receiver: config.Receiver{
+							Name: "graf",
+						},
+						PostableGrafanaReceivers: PostableGrafanaReceivers{
+							GrafanaManagedReceivers: []*PostableGrafanaReceiver{{}, {}},
+							DeliveryMode:            DeliveryModeFirstSuccess,
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "failure graf invalid delivery mode",
+			input: PostableApiAlertingConfig{
+				Config: Config{
+					Route: &Route{
+						Receiver: "graf",
+					},
+				},
+				Receivers: []*PostableApiReceiver{
+					{
+						Receiver: config.Receiver{
+							Name: "graf",
+						},
+						PostableGrafanaReceivers: PostableGrafanaReceivers{
+							GrafanaManagedReceivers: []*PostableGrafanaReceiver{{}, {}},
+							DeliveryMode:            "sequential",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		{
+			desc: "success graf digest",
+			input: PostableApiAlertingConfig{
+				Config: Config{
+					Route: &Route{
+						Receiver: "graf",
+					},
+				},
+				Receivers: []*PostableApiReceiver{
+					{
+						Receiver: config.Receiver{
+							Name: "graf",
+						},
+						PostableGrafanaReceivers: PostableGrafanaReceivers{
+							GrafanaManagedReceivers: []*PostableGrafanaReceiver{{}},
+							Digest:                  &DigestConfig{Window: model.Duration(time.Hour), TopRules: 5},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "failure graf digest window too short",
+			input: PostableApiAlertingConfig{
+				Config: Config{
+					Route: &Route{
+						Receiver: "graf",
+					},
+				},
+				Receivers: []*PostableApiReceiver{
+					{
+						Receiver: config.Receiver{
+							Name: "graf",
+						},
+						PostableGrafanaReceivers: PostableGrafanaReceivers{
+							GrafanaManagedReceivers: []*PostableGrafanaReceiver{{}},
+							Digest:                  &DigestConfig{Window: model.Duration(time.Second)},
+						},
+					},
+				},
+			},
+			err: true,
+		},
 		{
 			desc: "failure undefined am receiver",
 			input: PostableApiAlertingConfig{
@@ -43,6 +43,11 @@ func (r *Route) validateChild() error {
 	if r.RepeatInterval != nil && time.Duration(*r.RepeatInterval) == time.Duration(0) {
 		return fmt.Errorf("repeat_interval cannot be zero")
 	}
+	if r.TimeZone != "" && !strings.EqualFold(r.TimeZone, "utc") {
+		if _, err := time.LoadLocation(r.TimeZone); err != nil {
+			return fmt.Errorf("invalid time_zone %q: %w", r.TimeZone, err)
+		}
+	}
 
 	// Routes are a self-referential structure.
 	if r.Routes != nil {
@@ -57,6 +62,35 @@ func (r *Route) validateChild() error {
 	return nil
 }
 
+func (m DeliveryMode) Validate() error {
+	switch m {
+	case "", DeliveryModeParallel, DeliveryModeFirstSuccess:
+		return nil
+	default:
+		return fmt.Errorf("invalid delivery mode %q, must be %s or %s", m, DeliveryModeParallel, DeliveryModeFirstSuccess)
+	}
+}
+
+// The bounds of the window and of the number of top rules of a digest.
+const (
+	MinDigestWindow = model.Duration(time.Minute)
+	MaxDigestWindow = model.Duration(24 * time.Hour)
+	MaxDigestRules  = 50
+)
+
+func (d *DigestConfig) Validate() error {
+	if d == nil {
+		return nil
+	}
+	if d.Window < MinDigestWindow || d.Window > MaxDigestWindow {
+		return fmt.Errorf("invalid digest window %s, must be between %s and %s", d.Window, MinDigestWindow, MaxDigestWindow)
+	}
+	if d.TopRules < 0 || d.TopRules > MaxDigestRules {
+		return fmt.Errorf("invalid digest top rules %d, must be between 0 and %d", d.TopRules, MaxDigestRules)
+	}
+	return nil
+}
+
 func (t *MessageTemplate) Validate() error {
 	if t.Name == "" {
 		return fmt.Errorf("template must have a name")
@@ -43,6 +43,20 @@ func TestValidateRoutes(t *testing.T) {
 					},
 				},
 			},
+			{
+				desc: "timezone and locale",
+				route: Route{
+					Receiver: "foo",
+					TimeZone: "UTC",
+					Routes: []*Route{
+						{
+							Receiver: "bar",
+							TimeZone: "Europe/Berlin",
+							Locale:   "de-DE",
+						},
+					},
+				},
+			},
 		}
 
 		for _, c := range cases {
@@ -112,6 +126,19 @@ func TestValidateRoutes(t *testing.T) {
 				},
 				expMsg: "duplicated label",
 			},
+			{
+				desc: "unknown timezone",
+				route: Route{
+					Receiver: "foo",
+					Routes: []*Route{
+						{
+							Receiver: "bar",
+							TimeZone: "Mars/Olympus_Mons",
+						},
+					},
+				},
+				expMsg: "invalid time_zone",
+			},
 		}
 
 		for _, c := range cases {
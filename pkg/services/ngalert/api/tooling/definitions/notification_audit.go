@@ -0,0 +1,54 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/v1/notifications/audit configuration RouteGetNotificationAudit
+//
+// Get the notifications sent for the user's organization recorded in the audit log, the most recent first.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableNotificationAuditEntries
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:parameters RouteGetNotificationAudit
+type NotificationAuditParams struct {
+	// Receiver filters the notifications by the name of the receiver.
+	// in:query
+	Receiver string `json:"receiver"`
+	// Fingerprint filters the notifications sent for the alert with this fingerprint.
+	// in:query
+	Fingerprint string `json:"fingerprint"`
+	// From and To are the time range of the notifications, as RFC3339 dates or milliseconds
+	// since the epoch. The default is the last hour.
+	// in:query
+	From string `json:"from"`
+	// in:query
+	To string `json:"to"`
+	// in:query
+	// default:100
+	Limit int `json:"limit"`
+}
+
+// swagger:model
+type GettableNotificationAuditEntries []GettableNotificationAuditEntry
+
+// GettableNotificationAuditEntry is a notification sent by an integration of a receiver.
+// swagger:model
+type GettableNotificationAuditEntry struct {
+	Receiver    string `json:"receiver"`
+	Integration string `json:"integration"`
+	// Index is the index of the integration in the receiver.
+	Index  int       `json:"index"`
+	SentAt time.Time `json:"sentAt"`
+	// Title is the rendered title of the notification.
+	Title string `json:"title"`
+	// Fingerprints are the fingerprints of the alerts of the notification.
+	Fingerprints []string `json:"fingerprints"`
+	// Result is delivered or failed.
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
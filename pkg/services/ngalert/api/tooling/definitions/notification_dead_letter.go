@@ -0,0 +1,70 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/alertmanager/grafana/config/api/v1/dead-letters alertmanager RouteGetGrafanaDeadLetters
+//
+// Get the notifications of Grafana managed receivers that failed to be delivered after all their retries, the most recent first.
+//
+//     Responses:
+//       200: GettableNotificationDeadLetters
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/alertmanager/grafana/config/api/v1/dead-letters/{ID}/replay alertmanager RoutePostGrafanaDeadLetterReplay
+//
+// Send a notification that failed to be delivered again, and delete it if it is delivered.
+//
+//     Responses:
+//       200: Ack
+//       404: NotFound
+//       409: Failure
+//       502: Failure
+
+// swagger:parameters RouteGetGrafanaDeadLetters
+type DeadLettersParams struct {
+	// Receiver filters the dead letters by the name of the receiver.
+	// in:query
+	Receiver string `json:"receiver"`
+	// in:query
+	// default:100
+	Limit int `json:"limit"`
+}
+
+// swagger:parameters RoutePostGrafanaDeadLetterReplay
+type DeadLetterParams struct {
+	// in:path
+	// required: true
+	ID int64 `json:"ID"`
+}
+
+// swagger:model
+type GettableNotificationDeadLetters []GettableNotificationDeadLetter
+
+// GettableNotificationDeadLetter is a notification that an integration of a receiver failed to
+// deliver after all its retries.
+// swagger:model
+type GettableNotificationDeadLetter struct {
+	ID          int64  `json:"id"`
+	Receiver    string `json:"receiver"`
+	Integration string `json:"integration"`
+	// Index is the index of the integration in the receiver.
+	Index       int               `json:"index"`
+	GroupLabels map[string]string `json:"groupLabels"`
+	Alerts      []DeadLetterAlert `json:"alerts"`
+	// FailedAt is when the notification last failed, and Error its last error.
+	FailedAt time.Time `json:"failedAt"`
+	Error    string    `json:"error"`
+	// Replays is the number of replays of the notification that failed too.
+	Replays int `json:"replays"`
+}
+
+// DeadLetterAlert is an alert of a notification that failed to be delivered, as it was when the
+// notification was sent.
+type DeadLetterAlert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
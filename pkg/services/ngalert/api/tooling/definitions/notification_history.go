@@ -0,0 +1,68 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/v1/notifications/deliveries configuration RouteGetNotificationDeliveries
+//
+// Get the deliveries of the notifications of the user's organization recorded in the notification history, the most recent first.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableNotificationDeliveries
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:parameters RouteGetNotificationDeliveries
+type NotificationDeliveriesParams struct {
+	// Receiver and Integration filter the deliveries by the name of the receiver and the type of
+	// the integration.
+	// in:query
+	Receiver string `json:"receiver"`
+	// in:query
+	Integration string `json:"integration"`
+	// From and To are the time range of the deliveries, as RFC3339 dates or milliseconds since
+	// the epoch. The default is the last hour.
+	// in:query
+	From string `json:"from"`
+	// in:query
+	To string `json:"to"`
+	// in:query
+	// default:100
+	Limit int `json:"limit"`
+}
+
+// swagger:model
+type GettableNotificationDeliveries []GettableNotificationDelivery
+
+// GettableNotificationDelivery is the delivery of a notification by an integration of a
+// receiver, including all its retries.
+// swagger:model
+type GettableNotificationDelivery struct {
+	Receiver    string `json:"receiver"`
+	Integration string `json:"integration"`
+	// Index is the index of the integration in the receiver.
+	Index      int       `json:"index"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs float64   `json:"durationMs"`
+	Alerts     int       `json:"alerts"`
+	Attempts   int       `json:"attempts"`
+	// Status is delivered or failed.
+	Status string `json:"status"`
+	// StatusCode is the status code of the response to the last attempt, if the integration
+	// sends webhooks.
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Canceled is set when the notification failed because it was cancelled or timed out, such
+	// as when Grafana shuts down, rather than rejected by the integration.
+	Canceled bool `json:"canceled,omitempty"`
+	// Failover is set when the integration was tried because the previous integrations of a
+	// receiver in first_success delivery mode failed to deliver the notification.
+	Failover bool `json:"failover,omitempty"`
+}
+
+const (
+	NotificationDeliveryDelivered = "delivered"
+	NotificationDeliveryFailed    = "failed"
+)
@@ -38,6 +38,30 @@ import (
 //     Responses:
 //       204: description: The template was deleted successfully.
 
+// swagger:route POST /api/v1/provisioning/templates/import/preview provisioning stable RoutePostTemplatePackPreview
+//
+// Preview the import of a pack of templates, without changing the templates.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: TemplatePackImportResult
+//       400: ValidationError
+//       409: TemplatePackImportResult
+
+// swagger:route POST /api/v1/provisioning/templates/import provisioning stable RoutePostTemplatePackImport
+//
+// Import a pack of templates.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: TemplatePackImportResult
+//       400: ValidationError
+//       409: TemplatePackImportResult
+
 // swagger:parameters RouteGetTemplate RoutePutTemplate RouteDeleteTemplate
 type RouteGetTemplateParam struct {
 	// Template Name
@@ -65,6 +89,98 @@ type MessageTemplatePayload struct {
 	Body MessageTemplateContent
 }
 
+// swagger:parameters RoutePostTemplatePackPreview RoutePostTemplatePackImport
+type TemplatePackImportPayload struct {
+	// in:body
+	Body TemplatePackImport
+}
+
+// TemplatePackConflicts are the ways to resolve the conflicts between the templates of a pack and
+// the existing templates with the same names and different contents.
+type TemplatePackConflicts string
+
+const (
+	// TemplatePackConflictsFail fails the import if there are conflicts.
+	TemplatePackConflictsFail TemplatePackConflicts = "fail"
+	// TemplatePackConflictsSkip keeps the existing templates.
+	TemplatePackConflictsSkip TemplatePackConflicts = "skip"
+	// TemplatePackConflictsOverwrite replaces the existing templates.
+	TemplatePackConflictsOverwrite TemplatePackConflicts = "overwrite"
+	// TemplatePackConflictsRename imports the templates under the name of the pack.
+	TemplatePackConflictsRename TemplatePackConflicts = "rename"
+)
+
+// swagger:model
+type TemplatePackImport struct {
+	// required: true
+	Bundle TemplatePackBundle `json:"bundle"`
+	// How to resolve the conflicts with the existing templates, fail by default.
+	// enum: fail,skip,overwrite,rename
+	Conflicts TemplatePackConflicts `json:"conflicts,omitempty"`
+}
+
+// TemplatePackBundle is a signed pack of templates. The signature is the Ed25519 signature of the
+// payload, which is the JSON of the TemplatePack.
+// swagger:model
+type TemplatePackBundle struct {
+	// required: true
+	Payload string `json:"payload"`
+	// The ID of the trusted key that signed the payload.
+	KeyID string `json:"keyId,omitempty"`
+	// The base64 encoded signature of the payload.
+	Signature string `json:"signature,omitempty"`
+}
+
+// swagger:model
+type TemplatePack struct {
+	Name        string                 `json:"name"`
+	Version     string                 `json:"version,omitempty"`
+	Author      string                 `json:"author,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Templates   []TemplatePackTemplate `json:"templates"`
+}
+
+// swagger:model
+type TemplatePackTemplate struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+}
+
+// TemplatePackImportAction is what the import of a pack does to one of its templates.
+type TemplatePackImportAction string
+
+const (
+	TemplatePackImportCreate    TemplatePackImportAction = "create"
+	TemplatePackImportUpdate    TemplatePackImportAction = "update"
+	TemplatePackImportUnchanged TemplatePackImportAction = "unchanged"
+	TemplatePackImportSkip      TemplatePackImportAction = "skip"
+	TemplatePackImportConflict  TemplatePackImportAction = "conflict"
+)
+
+// swagger:model
+type TemplatePackImportResult struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Description string `json:"description,omitempty"`
+	// The ID of the key that signed the pack, empty if it is not signed.
+	KeyID     string                       `json:"keyId,omitempty"`
+	Templates []TemplatePackImportTemplate `json:"templates"`
+}
+
+// swagger:model
+type TemplatePackImportTemplate struct {
+	// The name of the template after the import.
+	Name string `json:"name"`
+	// The name of the template in the pack, if it is renamed.
+	PackName string `json:"packName,omitempty"`
+	// enum: create,update,unchanged,skip,conflict
+	Action   TemplatePackImportAction `json:"action"`
+	Template string                   `json:"template"`
+	// The existing template with the same name, if it is different.
+	Existing string `json:"existing,omitempty"`
+}
+
 func (t *MessageTemplate) ResourceType() string {
 	return "template"
 }
@@ -73,6 +73,9 @@ type API struct {
 type Alertmanager struct {
 	Registerer prometheus.Registerer
 	*metrics.Alerts
+	ReceiverNotifications       *prometheus.CounterVec
+	ReceiverNotificationsFailed *prometheus.CounterVec
+	ReceiverNotificationLatency *prometheus.HistogramVec
 }
 
 type State struct {
@@ -112,6 +115,34 @@ func NewAlertmanagerMetrics(r prometheus.Registerer) *Alertmanager {
 	return &Alertmanager{
 		Registerer: r,
 		Alerts:     metrics.NewAlerts("grafana", prometheus.WrapRegistererWithPrefix(fmt.Sprintf("%s_%s_", Namespace, Subsystem), r)),
+		ReceiverNotifications: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "receiver_notifications_total",
+				Help:      "The total number of notifications sent by each integration of a contact point.",
+			},
+			[]string{"receiver", "integration"},
+		),
+		ReceiverNotificationsFailed: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "receiver_notifications_failed_total",
+				Help:      "The total number of notifications that each integration of a contact point failed to send.",
+			},
+			[]string{"receiver", "integration"},
+		),
+		ReceiverNotificationLatency: promauto.With(r).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "receiver_notification_latency_seconds",
+				Help:      "The latency of the notifications of each integration of a contact point, retries included.",
+				Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 25, 60},
+			},
+			[]string{"receiver", "integration"},
+		),
 	}
 }
 
@@ -120,6 +151,11 @@ func (moa *MultiOrgAlertmanager) RemoveOrgRegistry(id int64) {
 	moa.registries.RemoveOrgRegistry(id)
 }
 
+// OrgGatherers returns the gatherer of the registry of each org. It is safe to call concurrently.
+func (moa *MultiOrgAlertmanager) OrgGatherers() map[int64]prometheus.Gatherer {
+	return moa.registries.Gatherers()
+}
+
 // GetOrCreateOrgRegistry gets or creates a *prometheus.Registry for the specified org. It is safe to call concurrently.
 func (moa *MultiOrgAlertmanager) GetOrCreateOrgRegistry(id int64) prometheus.Registerer {
 	return moa.registries.GetOrCreateOrgRegistry(id)
@@ -294,6 +330,20 @@ func (m *OrgRegistries) GetOrCreateOrgRegistry(orgID int64) prometheus.Registere
 	return orgRegistry
 }
 
+// Gatherers returns the gatherer of the registry of each org. It is safe to call concurrently.
+func (m *OrgRegistries) Gatherers() map[int64]prometheus.Gatherer {
+	m.regsMu.Lock()
+	defer m.regsMu.Unlock()
+
+	gatherers := make(map[int64]prometheus.Gatherer, len(m.regs))
+	for orgID, reg := range m.regs {
+		if g, ok := reg.(prometheus.Gatherer); ok {
+			gatherers[orgID] = g
+		}
+	}
+	return gatherers
+}
+
 // RemoveOrgRegistry removes the *prometheus.Registry for the specified org. It is safe to call concurrently.
 func (m *OrgRegistries) RemoveOrgRegistry(org int64) {
 	m.regsMu.Lock()
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 )
 
 type AlertmanagersChoice int
@@ -34,11 +35,24 @@ type AdminConfiguration struct {
 
 	CreatedAt int64 `xorm:"created"`
 	UpdatedAt int64 `xorm:"updated"`
+
+	// Credentials of the Alertmanager(s) by URL, for the ones whose credentials are not in the
+	// URL. They are not persisted: they come from the credentials referenced by the data sources.
+	Credentials map[string]*AlertmanagerHTTPCredentials `xorm:"-"`
 }
 
 func (ac *AdminConfiguration) AsSHA256() string {
 	h := sha256.New()
 	_, _ = h.Write([]byte(fmt.Sprintf("%v", ac.Alertmanagers)))
+	// The credentials are part of the hash so that their rotation is applied.
+	urls := make([]string, 0, len(ac.Credentials))
+	for u := range ac.Credentials {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		_, _ = h.Write([]byte(u + ac.Credentials[u].hashInput()))
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
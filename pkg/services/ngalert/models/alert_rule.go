@@ -91,6 +91,14 @@ const (
 	//nolint:gosec
 	ImageTokenAnnotation = "__alertImageToken__"
 
+	// DryRunAnnotation set to "true" on a rule renders and records the notifications of its alerts
+	// in the dispatch history of the contact points without sending them.
+	DryRunAnnotation = "__dryRun__"
+
+	// DatasourceUIDsAnnotation lists the UIDs of the data sources queried by the rule of an alert,
+	// separated by commas.
+	DatasourceUIDsAnnotation = "__datasourceUids__"
+
 	// GrafanaReservedLabelPrefix contains the prefix for Grafana reserved labels. These differ from "__<label>__" labels
 	// in that they are not meant for internal-use only and will be passed-through to AMs and available to users in the same
 	// way as manually configured labels.
@@ -178,6 +186,23 @@ func (alertRule *AlertRule) GetEvalCondition() Condition {
 	}
 }
 
+// DatasourceUIDs returns the sorted UIDs of the data sources queried by the rule, without the
+// expressions.
+func (alertRule *AlertRule) DatasourceUIDs() []string {
+	set := make(map[string]struct{}, len(alertRule.Data))
+	for i := range alertRule.Data {
+		if isExpr, _ := alertRule.Data[i].IsExpression(); !isExpr && alertRule.Data[i].DatasourceUID != "" {
+			set[alertRule.Data[i].DatasourceUID] = struct{}{}
+		}
+	}
+	uids := make([]string, 0, len(set))
+	for uid := range set {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
+
 // Diff calculates diff between two alert rules. Returns nil if two rules are equal. Otherwise, returns cmputil.DiffReport
 func (alertRule *AlertRule) Diff(rule *AlertRule, ignore ...string) cmputil.DiffReport {
 	var reporter cmputil.DiffReporter
@@ -597,3 +597,15 @@ func TestTimeRangeYAML(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, yamlRaw, string(serialized))
 }
+
+func TestAlertRuleDatasourceUIDs(t *testing.T) {
+	rule := AlertRuleGen()()
+	rule.Data = []AlertQuery{
+		{RefID: "A", DatasourceUID: "prometheus"},
+		{RefID: "B", DatasourceUID: "loki"},
+		{RefID: "C", DatasourceUID: "prometheus"},
+		{RefID: "D", DatasourceUID: "__expr__"},
+		{RefID: "E", DatasourceUID: "-100"},
+	}
+	require.Equal(t, []string{"loki", "prometheus"}, rule.DatasourceUIDs())
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrExternalAlertmanagerCredentialsNotFound is returned when the credentials do not exist.
+	ErrExternalAlertmanagerCredentialsNotFound = errors.New("external Alertmanager credentials not found")
+	// ErrExternalAlertmanagerCredentialsConflict is returned when credentials of the organization
+	// have the same name or UID.
+	ErrExternalAlertmanagerCredentialsConflict = errors.New("external Alertmanager credentials with the same name or UID already exist")
+)
+
+// The keys of the secure settings of external Alertmanager credentials. The values of the HTTP
+// headers are stored under the name of the header with the prefix SecureHeaderPrefix.
+const (
+	SecureBasicAuthPassword = "basicAuthPassword"
+	SecureTLSCACert         = "tlsCACert"
+	SecureTLSClientCert     = "tlsClientCert"
+	SecureTLSClientKey      = "tlsClientKey"
+	SecureHeaderPrefix      = "httpHeader:"
+)
+
+// ExternalAlertmanagerCredentials are the credentials that Grafana uses to send the alerts of an
+// organization to an external Alertmanager. Alertmanager data sources reference them by UID, so
+// that their secrets are encrypted with a key of the organization and rotated in one place.
+type ExternalAlertmanagerCredentials struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	OrgID int64  `xorm:"org_id"`
+	UID   string `xorm:"uid"`
+	Name  string `xorm:"name"`
+
+	BasicAuthUser string `xorm:"basic_auth_user"`
+	TLSServerName string `xorm:"tls_server_name"`
+	TLSSkipVerify bool   `xorm:"tls_skip_verify"`
+
+	// SecureSettings are the encrypted secrets: the basic auth password, the TLS certificates
+	// and key, and the values of the HTTP headers.
+	SecureSettings map[string][]byte `xorm:"secure_settings"`
+
+	// Version is incremented each time the secrets are rotated. Its column name is quoted, so that
+	// xorm does not use it as an optimistic lock.
+	Version   int64 `xorm:"'version'"`
+	RotatedAt int64 `xorm:"rotated_at"`
+	CreatedAt int64 `xorm:"created"`
+	UpdatedAt int64 `xorm:"updated"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (c *ExternalAlertmanagerCredentials) TableName() string {
+	return "ngalert_alertmanager_credentials"
+}
+
+// SecureFields returns the keys of the secure settings that are set.
+func (c *ExternalAlertmanagerCredentials) SecureFields() map[string]bool {
+	fields := make(map[string]bool, len(c.SecureSettings))
+	for k := range c.SecureSettings {
+		fields[k] = true
+	}
+	return fields
+}
+
+// HTTPCredentials returns the credentials with the decrypted secure settings.
+func (c *ExternalAlertmanagerCredentials) HTTPCredentials(secrets map[string]string) *AlertmanagerHTTPCredentials {
+	creds := &AlertmanagerHTTPCredentials{
+		UID:               c.UID,
+		BasicAuthUser:     c.BasicAuthUser,
+		BasicAuthPassword: secrets[SecureBasicAuthPassword],
+		TLSCACert:         secrets[SecureTLSCACert],
+		TLSClientCert:     secrets[SecureTLSClientCert],
+		TLSClientKey:      secrets[SecureTLSClientKey],
+		TLSServerName:     c.TLSServerName,
+		TLSSkipVerify:     c.TLSSkipVerify,
+	}
+	for k, v := range secrets {
+		if name := strings.TrimPrefix(k, SecureHeaderPrefix); name != k {
+			if creds.Headers == nil {
+				creds.Headers = map[string]string{}
+			}
+			creds.Headers[name] = v
+		}
+	}
+	return creds
+}
+
+// ValidateSecureSettings checks that the keys of the secrets are known.
+func ValidateSecureSettings(secrets map[string]string) error {
+	for k := range secrets {
+		switch {
+		case k == SecureBasicAuthPassword, k == SecureTLSCACert, k == SecureTLSClientCert, k == SecureTLSClientKey:
+		case strings.HasPrefix(k, SecureHeaderPrefix) && len(k) > len(SecureHeaderPrefix):
+		default:
+			return fmt.Errorf("unknown secure setting '%s'", k)
+		}
+	}
+	return nil
+}
+
+// AlertmanagerHTTPCredentials are the decrypted credentials used to send alerts to an external
+// Alertmanager, when they are not in its URL.
+type AlertmanagerHTTPCredentials struct {
+	UID               string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	Headers           map[string]string
+	TLSCACert         string
+	TLSClientCert     string
+	TLSClientKey      string
+	TLSServerName     string
+	TLSSkipVerify     bool
+}
+
+// hashInput returns the credentials in a stable form, to detect their changes. The secrets are
+// part of it, so it must never be logged.
+func (c *AlertmanagerHTTPCredentials) hashInput() string {
+	names := make([]string, 0, len(c.Headers))
+	for name := range c.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]string, 0, len(names))
+	for _, name := range names {
+		headers = append(headers, name+"="+c.Headers[name])
+	}
+	return fmt.Sprintf("%s|%s|%s|%v|%s|%s|%s|%s|%t", c.UID, c.BasicAuthUser, c.BasicAuthPassword, headers,
+		c.TLSCACert, c.TLSClientCert, c.TLSClientKey, c.TLSServerName, c.TLSSkipVerify)
+}
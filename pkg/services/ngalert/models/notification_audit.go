@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// NotificationAuditEntry is a notification sent by an integration of a receiver, with its
+// rendered title and the alerts it was sent for. It is recorded in the notification audit log.
+type NotificationAuditEntry struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	// SentAt is when the notification was sent, in milliseconds since the epoch.
+	SentAt int64  `xorm:"sent_at"`
+	Title  string `xorm:"title"`
+	// Fingerprints are the comma-separated fingerprints of the alerts of the notification.
+	Fingerprints string `xorm:"fingerprints"`
+	Alerts       int    `xorm:"alerts"`
+	// Result is delivered or failed, and Error the error of a failed notification.
+	Result string `xorm:"result"`
+	Error  string `xorm:"error"`
+}
+
+const (
+	NotificationAuditDelivered = "delivered"
+	NotificationAuditFailed    = "failed"
+)
+
+// A XORM interface that defines the used table for this struct.
+func (e *NotificationAuditEntry) TableName() string {
+	return "ngalert_notification_audit"
+}
+
+// GetNotificationAuditQuery is the query of the audit log of an organization in a time range, the
+// most recent entries first.
+type GetNotificationAuditQuery struct {
+	OrgID int64
+	From  time.Time
+	To    time.Time
+	// Receiver filters the entries if not empty.
+	Receiver string
+	// Fingerprint filters the entries of the notifications sent for an alert if not empty. It
+	// must be a full fingerprint.
+	Fingerprint string
+	Limit       int
+}
@@ -0,0 +1,53 @@
+package models
+
+import "errors"
+
+// ErrNotificationDeadLetterNotFound is returned when a dead letter does not exist in the
+// organization.
+var ErrNotificationDeadLetterNotFound = errors.New("notification dead letter not found")
+
+// NotificationDeadLetter is a notification that an integration of a receiver failed to deliver
+// after all its retries. It is kept with its alerts so that it can be replayed once the
+// destination recovers.
+type NotificationDeadLetter struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	// GroupKey and GroupLabels are the key and the labels of the aggregation group of the
+	// notification, and Alerts its alerts, encoded in JSON.
+	GroupKey    string `xorm:"group_key"`
+	GroupLabels string `xorm:"group_labels"`
+	Alerts      string `xorm:"alerts"`
+	// FailedAt is when the notification last failed, in milliseconds since the epoch, and Error
+	// its last error.
+	FailedAt int64  `xorm:"failed_at"`
+	Error    string `xorm:"error"`
+	// Replays is the number of replays of the notification that failed too.
+	Replays int `xorm:"replays"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (d *NotificationDeadLetter) TableName() string {
+	return "ngalert_notification_dead_letter"
+}
+
+// GetNotificationDeadLettersQuery is the query of the dead letters of an organization, the most
+// recent first.
+type GetNotificationDeadLettersQuery struct {
+	OrgID int64
+	// Receiver filters the dead letters if not empty.
+	Receiver string
+	Limit    int
+}
+
+// DeleteGroupNotificationDeadLetterCommand deletes the dead letter of a group of alerts and an
+// integration, for instance when a later notification of the group is delivered.
+type DeleteGroupNotificationDeadLetterCommand struct {
+	OrgID            int64
+	Receiver         string
+	Integration      string
+	IntegrationIndex int
+	GroupKey         string
+}
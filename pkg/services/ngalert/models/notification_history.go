@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// NotificationDelivery is the delivery of a notification by an integration of a receiver. It is
+// recorded in the notification history, which can be queried in Explore with the Grafana data
+// source.
+type NotificationDelivery struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	// StartedAt is when the delivery started, in milliseconds since the epoch.
+	StartedAt  int64   `xorm:"started_at"`
+	DurationMs float64 `xorm:"duration_ms"`
+	Alerts     int     `xorm:"alerts"`
+	Attempts   int     `xorm:"attempts"`
+	// StatusCode is the status code of the response to the last attempt, 0 if the integration
+	// does not send webhooks or got no response.
+	StatusCode int `xorm:"status_code"`
+	// Error is empty if the notification was delivered.
+	Error string `xorm:"error"`
+	// Canceled is set when the notification was not delivered because it was cancelled or timed
+	// out, such as when Grafana shuts down, rather than rejected by the integration.
+	Canceled bool `xorm:"canceled"`
+	// Failover is set when the integration was tried because the previous integrations of a
+	// receiver in first_success delivery mode failed to deliver the notification.
+	Failover bool `xorm:"failover"`
+	// DispatchID identifies the dispatch in the tracking URLs of the alert emails.
+	DispatchID string `xorm:"dispatch_id"`
+	// EmailOpens and EmailClicks are the number of times the email sent by the delivery was
+	// opened and its links clicked, and EmailOpenedAt when it was first opened, in milliseconds
+	// since the epoch, or 0. They are only recorded if the organization tracks its alert emails.
+	EmailOpens    int   `xorm:"email_opens"`
+	EmailClicks   int   `xorm:"email_clicks"`
+	EmailOpenedAt int64 `xorm:"email_opened_at"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (d *NotificationDelivery) TableName() string {
+	return "ngalert_notification_history"
+}
+
+// GetNotificationDeliveriesQuery is the query of the deliveries of an organization in a time range.
+type GetNotificationDeliveriesQuery struct {
+	OrgID int64
+	From  time.Time
+	To    time.Time
+	// Receiver and Integration filter the deliveries if not empty.
+	Receiver    string
+	Integration string
+	// Limit is the maximum number of deliveries, the oldest first unless Latest is set.
+	Limit int
+	// Latest returns the most recent deliveries first.
+	Latest bool
+}
+
+// TrackNotificationDeliveryEmailCommand records that the email sent by a delivery was opened, or
+// that one of its links was clicked.
+type TrackNotificationDeliveryEmailCommand struct {
+	OrgID      int64
+	DispatchID string
+	// At is when the email was opened or clicked, in milliseconds since the epoch.
+	At    int64
+	Click bool
+}
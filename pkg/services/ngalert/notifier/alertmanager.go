@@ -35,12 +35,14 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
+	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -89,6 +91,9 @@ type ClusterPeer interface {
 type AlertingStore interface {
 	store.AlertingStore
 	store.ImageStore
+	store.NotificationHistoryStore
+	store.NotificationAuditStore
+	store.NotificationDeadLetterStore
 }
 
 type Alertmanager struct {
@@ -97,8 +102,11 @@ type Alertmanager struct {
 	Settings            *setting.Cfg
 	Store               AlertingStore
 	fileStore           *FileStore
+	channelStore        *kvstore.NamespacedKVStore
+	receiverOwners      *ReceiverOwnerStore
 	Metrics             *metrics.Alertmanager
 	NotificationService notifications.Service
+	PreferenceService   pref.Service
 
 	notificationLog *nflog.Log
 	marker          types.Marker
@@ -107,6 +115,9 @@ type Alertmanager struct {
 	peer            ClusterPeer
 	peerTimeout     time.Duration
 
+	// policyPreferences are the timezone and locale of the notification policies of route.
+	policyPreferences policyPreferences
+
 	dispatcher *dispatch.Dispatcher
 	inhibitor  *inhibit.Inhibitor
 	// wg is for dispatcher, inhibitor, silences and notifications
@@ -124,6 +135,24 @@ type Alertmanager struct {
 
 	stageMetrics      *notify.Metrics
 	dispatcherMetrics *dispatch.DispatcherMetrics
+	profiles          *dispatchProfiles
+	contactPoints     *contactPointStates
+	digests           *digests
+	dashboardMetadata *dashboardMetadataStage
+	orgName           notify.Stage
+	emailTracking     *emailTracking
+	drainer           *drainer
+	// history is nil if the notification history is disabled.
+	history *notificationHistory
+	// audit is nil if the audit log of the notifications is disabled.
+	audit *notificationAudit
+	// deadLetters is nil if the dead letters of the notifications are disabled.
+	deadLetters *notificationDeadLetters
+	// integrations are the integrations of the receivers of the current configuration, to replay
+	// the dead letters.
+	integrations map[string][]notify.Integration
+	// cassettes is nil unless the development VCR mode is enabled.
+	cassettes *cassettes
 
 	reloadConfigMtx sync.RWMutex
 	config          *apimodels.PostableUserConfig
@@ -134,7 +163,7 @@ type Alertmanager struct {
 }
 
 func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store AlertingStore, kvStore kvstore.KVStore,
-	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, m *metrics.Alertmanager) (*Alertmanager, error) {
+	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, prefs pref.Service, dashboards dashboards.DashboardService, orgs store.OrgStore, m *metrics.Alertmanager) (*Alertmanager, error) {
 	am := &Alertmanager{
 		Settings:            cfg,
 		stopc:               make(chan struct{}),
@@ -142,16 +171,27 @@ func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		marker:              types.NewMarker(m.Registerer),
 		stageMetrics:        notify.NewMetrics(m.Registerer),
 		dispatcherMetrics:   dispatch.NewDispatcherMetrics(false, m.Registerer),
+		profiles:            newDispatchProfiles(),
+		contactPoints:       newContactPointStates(),
+		dashboardMetadata:   newDashboardMetadataStage(orgID, dashboards),
 		Store:               store,
+		orgName:             newOrgNameStage(orgID, orgs, log.New("alertmanager", "org", orgID)),
+		emailTracking:       newEmailTracking(orgID, cfg.UnifiedAlerting.EmailTrackingOrgs, cfg.AppURL, cfg.SecretKey),
+		cassettes:           newCassettes(orgID, cfg.UnifiedAlerting.NotifierVCRMode, cfg.UnifiedAlerting.NotifierVCRDir),
 		peer:                peer,
 		peerTimeout:         cfg.UnifiedAlerting.HAPeerTimeout,
 		Metrics:             m,
 		NotificationService: ns,
+		PreferenceService:   prefs,
 		orgID:               orgID,
 		decryptFn:           decryptFn,
 	}
 
+	am.digests = newDigests(am.logger)
 	am.fileStore = NewFileStore(am.orgID, kvStore, am.WorkingDirPath())
+	am.channelStore = kvstore.WithNamespace(kvStore, am.orgID, KVNamespace)
+	am.receiverOwners = NewReceiverOwnerStore(kvStore)
+	am.drainer = newDrainer(newUndeliveredStore(am.orgID, kvStore), am.logger)
 
 	nflogFilepath, err := am.fileStore.FilepathFor(ctx, notificationLogFilename)
 	if err != nil {
@@ -211,6 +251,18 @@ func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		return nil, fmt.Errorf("unable to initialize the alert provider component of alerting: %w", err)
 	}
 
+	// Put back the alerts that were not notified when the Alertmanager stopped, the notification log
+	// prevents the alerts that were notified from being sent twice.
+	undelivered, err := am.drainer.store.pop(ctx)
+	if err != nil {
+		am.logger.Error("failed to load the undelivered alerts", "err", err)
+	} else if len(undelivered) > 0 {
+		if err := am.alerts.Put(undelivered...); err != nil {
+			return nil, fmt.Errorf("unable to restore the undelivered alerts: %w", err)
+		}
+		am.logger.Info("restored undelivered alerts", "alerts", len(undelivered))
+	}
+
 	return am, nil
 }
 
@@ -228,7 +280,15 @@ func (am *Alertmanager) ready() bool {
 	return am.config != nil
 }
 
+// drain stops accepting new notifications, the notifications in flight are given until the drain
+// timeout to be delivered before the Alertmanager is stopped.
+func (am *Alertmanager) drain() {
+	am.drainer.start(am.Settings.UnifiedAlerting.NotificationDrainTimeout)
+}
+
 func (am *Alertmanager) StopAndWait() {
+	am.drain()
+
 	if am.dispatcher != nil {
 		am.dispatcher.Stop()
 	}
@@ -238,6 +298,7 @@ func (am *Alertmanager) StopAndWait() {
 	}
 
 	am.alerts.Close()
+	am.digests.stop()
 
 	close(am.stopc)
 
@@ -421,12 +482,44 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	inhibitionStage := notify.NewMuteStage(am.inhibitor)
 	timeMuteStage := notify.NewTimeMuteStage(am.muteTimes)
 	silencingStage := notify.NewMuteStage(am.silencer)
+	route := dispatch.NewRoute(cfg.AlertmanagerConfig.Route.AsAMRoute(), nil)
+	am.policyPreferences = newPolicyPreferences(cfg.AlertmanagerConfig.Route, route)
+	orgPreferencesStage := newOrgPreferencesStage(am.orgID, am.PreferenceService, am.policyPreferences, am.logger)
+	am.profiles.removeExcept(integrationsMap)
+	deliveryModes := make(map[string]apimodels.DeliveryMode, len(cfg.AlertmanagerConfig.Receivers))
+	digestConfigs := map[string]*apimodels.DigestConfig{}
+	digestReceivers := map[string]struct{}{}
+	uids := map[string]struct{}{}
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		deliveryModes[r.Name] = r.DeliveryMode
+		if r.Digest != nil {
+			digestConfigs[r.Name] = r.Digest
+			digestReceivers[r.Name] = struct{}{}
+		}
+		for _, gr := range r.GrafanaManagedReceivers {
+			uids[gr.UID] = struct{}{}
+		}
+	}
+	am.contactPoints.removeExcept(uids)
+	am.digests.removeExcept(digestReceivers)
 	for name := range integrationsMap {
-		stage := am.createReceiverStage(name, integrationsMap[name], am.waitFunc, am.notificationLog)
-		routingStage[name] = notify.MultiStage{meshStage, silencingStage, timeMuteStage, inhibitionStage, stage}
+		live := am.createReceiverStage(name, integrationsMap[name], deliveryModes[name], false, am.waitFunc, am.notificationLog)
+		if cfg := digestConfigs[name]; cfg != nil {
+			// Dry runs are not accumulated, to show what each notification would send.
+			live = am.digests.stage(name, *cfg, live)
+		}
+		stage := dryRunStage{
+			live:    live,
+			dryRun:  am.createReceiverStage(name, integrationsMap[name], deliveryModes[name], true, am.waitFunc, am.notificationLog),
+			folders: am.Settings.UnifiedAlerting.DryRunFolders,
+		}
+		routingStage[name] = drainingStage{
+			stage:   notify.MultiStage{meshStage, silencingStage, timeMuteStage, inhibitionStage, orgPreferencesStage, am.orgName, am.dashboardMetadata, stage},
+			drainer: am.drainer,
+		}
 	}
 
-	am.route = dispatch.NewRoute(cfg.AlertmanagerConfig.Route.AsAMRoute(), nil)
+	am.route = route
 	am.dispatcher = dispatch.NewDispatcher(am.alerts, am.route, routingStage, am.marker, am.timeoutFunc, &nilLimits{}, am.logger, am.dispatcherMetrics)
 
 	am.wg.Add(1)
@@ -443,6 +536,8 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 
 	am.config = cfg
 	am.configHash = md5.Sum(rawConfig)
+	am.integrations = integrationsMap
+	am.reconcileReceiverOwners(cfg)
 
 	return nil
 }
@@ -473,7 +568,16 @@ func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableAp
 		if err != nil {
 			return nil, err
 		}
-		integrations = append(integrations, notify.NewIntegration(n, n, r.Type, i))
+		// Test notifications are built without the resolved suppression, as they are sent at once,
+		// and without the circuit breaker and the rate limit, to probe the contact point. The
+		// throttled notifications do not count as failures of the contact point.
+		n = channels.NewResolvedSuppressingNotifier(n, r.Settings)
+		n = channels.NewCircuitBreakingNotifier(n, am.contactPoints.circuitBreaker(r.UID, r.Type, r.Settings))
+		n = channels.NewThrottlingNotifier(n, am.contactPoints.throttle(r.UID, r.Type, r.Settings))
+		// The title of the notifications is rendered for the audit log before they are throttled,
+		// so that the notifications that are not sent are recorded with their title too.
+		audited := newAuditingNotifier(n, r.Settings, tmpl, am.logger)
+		integrations = append(integrations, notify.NewIntegration(profilingNotifier{audited}, n, r.Type, i))
 	}
 	return integrations, nil
 }
@@ -511,6 +615,9 @@ func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaRec
 			Err:      err,
 		}
 	}
+	if am.channelStore != nil {
+		factoryConfig.KVStore = am.channelStore
+	}
 	receiverFactory, exists := channels.Factory(r.Type)
 	if !exists {
 		return nil, InvalidReceiverError{
@@ -666,24 +773,65 @@ func (e AlertValidationError) Error() string {
 	return errMsg
 }
 
-// createReceiverStage creates a pipeline of stages for a receiver.
-func (am *Alertmanager) createReceiverStage(name string, integrations []notify.Integration, wait func() time.Duration, notificationLog notify.NotificationLog) notify.Stage {
-	var fs notify.FanoutStage
+// createReceiverStage creates a pipeline of stages for a receiver. The integrations are notified
+// in parallel, or one after the other until one succeeds with the first success delivery mode.
+// In a dry run the notifications are recorded in the dispatch profiles instead of being sent.
+func (am *Alertmanager) createReceiverStage(name string, integrations []notify.Integration, mode apimodels.DeliveryMode, dryRun bool, wait func() time.Duration, notificationLog notify.NotificationLog) notify.Stage {
+	groupName := name
+	if dryRun {
+		groupName += dryRunGroupSuffix
+	}
+	var stages, notifies []notify.Stage
 	for i := range integrations {
 		recv := &nflogpb.Receiver{
-			GroupName:   name,
+			GroupName:   groupName,
 			Integration: integrations[i].Name(),
 			Idx:         uint32(integrations[i].Index()),
 		}
 		var s notify.MultiStage
-		s = append(s, notify.NewWaitStage(wait))
+		if mode != apimodels.DeliveryModeFirstSuccess {
+			s = append(s, notify.NewWaitStage(wait))
+		}
 		s = append(s, notify.NewDedupStage(&integrations[i], notificationLog, recv))
-		s = append(s, notify.NewRetryStage(integrations[i], name, am.stageMetrics))
-		s = append(s, notify.NewSetNotifiesStage(notificationLog, recv))
+		var send notify.Stage = notify.NewRetryStage(integrations[i], name, am.stageMetrics)
+		if dryRun {
+			send = dryRunNotifyStage{integration: integrations[i]}
+		} else {
+			if am.audit != nil {
+				send = auditStage{receiver: name, integration: integrations[i], stage: send, audit: am.audit, orgID: am.orgID}
+			}
+			if am.deadLetters != nil {
+				send = deadLetterStage{receiver: name, integration: integrations[i], stage: send, deadLetters: am.deadLetters, drainer: am.drainer, orgID: am.orgID, logger: am.logger}
+			}
+		}
+		s = append(s, profilingStage{
+			receiver:      name,
+			integration:   integrations[i],
+			stage:         send,
+			profiles:      am.profiles,
+			dryRun:        dryRun,
+			emailTracking: am.emailTracking,
+			metrics:       am.Metrics,
+			history:       am.history,
+			cassettes:     am.cassettes,
+			orgID:         am.orgID,
+		})
+		setNotifies := notify.NewSetNotifiesStage(notificationLog, recv)
+		s = append(s, setNotifies)
 
-		fs = append(fs, s)
+		stages = append(stages, s)
+		notifies = append(notifies, setNotifies)
+	}
+
+	if mode == apimodels.DeliveryModeFirstSuccess {
+		// The integrations are tried in order, so the wait for the position in the cluster only
+		// happens once.
+		return notify.MultiStage{
+			notify.NewWaitStage(wait),
+			firstSuccessStage{receiver: name, stages: stages, notifies: notifies, logger: am.logger},
+		}
 	}
-	return fs
+	return notify.FanoutStage(stages)
 }
 
 func (am *Alertmanager) waitFunc() time.Duration {
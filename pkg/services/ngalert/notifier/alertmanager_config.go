@@ -75,6 +75,8 @@ func (moa *MultiOrgAlertmanager) GetAlertmanagerConfiguration(ctx context.Contex
 		gettableApiReceiver := definitions.GettableApiReceiver{
 			GettableGrafanaReceivers: definitions.GettableGrafanaReceivers{
 				GrafanaManagedReceivers: receivers,
+				DeliveryMode:            recv.DeliveryMode,
+				Digest:                  recv.Digest,
 			},
 		}
 		gettableApiReceiver.Name = recv.Name
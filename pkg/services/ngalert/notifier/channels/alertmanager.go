@@ -2,6 +2,7 @@ package channels
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ type AlertmanagerConfig struct {
 	URLs              []*url.URL
 	BasicAuthUser     string
 	BasicAuthPassword string
+	TLSConfig         *tls.Config
 }
 
 func NewAlertmanagerConfig(config *NotificationChannelConfig, fn GetDecryptedValueFn) (*AlertmanagerConfig, error) {
@@ -47,11 +49,16 @@ func NewAlertmanagerConfig(config *NotificationChannelConfig, fn GetDecryptedVal
 		}
 		urls = append(urls, url)
 	}
+	tlsConfig, err := clientTLSFromSettings(config, fn)
+	if err != nil {
+		return nil, err
+	}
 	return &AlertmanagerConfig{
 		NotificationChannelConfig: config,
 		URLs:                      urls,
 		BasicAuthUser:             config.Settings.Get("basicAuthUser").MustString(),
 		BasicAuthPassword:         fn(context.Background(), config.SecureSettings, "basicAuthPassword", config.Settings.Get("basicAuthPassword").MustString()),
+		TLSConfig:                 tlsConfig,
 	}, nil
 }
 
@@ -79,6 +86,8 @@ func NewAlertmanagerNotifier(config *AlertmanagerConfig, images ImageStore, _ *t
 		urls:              config.URLs,
 		basicAuthUser:     config.BasicAuthUser,
 		basicAuthPassword: config.BasicAuthPassword,
+		proxy:             config.Proxy,
+		tlsConfig:         config.TLSConfig,
 		logger:            log.New("alerting.notifier.prometheus-alertmanager"),
 	}
 }
@@ -91,6 +100,8 @@ type AlertmanagerNotifier struct {
 	urls              []*url.URL
 	basicAuthUser     string
 	basicAuthPassword string
+	proxy             *ProxyConfig
+	tlsConfig         *tls.Config
 	logger            log.Logger
 }
 
@@ -121,11 +132,19 @@ func (n *AlertmanagerNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 		numErrs int
 	)
 	for _, u := range n.urls {
-		if _, err := sendHTTPRequest(ctx, u, httpCfg{
-			user:     n.basicAuthUser,
-			password: n.basicAuthPassword,
-			body:     body,
-		}, n.logger); err != nil {
+		if recordDryRun(ctx, urlTarget(u.String()), string(body)) {
+			continue
+		}
+		cfg := httpCfg{
+			user:      n.basicAuthUser,
+			password:  n.basicAuthPassword,
+			body:      body,
+			tlsConfig: n.tlsConfig,
+		}
+		if n.proxy != nil {
+			cfg.proxy = n.proxy.ProxyFunc()
+		}
+		if _, err := sendHTTPRequest(ctx, u, cfg, n.logger); err != nil {
 			n.logger.Warn("failed to send to Alertmanager", "err", err, "alertmanager", n.Name, "url", u.String())
 			lastErr = err
 			numErrs++
@@ -0,0 +1,274 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// amqpChannel is the subset of *amqp.Channel used by the AMQP notifier.
+type amqpChannel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// amqpConnectionTimeout is the timeout of the connection to the broker and of its handshake, as
+// in the AMQP client, unless the context of the notification expires first.
+const amqpConnectionTimeout = 30 * time.Second
+
+// amqpOpenChannel connects to the broker and opens a channel. The returned function closes
+// both the channel and the connection. Can be overwritten in tests.
+var amqpOpenChannel = func(ctx context.Context, brokerURL string, tlsConfig *tls.Config) (amqpChannel, func(), error) {
+	conn, err := amqp.DialConfig(brokerURL, amqp.Config{
+		Heartbeat:       10 * time.Second,
+		Locale:          "en_US",
+		TLSClientConfig: tlsConfig,
+		Dial:            amqpDial(ctx),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	return ch, func() {
+		_ = ch.Close()
+		_ = conn.Close()
+	}, nil
+}
+
+// amqpDial returns the dial function of the AMQP client, which gives up when the context is done.
+// The deadline of the connection bounds the handshake, the client clears it once connected.
+func amqpDial(ctx context.Context) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		dialer := net.Dialer{Timeout: amqpConnectionTimeout}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(amqpConnectionTimeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+type AMQPConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	Exchange      string
+	RoutingKey    string
+	TLSSkipVerify bool
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+}
+
+func AMQPFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewAMQPConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	n, err := NewAMQPNotifier(cfg, fc.ImageStore, fc.Template)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: "invalid TLS settings",
+			Err:    err,
+			Cfg:    *fc.Config,
+		}
+	}
+	return n, nil
+}
+
+func NewAMQPConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*AMQPConfig, error) {
+	brokerURL := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if brokerURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	u, err := url.Parse(brokerURL)
+	if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+		return nil, errors.New("url must be a valid amqp:// or amqps:// URL")
+	}
+	routingKey := config.Settings.Get("routingKey").MustString()
+	if routingKey == "" {
+		return nil, errors.New("could not find routing key in settings")
+	}
+
+	return &AMQPConfig{
+		NotificationChannelConfig: config,
+		URL:                       brokerURL,
+		Exchange:                  config.Settings.Get("exchange").MustString(),
+		RoutingKey:                routingKey,
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSCACert:                 config.Settings.Get("tlsCACert").MustString(),
+		TLSClientCert:             config.Settings.Get("tlsClientCert").MustString(),
+		TLSClientKey:              decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString()),
+	}, nil
+}
+
+// NewAMQPNotifier is the constructor for the AMQP notifier.
+func NewAMQPNotifier(config *AMQPConfig, images ImageStore, t *template.Template) (*AMQPNotifier, error) {
+	var tlsConfig *tls.Config
+	if strings.HasPrefix(config.URL, "amqps://") {
+		var err error
+		tlsConfig, err = brokerTLSConfig(config.TLSSkipVerify, config.TLSCACert, config.TLSClientCert, config.TLSClientKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &AMQPNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:      config.OrgID,
+		URL:        config.URL,
+		Exchange:   config.Exchange,
+		RoutingKey: config.RoutingKey,
+		tlsConfig:  tlsConfig,
+		log:        log.New("alerting.notifier.amqp"),
+		images:     images,
+		tmpl:       t,
+	}, nil
+}
+
+// AMQPNotifier is responsible for publishing alert notifications to an AMQP 0.9.1 broker such as RabbitMQ.
+type AMQPNotifier struct {
+	*Base
+	URL        string
+	Exchange   string
+	RoutingKey string
+	orgID      int64
+	tlsConfig  *tls.Config
+	log        log.Logger
+	images     ImageStore
+	tmpl       *template.Template
+}
+
+// Notify publishes the alerts to the configured exchange. The channel is put in confirm
+// mode and the message is published as mandatory, so that messages that are not routed
+// to any queue or that are rejected by the broker are reported as failures.
+func (an *AMQPNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	an.log.Debug("publishing to AMQP", "notification", an.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, an.tmpl, as, an.log, &tmplErr)
+
+	_ = withStoredImages(ctx, an.log, an.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	routingKey := strings.TrimSpace(tmpl(an.RoutingKey))
+	msg := newBrokerMessage(ctx, an.PayloadVersion(), tmpl, data, groupKey.String(), an.orgID, as...)
+
+	if tmplErr != nil {
+		an.log.Warn("failed to template AMQP message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if recordDryRun(ctx, an.Exchange+"/"+routingKey, string(body)) {
+		return true, nil
+	}
+
+	ch, closeFn, err := amqpOpenChannel(ctx, an.URL, an.tlsConfig)
+	if err != nil {
+		an.log.Error("failed to connect to AMQP broker", "err", err, "notification", an.Name)
+		return false, err
+	}
+	defer closeFn()
+
+	if err := an.publish(ctx, ch, routingKey, body); err != nil {
+		an.log.Error("failed to publish to AMQP", "err", err, "exchange", an.Exchange, "routingKey", routingKey, "notification", an.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (an *AMQPNotifier) publish(ctx context.Context, ch amqpChannel, routingKey string, body []byte) error {
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	err := ch.PublishWithContext(ctx, an.Exchange, routingKey, true, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    timeNow(),
+		AppId:        "grafana",
+		Body:         body,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case confirm, ok := <-confirms:
+		if !ok {
+			return errors.New("channel closed before the message was confirmed")
+		}
+		// The broker sends basic.return before basic.ack for unroutable mandatory messages.
+		select {
+		case r := <-returns:
+			return fmt.Errorf("message was returned by the broker: %d %s", r.ReplyCode, r.ReplyText)
+		default:
+		}
+		if !confirm.Ack {
+			return errors.New("message was rejected by the broker")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (an *AMQPNotifier) SendResolved() bool {
+	return !an.GetDisableResolveMessage()
+}
@@ -0,0 +1,231 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultArgoCDApplicationLabel = "argocd_application"
+
+	// The annotations of the applications, which the triggers of Argo CD notifications can use.
+	argoCDAnnotationPrefix    = "alerting.grafana.com/"
+	argoCDAnnotationState     = argoCDAnnotationPrefix + "alert-state"
+	argoCDAnnotationAlerts    = argoCDAnnotationPrefix + "alert-names"
+	argoCDAnnotationMessage   = argoCDAnnotationPrefix + "alert-message"
+	argoCDAnnotationURL       = argoCDAnnotationPrefix + "alert-url"
+	argoCDAnnotationUpdatedAt = argoCDAnnotationPrefix + "alert-updated-at"
+
+	argoCDMaxAnnotationLength = 1024
+	// argoCDMaxAlertNames is the maximum number of alert names in the annotation.
+	argoCDMaxAlertNames = 10
+)
+
+type ArgoCDConfig struct {
+	*NotificationChannelConfig
+	URL              string
+	Token            string
+	ApplicationLabel string
+	Application      string
+	AppNamespace     string
+	Message          string
+}
+
+func ArgoCDFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewArgoCDConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewArgoCDNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewArgoCDConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ArgoCDConfig, error) {
+	serverURL := strings.TrimSuffix(strings.TrimSpace(config.Settings.Get("url").MustString()), "/")
+	if serverURL == "" {
+		return nil, errors.New("could not find Argo CD URL in settings")
+	}
+	if _, err := url.Parse(serverURL); err != nil {
+		return nil, fmt.Errorf("invalid Argo CD URL: %w", err)
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	return &ArgoCDConfig{
+		NotificationChannelConfig: config,
+		URL:                       serverURL,
+		Token:                     token,
+		ApplicationLabel:          config.Settings.Get("applicationLabel").MustString(defaultArgoCDApplicationLabel),
+		Application:               strings.TrimSpace(config.Settings.Get("application").MustString()),
+		AppNamespace:              strings.TrimSpace(config.Settings.Get("appNamespace").MustString()),
+		Message:                   config.Settings.Get("message").MustString(defaultGitHubTitle),
+	}, nil
+}
+
+// NewArgoCDNotifier is the constructor for the Argo CD notifier.
+func NewArgoCDNotifier(config *ArgoCDConfig, ns notifications.WebhookSender, t *template.Template) *ArgoCDNotifier {
+	return &ArgoCDNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:              config.URL,
+		Token:            config.Token,
+		ApplicationLabel: config.ApplicationLabel,
+		Application:      config.Application,
+		AppNamespace:     config.AppNamespace,
+		Message:          config.Message,
+		log:              log.New("alerting.notifier.argocd"),
+		ns:               ns,
+		tmpl:             t,
+	}
+}
+
+// ArgoCDNotifier is responsible for annotating the Argo CD applications of the alerts with their
+// state, so that the triggers of Argo CD notifications can relay them along with the recent
+// syncs of the applications.
+type ArgoCDNotifier struct {
+	*Base
+	URL              string
+	Token            string
+	ApplicationLabel string
+	Application      string
+	AppNamespace     string
+	Message          string
+	log              log.Logger
+	ns               notifications.WebhookSender
+	tmpl             *template.Template
+}
+
+// Notify annotates each application with the alerts of the notification that belong to it. The
+// state of an application is firing if any of its alerts is firing.
+func (an *ArgoCDNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	an.log.Debug("sending Argo CD notification", "notification", an.Name)
+
+	applications := map[string][]*types.Alert{}
+	for _, a := range as {
+		app := an.Application
+		if v := string(a.Labels[model.LabelName(an.ApplicationLabel)]); v != "" {
+			app = v
+		}
+		if app == "" {
+			an.log.Debug("skipping alert without Argo CD application", "alert", a.Fingerprint().String(), "label", an.ApplicationLabel)
+			continue
+		}
+		applications[app] = append(applications[app], a)
+	}
+
+	names := make([]string, 0, len(applications))
+	for app := range applications {
+		names = append(names, app)
+	}
+	sort.Strings(names)
+	for _, app := range names {
+		if err := an.annotate(ctx, app, applications[app]); err != nil {
+			an.log.Error("failed to send Argo CD notification", "err", err, "application", app, "notification", an.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (an *ArgoCDNotifier) annotate(ctx context.Context, app string, as []*types.Alert) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, an.tmpl, as, an.log, &tmplErr)
+	message, _ := an.Truncate(strings.TrimSpace(tmpl(an.Message)), argoCDMaxAnnotationLength)
+	if tmplErr != nil {
+		an.log.Warn("failed to template Argo CD message", "err", tmplErr.Error())
+	}
+
+	seen := map[string]struct{}{}
+	var alertNames []string
+	for _, a := range as {
+		name := string(a.Labels[model.AlertNameLabel])
+		if _, ok := seen[name]; ok || len(alertNames) == argoCDMaxAlertNames {
+			continue
+		}
+		seen[name] = struct{}{}
+		alertNames = append(alertNames, name)
+	}
+
+	alertsURL := an.RuleListURL(an.tmpl.ExternalURL)
+	if as[0].Labels[model.LabelName(an.ApplicationLabel)] != "" {
+		alertsURL = an.AlertListURL(an.tmpl.ExternalURL, model.LabelSet{model.LabelName(an.ApplicationLabel): model.LabelValue(app)})
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				argoCDAnnotationState:     string(types.Alerts(as...).Status()),
+				argoCDAnnotationAlerts:    strings.Join(alertNames, ","),
+				argoCDAnnotationMessage:   message,
+				argoCDAnnotationURL:       alertsURL,
+				argoCDAnnotationUpdatedAt: timeNow().UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	req := map[string]string{
+		"name":      app,
+		"patch":     string(patch),
+		"patchType": "merge",
+	}
+	path := "/api/v1/applications/" + url.PathEscape(app)
+	if an.AppNamespace != "" {
+		req["appNamespace"] = an.AppNamespace
+		path += "?appNamespace=" + url.QueryEscape(an.AppNamespace)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        an.URL + path,
+		HttpMethod: "PATCH",
+		HttpHeader: map[string]string{
+			"Authorization": "Bearer " + an.Token,
+		},
+		ContentType: "application/json",
+		Body:        string(body),
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 == 2 {
+				return nil
+			}
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+				return fmt.Errorf("the Argo CD API returned status %d: %s", statusCode, resp.Message)
+			}
+			return fmt.Errorf("the Argo CD API returned status %d", statusCode)
+		},
+	}
+	return an.ns.SendWebhookSync(ctx, cmd)
+}
+
+func (an *ArgoCDNotifier) SendResolved() bool {
+	return !an.GetDisableResolveMessage()
+}
@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeArgoCD records the requests sent to the Argo CD API and answers them with the given status.
+type fakeArgoCD struct {
+	notificationServiceMock
+	requests []models.SendWebhookSync
+	status   int
+	response string
+}
+
+func (f *fakeArgoCD) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, *cmd)
+	return cmd.Validation([]byte(f.response), f.status)
+}
+
+func TestArgoCDNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	newAlert := func(labels model.LabelSet, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: labels, Annotations: model.LabelSet{"summary": "it broke"}}}
+		if resolved {
+			a.EndsAt = now.Add(-time.Minute)
+		}
+		return a
+	}
+
+	type patchRequest struct {
+		Name         string `json:"name"`
+		Patch        string `json:"patch"`
+		PatchType    string `json:"patchType"`
+		AppNamespace string `json:"appNamespace"`
+	}
+	annotationsOf := func(t *testing.T, cmd models.SendWebhookSync) (patchRequest, map[string]string) {
+		var req patchRequest
+		require.NoError(t, json.Unmarshal([]byte(cmd.Body), &req))
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(req.Patch), &patch))
+		return req, patch.Metadata.Annotations
+	}
+
+	newNotifier := func(t *testing.T, settings string, sender *fakeArgoCD) *ArgoCDNotifier {
+		settingsJSON, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewArgoCDConfig(&NotificationChannelConfig{
+			Name:     "argocd_testing",
+			Type:     "argocd",
+			Settings: settingsJSON,
+		}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+		return NewArgoCDNotifier(cfg, sender, tmpl)
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	t.Run("annotates each application with its alerts", func(t *testing.T) {
+		sender := &fakeArgoCD{status: 200}
+		n := newNotifier(t, `{"url": "https://argocd.example.com/", "token": "secret"}`, sender)
+
+		ok, err := n.Notify(ctx,
+			newAlert(model.LabelSet{"alertname": "HighLatency", "argocd_application": "payments"}, false),
+			newAlert(model.LabelSet{"alertname": "HighErrorRate", "argocd_application": "payments"}, true),
+			newAlert(model.LabelSet{"alertname": "HighLatency", "argocd_application": "checkout"}, true),
+			newAlert(model.LabelSet{"alertname": "DiskFull"}, false),
+		)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.requests, 2)
+
+		require.Equal(t, "https://argocd.example.com/api/v1/applications/checkout", sender.requests[0].Url)
+		require.Equal(t, "PATCH", sender.requests[0].HttpMethod)
+		require.Equal(t, "Bearer secret", sender.requests[0].HttpHeader["Authorization"])
+		req, annotations := annotationsOf(t, sender.requests[0])
+		require.Equal(t, "checkout", req.Name)
+		require.Equal(t, "merge", req.PatchType)
+		require.Equal(t, map[string]string{
+			"alerting.grafana.com/alert-state":      "resolved",
+			"alerting.grafana.com/alert-names":      "HighLatency",
+			"alerting.grafana.com/alert-message":    "HighLatency: it broke",
+			"alerting.grafana.com/alert-url":        "http://localhost/alerting/list?queryString=argocd_application%3D%22checkout%22",
+			"alerting.grafana.com/alert-updated-at": "2022-06-01T12:00:00Z",
+		}, annotations)
+
+		require.Equal(t, "https://argocd.example.com/api/v1/applications/payments", sender.requests[1].Url)
+		_, annotations = annotationsOf(t, sender.requests[1])
+		require.Equal(t, "firing", annotations["alerting.grafana.com/alert-state"])
+		require.Equal(t, "HighLatency,HighErrorRate", annotations["alerting.grafana.com/alert-names"])
+	})
+
+	t.Run("uses the static application and namespace", func(t *testing.T) {
+		sender := &fakeArgoCD{status: 200}
+		n := newNotifier(t, `{"url": "https://argocd.example.com", "token": "secret", "application": "platform", "appNamespace": "team-a"}`, sender)
+
+		ok, err := n.Notify(ctx, newAlert(model.LabelSet{"alertname": "DiskFull"}, false))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.requests, 1)
+		require.Equal(t, "https://argocd.example.com/api/v1/applications/platform?appNamespace=team-a", sender.requests[0].Url)
+		req, annotations := annotationsOf(t, sender.requests[0])
+		require.Equal(t, "team-a", req.AppNamespace)
+		require.Equal(t, "http://localhost/alerting/list", annotations["alerting.grafana.com/alert-url"])
+	})
+
+	t.Run("returns the error of the API", func(t *testing.T) {
+		sender := &fakeArgoCD{status: 403, response: `{"error": "permission denied", "message": "permission denied", "code": 7}`}
+		n := newNotifier(t, `{"url": "https://argocd.example.com", "token": "secret"}`, sender)
+
+		ok, err := n.Notify(ctx, newAlert(model.LabelSet{"alertname": "DiskFull", "argocd_application": "payments"}, false))
+		require.EqualError(t, err, "the Argo CD API returned status 403: permission denied")
+		require.False(t, ok)
+	})
+}
+
+func TestNewArgoCDConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expErr   string
+	}{
+		{name: "Error when the URL is missing", settings: `{"token": "secret"}`, expErr: "could not find Argo CD URL in settings"},
+		{name: "Error when the token is missing", settings: `{"url": "https://argocd.example.com"}`, expErr: "could not find token in settings"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewArgoCDConfig(&NotificationChannelConfig{Type: "argocd", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expErr)
+		})
+	}
+}
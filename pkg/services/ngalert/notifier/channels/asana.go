@@ -0,0 +1,318 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	asanaAPIURL = "https://app.asana.com/api/1.0"
+
+	asanaMaxNotesLength = 65535
+	// asanaPageSize is the maximum number of tasks per page of the Asana API.
+	asanaPageSize = 100
+)
+
+type AsanaConfig struct {
+	*NotificationChannelConfig
+	AccessToken       string
+	ProjectID         string
+	Title             string
+	Description       string
+	Assignee          string
+	AssigneeLabel     string
+	DueDateOffset     time.Duration
+	ResolveComment    string
+	CompleteOnResolve bool
+}
+
+func AsanaFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewAsanaConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewAsanaNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewAsanaConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*AsanaConfig, error) {
+	accessToken := decryptFunc(context.Background(), config.SecureSettings, "accessToken", config.Settings.Get("accessToken").MustString())
+	if accessToken == "" {
+		return nil, errors.New("could not find access token in settings")
+	}
+	projectID := strings.TrimSpace(config.Settings.Get("projectId").MustString())
+	if projectID == "" {
+		return nil, errors.New("could not find project ID in settings")
+	}
+	var dueDateOffset time.Duration
+	if s := strings.TrimSpace(config.Settings.Get("dueDateOffset").MustString()); s != "" {
+		d, err := gtime.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid due date offset %q", s)
+		}
+		dueDateOffset = d
+	}
+	return &AsanaConfig{
+		NotificationChannelConfig: config,
+		AccessToken:               accessToken,
+		ProjectID:                 projectID,
+		Title:                     config.Settings.Get("title").MustString(defaultGitHubTitle),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		Assignee:                  strings.TrimSpace(config.Settings.Get("assignee").MustString()),
+		AssigneeLabel:             strings.TrimSpace(config.Settings.Get("assigneeLabel").MustString()),
+		DueDateOffset:             dueDateOffset,
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultGitHubResolve),
+		CompleteOnResolve:         config.Settings.Get("completeOnResolve").MustBool(true),
+	}, nil
+}
+
+// NewAsanaNotifier is the constructor for the Asana notifier.
+func NewAsanaNotifier(config *AsanaConfig, ns notifications.WebhookSender, t *template.Template) *AsanaNotifier {
+	return &AsanaNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:               asanaAPIURL,
+		AccessToken:       config.AccessToken,
+		ProjectID:         config.ProjectID,
+		Title:             config.Title,
+		Description:       config.Description,
+		Assignee:          config.Assignee,
+		AssigneeLabel:     config.AssigneeLabel,
+		DueDateOffset:     config.DueDateOffset,
+		ResolveComment:    config.ResolveComment,
+		CompleteOnResolve: config.CompleteOnResolve,
+		log:               log.New("alerting.notifier.asana"),
+		ns:                ns,
+		tmpl:              t,
+	}
+}
+
+// AsanaNotifier is responsible for creating an Asana task per firing alert in a project, and for
+// commenting on and optionally completing the task when the alert is resolved.
+type AsanaNotifier struct {
+	*Base
+	URL               string
+	AccessToken       string
+	ProjectID         string
+	Title             string
+	Description       string
+	Assignee          string
+	AssigneeLabel     string
+	DueDateOffset     time.Duration
+	ResolveComment    string
+	CompleteOnResolve bool
+	log               log.Logger
+	ns                notifications.WebhookSender
+	tmpl              *template.Template
+}
+
+type asanaTask struct {
+	GID   string `json:"gid"`
+	Notes string `json:"notes"`
+}
+
+// Notify creates a task for each firing alert that does not have an incomplete task in the
+// project yet, and resolves the incomplete task of each resolved alert. Tasks are found by the
+// marker with the fingerprint of the alert in their notes.
+func (an *AsanaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	an.log.Debug("sending Asana notification", "notification", an.Name)
+
+	tasks, err := an.incompleteTasks(ctx)
+	if err != nil {
+		an.log.Error("failed to fetch the Asana tasks", "err", err, "notification", an.Name)
+		return false, err
+	}
+
+	for _, a := range as {
+		marker := gitHubAlertMarkerPrefix + a.Fingerprint().String()
+		task := findAsanaTask(tasks, marker)
+		switch {
+		case !a.Resolved() && task == nil:
+			err = an.createTask(ctx, a, marker)
+		case a.Resolved() && task != nil:
+			err = an.resolveTask(ctx, a, task.GID)
+		}
+		if err != nil {
+			an.log.Error("failed to send Asana notification", "err", err, "notification", an.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// incompleteTasks returns the incomplete tasks of the project, following the pages of the API.
+func (an *AsanaNotifier) incompleteTasks(ctx context.Context) ([]asanaTask, error) {
+	var tasks []asanaTask
+	offset := ""
+	for {
+		q := url.Values{}
+		q.Set("completed_since", "now")
+		q.Set("opt_fields", "notes")
+		q.Set("limit", fmt.Sprint(asanaPageSize))
+		if offset != "" {
+			q.Set("offset", offset)
+		}
+		var page struct {
+			Data     []asanaTask `json:"data"`
+			NextPage *struct {
+				Offset string `json:"offset"`
+			} `json:"next_page"`
+		}
+		if err := an.request(ctx, "GET", "/projects/"+url.PathEscape(an.ProjectID)+"/tasks?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Data...)
+		if page.NextPage == nil || page.NextPage.Offset == "" {
+			return tasks, nil
+		}
+		offset = page.NextPage.Offset
+	}
+}
+
+func findAsanaTask(tasks []asanaTask, marker string) *asanaTask {
+	for i := range tasks {
+		if strings.Contains(tasks[i].Notes, marker) {
+			return &tasks[i]
+		}
+	}
+	return nil
+}
+
+func (an *AsanaNotifier) createTask(ctx context.Context, a *types.Alert, marker string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, an.tmpl, []*types.Alert{a}, an.log, &tmplErr)
+
+	name := strings.Join(strings.Fields(tmpl(an.Title)), " ")
+	footer := "\n\n---\n" + marker
+	notes, _ := an.Truncate(tmpl(an.Description), asanaMaxNotesLength-utf8.RuneCountInString(footer))
+	if tmplErr != nil {
+		an.log.Warn("failed to template Asana task", "err", tmplErr.Error())
+	}
+
+	task := map[string]interface{}{
+		"name":     name,
+		"notes":    notes + footer,
+		"projects": []string{an.ProjectID},
+	}
+	if assignee := an.assignee(a); assignee != "" {
+		task["assignee"] = assignee
+	}
+	if an.DueDateOffset > 0 {
+		task["due_at"] = a.StartsAt.Add(an.DueDateOffset).UTC().Format(time.RFC3339)
+	}
+
+	var created struct {
+		Data asanaTask `json:"data"`
+	}
+	if err := an.request(ctx, "POST", "/tasks", task, &created); err != nil {
+		return err
+	}
+	an.log.Debug("created Asana task", "task", created.Data.GID, "alert", a.Fingerprint().String())
+	return nil
+}
+
+// assignee returns the value of the assignee label of the alert, that is the email or the ID of
+// a user, or the default assignee if the alert does not have the label.
+func (an *AsanaNotifier) assignee(a *types.Alert) string {
+	if an.AssigneeLabel != "" {
+		if v := strings.TrimSpace(string(a.Labels[model.LabelName(an.AssigneeLabel)])); v != "" {
+			return v
+		}
+	}
+	return an.Assignee
+}
+
+func (an *AsanaNotifier) resolveTask(ctx context.Context, a *types.Alert, gid string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, an.tmpl, []*types.Alert{a}, an.log, &tmplErr)
+	comment, _ := an.Truncate(tmpl(an.ResolveComment), asanaMaxNotesLength)
+	if tmplErr != nil {
+		an.log.Warn("failed to template Asana comment", "err", tmplErr.Error())
+	}
+
+	path := "/tasks/" + url.PathEscape(gid)
+	if comment != "" {
+		if err := an.request(ctx, "POST", path+"/stories", map[string]string{"text": comment}, nil); err != nil {
+			return err
+		}
+	}
+	if !an.CompleteOnResolve {
+		return nil
+	}
+	return an.request(ctx, "PUT", path, map[string]bool{"completed": true}, nil)
+}
+
+// request sends a request to the Asana REST API and decodes the response into out, if not nil.
+// The body of the request is wrapped in a data object, like the bodies of the responses.
+func (an *AsanaNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        an.URL + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Authorization": "Bearer " + an.AccessToken,
+			"Accept":        "application/json",
+			"Content-Type":  "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return asanaError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(map[string]interface{}{"data": in})
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return an.ns.SendWebhookSync(ctx, cmd)
+}
+
+// asanaError returns the error of a response of the Asana API, with the messages of its errors.
+func asanaError(body []byte, statusCode int) error {
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		msgs := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("the Asana API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+	}
+	return fmt.Errorf("the Asana API returned status %d", statusCode)
+}
+
+func (an *AsanaNotifier) SendResolved() bool {
+	return !an.GetDisableResolveMessage()
+}
@@ -0,0 +1,257 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeAsanaTask struct {
+	GID       string
+	Fields    map[string]interface{}
+	Comments  []string
+	Completed bool
+}
+
+// fakeAsana implements the parts of the Asana REST API used by the notifier, for the project
+// "ops". The tasks are listed one per page, to follow the pagination.
+type fakeAsana struct {
+	tasks    []*fakeAsanaTask
+	requests []*models.SendWebhookSync
+}
+
+func (f *fakeAsana) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	path := strings.TrimPrefix(u.Path, "/api/1.0")
+	switch {
+	case path == "/projects/ops/tasks":
+		var incomplete []*fakeAsanaTask
+		for _, t := range f.tasks {
+			if !t.Completed {
+				incomplete = append(incomplete, t)
+			}
+		}
+		i, _ := strconv.Atoi(u.Query().Get("offset"))
+		page := map[string]interface{}{"data": []asanaTask{}, "next_page": nil}
+		if i < len(incomplete) {
+			page["data"] = []asanaTask{{GID: incomplete[i].GID, Notes: incomplete[i].Fields["notes"].(string)}}
+		}
+		if i+1 < len(incomplete) {
+			page["next_page"] = map[string]string{"offset": strconv.Itoa(i + 1)}
+		}
+		return respond(200, page)
+	case strings.HasPrefix(path, "/projects/"):
+		return respond(404, map[string]interface{}{"errors": []map[string]string{{"message": "project: Not a recognized ID"}}})
+	case path == "/tasks":
+		task := &fakeAsanaTask{GID: strconv.Itoa(len(f.tasks) + 1), Fields: in.Data}
+		f.tasks = append(f.tasks, task)
+		return respond(201, map[string]interface{}{"data": map[string]string{"gid": task.GID}})
+	case strings.HasSuffix(path, "/stories"):
+		task := f.task(strings.TrimSuffix(strings.TrimPrefix(path, "/tasks/"), "/stories"))
+		task.Comments = append(task.Comments, in.Data["text"].(string))
+		return respond(201, map[string]interface{}{"data": map[string]string{}})
+	case strings.HasPrefix(path, "/tasks/") && cmd.HttpMethod == "PUT":
+		task := f.task(strings.TrimPrefix(path, "/tasks/"))
+		task.Completed = in.Data["completed"].(bool)
+		return respond(200, map[string]interface{}{"data": map[string]string{"gid": task.GID}})
+	}
+	return respond(404, map[string]interface{}{"errors": []map[string]string{{"message": "not found"}}})
+}
+
+func (f *fakeAsana) task(gid string) *fakeAsanaTask {
+	for _, t := range f.tasks {
+		if t.GID == gid {
+			return t
+		}
+	}
+	return nil
+}
+
+func TestAsanaNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "owner": "dba@example.com"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+			StartsAt:    startsAt,
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert2"},
+			StartsAt: startsAt,
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(time.Minute)
+
+	t.Run("one task per alert, commented and completed when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"accessToken":   "token",
+			"projectId":     "ops",
+			"assignee":      "oncall@example.com",
+			"assigneeLabel": "owner",
+			"dueDateOffset": "1d",
+		})
+		cfg, err := NewAsanaConfig(&NotificationChannelConfig{Name: "asana_testing", Type: "asana", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		asana := &fakeAsana{}
+		n := NewAsanaNotifier(cfg, asana, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not create duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, asana.tasks, 2)
+
+		task := asana.tasks[0]
+		require.Equal(t, "alert1: CPU is high", task.Fields["name"])
+		require.Equal(t, []interface{}{"ops"}, task.Fields["projects"])
+		require.True(t, strings.HasSuffix(task.Fields["notes"].(string), "\n\n---\ngrafana-alert-"+firing.Fingerprint().String()))
+		require.Contains(t, task.Fields["notes"], "CPU is high")
+		require.Equal(t, "2022-06-02T10:00:00Z", task.Fields["due_at"])
+		require.Equal(t, "dba@example.com", task.Fields["assignee"])
+		require.Equal(t, "oncall@example.com", asana.tasks[1].Fields["assignee"])
+
+		req := asana.requests[0]
+		require.Equal(t, "https://app.asana.com/api/1.0/projects/ops/tasks?completed_since=now&limit=100&opt_fields=notes", req.Url)
+		require.Equal(t, "Bearer token", req.HttpHeader["Authorization"])
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, task.Comments)
+		require.True(t, task.Completed)
+		require.False(t, asana.tasks[1].Completed)
+
+		// The alert fires again after its task was completed.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, asana.tasks, 3)
+	})
+
+	t.Run("comment without completing when resolved", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"accessToken":       "token",
+			"projectId":         "ops",
+			"resolveComment":    "{{ .CommonLabels.alertname }} is resolved",
+			"completeOnResolve": false,
+		})
+		cfg, err := NewAsanaConfig(&NotificationChannelConfig{Name: "asana_testing", Type: "asana", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		asana := &fakeAsana{}
+		n := NewAsanaNotifier(cfg, asana, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		task := asana.tasks[0]
+		require.NotContains(t, task.Fields, "assignee")
+		require.NotContains(t, task.Fields, "due_at")
+		require.Equal(t, []string{"alert1 is resolved"}, task.Comments)
+		require.False(t, task.Completed)
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"accessToken": "token",
+			"projectId":   "unknown",
+		})
+		cfg, err := NewAsanaConfig(&NotificationChannelConfig{Name: "asana_testing", Type: "asana", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewAsanaNotifier(cfg, &fakeAsana{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the Asana API returned status 404: project: Not a recognized ID")
+		require.False(t, ok)
+	})
+}
+
+func TestNewAsanaConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Minimal settings",
+			settings: map[string]interface{}{"accessToken": "token", "projectId": "ops"},
+		}, {
+			name:         "Error when the access token is missing",
+			settings:     map[string]interface{}{"projectId": "ops"},
+			expInitError: "could not find access token in settings",
+		}, {
+			name:         "Error when the project ID is missing",
+			settings:     map[string]interface{}{"accessToken": "token"},
+			expInitError: "could not find project ID in settings",
+		}, {
+			name:         "Error when the due date offset is invalid",
+			settings:     map[string]interface{}{"accessToken": "token", "projectId": "ops", "dueDateOffset": "tomorrow"},
+			expInitError: `invalid due date offset "tomorrow"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "asana_testing", Type: "asana", Settings: simplejson.NewFromAny(c.settings)}
+
+			_, err := NewAsanaConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
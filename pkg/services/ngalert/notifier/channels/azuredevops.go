@@ -0,0 +1,300 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultAzureDevOpsURL          = "https://dev.azure.com"
+	defaultAzureDevOpsWorkItemType = "Task"
+	defaultAzureDevOpsTitle        = `{{ .CommonLabels.alertname }}{{ with .CommonAnnotations.summary }}: {{ . }}{{ end }}`
+	defaultAzureDevOpsResolve      = "The alert is resolved."
+
+	azureDevOpsAPIVersion = "7.0"
+
+	// azureDevOpsAlertTagPrefix is the prefix of the tag that links a work item to the
+	// fingerprint of its alert, so that an alert only ever has one open work item.
+	azureDevOpsAlertTagPrefix = "grafana-alert-"
+
+	azureDevOpsMaxTitleLength = 255
+)
+
+// azureDevOpsDoneStates are the states of the work items of the default processes of Azure
+// Boards in which the work item is no longer open.
+var azureDevOpsDoneStates = []string{"Closed", "Done", "Removed", "Resolved"}
+
+type AzureDevOpsConfig struct {
+	*NotificationChannelConfig
+	URL            string
+	Organization   string
+	Project        string
+	Token          string
+	WorkItemType   string
+	AreaPath       string
+	Tags           string
+	Title          string
+	Description    string
+	ResolveComment string
+	ResolveState   string
+}
+
+func AzureDevOpsFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewAzureDevOpsConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewAzureDevOpsNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewAzureDevOpsConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*AzureDevOpsConfig, error) {
+	baseURL := strings.TrimSuffix(config.Settings.Get("url").MustString(defaultAzureDevOpsURL), "/")
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	organization := strings.TrimSpace(config.Settings.Get("organization").MustString())
+	if organization == "" {
+		return nil, errors.New("could not find organization in settings")
+	}
+	project := strings.TrimSpace(config.Settings.Get("project").MustString())
+	if project == "" {
+		return nil, errors.New("could not find project in settings")
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find personal access token in settings")
+	}
+	return &AzureDevOpsConfig{
+		NotificationChannelConfig: config,
+		URL:                       baseURL,
+		Organization:              organization,
+		Project:                   project,
+		Token:                     token,
+		WorkItemType:              config.Settings.Get("workItemType").MustString(defaultAzureDevOpsWorkItemType),
+		AreaPath:                  config.Settings.Get("areaPath").MustString(),
+		Tags:                      config.Settings.Get("tags").MustString(),
+		Title:                     config.Settings.Get("title").MustString(defaultAzureDevOpsTitle),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultAzureDevOpsResolve),
+		ResolveState:              config.Settings.Get("resolveState").MustString(),
+	}, nil
+}
+
+// NewAzureDevOpsNotifier is the constructor for the Azure DevOps notifier.
+func NewAzureDevOpsNotifier(config *AzureDevOpsConfig, ns notifications.WebhookSender, t *template.Template) *AzureDevOpsNotifier {
+	return &AzureDevOpsNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:            config.URL,
+		Organization:   config.Organization,
+		Project:        config.Project,
+		Token:          config.Token,
+		WorkItemType:   config.WorkItemType,
+		AreaPath:       config.AreaPath,
+		Tags:           config.Tags,
+		Title:          config.Title,
+		Description:    config.Description,
+		ResolveComment: config.ResolveComment,
+		ResolveState:   config.ResolveState,
+		log:            log.New("alerting.notifier.azuredevops"),
+		ns:             ns,
+		tmpl:           t,
+	}
+}
+
+// AzureDevOpsNotifier is responsible for creating an Azure Boards work item per firing alert,
+// and for commenting on the work item and optionally changing its state when the alert is
+// resolved.
+type AzureDevOpsNotifier struct {
+	*Base
+	URL            string
+	Organization   string
+	Project        string
+	Token          string
+	WorkItemType   string
+	AreaPath       string
+	Tags           string
+	Title          string
+	Description    string
+	ResolveComment string
+	ResolveState   string
+	log            log.Logger
+	ns             notifications.WebhookSender
+	tmpl           *template.Template
+}
+
+type azureDevOpsPatch struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+type azureDevOpsWorkItem struct {
+	ID int `json:"id"`
+}
+
+// Notify creates a work item for each firing alert that does not have an open work item yet,
+// and resolves the open work item of each resolved alert. Work items are found by the tag with
+// the fingerprint of their alert.
+func (an *AzureDevOpsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	an.log.Debug("sending Azure DevOps notification", "notification", an.Name)
+
+	for _, a := range as {
+		tag := azureDevOpsAlertTagPrefix + a.Fingerprint().String()
+		item, err := an.findOpenWorkItem(ctx, tag)
+		if err != nil {
+			an.log.Error("failed to query Azure DevOps work items", "err", err, "notification", an.Name)
+			return false, err
+		}
+
+		switch {
+		case !a.Resolved() && item == nil:
+			err = an.createWorkItem(ctx, a, tag)
+		case a.Resolved() && item != nil:
+			err = an.resolveWorkItem(ctx, a, item.ID)
+		}
+		if err != nil {
+			an.log.Error("failed to send Azure DevOps notification", "err", err, "notification", an.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (an *AzureDevOpsNotifier) findOpenWorkItem(ctx context.Context, tag string) (*azureDevOpsWorkItem, error) {
+	query := fmt.Sprintf(
+		"SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.Tags] CONTAINS '%s' AND [System.State] NOT IN ('%s')",
+		tag, strings.Join(azureDevOpsDoneStates, "', '"),
+	)
+	var result struct {
+		WorkItems []azureDevOpsWorkItem `json:"workItems"`
+	}
+	if err := an.request(ctx, "POST", "/wit/wiql", "application/json", map[string]string{"query": query}, &result); err != nil {
+		return nil, err
+	}
+	if len(result.WorkItems) == 0 {
+		return nil, nil
+	}
+	return &result.WorkItems[0], nil
+}
+
+func (an *AzureDevOpsNotifier) createWorkItem(ctx context.Context, a *types.Alert, tag string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, an.tmpl, []*types.Alert{a}, an.log, &tmplErr)
+
+	title, _ := an.Truncate(strings.Join(strings.Fields(tmpl(an.Title)), " "), azureDevOpsMaxTitleLength)
+	tags := append([]string{tag}, splitCommaList(tmpl(an.Tags))...)
+	patch := []azureDevOpsPatch{
+		{Op: "add", Path: "/fields/System.Title", Value: title},
+		// The description is HTML, line breaks are kept.
+		{Op: "add", Path: "/fields/System.Description", Value: azureDevOpsHTML(tmpl(an.Description))},
+		{Op: "add", Path: "/fields/System.Tags", Value: strings.Join(tags, "; ")},
+	}
+	if areaPath := strings.TrimSpace(tmpl(an.AreaPath)); areaPath != "" {
+		patch = append(patch, azureDevOpsPatch{Op: "add", Path: "/fields/System.AreaPath", Value: areaPath})
+	}
+
+	if tmplErr != nil {
+		an.log.Warn("failed to template Azure DevOps work item", "err", tmplErr.Error())
+	}
+
+	var created azureDevOpsWorkItem
+	path := "/wit/workitems/$" + url.PathEscape(an.WorkItemType)
+	if err := an.request(ctx, "POST", path, "application/json-patch+json", patch, &created); err != nil {
+		return err
+	}
+	an.log.Debug("created Azure DevOps work item", "id", created.ID, "alert", a.Fingerprint().String())
+	return nil
+}
+
+func (an *AzureDevOpsNotifier) resolveWorkItem(ctx context.Context, a *types.Alert, id int) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, an.tmpl, []*types.Alert{a}, an.log, &tmplErr)
+	comment := tmpl(an.ResolveComment)
+	if tmplErr != nil {
+		an.log.Warn("failed to template Azure DevOps comment", "err", tmplErr.Error())
+	}
+
+	var patch []azureDevOpsPatch
+	if comment != "" {
+		// Changes of the history field are added to the discussion of the work item.
+		patch = append(patch, azureDevOpsPatch{Op: "add", Path: "/fields/System.History", Value: azureDevOpsHTML(comment)})
+	}
+	if an.ResolveState != "" {
+		patch = append(patch, azureDevOpsPatch{Op: "add", Path: "/fields/System.State", Value: an.ResolveState})
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	return an.request(ctx, "PATCH", fmt.Sprintf("/wit/workitems/%d", id), "application/json-patch+json", patch, nil)
+}
+
+// request sends a request to the REST API of the project and decodes the response into out, if
+// not nil. Personal access tokens are the password of basic authentication without user.
+func (an *AzureDevOpsNotifier) request(ctx context.Context, method, path, contentType string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	cmd := &models.SendWebhookSync{
+		Url: fmt.Sprintf("%s/%s/%s/_apis%s?api-version=%s",
+			an.URL, url.PathEscape(an.Organization), url.PathEscape(an.Project), path, azureDevOpsAPIVersion),
+		Body:        string(body),
+		HttpMethod:  method,
+		ContentType: contentType,
+		HttpHeader: map[string]string{
+			"Authorization": util.GetBasicAuthHeader("", an.Token),
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return azureDevOpsError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	return an.ns.SendWebhookSync(ctx, cmd)
+}
+
+func azureDevOpsError(body []byte, statusCode int) error {
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+		return fmt.Errorf("the Azure DevOps API returned status %d: %s", statusCode, resp.Message)
+	}
+	return fmt.Errorf("the Azure DevOps API returned status %d", statusCode)
+}
+
+// azureDevOpsHTML returns the text as HTML, for the fields of the work items that are HTML.
+func azureDevOpsHTML(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>")
+}
+
+func (an *AzureDevOpsNotifier) SendResolved() bool {
+	return !an.GetDisableResolveMessage()
+}
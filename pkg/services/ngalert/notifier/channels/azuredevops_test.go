@@ -0,0 +1,264 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeAzureDevOpsWorkItem struct {
+	ID      int
+	Type    string
+	Fields  map[string]string
+	History []string
+}
+
+// fakeAzureDevOps implements the parts of the Azure DevOps REST API used by the notifier.
+type fakeAzureDevOps struct {
+	items    []*fakeAzureDevOpsWorkItem
+	requests []*models.SendWebhookSync
+}
+
+var azureDevOpsTagQuery = regexp.MustCompile(`\[System.Tags\] CONTAINS '([^']+)'`)
+
+func (a *fakeAzureDevOps) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	a.requests = append(a.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	// Azure DevOps Server has a collection path before the organization.
+	path := u.Path
+	if i := strings.Index(path, "/contoso/Ops/_apis/"); i >= 0 {
+		path = path[i+len("/contoso/Ops/_apis"):]
+	}
+	switch {
+	case path == "/wit/wiql":
+		var in map[string]string
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+		tag := azureDevOpsTagQuery.FindStringSubmatch(in["query"])[1]
+		items := []map[string]int{}
+		for _, item := range a.items {
+			if item.Fields["System.State"] != "Closed" && strings.Contains(item.Fields["System.Tags"], tag) {
+				items = append(items, map[string]int{"id": item.ID})
+			}
+		}
+		return respond(200, map[string]interface{}{"workItems": items})
+	case strings.HasPrefix(path, "/wit/workitems/$"):
+		var patch []azureDevOpsPatch
+		if err := json.Unmarshal([]byte(cmd.Body), &patch); err != nil {
+			return err
+		}
+		item := &fakeAzureDevOpsWorkItem{ID: len(a.items) + 1, Type: strings.TrimPrefix(path, "/wit/workitems/$"), Fields: map[string]string{"System.State": "To Do"}}
+		if item.Type == "Epic" {
+			return respond(400, map[string]string{"message": "VS402323: Work item type Epic does not exist in project Ops."})
+		}
+		for _, p := range patch {
+			item.Fields[strings.TrimPrefix(p.Path, "/fields/")] = p.Value
+		}
+		a.items = append(a.items, item)
+		return respond(200, map[string]int{"id": item.ID})
+	case strings.HasPrefix(path, "/wit/workitems/"):
+		var patch []azureDevOpsPatch
+		if err := json.Unmarshal([]byte(cmd.Body), &patch); err != nil {
+			return err
+		}
+		id, _ := strconv.Atoi(strings.TrimPrefix(path, "/wit/workitems/"))
+		item := a.items[id-1]
+		for _, p := range patch {
+			if p.Path == "/fields/System.History" {
+				item.History = append(item.History, p.Value)
+				continue
+			}
+			item.Fields[strings.TrimPrefix(p.Path, "/fields/")] = p.Value
+		}
+		return respond(200, map[string]int{"id": item.ID})
+	}
+	return respond(404, map[string]string{"message": "not found"})
+}
+
+func TestAzureDevOpsNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "team": "sre"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "team": "dba"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	t.Run("one work item per alert, commented and closed when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"organization": "contoso",
+			"project":      "Ops",
+			"token":        "pat",
+			"workItemType": "Bug",
+			"areaPath":     `Ops\{{ .CommonLabels.team }}`,
+			"tags":         "alert, {{ .CommonLabels.team }}",
+			"resolveState": "Closed",
+		})
+		cfg, err := NewAzureDevOpsConfig(&NotificationChannelConfig{Name: "azuredevops_testing", Type: "azuredevops", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		ado := &fakeAzureDevOps{}
+		n := NewAzureDevOpsNotifier(cfg, ado, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not create duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, ado.items, 2)
+
+		item := ado.items[0]
+		require.Equal(t, "Bug", item.Type)
+		require.Equal(t, "alert1: CPU is high", item.Fields["System.Title"])
+		require.Equal(t, `Ops\sre`, item.Fields["System.AreaPath"])
+		require.Equal(t, "grafana-alert-"+firing.Fingerprint().String()+"; alert; sre", item.Fields["System.Tags"])
+		require.Contains(t, item.Fields["System.Description"], "CPU is high<br>")
+		require.Equal(t, `Ops\dba`, ado.items[1].Fields["System.AreaPath"])
+
+		req := ado.requests[0]
+		require.Equal(t, "https://dev.azure.com/contoso/Ops/_apis/wit/wiql?api-version=7.0", req.Url)
+		require.Equal(t, "Basic OnBhdA==", req.HttpHeader["Authorization"])
+		require.Equal(t, "application/json-patch+json", ado.requests[1].ContentType)
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, item.History)
+		require.Equal(t, "Closed", item.Fields["System.State"])
+		require.Equal(t, "To Do", ado.items[1].Fields["System.State"])
+
+		// The alert fires again after its work item was closed.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, ado.items, 3)
+	})
+
+	t.Run("comment without changing the state when resolved", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":          "https://ado.example.com/tfs/",
+			"organization": "contoso",
+			"project":      "Ops",
+			"token":        "pat",
+		})
+		cfg, err := NewAzureDevOpsConfig(&NotificationChannelConfig{Name: "azuredevops_testing", Type: "azuredevops", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ado := &fakeAzureDevOps{}
+		n := NewAzureDevOpsNotifier(cfg, ado, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		item := ado.items[0]
+		require.Equal(t, "Task", item.Type)
+		require.NotContains(t, item.Fields, "System.AreaPath")
+		require.Equal(t, []string{"The alert is resolved."}, item.History)
+		require.Equal(t, "To Do", item.Fields["System.State"])
+		require.Equal(t, "https://ado.example.com/tfs/contoso/Ops/_apis/wit/wiql?api-version=7.0", ado.requests[0].Url)
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"organization": "contoso",
+			"project":      "Ops",
+			"token":        "pat",
+			"workItemType": "Epic",
+		})
+		cfg, err := NewAzureDevOpsConfig(&NotificationChannelConfig{Name: "azuredevops_testing", Type: "azuredevops", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewAzureDevOpsNotifier(cfg, &fakeAzureDevOps{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the Azure DevOps API returned status 400: VS402323: Work item type Epic does not exist in project Ops.")
+		require.False(t, ok)
+	})
+}
+
+func TestNewAzureDevOpsConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Minimal settings",
+			settings: map[string]interface{}{"organization": "contoso", "project": "Ops", "token": "pat"},
+		}, {
+			name:         "Error when the organization is missing",
+			settings:     map[string]interface{}{"project": "Ops", "token": "pat"},
+			expInitError: "could not find organization in settings",
+		}, {
+			name:         "Error when the project is missing",
+			settings:     map[string]interface{}{"organization": "contoso", "token": "pat"},
+			expInitError: "could not find project in settings",
+		}, {
+			name:         "Error when the token is missing",
+			settings:     map[string]interface{}{"organization": "contoso", "project": "Ops"},
+			expInitError: "could not find personal access token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "azuredevops_testing", Type: "azuredevops", Settings: simplejson.NewFromAny(c.settings)}
+
+			_, err := NewAzureDevOpsConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAzureDevOpsError(t *testing.T) {
+	require.EqualError(t, azureDevOpsError([]byte(`{"message":"TF401019: The Git repository does not exist."}`), 404), "the Azure DevOps API returned status 404: TF401019: The Git repository does not exist.")
+	require.EqualError(t, azureDevOpsError([]byte(`<html>Sign in</html>`), 203), "the Azure DevOps API returned status 203")
+	require.EqualError(t, azureDevOpsError(nil, 502), "the Azure DevOps API returned status 502")
+}
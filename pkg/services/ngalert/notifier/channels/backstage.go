@@ -0,0 +1,246 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultBackstageEntityLabel = "backstage_entity"
+	defaultBackstageKind        = "component"
+	defaultBackstageNamespace   = "default"
+	defaultBackstageSeverity    = "high"
+	defaultBackstageTopic       = "Grafana Alerting"
+)
+
+// backstageSeverities are the severities of the notifications of Backstage.
+var backstageSeverities = map[string]bool{"critical": true, "high": true, "normal": true, "low": true}
+
+type BackstageConfig struct {
+	*NotificationChannelConfig
+	URL         string
+	Token       string
+	EntityLabel string
+	Entity      string
+	Severity    string
+	Topic       string
+	Title       string
+	Description string
+}
+
+func BackstageFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewBackstageConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewBackstageNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewBackstageConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*BackstageConfig, error) {
+	baseURL := strings.TrimSuffix(strings.TrimSpace(config.Settings.Get("url").MustString()), "/")
+	if baseURL == "" {
+		return nil, errors.New("could not find Backstage URL in settings")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid Backstage URL: %w", err)
+	}
+	entityLabel := config.Settings.Get("entityLabel").MustString(defaultBackstageEntityLabel)
+	entity := strings.TrimSpace(config.Settings.Get("entity").MustString())
+	if entityLabel == "" && entity == "" {
+		return nil, errors.New("could not find the entity or the label of the entity in settings")
+	}
+	severity := config.Settings.Get("severity").MustString(defaultBackstageSeverity)
+	if !backstageSeverities[severity] {
+		return nil, fmt.Errorf("invalid severity %q, must be critical, high, normal or low", severity)
+	}
+	return &BackstageConfig{
+		NotificationChannelConfig: config,
+		URL:                       baseURL,
+		Token:                     decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString()),
+		EntityLabel:               entityLabel,
+		Entity:                    entity,
+		Severity:                  severity,
+		Topic:                     config.Settings.Get("topic").MustString(defaultBackstageTopic),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewBackstageNotifier is the constructor for the Backstage notifier.
+func NewBackstageNotifier(config *BackstageConfig, ns notifications.WebhookSender, t *template.Template) *BackstageNotifier {
+	return &BackstageNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:         config.URL,
+		Token:       config.Token,
+		EntityLabel: config.EntityLabel,
+		Entity:      config.Entity,
+		Severity:    config.Severity,
+		Topic:       config.Topic,
+		Title:       config.Title,
+		Description: config.Description,
+		log:         log.New("alerting.notifier.backstage"),
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// BackstageNotifier is responsible for sending alert notifications to the owners of Backstage
+// entities, through the notifications plugin of Backstage.
+type BackstageNotifier struct {
+	*Base
+	URL         string
+	Token       string
+	EntityLabel string
+	Entity      string
+	Severity    string
+	Topic       string
+	Title       string
+	Description string
+	log         log.Logger
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+type backstageNotification struct {
+	Recipients backstageRecipients `json:"recipients"`
+	Payload    backstagePayload    `json:"payload"`
+}
+
+type backstageRecipients struct {
+	Type      string `json:"type"`
+	EntityRef string `json:"entityRef"`
+}
+
+type backstagePayload struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Severity    string `json:"severity"`
+	Topic       string `json:"topic,omitempty"`
+	Scope       string `json:"scope"`
+}
+
+// Notify sends a notification per entity with the alerts of the entity. The notifications of an
+// alert group have the same scope, so that Backstage updates the notification of the group
+// instead of adding a new one.
+func (bn *BackstageNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	bn.log.Debug("sending Backstage notification", "notification", bn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	entities := map[string][]*types.Alert{}
+	for _, a := range as {
+		entity := bn.Entity
+		if bn.EntityLabel != "" {
+			if v := string(a.Labels[model.LabelName(bn.EntityLabel)]); v != "" {
+				entity = v
+			}
+		}
+		if entity == "" {
+			bn.log.Debug("skipping alert without Backstage entity", "alert", a.Fingerprint().String(), "label", bn.EntityLabel)
+			continue
+		}
+		ref := backstageEntityRef(entity)
+		entities[ref] = append(entities[ref], a)
+	}
+
+	refs := make([]string, 0, len(entities))
+	for ref := range entities {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if err := bn.send(ctx, ref, "grafana-"+groupKey.Hash(), entities[ref]); err != nil {
+			bn.log.Error("failed to send Backstage notification", "err", err, "entity", ref, "notification", bn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (bn *BackstageNotifier) send(ctx context.Context, ref, scope string, as []*types.Alert) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, bn.tmpl, as, bn.log, &tmplErr)
+
+	severity := bn.Severity
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		severity = "low"
+	}
+	n := backstageNotification{
+		Recipients: backstageRecipients{Type: "entity", EntityRef: ref},
+		Payload: backstagePayload{
+			Title:       strings.TrimSpace(tmpl(bn.Title)),
+			Description: tmpl(bn.Description),
+			Link:        bn.RuleListURL(bn.tmpl.ExternalURL),
+			Severity:    severity,
+			Topic:       tmpl(bn.Topic),
+			Scope:       scope,
+		},
+	}
+	if tmplErr != nil {
+		bn.log.Warn("failed to template Backstage message", "err", tmplErr.Error())
+	}
+	if bn.EntityLabel != "" && as[0].Labels[model.LabelName(bn.EntityLabel)] != "" {
+		n.Payload.Link = bn.AlertListURL(bn.tmpl.ExternalURL, model.LabelSet{model.LabelName(bn.EntityLabel): as[0].Labels[model.LabelName(bn.EntityLabel)]})
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         bn.URL + "/api/notifications/notifications",
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	if bn.Token != "" {
+		cmd.HttpHeader = map[string]string{"Authorization": "Bearer " + bn.Token}
+	}
+	return bn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// backstageEntityRef completes a reference to a Backstage entity, [<kind>:][<namespace>/]<name>,
+// with the default kind and namespace, and lower cases it as Backstage compares them case
+// insensitively.
+func backstageEntityRef(ref string) string {
+	kind, name := defaultBackstageKind, ref
+	if i := strings.Index(name, ":"); i >= 0 {
+		kind, name = name[:i], name[i+1:]
+	}
+	namespace := defaultBackstageNamespace
+	if i := strings.Index(name, "/"); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	return strings.ToLower(kind + ":" + namespace + "/" + name)
+}
+
+func (bn *BackstageNotifier) SendResolved() bool {
+	return !bn.GetDisableResolveMessage()
+}
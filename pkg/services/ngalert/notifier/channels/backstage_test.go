@@ -0,0 +1,159 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeBackstage records the notifications sent to Backstage.
+type fakeBackstage struct {
+	notificationServiceMock
+	requests []models.SendWebhookSync
+}
+
+func (f *fakeBackstage) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, *cmd)
+	return nil
+}
+
+func TestBackstageNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newAlert := func(labels model.LabelSet, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: labels}}
+		if resolved {
+			a.EndsAt = a.StartsAt.Add(1)
+		}
+		return a
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expToken     string
+		expSent      []backstageNotification
+		expInitError string
+	}{
+		{
+			name:     "Alerts are sent to the entities of their label",
+			settings: `{"url": "https://backstage.example.com/", "token": "secret", "title": "{{ .CommonLabels.alertname }}", "description": "{{ len .Alerts }} alerts"}`,
+			alerts: []*types.Alert{
+				newAlert(model.LabelSet{"alertname": "HighLatency", "backstage_entity": "payments"}, false),
+				newAlert(model.LabelSet{"alertname": "HighLatency", "backstage_entity": "Component:default/payments"}, false),
+				newAlert(model.LabelSet{"alertname": "HighLatency", "backstage_entity": "system:shop/checkout"}, true),
+				newAlert(model.LabelSet{"alertname": "HighLatency"}, false),
+			},
+			expToken: "Bearer secret",
+			expSent: []backstageNotification{
+				{
+					Recipients: backstageRecipients{Type: "entity", EntityRef: "component:default/payments"},
+					Payload: backstagePayload{
+						Title:       "HighLatency",
+						Description: "2 alerts",
+						Link:        "http://localhost/alerting/list?queryString=backstage_entity%3D%22payments%22",
+						Severity:    "high",
+						Topic:       "Grafana Alerting",
+						Scope:       "grafana-" + notify.Key("alertname").Hash(),
+					},
+				},
+				{
+					Recipients: backstageRecipients{Type: "entity", EntityRef: "system:shop/checkout"},
+					Payload: backstagePayload{
+						Title:       "HighLatency",
+						Description: "1 alerts",
+						Link:        "http://localhost/alerting/list?queryString=backstage_entity%3D%22system%3Ashop%2Fcheckout%22",
+						Severity:    "low",
+						Topic:       "Grafana Alerting",
+						Scope:       "grafana-" + notify.Key("alertname").Hash(),
+					},
+				},
+			},
+		}, {
+			name:     "Alerts without label are sent to the configured entity",
+			settings: `{"url": "https://backstage.example.com", "entity": "group:default/platform", "severity": "critical", "topic": "{{ .CommonLabels.alertname }}", "title": "firing", "description": ""}`,
+			alerts: []*types.Alert{
+				newAlert(model.LabelSet{"alertname": "DiskFull"}, false),
+			},
+			expSent: []backstageNotification{
+				{
+					Recipients: backstageRecipients{Type: "entity", EntityRef: "group:default/platform"},
+					Payload: backstagePayload{
+						Title:    "firing",
+						Link:     "http://localhost/alerting/list",
+						Severity: "critical",
+						Topic:    "DiskFull",
+						Scope:    "grafana-" + notify.Key("alertname").Hash(),
+					},
+				},
+			},
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find Backstage URL in settings",
+		}, {
+			name:         "Error when the severity is invalid",
+			settings:     `{"url": "https://backstage.example.com", "severity": "urgent"}`,
+			expInitError: `invalid severity "urgent", must be critical, high, normal or low`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "backstage_testing",
+				Type:     "backstage",
+				Settings: settingsJSON,
+			}
+
+			sender := &fakeBackstage{}
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewBackstageConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewBackstageNotifier(cfg, sender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Len(t, sender.requests, len(c.expSent))
+			for i, exp := range c.expSent {
+				require.Equal(t, "https://backstage.example.com/api/notifications/notifications", sender.requests[i].Url)
+				require.Equal(t, c.expToken, sender.requests[i].HttpHeader["Authorization"])
+				var sent backstageNotification
+				require.NoError(t, json.Unmarshal([]byte(sender.requests[i].Body), &sent))
+				require.Equal(t, exp, sent)
+			}
+		})
+	}
+}
+
+func TestBackstageEntityRef(t *testing.T) {
+	require.Equal(t, "component:default/payments", backstageEntityRef("payments"))
+	require.Equal(t, "component:shop/payments", backstageEntityRef("shop/payments"))
+	require.Equal(t, "group:default/platform", backstageEntityRef("Group:platform"))
+	require.Equal(t, "system:shop/checkout", backstageEntityRef("system:shop/checkout"))
+}
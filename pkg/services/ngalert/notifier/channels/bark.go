@@ -0,0 +1,261 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultBarkServerURL     = "https://api.day.app"
+	defaultBarkSeverityLabel = "severity"
+	defaultBarkGroup         = "Grafana"
+
+	// The interruption levels of the notifications of Bark, from the most to the least urgent.
+	barkLevelCritical      = "critical"
+	barkLevelTimeSensitive = "timeSensitive"
+	barkLevelActive        = "active"
+	barkLevelPassive       = "passive"
+)
+
+// barkLevelRanks ranks the interruption levels, so that a notification has the most urgent level
+// of its alerts.
+var barkLevelRanks = map[string]int{
+	barkLevelCritical:      3,
+	barkLevelTimeSensitive: 2,
+	barkLevelActive:        1,
+	barkLevelPassive:       0,
+}
+
+// barkLevels maps common values of the severity label to the interruption levels of iOS. Critical
+// notifications play their sound even when the device is muted.
+var barkLevels = map[string]string{
+	"critical": barkLevelCritical,
+	"urgent":   barkLevelCritical,
+	"page":     barkLevelCritical,
+	"high":     barkLevelTimeSensitive,
+	"error":    barkLevelTimeSensitive,
+	"major":    barkLevelTimeSensitive,
+	"warning":  barkLevelActive,
+	"medium":   barkLevelActive,
+	"low":      barkLevelPassive,
+	"minor":    barkLevelPassive,
+	"info":     barkLevelPassive,
+	"none":     barkLevelPassive,
+	"debug":    barkLevelPassive,
+}
+
+// barkSounds maps common values of the severity label to the sounds of Bark. Other severities play
+// the default sound of the device.
+var barkSounds = map[string]string{
+	"critical": "alarm",
+	"urgent":   "alarm",
+	"page":     "alarm",
+	"high":     "alarm",
+}
+
+type BarkConfig struct {
+	*NotificationChannelConfig
+	ServerURL     string
+	DeviceKey     string
+	SeverityLabel string
+	Levels        map[string]string
+	Sounds        map[string]string
+	Group         string
+	Title         string
+	Message       string
+}
+
+func BarkFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewBarkConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewBarkNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewBarkConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*BarkConfig, error) {
+	serverURL := strings.TrimRight(strings.TrimSpace(config.Settings.Get("serverUrl").MustString(defaultBarkServerURL)), "/")
+	if serverURL == "" {
+		serverURL = defaultBarkServerURL
+	}
+	if u, err := url.Parse(serverURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid server URL %q", serverURL)
+	}
+	deviceKey := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "deviceKey", config.Settings.Get("deviceKey").MustString()))
+	if deviceKey == "" {
+		return nil, errors.New("could not find device key in settings")
+	}
+	levels, err := severityMapFromSettings(config.Settings.Get("levels"), "level", barkLevels)
+	if err != nil {
+		return nil, err
+	}
+	for severity, level := range levels {
+		if _, ok := barkLevelRanks[level]; !ok {
+			return nil, fmt.Errorf("invalid level %q for severity %q, must be one of critical, timeSensitive, active or passive", level, severity)
+		}
+	}
+	sounds, err := severityMapFromSettings(config.Settings.Get("sounds"), "sound", barkSounds)
+	if err != nil {
+		return nil, err
+	}
+	return &BarkConfig{
+		NotificationChannelConfig: config,
+		ServerURL:                 serverURL,
+		DeviceKey:                 deviceKey,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultBarkSeverityLabel),
+		Levels:                    levels,
+		Sounds:                    sounds,
+		Group:                     config.Settings.Get("group").MustString(defaultBarkGroup),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewBarkNotifier is the constructor for the Bark notifier.
+func NewBarkNotifier(config *BarkConfig, ns notifications.WebhookSender, t *template.Template) *BarkNotifier {
+	return &BarkNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		ServerURL:     config.ServerURL,
+		DeviceKey:     config.DeviceKey,
+		SeverityLabel: config.SeverityLabel,
+		Levels:        config.Levels,
+		Sounds:        config.Sounds,
+		Group:         config.Group,
+		Title:         config.Title,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.bark"),
+		ns:            ns,
+		tmpl:          t,
+	}
+}
+
+// BarkNotifier is responsible for sending alert notifications to the iOS devices of the Bark app,
+// through the Bark server, which pushes them with its own APNs certificate.
+type BarkNotifier struct {
+	*Base
+	ServerURL     string
+	DeviceKey     string
+	SeverityLabel string
+	Levels        map[string]string
+	Sounds        map[string]string
+	Group         string
+	Title         string
+	Message       string
+	log           log.Logger
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+}
+
+type barkPush struct {
+	DeviceKey string `json:"device_key"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Level     string `json:"level,omitempty"`
+	Sound     string `json:"sound,omitempty"`
+	Group     string `json:"group,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Notify pushes the notification to the device, with the interruption level and the sound of the
+// most severe firing alert. Resolved notifications have the active level and the default sound.
+func (bn *BarkNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	bn.log.Debug("executing Bark notification", "notification", bn.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, bn.tmpl, as, bn.log, &tmplErr)
+
+	push := barkPush{
+		DeviceKey: bn.DeviceKey,
+		Title:     strings.TrimSpace(tmpl(bn.Title)),
+		Body:      strings.TrimSpace(tmpl(bn.Message)),
+		Level:     barkLevelActive,
+		Group:     tmpl(bn.Group),
+		URL:       bn.RuleListURL(bn.tmpl.ExternalURL),
+	}
+	if types.Alerts(as...).Status() == model.AlertFiring {
+		push.Level, push.Sound = bn.levelAndSound(as)
+	}
+	if tmplErr != nil {
+		bn.log.Warn("failed to template Bark message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         bn.ServerURL + "/push",
+		HttpMethod:  "POST",
+		ContentType: "application/json; charset=utf-8",
+		Body:        string(body),
+		Validation:  barkValidation,
+	}
+	if err := bn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		bn.log.Error("failed to send notification to Bark", "err", err, "notification", bn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// levelAndSound returns the most urgent level of the firing alerts, by the value of their severity
+// label, and the sound of the alert with that level. Alerts without a known severity have the
+// active level.
+func (bn *BarkNotifier) levelAndSound(as []*types.Alert) (string, string) {
+	level, sound := barkLevelActive, ""
+	rank := -1
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(bn.SeverityLabel)]))
+		l, ok := bn.Levels[severity]
+		if !ok {
+			l = barkLevelActive
+		}
+		if barkLevelRanks[l] > rank {
+			level, sound, rank = l, bn.Sounds[severity], barkLevelRanks[l]
+		}
+	}
+	return level, sound
+}
+
+// barkValidation checks the code of the responses of the Bark server, which also has the status of
+// the push to the device.
+func barkValidation(body []byte, statusCode int) error {
+	var resp struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Code != 0 && resp.Code != 200 {
+		return fmt.Errorf("the Bark server returned code %d: %s", resp.Code, resp.Message)
+	}
+	if statusCode/100 != 2 {
+		return fmt.Errorf("the Bark server returned status %d", statusCode)
+	}
+	return nil
+}
+
+func (bn *BarkNotifier) SendResolved() bool {
+	return !bn.GetDisableResolveMessage()
+}
@@ -0,0 +1,146 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestBarkNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	warning := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFilling", "severity": "warning"},
+			Annotations: model.LabelSet{"summary": "disk 80% full"},
+		},
+	}
+	critical := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "severity": "Critical", "priority": "p1"},
+			Annotations: model.LabelSet{"summary": "disk full"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DiskFull", "severity": "critical"},
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expPush      map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Critical level and sound of the most severe alert",
+			settings: `{"deviceKey": "abc123", "title": "{{ .CommonLabels.alertname }}", "message": "{{ range .Alerts }}{{ .Annotations.summary }};{{ end }}"}`,
+			alerts:   []*types.Alert{warning, critical},
+			expURL:   "https://api.day.app/push",
+			expPush: map[string]interface{}{
+				"device_key": "abc123",
+				"title":      "",
+				"body":       "disk 80% full;disk full;",
+				"level":      "critical",
+				"sound":      "alarm",
+				"group":      "Grafana",
+				"url":        "http://localhost/alerting/list",
+			},
+		}, {
+			name:     "Custom server, severity label and mappings",
+			settings: `{"serverUrl": "https://bark.example.com/", "deviceKey": "abc123", "severityLabel": "priority", "levels": "P1=timeSensitive", "sounds": {"p1": "horn"}, "group": "{{ .CommonLabels.alertname }}", "title": "t", "message": "m"}`,
+			alerts:   []*types.Alert{critical},
+			expURL:   "https://bark.example.com/push",
+			expPush: map[string]interface{}{
+				"device_key": "abc123",
+				"title":      "t",
+				"body":       "m",
+				"level":      "timeSensitive",
+				"sound":      "horn",
+				"group":      "DiskFull",
+				"url":        "http://localhost/alerting/list",
+			},
+		}, {
+			name:     "Resolved notifications have the active level",
+			settings: `{"deviceKey": "abc123", "title": "resolved", "message": "all good"}`,
+			alerts:   []*types.Alert{resolved},
+			expURL:   "https://api.day.app/push",
+			expPush: map[string]interface{}{
+				"device_key": "abc123",
+				"title":      "resolved",
+				"body":       "all good",
+				"level":      "active",
+				"group":      "Grafana",
+				"url":        "http://localhost/alerting/list",
+			},
+		}, {
+			name:         "Error when the device key is missing",
+			settings:     `{}`,
+			expInitError: "could not find device key in settings",
+		}, {
+			name:         "Error when a level is invalid",
+			settings:     `{"deviceKey": "abc123", "levels": "critical=loud"}`,
+			expInitError: `invalid level "loud" for severity "critical", must be one of critical, timeSensitive, active or passive`,
+		}, {
+			name:         "Error when the server URL is invalid",
+			settings:     `{"deviceKey": "abc123", "serverUrl": "api.day.app"}`,
+			expInitError: `invalid server URL "api.day.app"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "bark_testing",
+				Type:     "bark",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewBarkConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewBarkNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			var sent map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &sent))
+			require.Equal(t, c.expPush, sent)
+		})
+	}
+}
+
+func TestBarkValidation(t *testing.T) {
+	require.NoError(t, barkValidation([]byte(`{"code": 200, "message": "success", "timestamp": 1700000000}`), 200))
+	require.EqualError(t, barkValidation([]byte(`{"code": 400, "message": "failed to get device token: device key is empty"}`), 400), "the Bark server returned code 400: failed to get device token: device key is empty")
+	require.EqualError(t, barkValidation([]byte(`bad gateway`), 502), "the Bark server returned status 502")
+}
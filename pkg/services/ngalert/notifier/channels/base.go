@@ -1,10 +1,25 @@
 package channels
 
 import (
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 )
 
+const (
+	// EmojiFiring is the emoji used by notifiers to represent firing alerts.
+	EmojiFiring = "\u26A0\uFE0F" // Warning sign
+	// EmojiResolved is the emoji used by notifiers to represent resolved alerts.
+	EmojiResolved = "\u2705" // Check Mark Button
+
+	truncationSuffix = "..."
+)
+
 // Base is the base implementation of a notifier. It contains the common fields across all notifier types.
 type Base struct {
 	Name                  string
@@ -13,20 +28,91 @@ type Base struct {
 	IsDefault             bool
 	DisableResolveMessage bool
 
-	log log.Logger
+	capabilities   ChannelCapabilities
+	payloadVersion string
+	fieldLimits    FieldLimits
+	log            log.Logger
 }
 
 func (n *Base) GetDisableResolveMessage() bool {
 	return n.DisableResolveMessage
 }
 
+// Capabilities returns the capabilities of the notifier type.
+func (n *Base) Capabilities() ChannelCapabilities {
+	return n.capabilities
+}
+
+// PayloadVersion returns the version of the JSON payload sent by the notifier.
+func (n *Base) PayloadVersion() string {
+	return n.payloadVersion
+}
+
+// FieldLimits returns the limits of the fields of the JSON payloads sent by the notifier.
+func (n *Base) FieldLimits() FieldLimits {
+	return n.fieldLimits
+}
+
+// StateEmoji returns EmojiResolved if all the alerts are resolved and EmojiFiring otherwise.
+func (n *Base) StateEmoji(as ...*types.Alert) string {
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		return EmojiResolved
+	}
+	return EmojiFiring
+}
+
+// JoinURLPath appends additionalPath to the path of base. Unlike path.Join on the whole URL,
+// it leaves the scheme and host untouched. If base cannot be parsed it is returned as is.
+func (n *Base) JoinURLPath(base, additionalPath string) string {
+	return joinUrlPath(base, additionalPath, n.log)
+}
+
+// RuleListURL returns the URL of the alert rule list page for the given Grafana external URL.
+func (n *Base) RuleListURL(externalURL *url.URL) string {
+	if externalURL == nil {
+		return ""
+	}
+	return n.JoinURLPath(externalURL.String(), "/alerting/list")
+}
+
+// Truncate shortens s to at most maxRunes runes, replacing the end of the string with "...".
+// It never splits a multi-byte character. The second return value reports whether s was truncated.
+func (n *Base) Truncate(s string, maxRunes int) (string, bool) {
+	if maxRunes <= 0 || utf8.RuneCountInString(s) <= maxRunes {
+		return s, false
+	}
+
+	suffixLen := utf8.RuneCountInString(truncationSuffix)
+	if maxRunes <= suffixLen {
+		return string([]rune(s)[:maxRunes]), true
+	}
+
+	return string([]rune(s)[:maxRunes-suffixLen]) + truncationSuffix, true
+}
+
+// TruncateMessage truncates the message body to the maximum length supported by the notifier type.
+func (n *Base) TruncateMessage(s string) (string, bool) {
+	return n.Truncate(s, n.capabilities.MaxMessageLength)
+}
+
 func NewBase(model *models.AlertNotification) *Base {
+	capabilities, _ := GetCapabilities(model.Type)
+	// The setting is validated by NewFactoryConfig.
+	payloadVersion, err := payloadVersionFromSettings(model.Settings)
+	if err != nil {
+		payloadVersion = PayloadVersion1
+	}
+	// The setting is validated by NewFactoryConfig.
+	fieldLimits, _ := fieldLimitsFromSettings(model.Settings)
 	return &Base{
 		UID:                   model.Uid,
 		Name:                  model.Name,
 		IsDefault:             model.IsDefault,
 		Type:                  model.Type,
 		DisableResolveMessage: model.DisableResolveMessage,
+		capabilities:          capabilities,
+		payloadVersion:        payloadVersion,
+		fieldLimits:           fieldLimits,
 		log:                   log.New("alerting.notifier." + model.Name),
 	}
 }
@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestBase_StateEmoji(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "base_testing"})
+
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert2"},
+		StartsAt: time.Now().Add(-2 * time.Hour),
+		EndsAt:   time.Now().Add(-time.Hour),
+	}}
+
+	require.Equal(t, EmojiFiring, b.StateEmoji(firing))
+	require.Equal(t, EmojiFiring, b.StateEmoji(firing, resolved))
+	require.Equal(t, EmojiResolved, b.StateEmoji(resolved))
+}
+
+func TestBase_JoinURLPath(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "base_testing"})
+
+	cases := []struct {
+		name           string
+		base           string
+		additionalPath string
+		expURL         string
+	}{
+		{
+			name:           "scheme is preserved",
+			base:           "http://localhost:3000",
+			additionalPath: "/alerting/list",
+			expURL:         "http://localhost:3000/alerting/list",
+		}, {
+			name:           "sub path is kept",
+			base:           "https://grafana.example.com/grafana/",
+			additionalPath: "alerting/list",
+			expURL:         "https://grafana.example.com/grafana/alerting/list",
+		}, {
+			name:           "query is kept",
+			base:           "https://grafana.example.com/grafana?orgId=1",
+			additionalPath: "/alerting/list",
+			expURL:         "https://grafana.example.com/grafana/alerting/list?orgId=1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expURL, b.JoinURLPath(c.base, c.additionalPath))
+		})
+	}
+}
+
+func TestBase_RuleListURL(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "base_testing"})
+
+	externalURL, err := url.Parse("https://grafana.example.com/grafana")
+	require.NoError(t, err)
+
+	require.Equal(t, "https://grafana.example.com/grafana/alerting/list", b.RuleListURL(externalURL))
+	require.Equal(t, "", b.RuleListURL(nil))
+}
+
+func TestBase_Truncate(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "base_testing"})
+
+	cases := []struct {
+		name         string
+		input        string
+		maxRunes     int
+		expOutput    string
+		expTruncated bool
+	}{
+		{
+			name:      "shorter than limit",
+			input:     "alert",
+			maxRunes:  10,
+			expOutput: "alert",
+		}, {
+			name:      "equal to limit",
+			input:     "alert",
+			maxRunes:  5,
+			expOutput: "alert",
+		}, {
+			name:         "longer than limit",
+			input:        "this is a long alert title",
+			maxRunes:     10,
+			expOutput:    "this is...",
+			expTruncated: true,
+		}, {
+			name:         "multi-byte characters are not split",
+			input:        "⚠️ 警告警告警告警告",
+			maxRunes:     7,
+			expOutput:    "⚠️ 警...",
+			expTruncated: true,
+		}, {
+			name:         "limit smaller than suffix",
+			input:        "alert",
+			maxRunes:     2,
+			expOutput:    "al",
+			expTruncated: true,
+		}, {
+			name:      "no limit",
+			input:     "alert",
+			maxRunes:  0,
+			expOutput: "alert",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, truncated := b.Truncate(c.input, c.maxRunes)
+			require.Equal(t, c.expOutput, out)
+			require.Equal(t, c.expTruncated, truncated)
+		})
+	}
+}
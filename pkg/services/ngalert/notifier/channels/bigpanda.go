@@ -0,0 +1,239 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultBigPandaURL            = "https://api.bigpanda.io/data/v2/alerts"
+	defaultBigPandaPrimaryLabel   = model.AlertNameLabel
+	defaultBigPandaSecondaryLabel = "instance"
+
+	bigPandaStatusCritical = "critical"
+	bigPandaStatusOK       = "ok"
+)
+
+// bigPandaReservedFields are the fields of BigPanda alerts that are not overwritten by labels
+// and annotations of the same name.
+var bigPandaReservedFields = map[string]struct{}{
+	"app_key":            {},
+	"status":             {},
+	"timestamp":          {},
+	"primary_property":   {},
+	"secondary_property": {},
+	"description":        {},
+}
+
+type BigPandaConfig struct {
+	*NotificationChannelConfig
+	URL            string
+	AppKey         string
+	Token          string
+	PrimaryLabel   string
+	SecondaryLabel string
+	Description    string
+}
+
+func BigPandaFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewBigPandaConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewBigPandaNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewBigPandaConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*BigPandaConfig, error) {
+	appKey := config.Settings.Get("appKey").MustString()
+	if appKey == "" {
+		return nil, errors.New("could not find app key in settings")
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	return &BigPandaConfig{
+		NotificationChannelConfig: config,
+		URL:                       config.Settings.Get("url").MustString(defaultBigPandaURL),
+		AppKey:                    appKey,
+		Token:                     token,
+		PrimaryLabel:              config.Settings.Get("primaryLabel").MustString(defaultBigPandaPrimaryLabel),
+		SecondaryLabel:            config.Settings.Get("secondaryLabel").MustString(defaultBigPandaSecondaryLabel),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.title" . }}`),
+	}, nil
+}
+
+// NewBigPandaNotifier is the constructor for the BigPanda notifier.
+func NewBigPandaNotifier(config *BigPandaConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *BigPandaNotifier {
+	return &BigPandaNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:            config.URL,
+		AppKey:         config.AppKey,
+		Token:          config.Token,
+		PrimaryLabel:   config.PrimaryLabel,
+		SecondaryLabel: config.SecondaryLabel,
+		Description:    config.Description,
+		log:            log.New("alerting.notifier.bigpanda"),
+		images:         images,
+		ns:             ns,
+		tmpl:           t,
+	}
+}
+
+// BigPandaNotifier sends alerts to the alerts API of BigPanda. Each Grafana alert is a BigPanda
+// alert, identified by the values of its primary and secondary labels, that is critical while
+// it fires and ok once resolved.
+type BigPandaNotifier struct {
+	*Base
+	URL            string
+	AppKey         string
+	Token          string
+	PrimaryLabel   string
+	SecondaryLabel string
+	Description    string
+	log            log.Logger
+	images         ImageStore
+	ns             notifications.WebhookSender
+	tmpl           *template.Template
+}
+
+type bigPandaRequest struct {
+	AppKey string                   `json:"app_key"`
+	Alerts []map[string]interface{} `json:"alerts"`
+}
+
+// Notify sends the alerts to BigPanda in a single request.
+func (bn *BigPandaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	bn.log.Debug("sending BigPanda notification", "notification", bn.Name)
+
+	var tmplErr error
+	_, data := TmplText(ctx, bn.tmpl, as, bn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, bn.log, bn.images,
+		func(index int, image ngmodels.Image) error {
+			if image.URL != "" {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	req := bigPandaRequest{AppKey: bn.AppKey, Alerts: make([]map[string]interface{}, 0, len(data.Alerts))}
+	for i, alert := range data.Alerts {
+		// The description is templated for each alert, as BigPanda shows alerts on their own.
+		alertTmpl, _ := TmplText(ctx, bn.tmpl, as[i:i+1], bn.log, &tmplErr)
+		req.Alerts = append(req.Alerts, bn.bigPandaAlert(alert, alertTmpl(bn.Description)))
+	}
+	if tmplErr != nil {
+		bn.log.Warn("failed to template BigPanda message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        bn.URL,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "Bearer " + bn.Token,
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 == 2 {
+				return nil
+			}
+			var res struct {
+				Error   string `json:"error"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(body, &res); err == nil {
+				if res.Error != "" {
+					return fmt.Errorf("the BigPanda API returned status %d: %s", statusCode, res.Error)
+				}
+				if res.Message != "" {
+					return fmt.Errorf("the BigPanda API returned status %d: %s", statusCode, res.Message)
+				}
+			}
+			return fmt.Errorf("the BigPanda API returned status %d", statusCode)
+		},
+	}
+	if err := bn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		bn.log.Error("failed to send BigPanda notification", "err", err, "notification", bn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// bigPandaAlert returns the BigPanda alert of a Grafana alert, with its labels and annotations
+// as fields.
+func (bn *BigPandaNotifier) bigPandaAlert(alert ExtendedAlert, description string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(alert.Labels)+len(alert.Annotations)+10)
+	for k, v := range alert.Annotations {
+		fields[k] = v
+	}
+	// Labels identify the alert, so they win over annotations of the same name.
+	for k, v := range alert.Labels {
+		fields[k] = v
+	}
+	for k := range bigPandaReservedFields {
+		delete(fields, k)
+	}
+
+	status, timestamp := bigPandaStatusCritical, alert.StartsAt
+	if alert.Status == string(model.AlertResolved) {
+		status, timestamp = bigPandaStatusOK, alert.EndsAt
+	}
+	fields["status"] = status
+	fields["timestamp"] = timestamp.Unix()
+	fields["description"] = description
+
+	// BigPanda rejects alerts without a value for their primary property.
+	if alert.Labels[bn.PrimaryLabel] != "" {
+		fields["primary_property"] = bn.PrimaryLabel
+	} else {
+		fields["primary_property"] = model.AlertNameLabel
+	}
+	if bn.SecondaryLabel != "" && alert.Labels[bn.SecondaryLabel] != "" {
+		fields["secondary_property"] = bn.SecondaryLabel
+	}
+
+	for k, v := range map[string]string{
+		"grafana_url":   alert.GeneratorURL,
+		"silence_url":   alert.SilenceURL,
+		"dashboard_url": alert.DashboardURL,
+		"panel_url":     alert.PanelURL,
+		"image_url":     alert.ImageURL,
+	} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+func (bn *BigPandaNotifier) SendResolved() bool {
+	return !bn.GetDisableResolveMessage()
+}
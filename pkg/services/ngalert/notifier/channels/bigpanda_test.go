@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestBigPandaNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	images := newFakeImageStore(2)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(time.Hour)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Firing and resolved alerts",
+			settings: `{"appKey": "app-key", "token": "secret"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "HighCPU", "instance": "web-1", "status": "label"},
+						Annotations: model.LabelSet{"summary": "CPU is high", "instance": "annotation", "__alertImageToken__": "test-image-1"},
+						StartsAt:    startsAt,
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "HighCPU", "job": "web"},
+						StartsAt: startsAt,
+						EndsAt:   endsAt,
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"app_key": "app-key",
+				"alerts": []interface{}{
+					map[string]interface{}{
+						"alertname":          "HighCPU",
+						"instance":           "web-1",
+						"summary":            "CPU is high",
+						"status":             "critical",
+						"timestamp":          float64(startsAt.Unix()),
+						"description":        "[FIRING:1]  (HighCPU web-1 label)",
+						"primary_property":   "alertname",
+						"secondary_property": "instance",
+						"silence_url":        "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=instance%3Dweb-1&matcher=status%3Dlabel",
+						"image_url":          "https://www.example.com/test-image-1.jpg",
+					},
+					map[string]interface{}{
+						"alertname":        "HighCPU",
+						"job":              "web",
+						"status":           "ok",
+						"timestamp":        float64(endsAt.Unix()),
+						"description":      "[RESOLVED]  (HighCPU web)",
+						"primary_property": "alertname",
+						"silence_url":      "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=job%3Dweb",
+					},
+				},
+			},
+		}, {
+			name:     "Custom properties",
+			settings: `{"appKey": "app-key", "token": "secret", "primaryLabel": "service", "secondaryLabel": "job", "description": "{{ .CommonAnnotations.summary }}"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "HighCPU", "service": "checkout", "job": "api"},
+						Annotations: model.LabelSet{"summary": "CPU is high"},
+						StartsAt:    startsAt,
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"app_key": "app-key",
+				"alerts": []interface{}{
+					map[string]interface{}{
+						"alertname":          "HighCPU",
+						"service":            "checkout",
+						"job":                "api",
+						"summary":            "CPU is high",
+						"status":             "critical",
+						"timestamp":          float64(startsAt.Unix()),
+						"description":        "CPU is high",
+						"primary_property":   "service",
+						"secondary_property": "job",
+						"silence_url":        "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=job%3Dapi&matcher=service%3Dcheckout",
+					},
+				},
+			},
+		}, {
+			name:         "Error with a missing app key",
+			settings:     `{"token": "secret"}`,
+			expInitError: "could not find app key in settings",
+		}, {
+			name:         "Error with a missing token",
+			settings:     `{"appKey": "app-key"}`,
+			expInitError: "could not find token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "bigpanda_testing",
+				Type:     "bigpanda",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			cfg, err := NewBigPandaConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			n := NewBigPandaNotifier(cfg, images, webhookSender, tmpl)
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://api.bigpanda.io/data/v2/alerts", webhookSender.Webhook.Url)
+			require.Equal(t, "Bearer secret", webhookSender.Webhook.HttpHeader["Authorization"])
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+			require.Equal(t, c.expMsg, body)
+
+			validate := webhookSender.Webhook.Validation
+			require.NoError(t, validate([]byte(`{"status":"created"}`), 201))
+			require.EqualError(t, validate([]byte(`{"status":"failure","error":"invalid app_key"}`), 400), "the BigPanda API returned status 400: invalid app_key")
+			require.EqualError(t, validate(nil, 503), "the BigPanda API returned status 503")
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// brokerMessage defines the JSON object published to message brokers such as NATS, AMQP or MQTT.
+type brokerMessage struct {
+	*ExtendedData
+
+	Version  string `json:"version"`
+	GroupKey string `json:"groupKey"`
+	OrgID    int64  `json:"orgId"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	Message  string `json:"message"`
+}
+
+func newBrokerMessage(ctx context.Context, version string, tmpl func(string) string, data *ExtendedData, groupKey string, orgID int64, as ...*types.Alert) *brokerMessage {
+	data.setPayloadVersion(ctx, version, orgID)
+	msg := &brokerMessage{
+		Version:      version,
+		ExtendedData: data,
+		GroupKey:     groupKey,
+		OrgID:        orgID,
+		Title:        tmpl(DefaultMessageTitleEmbed),
+		Message:      tmpl(`{{ template "default.message" . }}`),
+	}
+	if types.Alerts(as...).Status() == model.AlertFiring {
+		msg.State = string(models.AlertStateAlerting)
+	} else {
+		msg.State = string(models.AlertStateOK)
+	}
+	return msg
+}
+
+// brokerTLSConfig builds the TLS configuration used to connect to a message broker.
+// caCert, clientCert and clientKey are PEM encoded and optional.
+func brokerTLSConfig(skipVerify bool, caCert, clientCert, clientKey string) (*tls.Config, error) {
+	// #nosec G402 -- skipping verification is an explicit opt-in of the user.
+	cfg := &tls.Config{InsecureSkipVerify: skipVerify}
+
+	if caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if clientCert != "" || clientKey != "" {
+		if clientCert == "" || clientKey == "" {
+			return nil, errors.New("both client certificate and client key are required")
+		}
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
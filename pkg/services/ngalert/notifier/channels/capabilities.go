@@ -0,0 +1,140 @@
+package channels
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ChannelCapabilities describes the features supported by a notification channel type.
+// They are exposed to the frontend alongside the channel options and used by shared
+// notification logic to degrade gracefully when a feature is not supported.
+type ChannelCapabilities struct {
+	// ImageUpload is true if the channel can upload screenshots as files.
+	ImageUpload bool `json:"imageUpload"`
+	// ImageURL is true if the channel can show or link screenshots by URL.
+	ImageURL bool `json:"imageUrl"`
+	// Markdown is true if the channel renders markdown, or a similar markup, in the message.
+	Markdown bool `json:"markdown"`
+	// Threading is true if the channel can group notifications of the same alert group in a thread.
+	Threading bool `json:"threading"`
+	// Actions is true if the channel can render buttons or links to act on the alert.
+	Actions bool `json:"actions"`
+	// MaxMessageLength is the maximum number of characters of the message body, 0 if there is no limit.
+	MaxMessageLength int `json:"maxMessageLength"`
+	// SupportsResolved is true if the channel can send a notification when alerts are resolved.
+	SupportsResolved bool `json:"supportsResolved"`
+}
+
+// SupportsImages returns true if the channel can include screenshots in any form.
+func (c ChannelCapabilities) SupportsImages() bool {
+	return c.ImageUpload || c.ImageURL
+}
+
+var channelCapabilities = map[string]ChannelCapabilities{
+	"prometheus-alertmanager": {ImageURL: true, SupportsResolved: true},
+	"amqp":                    {ImageURL: true, SupportsResolved: true},
+	"argocd":                  {SupportsResolved: true},
+	"asana":                   {SupportsResolved: true},
+	"azuredevops":             {SupportsResolved: true},
+	"backstage":               {SupportsResolved: true},
+	"bark":                    {SupportsResolved: true},
+	"bigpanda":                {ImageURL: true, SupportsResolved: true},
+	"chime":                   {Markdown: true, MaxMessageLength: 4096, SupportsResolved: true},
+	"dingding":                {Markdown: true, Actions: true, SupportsResolved: true},
+	"discord":                 {ImageUpload: true, ImageURL: true, Markdown: true, MaxMessageLength: 2000, SupportsResolved: true},
+	"dynamicwebhook":          {ImageURL: true, SupportsResolved: true},
+	"email":                   {ImageUpload: true, ImageURL: true, Actions: true, SupportsResolved: true},
+	"eventgrid":               {ImageURL: true, SupportsResolved: true},
+	"flux":                    {SupportsResolved: true},
+	"github":                  {SupportsResolved: true},
+	"gitlab":                  {SupportsResolved: true},
+	"googlechat":              {ImageURL: true, Actions: true, SupportsResolved: true},
+	"gotify":                  {ImageURL: true, Markdown: true, Actions: true, SupportsResolved: true},
+	"grpc":                    {ImageURL: true, SupportsResolved: true},
+	"homeassistant":           {SupportsResolved: true},
+	"icinga":                  {SupportsResolved: true},
+	"ifttt":                   {SupportsResolved: true},
+	"incidentio":              {SupportsResolved: true},
+	"irc":                     {MaxMessageLength: 400, SupportsResolved: true},
+	"jira":                    {SupportsResolved: true},
+	"kafka":                   {ImageURL: true, SupportsResolved: true},
+	"kubernetes":              {SupportsResolved: true},
+	"lark":                    {SupportsResolved: true},
+	"line":                    {MaxMessageLength: 1000, SupportsResolved: true},
+	"mqtt":                    {ImageURL: true, SupportsResolved: true},
+	"msgraphmail":             {ImageUpload: true, ImageURL: true, Actions: true, SupportsResolved: true},
+	"nats":                    {ImageURL: true, SupportsResolved: true},
+	"nrdp":                    {SupportsResolved: true},
+	"ntfy":                    {ImageURL: true, Actions: true, MaxMessageLength: 4096, SupportsResolved: true},
+	"opsgenie":                {ImageURL: true, SupportsResolved: true},
+	"pagerduty":               {ImageURL: true, Actions: true, SupportsResolved: true},
+	"powerautomate":           {ImageURL: true, Markdown: true, Actions: true, SupportsResolved: true},
+	"pubsub":                  {ImageURL: true, SupportsResolved: true},
+	"pulsar":                  {ImageURL: true, SupportsResolved: true},
+	"pushbullet":              {Actions: true, SupportsResolved: true},
+	"pushover":                {ImageUpload: true, Actions: true, MaxMessageLength: 1024, SupportsResolved: true},
+	"sensugo":                 {ImageURL: true, SupportsResolved: true},
+	"servicenow":              {SupportsResolved: true},
+	"slack":                   {ImageURL: true, Markdown: true, SupportsResolved: true},
+	"smsgateway":              {SupportsResolved: true},
+	"snmp":                    {SupportsResolved: true},
+	"squadcast":               {SupportsResolved: true},
+	"statuspage":              {SupportsResolved: true},
+	"symphony":                {SupportsResolved: true},
+	"syslog":                  {SupportsResolved: true},
+	"teams":                   {ImageURL: true, Markdown: true, Actions: true, SupportsResolved: true},
+	"telegram":                {ImageUpload: true, MaxMessageLength: 4096, SupportsResolved: true},
+	"threema":                 {ImageURL: true, SupportsResolved: true},
+	"trello":                  {SupportsResolved: true},
+	"victorops":               {ImageURL: true, SupportsResolved: true},
+	"webex":                   {ImageUpload: true, ImageURL: true, Markdown: true, Threading: true, Actions: true, MaxMessageLength: 7439, SupportsResolved: true},
+	"webhook":                 {ImageURL: true, SupportsResolved: true},
+	"wecom":                   {ImageUpload: true, Markdown: true, SupportsResolved: true},
+	"worker":                  {ImageURL: true, SupportsResolved: true},
+	"xmatters":                {ImageURL: true, SupportsResolved: true},
+	"zapier":                  {SupportsResolved: true},
+	"zenduty":                 {ImageURL: true, SupportsResolved: true},
+	"zoom":                    {MaxMessageLength: 4096, SupportsResolved: true},
+	"xmpp":                    {SupportsResolved: true},
+}
+
+// GetCapabilities returns the capabilities of the given channel type. The second return
+// value is false if the channel type is unknown.
+func GetCapabilities(channelType string) (ChannelCapabilities, bool) {
+	c, ok := channelCapabilities[channelType]
+	return c, ok
+}
+
+// imageStoreWithCapabilities restricts the image store to what the channel type can use:
+// channels that do not support images never query the store, and channels that cannot
+// upload images only get images that have a URL.
+func imageStoreWithCapabilities(channelType string, imageStore ImageStore) ImageStore {
+	c, ok := GetCapabilities(channelType)
+	if !ok {
+		return imageStore
+	}
+	if !c.SupportsImages() {
+		return &UnavailableImageStore{}
+	}
+	if !c.ImageUpload {
+		return &urlOnlyImageStore{ImageStore: imageStore}
+	}
+	return imageStore
+}
+
+// urlOnlyImageStore hides images that have not been uploaded to external storage.
+type urlOnlyImageStore struct {
+	ImageStore
+}
+
+func (s *urlOnlyImageStore) GetImage(ctx context.Context, token string) (*models.Image, error) {
+	img, err := s.ImageStore.GetImage(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil || img.URL == "" {
+		return nil, models.ErrImageNotFound
+	}
+	return img, nil
+}
@@ -0,0 +1,77 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestGetCapabilities(t *testing.T) {
+	t.Run("every receiver type has capabilities", func(t *testing.T) {
+		for typ := range receiverFactories {
+			_, ok := GetCapabilities(typ)
+			require.True(t, ok, "missing capabilities for %s", typ)
+		}
+	})
+
+	t.Run("unknown receiver type", func(t *testing.T) {
+		c, ok := GetCapabilities("unknown")
+		require.False(t, ok)
+		require.Equal(t, ChannelCapabilities{}, c)
+	})
+}
+
+func TestImageStoreWithCapabilities(t *testing.T) {
+	store := &fakeImageStore{Images: []*ngmodels.Image{
+		{Token: "with-url", URL: "https://www.example.com/with-url.png", Path: "/tmp/with-url.png"},
+		{Token: "without-url", Path: "/tmp/without-url.png"},
+	}}
+
+	t.Run("channels that upload images get all images", func(t *testing.T) {
+		s := imageStoreWithCapabilities("telegram", store)
+		for _, token := range []string{"with-url", "without-url"} {
+			img, err := s.GetImage(context.Background(), token)
+			require.NoError(t, err)
+			require.Equal(t, token, img.Token)
+		}
+	})
+
+	t.Run("channels that link images only get images with a URL", func(t *testing.T) {
+		s := imageStoreWithCapabilities("slack", store)
+		img, err := s.GetImage(context.Background(), "with-url")
+		require.NoError(t, err)
+		require.Equal(t, "with-url", img.Token)
+
+		_, err = s.GetImage(context.Background(), "without-url")
+		require.ErrorIs(t, err, ngmodels.ErrImageNotFound)
+	})
+
+	t.Run("channels without image support do not query the store", func(t *testing.T) {
+		s := imageStoreWithCapabilities("line", store)
+		_, err := s.GetImage(context.Background(), "with-url")
+		require.ErrorIs(t, err, ErrImagesUnavailable)
+	})
+
+	t.Run("unknown channels get the store as is", func(t *testing.T) {
+		require.Equal(t, store, imageStoreWithCapabilities("unknown", store))
+	})
+}
+
+func TestBase_TruncateMessage(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "base_testing", Type: "line"})
+	require.Equal(t, 1000, b.Capabilities().MaxMessageLength)
+
+	msg, truncated := b.TruncateMessage(strings.Repeat("a", 1001))
+	require.True(t, truncated)
+	require.Equal(t, strings.Repeat("a", 997)+"...", msg)
+
+	b = NewBase(&models.AlertNotification{Name: "base_testing", Type: "webhook"})
+	msg, truncated = b.TruncateMessage(strings.Repeat("a", 1001))
+	require.False(t, truncated)
+	require.Len(t, msg, 1001)
+}
@@ -0,0 +1,151 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	chimeMentionAll     = "all"
+	chimeMentionPresent = "present"
+)
+
+type ChimeConfig struct {
+	*NotificationChannelConfig
+	URL     string
+	Title   string
+	Message string
+	Mention string
+}
+
+func ChimeFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewChimeConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewChimeNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewChimeConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ChimeConfig, error) {
+	webhookURL := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if webhookURL == "" {
+		return nil, errors.New("could not find webhook URL in settings")
+	}
+	if _, err := url.Parse(webhookURL); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	mention := config.Settings.Get("mention").MustString()
+	if mention != "" && mention != chimeMentionAll && mention != chimeMentionPresent {
+		return nil, fmt.Errorf("invalid mention %q, must be %s or %s", mention, chimeMentionAll, chimeMentionPresent)
+	}
+	return &ChimeConfig{
+		NotificationChannelConfig: config,
+		URL:                       webhookURL,
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+		Mention:                   mention,
+	}, nil
+}
+
+// NewChimeNotifier is the constructor for the Amazon Chime notifier.
+func NewChimeNotifier(config *ChimeConfig, ns notifications.WebhookSender, t *template.Template) *ChimeNotifier {
+	return &ChimeNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:     config.URL,
+		Title:   config.Title,
+		Message: config.Message,
+		Mention: config.Mention,
+		log:     log.New("alerting.notifier.chime"),
+		ns:      ns,
+		tmpl:    t,
+	}
+}
+
+// ChimeNotifier is responsible for sending alert notifications to an Amazon Chime chat room
+// through an incoming webhook.
+type ChimeNotifier struct {
+	*Base
+	URL     string
+	Title   string
+	Message string
+	Mention string
+	log     log.Logger
+	ns      notifications.WebhookSender
+	tmpl    *template.Template
+}
+
+// Notify sends a markdown message to the chat room. The members of the room are only mentioned
+// when alerts are firing, so that resolved notifications do not page them again.
+func (cn *ChimeNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	cn.log.Debug("executing Amazon Chime notification", "notification", cn.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, cn.tmpl, as, cn.log, &tmplErr)
+
+	// Markdown messages start with /md, and mentions are written as text.
+	var header strings.Builder
+	header.WriteString("/md ")
+	if cn.Mention != "" && types.Alerts(as...).Status() == model.AlertFiring {
+		if cn.Mention == chimeMentionAll {
+			header.WriteString("@All ")
+		} else {
+			header.WriteString("@Present ")
+		}
+	}
+	// The title always refers to all the alerts of the group, even if only some of them fit in the message.
+	title := strings.TrimSpace(tmpl(cn.Title))
+	if ruleURL := cn.RuleListURL(cn.tmpl.ExternalURL); ruleURL != "" {
+		fmt.Fprintf(&header, "**[%s](%s)**", title, ruleURL)
+	} else {
+		fmt.Fprintf(&header, "**%s**", title)
+	}
+
+	content, _ := cn.FitAlerts(ctx, cn.tmpl.ExternalURL, as, func(alerts []*types.Alert) string {
+		tmpl, _ := TmplText(ctx, cn.tmpl, alerts, cn.log, &tmplErr)
+		return header.String() + "\n" + tmpl(cn.Message)
+	})
+	if tmplErr != nil {
+		cn.log.Warn("failed to template Amazon Chime message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(map[string]string{"Content": content})
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         cn.URL,
+		Body:        string(body),
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+	}
+	if err := cn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		cn.log.Error("failed to send notification to Amazon Chime", "err", err, "notification", cn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+func (cn *ChimeNotifier) SendResolved() bool {
+	return !cn.GetDisableResolveMessage()
+}
@@ -0,0 +1,100 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestChimeNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expContent   string
+		expInitError string
+	}{
+		{
+			name:       "Firing alert with the default message",
+			settings:   `{"url": "https://hooks.chime.aws/incomingwebhooks/abc?token=def"}`,
+			alerts:     []*types.Alert{firing},
+			expContent: "/md **[[FIRING:1]  (val1)](http://localhost/alerting/list)**\n**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+		}, {
+			name:       "Firing alert mentioning the present members",
+			settings:   `{"url": "https://hooks.chime.aws/incomingwebhooks/abc?token=def", "mention": "present", "title": "{{ .CommonLabels.alertname }}", "message": "{{ len .Alerts.Firing }} firing"}`,
+			alerts:     []*types.Alert{firing},
+			expContent: "/md @Present **[alert1](http://localhost/alerting/list)**\n1 firing",
+		}, {
+			name:       "Resolved alert does not mention anyone",
+			settings:   `{"url": "https://hooks.chime.aws/incomingwebhooks/abc?token=def", "mention": "all", "title": "{{ .CommonLabels.alertname }}", "message": "{{ len .Alerts.Resolved }} resolved"}`,
+			alerts:     []*types.Alert{resolved},
+			expContent: "/md **[alert1](http://localhost/alerting/list)**\n1 resolved",
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find webhook URL in settings",
+		}, {
+			name:         "Error when the mention is invalid",
+			settings:     `{"url": "https://hooks.chime.aws/incomingwebhooks/abc?token=def", "mention": "everyone"}`,
+			expInitError: `invalid mention "everyone", must be all or present`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "chime_testing",
+				Type:     "chime",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewChimeConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewChimeNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://hooks.chime.aws/incomingwebhooks/abc?token=def", webhookSender.Webhook.Url)
+			require.Equal(t, "application/json", webhookSender.Webhook.ContentType)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+			require.Equal(t, c.expContent, body["Content"])
+		})
+	}
+}
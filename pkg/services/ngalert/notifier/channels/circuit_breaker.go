@@ -0,0 +1,237 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// The contact point settings of the circuit breaker.
+	circuitBreakerFailuresSetting     = "circuitBreakerFailures"
+	circuitBreakerOpenDurationSetting = "circuitBreakerOpenDuration"
+
+	defaultCircuitBreakerOpenDuration = time.Minute
+)
+
+// ErrCircuitOpen is returned by the contact points whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("the circuit breaker of the contact point is open after consecutive failures")
+
+var (
+	circuitBreakersOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_circuit_breakers_open",
+		Help:      "The number of contact points whose circuit breaker is open or half-open.",
+	}, []string{"integration"})
+	circuitBreakerTrips = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_circuit_breaker_trips_total",
+		Help:      "The total number of times the circuit breaker of a contact point opened.",
+	}, []string{"integration"})
+	circuitBreakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_circuit_breaker_rejected_total",
+		Help:      "The total number of notifications failed at once by an open circuit breaker.",
+	}, []string{"integration"})
+)
+
+// CircuitBreakerSettings are the options of the circuit breaker of a contact point.
+type CircuitBreakerSettings struct {
+	// Failures is the number of consecutive failures that open the circuit breaker. Zero
+	// disables it.
+	Failures int
+	// OpenDuration is how long the circuit breaker stays open before a notification is let
+	// through to probe the contact point.
+	OpenDuration time.Duration
+}
+
+// circuitBreakerFromSettings returns the circuit breaker options of a contact point.
+func circuitBreakerFromSettings(settings *simplejson.Json) (CircuitBreakerSettings, error) {
+	s := CircuitBreakerSettings{OpenDuration: defaultCircuitBreakerOpenDuration}
+	if settings == nil {
+		return s, nil
+	}
+	// The number of failures is a number in provisioned contact points and a string in the UI.
+	if v := settings.Get(circuitBreakerFailuresSetting).Interface(); v != nil && v != "" {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 0 {
+			return CircuitBreakerSettings{}, fmt.Errorf("invalid %s %v, must be a positive number of failures", circuitBreakerFailuresSetting, v)
+		}
+		s.Failures = n
+	}
+	if v := strings.TrimSpace(settings.Get(circuitBreakerOpenDurationSetting).MustString()); v != "" {
+		d, err := gtime.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return CircuitBreakerSettings{}, fmt.Errorf("invalid %s %q, must be a duration such as 1m", circuitBreakerOpenDurationSetting, v)
+		}
+		s.OpenDuration = d
+	}
+	return s, nil
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker fails the notifications of a contact point at once, instead of having each of
+// them time out, once the contact point failed the number of consecutive times of its settings.
+// After the open duration, a single notification is let through to probe the contact point: the
+// circuit breaker closes if it succeeds, and opens again if it fails.
+type CircuitBreaker struct {
+	integrationType string
+	now             func() time.Time
+	log             log.Logger
+
+	mtx      sync.Mutex
+	settings CircuitBreakerSettings
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns a closed circuit breaker for a contact point of the integration type.
+func NewCircuitBreaker(integrationType string, settings CircuitBreakerSettings) *CircuitBreaker {
+	return &CircuitBreaker{
+		integrationType: integrationType,
+		settings:        settings,
+		now:             time.Now,
+		log:             log.New("alerting.notifier.circuit_breaker"),
+	}
+}
+
+// CircuitBreakerFromSettings returns the circuit breaker options of the settings of a contact
+// point, which are validated by NewFactoryConfig.
+func CircuitBreakerFromSettings(settings *simplejson.Json) CircuitBreakerSettings {
+	s, _ := circuitBreakerFromSettings(settings)
+	return s
+}
+
+// Configure changes the options of the circuit breaker, keeping its state.
+func (b *CircuitBreaker) Configure(settings CircuitBreakerSettings) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.settings = settings
+	if settings.Failures == 0 {
+		b.failures = 0
+		b.setState(circuitClosed)
+	}
+}
+
+// Close closes the circuit breaker of a contact point that is removed.
+func (b *CircuitBreaker) Close() {
+	b.Configure(CircuitBreakerSettings{})
+}
+
+// allow returns whether a notification can be sent, moving an open circuit breaker to half-open
+// once its open duration has passed.
+func (b *CircuitBreaker) allow() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.settings.OpenDuration {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		return true
+	case circuitHalfOpen:
+		// A probe is in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+// record records the result of a notification.
+func (b *CircuitBreaker) record(success bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if success || b.settings.Failures == 0 {
+		b.failures = 0
+		b.setState(circuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.settings.Failures {
+		b.openedAt = b.now()
+		if b.state != circuitOpen {
+			circuitBreakerTrips.WithLabelValues(b.integrationType).Inc()
+		}
+		b.setState(circuitOpen)
+	}
+}
+
+// setState changes the state of the circuit breaker, updating the gauge of the open ones. The
+// lock must be held.
+func (b *CircuitBreaker) setState(s circuitState) {
+	if s == b.state {
+		return
+	}
+	if b.state == circuitClosed {
+		circuitBreakersOpen.WithLabelValues(b.integrationType).Inc()
+	} else if s == circuitClosed {
+		circuitBreakersOpen.WithLabelValues(b.integrationType).Dec()
+	}
+	b.log.Info("Circuit breaker changed state", "integration", b.integrationType, "from", b.state, "to", s, "failures", b.failures)
+	b.state = s
+}
+
+// NewCircuitBreakingNotifier returns the notifier guarded by the circuit breaker, or the notifier
+// itself if the circuit breaker is nil.
+func NewCircuitBreakingNotifier(n NotificationChannel, b *CircuitBreaker) NotificationChannel {
+	if b == nil {
+		return n
+	}
+	return &circuitBreakingNotifier{NotificationChannel: n, breaker: b}
+}
+
+type circuitBreakingNotifier struct {
+	NotificationChannel
+	breaker *CircuitBreaker
+}
+
+// Notify sends the notification unless the circuit breaker is open. Dry runs are sent as is.
+func (n *circuitBreakingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	if _, ok := dryRunFromContext(ctx); ok {
+		return n.NotificationChannel.Notify(ctx, as...)
+	}
+	if !n.breaker.allow() {
+		circuitBreakerRejected.WithLabelValues(n.breaker.integrationType).Inc()
+		// The notification is not retried, so that the dispatch is not held until the
+		// circuit breaker lets notifications through again.
+		return false, ErrCircuitOpen
+	}
+	retry, err := n.NotificationChannel.Notify(ctx, as...)
+	n.breaker.record(err == nil)
+	return retry, err
+}
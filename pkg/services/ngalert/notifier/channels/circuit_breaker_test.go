@@ -0,0 +1,107 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// failingNotificationChannel fails the notifications while err is set.
+type failingNotificationChannel struct {
+	err   error
+	calls int
+}
+
+func (n *failingNotificationChannel) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	n.calls++
+	return true, n.err
+}
+
+func (n *failingNotificationChannel) SendResolved() bool {
+	return true
+}
+
+func TestCircuitBreakingNotifier(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("webhook", CircuitBreakerSettings{Failures: 2, OpenDuration: time.Minute})
+	b.now = func() time.Time { return now }
+	inner := &failingNotificationChannel{err: errors.New("connection refused")}
+	n := NewCircuitBreakingNotifier(inner, b)
+	ctx := context.Background()
+
+	// The circuit breaker opens after the consecutive failures.
+	for i := 0; i < 2; i++ {
+		retry, err := n.Notify(ctx)
+		require.EqualError(t, err, "connection refused")
+		require.True(t, retry)
+	}
+	retry, err := n.Notify(ctx)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.False(t, retry)
+	require.Equal(t, 2, inner.calls)
+
+	// Dry runs are sent as is.
+	_, err = n.Notify(WithDryRun(ctx, &DryRun{}))
+	require.EqualError(t, err, "connection refused")
+	require.Equal(t, 3, inner.calls)
+
+	// After the open duration, a failed probe opens it again.
+	now = now.Add(time.Minute)
+	_, err = n.Notify(ctx)
+	require.EqualError(t, err, "connection refused")
+	_, err = n.Notify(ctx)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, 4, inner.calls)
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	inner.err = nil
+	_, err = n.Notify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, circuitClosed, b.state)
+
+	// A success resets the consecutive failures.
+	inner.err = errors.New("connection refused")
+	_, _ = n.Notify(ctx)
+	inner.err = nil
+	_, _ = n.Notify(ctx)
+	inner.err = errors.New("connection refused")
+	_, _ = n.Notify(ctx)
+	require.Equal(t, circuitClosed, b.state)
+
+	// Disabling the circuit breaker closes it.
+	_, _ = n.Notify(ctx)
+	require.Equal(t, circuitOpen, b.state)
+	b.Close()
+	require.Equal(t, circuitClosed, b.state)
+
+	require.Equal(t, inner, NewCircuitBreakingNotifier(inner, nil))
+}
+
+func TestCircuitBreakerFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"circuitBreakerFailures": 5, "circuitBreakerOpenDuration": "2m"}`))
+	require.NoError(t, err)
+	s, err := circuitBreakerFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, CircuitBreakerSettings{Failures: 5, OpenDuration: 2 * time.Minute}, s)
+
+	s, err = circuitBreakerFromSettings(simplejson.New())
+	require.NoError(t, err)
+	require.Equal(t, CircuitBreakerSettings{OpenDuration: time.Minute}, s)
+
+	settings, err = simplejson.NewJson([]byte(`{"circuitBreakerFailures": "many"}`))
+	require.NoError(t, err)
+	_, err = circuitBreakerFromSettings(settings)
+	require.EqualError(t, err, "invalid circuitBreakerFailures many, must be a positive number of failures")
+
+	settings, err = simplejson.NewJson([]byte(`{"circuitBreakerOpenDuration": "0s"}`))
+	require.NoError(t, err)
+	_, err = circuitBreakerFromSettings(settings)
+	require.EqualError(t, err, `invalid circuitBreakerOpenDuration "0s", must be a duration such as 1m`)
+}
@@ -0,0 +1,33 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+const (
+	// The contact point settings of the TLS client of the webhook and Alertmanager contact points.
+	tlsCACertSetting     = "tlsCACert"
+	tlsClientCertSetting = "tlsClientCert"
+	tlsClientKeySetting  = "tlsClientKey"
+)
+
+// clientTLSFromSettings returns the TLS configuration of the destinations that require mutual TLS,
+// or nil if the contact point sets neither a CA certificate nor a client certificate. The client
+// certificate and key are read from the secure settings first.
+func clientTLSFromSettings(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*tls.Config, error) {
+	caCert := strings.TrimSpace(config.Settings.Get(tlsCACertSetting).MustString())
+	clientCert := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, tlsClientCertSetting, config.Settings.Get(tlsClientCertSetting).MustString()))
+	clientKey := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, tlsClientKeySetting, config.Settings.Get(tlsClientKeySetting).MustString()))
+	if caCert == "" && clientCert == "" && clientKey == "" {
+		return nil, nil
+	}
+	cfg, err := brokerTLSConfig(false, caCert, clientCert, clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+	cfg.Renegotiation = tls.RenegotiateFreelyAsClient
+	return cfg, nil
+}
@@ -0,0 +1,84 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestClientTLSFromSettings(t *testing.T) {
+	cert, certPEM, keyPEM := newClientCertificate(t, "grafana")
+	var received []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	// The secure settings are stored in plain text by this decrypt function.
+	decryptFn := func(_ context.Context, sjd map[string][]byte, key string, fallback string) string {
+		if v, ok := sjd[key]; ok {
+			return string(v)
+		}
+		return fallback
+	}
+	newConfig := func(t *testing.T, settings map[string]interface{}, secureSettings map[string][]byte) *NotificationChannelConfig {
+		b, err := json.Marshal(settings)
+		require.NoError(t, err)
+		s, err := simplejson.NewJson(b)
+		require.NoError(t, err)
+		return &NotificationChannelConfig{Name: "mtls", Type: "prometheus-alertmanager", Settings: s, SecureSettings: secureSettings}
+	}
+
+	t.Run("should be nil without settings", func(t *testing.T) {
+		cfg, err := clientTLSFromSettings(newConfig(t, map[string]interface{}{"url": server.URL}, nil), decryptFn)
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("should require both the client certificate and key", func(t *testing.T) {
+		_, err := clientTLSFromSettings(newConfig(t, map[string]interface{}{"tlsClientCert": certPEM}, nil), decryptFn)
+		require.EqualError(t, err, "invalid TLS settings: both client certificate and client key are required")
+	})
+
+	t.Run("should present the client certificate to Alertmanager", func(t *testing.T) {
+		config, err := NewAlertmanagerConfig(newConfig(t,
+			map[string]interface{}{"url": server.URL, "tlsCACert": caPEM, "tlsClientCert": certPEM},
+			map[string][]byte{"tlsClientKey": []byte(keyPEM)},
+		), decryptFn)
+		require.NoError(t, err)
+		require.NotNil(t, config.TLSConfig)
+
+		n := NewAlertmanagerNotifier(config, &UnavailableImageStore{}, nil, decryptFn)
+		ok, err := n.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"grafana"}, received)
+	})
+
+	t.Run("should send the webhooks with the client certificate", func(t *testing.T) {
+		config, err := NewWebHookConfig(newConfig(t,
+			map[string]interface{}{"url": server.URL, "tlsClientCert": certPEM},
+			map[string][]byte{"tlsClientKey": []byte(keyPEM)},
+		), decryptFn)
+		require.NoError(t, err)
+		require.NotNil(t, config.TLSConfig)
+		require.Len(t, config.TLSConfig.Certificates, 1)
+		require.Nil(t, config.TLSConfig.RootCAs)
+	})
+}
@@ -0,0 +1,111 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pooledConn is a long-lived connection of a notifier to a chat server.
+type pooledConn interface {
+	Closed() bool
+	Close() error
+}
+
+// connPool keeps a connection per key, usually the connection settings of the notifier, so that
+// notifiers of the same account share a connection that survives configuration reloads.
+// Connections are closed after they have not been used for the idle timeout.
+type connPool struct {
+	idleTimeout time.Duration
+
+	mtx   sync.Mutex
+	conns map[interface{}]*connPoolEntry
+}
+
+type connPoolEntry struct {
+	mtx  sync.Mutex
+	conn pooledConn
+	idle *time.Timer
+}
+
+func newConnPool(idleTimeout time.Duration) *connPool {
+	return &connPool{idleTimeout: idleTimeout, conns: make(map[interface{}]*connPoolEntry)}
+}
+
+// get returns the connection for the key, connecting if there is none or it was closed. The
+// boolean is true if the connection was reused.
+func (p *connPool) get(ctx context.Context, key interface{}, connect func(context.Context) (pooledConn, error)) (pooledConn, bool, error) {
+	p.mtx.Lock()
+	e, ok := p.conns[key]
+	if !ok {
+		e = &connPoolEntry{}
+		p.conns[key] = e
+	}
+	p.mtx.Unlock()
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.conn != nil && !e.conn.Closed() {
+		e.idle.Reset(p.idleTimeout)
+		return e.conn, true, nil
+	}
+
+	conn, err := connect(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	e.conn = conn
+	if e.idle == nil {
+		e.idle = time.AfterFunc(p.idleTimeout, func() {
+			e.mtx.Lock()
+			defer e.mtx.Unlock()
+			if e.conn != nil {
+				_ = e.conn.Close()
+				e.conn = nil
+			}
+		})
+	} else {
+		e.idle.Reset(p.idleTimeout)
+	}
+	return conn, false, nil
+}
+
+// do calls fn with the connection for the key. When fn fails with a connection that was reused,
+// as the server might have dropped it while it was idle, it is called again with a new connection.
+// Connections are discarded when fn fails.
+func (p *connPool) do(ctx context.Context, key interface{}, connect func(context.Context) (pooledConn, error), fn func(pooledConn) error) error {
+	conn, reused, err := p.get(ctx, key, connect)
+	if err != nil {
+		return err
+	}
+	err = fn(conn)
+	if err != nil && reused {
+		p.discard(key, conn)
+		if conn, _, err = p.get(ctx, key, connect); err != nil {
+			return err
+		}
+		err = fn(conn)
+	}
+	if err != nil {
+		p.discard(key, conn)
+	}
+	return err
+}
+
+// discard closes the connection after an error so that the next notification reconnects.
+func (p *connPool) discard(key interface{}, conn pooledConn) {
+	p.mtx.Lock()
+	e, ok := p.conns[key]
+	p.mtx.Unlock()
+	if !ok {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.conn == conn {
+		_ = e.conn.Close()
+		e.conn = nil
+	}
+}
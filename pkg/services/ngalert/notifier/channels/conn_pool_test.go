@@ -0,0 +1,76 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePooledConn struct {
+	mtx    sync.Mutex
+	closed bool
+}
+
+func (c *fakePooledConn) Closed() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.closed
+}
+
+func (c *fakePooledConn) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.closed = true
+	return nil
+}
+
+func TestConnPool(t *testing.T) {
+	var conns []*fakePooledConn
+	connect := func(context.Context) (pooledConn, error) {
+		c := &fakePooledConn{}
+		conns = append(conns, c)
+		return c, nil
+	}
+
+	t.Run("connections are reused per key", func(t *testing.T) {
+		conns = nil
+		p := newConnPool(time.Minute)
+		for _, key := range []string{"a", "a", "b"} {
+			require.NoError(t, p.do(context.Background(), key, connect, func(pooledConn) error { return nil }))
+		}
+		require.Len(t, conns, 2)
+	})
+
+	t.Run("a reused connection that fails is replaced once", func(t *testing.T) {
+		conns = nil
+		p := newConnPool(time.Minute)
+		_, _, err := p.get(context.Background(), "a", connect)
+		require.NoError(t, err)
+
+		sendErr := errors.New("broken pipe")
+		var calls int
+		err = p.do(context.Background(), "a", connect, func(pooledConn) error {
+			calls++
+			return sendErr
+		})
+		require.ErrorIs(t, err, sendErr)
+		require.Equal(t, 2, calls)
+		require.Len(t, conns, 2)
+		require.True(t, conns[0].Closed())
+		require.True(t, conns[1].Closed())
+	})
+
+	t.Run("idle connections are closed", func(t *testing.T) {
+		conns = nil
+		p := newConnPool(10 * time.Millisecond)
+		require.NoError(t, p.do(context.Background(), "a", connect, func(pooledConn) error { return nil }))
+		require.Eventually(t, conns[0].Closed, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, p.do(context.Background(), "a", connect, func(pooledConn) error { return nil }))
+		require.Len(t, conns, 2)
+	})
+}
@@ -0,0 +1,123 @@
+package channels
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/alertmanager/template"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// DashboardMetadata is the metadata of the dashboard, and of the panel if any, an alert rule is
+// linked to. Templates access it with the Dashboard method of an alert or of the notification,
+// for example {{ with .Dashboard }}Panel: {{ .PanelTitle }} ({{ .Title }}){{ end }}.
+type DashboardMetadata struct {
+	UID              string
+	Title            string
+	Folder           string
+	Tags             []string
+	PanelID          int64
+	PanelTitle       string
+	PanelDescription string
+}
+
+// DashboardMetadataFunc returns the metadata of the dashboard and panel, nil if the dashboard does
+// not exist. The panel ID is 0 when the alert rule is only linked to the dashboard.
+type DashboardMetadataFunc func(ctx context.Context, dashboardUID string, panelID int64) (*DashboardMetadata, error)
+
+type dashboardMetadataKey struct{}
+
+// WithDashboardMetadata returns a copy of the context with the function that looks up the metadata
+// of the dashboards alert rules are linked to.
+func WithDashboardMetadata(ctx context.Context, fn DashboardMetadataFunc) context.Context {
+	return context.WithValue(ctx, dashboardMetadataKey{}, fn)
+}
+
+func dashboardMetadataFromContext(ctx context.Context) (DashboardMetadataFunc, bool) {
+	fn, ok := ctx.Value(dashboardMetadataKey{}).(DashboardMetadataFunc)
+	return fn, ok && fn != nil
+}
+
+// dashboardLookup looks up the metadata of dashboards when a template uses it, at most once per
+// dashboard and panel for a notification.
+type dashboardLookup struct {
+	ctx    context.Context
+	fn     DashboardMetadataFunc
+	logger log.Logger
+	// refs are the dashboards and panels of the alerts by fingerprint, as private annotations are
+	// removed from the template data.
+	refs map[string]dashboardRef
+
+	mtx     sync.Mutex
+	results map[dashboardRef]*DashboardMetadata
+}
+
+type dashboardRef struct {
+	uid     string
+	panelID int64
+}
+
+func newDashboardLookup(ctx context.Context, fn DashboardMetadataFunc, alerts template.Alerts, logger log.Logger) *dashboardLookup {
+	refs := make(map[string]dashboardRef, len(alerts))
+	for _, a := range alerts {
+		ref := dashboardRef{uid: a.Annotations[ngmodels.DashboardUIDAnnotation]}
+		ref.panelID, _ = strconv.ParseInt(a.Annotations[ngmodels.PanelIDAnnotation], 10, 64)
+		refs[a.Fingerprint] = ref
+	}
+	return &dashboardLookup{ctx: ctx, fn: fn, logger: logger, refs: refs, results: make(map[dashboardRef]*DashboardMetadata)}
+}
+
+func (l *dashboardLookup) get(fingerprint string) *DashboardMetadata {
+	if l == nil {
+		return nil
+	}
+	ref := l.refs[fingerprint]
+	if ref.uid == "" {
+		return nil
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if m, ok := l.results[ref]; ok {
+		return m
+	}
+	m, err := l.fn(l.ctx, ref.uid, ref.panelID)
+	if err != nil {
+		// The template renders as if the alert rule was not linked to a dashboard.
+		l.logger.Warn("failed to get dashboard metadata for template", "dashboard", ref.uid, "panel", ref.panelID, "err", err)
+	}
+	l.results[ref] = m
+	return m
+}
+
+func (d *ExtendedData) setDashboardLookup(l *dashboardLookup) {
+	d.dashboards = l
+	for i := range d.Alerts {
+		d.Alerts[i].dashboards = l
+	}
+}
+
+// Dashboard returns the metadata of the dashboard and panel the alert rule is linked to, nil if
+// it is not linked to a dashboard or the dashboard does not exist.
+func (a ExtendedAlert) Dashboard() *DashboardMetadata {
+	return a.dashboards.get(a.Fingerprint)
+}
+
+// Dashboard returns the metadata of the dashboard and panel all the alerts are linked to, nil if
+// they are not all linked to the same dashboard and panel.
+func (d *ExtendedData) Dashboard() *DashboardMetadata {
+	if d.dashboards == nil || len(d.Alerts) == 0 {
+		return nil
+	}
+	first := d.Alerts[0].Fingerprint
+	for _, a := range d.Alerts[1:] {
+		if d.dashboards.refs[a.Fingerprint] != d.dashboards.refs[first] {
+			return nil
+		}
+	}
+	return d.dashboards.get(first)
+}
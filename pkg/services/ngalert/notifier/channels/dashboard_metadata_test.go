@@ -0,0 +1,102 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestDashboardMetadataTemplates(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newAlert := func(name, dashboardUID, panelID string) *types.Alert {
+		annotations := model.LabelSet{}
+		if dashboardUID != "" {
+			annotations["__dashboardUid__"] = model.LabelValue(dashboardUID)
+		}
+		if panelID != "" {
+			annotations["__panelId__"] = model.LabelValue(panelID)
+		}
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}, Annotations: annotations}}
+	}
+
+	var lookups []string
+	fn := func(_ context.Context, uid string, panelID int64) (*DashboardMetadata, error) {
+		lookups = append(lookups, uid)
+		switch uid {
+		case "slo":
+			m := &DashboardMetadata{UID: uid, Title: "SLO dashboard", Folder: "Payments", PanelID: panelID}
+			if panelID == 4 {
+				m.PanelTitle = "Checkout latency"
+			}
+			return m, nil
+		case "broken":
+			return nil, errors.New("database is locked")
+		}
+		return nil, nil
+	}
+
+	cases := []struct {
+		name       string
+		alerts     []*types.Alert
+		template   string
+		expected   string
+		expLookups []string
+	}{
+		{
+			name:       "Metadata of the dashboard and panel of the notification",
+			alerts:     []*types.Alert{newAlert("a1", "slo", "4"), newAlert("a2", "slo", "4")},
+			template:   `{{ with .Dashboard }}Panel: {{ .PanelTitle }} ({{ .Title }}, {{ .Folder }}){{ end }}`,
+			expected:   "Panel: Checkout latency (SLO dashboard, Payments)",
+			expLookups: []string{"slo"},
+		}, {
+			name:       "Metadata of each alert",
+			alerts:     []*types.Alert{newAlert("a1", "slo", "4"), newAlert("a2", "slo", ""), newAlert("a3", "", "")},
+			template:   `{{ .Dashboard }}{{ range .Alerts }}[{{ with .Dashboard }}{{ .Title }}/{{ .PanelID }}{{ end }}]{{ end }}`,
+			expected:   "<nil>[SLO dashboard/4][SLO dashboard/0][]",
+			expLookups: []string{"slo", "slo"},
+		}, {
+			name:       "Missing and failing dashboards render nothing",
+			alerts:     []*types.Alert{newAlert("a1", "missing", "1"), newAlert("a2", "broken", "1")},
+			template:   `{{ range .Alerts }}[{{ with .Dashboard }}{{ .Title }}{{ end }}]{{ end }}`,
+			expected:   "[][]",
+			expLookups: []string{"missing", "broken"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lookups = nil
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = WithDashboardMetadata(ctx, fn)
+
+			var tmplErr error
+			expand, _ := TmplText(ctx, tmpl, c.alerts, log.New("test"), &tmplErr)
+			require.Equal(t, c.expected, expand(c.template))
+			require.NoError(t, tmplErr)
+
+			// Dashboards are looked up once per notification.
+			expand(c.template)
+			require.Equal(t, c.expLookups, lookups)
+		})
+	}
+
+	t.Run("No metadata without a lookup function", func(t *testing.T) {
+		var tmplErr error
+		expand, _ := TmplText(context.Background(), tmpl, []*types.Alert{newAlert("a1", "slo", "4")}, log.New("test"), &tmplErr)
+		require.Equal(t, "<nil>", expand(`{{ .Dashboard }}`))
+		require.NoError(t, tmplErr)
+	})
+}
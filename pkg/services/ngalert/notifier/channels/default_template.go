@@ -19,19 +19,32 @@ Labels:
 {{ range .Labels.SortedPairs }} - {{ .Name }} = {{ .Value }}
 {{ end }}Annotations:
 {{ range .Annotations.SortedPairs }} - {{ .Name }} = {{ .Value }}
-{{ end }}{{ if gt (len .GeneratorURL) 0 }}Source: {{ .GeneratorURL }}
+{{ end }}{{ template "__alert_times" . }}{{ if gt (len .GeneratorURL) 0 }}Source: {{ .GeneratorURL }}
 {{ end }}{{ if gt (len .SilenceURL) 0 }}Silence: {{ .SilenceURL }}
 {{ end }}{{ if gt (len .DashboardURL) 0 }}Dashboard: {{ .DashboardURL }}
 {{ end }}{{ if gt (len .PanelURL) 0 }}Panel: {{ .PanelURL }}
 {{ end }}{{ end }}{{ end }}
 
-{{ define "default.title" }}{{ template "__subject" . }}{{ end }}
+{{ define "__alert_times" }}Started: {{ .StartsAt.Format "2006-01-02 15:04:05 MST" }}
+{{ if eq .Status "resolved" }}Ended: {{ .EndsAt.Format "2006-01-02 15:04:05 MST" }}
+{{ end }}{{ end }}
 
-{{ define "default.message" }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
+{{ define "__digest_subject" }}[DIGEST:{{ .Alerts }}] {{ .Firing }} firing, {{ .Resolved }} resolved{{ end }}
+
+{{ define "__digest_summary" }}**Digest** of {{ .Alerts }} alerts since {{ .Since.Format "2006-01-02 15:04 MST" }}
+By severity:
+{{ range .BySeverity }} - {{ .Name }}: {{ .Count }}
+{{ end }}{{ if gt (len .TopRules) 0 }}Top rules:
+{{ range .TopRules }} - {{ .Name }}: {{ .Count }}
+{{ end }}{{ end }}{{ end }}
+
+{{ define "default.title" }}{{ if .Digest }}{{ template "__digest_subject" .Digest }}{{ else }}{{ template "__subject" . }}{{ end }}{{ end }}
+
+{{ define "default.message" }}{{ if .Digest }}{{ template "__digest_summary" .Digest }}{{ else }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
 {{ template "__text_alert_list" .Alerts.Firing }}{{ if gt (len .Alerts.Resolved) 0 }}
 
 {{ end }}{{ end }}{{ if gt (len .Alerts.Resolved) 0 }}**Resolved**
-{{ template "__text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}
+{{ template "__text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}{{ end }}
 
 
 {{ define "__teams_text_alert_list" }}{{ range . }}
@@ -42,6 +55,7 @@ Labels:
 Annotations:
 {{ range .Annotations.SortedPairs }} - {{ .Name }} = {{ .Value }}
 {{ end }}
+{{ template "__alert_times" . }}
 {{ if gt (len .GeneratorURL) 0 }}Source: [{{ .GeneratorURL }}]({{ .GeneratorURL }})
 
 {{ end }}{{ if gt (len .SilenceURL) 0 }}Silence: [{{ .SilenceURL }}]({{ .SilenceURL }})
@@ -54,11 +68,31 @@ Annotations:
 {{ end }}{{ end }}
 
 
-{{ define "teams.default.message" }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
+{{ define "teams.default.message" }}{{ if .Digest }}{{ template "__digest_summary" .Digest }}{{ else }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
 {{ template "__teams_text_alert_list" .Alerts.Firing }}{{ if gt (len .Alerts.Resolved) 0 }}
 
 {{ end }}{{ end }}{{ if gt (len .Alerts.Resolved) 0 }}**Resolved**
-{{ template "__teams_text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}
+{{ template "__teams_text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}{{ end }}
+
+
+{{ define "__webex_text_alert_list" }}{{ range . }}
+Value: {{ or .ValueString "[no value]" | escapeMarkdown }}
+Labels:
+{{ range .Labels.SortedPairs }} - {{ .Name | escapeMarkdown }} = {{ .Value | escapeMarkdown }}
+{{ end }}Annotations:
+{{ range .Annotations.SortedPairs }} - {{ .Name | escapeMarkdown }} = {{ .Value | escapeMarkdown }}
+{{ end }}{{ template "__alert_times" . }}{{ if gt (len .GeneratorURL) 0 }}Source: [{{ .GeneratorURL }}]({{ .GeneratorURL }})
+{{ end }}{{ if gt (len .SilenceURL) 0 }}Silence: [{{ .SilenceURL }}]({{ .SilenceURL }})
+{{ end }}{{ if gt (len .DashboardURL) 0 }}Dashboard: [{{ .DashboardURL }}]({{ .DashboardURL }})
+{{ end }}{{ if gt (len .PanelURL) 0 }}Panel: [{{ .PanelURL }}]({{ .PanelURL }})
+{{ end }}{{ end }}{{ end }}
+
+
+{{ define "webex.default.message" }}{{ if .Digest }}{{ template "__digest_summary" .Digest }}{{ else }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
+{{ template "__webex_text_alert_list" .Alerts.Firing }}{{ if gt (len .Alerts.Resolved) 0 }}
+
+{{ end }}{{ end }}{{ if gt (len .Alerts.Resolved) 0 }}**Resolved**
+{{ template "__webex_text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}{{ end }}
 `
 
 // TemplateForTestsString is the template used for unit tests and integration tests.
@@ -88,6 +122,8 @@ Labels:
 {{ template "__text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}
 
 {{ define "teams.default.message" }}{{ template "default.message" . }}{{ end }}
+
+{{ define "webex.default.message" }}{{ template "default.message" . }}{{ end }}
 `
 
 func templateForTests(t *testing.T) *template.Template {
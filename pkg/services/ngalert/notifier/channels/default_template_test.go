@@ -15,6 +15,7 @@ import (
 )
 
 func TestDefaultTemplateString(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
 	alerts := []*types.Alert{
 		{ // Firing with dashboard and panel ID.
 			Alert: model.Alert{
@@ -22,7 +23,7 @@ func TestDefaultTemplateString(t *testing.T) {
 				Annotations: model.LabelSet{
 					"ann1": "annv1", "__dashboardUid__": "dbuid123", "__panelId__": "puid123", "__value_string__": "1234",
 				},
-				StartsAt:     time.Now(),
+				StartsAt:     now,
 				EndsAt:       time.Now().Add(1 * time.Hour),
 				GeneratorURL: "http://localhost/alert1",
 			},
@@ -30,7 +31,7 @@ func TestDefaultTemplateString(t *testing.T) {
 			Alert: model.Alert{
 				Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
 				Annotations:  model.LabelSet{"ann1": "annv2", "__value_string__": "1234"},
-				StartsAt:     time.Now(),
+				StartsAt:     now,
 				EndsAt:       time.Now().Add(2 * time.Hour),
 				GeneratorURL: "http://localhost/alert2",
 			},
@@ -40,16 +41,16 @@ func TestDefaultTemplateString(t *testing.T) {
 				Annotations: model.LabelSet{
 					"ann1": "annv3", "__dashboardUid__": "dbuid456", "__panelId__": "puid456", "__value_string__": "1234",
 				},
-				StartsAt:     time.Now().Add(-1 * time.Hour),
-				EndsAt:       time.Now().Add(-30 * time.Minute),
+				StartsAt:     now.Add(-1 * time.Hour),
+				EndsAt:       now.Add(-30 * time.Minute),
 				GeneratorURL: "http://localhost/alert3",
 			},
 		}, { // Resolved without dashboard and panel ID.
 			Alert: model.Alert{
 				Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val4"},
 				Annotations:  model.LabelSet{"ann1": "annv4", "__value_string__": "1234"},
-				StartsAt:     time.Now().Add(-2 * time.Hour),
-				EndsAt:       time.Now().Add(-3 * time.Hour),
+				StartsAt:     now.Add(-2 * time.Hour),
+				EndsAt:       now.Add(-3 * time.Hour),
 				GeneratorURL: "http://localhost/alert4",
 			},
 		},
@@ -97,6 +98,7 @@ Labels:
  - lbl1 = val1
 Annotations:
  - ann1 = annv1
+Started: 2022-09-01 12:00:00 UTC
 Source: http://localhost/alert1
 Silence: http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1
 Dashboard: http://localhost/grafana/d/dbuid123
@@ -108,6 +110,7 @@ Labels:
  - lbl1 = val2
 Annotations:
  - ann1 = annv2
+Started: 2022-09-01 12:00:00 UTC
 Source: http://localhost/alert2
 Silence: http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2
 
@@ -120,6 +123,8 @@ Labels:
  - lbl1 = val3
 Annotations:
  - ann1 = annv3
+Started: 2022-09-01 11:00:00 UTC
+Ended: 2022-09-01 11:30:00 UTC
 Source: http://localhost/alert3
 Silence: http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval3
 Dashboard: http://localhost/grafana/d/dbuid456
@@ -131,6 +136,8 @@ Labels:
  - lbl1 = val4
 Annotations:
  - ann1 = annv4
+Started: 2022-09-01 10:00:00 UTC
+Ended: 2022-09-01 09:00:00 UTC
 Source: http://localhost/alert4
 Silence: http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval4
 `,
@@ -147,6 +154,8 @@ Labels:
 Annotations:
  - ann1 = annv1
 
+Started: 2022-09-01 12:00:00 UTC
+
 Source: [http://localhost/alert1](http://localhost/alert1)
 
 Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1)
@@ -165,6 +174,8 @@ Labels:
 Annotations:
  - ann1 = annv2
 
+Started: 2022-09-01 12:00:00 UTC
+
 Source: [http://localhost/alert2](http://localhost/alert2)
 
 Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2)
@@ -182,6 +193,9 @@ Labels:
 Annotations:
  - ann1 = annv3
 
+Started: 2022-09-01 11:00:00 UTC
+Ended: 2022-09-01 11:30:00 UTC
+
 Source: [http://localhost/alert3](http://localhost/alert3)
 
 Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval3](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval3)
@@ -200,11 +214,68 @@ Labels:
 Annotations:
  - ann1 = annv4
 
+Started: 2022-09-01 10:00:00 UTC
+Ended: 2022-09-01 09:00:00 UTC
+
 Source: [http://localhost/alert4](http://localhost/alert4)
 
 Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval4](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval4)
 
 
+`,
+		},
+		{
+			templateString: `{{ template "webex.default.message" .}}`,
+			expected: `**Firing**
+
+Value: 1234
+Labels:
+ - alertname = alert1
+ - lbl1 = val1
+Annotations:
+ - ann1 = annv1
+Started: 2022-09-01 12:00:00 UTC
+Source: [http://localhost/alert1](http://localhost/alert1)
+Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1)
+Dashboard: [http://localhost/grafana/d/dbuid123](http://localhost/grafana/d/dbuid123)
+Panel: [http://localhost/grafana/d/dbuid123?viewPanel=puid123](http://localhost/grafana/d/dbuid123?viewPanel=puid123)
+
+Value: 1234
+Labels:
+ - alertname = alert1
+ - lbl1 = val2
+Annotations:
+ - ann1 = annv2
+Started: 2022-09-01 12:00:00 UTC
+Source: [http://localhost/alert2](http://localhost/alert2)
+Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2)
+
+
+**Resolved**
+
+Value: 1234
+Labels:
+ - alertname = alert1
+ - lbl1 = val3
+Annotations:
+ - ann1 = annv3
+Started: 2022-09-01 11:00:00 UTC
+Ended: 2022-09-01 11:30:00 UTC
+Source: [http://localhost/alert3](http://localhost/alert3)
+Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval3](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval3)
+Dashboard: [http://localhost/grafana/d/dbuid456](http://localhost/grafana/d/dbuid456)
+Panel: [http://localhost/grafana/d/dbuid456?viewPanel=puid456](http://localhost/grafana/d/dbuid456?viewPanel=puid456)
+
+Value: 1234
+Labels:
+ - alertname = alert1
+ - lbl1 = val4
+Annotations:
+ - ann1 = annv4
+Started: 2022-09-01 10:00:00 UTC
+Ended: 2022-09-01 09:00:00 UTC
+Source: [http://localhost/alert4](http://localhost/alert4)
+Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval4](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval4)
 `,
 		},
 	}
@@ -218,3 +289,43 @@ Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&mat
 	}
 	require.NoError(t, tmplErr)
 }
+
+func TestDefaultTemplateString_WebexEscapesMarkdown(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "path": "/var/*"},
+			Annotations: model.LabelSet{"summary": "*node_load1* is [high](http://x)", "__value_string__": "[ var='B' value=2 ]"},
+			StartsAt:    now,
+			EndsAt:      time.Now().Add(1 * time.Hour),
+		},
+	}}
+
+	// The real default template, not the one for tests, is the one that notifies Webex.
+	f, err := os.CreateTemp(t.TempDir(), "template")
+	require.NoError(t, err)
+	_, err = f.WriteString(DefaultTemplateString)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tmpl, err := template.FromGlobs(f.Name())
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+
+	var tmplErr error
+	expand, _ := TmplText(context.Background(), tmpl, alerts, log.New("default-template-test"), &tmplErr)
+	act := expand(`{{ template "webex.default.message" . }}`)
+	require.NoError(t, tmplErr)
+	require.Equal(t, `**Firing**
+
+Value: \[ var='B' value=2 \]
+Labels:
+ - alertname = alert1
+ - path = /var/\*
+Annotations:
+ - summary = \*node\_load1\* is \[high\](http://x)
+Started: 2022-09-01 12:00:00 UTC
+Silence: [http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=path%3D%2Fvar%2F%2A](http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=path%3D%2Fvar%2F%2A)
+`, act)
+}
@@ -0,0 +1,96 @@
+package channels
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// digestSeverityLabel is the label the alerts of a digest are counted by.
+	digestSeverityLabel = "severity"
+	// digestNoSeverity counts the alerts without severity.
+	digestNoSeverity = "none"
+)
+
+// DigestCount is the number of alerts of a digest with a severity or of a rule.
+type DigestCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Digest is the summary of the alerts of a receiver in digest mode, accumulated over a window
+// and sent in a single notification.
+type Digest struct {
+	// Since and Until are the window of the digest.
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+
+	Alerts   int `json:"alerts"`
+	Firing   int `json:"firing"`
+	Resolved int `json:"resolved"`
+
+	// BySeverity is the number of alerts by value of their severity label, the most frequent
+	// first.
+	BySeverity []DigestCount `json:"bySeverity"`
+	// TopRules are the rules with the most alerts, the most frequent first.
+	TopRules []DigestCount `json:"topRules"`
+}
+
+// NewDigest returns the digest of the alerts of the window, with up to topRules rules.
+func NewDigest(alerts []*types.Alert, topRules int, since, until time.Time) *Digest {
+	d := &Digest{Since: since, Until: until, Alerts: len(alerts)}
+	severities := map[string]int{}
+	rules := map[string]int{}
+	for _, a := range alerts {
+		if a.ResolvedAt(until) {
+			d.Resolved++
+		} else {
+			d.Firing++
+		}
+		severity := string(a.Labels[digestSeverityLabel])
+		if severity == "" {
+			severity = digestNoSeverity
+		}
+		severities[severity]++
+		rules[string(a.Labels[model.AlertNameLabel])]++
+	}
+	d.BySeverity = sortedDigestCounts(severities)
+	d.TopRules = sortedDigestCounts(rules)
+	if len(d.TopRules) > topRules {
+		d.TopRules = d.TopRules[:topRules]
+	}
+	return d
+}
+
+// sortedDigestCounts returns the counts, the highest first and by name for the same count.
+func sortedDigestCounts(counts map[string]int) []DigestCount {
+	res := make([]DigestCount, 0, len(counts))
+	for name, count := range counts {
+		res = append(res, DigestCount{Name: name, Count: count})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+type digestKey struct{}
+
+// WithDigest returns a copy of the context for the notification of the digest, which templates
+// can refer to as .Digest.
+func WithDigest(ctx context.Context, d *Digest) context.Context {
+	return context.WithValue(ctx, digestKey{}, d)
+}
+
+// DigestFromContext returns the digest of the notification, if any.
+func DigestFromContext(ctx context.Context) (*Digest, bool) {
+	d, ok := ctx.Value(digestKey{}).(*Digest)
+	return d, ok && d != nil
+}
@@ -0,0 +1,69 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestDigestTemplate(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	alert := func(rule, severity string, endsAt time.Time) *types.Alert {
+		labels := model.LabelSet{"alertname": model.LabelValue(rule)}
+		if severity != "" {
+			labels["severity"] = model.LabelValue(severity)
+		}
+		return &types.Alert{Alert: model.Alert{Labels: labels, StartsAt: now.Add(-time.Hour), EndsAt: endsAt}}
+	}
+	alerts := []*types.Alert{
+		alert("HighCPU", "critical", now.Add(time.Hour)),
+		alert("HighCPU", "", now.Add(time.Hour)),
+		alert("DiskFull", "critical", now.Add(-time.Minute)),
+		alert("Latency", "warning", now.Add(time.Hour)),
+	}
+
+	d := NewDigest(alerts, 2, now.Add(-time.Hour), now)
+	require.Equal(t, &Digest{
+		Since:      now.Add(-time.Hour),
+		Until:      now,
+		Alerts:     4,
+		Firing:     3,
+		Resolved:   1,
+		BySeverity: []DigestCount{{Name: "critical", Count: 2}, {Name: "none", Count: 1}, {Name: "warning", Count: 1}},
+		TopRules:   []DigestCount{{Name: "HighCPU", Count: 2}, {Name: "DiskFull", Count: 1}},
+	}, d)
+
+	f, err := os.CreateTemp(t.TempDir(), "template")
+	require.NoError(t, err)
+	_, err = f.WriteString(DefaultTemplateString)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	tmpl, err := template.FromGlobs(f.Name())
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost")
+	require.NoError(t, err)
+
+	var tmplErr error
+	expand, data := TmplText(WithDigest(context.Background(), d), tmpl, alerts, log.New("test"), &tmplErr)
+	require.Same(t, d, data.Digest)
+	require.Equal(t, "[DIGEST:4] 3 firing, 1 resolved", expand(`{{ template "default.title" . }}`))
+	require.Equal(t, `**Digest** of 4 alerts since 2022-06-01 11:00 UTC
+By severity:
+ - critical: 2
+ - none: 1
+ - warning: 1
+Top rules:
+ - HighCPU: 2
+ - DiskFull: 1
+`, expand(`{{ template "default.message" . }}`))
+	require.NoError(t, tmplErr)
+}
@@ -81,7 +81,7 @@ type DingDingNotifier struct {
 func (dd *DingDingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	dd.log.Info("sending dingding")
 
-	ruleURL := joinUrlPath(dd.tmpl.ExternalURL.String(), "/alerting/list", dd.log)
+	ruleURL := dd.RuleListURL(dd.tmpl.ExternalURL)
 
 	q := url.Values{
 		"pc_slide": {"false"},
@@ -113,7 +113,11 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	tmpl, _ := TmplText(ctx, d.tmpl, as, d.log, &tmplErr)
 
 	if d.Content != "" {
-		bodyJSON.Set("content", tmpl(d.Content))
+		content, _ := d.FitAlerts(ctx, d.tmpl.ExternalURL, as, func(alerts []*types.Alert) string {
+			tmpl, _ := TmplText(ctx, d.tmpl, alerts, d.log, &tmplErr)
+			return tmpl(d.Content)
+		})
+		bodyJSON.Set("content", content)
 		if tmplErr != nil {
 			d.log.Warn("failed to template Discord notification content", "err", tmplErr.Error())
 			// Reset tmplErr for templating other fields.
@@ -143,7 +147,7 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	color, _ := strconv.ParseInt(strings.TrimLeft(getAlertStatusColor(alerts.Status()), "#"), 16, 0)
 	linkEmbed.Set("color", color)
 
-	ruleURL := joinUrlPath(d.tmpl.ExternalURL.String(), "/alerting/list", d.log)
+	ruleURL := d.RuleListURL(d.tmpl.ExternalURL)
 	linkEmbed.Set("url", ruleURL)
 
 	embeds := []interface{}{linkEmbed}
@@ -0,0 +1,91 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+type dryRunKey int
+
+const dryRunCtxKey dryRunKey = iota
+
+// DryRunRequest is a notification that an integration would have sent.
+type DryRunRequest struct {
+	Target string
+	Body   string
+}
+
+// DryRun records the notifications rendered by the integrations instead of sending them.
+type DryRun struct {
+	mtx      sync.Mutex
+	requests []DryRunRequest
+}
+
+// WithDryRun returns a context in which the integrations record their notifications in d
+// instead of sending them.
+func WithDryRun(ctx context.Context, d *DryRun) context.Context {
+	return context.WithValue(ctx, dryRunCtxKey, d)
+}
+
+func dryRunFromContext(ctx context.Context) (*DryRun, bool) {
+	d, ok := ctx.Value(dryRunCtxKey).(*DryRun)
+	return d, ok && d != nil
+}
+
+// Requests returns the notifications recorded so far.
+func (d *DryRun) Requests() []DryRunRequest {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return append([]DryRunRequest(nil), d.requests...)
+}
+
+func (d *DryRun) record(target, body string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.requests = append(d.requests, DryRunRequest{Target: target, Body: body})
+}
+
+// recordDryRun records the notification if the context is a dry run, in which case the
+// integration must not send it.
+func recordDryRun(ctx context.Context, target, body string) bool {
+	d, ok := dryRunFromContext(ctx)
+	if ok {
+		d.record(target, body)
+	}
+	return ok
+}
+
+// urlTarget returns the scheme and host of the URL. The path and query of the URLs of many
+// integrations contain credentials, which must not end up in the dispatch history.
+func urlTarget(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// dryRunNotificationService records the webhooks and emails sent during a dry run instead of
+// sending them.
+type dryRunNotificationService struct {
+	notifications.Service
+}
+
+func (s *dryRunNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	if recordDryRun(ctx, urlTarget(cmd.Url), cmd.Body) {
+		return nil
+	}
+	return s.Service.SendWebhookSync(ctx, cmd)
+}
+
+func (s *dryRunNotificationService) SendEmailCommandHandlerSync(ctx context.Context, cmd *models.SendEmailCommandSync) error {
+	if recordDryRun(ctx, strings.Join(cmd.To, ", "), cmd.Subject) {
+		return nil
+	}
+	return s.Service.SendEmailCommandHandlerSync(ctx, cmd)
+}
@@ -0,0 +1,217 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/alertmanager/template"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultDynamicWebhookLookupField = "url"
+	defaultDynamicWebhookCacheTTL    = time.Minute
+)
+
+type DynamicWebhookConfig struct {
+	*WebhookConfig
+	LookupURL           string
+	LookupAuthorization string
+	LookupField         string
+	CacheTTL            time.Duration
+	OverrideURL         string
+	FallbackURL         string
+}
+
+func DynamicWebhookFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewDynamicWebhookConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewDynamicWebhookNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
+}
+
+func NewDynamicWebhookConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*DynamicWebhookConfig, error) {
+	lookupURL := strings.TrimSpace(config.Settings.Get("lookupUrl").MustString())
+	if lookupURL == "" {
+		return nil, errors.New("could not find lookup URL in settings")
+	}
+	if _, err := url.Parse(lookupURL); err != nil {
+		return nil, fmt.Errorf("invalid lookup URL: %w", err)
+	}
+	cacheTTL := defaultDynamicWebhookCacheTTL
+	if s := strings.TrimSpace(config.Settings.Get("cacheTtl").MustString()); s != "" {
+		d, err := gtime.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid cache TTL %q", s)
+		}
+		cacheTTL = d
+	}
+	overrideURL, err := dynamicWebhookEndpoint(config.Settings.Get("overrideUrl").MustString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid override URL: %w", err)
+	}
+	fallbackURL, err := dynamicWebhookEndpoint(config.Settings.Get("fallbackUrl").MustString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid fallback URL: %w", err)
+	}
+
+	// The URL of the webhook is the URL of the lookup until the endpoint is looked up.
+	webhook, err := newWebhookConfig(config, decryptFunc, lookupURL)
+	if err != nil {
+		return nil, err
+	}
+	return &DynamicWebhookConfig{
+		WebhookConfig:       webhook,
+		LookupURL:           lookupURL,
+		LookupAuthorization: decryptFunc(context.Background(), config.SecureSettings, "lookupAuthorization", config.Settings.Get("lookupAuthorization").MustString()),
+		LookupField:         config.Settings.Get("lookupField").MustString(defaultDynamicWebhookLookupField),
+		CacheTTL:            cacheTTL,
+		OverrideURL:         overrideURL,
+		FallbackURL:         fallbackURL,
+	}, nil
+}
+
+// dynamicWebhookEndpoint checks that an endpoint is an absolute HTTP URL, and returns it without
+// the surrounding spaces.
+func dynamicWebhookEndpoint(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%q is not an HTTP URL", s)
+	}
+	return s, nil
+}
+
+// NewDynamicWebhookNotifier is the constructor for the dynamic webhook notifier. It is a webhook
+// notifier whose requests are sent to the endpoint that is looked up at send time.
+func NewDynamicWebhookNotifier(config *DynamicWebhookConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *WebhookNotifier {
+	l := log.New("alerting.notifier.dynamicwebhook")
+	n := NewWebHookNotifier(config.WebhookConfig, &dynamicEndpointSender{
+		WebhookSender:       ns,
+		lookupURL:           config.LookupURL,
+		lookupAuthorization: config.LookupAuthorization,
+		lookupField:         config.LookupField,
+		cacheTTL:            config.CacheTTL,
+		overrideURL:         config.OverrideURL,
+		fallbackURL:         config.FallbackURL,
+		log:                 l,
+	}, images, t)
+	n.log = l
+	return n
+}
+
+// dynamicEndpointSender sends the webhooks to the endpoint returned by the lookup URL, such as the
+// webhook of the team that is on duty. The endpoint is cached for the cache TTL, and the last
+// endpoint, or else the fallback URL, is used if the lookup fails. The override URL replaces the
+// lookup, so that the notifications can be routed manually.
+type dynamicEndpointSender struct {
+	notifications.WebhookSender
+	lookupURL           string
+	lookupAuthorization string
+	lookupField         string
+	cacheTTL            time.Duration
+	overrideURL         string
+	fallbackURL         string
+	log                 log.Logger
+
+	mtx      sync.Mutex
+	endpoint string
+	expires  time.Time
+}
+
+func (s *dynamicEndpointSender) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	endpoint, err := s.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	cmd.Url = endpoint
+	return s.WebhookSender.SendWebhookSync(ctx, cmd)
+}
+
+func (s *dynamicEndpointSender) resolve(ctx context.Context) (string, error) {
+	if s.overrideURL != "" {
+		return s.overrideURL, nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.endpoint != "" && timeNow().Before(s.expires) {
+		return s.endpoint, nil
+	}
+	endpoint, err := s.lookup(ctx)
+	if err == nil {
+		s.endpoint, s.expires = endpoint, timeNow().Add(s.cacheTTL)
+		return endpoint, nil
+	}
+	switch {
+	case s.endpoint != "":
+		s.log.Warn("failed to look up the endpoint, using the last endpoint", "err", err, "endpoint", s.endpoint)
+		return s.endpoint, nil
+	case s.fallbackURL != "":
+		s.log.Warn("failed to look up the endpoint, using the fallback URL", "err", err)
+		return s.fallbackURL, nil
+	}
+	return "", fmt.Errorf("failed to look up the endpoint: %w", err)
+}
+
+// lookup returns the endpoint returned by the lookup URL, which is either the field of a JSON
+// object or the whole body of the response.
+func (s *dynamicEndpointSender) lookup(ctx context.Context) (string, error) {
+	var endpoint string
+	cmd := &models.SendWebhookSync{
+		Url:        s.lookupURL,
+		HttpMethod: "GET",
+		HttpHeader: map[string]string{"Accept": "application/json, text/plain"},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return fmt.Errorf("the lookup URL returned status %d", statusCode)
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				endpoint = string(body)
+				return nil
+			}
+			v, ok := resp[s.lookupField].(string)
+			if !ok {
+				return fmt.Errorf("the response of the lookup URL has no field %q", s.lookupField)
+			}
+			endpoint = v
+			return nil
+		},
+	}
+	if s.lookupAuthorization != "" {
+		cmd.HttpHeader["Authorization"] = s.lookupAuthorization
+	}
+	if err := s.WebhookSender.SendWebhookSync(ctx, cmd); err != nil {
+		return "", err
+	}
+
+	endpoint, err := dynamicWebhookEndpoint(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if endpoint == "" {
+		return "", errors.New("the lookup URL returned no endpoint")
+	}
+	return endpoint, nil
+}
@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeOnDuty answers the lookups of the endpoint with its response, and records the webhooks.
+type fakeOnDuty struct {
+	notificationServiceMock
+	t          *testing.T
+	status     int
+	response   string
+	lookups    int
+	sentTo     []string
+	lookupAuth string
+}
+
+func (f *fakeOnDuty) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	if cmd.Url == "https://oncall.example.com/on-duty" {
+		require.Equal(f.t, "GET", cmd.HttpMethod)
+		f.lookups++
+		f.lookupAuth = cmd.HttpHeader["Authorization"]
+		return cmd.Validation([]byte(f.response), f.status)
+	}
+	f.sentTo = append(f.sentTo, cmd.Url)
+	return nil
+}
+
+func TestDynamicWebhookNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings string) (*WebhookNotifier, *fakeOnDuty) {
+		settingsJSON, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewDynamicWebhookConfig(&NotificationChannelConfig{
+			Name:     "dynamic_testing",
+			Type:     "dynamicwebhook",
+			Settings: settingsJSON,
+		}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+		sender := &fakeOnDuty{t: t, status: 200, response: `{"url": "https://team-a.example.com/hook"}`}
+		return NewDynamicWebhookNotifier(cfg, sender, &UnavailableImageStore{}, tmpl), sender
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	notifyAt := func(t *testing.T, n *WebhookNotifier, at time.Time) error {
+		defer mockTimeNow(at)()
+		_, err := n.Notify(ctx, alert)
+		return err
+	}
+	now := time.Unix(1700000000, 0)
+
+	t.Run("caches the endpoint for the cache TTL", func(t *testing.T) {
+		n, sender := newNotifier(t, `{"lookupUrl": "https://oncall.example.com/on-duty", "lookupAuthorization": "Bearer token", "cacheTtl": "30s"}`)
+
+		require.NoError(t, notifyAt(t, n, now))
+		require.NoError(t, notifyAt(t, n, now.Add(29*time.Second)))
+		require.Equal(t, 1, sender.lookups)
+		require.Equal(t, "Bearer token", sender.lookupAuth)
+
+		sender.response = "https://team-b.example.com/hook\n"
+		require.NoError(t, notifyAt(t, n, now.Add(30*time.Second)))
+		require.Equal(t, 2, sender.lookups)
+		require.Equal(t, []string{"https://team-a.example.com/hook", "https://team-a.example.com/hook", "https://team-b.example.com/hook"}, sender.sentTo)
+	})
+
+	t.Run("uses the last endpoint when the lookup fails", func(t *testing.T) {
+		n, sender := newNotifier(t, `{"lookupUrl": "https://oncall.example.com/on-duty", "fallbackUrl": "https://fallback.example.com/hook"}`)
+
+		require.NoError(t, notifyAt(t, n, now))
+		sender.status = 503
+		require.NoError(t, notifyAt(t, n, now.Add(time.Hour)))
+		require.Equal(t, []string{"https://team-a.example.com/hook", "https://team-a.example.com/hook"}, sender.sentTo)
+	})
+
+	t.Run("uses the fallback URL when the lookup fails", func(t *testing.T) {
+		n, sender := newNotifier(t, `{"lookupUrl": "https://oncall.example.com/on-duty", "lookupField": "webhook", "fallbackUrl": "https://fallback.example.com/hook"}`)
+
+		require.NoError(t, notifyAt(t, n, now))
+		require.Equal(t, []string{"https://fallback.example.com/hook"}, sender.sentTo)
+	})
+
+	t.Run("fails when the lookup fails without fallback", func(t *testing.T) {
+		n, sender := newNotifier(t, `{"lookupUrl": "https://oncall.example.com/on-duty"}`)
+		sender.response = "not a URL"
+
+		require.EqualError(t, notifyAt(t, n, now), `failed to look up the endpoint: "not a URL" is not an HTTP URL`)
+		require.Empty(t, sender.sentTo)
+	})
+
+	t.Run("sends to the override URL without lookup", func(t *testing.T) {
+		n, sender := newNotifier(t, `{"lookupUrl": "https://oncall.example.com/on-duty", "overrideUrl": "https://incident.example.com/hook"}`)
+
+		require.NoError(t, notifyAt(t, n, now))
+		require.Equal(t, 0, sender.lookups)
+		require.Equal(t, []string{"https://incident.example.com/hook"}, sender.sentTo)
+	})
+}
+
+func TestNewDynamicWebhookConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expErr   string
+	}{
+		{name: "Error when the lookup URL is missing", settings: `{}`, expErr: "could not find lookup URL in settings"},
+		{name: "Error when the cache TTL is invalid", settings: `{"lookupUrl": "https://oncall.example.com", "cacheTtl": "soon"}`, expErr: `invalid cache TTL "soon"`},
+		{name: "Error when the override URL is invalid", settings: `{"lookupUrl": "https://oncall.example.com", "overrideUrl": "team-a"}`, expErr: `invalid override URL: "team-a" is not an HTTP URL`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewDynamicWebhookConfig(&NotificationChannelConfig{Type: "dynamicwebhook", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expErr)
+		})
+	}
+}
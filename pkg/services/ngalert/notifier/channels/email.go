@@ -3,10 +3,14 @@ package channels
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 
@@ -25,6 +29,10 @@ type EmailNotifier struct {
 	SingleEmail bool
 	Message     string
 	Subject     string
+	Tracking    bool
+	Headers     map[string]string
+	Priority    string
+	orgID       int64
 	log         log.Logger
 	ns          notifications.EmailSender
 	images      ImageStore
@@ -37,6 +45,9 @@ type EmailConfig struct {
 	Addresses   []string
 	Message     string
 	Subject     string
+	Tracking    bool
+	Headers     map[string]string
+	Priority    string
 }
 
 func EmailFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -57,11 +68,22 @@ func NewEmailConfig(config *NotificationChannelConfig) (*EmailConfig, error) {
 	}
 	// split addresses with a few different ways
 	addresses := util.SplitEmails(addressesString)
+	headers, err := parseEmailHeaders(config.Settings.Get("headers").MustString())
+	if err != nil {
+		return nil, err
+	}
+	priority := config.Settings.Get("priority").MustString(emailPriorityNormal)
+	if _, ok := emailPriorityHeaders[priority]; !ok {
+		return nil, fmt.Errorf("invalid priority %q, must be one of high, normal or low", priority)
+	}
 	return &EmailConfig{
 		NotificationChannelConfig: config,
 		SingleEmail:               config.Settings.Get("singleEmail").MustBool(false),
 		Message:                   config.Settings.Get("message").MustString(),
 		Subject:                   config.Settings.Get("subject").MustString(DefaultMessageTitleEmbed),
+		Tracking:                  config.Settings.Get("tracking").MustBool(false),
+		Headers:                   headers,
+		Priority:                  priority,
 		Addresses:                 addresses,
 	}, nil
 }
@@ -81,6 +103,10 @@ func NewEmailNotifier(config *EmailConfig, ns notifications.EmailSender, images
 		SingleEmail: config.SingleEmail,
 		Message:     config.Message,
 		Subject:     config.Subject,
+		Tracking:    config.Tracking,
+		Headers:     config.Headers,
+		Priority:    config.Priority,
+		orgID:       config.OrgID,
 		log:         log.New("alerting.notifier.email"),
 		ns:          ns,
 		images:      images,
@@ -125,6 +151,15 @@ func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 			return nil
 		}, alerts...)
 
+	// Tracking is only available when it is enabled for the organization.
+	var pixelURL string
+	if t, ok := EmailTrackingFromContext(ctx); ok && en.Tracking {
+		trackLinks(t, data)
+		ruleURL = t.LinkURL(ruleURL)
+		alertPageURL = t.LinkURL(alertPageURL)
+		pixelURL = t.PixelURL()
+	}
+
 	cmd := &models.SendEmailCommandSync{
 		SendEmailCommand: models.SendEmailCommand{
 			Subject: subject,
@@ -141,12 +176,17 @@ func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 				"AlertPageUrl":      alertPageURL,
 			},
 			EmbeddedFiles: embeddedFiles,
+			Headers:       en.headers(ctx, tmpl, data.Alerts),
 			To:            en.Addresses,
 			SingleEmail:   en.SingleEmail,
 			Template:      "ng_alert_notification",
 		},
 	}
 
+	if pixelURL != "" {
+		cmd.Data["TrackingPixelUrl"] = pixelURL
+	}
+
 	if tmplErr != nil {
 		en.log.Warn("failed to template email message", "err", tmplErr.Error())
 	}
@@ -158,6 +198,26 @@ func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 	return true, nil
 }
 
+// headers returns the headers of the email: the custom headers of the contact point, its
+// priority, and its ID in the thread of the episode of the alert group.
+func (en *EmailNotifier) headers(ctx context.Context, tmpl func(string) string, alerts []ExtendedAlert) map[string]string {
+	headers := make(map[string]string, len(en.Headers)+6)
+	for name, value := range emailPriorityHeaders[en.Priority] {
+		headers[name] = value
+	}
+	for name, value := range en.Headers {
+		// Headers are a single line, templates must not add others.
+		headers[name] = strings.Join(strings.Fields(tmpl(value)), " ")
+	}
+	if key, err := notify.ExtractGroupKey(ctx); err == nil {
+		thread := newEmailThread(en.orgID, key.String(), alerts, en.tmpl.ExternalURL)
+		headers["Message-ID"] = thread.messageID(time.Now())
+		headers["In-Reply-To"] = thread.ID()
+		headers["References"] = thread.ID()
+	}
+	return headers
+}
+
 func (en *EmailNotifier) SendResolved() bool {
 	return !en.GetDisableResolveMessage()
 }
@@ -0,0 +1,111 @@
+package channels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	emailPriorityHigh   = "high"
+	emailPriorityNormal = "normal"
+	emailPriorityLow    = "low"
+)
+
+// emailHeaderName matches the characters allowed in the names of email headers by RFC 5322.
+var emailHeaderName = regexp.MustCompile(`^[!-9;-~]+$`)
+
+// reservedEmailHeaders are set by Grafana and cannot be overwritten by the contact point. Their
+// names are in canonical format.
+var reservedEmailHeaders = map[string]struct{}{
+	"Bcc":                       {},
+	"Cc":                        {},
+	"Content-Transfer-Encoding": {},
+	"Content-Type":              {},
+	"Date":                      {},
+	"From":                      {},
+	"In-Reply-To":               {},
+	"Message-Id":                {},
+	"Mime-Version":              {},
+	"References":                {},
+	"Reply-To":                  {},
+	"Sender":                    {},
+	"Subject":                   {},
+	"To":                        {},
+}
+
+// emailPriorityHeaders are the headers of each priority understood by the common mail clients.
+var emailPriorityHeaders = map[string]map[string]string{
+	emailPriorityHigh: {
+		"X-Priority":        "1 (Highest)",
+		"X-MSMail-Priority": "High",
+		"Importance":        "high",
+	},
+	emailPriorityNormal: {},
+	emailPriorityLow: {
+		"X-Priority":        "5 (Lowest)",
+		"X-MSMail-Priority": "Low",
+		"Importance":        "low",
+	},
+}
+
+// parseEmailHeaders parses the custom headers of an email contact point, one "Name: value" per line.
+func parseEmailHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !emailHeaderName.MatchString(name) {
+			return nil, fmt.Errorf("invalid header %q, must be in the format Name: value", line)
+		}
+		if _, ok := reservedEmailHeaders[textproto.CanonicalMIMEHeaderKey(name)]; ok {
+			return nil, fmt.Errorf("header %q is set by Grafana and cannot be overwritten", name)
+		}
+		headers[name] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+// emailThread is the conversation of the emails of an episode of an alert group, from the
+// time its oldest alert started firing until it is resolved. All the emails of the episode
+// reply to the thread, so that mail clients show its firing, repeated and resolved emails
+// together, and the next episode of the group starts a new conversation.
+type emailThread struct {
+	hash string
+	host string
+}
+
+func newEmailThread(orgID int64, groupKey string, alerts []ExtendedAlert, externalURL *url.URL) emailThread {
+	var started time.Time
+	for _, a := range alerts {
+		if started.IsZero() || a.StartsAt.Before(started) {
+			started = a.StartsAt
+		}
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s/%d", orgID, groupKey, started.UnixNano())))
+	host := "grafana"
+	if externalURL != nil && externalURL.Hostname() != "" {
+		host = externalURL.Hostname()
+	}
+	return emailThread{hash: hex.EncodeToString(sum[:16]), host: host}
+}
+
+// ID returns the ID of the thread, which the emails of the thread reply to.
+func (t emailThread) ID() string {
+	return fmt.Sprintf("<alert-%s@%s>", t.hash, t.host)
+}
+
+// messageID returns the ID of an email of the thread sent at the given time. Emails have their
+// own IDs, as mail clients ignore the emails with the ID of an email they already received.
+func (t emailThread) messageID(sent time.Time) string {
+	return fmt.Sprintf("<alert-%s.%d@%s>", t.hash, sent.UnixNano(), t.host)
+}
@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmailHeaders(t *testing.T) {
+	headers, err := parseEmailHeaders("X-Priority: 1\n\n  List-Id: <ops.example.com>  \nAuto-Submitted: auto-generated\nX-Team: {{ .CommonLabels.team }}\n")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"X-Priority":     "1",
+		"List-Id":        "<ops.example.com>",
+		"Auto-Submitted": "auto-generated",
+		"X-Team":         "{{ .CommonLabels.team }}",
+	}, headers)
+
+	headers, err = parseEmailHeaders("")
+	require.NoError(t, err)
+	require.Empty(t, headers)
+
+	_, err = parseEmailHeaders("X-Priority 1")
+	require.EqualError(t, err, `invalid header "X-Priority 1", must be in the format Name: value`)
+	_, err = parseEmailHeaders("X Priority: 1")
+	require.EqualError(t, err, `invalid header "X Priority: 1", must be in the format Name: value`)
+	_, err = parseEmailHeaders("bcc: someone@example.com")
+	require.EqualError(t, err, `header "bcc" is set by Grafana and cannot be overwritten`)
+	_, err = parseEmailHeaders("MESSAGE-ID: <id@example.com>")
+	require.EqualError(t, err, `header "MESSAGE-ID" is set by Grafana and cannot be overwritten`)
+}
+
+func TestEmailThread(t *testing.T) {
+	externalURL, err := url.Parse("https://grafana.example.com:3000/base")
+	require.NoError(t, err)
+
+	started := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []ExtendedAlert{{StartsAt: started.Add(time.Minute)}, {StartsAt: started}}
+	thread := newEmailThread(1, "{}:{alertname=\"HighCPU\"}", alerts, externalURL)
+	require.Regexp(t, `^<alert-[0-9a-f]{32}@grafana\.example\.com>$`, thread.ID())
+
+	// The thread is the same for all the notifications of the episode.
+	require.Equal(t, thread, newEmailThread(1, "{}:{alertname=\"HighCPU\"}", alerts[1:], externalURL))
+	require.Equal(t, thread, newEmailThread(1, "{}:{alertname=\"HighCPU\"}", append(alerts, ExtendedAlert{StartsAt: started.Add(time.Hour)}), externalURL))
+
+	// Other organizations, groups and episodes have their own threads.
+	require.NotEqual(t, thread, newEmailThread(2, "{}:{alertname=\"HighCPU\"}", alerts, externalURL))
+	require.NotEqual(t, thread, newEmailThread(1, "{}:{alertname=\"HighMemory\"}", alerts, externalURL))
+	require.NotEqual(t, thread, newEmailThread(1, "{}:{alertname=\"HighCPU\"}", alerts[:1], externalURL))
+
+	require.Equal(t, "grafana", newEmailThread(1, "{}", alerts, nil).host)
+
+	// Each email has its own ID in the thread.
+	first, second := thread.messageID(started), thread.messageID(started.Add(time.Second))
+	require.Regexp(t, `^<alert-[0-9a-f]{32}\.[0-9]+@grafana\.example\.com>$`, first)
+	require.NotEqual(t, first, second)
+	require.NotEqual(t, thread.ID(), first)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
@@ -101,8 +103,137 @@ func TestEmailNotifier(t *testing.T) {
 			},
 		}, expected)
 	})
+	t.Run("tracking wraps the links and adds the pixel when enabled for the organization", func(t *testing.T) {
+		alerts := []*types.Alert{
+			{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring"},
+					Annotations: model.LabelSet{"__dashboardUid__": "abc"},
+				},
+			},
+		}
+		send := func(ctx context.Context, tracking bool) map[string]interface{} {
+			emailSender := mockNotificationService()
+			cfg, err := NewEmailConfig(&NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: simplejson.NewFromAny(map[string]interface{}{"addresses": "someops@example.com", "tracking": tracking}),
+			})
+			require.NoError(t, err)
+			ok, err := NewEmailNotifier(cfg, emailSender, &UnavailableImageStore{}, tmpl).Notify(ctx, alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			return emailSender.EmailSync.Data
+		}
+		ctx := WithEmailTracking(context.Background(), fakeEmailTracking{})
+
+		data := send(ctx, true)
+		require.Equal(t, "pixel", data["TrackingPixelUrl"])
+		require.Equal(t, "track:http://localhost/base/alerting/list", data["RuleUrl"])
+		alert := data["Alerts"].(ExtendedAlerts)[0]
+		require.Equal(t, "track:http://localhost/base/d/abc", alert.DashboardURL)
+		require.Empty(t, alert.PanelURL)
+
+		// Tracking is opt-in for each contact point.
+		data = send(ctx, false)
+		require.NotContains(t, data, "TrackingPixelUrl")
+		require.Equal(t, "http://localhost/base/alerting/list", data["RuleUrl"])
+
+		// Tracking is not available outside of the organizations that enable it.
+		data = send(context.Background(), true)
+		require.NotContains(t, data, "TrackingPixelUrl")
+		require.Equal(t, "http://localhost/base/d/abc", data["Alerts"].(ExtendedAlerts)[0].DashboardURL)
+	})
 }
 
+func TestEmailNotifierHeaders(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	send := func(t *testing.T, settings map[string]interface{}, alerts ...*types.Alert) map[string]string {
+		t.Helper()
+		emailSender := mockNotificationService()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			OrgID:    1,
+			Name:     "ops",
+			Type:     "email",
+			Settings: simplejson.NewFromAny(settings),
+		})
+		require.NoError(t, err)
+		ctx := notify.WithGroupKey(context.Background(), "{}:{alertname=\"HighCPU\"}")
+		ok, err := NewEmailNotifier(cfg, emailSender, &UnavailableImageStore{}, tmpl).Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return emailSender.EmailSync.Headers
+	}
+	startsAt := time.Now().Add(-time.Hour)
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighCPU", "team": "ops"}, StartsAt: startsAt}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "HighCPU", "team": "ops"},
+		StartsAt: startsAt,
+		EndsAt:   time.Now().Add(-time.Minute),
+	}}
+	thread := newEmailThread(1, "{}:{alertname=\"HighCPU\"}", []ExtendedAlert{{StartsAt: startsAt}}, externalURL).ID()
+
+	t.Run("custom headers and priority", func(t *testing.T) {
+		headers := send(t, map[string]interface{}{
+			"addresses": "someops@example.com",
+			"priority":  "high",
+			"headers":   "List-Id: <{{ .CommonLabels.team }}.alerts.example.com>\nAuto-Submitted: auto-generated\nImportance: urgent",
+		}, firing)
+		delete(headers, "Message-ID")
+		require.Equal(t, map[string]string{
+			"X-Priority":        "1 (Highest)",
+			"X-MSMail-Priority": "High",
+			// Custom headers win over the headers of the priority.
+			"Importance":     "urgent",
+			"List-Id":        "<ops.alerts.example.com>",
+			"Auto-Submitted": "auto-generated",
+			"In-Reply-To":    thread,
+			"References":     thread,
+		}, headers)
+	})
+
+	t.Run("firing, repeated and resolved emails of an episode are in the same thread", func(t *testing.T) {
+		settings := map[string]interface{}{"addresses": "someops@example.com"}
+		var messageIDs []string
+		for _, alert := range []*types.Alert{firing, firing, resolved} {
+			headers := send(t, settings, alert)
+			require.Equal(t, thread, headers["In-Reply-To"])
+			require.Equal(t, thread, headers["References"])
+			require.NotContains(t, messageIDs, headers["Message-ID"])
+			messageIDs = append(messageIDs, headers["Message-ID"])
+		}
+
+		// The next episode is a new thread.
+		refiring := &types.Alert{Alert: model.Alert{Labels: firing.Labels, StartsAt: time.Now()}}
+		require.NotEqual(t, thread, send(t, settings, refiring)["References"])
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"addresses": "someops@example.com", "priority": "urgent"}),
+		})
+		require.EqualError(t, err, `invalid priority "urgent", must be one of high, normal or low`)
+
+		_, err = NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"addresses": "someops@example.com", "headers": "To: someone@example.com"}),
+		})
+		require.EqualError(t, err, `header "To" is set by Grafana and cannot be overwritten`)
+	})
+}
+
+type fakeEmailTracking struct{}
+
+func (fakeEmailTracking) PixelURL() string             { return "pixel" }
+func (fakeEmailTracking) LinkURL(target string) string { return "track:" + target }
+
 func TestEmailNotifierIntegration(t *testing.T) {
 	ns := CreateNotificationService(t)
 
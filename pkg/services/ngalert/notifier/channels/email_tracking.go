@@ -0,0 +1,44 @@
+package channels
+
+import "context"
+
+type emailTrackingKey int
+
+const emailTrackingCtxKey emailTrackingKey = iota
+
+// EmailTracking returns the URLs served by Grafana that record when an alert email is opened
+// or one of its links is clicked.
+type EmailTracking interface {
+	// PixelURL returns the URL of the image that records the email as opened.
+	PixelURL() string
+	// LinkURL returns the URL that records the click and redirects to target.
+	LinkURL(target string) string
+}
+
+// WithEmailTracking returns a context in which the emails sent by the email notifier are tracked.
+func WithEmailTracking(ctx context.Context, t EmailTracking) context.Context {
+	return context.WithValue(ctx, emailTrackingCtxKey, t)
+}
+
+// EmailTrackingFromContext returns the tracking of the emails, if tracking is enabled for the organization.
+func EmailTrackingFromContext(ctx context.Context) (EmailTracking, bool) {
+	t, ok := ctx.Value(emailTrackingCtxKey).(EmailTracking)
+	return t, ok && t != nil
+}
+
+// trackLinks replaces the links of the data of an email with tracked links.
+func trackLinks(t EmailTracking, data *ExtendedData) {
+	link := func(u string) string {
+		if u == "" {
+			return u
+		}
+		return t.LinkURL(u)
+	}
+	for i := range data.Alerts {
+		a := &data.Alerts[i]
+		a.GeneratorURL = link(a.GeneratorURL)
+		a.SilenceURL = link(a.SilenceURL)
+		a.DashboardURL = link(a.DashboardURL)
+		a.PanelURL = link(a.PanelURL)
+	}
+}
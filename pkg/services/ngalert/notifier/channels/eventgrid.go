@@ -0,0 +1,276 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	EventGridAuthKey = "key"
+	EventGridAuthAAD = "aad"
+
+	EventGridSchemaEventGrid   = "eventgrid"
+	EventGridSchemaCloudEvents = "cloudevents"
+
+	eventGridEventTypeFiring   = "Grafana.Alerting.AlertFiring"
+	eventGridEventTypeResolved = "Grafana.Alerting.AlertResolved"
+	eventGridAADScope          = "https://eventgrid.azure.net/.default"
+)
+
+// EventGridAADTokenURL is the Azure AD token endpoint used for client credential auth. Can be overwritten in tests.
+var EventGridAADTokenURL = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+
+type EventGridConfig struct {
+	*NotificationChannelConfig
+	TopicEndpoint string
+	AuthType      string
+	AccessKey     string
+	TenantID      string
+	ClientID      string
+	ClientSecret  string
+	Schema        string
+}
+
+func EventGridFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewEventGridConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewEventGridNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewEventGridConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*EventGridConfig, error) {
+	topicEndpoint := config.Settings.Get("topicEndpoint").MustString()
+	if topicEndpoint == "" {
+		return nil, errors.New("could not find topic endpoint in settings")
+	}
+
+	cfg := &EventGridConfig{
+		NotificationChannelConfig: config,
+		TopicEndpoint:             topicEndpoint,
+		AuthType:                  config.Settings.Get("authType").MustString(EventGridAuthKey),
+		Schema:                    config.Settings.Get("schema").MustString(EventGridSchemaEventGrid),
+	}
+
+	switch cfg.AuthType {
+	case EventGridAuthKey:
+		cfg.AccessKey = decryptFunc(context.Background(), config.SecureSettings, "accessKey", config.Settings.Get("accessKey").MustString())
+		if cfg.AccessKey == "" {
+			return nil, errors.New("could not find access key in settings")
+		}
+	case EventGridAuthAAD:
+		cfg.TenantID = config.Settings.Get("tenantId").MustString()
+		cfg.ClientID = config.Settings.Get("clientId").MustString()
+		cfg.ClientSecret = decryptFunc(context.Background(), config.SecureSettings, "clientSecret", config.Settings.Get("clientSecret").MustString())
+		if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, errors.New("tenant ID, client ID and client secret are required for Azure AD authentication")
+		}
+	default:
+		return nil, fmt.Errorf("invalid authentication type %q", cfg.AuthType)
+	}
+
+	if cfg.Schema != EventGridSchemaEventGrid && cfg.Schema != EventGridSchemaCloudEvents {
+		return nil, fmt.Errorf("invalid event schema %q", cfg.Schema)
+	}
+
+	return cfg, nil
+}
+
+// NewEventGridNotifier is the constructor for the Azure Event Grid notifier.
+func NewEventGridNotifier(config *EventGridConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *EventGridNotifier {
+	n := &EventGridNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:         config.OrgID,
+		TopicEndpoint: config.TopicEndpoint,
+		AuthType:      config.AuthType,
+		AccessKey:     config.AccessKey,
+		Schema:        config.Schema,
+		log:           log.New("alerting.notifier.eventgrid"),
+		images:        images,
+		ns:            ns,
+		tmpl:          t,
+	}
+
+	if config.AuthType == EventGridAuthAAD {
+		cc := clientcredentials.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			TokenURL:     fmt.Sprintf(EventGridAADTokenURL, config.TenantID),
+			Scopes:       []string{eventGridAADScope},
+		}
+		// The token source caches the token until it expires.
+		n.tokenSource = cc.TokenSource(context.Background())
+	}
+
+	return n
+}
+
+// EventGridNotifier is responsible for publishing alert events to an Azure Event Grid topic.
+type EventGridNotifier struct {
+	*Base
+	TopicEndpoint string
+	AuthType      string
+	AccessKey     string
+	Schema        string
+	orgID         int64
+	tokenSource   oauth2.TokenSource
+	log           log.Logger
+	images        ImageStore
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+}
+
+// eventGridAlertData is the data payload of every published event.
+type eventGridAlertData struct {
+	Receiver string        `json:"receiver"`
+	GroupKey string        `json:"groupKey"`
+	OrgID    int64         `json:"orgId"`
+	Alert    ExtendedAlert `json:"alert"`
+}
+
+type eventGridEvent struct {
+	ID          string             `json:"id"`
+	EventType   string             `json:"eventType"`
+	Subject     string             `json:"subject"`
+	EventTime   time.Time          `json:"eventTime"`
+	Data        eventGridAlertData `json:"data"`
+	DataVersion string             `json:"dataVersion"`
+}
+
+type cloudEvent struct {
+	SpecVersion     string             `json:"specversion"`
+	Type            string             `json:"type"`
+	Source          string             `json:"source"`
+	ID              string             `json:"id"`
+	Time            time.Time          `json:"time"`
+	Subject         string             `json:"subject"`
+	DataContentType string             `json:"datacontenttype"`
+	Data            eventGridAlertData `json:"data"`
+}
+
+// Notify publishes one event per alert to the configured Event Grid topic.
+func (en *EventGridNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	en.log.Debug("publishing to Azure Event Grid", "notification", en.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	_, data := TmplText(ctx, en.tmpl, as, en.log, &tmplErr)
+
+	_ = withStoredImages(ctx, en.log, en.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	data.setPayloadVersion(ctx, en.PayloadVersion(), en.orgID)
+	now := timeNow().UTC()
+	events := make([]interface{}, 0, len(data.Alerts))
+	for _, alert := range data.Alerts {
+		eventType := eventGridEventTypeFiring
+		if alert.Status == "resolved" {
+			eventType = eventGridEventTypeResolved
+		}
+		payload := eventGridAlertData{
+			Receiver: data.Receiver,
+			GroupKey: groupKey.String(),
+			OrgID:    en.orgID,
+			Alert:    alert,
+		}
+		id := fmt.Sprintf("%s-%s-%d", alert.Fingerprint, alert.Status, now.UnixNano())
+		subject := "alerts/" + alert.Fingerprint
+		if name, ok := alert.Labels["alertname"]; ok && name != "" {
+			subject = fmt.Sprintf("alerts/%s/%s", name, alert.Fingerprint)
+		}
+
+		if en.Schema == EventGridSchemaCloudEvents {
+			events = append(events, cloudEvent{
+				SpecVersion:     "1.0",
+				Type:            eventType,
+				Source:          data.ExternalURL,
+				ID:              id,
+				Time:            now,
+				Subject:         subject,
+				DataContentType: "application/json",
+				Data:            payload,
+			})
+		} else {
+			events = append(events, eventGridEvent{
+				ID:          id,
+				EventType:   eventType,
+				Subject:     subject,
+				EventTime:   now,
+				Data:        payload,
+				DataVersion: en.PayloadVersion(),
+			})
+		}
+	}
+
+	body, err := json.Marshal(events)
+	if err != nil {
+		return false, err
+	}
+
+	headers := map[string]string{}
+	if en.AuthType == EventGridAuthAAD {
+		token, err := en.tokenSource.Token()
+		if err != nil {
+			return false, fmt.Errorf("failed to get Azure AD token: %w", err)
+		}
+		headers["Authorization"] = "Bearer " + token.AccessToken
+	} else {
+		headers["aeg-sas-key"] = en.AccessKey
+	}
+
+	contentType := "application/json"
+	if en.Schema == EventGridSchemaCloudEvents {
+		contentType = "application/cloudevents-batch+json; charset=utf-8"
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:         en.TopicEndpoint,
+		Body:        string(body),
+		HttpMethod:  "POST",
+		HttpHeader:  headers,
+		ContentType: contentType,
+	}
+
+	if err := en.ns.SendWebhookSync(ctx, cmd); err != nil {
+		en.log.Error("failed to publish to Azure Event Grid", "err", err, "notification", en.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (en *EventGridNotifier) SendResolved() bool {
+	return !en.GetDisableResolveMessage()
+}
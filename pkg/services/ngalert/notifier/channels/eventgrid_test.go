@@ -0,0 +1,168 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestEventGridNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "aad-token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenServer.Close()
+	origTokenURL := EventGridAADTokenURL
+	EventGridAADTokenURL = tokenServer.URL + "/%s/token"
+	defer func() { EventGridAADTokenURL = origTokenURL }()
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "test-image-1"},
+		},
+	}
+	fingerprint := alert.Fingerprint().String()
+	expAlert := ExtendedAlert{
+		Status:       "firing",
+		Labels:       map[string]string{"alertname": "alert1", "lbl1": "val1"},
+		Annotations:  map[string]string{"ann1": "annv1"},
+		Fingerprint:  fingerprint,
+		SilenceURL:   "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1",
+		ImageURL:     "https://www.example.com/test-image-1.jpg",
+		GeneratorURL: "",
+	}
+	expData := eventGridAlertData{
+		Receiver: "my_receiver",
+		GroupKey: "alertname",
+		OrgID:    1,
+		Alert:    expAlert,
+	}
+	expID := fmt.Sprintf("%s-firing-%d", fingerprint, now.UnixNano())
+
+	cases := []struct {
+		name           string
+		settings       string
+		secureSettings map[string][]byte
+		expMsg         interface{}
+		expHeaders     map[string]string
+		expContentType string
+		expInitError   string
+	}{
+		{
+			name:     "Event Grid schema with access key",
+			settings: `{"topicEndpoint": "https://topic.westeurope-1.eventgrid.azure.net/api/events", "accessKey": "secret"}`,
+			expMsg: []eventGridEvent{{
+				ID:          expID,
+				EventType:   "Grafana.Alerting.AlertFiring",
+				Subject:     "alerts/alert1/" + fingerprint,
+				EventTime:   now,
+				Data:        expData,
+				DataVersion: "1",
+			}},
+			expHeaders:     map[string]string{"aeg-sas-key": "secret"},
+			expContentType: "application/json",
+		}, {
+			name: "CloudEvents schema with Azure AD",
+			settings: `{
+				"topicEndpoint": "https://topic.westeurope-1.eventgrid.azure.net/api/events",
+				"authType": "aad",
+				"tenantId": "tenant",
+				"clientId": "client",
+				"clientSecret": "secret",
+				"schema": "cloudevents"
+			}`,
+			expMsg: []cloudEvent{{
+				SpecVersion:     "1.0",
+				Type:            "Grafana.Alerting.AlertFiring",
+				Source:          "http://localhost",
+				ID:              expID,
+				Time:            now,
+				Subject:         "alerts/alert1/" + fingerprint,
+				DataContentType: "application/json",
+				Data:            expData,
+			}},
+			expHeaders:     map[string]string{"Authorization": "Bearer aad-token"},
+			expContentType: "application/cloudevents-batch+json; charset=utf-8",
+		}, {
+			name:         "Error when topic endpoint is missing",
+			settings:     `{"accessKey": "secret"}`,
+			expInitError: "could not find topic endpoint in settings",
+		}, {
+			name:         "Error when access key is missing",
+			settings:     `{"topicEndpoint": "https://topic.westeurope-1.eventgrid.azure.net/api/events"}`,
+			expInitError: "could not find access key in settings",
+		}, {
+			name:         "Error when Azure AD settings are incomplete",
+			settings:     `{"topicEndpoint": "https://topic.westeurope-1.eventgrid.azure.net/api/events", "authType": "aad", "tenantId": "tenant"}`,
+			expInitError: "tenant ID, client ID and client secret are required for Azure AD authentication",
+		}, {
+			name:         "Error on invalid schema",
+			settings:     `{"topicEndpoint": "https://topic.westeurope-1.eventgrid.azure.net/api/events", "accessKey": "secret", "schema": "foo"}`,
+			expInitError: `invalid event schema "foo"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				OrgID:          1,
+				Name:           "eventgrid_testing",
+				Type:           "eventgrid",
+				Settings:       settingsJSON,
+				SecureSettings: c.secureSettings,
+			}
+
+			webhookSender := mockNotificationService()
+			cfg, err := NewEventGridConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			n := NewEventGridNotifier(cfg, newFakeImageStore(1), webhookSender, tmpl)
+			ok, err := n.Notify(ctx, alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			expBody, err := json.Marshal(c.expMsg)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expBody), webhookSender.Webhook.Body)
+			require.Equal(t, c.expHeaders, webhookSender.Webhook.HttpHeader)
+			require.Equal(t, c.expContentType, webhookSender.Webhook.ContentType)
+			require.Equal(t, "https://topic.westeurope-1.eventgrid.azure.net/api/events", webhookSender.Webhook.Url)
+		})
+	}
+}
@@ -3,8 +3,10 @@ package channels
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/prometheus/alertmanager/template"
@@ -17,6 +19,16 @@ type FactoryConfig struct {
 	ImageStore          ImageStore
 	// Used to retrieve image URLs for messages, or data for uploads.
 	Template *template.Template
+	// Used to persist the state of the notifiers, such as the messages to reply to. It is nil if
+	// the state is not persisted.
+	KVStore KVStore
+}
+
+// KVStore is the key-value store of the organization of the Alertmanager, in its namespace.
+type KVStore interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string) error
+	Del(ctx context.Context, key string) error
 }
 
 type ImageStore interface {
@@ -37,6 +49,65 @@ func NewFactoryConfig(config *NotificationChannelConfig, notificationService not
 	if imageStore == nil {
 		imageStore = &UnavailableImageStore{}
 	}
+	imageStore = imageStoreWithCapabilities(config.Type, imageStore)
+
+	if _, err := payloadVersionFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	if _, err := fieldLimitsFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	if _, err := resolvedSuppressionFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	if _, err := circuitBreakerFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	if _, err := throttleFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	retryPolicy, err := retryPolicyFromSettings(config.Settings)
+	if config.Type == "webex" {
+		// The rate-limited messages of Webex are retried by default.
+		retryPolicy, err = webexRetryPolicy(config.Settings)
+	}
+	if err != nil {
+		return FactoryConfig{}, err
+	}
+	if config.Proxy, err = proxyFromSettings(config.Settings); err != nil {
+		return FactoryConfig{}, err
+	}
+	signer, err := webhookSignerFromSettings(config, decryptFunc)
+	if err != nil {
+		return FactoryConfig{}, err
+	}
+
+	if config.Proxy != nil {
+		notificationService = &proxyingNotificationService{Service: notificationService, proxy: config.Proxy.ProxyFunc()}
+	}
+	if signer != nil {
+		notificationService = &signingNotificationService{Service: notificationService, signer: signer}
+	}
+	notificationService = &vcrNotificationService{Service: notificationService, config: config, decryptFunc: decryptFunc}
+	notificationService = &retryingNotificationService{
+		Service:         notificationService,
+		policy:          retryPolicy,
+		integrationType: config.Type,
+		log:             log.New("alerting.notifier.retry"),
+	}
+	notificationService = &profilingNotificationService{Service: notificationService}
+	notificationService = &dryRunNotificationService{Service: notificationService}
+	// The payloads are validated after they are transformed, and in dry runs too.
+	notificationService = &validatingNotificationService{Service: notificationService, integrationType: config.Type}
+
+	if script := config.Settings.Get(payloadTransformerSetting).MustString(); script != "" {
+		transformer, err := NewPayloadTransformer(script)
+		if err != nil {
+			return FactoryConfig{}, fmt.Errorf("invalid payload transformer: %w", err)
+		}
+		notificationService = &transformingNotificationService{Service: notificationService, transformer: transformer}
+	}
+
 	return FactoryConfig{
 		Config:              config,
 		NotificationService: notificationService,
@@ -48,23 +119,70 @@ func NewFactoryConfig(config *NotificationChannelConfig, notificationService not
 
 var receiverFactories = map[string]func(FactoryConfig) (NotificationChannel, error){
 	"prometheus-alertmanager": AlertmanagerFactory,
+	"amqp":                    AMQPFactory,
+	"argocd":                  ArgoCDFactory,
+	"asana":                   AsanaFactory,
+	"azuredevops":             AzureDevOpsFactory,
+	"backstage":               BackstageFactory,
+	"bark":                    BarkFactory,
+	"bigpanda":                BigPandaFactory,
+	"chime":                   ChimeFactory,
 	"dingding":                DingDingFactory,
 	"discord":                 DiscordFactory,
+	"dynamicwebhook":          DynamicWebhookFactory,
 	"email":                   EmailFactory,
+	"eventgrid":               EventGridFactory,
+	"flux":                    FluxFactory,
+	"github":                  GitHubFactory,
+	"gitlab":                  GitLabFactory,
 	"googlechat":              GoogleChatFactory,
+	"gotify":                  GotifyFactory,
+	"grpc":                    GRPCFactory,
+	"homeassistant":           HomeAssistantFactory,
+	"icinga":                  IcingaFactory,
+	"ifttt":                   IFTTTFactory,
+	"incidentio":              IncidentIOFactory,
+	"irc":                     IRCFactory,
+	"jira":                    JiraFactory,
 	"kafka":                   KafkaFactory,
+	"kubernetes":              KubernetesFactory,
+	"lark":                    LarkFactory,
 	"line":                    LineFactory,
+	"mqtt":                    MQTTFactory,
+	"msgraphmail":             MSGraphMailFactory,
+	"nats":                    NATSFactory,
+	"nrdp":                    NRDPFactory,
+	"ntfy":                    NtfyFactory,
 	"opsgenie":                OpsgenieFactory,
 	"pagerduty":               PagerdutyFactory,
+	"powerautomate":           PowerAutomateFactory,
+	"pubsub":                  PubSubFactory,
+	"pulsar":                  PulsarFactory,
+	"pushbullet":              PushbulletFactory,
 	"pushover":                PushoverFactory,
 	"sensugo":                 SensuGoFactory,
+	"servicenow":              ServiceNowFactory,
 	"slack":                   SlackFactory,
+	"smsgateway":              SMSGatewayFactory,
+	"snmp":                    SNMPFactory,
+	"squadcast":               SquadcastFactory,
+	"statuspage":              StatuspageFactory,
+	"symphony":                SymphonyFactory,
+	"syslog":                  SyslogFactory,
 	"teams":                   TeamsFactory,
 	"telegram":                TelegramFactory,
 	"threema":                 ThreemaFactory,
+	"trello":                  TrelloFactory,
 	"victorops":               VictorOpsFactory,
+	"webex":                   WebexFactory,
 	"webhook":                 WebHookFactory,
 	"wecom":                   WeComFactory,
+	"worker":                  WorkerFactory,
+	"xmatters":                XMattersFactory,
+	"xmpp":                    XMPPFactory,
+	"zapier":                  ZapierFactory,
+	"zenduty":                 ZendutyFactory,
+	"zoom":                    ZoomFactory,
 }
 
 func Factory(receiverType string) (func(FactoryConfig) (NotificationChannel, error), bool) {
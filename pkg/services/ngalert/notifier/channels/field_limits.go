@@ -0,0 +1,130 @@
+package channels
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/template"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	// The contact point settings holding the limits of the fields of the JSON payloads.
+	maxLabelBytesSetting      = "maxLabelBytes"
+	maxAnnotationBytesSetting = "maxAnnotationBytes"
+	maxMessageBytesSetting    = "maxMessageBytes"
+
+	// fieldTruncationMarker ends the truncated values, with the number of bytes removed.
+	fieldTruncationMarker = "...[truncated %d bytes]"
+)
+
+// FieldLimits are the limits, in bytes, of the values of the fields of the JSON payloads of a
+// contact point, for receivers that store them in columns of limited size. Zero means no limit.
+type FieldLimits struct {
+	// Label is the limit of the value of each label.
+	Label int
+	// Annotation is the limit of the value of each annotation.
+	Annotation int
+	// Message is the limit of the message.
+	Message int
+}
+
+// fieldLimitsFromSettings returns the field limits of a contact point.
+func fieldLimitsFromSettings(settings *simplejson.Json) (FieldLimits, error) {
+	var limits FieldLimits
+	if settings == nil {
+		return limits, nil
+	}
+	for _, l := range []struct {
+		setting string
+		limit   *int
+	}{
+		{setting: maxLabelBytesSetting, limit: &limits.Label},
+		{setting: maxAnnotationBytesSetting, limit: &limits.Annotation},
+		{setting: maxMessageBytesSetting, limit: &limits.Message},
+	} {
+		v := settings.Get(l.setting).Interface()
+		if v == nil || v == "" {
+			continue
+		}
+		// The limits are numbers in provisioned contact points and strings in the UI.
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 0 {
+			return FieldLimits{}, fmt.Errorf("invalid %s %v, must be a positive number of bytes", l.setting, v)
+		}
+		*l.limit = n
+	}
+	return limits, nil
+}
+
+// ApplyToData truncates the values of the labels and annotations of the data.
+func (l FieldLimits) ApplyToData(data *ExtendedData) {
+	for i := range data.Alerts {
+		data.Alerts[i].Labels = truncateKV(data.Alerts[i].Labels, l.Label)
+		data.Alerts[i].Annotations = truncateKV(data.Alerts[i].Annotations, l.Annotation)
+	}
+	data.GroupLabels = truncateKV(data.GroupLabels, l.Label)
+	data.CommonLabels = truncateKV(data.CommonLabels, l.Label)
+	data.CommonAnnotations = truncateKV(data.CommonAnnotations, l.Annotation)
+}
+
+// TruncateMessage truncates the message to the message limit.
+func (l FieldLimits) TruncateMessage(s string) string {
+	return truncateBytes(s, l.Message)
+}
+
+// truncateKV returns a copy of kv with the values truncated to limit bytes, or kv itself if no
+// value is truncated.
+func truncateKV(kv template.KV, limit int) template.KV {
+	if limit <= 0 {
+		return kv
+	}
+	var truncated template.KV
+	for k, v := range kv {
+		if len(v) <= limit {
+			continue
+		}
+		if truncated == nil {
+			truncated = make(template.KV, len(kv))
+			for k, v := range kv {
+				truncated[k] = v
+			}
+		}
+		truncated[k] = truncateBytes(v, limit)
+	}
+	if truncated == nil {
+		return kv
+	}
+	return truncated
+}
+
+// truncateBytes shortens s to at most limit bytes, ending it with the truncation marker and the
+// number of bytes removed, so that the same value is always truncated the same way. It never
+// splits a multi-byte character. The marker is left out if the limit is smaller than it.
+func truncateBytes(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	// The number of bytes removed has at most as many digits as the length of s.
+	cut := limit - len(fmt.Sprintf(fieldTruncationMarker, len(s)))
+	if cut < 0 {
+		return s[:runeBoundary(s, limit)]
+	}
+	cut = runeBoundary(s, cut)
+	// Keep the bytes left over when the number of bytes removed has fewer digits.
+	if longer := runeBoundary(s, limit-len(fmt.Sprintf(fieldTruncationMarker, len(s)-cut))); longer > cut &&
+		longer+len(fmt.Sprintf(fieldTruncationMarker, len(s)-longer)) <= limit {
+		cut = longer
+	}
+	return s[:cut] + fmt.Sprintf(fieldTruncationMarker, len(s)-cut)
+}
+
+// runeBoundary returns the largest index of s that is at most i and starts a character.
+func runeBoundary(s string, i int) int {
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,103 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestTruncateBytes(t *testing.T) {
+	cases := []struct {
+		name  string
+		s     string
+		limit int
+		exp   string
+	}{
+		{name: "no limit", s: "abcdef", limit: 0, exp: "abcdef"},
+		{name: "within the limit", s: "abcdef", limit: 6, exp: "abcdef"},
+		{name: "with the marker", s: strings.Repeat("a", 100), limit: 30, exp: "aaaaaaa...[truncated 93 bytes]"},
+		{name: "without splitting characters", s: strings.Repeat("é", 50), limit: 30, exp: "ééé...[truncated 94 bytes]"},
+		{name: "without the marker when the limit is smaller", s: "ééééé", limit: 5, exp: "éé"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			truncated := truncateBytes(c.s, c.limit)
+			require.Equal(t, c.exp, truncated)
+			require.LessOrEqual(t, len(truncated), len(c.s))
+			if c.limit > 0 {
+				require.LessOrEqual(t, len(truncated), c.limit)
+			}
+		})
+	}
+}
+
+func TestFieldLimitsFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"maxLabelBytes": 256, "maxAnnotationBytes": "1024", "maxMessageBytes": ""}`))
+	require.NoError(t, err)
+	limits, err := fieldLimitsFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, FieldLimits{Label: 256, Annotation: 1024}, limits)
+
+	settings, err = simplejson.NewJson([]byte(`{"maxMessageBytes": "64KB"}`))
+	require.NoError(t, err)
+	_, err = fieldLimitsFromSettings(settings)
+	require.EqualError(t, err, "invalid maxMessageBytes 64KB, must be a positive number of bytes")
+}
+
+func TestWebhookNotifierFieldLimits(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"url": "http://localhost/test", "maxLabelBytes": 32, "maxAnnotationBytes": "40", "maxMessageBytes": 64}`))
+	require.NoError(t, err)
+	m := &NotificationChannelConfig{
+		Name:     "webhook_testing",
+		Type:     "webhook",
+		Settings: settingsJSON,
+	}
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewWebHookConfig(m, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+
+	webhookSender := mockNotificationService()
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ok, err := NewWebHookNotifier(cfg, webhookSender, &UnavailableImageStore{}, tmpl).Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "query": model.LabelValue(strings.Repeat("q", 100))},
+			Annotations: model.LabelSet{"description": model.LabelValue(strings.Repeat("d", 100))},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg struct {
+		Alerts []struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"alerts"`
+		CommonLabels map[string]string `json:"commonLabels"`
+		Message      string            `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "alert1", msg.Alerts[0].Labels["alertname"])
+	require.Equal(t, "qqqqqqqqq...[truncated 91 bytes]", msg.Alerts[0].Labels["query"])
+	require.Equal(t, msg.Alerts[0].Labels["query"], msg.CommonLabels["query"])
+	require.Equal(t, "ddddddddddddddddd...[truncated 83 bytes]", msg.Alerts[0].Annotations["description"])
+	require.LessOrEqual(t, len(msg.Message), 64)
+	require.True(t, strings.HasPrefix(msg.Message, "**Firing**"))
+}
@@ -0,0 +1,215 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultFluxURL         = "http://notification-controller.flux-system.svc.cluster.local/"
+	defaultFluxObjectKind  = "Kustomization"
+	defaultFluxObjectLabel = "flux_object"
+	defaultFluxNamespace   = "flux-system"
+
+	fluxReportingController = "grafana"
+)
+
+// fluxObjectAPIVersions are the API versions of the kinds of Flux objects that events can be
+// about, the ones the alerts of the notification-controller can select.
+var fluxObjectAPIVersions = map[string]string{
+	"Kustomization":  "kustomize.toolkit.fluxcd.io/v1beta2",
+	"HelmRelease":    "helm.toolkit.fluxcd.io/v2beta1",
+	"GitRepository":  "source.toolkit.fluxcd.io/v1beta2",
+	"OCIRepository":  "source.toolkit.fluxcd.io/v1beta2",
+	"HelmRepository": "source.toolkit.fluxcd.io/v1beta2",
+	"Bucket":         "source.toolkit.fluxcd.io/v1beta2",
+}
+
+type FluxConfig struct {
+	*NotificationChannelConfig
+	URL         string
+	ObjectKind  string
+	ObjectLabel string
+	Object      string
+	Namespace   string
+	Message     string
+}
+
+func FluxFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewFluxConfig(fc.Config)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewFluxNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewFluxConfig(config *NotificationChannelConfig) (*FluxConfig, error) {
+	eventsURL := strings.TrimSpace(config.Settings.Get("url").MustString(defaultFluxURL))
+	if _, err := url.Parse(eventsURL); err != nil {
+		return nil, fmt.Errorf("invalid notification-controller URL: %w", err)
+	}
+	kind := config.Settings.Get("objectKind").MustString(defaultFluxObjectKind)
+	if _, ok := fluxObjectAPIVersions[kind]; !ok {
+		return nil, fmt.Errorf("unsupported kind of Flux object %q", kind)
+	}
+	objectLabel := config.Settings.Get("objectLabel").MustString(defaultFluxObjectLabel)
+	object := strings.TrimSpace(config.Settings.Get("object").MustString())
+	if objectLabel == "" && object == "" {
+		return nil, errors.New("could not find the Flux object or the label of the Flux object in settings")
+	}
+	namespace := strings.TrimSpace(config.Settings.Get("namespace").MustString())
+	if namespace == "" {
+		namespace = defaultFluxNamespace
+	}
+	return &FluxConfig{
+		NotificationChannelConfig: config,
+		URL:                       eventsURL,
+		ObjectKind:                kind,
+		ObjectLabel:               objectLabel,
+		Object:                    object,
+		Namespace:                 namespace,
+		Message:                   config.Settings.Get("message").MustString(defaultGitHubTitle),
+	}, nil
+}
+
+// NewFluxNotifier is the constructor for the Flux notifier.
+func NewFluxNotifier(config *FluxConfig, ns notifications.WebhookSender, t *template.Template) *FluxNotifier {
+	return &FluxNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:         config.URL,
+		ObjectKind:  config.ObjectKind,
+		ObjectLabel: config.ObjectLabel,
+		Object:      config.Object,
+		Namespace:   config.Namespace,
+		Message:     config.Message,
+		log:         log.New("alerting.notifier.flux"),
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// FluxNotifier is responsible for sending an event per alert to the Flux notification-controller,
+// about the Flux object of the alert, so that the alerts of the controller forward it to their
+// providers along with the events of the reconciliations of the object.
+type FluxNotifier struct {
+	*Base
+	URL         string
+	ObjectKind  string
+	ObjectLabel string
+	Object      string
+	Namespace   string
+	Message     string
+	log         log.Logger
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+// fluxEvent is an event of the notification-controller, see
+// https://github.com/fluxcd/pkg/blob/main/apis/event/v1beta1/event.go
+type fluxEvent struct {
+	InvolvedObject      kubernetesObjectReference `json:"involvedObject"`
+	Severity            string                    `json:"severity"`
+	Timestamp           string                    `json:"timestamp"`
+	Message             string                    `json:"message"`
+	Reason              string                    `json:"reason"`
+	Metadata            map[string]string         `json:"metadata,omitempty"`
+	ReportingController string                    `json:"reportingController"`
+}
+
+func (fn *FluxNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	fn.log.Debug("sending Flux notification", "notification", fn.Name)
+
+	for _, a := range as {
+		object := fn.Object
+		if fn.ObjectLabel != "" {
+			if v := string(a.Labels[model.LabelName(fn.ObjectLabel)]); v != "" {
+				object = v
+			}
+		}
+		if object == "" {
+			fn.log.Debug("skipping alert without Flux object", "alert", a.Fingerprint().String(), "label", fn.ObjectLabel)
+			continue
+		}
+		if err := fn.send(ctx, a, object); err != nil {
+			fn.log.Error("failed to send Flux notification", "err", err, "object", object, "notification", fn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (fn *FluxNotifier) send(ctx context.Context, a *types.Alert, object string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, fn.tmpl, []*types.Alert{a}, fn.log, &tmplErr)
+	message := strings.TrimSpace(tmpl(fn.Message))
+	if tmplErr != nil {
+		fn.log.Warn("failed to template Flux message", "err", tmplErr.Error())
+	}
+
+	event := fluxEvent{
+		InvolvedObject: kubernetesObjectReference{
+			APIVersion: fluxObjectAPIVersions[fn.ObjectKind],
+			Kind:       fn.ObjectKind,
+			Namespace:  fn.Namespace,
+			Name:       object,
+		},
+		Severity:            "error",
+		Timestamp:           timeNow().UTC().Format(time.RFC3339),
+		Message:             message,
+		Reason:              "AlertFiring",
+		Metadata:            map[string]string{},
+		ReportingController: fluxReportingController,
+	}
+	if a.Resolved() {
+		event.Severity, event.Reason = "info", "AlertResolved"
+	}
+	// The providers of the notification-controller show the metadata as the fields of the event.
+	for k, v := range a.Labels {
+		if strings.HasPrefix(string(k), "__") && strings.HasSuffix(string(k), "__") {
+			continue
+		}
+		event.Metadata[string(k)] = string(v)
+	}
+	event.Metadata["alertState"] = string(a.Status())
+	if a.GeneratorURL != "" {
+		event.Metadata["alertURL"] = a.GeneratorURL
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         fn.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	return fn.ns.SendWebhookSync(ctx, cmd)
+}
+
+func (fn *FluxNotifier) SendResolved() bool {
+	return !fn.GetDisableResolveMessage()
+}
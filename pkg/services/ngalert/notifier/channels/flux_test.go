@@ -0,0 +1,142 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestFluxNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "HighLatency", "flux_object": "apps", "__alert_rule_uid__": "rule-uid"},
+			Annotations:  model.LabelSet{"summary": "it broke"},
+			GeneratorURL: "http://localhost/alerting/grafana/rule-uid/view",
+		},
+	}
+	resolved := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "DiskFull"}}}
+	resolved.EndsAt = now.Add(-time.Minute)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expEvent     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Firing alert about the object of its label",
+			settings: `{}`,
+			alerts:   []*types.Alert{firing},
+			expURL:   "http://notification-controller.flux-system.svc.cluster.local/",
+			expEvent: map[string]interface{}{
+				"involvedObject": map[string]interface{}{
+					"apiVersion": "kustomize.toolkit.fluxcd.io/v1beta2",
+					"kind":       "Kustomization",
+					"namespace":  "flux-system",
+					"name":       "apps",
+				},
+				"severity":  "error",
+				"timestamp": "2022-06-01T12:00:00Z",
+				"message":   "HighLatency: it broke",
+				"reason":    "AlertFiring",
+				"metadata": map[string]interface{}{
+					"alertname":   "HighLatency",
+					"flux_object": "apps",
+					"alertState":  "firing",
+					"alertURL":    "http://localhost/alerting/grafana/rule-uid/view",
+				},
+				"reportingController": "grafana",
+			},
+		}, {
+			name:     "Resolved alert about the static object",
+			settings: `{"url": "http://flux.example.com/", "objectKind": "HelmRelease", "object": "podinfo", "namespace": "apps", "message": "{{ .CommonLabels.alertname }}"}`,
+			alerts:   []*types.Alert{resolved},
+			expURL:   "http://flux.example.com/",
+			expEvent: map[string]interface{}{
+				"involvedObject": map[string]interface{}{
+					"apiVersion": "helm.toolkit.fluxcd.io/v2beta1",
+					"kind":       "HelmRelease",
+					"namespace":  "apps",
+					"name":       "podinfo",
+				},
+				"severity":  "info",
+				"timestamp": "2022-06-01T12:00:00Z",
+				"message":   "DiskFull",
+				"reason":    "AlertResolved",
+				"metadata": map[string]interface{}{
+					"alertname":  "DiskFull",
+					"alertState": "resolved",
+				},
+				"reportingController": "grafana",
+			},
+		}, {
+			name:     "Alert without object is skipped",
+			settings: `{}`,
+			alerts:   []*types.Alert{resolved},
+		}, {
+			name:         "Error when the kind is not supported",
+			settings:     `{"objectKind": "Deployment"}`,
+			expInitError: `unsupported kind of Flux object "Deployment"`,
+		}, {
+			name:         "Error when there is no way to find the object",
+			settings:     `{"objectLabel": ""}`,
+			expInitError: "could not find the Flux object or the label of the Flux object in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "flux_testing",
+				Type:     "flux",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			cfg, err := NewFluxConfig(m)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewFluxNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			if c.expEvent == nil {
+				require.Empty(t, webhookSender.Webhook.Url)
+				return
+			}
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			require.Equal(t, "POST", webhookSender.Webhook.HttpMethod)
+			var event map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &event))
+			require.Equal(t, c.expEvent, event)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultGitHubURL     = "https://api.github.com"
+	defaultGitHubTitle   = `{{ .CommonLabels.alertname }}{{ with .CommonAnnotations.summary }}: {{ . }}{{ end }}`
+	defaultGitHubResolve = "The alert is resolved."
+
+	// gitHubAlertMarkerPrefix is the prefix of the marker of the fingerprint of the alert in
+	// the body of its issue, so that an alert only ever has one open issue.
+	gitHubAlertMarkerPrefix = "grafana-alert-"
+
+	gitHubMaxTitleLength = 256
+	gitHubMaxBodyLength  = 65536
+)
+
+type GitHubConfig struct {
+	*NotificationChannelConfig
+	URL            string
+	Repository     string
+	Token          string
+	Title          string
+	Body           string
+	Labels         string
+	Assignees      string
+	ResolveComment string
+	CloseOnResolve bool
+}
+
+func GitHubFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewGitHubConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewGitHubNotifier(cfg, fc.KVStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewGitHubConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*GitHubConfig, error) {
+	apiURL := strings.TrimSuffix(config.Settings.Get("url").MustString(defaultGitHubURL), "/")
+	if _, err := url.Parse(apiURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	repository := strings.Trim(config.Settings.Get("repository").MustString(), "/ ")
+	if repository == "" {
+		return nil, errors.New("could not find repository in settings")
+	}
+	if parts := strings.Split(repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid repository %q, must be owner/name", repository)
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	return &GitHubConfig{
+		NotificationChannelConfig: config,
+		URL:                       apiURL,
+		Repository:                repository,
+		Token:                     token,
+		Title:                     config.Settings.Get("title").MustString(defaultGitHubTitle),
+		Body:                      config.Settings.Get("body").MustString(`{{ template "default.message" . }}`),
+		Labels:                    config.Settings.Get("labels").MustString(),
+		Assignees:                 config.Settings.Get("assignees").MustString(),
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultGitHubResolve),
+		CloseOnResolve:            config.Settings.Get("closeOnResolve").MustBool(true),
+	}, nil
+}
+
+// NewGitHubNotifier is the constructor for the GitHub notifier. The numbers of the open issues are
+// kept in the key-value store if it is set.
+func NewGitHubNotifier(config *GitHubConfig, kv KVStore, ns notifications.WebhookSender, t *template.Template) *GitHubNotifier {
+	return &GitHubNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:            config.URL,
+		Repository:     config.Repository,
+		Token:          config.Token,
+		Title:          config.Title,
+		Body:           config.Body,
+		Labels:         config.Labels,
+		Assignees:      config.Assignees,
+		ResolveComment: config.ResolveComment,
+		CloseOnResolve: config.CloseOnResolve,
+		log:            log.New("alerting.notifier.github"),
+		kv:             kv,
+		ns:             ns,
+		tmpl:           t,
+	}
+}
+
+// GitHubNotifier is responsible for opening a GitHub issue per firing alert, and for commenting
+// on and optionally closing the issue when the alert is resolved.
+type GitHubNotifier struct {
+	*Base
+	URL            string
+	Repository     string
+	Token          string
+	Title          string
+	Body           string
+	Labels         string
+	Assignees      string
+	ResolveComment string
+	CloseOnResolve bool
+	log            log.Logger
+	// kv holds the numbers of the open issues of the alerts.
+	kv   KVStore
+	ns   notifications.WebhookSender
+	tmpl *template.Template
+}
+
+type gitHubIssue struct {
+	Number int `json:"number"`
+}
+
+type gitHubSearchResult struct {
+	Items []gitHubIssue `json:"items"`
+}
+
+type gitHubErrorResponse struct {
+	Message string `json:"message"`
+	Errors  []struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Notify opens an issue for each firing alert that does not have an open issue yet, and
+// resolves the open issue of each resolved alert. The number of the issue of an alert is kept in
+// the key-value store until the alert is resolved, so notifications that are retried or repeated
+// do not open duplicates. Without a key-value store the issues are searched by the marker with
+// the fingerprint of the alert in their body, which only finds the issues once GitHub has indexed
+// them.
+func (gn *GitHubNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	gn.log.Debug("sending GitHub notification", "notification", gn.Name)
+
+	for _, a := range as {
+		marker := gitHubAlertMarkerPrefix + a.Fingerprint().String()
+		number, err := gn.openIssueNumber(ctx, marker)
+		if err != nil {
+			gn.log.Error("failed to find GitHub issue", "err", err, "notification", gn.Name)
+			return false, err
+		}
+
+		switch {
+		case !a.Resolved() && number == 0:
+			err = gn.openIssue(ctx, a, marker)
+		case a.Resolved() && number != 0:
+			err = gn.resolveIssue(ctx, a, marker, number)
+		}
+		if err != nil {
+			gn.log.Error("failed to send GitHub notification", "err", err, "notification", gn.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// issueKey returns the key of the number of the open issue of the alert in the key-value store.
+func (gn *GitHubNotifier) issueKey(marker string) string {
+	return "github_issue." + gn.UID + "." + marker
+}
+
+// openIssueNumber returns the number of the open issue of the alert, or 0 if it has none.
+func (gn *GitHubNotifier) openIssueNumber(ctx context.Context, marker string) (int, error) {
+	if gn.kv == nil {
+		issue, err := gn.findOpenIssue(ctx, marker)
+		if err != nil || issue == nil {
+			return 0, err
+		}
+		return issue.Number, nil
+	}
+	v, ok, err := gn.kv.Get(ctx, gn.issueKey(marker))
+	if err != nil || !ok {
+		return 0, err
+	}
+	number, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of GitHub issue %q: %w", v, err)
+	}
+	return number, nil
+}
+
+func (gn *GitHubNotifier) findOpenIssue(ctx context.Context, marker string) (*gitHubIssue, error) {
+	q := url.Values{
+		"q":        {fmt.Sprintf("repo:%s is:issue is:open in:body %q", gn.Repository, marker)},
+		"per_page": {"1"},
+	}
+	var result gitHubSearchResult
+	if err := gn.request(ctx, "GET", "/search/issues?"+q.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	return &result.Items[0], nil
+}
+
+func (gn *GitHubNotifier) openIssue(ctx context.Context, a *types.Alert, marker string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, gn.tmpl, []*types.Alert{a}, gn.log, &tmplErr)
+
+	title, _ := gn.Truncate(strings.Join(strings.Fields(tmpl(gn.Title)), " "), gitHubMaxTitleLength)
+	// The marker is an HTML comment, so it is not shown in the issue.
+	footer := fmt.Sprintf("\n\n<!-- %s -->", marker)
+	body, _ := gn.Truncate(tmpl(gn.Body), gitHubMaxBodyLength-len(footer))
+
+	issue := map[string]interface{}{
+		"title": title,
+		"body":  body + footer,
+	}
+	if labels := splitCommaList(tmpl(gn.Labels)); len(labels) > 0 {
+		issue["labels"] = labels
+	}
+	if assignees := splitCommaList(tmpl(gn.Assignees)); len(assignees) > 0 {
+		issue["assignees"] = assignees
+	}
+
+	if tmplErr != nil {
+		gn.log.Warn("failed to template GitHub issue", "err", tmplErr.Error())
+	}
+
+	var created gitHubIssue
+	if err := gn.request(ctx, "POST", "/repos/"+gn.Repository+"/issues", issue, &created); err != nil {
+		return err
+	}
+	gn.log.Debug("opened GitHub issue", "issue", created.Number, "alert", a.Fingerprint().String())
+	if gn.kv == nil {
+		return nil
+	}
+	return gn.kv.Set(ctx, gn.issueKey(marker), strconv.Itoa(created.Number))
+}
+
+func (gn *GitHubNotifier) resolveIssue(ctx context.Context, a *types.Alert, marker string, number int) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, gn.tmpl, []*types.Alert{a}, gn.log, &tmplErr)
+	comment, _ := gn.Truncate(tmpl(gn.ResolveComment), gitHubMaxBodyLength)
+	if tmplErr != nil {
+		gn.log.Warn("failed to template GitHub comment", "err", tmplErr.Error())
+	}
+
+	path := fmt.Sprintf("/repos/%s/issues/%d", gn.Repository, number)
+	if comment != "" {
+		if err := gn.request(ctx, "POST", path+"/comments", map[string]string{"body": comment}, nil); err != nil {
+			return err
+		}
+	}
+	if gn.CloseOnResolve {
+		if err := gn.request(ctx, "PATCH", path, map[string]string{"state": "closed", "state_reason": "completed"}, nil); err != nil {
+			return err
+		}
+	}
+	// The alert opens a new issue the next time it fires.
+	if gn.kv == nil {
+		return nil
+	}
+	return gn.kv.Del(ctx, gn.issueKey(marker))
+}
+
+// request sends a request to the GitHub REST API and decodes the response into out, if not nil.
+func (gn *GitHubNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        gn.URL + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Accept":               "application/vnd.github+json",
+			"Authorization":        "Bearer " + gn.Token,
+			"Content-Type":         "application/json",
+			"X-GitHub-Api-Version": "2022-11-28",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return gitHubError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return gn.ns.SendWebhookSync(ctx, cmd)
+}
+
+func gitHubError(body []byte, statusCode int) error {
+	var resp gitHubErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Message == "" {
+		return fmt.Errorf("the GitHub API returned status %d", statusCode)
+	}
+	msgs := []string{resp.Message}
+	for _, e := range resp.Errors {
+		switch {
+		case e.Message != "":
+			msgs = append(msgs, e.Message)
+		case e.Field != "":
+			msgs = append(msgs, e.Field+": "+e.Code)
+		}
+	}
+	return fmt.Errorf("the GitHub API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+}
+
+// splitCommaList splits a comma-separated list, such as labels or assignees, ignoring empty items.
+func splitCommaList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (gn *GitHubNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
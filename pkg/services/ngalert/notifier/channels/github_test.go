@@ -0,0 +1,270 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeGitHubIssue struct {
+	Number   int
+	Fields   map[string]interface{}
+	Comments []string
+	State    string
+}
+
+// fakeGitHub implements the parts of the GitHub REST API used by the notifier. Like GitHub, the
+// search only finds the issues once they are indexed, when indexed is true.
+type fakeGitHub struct {
+	issues   []*fakeGitHubIssue
+	requests []*models.SendWebhookSync
+	indexed  bool
+}
+
+var gitHubMarkerQuery = regexp.MustCompile(`in:body "([^"]+)"`)
+
+func (g *fakeGitHub) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	g.requests = append(g.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+	// The API of GitHub Enterprise Server is under /api/v3.
+	u.Path = strings.TrimPrefix(u.Path, "/api/v3")
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in map[string]interface{}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case u.Path == "/search/issues":
+		marker := gitHubMarkerQuery.FindStringSubmatch(u.Query().Get("q"))[1]
+		items := []map[string]int{}
+		for _, issue := range g.issues {
+			if g.indexed && issue.State == "open" && strings.Contains(issue.Fields["body"].(string), marker) {
+				items = append(items, map[string]int{"number": issue.Number})
+			}
+		}
+		return respond(200, map[string]interface{}{"items": items})
+	case u.Path == "/repos/grafana/ops/issues":
+		if assignees, ok := in["assignees"].([]interface{}); ok && assignees[0] == "nobody" {
+			return respond(422, map[string]interface{}{
+				"message": "Validation Failed",
+				"errors":  []map[string]string{{"field": "assignees", "code": "invalid"}},
+			})
+		}
+		issue := &fakeGitHubIssue{Number: len(g.issues) + 1, Fields: in, State: "open"}
+		g.issues = append(g.issues, issue)
+		return respond(201, map[string]int{"number": issue.Number})
+	case strings.HasSuffix(u.Path, "/comments"):
+		issue := g.issue(u.Path)
+		issue.Comments = append(issue.Comments, in["body"].(string))
+		return respond(201, map[string]string{})
+	case strings.HasPrefix(u.Path, "/repos/grafana/ops/issues/") && cmd.HttpMethod == "PATCH":
+		g.issue(u.Path).State = in["state"].(string)
+		return respond(200, map[string]string{})
+	}
+	return respond(404, map[string]string{"message": "Not Found"})
+}
+
+func (g *fakeGitHub) issue(path string) *fakeGitHubIssue {
+	number, _ := strconv.Atoi(strings.Split(path, "/")[5])
+	for _, issue := range g.issues {
+		if issue.Number == number {
+			return issue
+		}
+	}
+	return nil
+}
+
+func TestGitHubNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "team": "sre"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "team": "dba"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	t.Run("one issue per alert, commented and closed when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"repository": "grafana/ops",
+			"token":      "ghp_token",
+			"labels":     "alert, team/{{ .CommonLabels.team }}",
+			"assignees":  "{{ if eq .CommonLabels.team \"sre\" }}octocat{{ end }}",
+		})
+		cfg, err := NewGitHubConfig(&NotificationChannelConfig{Name: "github_testing", Type: "github", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		// The issues are not indexed yet by the search of GitHub.
+		github := &fakeGitHub{}
+		kv := newFakeKVStore()
+		n := NewGitHubNotifier(cfg, kv, github, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not open duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, github.issues, 2)
+
+		issue := github.issues[0]
+		require.Equal(t, "alert1: CPU is high", issue.Fields["title"])
+		require.Equal(t, []interface{}{"alert", "team/sre"}, issue.Fields["labels"])
+		require.Equal(t, []interface{}{"octocat"}, issue.Fields["assignees"])
+		require.Contains(t, issue.Fields["body"], "CPU is high")
+		require.True(t, strings.HasSuffix(issue.Fields["body"].(string), "<!-- grafana-alert-"+firing.Fingerprint().String()+" -->"))
+		require.Equal(t, []interface{}{"alert", "team/dba"}, github.issues[1].Fields["labels"])
+		require.NotContains(t, github.issues[1].Fields, "assignees")
+
+		// The issues are not searched, their numbers are kept in the key-value store.
+		require.Len(t, github.requests, 2)
+		req := github.requests[0]
+		require.Equal(t, "POST", req.HttpMethod)
+		require.Equal(t, "https://api.github.com/repos/grafana/ops/issues", req.Url)
+		require.Equal(t, "Bearer ghp_token", req.HttpHeader["Authorization"])
+		require.Equal(t, "application/vnd.github+json", req.HttpHeader["Accept"])
+		require.Len(t, kv.values, 2)
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, issue.Comments)
+		require.Equal(t, "closed", issue.State)
+		require.Equal(t, "open", github.issues[1].State)
+		require.Len(t, kv.values, 1)
+
+		// The alert fires again after its issue was closed.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, github.issues, 3)
+	})
+
+	t.Run("comment without closing the issue when resolved", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":            "https://github.example.com/api/v3/",
+			"repository":     "grafana/ops",
+			"token":          "ghp_token",
+			"resolveComment": "{{ .CommonLabels.alertname }} is resolved",
+			"closeOnResolve": false,
+		})
+		cfg, err := NewGitHubConfig(&NotificationChannelConfig{Name: "github_testing", Type: "github", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		// Without a key-value store, the issues are searched.
+		github := &fakeGitHub{indexed: true}
+		n := NewGitHubNotifier(cfg, nil, github, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"alert1 is resolved"}, github.issues[0].Comments)
+		require.Equal(t, "open", github.issues[0].State)
+		require.True(t, strings.HasPrefix(github.requests[0].Url, "https://github.example.com/api/v3/search/issues?"))
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"repository": "grafana/ops",
+			"token":      "ghp_token",
+			"assignees":  "nobody",
+		})
+		cfg, err := NewGitHubConfig(&NotificationChannelConfig{Name: "github_testing", Type: "github", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewGitHubNotifier(cfg, newFakeKVStore(), &fakeGitHub{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the GitHub API returned status 422: Validation Failed; assignees: invalid")
+		require.False(t, ok)
+	})
+}
+
+func TestNewGitHubConfig(t *testing.T) {
+	cases := []struct {
+		name          string
+		settings      map[string]interface{}
+		expRepository string
+		expInitError  string
+	}{
+		{
+			name:          "Repository with surrounding slashes",
+			settings:      map[string]interface{}{"repository": "/grafana/ops/", "token": "ghp_token"},
+			expRepository: "grafana/ops",
+		}, {
+			name:         "Error when the repository is missing",
+			settings:     map[string]interface{}{"token": "ghp_token"},
+			expInitError: "could not find repository in settings",
+		}, {
+			name:         "Error with an invalid repository",
+			settings:     map[string]interface{}{"repository": "ops", "token": "ghp_token"},
+			expInitError: `invalid repository "ops", must be owner/name`,
+		}, {
+			name:         "Error when the token is missing",
+			settings:     map[string]interface{}{"repository": "grafana/ops"},
+			expInitError: "could not find token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "github_testing", Type: "github", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewGitHubConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expRepository, cfg.Repository)
+		})
+	}
+}
+
+func TestGitHubError(t *testing.T) {
+	require.EqualError(t, gitHubError([]byte(`{"message":"Bad credentials"}`), 401), "the GitHub API returned status 401: Bad credentials")
+	require.EqualError(t, gitHubError([]byte(`{"message":"Validation Failed","errors":[{"message":"Title is too long"}]}`), 422), "the GitHub API returned status 422: Validation Failed; Title is too long")
+	require.EqualError(t, gitHubError(nil, 502), "the GitHub API returned status 502")
+}
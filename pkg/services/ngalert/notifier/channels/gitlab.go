@@ -0,0 +1,305 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultGitLabURL = "https://gitlab.com"
+
+	gitLabMaxTitleLength       = 255
+	gitLabMaxDescriptionLength = 1048576
+)
+
+type GitLabConfig struct {
+	*NotificationChannelConfig
+	URL            string
+	Project        string
+	Token          string
+	Title          string
+	Description    string
+	Labels         string
+	AssigneeIDs    string
+	Confidential   bool
+	ResolveComment string
+	CloseOnResolve bool
+}
+
+func GitLabFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewGitLabConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewGitLabNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewGitLabConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*GitLabConfig, error) {
+	baseURL := strings.TrimSuffix(config.Settings.Get("url").MustString(defaultGitLabURL), "/")
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	project := strings.Trim(config.Settings.Get("project").MustString(), "/ ")
+	if project == "" {
+		return nil, errors.New("could not find project in settings")
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	return &GitLabConfig{
+		NotificationChannelConfig: config,
+		URL:                       baseURL,
+		Project:                   project,
+		Token:                     token,
+		Title:                     config.Settings.Get("title").MustString(defaultGitHubTitle),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		Labels:                    config.Settings.Get("labels").MustString(),
+		AssigneeIDs:               config.Settings.Get("assigneeIds").MustString(),
+		Confidential:              config.Settings.Get("confidential").MustBool(false),
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultGitHubResolve),
+		CloseOnResolve:            config.Settings.Get("closeOnResolve").MustBool(true),
+	}, nil
+}
+
+// NewGitLabNotifier is the constructor for the GitLab notifier.
+func NewGitLabNotifier(config *GitLabConfig, ns notifications.WebhookSender, t *template.Template) *GitLabNotifier {
+	return &GitLabNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:            config.URL,
+		Project:        config.Project,
+		Token:          config.Token,
+		Title:          config.Title,
+		Description:    config.Description,
+		Labels:         config.Labels,
+		AssigneeIDs:    config.AssigneeIDs,
+		Confidential:   config.Confidential,
+		ResolveComment: config.ResolveComment,
+		CloseOnResolve: config.CloseOnResolve,
+		log:            log.New("alerting.notifier.gitlab"),
+		ns:             ns,
+		tmpl:           t,
+	}
+}
+
+// GitLabNotifier is responsible for opening a GitLab issue per firing alert, and for commenting
+// on and optionally closing the issue when the alert is resolved. It works with GitLab.com as
+// well as self-managed instances.
+type GitLabNotifier struct {
+	*Base
+	URL            string
+	Project        string
+	Token          string
+	Title          string
+	Description    string
+	Labels         string
+	AssigneeIDs    string
+	Confidential   bool
+	ResolveComment string
+	CloseOnResolve bool
+	log            log.Logger
+	ns             notifications.WebhookSender
+	tmpl           *template.Template
+}
+
+type gitLabIssue struct {
+	IID int `json:"iid"`
+}
+
+// Notify opens an issue for each firing alert that does not have an open issue yet, and
+// resolves the open issue of each resolved alert. Like with GitHub, issues are found by the
+// marker with the fingerprint of the alert in their description.
+func (gn *GitLabNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	gn.log.Debug("sending GitLab notification", "notification", gn.Name)
+
+	for _, a := range as {
+		marker := gitHubAlertMarkerPrefix + a.Fingerprint().String()
+		issue, err := gn.findOpenIssue(ctx, marker)
+		if err != nil {
+			gn.log.Error("failed to search GitLab issues", "err", err, "notification", gn.Name)
+			return false, err
+		}
+
+		switch {
+		case !a.Resolved() && issue == nil:
+			err = gn.openIssue(ctx, a, marker)
+		case a.Resolved() && issue != nil:
+			err = gn.resolveIssue(ctx, a, issue.IID)
+		}
+		if err != nil {
+			gn.log.Error("failed to send GitLab notification", "err", err, "notification", gn.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// projectPath returns the path of the project in the API. The project is either its ID or its
+// full path, which is URL-encoded.
+func (gn *GitLabNotifier) projectPath() string {
+	return "/projects/" + url.PathEscape(gn.Project)
+}
+
+func (gn *GitLabNotifier) findOpenIssue(ctx context.Context, marker string) (*gitLabIssue, error) {
+	q := url.Values{
+		"state":    {"opened"},
+		"in":       {"description"},
+		"search":   {marker},
+		"per_page": {"1"},
+	}
+	var issues []gitLabIssue
+	if err := gn.request(ctx, "GET", gn.projectPath()+"/issues?"+q.Encode(), nil, &issues); err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return nil, nil
+	}
+	return &issues[0], nil
+}
+
+func (gn *GitLabNotifier) openIssue(ctx context.Context, a *types.Alert, marker string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, gn.tmpl, []*types.Alert{a}, gn.log, &tmplErr)
+
+	title, _ := gn.Truncate(strings.Join(strings.Fields(tmpl(gn.Title)), " "), gitLabMaxTitleLength)
+	// The marker is an HTML comment, so it is not shown in the issue.
+	footer := fmt.Sprintf("\n\n<!-- %s -->", marker)
+	description, _ := gn.Truncate(tmpl(gn.Description), gitLabMaxDescriptionLength-len(footer))
+
+	issue := map[string]interface{}{
+		"title":       title,
+		"description": description + footer,
+	}
+	if gn.Confidential {
+		issue["confidential"] = true
+	}
+	if labels := splitCommaList(tmpl(gn.Labels)); len(labels) > 0 {
+		issue["labels"] = strings.Join(labels, ",")
+	}
+	var assigneeIDs []int
+	for _, id := range splitCommaList(tmpl(gn.AssigneeIDs)) {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			gn.log.Warn("ignoring invalid GitLab assignee ID", "id", id)
+			continue
+		}
+		assigneeIDs = append(assigneeIDs, n)
+	}
+	if len(assigneeIDs) > 0 {
+		issue["assignee_ids"] = assigneeIDs
+	}
+
+	if tmplErr != nil {
+		gn.log.Warn("failed to template GitLab issue", "err", tmplErr.Error())
+	}
+
+	var created gitLabIssue
+	if err := gn.request(ctx, "POST", gn.projectPath()+"/issues", issue, &created); err != nil {
+		return err
+	}
+	gn.log.Debug("opened GitLab issue", "issue", created.IID, "alert", a.Fingerprint().String())
+	return nil
+}
+
+func (gn *GitLabNotifier) resolveIssue(ctx context.Context, a *types.Alert, iid int) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, gn.tmpl, []*types.Alert{a}, gn.log, &tmplErr)
+	comment, _ := gn.Truncate(tmpl(gn.ResolveComment), gitLabMaxDescriptionLength)
+	if tmplErr != nil {
+		gn.log.Warn("failed to template GitLab comment", "err", tmplErr.Error())
+	}
+
+	path := fmt.Sprintf("%s/issues/%d", gn.projectPath(), iid)
+	if comment != "" {
+		if err := gn.request(ctx, "POST", path+"/notes", map[string]string{"body": comment}, nil); err != nil {
+			return err
+		}
+	}
+	if !gn.CloseOnResolve {
+		return nil
+	}
+	return gn.request(ctx, "PUT", path, map[string]string{"state_event": "close"}, nil)
+}
+
+// request sends a request to the GitLab REST API and decodes the response into out, if not nil.
+func (gn *GitLabNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        gn.URL + "/api/v4" + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"PRIVATE-TOKEN": gn.Token,
+			"Content-Type":  "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return gitLabError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return gn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// gitLabError returns the error of a response of the GitLab API. Its message is either a string,
+// or the errors of each field of a request that failed to validate.
+func gitLabError(body []byte, statusCode int) error {
+	var resp struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil {
+		var msg string
+		var fields map[string][]string
+		switch {
+		case json.Unmarshal(resp.Message, &msg) == nil && msg != "":
+			return fmt.Errorf("the GitLab API returned status %d: %s", statusCode, msg)
+		case json.Unmarshal(resp.Message, &fields) == nil && len(fields) > 0:
+			msgs := make([]string, 0, len(fields))
+			for field, errs := range fields {
+				msgs = append(msgs, field+" "+strings.Join(errs, ", "))
+			}
+			sort.Strings(msgs)
+			return fmt.Errorf("the GitLab API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+		case resp.Error != "":
+			return fmt.Errorf("the GitLab API returned status %d: %s", statusCode, resp.Error)
+		}
+	}
+	return fmt.Errorf("the GitLab API returned status %d", statusCode)
+}
+
+func (gn *GitLabNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
@@ -0,0 +1,268 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeGitLabIssue struct {
+	IID      int
+	Fields   map[string]interface{}
+	Comments []string
+	State    string
+}
+
+// fakeGitLab implements the parts of the GitLab REST API used by the notifier, for the project
+// grafana/ops.
+type fakeGitLab struct {
+	issues   []*fakeGitLabIssue
+	requests []*models.SendWebhookSync
+}
+
+func (g *fakeGitLab) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	g.requests = append(g.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in map[string]interface{}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	path := strings.TrimPrefix(u.EscapedPath(), "/api/v4/projects/grafana%2Fops")
+	if path == u.EscapedPath() {
+		return respond(404, map[string]string{"message": "404 Project Not Found"})
+	}
+	switch {
+	case path == "/issues" && cmd.HttpMethod == "GET":
+		search := u.Query().Get("search")
+		issues := []map[string]int{}
+		for _, issue := range g.issues {
+			if issue.State == "opened" && strings.Contains(issue.Fields["description"].(string), search) {
+				issues = append(issues, map[string]int{"iid": issue.IID})
+			}
+		}
+		return respond(200, issues)
+	case path == "/issues":
+		if len(in["title"].(string)) == 0 {
+			return respond(400, map[string]interface{}{"message": map[string][]string{"title": {"can't be blank"}}})
+		}
+		issue := &fakeGitLabIssue{IID: len(g.issues) + 1, Fields: in, State: "opened"}
+		g.issues = append(g.issues, issue)
+		return respond(201, map[string]int{"iid": issue.IID})
+	case strings.HasSuffix(path, "/notes"):
+		issue := g.issue(path)
+		issue.Comments = append(issue.Comments, in["body"].(string))
+		return respond(201, map[string]string{})
+	case cmd.HttpMethod == "PUT":
+		if in["state_event"] == "close" {
+			g.issue(path).State = "closed"
+		}
+		return respond(200, map[string]string{})
+	}
+	return respond(404, map[string]string{"message": "404 Not Found"})
+}
+
+func (g *fakeGitLab) issue(path string) *fakeGitLabIssue {
+	iid, _ := strconv.Atoi(strings.Split(path, "/")[2])
+	for _, issue := range g.issues {
+		if issue.IID == iid {
+			return issue
+		}
+	}
+	return nil
+}
+
+func TestGitLabNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "team": "sre"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "team": "dba"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	t.Run("one confidential issue per alert, commented and closed when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"project":      "grafana/ops",
+			"token":        "glpat-token",
+			"labels":       "alert, team::{{ .CommonLabels.team }}",
+			"assigneeIds":  "{{ if eq .CommonLabels.team \"sre\" }}42, sre{{ end }}",
+			"confidential": true,
+		})
+		cfg, err := NewGitLabConfig(&NotificationChannelConfig{Name: "gitlab_testing", Type: "gitlab", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		gitlab := &fakeGitLab{}
+		n := NewGitLabNotifier(cfg, gitlab, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not open duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, gitlab.issues, 2)
+
+		issue := gitlab.issues[0]
+		require.Equal(t, "alert1: CPU is high", issue.Fields["title"])
+		require.Equal(t, "alert,team::sre", issue.Fields["labels"])
+		require.Equal(t, []interface{}{42.0}, issue.Fields["assignee_ids"])
+		require.Equal(t, true, issue.Fields["confidential"])
+		require.Contains(t, issue.Fields["description"], "CPU is high")
+		require.True(t, strings.HasSuffix(issue.Fields["description"].(string), "<!-- grafana-alert-"+firing.Fingerprint().String()+" -->"))
+		require.Equal(t, "alert,team::dba", gitlab.issues[1].Fields["labels"])
+		require.NotContains(t, gitlab.issues[1].Fields, "assignee_ids")
+
+		req := gitlab.requests[0]
+		require.Equal(t, "GET", req.HttpMethod)
+		require.True(t, strings.HasPrefix(req.Url, "https://gitlab.com/api/v4/projects/grafana%2Fops/issues?"))
+		require.Equal(t, "glpat-token", req.HttpHeader["PRIVATE-TOKEN"])
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, issue.Comments)
+		require.Equal(t, "closed", issue.State)
+		require.Equal(t, "opened", gitlab.issues[1].State)
+
+		// The alert fires again after its issue was closed.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, gitlab.issues, 3)
+	})
+
+	t.Run("self-managed instance, comment without closing the issue when resolved", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":            "https://gitlab.example.com/",
+			"project":        "grafana/ops",
+			"token":          "glpat-token",
+			"resolveComment": "{{ .CommonLabels.alertname }} is resolved",
+			"closeOnResolve": false,
+		})
+		cfg, err := NewGitLabConfig(&NotificationChannelConfig{Name: "gitlab_testing", Type: "gitlab", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		gitlab := &fakeGitLab{}
+		n := NewGitLabNotifier(cfg, gitlab, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"alert1 is resolved"}, gitlab.issues[0].Comments)
+		require.Equal(t, "opened", gitlab.issues[0].State)
+		require.NotContains(t, gitlab.issues[0].Fields, "confidential")
+		require.True(t, strings.HasPrefix(gitlab.requests[0].Url, "https://gitlab.example.com/api/v4/projects/grafana%2Fops/issues?"))
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"project": "grafana/ops",
+			"token":   "glpat-token",
+			"title":   " ",
+		})
+		cfg, err := NewGitLabConfig(&NotificationChannelConfig{Name: "gitlab_testing", Type: "gitlab", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewGitLabNotifier(cfg, &fakeGitLab{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the GitLab API returned status 400: title can't be blank")
+		require.False(t, ok)
+
+		cfg.Project = "1234"
+		ok, err = NewGitLabNotifier(cfg, &fakeGitLab{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the GitLab API returned status 404: 404 Project Not Found")
+		require.False(t, ok)
+	})
+}
+
+func TestNewGitLabConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expProject   string
+		expInitError string
+	}{
+		{
+			name:       "Project ID",
+			settings:   map[string]interface{}{"project": "1234", "token": "glpat-token"},
+			expProject: "1234",
+		}, {
+			name:       "Project path with surrounding slashes",
+			settings:   map[string]interface{}{"project": "/grafana/ops/", "token": "glpat-token"},
+			expProject: "grafana/ops",
+		}, {
+			name:         "Error when the project is missing",
+			settings:     map[string]interface{}{"token": "glpat-token"},
+			expInitError: "could not find project in settings",
+		}, {
+			name:         "Error when the token is missing",
+			settings:     map[string]interface{}{"project": "grafana/ops"},
+			expInitError: "could not find token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "gitlab_testing", Type: "gitlab", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewGitLabConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expProject, cfg.Project)
+		})
+	}
+}
+
+func TestGitLabError(t *testing.T) {
+	require.EqualError(t, gitLabError([]byte(`{"message":"401 Unauthorized"}`), 401), "the GitLab API returned status 401: 401 Unauthorized")
+	require.EqualError(t, gitLabError([]byte(`{"message":{"title":["is too long"],"labels":["are invalid"]}}`), 400), "the GitLab API returned status 400: labels are invalid; title is too long")
+	require.EqualError(t, gitLabError([]byte(`{"error":"invalid_token"}`), 401), "the GitLab API returned status 401: invalid_token")
+	require.EqualError(t, gitLabError(nil, 502), "the GitLab API returned status 502")
+}
@@ -101,7 +101,7 @@ func (gcn *GoogleChatNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 		tmplErr = nil
 	}
 
-	ruleURL := joinUrlPath(gcn.tmpl.ExternalURL.String(), "/alerting/list", gcn.log)
+	ruleURL := gcn.RuleListURL(gcn.tmpl.ExternalURL)
 	if gcn.isUrlAbsolute(ruleURL) {
 		// Add a button widget (link to Grafana).
 		widgets = append(widgets, buttonWidget{
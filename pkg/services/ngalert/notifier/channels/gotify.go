@@ -0,0 +1,230 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultGotifySeverityLabel = "severity"
+	defaultGotifyPriority      = 5
+)
+
+// gotifyPriorities maps common values of the severity label to Gotify priorities, from 0 (no
+// notification) to 10. Clients show notifications with priorities above 7 as high priority.
+var gotifyPriorities = map[string]int{
+	"critical": 10,
+	"urgent":   10,
+	"page":     10,
+	"high":     8,
+	"error":    8,
+	"major":    8,
+	"warning":  5,
+	"medium":   5,
+	"low":      2,
+	"minor":    2,
+	"info":     2,
+	"none":     1,
+	"debug":    1,
+}
+
+type GotifyConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	AppToken      string
+	Priority      int
+	SeverityLabel string
+	Title         string
+	Message       string
+}
+
+func GotifyFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewGotifyConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewGotifyNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewGotifyConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*GotifyConfig, error) {
+	url := strings.TrimSuffix(config.Settings.Get("url").MustString(), "/")
+	if url == "" {
+		return nil, errors.New("could not find url in settings")
+	}
+	appToken := decryptFunc(context.Background(), config.SecureSettings, "appToken", config.Settings.Get("appToken").MustString())
+	if appToken == "" {
+		return nil, errors.New("could not find app token in settings")
+	}
+
+	// The priority is a string when set from the UI and a number when provisioned.
+	priority, err := config.Settings.Get("priority").Int()
+	if err != nil {
+		priority, err = strconv.Atoi(config.Settings.Get("priority").MustString(strconv.Itoa(defaultGotifyPriority)))
+		if err != nil {
+			return nil, errors.New("invalid priority, must be between 0 and 10")
+		}
+	}
+	if priority < 0 || priority > 10 {
+		return nil, fmt.Errorf("invalid priority %d, must be between 0 and 10", priority)
+	}
+
+	return &GotifyConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		AppToken:                  appToken,
+		Priority:                  priority,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultGotifySeverityLabel),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewGotifyNotifier is the constructor for the Gotify notifier.
+func NewGotifyNotifier(config *GotifyConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *GotifyNotifier {
+	return &GotifyNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:           config.URL,
+		AppToken:      config.AppToken,
+		Priority:      config.Priority,
+		SeverityLabel: config.SeverityLabel,
+		Title:         config.Title,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.gotify"),
+		images:        images,
+		ns:            ns,
+		tmpl:          t,
+	}
+}
+
+// GotifyNotifier is responsible for sending alert notifications to a Gotify server.
+type GotifyNotifier struct {
+	*Base
+	URL           string
+	AppToken      string
+	Priority      int
+	SeverityLabel string
+	Title         string
+	Message       string
+	log           log.Logger
+	images        ImageStore
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+}
+
+type gotifyMessage struct {
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// Notify sends the alerts as a message of the Gotify application.
+func (gn *GotifyNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	gn.log.Debug("sending Gotify notification", "notification", gn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, gn.tmpl, as, gn.log, &tmplErr)
+
+	clientNotification := map[string]interface{}{
+		"click": map[string]string{"url": gn.clickURL(ctx, data)},
+	}
+	_ = withStoredImages(ctx, gn.log, gn.images,
+		func(_ int, image ngmodels.Image) error {
+			if image.URL != "" {
+				clientNotification["bigImageUrl"] = image.URL
+				return ErrImagesDone
+			}
+			return nil
+		}, as...)
+
+	msg := gotifyMessage{
+		Title:    tmpl(gn.Title),
+		Message:  tmpl(gn.Message),
+		Priority: gn.priority(as...),
+		Extras: map[string]interface{}{
+			"client::display":      map[string]string{"contentType": "text/markdown"},
+			"client::notification": clientNotification,
+		},
+	}
+
+	if tmplErr != nil {
+		gn.log.Warn("failed to template Gotify message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        gn.URL + "/message",
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+			"X-Gotify-Key": gn.AppToken,
+		},
+	}
+	if err := gn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		gn.log.Error("failed to send Gotify notification", "err", err, "notification", gn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// priority returns the highest priority of the severities of the firing alerts, or the
+// configured priority if none of them has a known severity.
+func (gn *GotifyNotifier) priority(as ...*types.Alert) int {
+	priority := -1
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(gn.SeverityLabel)]))
+		if p, ok := gotifyPriorities[severity]; ok && p > priority {
+			priority = p
+		}
+	}
+	if priority < 0 {
+		return gn.Priority
+	}
+	return priority
+}
+
+// clickURL links to the alert rule when the notification has a single alert, otherwise to the
+// alert list filtered by the group.
+func (gn *GotifyNotifier) clickURL(ctx context.Context, data *ExtendedData) string {
+	if len(data.Alerts) == 1 && data.Alerts[0].GeneratorURL != "" {
+		return data.Alerts[0].GeneratorURL
+	}
+	groupLabels, _ := notify.GroupLabels(ctx)
+	return gn.AlertListURL(gn.tmpl.ExternalURL, groupLabels)
+}
+
+func (gn *GotifyNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestGotifyNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			Annotations:  model.LabelSet{"ann1": "annv1"},
+			GeneratorURL: "http://localhost/alerting/grafana/rule1/view",
+		},
+	}
+	warning := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "severity": "Warning"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			StartsAt: time.Now().Add(-time.Hour),
+			EndsAt:   time.Now().Add(-time.Minute),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       string
+		expInitError string
+	}{
+		{
+			name:     "Single alert links to the rule",
+			settings: map[string]interface{}{"url": "https://gotify.example.com/", "appToken": "token"},
+			alerts:   []*types.Alert{firing},
+			expURL:   "https://gotify.example.com/message",
+			expMsg: `{
+				"title": "[FIRING:1] alert1 (critical)",
+				"message": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\n - ann1 = annv1\nSource: http://localhost/alerting/grafana/rule1/view\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=severity%3Dcritical\n",
+				"priority": 10,
+				"extras": {
+					"client::display": {"contentType": "text/markdown"},
+					"client::notification": {"click": {"url": "http://localhost/alerting/grafana/rule1/view"}}
+				}
+			}`,
+		}, {
+			name: "Multiple alerts link to the alert list, with custom templates",
+			settings: map[string]interface{}{
+				"url":      "https://gotify.example.com",
+				"appToken": "token",
+				"title":    "{{ len .Alerts.Firing }} alerts",
+				"message":  "see Grafana",
+			},
+			alerts: []*types.Alert{warning, {Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}}},
+			expURL: "https://gotify.example.com/message",
+			expMsg: `{
+				"title": "2 alerts",
+				"message": "see Grafana",
+				"priority": 5,
+				"extras": {
+					"client::display": {"contentType": "text/markdown"},
+					"client::notification": {"click": {"url": "http://localhost/alerting/list?queryString=alertname%3D%22alert2%22"}}
+				}
+			}`,
+		}, {
+			name:     "Default priority without known severity",
+			settings: map[string]interface{}{"url": "https://gotify.example.com", "appToken": "token", "priority": "7", "severityLabel": "level", "message": "msg"},
+			alerts:   []*types.Alert{firing},
+			expURL:   "https://gotify.example.com/message",
+			expMsg: `{
+				"title": "[FIRING:1] alert1 (critical)",
+				"message": "msg",
+				"priority": 7,
+				"extras": {
+					"client::display": {"contentType": "text/markdown"},
+					"client::notification": {"click": {"url": "http://localhost/alerting/grafana/rule1/view"}}
+				}
+			}`,
+		}, {
+			name:     "Resolved alerts use the default priority",
+			settings: map[string]interface{}{"url": "https://gotify.example.com", "appToken": "token", "priority": 0, "message": "msg"},
+			alerts:   []*types.Alert{resolved},
+			expURL:   "https://gotify.example.com/message",
+			expMsg: `{
+				"title": "[RESOLVED] alert1 (critical)",
+				"message": "msg",
+				"priority": 0,
+				"extras": {
+					"client::display": {"contentType": "text/markdown"},
+					"client::notification": {"click": {"url": "http://localhost/alerting/list?queryString=alertname%3D%22alert1%22"}}
+				}
+			}`,
+		}, {
+			name:         "Error when URL is missing",
+			settings:     map[string]interface{}{"appToken": "token"},
+			expInitError: "could not find url in settings",
+		}, {
+			name:         "Error when app token is missing",
+			settings:     map[string]interface{}{"url": "https://gotify.example.com"},
+			expInitError: "could not find app token in settings",
+		}, {
+			name:         "Error on invalid priority",
+			settings:     map[string]interface{}{"url": "https://gotify.example.com", "appToken": "token", "priority": "11"},
+			expInitError: "invalid priority 11, must be between 0 and 10",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "gotify_testing",
+				Type:     "gotify",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewGotifyConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": c.alerts[0].Labels["alertname"]})
+			ns := mockNotificationService()
+			n := NewGotifyNotifier(cfg, &UnavailableImageStore{}, ns, tmpl)
+
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, ns.Webhook.Url)
+			require.Equal(t, "token", ns.Webhook.HttpHeader["X-Gotify-Key"])
+			require.JSONEq(t, c.expMsg, ns.Webhook.Body)
+		})
+	}
+}
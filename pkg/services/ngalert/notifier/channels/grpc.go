@@ -0,0 +1,303 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+)
+
+const grpcIdleTimeout = 5 * time.Minute
+
+// grpcMetadataKey matches the keys of gRPC metadata, which are lowercase. Binary keys, which end
+// with -bin, are not supported.
+var grpcMetadataKey = regexp.MustCompile(`^[0-9a-z_.-]+$`)
+
+// grpcOptions are the connection settings of a gRPC notifier. They are comparable so that
+// notifiers with the same settings share a connection.
+type grpcOptions struct {
+	Address       string
+	Insecure      bool
+	TLSSkipVerify bool
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+}
+
+// grpcDial creates a client connection. Can be overwritten in tests.
+var grpcDial = grpc.DialContext
+
+// grpcConnections are shared by all the gRPC notifiers.
+var grpcConnections = newConnPool(grpcIdleTimeout)
+
+// grpcConn is a client connection to an alert receiver.
+type grpcConn struct {
+	*grpc.ClientConn
+}
+
+func (c grpcConn) Closed() bool {
+	return c.GetState() == connectivity.Shutdown
+}
+
+type GRPCConfig struct {
+	*NotificationChannelConfig
+	Address       string
+	Insecure      bool
+	TLSSkipVerify bool
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+	Metadata      map[string]string
+	MaxAlerts     int
+}
+
+func GRPCFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewGRPCConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewGRPCNotifier(cfg, fc.ImageStore, fc.Template), nil
+}
+
+func NewGRPCConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*GRPCConfig, error) {
+	address := strings.TrimSpace(config.Settings.Get("address").MustString())
+	if address == "" {
+		return nil, errors.New("could not find address in settings")
+	}
+	md, err := parseGRPCMetadata(decryptFunc(context.Background(), config.SecureSettings, "metadata", config.Settings.Get("metadata").MustString()))
+	if err != nil {
+		return nil, err
+	}
+	// The maximum number of alerts is a string when set from the UI and a number when provisioned.
+	maxAlerts, err := config.Settings.Get("maxAlerts").Int()
+	if err != nil {
+		maxAlerts, err = strconv.Atoi(config.Settings.Get("maxAlerts").MustString("0"))
+		if err != nil {
+			return nil, errors.New("invalid maximum number of alerts, must be a number")
+		}
+	}
+
+	cfg := &GRPCConfig{
+		NotificationChannelConfig: config,
+		Address:                   address,
+		Insecure:                  config.Settings.Get("insecure").MustBool(false),
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSCACert:                 config.Settings.Get("tlsCACert").MustString(),
+		TLSClientCert:             config.Settings.Get("tlsClientCert").MustString(),
+		TLSClientKey:              decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString()),
+		Metadata:                  md,
+		MaxAlerts:                 maxAlerts,
+	}
+	if cfg.Insecure && (cfg.TLSSkipVerify || cfg.TLSCACert != "" || cfg.TLSClientCert != "" || cfg.TLSClientKey != "") {
+		return nil, errors.New("TLS settings cannot be set when TLS is disabled")
+	}
+	if _, err := brokerTLSConfig(cfg.TLSSkipVerify, cfg.TLSCACert, cfg.TLSClientCert, cfg.TLSClientKey); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseGRPCMetadata parses the metadata sent with the requests, one "key: value" per line.
+func parseGRPCMetadata(s string) (map[string]string, error) {
+	md := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		if len(parts) != 2 || !grpcMetadataKey.MatchString(key) {
+			return nil, fmt.Errorf("invalid metadata %q, must be in the format key: value", line)
+		}
+		if strings.HasPrefix(key, "grpc-") || strings.HasSuffix(key, "-bin") {
+			return nil, fmt.Errorf("metadata key %q is reserved", key)
+		}
+		md[key] = strings.TrimSpace(parts[1])
+	}
+	return md, nil
+}
+
+// NewGRPCNotifier is the constructor for the gRPC notifier.
+func NewGRPCNotifier(config *GRPCConfig, images ImageStore, t *template.Template) *GRPCNotifier {
+	return &GRPCNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID: config.OrgID,
+		options: grpcOptions{
+			Address:       config.Address,
+			Insecure:      config.Insecure,
+			TLSSkipVerify: config.TLSSkipVerify,
+			TLSCACert:     config.TLSCACert,
+			TLSClientCert: config.TLSClientCert,
+			TLSClientKey:  config.TLSClientKey,
+		},
+		Metadata:  config.Metadata,
+		MaxAlerts: config.MaxAlerts,
+		log:       log.New("alerting.notifier.grpc"),
+		images:    images,
+		tmpl:      t,
+	}
+}
+
+// GRPCNotifier is responsible for sending alert notifications to a service that implements the
+// AlertReceiver service of the alertreceiverv1 package.
+type GRPCNotifier struct {
+	*Base
+	Metadata  map[string]string
+	MaxAlerts int
+	orgID     int64
+	options   grpcOptions
+	log       log.Logger
+	images    ImageStore
+	tmpl      *template.Template
+}
+
+// Notify calls the Notify method of the alert receiver with the alerts of the notification. The
+// connection to the receiver is kept open between notifications.
+func (gn *GRPCNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	gn.log.Debug("sending gRPC notification", "notification", gn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	as, numTruncated := truncateAlerts(gn.MaxAlerts, as)
+	var tmplErr error
+	tmpl, data := TmplText(ctx, gn.tmpl, as, gn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, gn.log, gn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	req := newNotifyRequest(groupKey.String(), gn.orgID, tmpl, data, numTruncated)
+
+	if tmplErr != nil {
+		gn.log.Warn("failed to template gRPC message", "err", tmplErr.Error())
+	}
+
+	if _, ok := dryRunFromContext(ctx); ok {
+		body, err := protojson.Marshal(req)
+		if err != nil {
+			return false, err
+		}
+		return recordDryRun(ctx, gn.options.Address, string(body)), nil
+	}
+
+	conn, _, err := grpcConnections.get(ctx, gn.options, gn.connect)
+	if err != nil {
+		gn.log.Error("failed to connect to gRPC alert receiver", "err", err, "notification", gn.Name)
+		return false, err
+	}
+	if len(gn.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(gn.Metadata))
+	}
+	start := time.Now()
+	_, err = alertreceiverv1.NewAlertReceiverClient(conn.(grpcConn)).Notify(ctx, req)
+	observe(ctx, sendStage, start)
+	if err != nil {
+		if status.Code(err) == codes.Unavailable {
+			// The next notification connects again, in case the address resolves to another server.
+			grpcConnections.discard(gn.options, conn)
+		}
+		gn.log.Error("failed to send gRPC notification", "err", err, "notification", gn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// newNotifyRequest returns the request of the notification of the alerts of the data, sent to
+// alert receivers and notification workers.
+func newNotifyRequest(groupKey string, orgID int64, tmpl func(string) string, data *ExtendedData, numTruncated int) *alertreceiverv1.NotifyRequest {
+	req := &alertreceiverv1.NotifyRequest{
+		Receiver:          data.Receiver,
+		Status:            data.Status,
+		GroupKey:          groupKey,
+		OrgId:             orgID,
+		GroupLabels:       data.GroupLabels,
+		CommonLabels:      data.CommonLabels,
+		CommonAnnotations: data.CommonAnnotations,
+		ExternalURL:       data.ExternalURL,
+		Title:             tmpl(DefaultMessageTitleEmbed),
+		Message:           tmpl(`{{ template "default.message" . }}`),
+		TruncatedAlerts:   int32(numTruncated),
+	}
+	for _, a := range data.Alerts {
+		alert := &alertreceiverv1.Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     timestamppb.New(a.StartsAt),
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+			SilenceURL:   a.SilenceURL,
+			DashboardURL: a.DashboardURL,
+			PanelURL:     a.PanelURL,
+			ValueString:  a.ValueString,
+			ImageURL:     a.ImageURL,
+		}
+		if !a.EndsAt.IsZero() {
+			alert.EndsAt = timestamppb.New(a.EndsAt)
+		}
+		req.Alerts = append(req.Alerts, alert)
+	}
+
+	return req
+}
+
+// connect creates the connection to the receiver. It does not wait for the connection to be
+// established, the first request does.
+func (gn *GRPCNotifier) connect(ctx context.Context) (pooledConn, error) {
+	creds := insecure.NewCredentials()
+	if !gn.options.Insecure {
+		tlsConfig, err := brokerTLSConfig(gn.options.TLSSkipVerify, gn.options.TLSCACert, gn.options.TLSClientCert, gn.options.TLSClientKey)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpcDial(ctx, gn.options.Address, grpc.WithTransportCredentials(creds), grpc.WithUserAgent("Grafana"))
+	if err != nil {
+		return nil, err
+	}
+	return grpcConn{conn}, nil
+}
+
+func (gn *GRPCNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
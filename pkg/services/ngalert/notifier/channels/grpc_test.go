@@ -0,0 +1,194 @@
+package channels
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeAlertReceiver records the requests and their metadata.
+type fakeAlertReceiver struct {
+	alertreceiverv1.UnimplementedAlertReceiverServer
+	requests []*alertreceiverv1.NotifyRequest
+	metadata []metadata.MD
+	err      error
+}
+
+func (f *fakeAlertReceiver) Notify(ctx context.Context, req *alertreceiverv1.NotifyRequest) (*alertreceiverv1.NotifyResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.requests = append(f.requests, req)
+	f.metadata = append(f.metadata, md)
+	return &alertreceiverv1.NotifyResponse{}, f.err
+}
+
+func TestGRPCNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	receiver := &fakeAlertReceiver{}
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	alertreceiverv1.RegisterAlertReceiverServer(srv, receiver)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	grpcConnections = newConnPool(grpcIdleTimeout)
+	origGRPCDial := grpcDial
+	t.Cleanup(func() {
+		grpcDial = origGRPCDial
+	})
+	dials := 0
+	grpcDial = func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+		require.Equal(t, "receiver.example.com:443", target)
+		dials++
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+		return origGRPCDial(ctx, target, opts...)
+	}
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"address": "receiver.example.com:443", "insecure": true, "metadata": "Authorization: Bearer token\nx-tenant: team-a", "maxAlerts": "1"}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewGRPCConfig(&NotificationChannelConfig{UID: "grpc-uid", Name: "grpc_testing", Type: "grpc", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+	n := NewGRPCNotifier(cfg, &UnavailableImageStore{}, tmpl)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "DiskFull"})
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			Annotations: model.LabelSet{"summary": "Disk full"},
+			StartsAt:    time.Unix(1700000000, 0),
+		},
+	}, {
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DiskFull", "instance": "db-2"},
+		},
+	}}
+
+	ok, err := n.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Len(t, receiver.requests, 1)
+	req := receiver.requests[0]
+	require.Equal(t, "firing", req.Status)
+	require.Equal(t, "alertname", req.GroupKey)
+	require.Equal(t, map[string]string{"alertname": "DiskFull"}, req.GroupLabels)
+	require.Equal(t, "[FIRING:1] DiskFull (db-1)", req.Title)
+	require.Equal(t, int32(1), req.TruncatedAlerts)
+	require.Len(t, req.Alerts, 1)
+	require.Equal(t, "firing", req.Alerts[0].Status)
+	require.Equal(t, map[string]string{"alertname": "DiskFull", "instance": "db-1"}, req.Alerts[0].Labels)
+	require.Equal(t, int64(1700000000), req.Alerts[0].StartsAt.GetSeconds())
+	require.Nil(t, req.Alerts[0].EndsAt)
+	require.Equal(t, []string{"Bearer token"}, receiver.metadata[0].Get("authorization"))
+	require.Equal(t, []string{"team-a"}, receiver.metadata[0].Get("x-tenant"))
+
+	// The connection is reused by the next notifications.
+	ok, err = n.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, receiver.requests, 2)
+	require.Equal(t, 1, dials)
+
+	// The errors of the receiver fail the notification.
+	receiver.err = status.Error(codes.InvalidArgument, "unknown team")
+	_, err = n.Notify(ctx, alerts...)
+	require.EqualError(t, err, "rpc error: code = InvalidArgument desc = unknown team")
+}
+
+func TestGRPCNotifierDryRun(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"address": "receiver.example.com:443"}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewGRPCConfig(&NotificationChannelConfig{Name: "grpc_testing", Type: "grpc", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	d := &DryRun{}
+	ctx = WithDryRun(ctx, d)
+	ok, err := NewGRPCNotifier(cfg, &UnavailableImageStore{}, tmpl).Notify(ctx, &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "DiskFull"}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, d.Requests(), 1)
+	require.Equal(t, "receiver.example.com:443", d.Requests()[0].Target)
+	require.Contains(t, d.Requests()[0].Body, `"groupKey":"alertname"`)
+}
+
+func TestNewGRPCConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expError string
+	}{
+		{
+			name:     "Error when the address is missing",
+			settings: `{}`,
+			expError: "could not find address in settings",
+		}, {
+			name:     "Error when the metadata is invalid",
+			settings: `{"address": "localhost:9000", "metadata": "x-tenant team-a"}`,
+			expError: `invalid metadata "x-tenant team-a", must be in the format key: value`,
+		}, {
+			name:     "Error when the metadata is reserved",
+			settings: `{"address": "localhost:9000", "metadata": "grpc-timeout: 1S"}`,
+			expError: `metadata key "grpc-timeout" is reserved`,
+		}, {
+			name:     "Error when the maximum number of alerts is invalid",
+			settings: `{"address": "localhost:9000", "maxAlerts": "all"}`,
+			expError: "invalid maximum number of alerts, must be a number",
+		}, {
+			name:     "Error when TLS settings are set without TLS",
+			settings: `{"address": "localhost:9000", "insecure": true, "tlsSkipVerify": true}`,
+			expError: "TLS settings cannot be set when TLS is disabled",
+		}, {
+			name:     "Error when the client certificate is invalid",
+			settings: `{"address": "localhost:9000", "tlsClientCert": "cert", "tlsClientKey": "key"}`,
+			expError: "invalid TLS settings: tls: failed to find any PEM data in certificate input",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewGRPCConfig(&NotificationChannelConfig{Name: "grpc_testing", Type: "grpc", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expError)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// homeAssistantServicePattern matches the services of Home Assistant, such as light.turn_on.
+var homeAssistantServicePattern = regexp.MustCompile(`^[a-z0-9_]+\.[a-z0-9_]+$`)
+
+type HomeAssistantConfig struct {
+	*NotificationChannelConfig
+	URL             string
+	Token           string
+	Service         string
+	ResolvedService string
+	EntityID        string
+	ServiceData     string
+	WebhookID       string
+}
+
+func HomeAssistantFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewHomeAssistantConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewHomeAssistantNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewHomeAssistantConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*HomeAssistantConfig, error) {
+	rawURL := strings.TrimRight(strings.TrimSpace(config.Settings.Get("url").MustString()), "/")
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL %q", rawURL)
+	}
+
+	service := strings.TrimSpace(config.Settings.Get("service").MustString())
+	webhookID := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "webhookId", config.Settings.Get("webhookId").MustString()))
+	if service == "" && webhookID == "" {
+		return nil, errors.New("either a service or a webhook ID is required")
+	}
+	if service != "" && webhookID != "" {
+		return nil, errors.New("a service and a webhook ID cannot both be set")
+	}
+	resolvedService := strings.TrimSpace(config.Settings.Get("resolvedService").MustString())
+	for _, s := range []string{service, resolvedService} {
+		if s != "" && !homeAssistantServicePattern.MatchString(s) {
+			return nil, fmt.Errorf("invalid service %q, must be a domain and a service such as light.turn_on", s)
+		}
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if service != "" && token == "" {
+		return nil, errors.New("could not find token in settings, a long-lived access token is required to call services")
+	}
+
+	return &HomeAssistantConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		Token:                     token,
+		Service:                   service,
+		ResolvedService:           resolvedService,
+		EntityID:                  strings.TrimSpace(config.Settings.Get("entityId").MustString()),
+		ServiceData:               strings.TrimSpace(config.Settings.Get("serviceData").MustString()),
+		WebhookID:                 webhookID,
+	}, nil
+}
+
+// NewHomeAssistantNotifier is the constructor for the Home Assistant notifier.
+func NewHomeAssistantNotifier(config *HomeAssistantConfig, ns notifications.WebhookSender, t *template.Template) *HomeAssistantNotifier {
+	return &HomeAssistantNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:           config.OrgID,
+		URL:             config.URL,
+		Token:           config.Token,
+		Service:         config.Service,
+		ResolvedService: config.ResolvedService,
+		EntityID:        config.EntityID,
+		ServiceData:     config.ServiceData,
+		WebhookID:       config.WebhookID,
+		log:             log.New("alerting.notifier.homeassistant"),
+		ns:              ns,
+		tmpl:            t,
+	}
+}
+
+// HomeAssistantNotifier is responsible for calling a service of Home Assistant when the alerts
+// fire, and another when they resolve, or for triggering the automations of a webhook.
+type HomeAssistantNotifier struct {
+	*Base
+	orgID           int64
+	URL             string
+	Token           string
+	Service         string
+	ResolvedService string
+	EntityID        string
+	ServiceData     string
+	WebhookID       string
+	log             log.Logger
+	ns              notifications.WebhookSender
+	tmpl            *template.Template
+}
+
+// Notify calls the service with the service data and the entity when the alerts fire, and the
+// resolved service with the entity when they all resolve. Without a resolved service, the
+// resolved notifications are not sent. In the webhook mode, the notification is sent to the
+// webhook, whose automations read it from trigger.json.
+func (hn *HomeAssistantNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	hn.log.Debug("executing Home Assistant notification", "notification", hn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, hn.tmpl, as, hn.log, &tmplErr)
+
+	var (
+		path string
+		body []byte
+	)
+	if hn.WebhookID != "" {
+		groupKey, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		path = "/api/webhook/" + url.PathEscape(hn.WebhookID)
+		body, err = json.Marshal(newBrokerMessage(ctx, hn.PayloadVersion(), tmpl, data, groupKey.String(), hn.orgID, as...))
+		if err != nil {
+			return false, err
+		}
+	} else {
+		service, serviceData := hn.Service, hn.ServiceData
+		if types.Alerts(as...).Status() == model.AlertResolved {
+			if hn.ResolvedService == "" {
+				hn.log.Debug("no resolved service, skipping the resolved notification", "notification", hn.Name)
+				return true, nil
+			}
+			service, serviceData = hn.ResolvedService, ""
+		}
+		call, err := hn.serviceCall(tmpl, serviceData)
+		if err != nil {
+			return false, err
+		}
+		path = "/api/services/" + strings.Replace(service, ".", "/", 1)
+		body, err = json.Marshal(call)
+		if err != nil {
+			return false, err
+		}
+	}
+	if tmplErr != nil {
+		hn.log.Warn("failed to template Home Assistant notification", "err", tmplErr.Error())
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:         hn.URL + path,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	if hn.WebhookID == "" {
+		cmd.HttpHeader = map[string]string{"Authorization": "Bearer " + hn.Token}
+	}
+	if err := hn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		hn.log.Error("failed to send Home Assistant notification", "err", err, "notification", hn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// serviceCall returns the data of the service call, the templated service data with the
+// templated entity.
+func (hn *HomeAssistantNotifier) serviceCall(tmpl func(string) string, serviceData string) (map[string]interface{}, error) {
+	call := map[string]interface{}{}
+	if serviceData != "" {
+		if err := json.Unmarshal([]byte(tmpl(serviceData)), &call); err != nil {
+			return nil, fmt.Errorf("the service data is not a JSON object: %w", err)
+		}
+	}
+	if entityID := strings.TrimSpace(tmpl(hn.EntityID)); entityID != "" {
+		call["entity_id"] = entityID
+	}
+	return call, nil
+}
+
+func (hn *HomeAssistantNotifier) SendResolved() bool {
+	return !hn.GetDisableResolveMessage()
+}
@@ -0,0 +1,155 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestHomeAssistantNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DoorOpen", "room": "garage"},
+			Annotations: model.LabelSet{"summary": "the door is open"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DoorOpen", "room": "garage"},
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	cases := []struct {
+		name          string
+		settings      string
+		alerts        []*types.Alert
+		expURL        string
+		expHeaders    map[string]string
+		expBody       string
+		expNotSent    bool
+		expError      string
+		expInitError  string
+		expBodyFields []string
+	}{
+		{
+			name: "Calls the service with the templated entity and data",
+			settings: `{"url": "http://homeassistant.local:8123/", "token": "secret", "service": "light.turn_on",
+				"entityId": "light.{{ .CommonLabels.room }}", "serviceData": "{\"color_name\": \"red\", \"flash\": \"long\"}"}`,
+			alerts:     []*types.Alert{firing},
+			expURL:     "http://homeassistant.local:8123/api/services/light/turn_on",
+			expHeaders: map[string]string{"Authorization": "Bearer secret"},
+			expBody:    `{"entity_id": "light.garage", "color_name": "red", "flash": "long"}`,
+		}, {
+			name: "Calls the resolved service with the entity",
+			settings: `{"url": "http://homeassistant.local:8123", "token": "secret", "service": "light.turn_on",
+				"resolvedService": "light.turn_off", "entityId": "light.{{ .CommonLabels.room }}", "serviceData": "{\"color_name\": \"red\"}"}`,
+			alerts:     []*types.Alert{resolved},
+			expURL:     "http://homeassistant.local:8123/api/services/light/turn_off",
+			expHeaders: map[string]string{"Authorization": "Bearer secret"},
+			expBody:    `{"entity_id": "light.garage"}`,
+		}, {
+			name:       "Skips the resolved notifications without a resolved service",
+			settings:   `{"url": "http://homeassistant.local:8123", "token": "secret", "service": "siren.turn_on"}`,
+			alerts:     []*types.Alert{resolved},
+			expNotSent: true,
+		}, {
+			name:          "Sends the notification to the webhook",
+			settings:      `{"url": "http://homeassistant.local:8123", "webhookId": "grafana-alerts"}`,
+			alerts:        []*types.Alert{firing},
+			expURL:        "http://homeassistant.local:8123/api/webhook/grafana-alerts",
+			expBodyFields: []string{"alerts", "state", "title", "message", "groupKey"},
+		}, {
+			name:     "Error when the service data is not a JSON object",
+			settings: `{"url": "http://homeassistant.local:8123", "token": "secret", "service": "light.turn_on", "serviceData": "[{{ .CommonLabels.room }}]"}`,
+			alerts:   []*types.Alert{firing},
+			expError: "the service data is not a JSON object: invalid character 'g' looking for beginning of value",
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{"token": "secret", "service": "light.turn_on"}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error without a service or a webhook",
+			settings:     `{"url": "http://homeassistant.local:8123", "token": "secret"}`,
+			expInitError: "either a service or a webhook ID is required",
+		}, {
+			name:         "Error with both a service and a webhook",
+			settings:     `{"url": "http://homeassistant.local:8123", "token": "secret", "service": "light.turn_on", "webhookId": "grafana-alerts"}`,
+			expInitError: "a service and a webhook ID cannot both be set",
+		}, {
+			name:         "Error when the service is invalid",
+			settings:     `{"url": "http://homeassistant.local:8123", "token": "secret", "service": "light.turn_on", "resolvedService": "turn_off"}`,
+			expInitError: `invalid service "turn_off", must be a domain and a service such as light.turn_on`,
+		}, {
+			name:         "Error when the token is missing",
+			settings:     `{"url": "http://homeassistant.local:8123", "service": "light.turn_on"}`,
+			expInitError: "could not find token in settings, a long-lived access token is required to call services",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "homeassistant_testing",
+				Type:     "homeassistant",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewHomeAssistantConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewHomeAssistantNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			if c.expError != "" {
+				require.EqualError(t, err, c.expError)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			if c.expNotSent {
+				require.Nil(t, webhookSender.Webhook.HttpHeader)
+				require.Empty(t, webhookSender.Webhook.Url)
+				return
+			}
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			require.Equal(t, c.expHeaders, webhookSender.Webhook.HttpHeader)
+			if c.expBody != "" {
+				require.JSONEq(t, c.expBody, webhookSender.Webhook.Body)
+			}
+			if c.expBodyFields != nil {
+				var body map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+				for _, f := range c.expBodyFields {
+					require.Contains(t, body, f)
+				}
+			}
+		})
+	}
+}
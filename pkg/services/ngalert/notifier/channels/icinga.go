@@ -0,0 +1,269 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultIcingaHostname    = `{{ or .CommonLabels.hostname .CommonLabels.instance "grafana" }}`
+	defaultIcingaServiceName = `{{ .CommonLabels.alertname }}`
+	defaultIcingaOutput      = `{{ template "default.title" . }}`
+
+	icingaCheckSource = "grafana"
+)
+
+// The exit statuses of the service and host check results.
+const (
+	icingaServiceOK       = 0
+	icingaServiceCritical = 2
+	icingaHostUp          = 0
+	icingaHostDown        = 1
+)
+
+type IcingaConfig struct {
+	*NotificationChannelConfig
+	URL         string
+	User        string
+	Password    string
+	TLS         *tls.Config
+	Hostname    string
+	ServiceName string
+	Output      string
+}
+
+func IcingaFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewIcingaConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewIcingaNotifier(cfg, fc.Template), nil
+}
+
+func NewIcingaConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*IcingaConfig, error) {
+	rawURL := strings.TrimRight(strings.TrimSpace(config.Settings.Get("url").MustString()), "/")
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q, the Icinga2 API is only served over https", rawURL)
+	}
+
+	user := config.Settings.Get("user").MustString()
+	password := decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString())
+	clientCert := config.Settings.Get("tlsClientCert").MustString()
+	clientKey := decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString())
+	if clientCert == "" && (user == "" || password == "") {
+		return nil, errors.New("either a client certificate or a user and a password are required")
+	}
+	tlsConfig, err := brokerTLSConfig(config.Settings.Get("tlsSkipVerify").MustBool(false), config.Settings.Get("tlsCACert").MustString(), clientCert, clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IcingaConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		User:                      user,
+		Password:                  password,
+		TLS:                       tlsConfig,
+		Hostname:                  config.Settings.Get("hostname").MustString(defaultIcingaHostname),
+		ServiceName:               config.Settings.Get("serviceName").MustString(defaultIcingaServiceName),
+		Output:                    config.Settings.Get("output").MustString(defaultIcingaOutput),
+	}, nil
+}
+
+// NewIcingaNotifier is the constructor for the Icinga2 notifier.
+func NewIcingaNotifier(config *IcingaConfig, t *template.Template) *IcingaNotifier {
+	transport := &http.Transport{
+		TLSClientConfig: config.TLS,
+		Proxy:           http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+	if config.Proxy != nil {
+		transport.Proxy = config.Proxy.ProxyFunc()
+	}
+	return &IcingaNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:         config.URL,
+		User:        config.User,
+		Password:    config.Password,
+		Hostname:    config.Hostname,
+		ServiceName: config.ServiceName,
+		Output:      config.Output,
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		log:         log.New("alerting.notifier.icinga"),
+		tmpl:        t,
+	}
+}
+
+// IcingaNotifier is responsible for processing the alerts as the check results of the services
+// or hosts of Icinga2, through its REST API.
+type IcingaNotifier struct {
+	*Base
+	URL         string
+	User        string
+	Password    string
+	Hostname    string
+	ServiceName string
+	Output      string
+	client      *http.Client
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// icingaCheckResult is the body of the process-check-result action. The names of the object are
+// passed as filter variables, so that they are never evaluated as part of the filter.
+type icingaCheckResult struct {
+	Type         string            `json:"type"`
+	Filter       string            `json:"filter"`
+	FilterVars   map[string]string `json:"filter_vars"`
+	ExitStatus   int               `json:"exit_status"`
+	PluginOutput string            `json:"plugin_output"`
+	CheckSource  string            `json:"check_source"`
+}
+
+// Notify processes a check result per alert, for the host and service named from the labels of
+// the alert. Firing alerts are CRITICAL and resolved alerts OK. When the service name is empty,
+// the result is the one of the host instead, which is DOWN or UP.
+func (in *IcingaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	in.log.Debug("processing Icinga2 check results", "notification", in.Name)
+
+	for _, a := range as {
+		var tmplErr error
+		tmpl, _ := TmplText(ctx, in.tmpl, []*types.Alert{a}, in.log, &tmplErr)
+		hostname := strings.TrimSpace(tmpl(in.Hostname))
+		serviceName := strings.TrimSpace(tmpl(in.ServiceName))
+		output := strings.TrimSpace(tmpl(in.Output))
+		if tmplErr != nil {
+			in.log.Warn("failed to template Icinga2 check result", "err", tmplErr.Error())
+		}
+		if hostname == "" {
+			return false, fmt.Errorf("the host name of the alert %s is empty", a.Name())
+		}
+
+		result := icingaCheckResult{
+			PluginOutput: output,
+			CheckSource:  icingaCheckSource,
+		}
+		if serviceName != "" {
+			result.Type = "Service"
+			result.Filter = "host.name == host_name && service.name == service_name"
+			result.FilterVars = map[string]string{"host_name": hostname, "service_name": serviceName}
+			result.ExitStatus = icingaServiceCritical
+			if a.Resolved() {
+				result.ExitStatus = icingaServiceOK
+			}
+		} else {
+			result.Type = "Host"
+			result.Filter = "host.name == host_name"
+			result.FilterVars = map[string]string{"host_name": hostname}
+			result.ExitStatus = icingaHostDown
+			if a.Resolved() {
+				result.ExitStatus = icingaHostUp
+			}
+		}
+
+		if err := in.processCheckResult(ctx, result); err != nil {
+			in.log.Error("failed to process Icinga2 check result", "err", err, "notification", in.Name, "host", hostname, "service", serviceName)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (in *IcingaNotifier) processCheckResult(ctx context.Context, result icingaCheckResult) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if recordDryRun(ctx, urlTarget(in.URL), string(b)) {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, in.URL+"/v1/actions/process-check-result", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+	if in.User != "" && in.Password != "" {
+		req.Header.Set("Authorization", util.GetBasicAuthHeader(in.User, in.Password))
+	}
+
+	resp, err := in.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			in.log.Warn("failed to close response body", "err", err)
+		}
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	return icingaValidation(body, resp.StatusCode)
+}
+
+// icingaValidation checks the results of the action, one per object matching the filter. No
+// object matching the filter is an error, as the check result would be lost.
+func icingaValidation(body []byte, statusCode int) error {
+	var resp struct {
+		Status  string `json:"status"`
+		Results []struct {
+			Code   float64 `json:"code"`
+			Status string  `json:"status"`
+		} `json:"results"`
+	}
+	_ = json.Unmarshal(body, &resp)
+	for _, r := range resp.Results {
+		if int(r.Code)/100 != 2 {
+			return fmt.Errorf("the Icinga2 API failed to process the check result: %s", r.Status)
+		}
+	}
+	if statusCode/100 != 2 {
+		if resp.Status != "" {
+			return fmt.Errorf("the Icinga2 API returned status %d: %s", statusCode, resp.Status)
+		}
+		return fmt.Errorf("the Icinga2 API returned status %d", statusCode)
+	}
+	if len(resp.Results) == 0 {
+		return errors.New("the Icinga2 API found no object matching the check result")
+	}
+	return nil
+}
+
+func (in *IcingaNotifier) SendResolved() bool {
+	return !in.GetDisableResolveMessage()
+}
@@ -0,0 +1,272 @@
+package channels
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeIcinga is an Icinga2 API that authenticates the API user "grafana" with its client
+// certificate or its password, and records the check results of the known hosts.
+type fakeIcinga struct {
+	*httptest.Server
+	mtx     sync.Mutex
+	results []map[string]interface{}
+}
+
+func newFakeIcinga(t *testing.T, clientCert *x509.Certificate) *fakeIcinga {
+	f := &fakeIcinga{}
+	f.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mtx.Lock()
+		defer f.mtx.Unlock()
+		user, password, basicAuth := r.BasicAuth()
+		certAuth := r.TLS != nil && len(r.TLS.PeerCertificates) > 0 && r.TLS.PeerCertificates[0].Subject.CommonName == "grafana"
+		if !certAuth && (!basicAuth || user != "grafana" || password != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":401.0,"status":"Unauthorized. Please check your user credentials."}`))
+			return
+		}
+		if r.URL.Path != "/v1/actions/process-check-result" || r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		var result map[string]interface{}
+		_ = json.Unmarshal(b, &result)
+		vars := result["filter_vars"].(map[string]interface{})
+		if vars["host_name"] != "db1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":404.0,"status":"No objects found."}`))
+			return
+		}
+		f.results = append(f.results, result)
+		_, _ = w.Write([]byte(`{"results":[{"code":200.0,"status":"Successfully processed check result."}]}`))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+	f.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	f.StartTLS()
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeIcinga) caCert() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.Certificate().Raw}))
+}
+
+// newClientCertificate returns a self-signed client certificate and its PEM encoded
+// certificate and key.
+func newClientCertificate(t *testing.T, commonName string) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return cert,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestIcingaNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cert, certPEM, keyPEM := newClientCertificate(t, "grafana")
+	icinga := newFakeIcinga(t, cert)
+	settings := func(extra string) string {
+		s := map[string]interface{}{"url": icinga.URL, "tlsCACert": icinga.caCert()}
+		require.NoError(t, json.Unmarshal([]byte(extra), &s))
+		b, err := json.Marshal(s)
+		require.NoError(t, err)
+		return string(b)
+	}
+	certSettings := func(extra string) string {
+		s := map[string]interface{}{"tlsClientCert": certPEM, "tlsClientKey": keyPEM}
+		require.NoError(t, json.Unmarshal([]byte(extra), &s))
+		b, err := json.Marshal(s)
+		require.NoError(t, err)
+		return settings(string(b))
+	}
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db1"},
+			Annotations: model.LabelSet{"summary": "disk full"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "HighCPU", "hostname": "db1"},
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+	unknownHost := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "HighCPU", "hostname": "web1"},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		dryRun       bool
+		expResults   []map[string]interface{}
+		expError     string
+		expInitError string
+	}{
+		{
+			name:     "A service check result per alert with a client certificate",
+			settings: certSettings(`{"output": "{{ .CommonAnnotations.summary }}"}`),
+			alerts:   []*types.Alert{firing, resolved},
+			expResults: []map[string]interface{}{
+				{"type": "Service", "filter": "host.name == host_name && service.name == service_name", "filter_vars": map[string]interface{}{"host_name": "db1", "service_name": "DiskFull"}, "exit_status": float64(2), "plugin_output": "disk full", "check_source": "grafana"},
+				{"type": "Service", "filter": "host.name == host_name && service.name == service_name", "filter_vars": map[string]interface{}{"host_name": "db1", "service_name": "HighCPU"}, "exit_status": float64(0), "plugin_output": "", "check_source": "grafana"},
+			},
+		}, {
+			name:     "Host check results with a password",
+			settings: settings(`{"user": "grafana", "password": "secret", "serviceName": "", "output": "down"}`),
+			alerts:   []*types.Alert{firing, resolved},
+			expResults: []map[string]interface{}{
+				{"type": "Host", "filter": "host.name == host_name", "filter_vars": map[string]interface{}{"host_name": "db1"}, "exit_status": float64(1), "plugin_output": "down", "check_source": "grafana"},
+				{"type": "Host", "filter": "host.name == host_name", "filter_vars": map[string]interface{}{"host_name": "db1"}, "exit_status": float64(0), "plugin_output": "down", "check_source": "grafana"},
+			},
+		}, {
+			name:     "Check results are recorded instead of sent in dry runs",
+			settings: certSettings(`{"output": "{{ .CommonAnnotations.summary }}"}`),
+			alerts:   []*types.Alert{firing, resolved},
+			dryRun:   true,
+		}, {
+			name:     "Error when the host is unknown",
+			settings: certSettings(`{}`),
+			alerts:   []*types.Alert{unknownHost},
+			expError: "the Icinga2 API returned status 404: No objects found.",
+		}, {
+			name:     "Error when the password is wrong",
+			settings: settings(`{"user": "grafana", "password": "wrong"}`),
+			alerts:   []*types.Alert{firing},
+			expError: "the Icinga2 API returned status 401: Unauthorized. Please check your user credentials.",
+		}, {
+			name:     "Error when the host name is empty",
+			settings: certSettings(`{"hostname": "{{ .CommonLabels.host }}"}`),
+			alerts:   []*types.Alert{firing},
+			expError: "the host name of the alert DiskFull is empty",
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{"user": "grafana", "password": "secret"}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when the URL is not https",
+			settings:     `{"url": "http://icinga.example.com:5665", "user": "grafana", "password": "secret"}`,
+			expInitError: `invalid URL "http://icinga.example.com:5665", the Icinga2 API is only served over https`,
+		}, {
+			name:         "Error when the credentials are missing",
+			settings:     `{"url": "https://icinga.example.com:5665", "user": "grafana"}`,
+			expInitError: "either a client certificate or a user and a password are required",
+		}, {
+			name:         "Error when the client key is missing",
+			settings:     fmt.Sprintf(`{"url": "https://icinga.example.com:5665", "tlsClientCert": %q}`, certPEM),
+			expInitError: "both client certificate and client key are required",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			icinga.mtx.Lock()
+			icinga.results = nil
+			icinga.mtx.Unlock()
+
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "icinga_testing",
+				Type:     "icinga",
+				Settings: settingsJSON,
+			}
+
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewIcingaConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			d := &DryRun{}
+			if c.dryRun {
+				ctx = WithDryRun(ctx, d)
+			}
+			ok, err := NewIcingaNotifier(cfg, tmpl).Notify(ctx, c.alerts...)
+			if c.expError != "" {
+				require.EqualError(t, err, c.expError)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			icinga.mtx.Lock()
+			defer icinga.mtx.Unlock()
+			require.Equal(t, c.expResults, icinga.results)
+			if c.dryRun {
+				require.Len(t, d.Requests(), len(c.alerts))
+				require.Equal(t, icinga.URL, d.Requests()[0].Target)
+				require.Contains(t, d.Requests()[0].Body, `"plugin_output":"disk full"`)
+			}
+		})
+	}
+}
+
+func TestIcingaValidation(t *testing.T) {
+	require.NoError(t, icingaValidation([]byte(`{"results":[{"code":200.0,"status":"Successfully processed check result."}]}`), 200))
+	require.EqualError(t, icingaValidation([]byte(`{"results":[]}`), 200), "the Icinga2 API found no object matching the check result")
+	require.EqualError(t, icingaValidation([]byte(`{"results":[{"code":200.0,"status":"OK"},{"code":500.0,"status":"Check result is not enabled."}]}`), 500),
+		"the Icinga2 API failed to process the check result: Check result is not enabled.")
+	require.EqualError(t, icingaValidation([]byte(`{"results":[{"code":409.0,"status":"Check result is not enabled."}]}`), 200),
+		"the Icinga2 API failed to process the check result: Check result is not enabled.")
+	require.EqualError(t, icingaValidation([]byte(`bad gateway`), 502), "the Icinga2 API returned status 502")
+}
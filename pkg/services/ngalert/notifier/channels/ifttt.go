@@ -0,0 +1,172 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultIFTTTURL    = "https://maker.ifttt.com"
+	defaultIFTTTValue3 = `{{ .Status }}`
+)
+
+type IFTTTConfig struct {
+	*NotificationChannelConfig
+	URL       string
+	EventName string
+	Key       string
+	Value1    string
+	Value2    string
+	Value3    string
+}
+
+func IFTTTFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewIFTTTConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewIFTTTNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewIFTTTConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*IFTTTConfig, error) {
+	eventName := strings.TrimSpace(config.Settings.Get("eventName").MustString())
+	if eventName == "" {
+		return nil, errors.New("could not find event name in settings")
+	}
+	key := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "key", config.Settings.Get("key").MustString()))
+	if key == "" {
+		return nil, errors.New("could not find key in settings")
+	}
+	rawURL := strings.TrimRight(strings.TrimSpace(config.Settings.Get("url").MustString(defaultIFTTTURL)), "/")
+	if rawURL == "" {
+		rawURL = defaultIFTTTURL
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL %q", rawURL)
+	}
+	return &IFTTTConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		EventName:                 eventName,
+		Key:                       key,
+		Value1:                    config.Settings.Get("value1").MustString(DefaultMessageTitleEmbed),
+		Value2:                    config.Settings.Get("value2").MustString(`{{ template "default.message" . }}`),
+		Value3:                    config.Settings.Get("value3").MustString(defaultIFTTTValue3),
+	}, nil
+}
+
+// NewIFTTTNotifier is the constructor for the IFTTT notifier.
+func NewIFTTTNotifier(config *IFTTTConfig, ns notifications.WebhookSender, t *template.Template) *IFTTTNotifier {
+	return &IFTTTNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:       config.URL,
+		EventName: config.EventName,
+		Key:       config.Key,
+		Value1:    config.Value1,
+		Value2:    config.Value2,
+		Value3:    config.Value3,
+		log:       log.New("alerting.notifier.ifttt"),
+		ns:        ns,
+		tmpl:      t,
+	}
+}
+
+// IFTTTNotifier is responsible for triggering an event of the Webhooks service of IFTTT, whose
+// applets read the three values of the event.
+type IFTTTNotifier struct {
+	*Base
+	URL       string
+	EventName string
+	Key       string
+	Value1    string
+	Value2    string
+	Value3    string
+	log       log.Logger
+	ns        notifications.WebhookSender
+	tmpl      *template.Template
+}
+
+type iftttEvent struct {
+	Value1 string `json:"value1"`
+	Value2 string `json:"value2"`
+	Value3 string `json:"value3"`
+}
+
+// Notify triggers the event with the templated values. By default, they are the title, the
+// message and the status of the notification.
+func (in *IFTTTNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	in.log.Debug("executing IFTTT notification", "notification", in.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, in.tmpl, as, in.log, &tmplErr)
+
+	event := iftttEvent{
+		Value1: strings.TrimSpace(tmpl(in.Value1)),
+		Value2: strings.TrimSpace(tmpl(in.Value2)),
+		Value3: strings.TrimSpace(tmpl(in.Value3)),
+	}
+	if tmplErr != nil {
+		in.log.Warn("failed to template IFTTT event", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         fmt.Sprintf("%s/trigger/%s/with/key/%s", in.URL, url.PathEscape(in.EventName), url.PathEscape(in.Key)),
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+		Validation:  iftttValidation,
+	}
+	if err := in.ns.SendWebhookSync(ctx, cmd); err != nil {
+		in.log.Error("failed to send IFTTT event", "err", err, "notification", in.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// iftttValidation returns the errors of the Webhooks service, such as an invalid key.
+func iftttValidation(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("IFTTT returned status %d: %s", statusCode, strings.Join(messages, "; "))
+	}
+	return fmt.Errorf("IFTTT returned status %d", statusCode)
+}
+
+func (in *IFTTTNotifier) SendResolved() bool {
+	return !in.GetDisableResolveMessage()
+}
@@ -0,0 +1,101 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestIFTTTNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "Leak", "room": "kitchen"},
+			Annotations: model.LabelSet{"summary": "water on the floor"},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		expURL       string
+		expBody      string
+		expInitError string
+	}{
+		{
+			name:     "Triggers the event with the templated values",
+			settings: `{"eventName": "grafana_alert", "key": "secret", "value1": "{{ .CommonLabels.alertname }}", "value2": "{{ .CommonLabels.room }}"}`,
+			expURL:   "https://maker.ifttt.com/trigger/grafana_alert/with/key/secret",
+			expBody:  `{"value1": "Leak", "value2": "kitchen", "value3": "firing"}`,
+		}, {
+			name:     "Default values",
+			settings: `{"eventName": "grafana_alert", "key": "secret", "url": "http://localhost:8080/"}`,
+			expURL:   "http://localhost:8080/trigger/grafana_alert/with/key/secret",
+			expBody: `{"value1": "[FIRING:1]  (kitchen)", "value3": "firing",
+				"value2": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = Leak\n - room = kitchen\nAnnotations:\n - summary = water on the floor\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DLeak&matcher=room%3Dkitchen"}`,
+		}, {
+			name:         "Error when the event name is missing",
+			settings:     `{"key": "secret"}`,
+			expInitError: "could not find event name in settings",
+		}, {
+			name:         "Error when the key is missing",
+			settings:     `{"eventName": "grafana_alert"}`,
+			expInitError: "could not find key in settings",
+		}, {
+			name:         "Error when the URL is invalid",
+			settings:     `{"eventName": "grafana_alert", "key": "secret", "url": "maker.ifttt.com"}`,
+			expInitError: `invalid URL "maker.ifttt.com"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "ifttt_testing",
+				Type:     "ifttt",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewIFTTTConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewIFTTTNotifier(cfg, webhookSender, tmpl).Notify(ctx, firing)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			require.JSONEq(t, c.expBody, webhookSender.Webhook.Body)
+		})
+	}
+}
+
+func TestIFTTTValidation(t *testing.T) {
+	require.NoError(t, iftttValidation([]byte("Congratulations! You've fired the grafana_alert event"), 200))
+	require.EqualError(t, iftttValidation([]byte(`{"errors":[{"message":"You sent an invalid key."}]}`), 401),
+		"IFTTT returned status 401: You sent an invalid key.")
+	require.EqualError(t, iftttValidation([]byte("Bad Gateway"), 502), "IFTTT returned status 502")
+}
@@ -0,0 +1,279 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The providers of the alert events API.
+	incidentIOProviderIncidentIO  = "incidentio"
+	incidentIOProviderBetterStack = "betterstack"
+
+	defaultIncidentIOSeverityLabel = "severity"
+
+	// incidentIODeduplicationPrefix is the prefix of the deduplication key of the alert events,
+	// which is the hash of their alert group.
+	incidentIODeduplicationPrefix = "grafana-"
+
+	incidentIOMaxTitleLength       = 255
+	incidentIOMaxDescriptionLength = 10000
+)
+
+// incidentIOPriorities maps common values of the severity label to the priorities of the alert
+// events, from P1 (urgent) to P4 (low).
+var incidentIOPriorities = map[string]string{
+	"critical": "P1",
+	"urgent":   "P1",
+	"page":     "P1",
+	"high":     "P2",
+	"error":    "P2",
+	"major":    "P2",
+	"warning":  "P3",
+	"medium":   "P3",
+	"low":      "P4",
+	"minor":    "P4",
+	"info":     "P4",
+	"none":     "P4",
+	"debug":    "P4",
+}
+
+type IncidentIOConfig struct {
+	*NotificationChannelConfig
+	Provider            string
+	URL                 string
+	Token               string
+	SeverityLabel       string
+	Priorities          map[string]string
+	MetadataAnnotations []string
+	Title               string
+	Description         string
+}
+
+func IncidentIOFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewIncidentIOConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewIncidentIONotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewIncidentIOConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*IncidentIOConfig, error) {
+	provider := config.Settings.Get("provider").MustString(incidentIOProviderIncidentIO)
+	switch provider {
+	case "":
+		provider = incidentIOProviderIncidentIO
+	case incidentIOProviderIncidentIO, incidentIOProviderBetterStack:
+	default:
+		return nil, fmt.Errorf("invalid provider %q, must be incidentio or betterstack", provider)
+	}
+	rawURL := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString()))
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.New("invalid URL of the alert source")
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	// The incoming webhooks of Better Stack are authenticated by their URL.
+	if token == "" && provider == incidentIOProviderIncidentIO {
+		return nil, errors.New("could not find token in settings")
+	}
+	priorities, err := severityMapFromSettings(config.Settings.Get("priorities"), "priority", incidentIOPriorities)
+	if err != nil {
+		return nil, err
+	}
+	return &IncidentIOConfig{
+		NotificationChannelConfig: config,
+		Provider:                  provider,
+		URL:                       rawURL,
+		Token:                     token,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultIncidentIOSeverityLabel),
+		Priorities:                priorities,
+		MetadataAnnotations:       splitCommaList(config.Settings.Get("metadataAnnotations").MustString()),
+		Title:                     config.Settings.Get("title").MustString(`{{ template "default.title" . }}`),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewIncidentIONotifier is the constructor for the incident.io notifier.
+func NewIncidentIONotifier(config *IncidentIOConfig, ns notifications.WebhookSender, t *template.Template) *IncidentIONotifier {
+	return &IncidentIONotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Provider:            config.Provider,
+		URL:                 config.URL,
+		Token:               config.Token,
+		SeverityLabel:       config.SeverityLabel,
+		Priorities:          config.Priorities,
+		MetadataAnnotations: config.MetadataAnnotations,
+		Title:               config.Title,
+		Description:         config.Description,
+		log:                 log.New("alerting.notifier.incidentio"),
+		ns:                  ns,
+		tmpl:                t,
+	}
+}
+
+// IncidentIONotifier is responsible for sending alert events to the HTTP alert sources of
+// incident.io, and to the incoming webhooks of Better Stack, which accept the same events.
+type IncidentIONotifier struct {
+	*Base
+	Provider            string
+	URL                 string
+	Token               string
+	SeverityLabel       string
+	Priorities          map[string]string
+	MetadataAnnotations []string
+	Title               string
+	Description         string
+	log                 log.Logger
+	ns                  notifications.WebhookSender
+	tmpl                *template.Template
+}
+
+type incidentIOAlertEvent struct {
+	Title            string                 `json:"title"`
+	Description      string                 `json:"description"`
+	DeduplicationKey string                 `json:"deduplication_key"`
+	Status           string                 `json:"status"`
+	SourceURL        string                 `json:"source_url,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
+}
+
+// Notify sends an alert event for the alert group. The events of a group have the same
+// deduplication key, so the alert of the group is updated while it fires, and resolved with it.
+func (in *IncidentIONotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	in.log.Debug("sending incident.io notification", "notification", in.Name, "provider", in.Provider)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, in.tmpl, as, in.log, &tmplErr)
+
+	title, _ := in.Truncate(strings.Join(strings.Fields(tmpl(in.Title)), " "), incidentIOMaxTitleLength)
+	description, _ := in.Truncate(tmpl(in.Description), incidentIOMaxDescriptionLength)
+	event := incidentIOAlertEvent{
+		Title:            title,
+		Description:      description,
+		DeduplicationKey: incidentIODeduplicationPrefix + groupKey.Hash(),
+		Status:           data.Status,
+		SourceURL:        in.RuleListURL(in.tmpl.ExternalURL),
+		Metadata:         in.metadata(data),
+	}
+	if len(data.Alerts) == 1 && data.Alerts[0].GeneratorURL != "" {
+		event.SourceURL = data.Alerts[0].GeneratorURL
+	}
+	if tmplErr != nil {
+		in.log.Warn("failed to template incident.io alert event", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         in.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+		Validation:  incidentIOValidation,
+	}
+	if in.Token != "" {
+		cmd.HttpHeader = map[string]string{"Authorization": "Bearer " + in.Token}
+	}
+	if err := in.ns.SendWebhookSync(ctx, cmd); err != nil {
+		in.log.Error("failed to send incident.io notification", "err", err, "notification", in.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// metadata returns the metadata of the alert event: the group labels, the number of firing and
+// resolved alerts, the priority of the most severe firing alert, and the common annotations of
+// the alerts, or those of the MetadataAnnotations if set.
+func (in *IncidentIONotifier) metadata(data *ExtendedData) map[string]interface{} {
+	firing := len(data.Alerts.Firing())
+	metadata := map[string]interface{}{
+		"group_labels":   data.GroupLabels,
+		"firing_count":   firing,
+		"resolved_count": len(data.Alerts) - firing,
+	}
+	if data.Receiver != "" {
+		metadata["receiver"] = data.Receiver
+	}
+
+	priority := ""
+	for _, a := range data.Alerts.Firing() {
+		p, ok := in.Priorities[strings.ToLower(a.Labels[in.SeverityLabel])]
+		if ok && (priority == "" || p < priority) {
+			priority = p
+		}
+	}
+	if priority != "" {
+		metadata["priority"] = priority
+	}
+
+	annotations := map[string]string{}
+	if len(in.MetadataAnnotations) == 0 {
+		for k, v := range data.CommonAnnotations {
+			annotations[k] = v
+		}
+	}
+	for _, name := range in.MetadataAnnotations {
+		if v := zapierValue(data.Alerts, func(a ExtendedAlert) string { return a.Annotations[name] }); v != "" {
+			annotations[name] = v
+		}
+	}
+	metadata["annotations"] = annotations
+	return metadata
+}
+
+// incidentIOValidation returns the errors of the alert events API, such as an unknown alert
+// source or an invalid token.
+func incidentIOValidation(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var resp struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		msgs := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("the alert events API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+	}
+	return fmt.Errorf("the alert events API returned status %d", statusCode)
+}
+
+func (in *IncidentIONotifier) SendResolved() bool {
+	return !in.GetDisableResolveMessage()
+}
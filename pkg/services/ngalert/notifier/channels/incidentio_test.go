@@ -0,0 +1,124 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestIncidentIONotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "DiskFull", "instance": "db-1", "severity": "warning"},
+			Annotations:  model.LabelSet{"summary": "Disk is full", "runbook_url": "https://runbooks/disk"},
+			GeneratorURL: "http://localhost/alerting/grafana/abc/view",
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-2", "severity": "critical"},
+			Annotations: model.LabelSet{"summary": "Disk is full", "runbook_url": "https://runbooks/disk"},
+		},
+	}}
+	dedupKey := "grafana-" + notify.Key("alertname").Hash()
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expHeaders   map[string]string
+		expBody      string
+		expInitError string
+	}{
+		{
+			name:       "incident.io alert event",
+			settings:   `{"url": "https://api.incident.io/v2/alert_events/http/01ABC", "token": "secret", "title": "{{ .CommonLabels.alertname }}", "description": "{{ .CommonAnnotations.summary }}"}`,
+			alerts:     alerts,
+			expURL:     "https://api.incident.io/v2/alert_events/http/01ABC",
+			expHeaders: map[string]string{"Authorization": "Bearer secret"},
+			expBody: `{"title": "DiskFull", "description": "Disk is full", "deduplication_key": "` + dedupKey + `", "status": "firing",
+				"source_url": "http://localhost/alerting/list",
+				"metadata": {"group_labels": {"alertname": ""}, "firing_count": 2, "resolved_count": 0, "receiver": "incident",
+					"priority": "P1", "annotations": {"summary": "Disk is full", "runbook_url": "https://runbooks/disk"}}}`,
+		}, {
+			name:     "Better Stack incoming webhook",
+			settings: `{"provider": "betterstack", "url": "https://uptime.betterstack.com/api/v1/incoming-webhook/abc", "title": "{{ .CommonLabels.alertname }}", "description": "", "metadataAnnotations": "runbook_url, dashboard", "priorities": "warning=P2"}`,
+			alerts:   alerts[:1],
+			expURL:   "https://uptime.betterstack.com/api/v1/incoming-webhook/abc",
+			expBody: `{"title": "DiskFull", "description": "", "deduplication_key": "` + dedupKey + `", "status": "firing",
+				"source_url": "http://localhost/alerting/grafana/abc/view",
+				"metadata": {"group_labels": {"alertname": ""}, "firing_count": 1, "resolved_count": 0, "receiver": "incident",
+					"priority": "P2", "annotations": {"runbook_url": "https://runbooks/disk"}}}`,
+		}, {
+			name:         "Error when the token of incident.io is missing",
+			settings:     `{"url": "https://api.incident.io/v2/alert_events/http/01ABC"}`,
+			expInitError: "could not find token in settings",
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{"token": "secret"}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when the provider is invalid",
+			settings:     `{"provider": "pagerduty", "url": "https://events.pagerduty.com"}`,
+			expInitError: `invalid provider "pagerduty", must be incidentio or betterstack`,
+		}, {
+			name:         "Error when a priority mapping is invalid",
+			settings:     `{"url": "https://api.incident.io/v2/alert_events/http/01ABC", "token": "secret", "priorities": "P1"}`,
+			expInitError: `invalid priority mapping "P1", must be severity=priority`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "incidentio_testing",
+				Type:     "incidentio",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewIncidentIOConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "incident")
+			ok, err := NewIncidentIONotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			require.Equal(t, c.expHeaders, webhookSender.Webhook.HttpHeader)
+			require.JSONEq(t, c.expBody, webhookSender.Webhook.Body)
+		})
+	}
+}
+
+func TestIncidentIOValidation(t *testing.T) {
+	require.NoError(t, incidentIOValidation(nil, 202))
+	require.EqualError(t, incidentIOValidation([]byte(`{"type":"validation_error","status":422,"errors":[{"code":"invalid_value","message":"deduplication_key is too long"}]}`), 422),
+		"the alert events API returned status 422: deduplication_key is too long")
+	require.EqualError(t, incidentIOValidation([]byte("Unauthorized"), 401), "the alert events API returned status 401")
+}
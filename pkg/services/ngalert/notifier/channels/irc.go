@@ -0,0 +1,191 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	defaultIRCNick = "grafana"
+	// defaultIRCMessage is rendered for each alert of the notification.
+	defaultIRCMessage = `[{{ .Status | toUpper }}] {{ .CommonLabels.alertname }}{{ with .CommonAnnotations.summary }}: {{ . }}{{ end }}`
+	// ircMaxLines is the number of alerts sent before the rest are summarized, to avoid being
+	// disconnected for flooding the channel.
+	ircMaxLines = 10
+)
+
+type IRCConfig struct {
+	*NotificationChannelConfig
+	Server        string
+	TLS           bool
+	TLSSkipVerify bool
+	Nick          string
+	Channel       string
+	ChannelKey    string
+	SASLUsername  string
+	SASLPassword  string
+	Message       string
+}
+
+func IRCFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewIRCConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewIRCNotifier(cfg, fc.Template), nil
+}
+
+func NewIRCConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*IRCConfig, error) {
+	server := config.Settings.Get("server").MustString()
+	if server == "" {
+		return nil, errors.New("could not find server in settings")
+	}
+	nick := config.Settings.Get("nick").MustString(defaultIRCNick)
+	if strings.ContainsAny(nick, " ,:!@#&\r\n") {
+		return nil, fmt.Errorf("invalid nick %q", nick)
+	}
+	channel := config.Settings.Get("channel").MustString()
+	if channel == "" {
+		return nil, errors.New("could not find channel in settings")
+	}
+	if !strings.HasPrefix(channel, "#") && !strings.HasPrefix(channel, "&") || strings.ContainsAny(channel, " ,\a\r\n") {
+		return nil, fmt.Errorf("invalid channel %q, must start with # or &", channel)
+	}
+	saslUsername := config.Settings.Get("saslUsername").MustString()
+	saslPassword := decryptFunc(context.Background(), config.SecureSettings, "saslPassword", config.Settings.Get("saslPassword").MustString())
+	if saslUsername != "" && saslPassword == "" {
+		return nil, errors.New("could not find SASL password in settings")
+	}
+
+	return &IRCConfig{
+		NotificationChannelConfig: config,
+		Server:                    server,
+		TLS:                       config.Settings.Get("tls").MustBool(true),
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		Nick:                      nick,
+		Channel:                   channel,
+		ChannelKey:                decryptFunc(context.Background(), config.SecureSettings, "channelKey", config.Settings.Get("channelKey").MustString()),
+		SASLUsername:              saslUsername,
+		SASLPassword:              saslPassword,
+		Message:                   config.Settings.Get("message").MustString(defaultIRCMessage),
+	}, nil
+}
+
+// NewIRCNotifier is the constructor for the IRC notifier.
+func NewIRCNotifier(config *IRCConfig, t *template.Template) *IRCNotifier {
+	return &IRCNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Channel:    config.Channel,
+		ChannelKey: config.ChannelKey,
+		Message:    config.Message,
+		options: ircOptions{
+			Server:        config.Server,
+			TLS:           config.TLS,
+			TLSSkipVerify: config.TLSSkipVerify,
+			Nick:          config.Nick,
+			SASLUsername:  config.SASLUsername,
+			SASLPassword:  config.SASLPassword,
+		},
+		log:  log.New("alerting.notifier.irc"),
+		tmpl: t,
+	}
+}
+
+// IRCNotifier is responsible for sending alert notifications to an IRC channel, one line per
+// alert.
+type IRCNotifier struct {
+	*Base
+	Channel    string
+	ChannelKey string
+	Message    string
+	options    ircOptions
+	log        log.Logger
+	tmpl       *template.Template
+}
+
+// Notify sends a line per alert over the connection to the server, which is kept open between
+// notifications. A connection that fails is replaced once before giving up.
+func (in *IRCNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	in.log.Debug("sending IRC notification", "notification", in.Name)
+
+	lines := in.buildLines(ctx, as)
+
+	if recordDryRun(ctx, in.options.Server+"/"+in.Channel, strings.Join(lines, "\n")) {
+		return true, nil
+	}
+
+	err := ircConnections.do(ctx, in.options, in.connect, func(conn pooledConn) error {
+		client := conn.(ircClient)
+		if err := client.Join(ctx, in.Channel, in.ChannelKey); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := client.Privmsg(ctx, in.Channel, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		in.log.Error("failed to send IRC notification", "err", err, "notification", in.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// buildLines renders the message of each alert on a single line. Alerts beyond the maximum
+// number of lines are replaced by a pagination footer.
+func (in *IRCNotifier) buildLines(ctx context.Context, as []*types.Alert) []string {
+	shown := as
+	if len(shown) > ircMaxLines {
+		shown = shown[:ircMaxLines]
+	}
+
+	var tmplErr error
+	lines := make([]string, 0, len(shown)+1)
+	for _, a := range shown {
+		tmpl, _ := TmplText(ctx, in.tmpl, []*types.Alert{a}, in.log, &tmplErr)
+		line := strings.Join(strings.Fields(tmpl(in.Message)), " ")
+		if line == "" {
+			continue
+		}
+		line, _ = in.TruncateMessage(line)
+		lines = append(lines, line)
+	}
+	if tmplErr != nil {
+		in.log.Warn("failed to template IRC message", "err", tmplErr.Error())
+	}
+
+	if len(shown) < len(as) {
+		groupLabels, _ := notify.GroupLabels(ctx)
+		lines = append(lines, PaginationFooter(len(shown), len(as), in.AlertListURL(in.tmpl.ExternalURL, groupLabels)))
+	}
+	return lines
+}
+
+func (in *IRCNotifier) connect(ctx context.Context) (pooledConn, error) {
+	return ircConnect(ctx, in.options)
+}
+
+func (in *IRCNotifier) SendResolved() bool {
+	return !in.GetDisableResolveMessage()
+}
@@ -0,0 +1,314 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ircTimeout         = 10 * time.Second
+	ircIdleTimeout     = 5 * time.Minute
+	ircMaxNickAttempts = 3
+)
+
+// ircOptions are the connection settings of an IRC client. They are comparable so that
+// notifiers with the same server and nick share a connection.
+type ircOptions struct {
+	Server        string
+	TLS           bool
+	TLSSkipVerify bool
+	Nick          string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// ircClient is a connection to an IRC server used by the IRC notifier.
+type ircClient interface {
+	pooledConn
+	Join(ctx context.Context, channel, key string) error
+	Privmsg(ctx context.Context, target, text string) error
+}
+
+// ircConnect connects and registers to the server. Can be overwritten in tests.
+var ircConnect = func(ctx context.Context, opts ircOptions) (ircClient, error) {
+	return dialIRC(ctx, opts)
+}
+
+// ircConnections are shared by all the IRC notifiers.
+var ircConnections = newConnPool(ircIdleTimeout)
+
+// ircMessage is a message received from the server.
+type ircMessage struct {
+	Command string
+	Params  []string
+}
+
+func (m ircMessage) param(i int) string {
+	if i < len(m.Params) {
+		return m.Params[i]
+	}
+	return ""
+}
+
+// parseIRCMessage parses a line sent by the server, ignoring tags and the prefix.
+func parseIRCMessage(line string) ircMessage {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "@") {
+		if i := strings.Index(line, " "); i >= 0 {
+			line = strings.TrimLeft(line[i+1:], " ")
+		}
+	}
+	if strings.HasPrefix(line, ":") {
+		if i := strings.Index(line, " "); i >= 0 {
+			line = strings.TrimLeft(line[i+1:], " ")
+		} else {
+			line = ""
+		}
+	}
+
+	var m ircMessage
+	for line != "" {
+		if strings.HasPrefix(line, ":") {
+			m.Params = append(m.Params, line[1:])
+			break
+		}
+		var field string
+		if i := strings.Index(line, " "); i >= 0 {
+			field, line = line[:i], strings.TrimLeft(line[i+1:], " ")
+		} else {
+			field, line = line, ""
+		}
+		if m.Command == "" {
+			m.Command = strings.ToUpper(field)
+		} else {
+			m.Params = append(m.Params, field)
+		}
+	}
+	return m
+}
+
+// ircConn is a minimal IRC client: it registers, optionally authenticating with SASL PLAIN, joins
+// channels and sends messages. It answers the pings of the server to keep the connection open.
+type ircConn struct {
+	mtx      sync.Mutex
+	conn     net.Conn
+	r        *bufio.Reader
+	channels map[string]struct{}
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func dialIRC(ctx context.Context, opts ircOptions) (*ircConn, error) {
+	addr := opts.Server
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := "6667"
+		if opts.TLS {
+			port = "6697"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ircTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLS {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConfig, err := brokerTLSConfig(opts.TLSSkipVerify, "", "", "")
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		tlsConfig.ServerName = host
+		conn = tls.Client(conn, tlsConfig)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c := &ircConn{conn: conn, r: bufio.NewReader(conn), channels: make(map[string]struct{}), closed: make(chan struct{})}
+	if err := c.register(opts); err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+
+	go c.read()
+	return c, nil
+}
+
+func (c *ircConn) register(opts ircOptions) error {
+	sasl := opts.SASLUsername != ""
+	if sasl {
+		if err := c.writeLine("CAP REQ :sasl"); err != nil {
+			return err
+		}
+	}
+	nick := opts.Nick
+	if err := c.writeLine("NICK " + nick); err != nil {
+		return err
+	}
+	if err := c.writeLine("USER " + nick + " 0 * :Grafana"); err != nil {
+		return err
+	}
+
+	attempts := 1
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		m := parseIRCMessage(line)
+		switch m.Command {
+		case "001":
+			return nil
+		case "PING":
+			err = c.writeLine("PONG :" + m.param(0))
+		case "CAP":
+			switch {
+			case !sasl:
+			case m.param(1) == "ACK":
+				err = c.writeLine("AUTHENTICATE PLAIN")
+			case m.param(1) == "NAK":
+				return errors.New("the IRC server does not support SASL")
+			}
+		case "AUTHENTICATE":
+			if m.param(0) == "+" {
+				creds := base64.StdEncoding.EncodeToString([]byte(opts.SASLUsername + "\x00" + opts.SASLUsername + "\x00" + opts.SASLPassword))
+				err = c.writeLine("AUTHENTICATE " + creds)
+			}
+		case "903":
+			err = c.writeLine("CAP END")
+		case "902", "904", "905", "906":
+			return fmt.Errorf("IRC SASL authentication failed: %s", m.param(len(m.Params)-1))
+		case "432":
+			return fmt.Errorf("invalid IRC nick %q", nick)
+		case "433", "436":
+			if attempts >= ircMaxNickAttempts {
+				return fmt.Errorf("the IRC nick %q is already in use", opts.Nick)
+			}
+			attempts++
+			nick += "_"
+			err = c.writeLine("NICK " + nick)
+		case "464", "465":
+			return fmt.Errorf("the IRC server refused the connection: %s", m.param(len(m.Params)-1))
+		case "ERROR":
+			return fmt.Errorf("IRC error: %s", m.param(0))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read answers the pings of the server and discards the other messages until the connection is
+// closed.
+func (c *ircConn) read() {
+	defer c.Close()
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		m := parseIRCMessage(line)
+		switch m.Command {
+		case "PING":
+			c.mtx.Lock()
+			err = c.write(context.Background(), "PONG :"+m.param(0))
+			c.mtx.Unlock()
+			if err != nil {
+				return
+			}
+		case "ERROR":
+			return
+		case "KICK":
+			c.mtx.Lock()
+			delete(c.channels, strings.ToLower(m.param(0)))
+			c.mtx.Unlock()
+		}
+	}
+}
+
+// Join joins the channel, unless the connection is already in the channel.
+func (c *ircConn) Join(ctx context.Context, channel, key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	name := strings.ToLower(channel)
+	if _, ok := c.channels[name]; ok {
+		return nil
+	}
+	cmd := "JOIN " + channel
+	if key != "" {
+		cmd += " " + key
+	}
+	if err := c.write(ctx, cmd); err != nil {
+		return err
+	}
+	c.channels[name] = struct{}{}
+	return nil
+}
+
+func (c *ircConn) Privmsg(ctx context.Context, target, text string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.write(ctx, "PRIVMSG "+target+" :"+text)
+}
+
+func (c *ircConn) write(ctx context.Context, line string) error {
+	if c.Closed() {
+		return errors.New("the IRC connection is closed")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(ircTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return c.writeLine(line)
+}
+
+// writeLine writes a line, stripping line breaks so that it cannot inject other commands.
+func (c *ircConn) writeLine(line string) error {
+	line = strings.NewReplacer("\r", "", "\n", " ").Replace(line)
+	_, err := io.WriteString(c.conn, line+"\r\n")
+	return err
+}
+
+func (c *ircConn) Closed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *ircConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.closed)
+		_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_ = c.writeLine("QUIT :Grafana")
+		err = c.conn.Close()
+	})
+	return err
+}
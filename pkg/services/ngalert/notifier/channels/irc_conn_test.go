@@ -0,0 +1,150 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIRCServer accepts a single client without TLS. The nick "taken" is in use and SASL
+// authentication succeeds with the password. After registration it pings the client and sends
+// the lines it receives to the returned channel.
+func fakeIRCServer(t *testing.T, password string) (string, <-chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	lines := make(chan string, 20)
+	go func() {
+		defer close(lines)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		send := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+		var nick, user string
+		capNegotiation, pinged := false, false
+		for nick == "" || user == "" || capNegotiation || pinged {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			m := parseIRCMessage(line)
+			switch m.Command {
+			case "CAP":
+				if m.param(0) == "END" {
+					capNegotiation = false
+					continue
+				}
+				capNegotiation = true
+				send(":irc.example.com CAP * ACK :sasl")
+			case "AUTHENTICATE":
+				if m.param(0) == "PLAIN" {
+					send("AUTHENTICATE +")
+					continue
+				}
+				creds, _ := base64.StdEncoding.DecodeString(m.param(0))
+				if string(creds) != "grafana\x00grafana\x00"+password {
+					send(":irc.example.com 904 * :SASL authentication failed")
+					return
+				}
+				send(":irc.example.com 903 * :SASL authentication successful")
+			case "NICK":
+				if m.param(0) == "taken" {
+					send(":irc.example.com 433 * taken :Nickname is already in use")
+					continue
+				}
+				nick = m.param(0)
+			case "USER":
+				user = m.param(0)
+				send("PING :registration")
+				pinged = true
+			case "PONG":
+				pinged = m.param(0) != "registration"
+			}
+		}
+		send(":irc.example.com 001 " + nick + " :Welcome")
+		lines <- "NICK " + nick
+
+		send("PING :keepalive")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines <- line
+			if strings.HasSuffix(line, ":close") {
+				send("ERROR :Closing link")
+				return
+			}
+		}
+	}()
+
+	return l.Addr().String(), lines
+}
+
+func TestIRCConn(t *testing.T) {
+	t.Run("joins channels and sends messages", func(t *testing.T) {
+		addr, lines := fakeIRCServer(t, "")
+		c, err := dialIRC(context.Background(), ircOptions{Server: addr, Nick: "grafana"})
+		require.NoError(t, err)
+		defer func() { _ = c.Close() }()
+
+		require.Equal(t, "NICK grafana", <-lines)
+		require.Equal(t, "PONG :keepalive", <-lines)
+
+		// Channels are joined once per connection.
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.Join(context.Background(), "#alerts", "key"))
+		}
+		require.NoError(t, c.Privmsg(context.Background(), "#alerts", "[FIRING] alert1\r\nQUIT"))
+		require.Equal(t, "JOIN #alerts key", <-lines)
+		require.Equal(t, "PRIVMSG #alerts :[FIRING] alert1 QUIT", <-lines)
+	})
+
+	t.Run("authenticates with SASL and picks another nick when it is taken", func(t *testing.T) {
+		addr, lines := fakeIRCServer(t, "secret")
+		c, err := dialIRC(context.Background(), ircOptions{Server: addr, Nick: "taken", SASLUsername: "grafana", SASLPassword: "secret"})
+		require.NoError(t, err)
+		defer func() { _ = c.Close() }()
+
+		require.Equal(t, "NICK taken_", <-lines)
+	})
+
+	t.Run("the connection is closed when the server closes it", func(t *testing.T) {
+		addr, lines := fakeIRCServer(t, "")
+		c, err := dialIRC(context.Background(), ircOptions{Server: addr, Nick: "grafana"})
+		require.NoError(t, err)
+
+		require.NoError(t, c.Privmsg(context.Background(), "#alerts", "close"))
+		for range lines {
+		}
+		require.Eventually(t, c.Closed, time.Second, 10*time.Millisecond)
+		require.Error(t, c.Privmsg(context.Background(), "#alerts", "firing"))
+	})
+
+	t.Run("error when SASL authentication fails", func(t *testing.T) {
+		addr, _ := fakeIRCServer(t, "secret")
+		_, err := dialIRC(context.Background(), ircOptions{Server: addr, Nick: "grafana", SASLUsername: "grafana", SASLPassword: "wrong"})
+		require.EqualError(t, err, "IRC SASL authentication failed: SASL authentication failed")
+	})
+}
+
+func TestParseIRCMessage(t *testing.T) {
+	require.Equal(t, ircMessage{Command: "PING", Params: []string{"irc.example.com"}}, parseIRCMessage("PING :irc.example.com\r\n"))
+	require.Equal(t, ircMessage{Command: "433", Params: []string{"*", "grafana", "Nickname is already in use"}},
+		parseIRCMessage(":irc.example.com 433 * grafana :Nickname is already in use\r\n"))
+	require.Equal(t, ircMessage{Command: "PRIVMSG", Params: []string{"#alerts", "hi"}}, parseIRCMessage("@time=2022-01-01T00:00:00Z :nick!user@host privmsg #alerts :hi"))
+}
@@ -0,0 +1,248 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeIRCClient struct {
+	joined []string
+	lines  []string
+	err    error
+	closed bool
+}
+
+func (c *fakeIRCClient) Join(_ context.Context, channel, key string) error {
+	c.joined = append(c.joined, channel+" "+key)
+	return c.err
+}
+
+func (c *fakeIRCClient) Privmsg(_ context.Context, target, text string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.lines = append(c.lines, target+" "+text)
+	return nil
+}
+
+func (c *fakeIRCClient) Closed() bool {
+	return c.closed
+}
+
+func (c *fakeIRCClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestIRCNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}, {
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2"},
+		},
+	}}
+
+	manyAlerts := make([]*types.Alert, 0, 12)
+	for i := 0; i < 12; i++ {
+		manyAlerts = append(manyAlerts, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))}}})
+	}
+	manyLines := make([]string, 0, 11)
+	for i := 0; i < 10; i++ {
+		manyLines = append(manyLines, fmt.Sprintf("#alerts [FIRING] alert%d", i))
+	}
+	manyLines = append(manyLines, `#alerts Showing 10 of 12 alerts — view all: http://localhost/alerting/list?queryString=alertname%3D%22test%22`)
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		alerts       []*types.Alert
+		expOptions   ircOptions
+		expJoined    []string
+		expLines     []string
+		expInitError string
+	}{
+		{
+			name: "A line per alert",
+			settings: map[string]interface{}{
+				"server":  "irc.example.com",
+				"channel": "#alerts",
+			},
+			alerts:     alerts,
+			expOptions: ircOptions{Server: "irc.example.com", TLS: true, Nick: "grafana"},
+			expJoined:  []string{"#alerts "},
+			expLines:   []string{"#alerts [FIRING] alert1: CPU is high", "#alerts [FIRING] alert2"},
+		}, {
+			name: "Custom message, SASL and channel key",
+			settings: map[string]interface{}{
+				"server":       "irc.example.com:6667",
+				"tls":          false,
+				"nick":         "alerts",
+				"channel":      "#ops",
+				"channelKey":   "key",
+				"saslUsername": "grafana",
+				"saslPassword": "secret",
+				"message":      "{{ .Status }}\n{{ .CommonLabels.alertname }} {{ .CommonLabels.lbl1 }}",
+			},
+			alerts:     alerts[:1],
+			expOptions: ircOptions{Server: "irc.example.com:6667", Nick: "alerts", SASLUsername: "grafana", SASLPassword: "secret"},
+			expJoined:  []string{"#ops key"},
+			expLines:   []string{"#ops firing alert1 val1"},
+		}, {
+			name: "Alerts beyond the maximum number of lines are summarized",
+			settings: map[string]interface{}{
+				"server":  "irc.example.com",
+				"channel": "#alerts",
+			},
+			alerts:     manyAlerts,
+			expOptions: ircOptions{Server: "irc.example.com", TLS: true, Nick: "grafana"},
+			expJoined:  []string{"#alerts "},
+			expLines:   manyLines,
+		}, {
+			name:         "Error when the server is missing",
+			settings:     map[string]interface{}{"channel": "#alerts"},
+			expInitError: "could not find server in settings",
+		}, {
+			name:         "Error when the channel is missing",
+			settings:     map[string]interface{}{"server": "irc.example.com"},
+			expInitError: "could not find channel in settings",
+		}, {
+			name:         "Error with an invalid channel",
+			settings:     map[string]interface{}{"server": "irc.example.com", "channel": "alerts"},
+			expInitError: `invalid channel "alerts", must start with # or &`,
+		}, {
+			name:         "Error with an invalid nick",
+			settings:     map[string]interface{}{"server": "irc.example.com", "channel": "#alerts", "nick": "grafana bot"},
+			expInitError: `invalid nick "grafana bot"`,
+		}, {
+			name:         "Error when the SASL password is missing",
+			settings:     map[string]interface{}{"server": "irc.example.com", "channel": "#alerts", "saslUsername": "grafana"},
+			expInitError: "could not find SASL password in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "irc_testing",
+				Type:     "irc",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewIRCConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			client := &fakeIRCClient{}
+			var options ircOptions
+			restore := setIRCConnect(func(_ context.Context, opts ircOptions) (ircClient, error) {
+				options = opts
+				return client, nil
+			})
+			defer restore()
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "test"})
+			n := NewIRCNotifier(cfg, tmpl)
+
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expOptions, options)
+			require.Equal(t, c.expJoined, client.joined)
+			require.Equal(t, c.expLines, client.lines)
+		})
+	}
+}
+
+func TestIRCNotifier_Connections(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settings := simplejson.NewFromAny(map[string]interface{}{
+		"server":  "irc.example.com",
+		"channel": "#alerts",
+	})
+	cfg, err := NewIRCConfig(&NotificationChannelConfig{Name: "irc_testing", Type: "irc", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+		return fallback
+	})
+	require.NoError(t, err)
+
+	var clients []*fakeIRCClient
+	restore := setIRCConnect(func(_ context.Context, _ ircOptions) (ircClient, error) {
+		c := &fakeIRCClient{}
+		clients = append(clients, c)
+		return c, nil
+	})
+	defer restore()
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	// The connection is reused by the notifiers with the same settings.
+	for i := 0; i < 2; i++ {
+		_, err := NewIRCNotifier(cfg, tmpl).Notify(ctx, alerts...)
+		require.NoError(t, err)
+	}
+	require.Len(t, clients, 1)
+	require.Len(t, clients[0].lines, 2)
+
+	// A broken connection is replaced.
+	clients[0].err = errors.New("broken pipe")
+	_, err = NewIRCNotifier(cfg, tmpl).Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, clients[0].closed)
+	require.Len(t, clients, 2)
+	require.Len(t, clients[1].lines, 1)
+
+	// Errors of a new connection are returned.
+	connectErr := errors.New("connection refused")
+	restoreErr := setIRCConnect(func(_ context.Context, _ ircOptions) (ircClient, error) {
+		return nil, connectErr
+	})
+	defer restoreErr()
+	ok, err := NewIRCNotifier(cfg, tmpl).Notify(ctx, alerts...)
+	require.ErrorIs(t, err, connectErr)
+	require.False(t, ok)
+}
+
+// setIRCConnect replaces the connect function and the connections for the duration of a test.
+func setIRCConnect(connect func(context.Context, ircOptions) (ircClient, error)) func() {
+	origConnect, origConnections := ircConnect, ircConnections
+	ircConnect = connect
+	ircConnections = newConnPool(ircIdleTimeout)
+	return func() {
+		ircConnect, ircConnections = origConnect, origConnections
+	}
+}
@@ -0,0 +1,362 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultJiraIssueType     = "Task"
+	defaultJiraSeverityLabel = "severity"
+	defaultJiraSummary       = `{{ .CommonLabels.alertname }}{{ with .CommonAnnotations.summary }}: {{ . }}{{ end }}`
+	defaultJiraResolve       = "The alert is resolved."
+
+	// jiraAlertLabelPrefix is the prefix of the label that links an issue to the fingerprint of
+	// its alert, so that an alert only ever has one open issue.
+	jiraAlertLabelPrefix = "grafana-alert-"
+
+	jiraMaxSummaryLength     = 255
+	jiraMaxDescriptionLength = 32767
+)
+
+// jiraPriorities maps common values of the severity label to the priorities of the default
+// Jira priority scheme.
+var jiraPriorities = map[string]string{
+	"critical": "Highest",
+	"urgent":   "Highest",
+	"page":     "Highest",
+	"high":     "High",
+	"error":    "High",
+	"major":    "High",
+	"warning":  "Medium",
+	"medium":   "Medium",
+	"low":      "Low",
+	"minor":    "Low",
+	"info":     "Lowest",
+	"none":     "Lowest",
+	"debug":    "Lowest",
+}
+
+type JiraConfig struct {
+	*NotificationChannelConfig
+	URL               string
+	User              string
+	APIToken          string
+	Project           string
+	IssueType         string
+	Labels            []string
+	SeverityLabel     string
+	Priorities        map[string]string
+	Summary           string
+	Description       string
+	ResolveComment    string
+	ResolveTransition string
+}
+
+func JiraFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewJiraConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewJiraNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewJiraConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*JiraConfig, error) {
+	jiraURL := strings.TrimSuffix(config.Settings.Get("url").MustString(), "/")
+	if jiraURL == "" {
+		return nil, errors.New("could not find url in settings")
+	}
+	if _, err := url.Parse(jiraURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	apiToken := decryptFunc(context.Background(), config.SecureSettings, "apiToken", config.Settings.Get("apiToken").MustString())
+	if apiToken == "" {
+		return nil, errors.New("could not find API token in settings")
+	}
+	project := config.Settings.Get("project").MustString()
+	if project == "" {
+		return nil, errors.New("could not find project in settings")
+	}
+
+	var labels []string
+	for _, l := range strings.Split(config.Settings.Get("labels").MustString(), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			if strings.ContainsAny(l, " \t") {
+				return nil, fmt.Errorf("invalid label %q, labels cannot contain spaces", l)
+			}
+			labels = append(labels, l)
+		}
+	}
+
+	priorities, err := severityMapFromSettings(config.Settings.Get("priorities"), "priority", jiraPriorities)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JiraConfig{
+		NotificationChannelConfig: config,
+		URL:                       jiraURL,
+		User:                      config.Settings.Get("user").MustString(),
+		APIToken:                  apiToken,
+		Project:                   project,
+		IssueType:                 config.Settings.Get("issueType").MustString(defaultJiraIssueType),
+		Labels:                    labels,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultJiraSeverityLabel),
+		Priorities:                priorities,
+		Summary:                   config.Settings.Get("summary").MustString(defaultJiraSummary),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultJiraResolve),
+		ResolveTransition:         config.Settings.Get("resolveTransition").MustString(),
+	}, nil
+}
+
+// NewJiraNotifier is the constructor for the Jira notifier.
+func NewJiraNotifier(config *JiraConfig, ns notifications.WebhookSender, t *template.Template) *JiraNotifier {
+	return &JiraNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:               config.URL,
+		User:              config.User,
+		APIToken:          config.APIToken,
+		Project:           config.Project,
+		IssueType:         config.IssueType,
+		Labels:            config.Labels,
+		SeverityLabel:     config.SeverityLabel,
+		Priorities:        config.Priorities,
+		Summary:           config.Summary,
+		Description:       config.Description,
+		ResolveComment:    config.ResolveComment,
+		ResolveTransition: config.ResolveTransition,
+		log:               log.New("alerting.notifier.jira"),
+		ns:                ns,
+		tmpl:              t,
+	}
+}
+
+// JiraNotifier is responsible for creating a Jira issue per firing alert, and for commenting on
+// and optionally transitioning the issue when the alert is resolved.
+type JiraNotifier struct {
+	*Base
+	URL               string
+	User              string
+	APIToken          string
+	Project           string
+	IssueType         string
+	Labels            []string
+	SeverityLabel     string
+	Priorities        map[string]string
+	Summary           string
+	Description       string
+	ResolveComment    string
+	ResolveTransition string
+	log               log.Logger
+	ns                notifications.WebhookSender
+	tmpl              *template.Template
+}
+
+type jiraIssue struct {
+	Key string `json:"key"`
+}
+
+type jiraSearchResult struct {
+	Issues []jiraIssue `json:"issues"`
+}
+
+type jiraTransitions struct {
+	Transitions []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"transitions"`
+}
+
+type jiraErrorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// Notify creates an issue for each firing alert that does not have an open issue yet, and
+// resolves the open issue of each resolved alert. Issues are found by the label with the
+// fingerprint of the alert, so notifications that are retried or repeated do not create
+// duplicates.
+func (jn *JiraNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	jn.log.Debug("sending Jira notification", "notification", jn.Name)
+
+	for _, a := range as {
+		label := jiraAlertLabelPrefix + a.Fingerprint().String()
+		issue, err := jn.findOpenIssue(ctx, label)
+		if err != nil {
+			jn.log.Error("failed to search Jira issues", "err", err, "notification", jn.Name)
+			return false, err
+		}
+
+		switch {
+		case !a.Resolved() && issue == nil:
+			err = jn.createIssue(ctx, a, label)
+		case a.Resolved() && issue != nil:
+			err = jn.resolveIssue(ctx, a, issue.Key)
+		}
+		if err != nil {
+			jn.log.Error("failed to send Jira notification", "err", err, "notification", jn.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (jn *JiraNotifier) findOpenIssue(ctx context.Context, label string) (*jiraIssue, error) {
+	search := map[string]interface{}{
+		"jql":        fmt.Sprintf("project = %q AND labels = %q AND statusCategory != Done ORDER BY created DESC", jn.Project, label),
+		"fields":     []string{"status"},
+		"maxResults": 1,
+	}
+	var result jiraSearchResult
+	if err := jn.request(ctx, "POST", "/rest/api/2/search", search, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Issues) == 0 {
+		return nil, nil
+	}
+	return &result.Issues[0], nil
+}
+
+func (jn *JiraNotifier) createIssue(ctx context.Context, a *types.Alert, label string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, jn.tmpl, []*types.Alert{a}, jn.log, &tmplErr)
+
+	summary, _ := jn.Truncate(strings.Join(strings.Fields(tmpl(jn.Summary)), " "), jiraMaxSummaryLength)
+	description, _ := jn.Truncate(tmpl(jn.Description), jiraMaxDescriptionLength)
+	labels := append([]string{label}, jn.Labels...)
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": jn.Project},
+		"issuetype":   map[string]string{"name": jn.IssueType},
+		"summary":     summary,
+		"description": description,
+		"labels":      labels,
+	}
+	severity := strings.ToLower(string(a.Labels[model.LabelName(jn.SeverityLabel)]))
+	if priority, ok := jn.Priorities[severity]; ok {
+		fields["priority"] = map[string]string{"name": priority}
+	}
+
+	if tmplErr != nil {
+		jn.log.Warn("failed to template Jira issue", "err", tmplErr.Error())
+	}
+
+	var issue jiraIssue
+	if err := jn.request(ctx, "POST", "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &issue); err != nil {
+		return err
+	}
+	jn.log.Debug("created Jira issue", "issue", issue.Key, "alert", a.Fingerprint().String())
+	return nil
+}
+
+func (jn *JiraNotifier) resolveIssue(ctx context.Context, a *types.Alert, key string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, jn.tmpl, []*types.Alert{a}, jn.log, &tmplErr)
+	comment, _ := jn.Truncate(tmpl(jn.ResolveComment), jiraMaxDescriptionLength)
+	if tmplErr != nil {
+		jn.log.Warn("failed to template Jira comment", "err", tmplErr.Error())
+	}
+
+	if comment != "" {
+		if err := jn.request(ctx, "POST", "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": comment}, nil); err != nil {
+			return err
+		}
+	}
+	if jn.ResolveTransition == "" {
+		return nil
+	}
+
+	var transitions jiraTransitions
+	if err := jn.request(ctx, "GET", "/rest/api/2/issue/"+url.PathEscape(key)+"/transitions", nil, &transitions); err != nil {
+		return err
+	}
+	for _, t := range transitions.Transitions {
+		if strings.EqualFold(t.Name, jn.ResolveTransition) {
+			return jn.request(ctx, "POST", "/rest/api/2/issue/"+url.PathEscape(key)+"/transitions",
+				map[string]interface{}{"transition": map[string]string{"id": t.ID}}, nil)
+		}
+	}
+	return fmt.Errorf("could not find transition %q of issue %s", jn.ResolveTransition, key)
+}
+
+// request sends a request to the Jira REST API and decodes the response into out, if not nil.
+// Jira Cloud authenticates with the email of the user and an API token, Jira Server and Data
+// Center with a personal access token.
+func (jn *JiraNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        jn.URL + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Accept":       "application/json",
+			"Content-Type": "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return jiraError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if jn.User != "" {
+		cmd.User = jn.User
+		cmd.Password = jn.APIToken
+	} else {
+		cmd.HttpHeader["Authorization"] = "Bearer " + jn.APIToken
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return jn.ns.SendWebhookSync(ctx, cmd)
+}
+
+func jiraError(body []byte, statusCode int) error {
+	var resp jiraErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.ErrorMessages)+len(resp.Errors) == 0 {
+		return fmt.Errorf("the Jira API returned status %d", statusCode)
+	}
+	msgs := append([]string{}, resp.ErrorMessages...)
+	fields := make([]string, 0, len(resp.Errors))
+	for field := range resp.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		msgs = append(msgs, field+": "+resp.Errors[field])
+	}
+	return fmt.Errorf("the Jira API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+}
+
+func (jn *JiraNotifier) SendResolved() bool {
+	return !jn.GetDisableResolveMessage()
+}
@@ -0,0 +1,269 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeJiraIssue struct {
+	Key      string
+	Fields   map[string]interface{}
+	Comments []string
+	Status   string
+}
+
+// fakeJira implements the parts of the Jira REST API used by the notifier.
+type fakeJira struct {
+	issues   []*fakeJiraIssue
+	requests []*models.SendWebhookSync
+}
+
+var jiraLabelQuery = regexp.MustCompile(`labels = "([^"]+)"`)
+
+func (j *fakeJira) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	j.requests = append(j.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in map[string]interface{}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case u.Path == "/rest/api/2/search":
+		label := jiraLabelQuery.FindStringSubmatch(in["jql"].(string))[1]
+		issues := []map[string]string{}
+		for _, issue := range j.issues {
+			if issue.Status == "Done" {
+				continue
+			}
+			for _, l := range issue.Fields["labels"].([]interface{}) {
+				if l == label {
+					issues = append(issues, map[string]string{"key": issue.Key})
+				}
+			}
+		}
+		return respond(200, map[string]interface{}{"issues": issues})
+	case u.Path == "/rest/api/2/issue":
+		fields := in["fields"].(map[string]interface{})
+		if fields["issuetype"].(map[string]interface{})["name"] == "Epic" {
+			return respond(400, map[string]interface{}{"errors": map[string]string{"issuetype": "Specify a valid issue type"}})
+		}
+		issue := &fakeJiraIssue{Key: fmt.Sprintf("OPS-%d", len(j.issues)+1), Fields: fields, Status: "To Do"}
+		j.issues = append(j.issues, issue)
+		return respond(201, map[string]string{"key": issue.Key})
+	case strings.HasSuffix(u.Path, "/comment"):
+		issue := j.issue(u.Path)
+		issue.Comments = append(issue.Comments, in["body"].(string))
+		return respond(201, map[string]string{})
+	case strings.HasSuffix(u.Path, "/transitions") && cmd.HttpMethod == "GET":
+		return respond(200, map[string]interface{}{"transitions": []map[string]string{{"id": "11", "name": "In Progress"}, {"id": "31", "name": "Done"}}})
+	case strings.HasSuffix(u.Path, "/transitions"):
+		if in["transition"].(map[string]interface{})["id"] == "31" {
+			j.issue(u.Path).Status = "Done"
+		}
+		return respond(204, nil)
+	}
+	return respond(404, map[string]interface{}{"errorMessages": []string{"not found"}})
+}
+
+func (j *fakeJira) issue(path string) *fakeJiraIssue {
+	key := strings.Split(path, "/")[5]
+	for _, issue := range j.issues {
+		if issue.Key == key {
+			return issue
+		}
+	}
+	return nil
+}
+
+func TestJiraNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "severity": "p2"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	t.Run("one issue per alert, commented and transitioned when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":               "https://example.atlassian.net/",
+			"user":              "grafana@example.com",
+			"apiToken":          "token",
+			"project":           "OPS",
+			"labels":            "grafana, monitoring",
+			"priorities":        "p2=High",
+			"resolveTransition": "done",
+		})
+		cfg, err := NewJiraConfig(&NotificationChannelConfig{Name: "jira_testing", Type: "jira", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		jira := &fakeJira{}
+		n := NewJiraNotifier(cfg, jira, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not create duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, jira.issues, 2)
+
+		issue := jira.issues[0]
+		require.Equal(t, "alert1: CPU is high", issue.Fields["summary"])
+		require.Equal(t, map[string]interface{}{"key": "OPS"}, issue.Fields["project"])
+		require.Equal(t, map[string]interface{}{"name": "Task"}, issue.Fields["issuetype"])
+		require.Equal(t, map[string]interface{}{"name": "Highest"}, issue.Fields["priority"])
+		require.Equal(t, []interface{}{"grafana-alert-" + firing.Fingerprint().String(), "grafana", "monitoring"}, issue.Fields["labels"])
+		require.Contains(t, issue.Fields["description"], "CPU is high")
+		require.Equal(t, map[string]interface{}{"name": "High"}, jira.issues[1].Fields["priority"])
+
+		req := jira.requests[0]
+		require.Equal(t, "https://example.atlassian.net/rest/api/2/search", req.Url)
+		require.Equal(t, "grafana@example.com", req.User)
+		require.Equal(t, "token", req.Password)
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, issue.Comments)
+		require.Equal(t, "Done", issue.Status)
+
+		// The alert fires again after its issue was resolved.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, jira.issues, 3)
+	})
+
+	t.Run("personal access token and errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":       "https://jira.example.com",
+			"apiToken":  "pat",
+			"project":   "OPS",
+			"issueType": "Epic",
+		})
+		cfg, err := NewJiraConfig(&NotificationChannelConfig{Name: "jira_testing", Type: "jira", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		jira := &fakeJira{}
+		ok, err := NewJiraNotifier(cfg, jira, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the Jira API returned status 400: issuetype: Specify a valid issue type")
+		require.False(t, ok)
+		require.Equal(t, "Bearer pat", jira.requests[0].HttpHeader["Authorization"])
+	})
+
+	t.Run("error when the transition does not exist", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":               "https://jira.example.com",
+			"apiToken":          "pat",
+			"project":           "OPS",
+			"resolveTransition": "Closed",
+		})
+		cfg, err := NewJiraConfig(&NotificationChannelConfig{Name: "jira_testing", Type: "jira", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		jira := &fakeJira{}
+		n := NewJiraNotifier(cfg, jira, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.EqualError(t, err, `could not find transition "Closed" of issue OPS-1`)
+	})
+}
+
+func TestNewJiraConfig(t *testing.T) {
+	cases := []struct {
+		name          string
+		settings      map[string]interface{}
+		expPriorities map[string]string
+		expInitError  string
+	}{
+		{
+			name:          "Provisioned priorities",
+			settings:      map[string]interface{}{"url": "https://jira.example.com", "apiToken": "pat", "project": "OPS", "priorities": map[string]interface{}{"Critical": "P1"}},
+			expPriorities: map[string]string{"critical": "P1", "warning": "Medium"},
+		}, {
+			name:         "Error when the url is missing",
+			settings:     map[string]interface{}{"apiToken": "pat", "project": "OPS"},
+			expInitError: "could not find url in settings",
+		}, {
+			name:         "Error when the API token is missing",
+			settings:     map[string]interface{}{"url": "https://jira.example.com", "project": "OPS"},
+			expInitError: "could not find API token in settings",
+		}, {
+			name:         "Error when the project is missing",
+			settings:     map[string]interface{}{"url": "https://jira.example.com", "apiToken": "pat"},
+			expInitError: "could not find project in settings",
+		}, {
+			name:         "Error with an invalid label",
+			settings:     map[string]interface{}{"url": "https://jira.example.com", "apiToken": "pat", "project": "OPS", "labels": "on call"},
+			expInitError: `invalid label "on call", labels cannot contain spaces`,
+		}, {
+			name:         "Error with an invalid priority mapping",
+			settings:     map[string]interface{}{"url": "https://jira.example.com", "apiToken": "pat", "project": "OPS", "priorities": "critical"},
+			expInitError: `invalid priority mapping "critical", must be severity=priority`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "jira_testing", Type: "jira", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewJiraConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			for severity, priority := range c.expPriorities {
+				require.Equal(t, priority, cfg.Priorities[severity])
+			}
+		})
+	}
+}
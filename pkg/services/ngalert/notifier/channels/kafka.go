@@ -100,9 +100,9 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	bodyJSON.Set("alert_state", state)
 	bodyJSON.Set("description", tmpl(DefaultMessageTitleEmbed))
 	bodyJSON.Set("client", "Grafana")
-	bodyJSON.Set("details", tmpl(`{{ template "default.message" . }}`))
+	bodyJSON.Set("details", kn.FieldLimits().TruncateMessage(tmpl(`{{ template "default.message" . }}`)))
 
-	ruleURL := joinUrlPath(kn.tmpl.ExternalURL.String(), "/alerting/list", kn.log)
+	ruleURL := kn.RuleListURL(kn.tmpl.ExternalURL)
 	bodyJSON.Set("client_url", ruleURL)
 
 	var contexts []interface{}
@@ -0,0 +1,552 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	kubernetesModeEvent    = "event"
+	kubernetesModeResource = "resource"
+
+	// The custom resource created in the resource mode, see the documentation of the notifier.
+	kubernetesResourceGroup   = "alerting.grafana.com"
+	kubernetesResourceVersion = "v1alpha1"
+	kubernetesResourceKind    = "AlertNotification"
+	kubernetesResourcePlural  = "alertnotifications"
+
+	kubernetesReportingComponent = "grafana-alerting"
+	// kubernetesMaxMessageLength is the maximum length of the message of an event.
+	kubernetesMaxMessageLength = 1024
+)
+
+// kubernetesServiceAccountDir is where the credentials of the service account of the pod are
+// mounted, a variable so that tests can use their own.
+var kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+var kubernetesInvalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+type KubernetesConfig struct {
+	*NotificationChannelConfig
+	Cluster           *kubernetesCluster
+	Namespace         string
+	UseAlertNamespace bool
+	Mode              string
+	Message           string
+}
+
+func KubernetesFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewKubernetesConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewKubernetesNotifier(cfg, fc.Template), nil
+}
+
+func NewKubernetesConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*KubernetesConfig, error) {
+	var (
+		cluster *kubernetesCluster
+		err     error
+	)
+	if kubeconfig := decryptFunc(context.Background(), config.SecureSettings, "kubeconfig", config.Settings.Get("kubeconfig").MustString()); kubeconfig != "" {
+		cluster, err = parseKubeconfig([]byte(kubeconfig), strings.TrimSpace(config.Settings.Get("context").MustString()))
+	} else {
+		cluster, err = inClusterKubernetesCluster()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mode := config.Settings.Get("mode").MustString(kubernetesModeEvent)
+	if mode != kubernetesModeEvent && mode != kubernetesModeResource {
+		return nil, fmt.Errorf("invalid mode %q, must be %s or %s", mode, kubernetesModeEvent, kubernetesModeResource)
+	}
+
+	namespace := strings.TrimSpace(config.Settings.Get("namespace").MustString(cluster.Namespace))
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &KubernetesConfig{
+		NotificationChannelConfig: config,
+		Cluster:                   cluster,
+		Namespace:                 namespace,
+		UseAlertNamespace:         config.Settings.Get("useAlertNamespace").MustBool(true),
+		Mode:                      mode,
+		Message:                   config.Settings.Get("message").MustString(defaultGitHubTitle),
+	}, nil
+}
+
+// NewKubernetesNotifier is the constructor for the Kubernetes notifier.
+func NewKubernetesNotifier(config *KubernetesConfig, t *template.Template) *KubernetesNotifier {
+	var proxy func(*http.Request) (*url.URL, error)
+	if config.Proxy != nil {
+		proxy = config.Proxy.ProxyFunc()
+	}
+	return &KubernetesNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Cluster:           config.Cluster,
+		Namespace:         config.Namespace,
+		UseAlertNamespace: config.UseAlertNamespace,
+		Mode:              config.Mode,
+		Message:           config.Message,
+		client:            &http.Client{Timeout: 30 * time.Second, Transport: newHTTPTransport(config.Cluster.TLS, proxy)},
+		log:               log.New("alerting.notifier.kubernetes"),
+		tmpl:              t,
+	}
+}
+
+// KubernetesNotifier is responsible for creating a Kubernetes event per alert, attached to the
+// workload of the alert, or for creating and updating an AlertNotification custom resource per
+// alert, so that the alerts are seen by the tooling of the cluster.
+type KubernetesNotifier struct {
+	*Base
+	Cluster           *kubernetesCluster
+	Namespace         string
+	UseAlertNamespace bool
+	Mode              string
+	Message           string
+	client            *http.Client
+	log               log.Logger
+	tmpl              *template.Template
+}
+
+// kubernetesObjectReference is the object an event is about.
+type kubernetesObjectReference struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// kubernetesWorkloadLabels are the labels of the alerts that name the object of the events,
+// in order of precedence, as set by kube-state-metrics and the Kubernetes integrations.
+var kubernetesWorkloadLabels = []struct {
+	label      model.LabelName
+	apiVersion string
+	kind       string
+	namespaced bool
+}{
+	{"pod", "v1", "Pod", true},
+	{"deployment", "apps/v1", "Deployment", true},
+	{"statefulset", "apps/v1", "StatefulSet", true},
+	{"daemonset", "apps/v1", "DaemonSet", true},
+	{"cronjob", "batch/v1", "CronJob", true},
+	{"job_name", "batch/v1", "Job", true},
+	{"persistentvolumeclaim", "v1", "PersistentVolumeClaim", true},
+	{"service", "v1", "Service", true},
+	{"node", "v1", "Node", false},
+}
+
+func (kn *KubernetesNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	kn.log.Debug("sending Kubernetes notification", "notification", kn.Name, "mode", kn.Mode)
+
+	for _, a := range as {
+		var err error
+		if kn.Mode == kubernetesModeResource {
+			err = kn.applyResource(ctx, a)
+		} else {
+			err = kn.createEvent(ctx, a)
+		}
+		if err != nil {
+			kn.log.Error("failed to send Kubernetes notification", "err", err, "notification", kn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// namespace returns the namespace of the objects of the alert.
+func (kn *KubernetesNotifier) namespace(a *types.Alert) string {
+	if kn.UseAlertNamespace {
+		if ns := string(a.Labels["namespace"]); ns != "" {
+			return ns
+		}
+	}
+	return kn.Namespace
+}
+
+func (kn *KubernetesNotifier) message(ctx context.Context, a *types.Alert) string {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, kn.tmpl, []*types.Alert{a}, kn.log, &tmplErr)
+	msg, _ := kn.Truncate(strings.TrimSpace(tmpl(kn.Message)), kubernetesMaxMessageLength)
+	if tmplErr != nil {
+		kn.log.Warn("failed to template Kubernetes message", "err", tmplErr.Error())
+	}
+	return msg
+}
+
+// createEvent creates a core event about the workload of the alert, or about the alert rule if
+// the alert does not name a workload.
+func (kn *KubernetesNotifier) createEvent(ctx context.Context, a *types.Alert) error {
+	namespace := kn.namespace(a)
+	object := kubernetesInvolvedObject(a, namespace)
+
+	// Like the events of the kubelet, named after their object and the time.
+	name := object.Name
+	if len(name) > 236 {
+		name = name[:236]
+	}
+	name = fmt.Sprintf("%s.%x", name, time.Now().UnixNano())
+
+	reason, eventType, timestamp := "AlertFiring", "Warning", time.Now()
+	if a.Resolved() {
+		reason, eventType, timestamp = "AlertResolved", "Normal", a.EndsAt
+	}
+	event := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]string{"alerting.grafana.com/fingerprint": a.Fingerprint().String()},
+		},
+		"involvedObject":     object,
+		"reason":             reason,
+		"type":               eventType,
+		"message":            kn.message(ctx, a),
+		"source":             map[string]string{"component": kubernetesReportingComponent},
+		"reportingComponent": kubernetesReportingComponent,
+		"firstTimestamp":     a.StartsAt.UTC().Format(time.RFC3339),
+		"lastTimestamp":      timestamp.UTC().Format(time.RFC3339),
+		"count":              1,
+	}
+	return kn.request(ctx, http.MethodPost, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/events", "application/json", event)
+}
+
+func kubernetesInvolvedObject(a *types.Alert, namespace string) kubernetesObjectReference {
+	for _, w := range kubernetesWorkloadLabels {
+		if name := string(a.Labels[w.label]); name != "" {
+			ref := kubernetesObjectReference{APIVersion: w.apiVersion, Kind: w.kind, Name: name}
+			if w.namespaced {
+				ref.Namespace = namespace
+			}
+			return ref
+		}
+	}
+	name := string(a.Labels[ngmodels.RuleUIDLabel])
+	if name == "" {
+		name = string(a.Labels[model.AlertNameLabel])
+	}
+	return kubernetesObjectReference{
+		APIVersion: kubernetesResourceGroup + "/" + kubernetesResourceVersion,
+		Kind:       "AlertRule",
+		Namespace:  namespace,
+		Name:       kubernetesName(name),
+	}
+}
+
+// applyResource creates the AlertNotification resource of the alert, or updates its status if
+// it exists.
+func (kn *KubernetesNotifier) applyResource(ctx context.Context, a *types.Alert) error {
+	namespace := kn.namespace(a)
+	name := "grafana-" + a.Fingerprint().String()
+	labels := make(map[string]string, len(a.Labels))
+	for k, v := range a.Labels {
+		labels[string(k)] = string(v)
+	}
+	annotations := make(map[string]string, len(a.Annotations))
+	for k, v := range a.Annotations {
+		annotations[string(k)] = string(v)
+	}
+	spec := map[string]interface{}{
+		"status":       string(a.Status()),
+		"message":      kn.message(ctx, a),
+		"labels":       labels,
+		"annotations":  annotations,
+		"startsAt":     a.StartsAt.UTC().Format(time.RFC3339),
+		"generatorURL": a.GeneratorURL,
+	}
+	if a.Resolved() {
+		spec["endsAt"] = a.EndsAt.UTC().Format(time.RFC3339)
+	}
+
+	collection := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", kubernetesResourceGroup, kubernetesResourceVersion, url.PathEscape(namespace), kubernetesResourcePlural)
+	err := kn.request(ctx, http.MethodPatch, collection+"/"+name, "application/merge-patch+json", map[string]interface{}{"spec": spec})
+	var statusErr *kubernetesStatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+		return err
+	}
+	resource := map[string]interface{}{
+		"apiVersion": kubernetesResourceGroup + "/" + kubernetesResourceVersion,
+		"kind":       kubernetesResourceKind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}
+	return kn.request(ctx, http.MethodPost, collection, "application/json", resource)
+}
+
+// kubernetesName returns a valid name of a Kubernetes object derived from s.
+func kubernetesName(s string) string {
+	name := strings.Trim(kubernetesInvalidNameChars.ReplaceAllString(strings.ToLower(s), "-"), "-.")
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	if name == "" {
+		return "alert"
+	}
+	return name
+}
+
+// kubernetesStatusError is the Status returned by the Kubernetes API on failure.
+type kubernetesStatusError struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (e *kubernetesStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("the Kubernetes API returned status %d", e.Code)
+	}
+	return fmt.Sprintf("the Kubernetes API returned status %d: %s", e.Code, e.Message)
+}
+
+func (kn *KubernetesNotifier) request(ctx context.Context, method, path, contentType string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if recordDryRun(ctx, urlTarget(kn.Cluster.Server), string(b)) {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, method, kn.Cluster.Server+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+	if err := kn.Cluster.authorize(req); err != nil {
+		return err
+	}
+
+	resp, err := kn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			kn.log.Warn("failed to close response body", "err", err)
+		}
+	}()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	statusErr := &kubernetesStatusError{}
+	if err := json.Unmarshal(respBody, statusErr); err != nil {
+		statusErr.Message = ""
+	}
+	statusErr.Code = resp.StatusCode
+	return statusErr
+}
+
+// kubernetesCluster is the server and the credentials of a Kubernetes cluster.
+type kubernetesCluster struct {
+	Server string
+	// Namespace is the namespace of the context of the kubeconfig, or of the pod in the cluster.
+	Namespace string
+	TLS       *tls.Config
+	Token     string
+	// TokenFile is read for each request, as the tokens of the service accounts are rotated.
+	TokenFile string
+	Username  string
+	Password  string
+}
+
+func (c *kubernetesCluster) authorize(req *http.Request) error {
+	switch {
+	case c.TokenFile != "":
+		token, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the token of the service account: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return nil
+}
+
+// kubeconfig is the part of a kubeconfig file used by the notifier. The certificates and the
+// keys must be embedded, as the files of the paths are not available to Grafana.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string    `yaml:"token"`
+			ClientCertificateData string    `yaml:"client-certificate-data"`
+			ClientKeyData         string    `yaml:"client-key-data"`
+			ClientCertificate     string    `yaml:"client-certificate"`
+			Username              string    `yaml:"username"`
+			Password              string    `yaml:"password"`
+			Exec                  *struct{} `yaml:"exec"`
+			AuthProvider          *struct{} `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// parseKubeconfig returns the cluster of the context of the kubeconfig, its current context if
+// contextName is empty.
+func parseKubeconfig(data []byte, contextName string) (*kubernetesCluster, error) {
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return nil, errors.New("the kubeconfig has no current context, the context is required")
+	}
+
+	cluster := &kubernetesCluster{}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, cluster.Namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("could not find context %q in the kubeconfig", contextName)
+	}
+
+	var caCert, clientCert, clientKey string
+	skipVerify := false
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		if c.Cluster.CertificateAuthority != "" {
+			return nil, errors.New("the certificate authority of the cluster must be embedded in the kubeconfig")
+		}
+		ca, err := base64.StdEncoding.DecodeString(c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate authority of the cluster: %w", err)
+		}
+		cluster.Server, caCert, skipVerify = strings.TrimSuffix(c.Cluster.Server, "/"), string(ca), c.Cluster.InsecureSkipTLSVerify
+		found = true
+		break
+	}
+	if !found || cluster.Server == "" {
+		return nil, fmt.Errorf("could not find the server of cluster %q in the kubeconfig", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil || u.User.AuthProvider != nil || u.User.ClientCertificate != "" {
+			return nil, errors.New("the credentials of the user must be a token, a password or a client certificate embedded in the kubeconfig")
+		}
+		cert, err := base64.StdEncoding.DecodeString(u.User.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate of the user: %w", err)
+		}
+		key, err := base64.StdEncoding.DecodeString(u.User.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client key of the user: %w", err)
+		}
+		clientCert, clientKey = string(cert), string(key)
+		cluster.Token, cluster.Username, cluster.Password = u.User.Token, u.User.Username, u.User.Password
+		break
+	}
+
+	tlsConfig, err := brokerTLSConfig(skipVerify, caCert, clientCert, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	cluster.TLS = tlsConfig
+	return cluster, nil
+}
+
+// inClusterKubernetesCluster returns the cluster Grafana runs in, with the credentials of the
+// service account of its pod.
+func inClusterKubernetesCluster() (*kubernetesCluster, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("could not find kubeconfig in settings, and Grafana does not run in a Kubernetes cluster")
+	}
+	tokenFile := filepath.Join(kubernetesServiceAccountDir, "token")
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, fmt.Errorf("could not find the token of the service account: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(kubernetesServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("could not find the certificate authority of the cluster: %w", err)
+	}
+	tlsConfig, err := brokerTLSConfig(false, string(ca), "", "")
+	if err != nil {
+		return nil, err
+	}
+	namespace, _ := os.ReadFile(filepath.Join(kubernetesServiceAccountDir, "namespace"))
+	return &kubernetesCluster{
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Namespace: strings.TrimSpace(string(namespace)),
+		TLS:       tlsConfig,
+		TokenFile: tokenFile,
+	}, nil
+}
+
+func (kn *KubernetesNotifier) SendResolved() bool {
+	return !kn.GetDisableResolveMessage()
+}
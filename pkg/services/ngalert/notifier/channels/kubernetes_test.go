@@ -0,0 +1,349 @@
+package channels
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type kubernetesRequest struct {
+	Method        string
+	Path          string
+	ContentType   string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+// fakeKubernetes is a Kubernetes API server that records the requests, and stores the
+// AlertNotification resources.
+type fakeKubernetes struct {
+	*httptest.Server
+	mtx       sync.Mutex
+	requests  []kubernetesRequest
+	resources map[string]bool
+}
+
+func newFakeKubernetes(t *testing.T) *fakeKubernetes {
+	f := &fakeKubernetes{resources: map[string]bool{}}
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mtx.Lock()
+		defer f.mtx.Unlock()
+		b, _ := io.ReadAll(r.Body)
+		req := kubernetesRequest{Method: r.Method, Path: r.URL.Path, ContentType: r.Header.Get("Content-Type"), Authorization: r.Header.Get("Authorization")}
+		_ = json.Unmarshal(b, &req.Body)
+		f.requests = append(f.requests, req)
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"kind":"Status","status":"Failure","message":"Unauthorized","reason":"Unauthorized","code":401}`))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/apis/alerting.grafana.com/") {
+			switch r.Method {
+			case http.MethodPatch:
+				if !f.resources[r.URL.Path] {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"kind":"Status","status":"Failure","message":"not found","reason":"NotFound","code":404}`))
+					return
+				}
+			case http.MethodPost:
+				name := req.Body["metadata"].(map[string]interface{})["name"].(string)
+				f.resources[r.URL.Path+"/"+name] = true
+				w.WriteHeader(http.StatusCreated)
+			}
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeKubernetes) kubeconfig(token string) string {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.Certificate().Raw})
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: grafana
+    namespace: monitoring
+users:
+- name: grafana
+  user:
+    token: %s
+`, f.URL, base64.StdEncoding.EncodeToString(ca), token)
+}
+
+func TestKubernetesNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	podAlert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "PodCrashLooping", "namespace": "shop", "pod": "cart-7d9f"},
+			Annotations: model.LabelSet{"summary": "cart is crash looping"},
+			StartsAt:    startsAt,
+		},
+	}
+	ruleAlert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "High Latency", "__alert_rule_uid__": "Rule_1"},
+			StartsAt: startsAt,
+		},
+	}
+
+	t.Run("events about the workloads of the alerts", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{"kubeconfig": server.kubeconfig("token")})
+		cfg, err := NewKubernetesConfig(&NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+		require.Equal(t, "monitoring", cfg.Namespace)
+
+		ok, err := NewKubernetesNotifier(cfg, tmpl).Notify(context.Background(), podAlert, ruleAlert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, server.requests, 2)
+
+		pod := server.requests[0]
+		require.Equal(t, "POST", pod.Method)
+		require.Equal(t, "/api/v1/namespaces/shop/events", pod.Path)
+		require.Equal(t, "Bearer token", pod.Authorization)
+		require.Equal(t, map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "namespace": "shop", "name": "cart-7d9f"}, pod.Body["involvedObject"])
+		require.Equal(t, "AlertFiring", pod.Body["reason"])
+		require.Equal(t, "Warning", pod.Body["type"])
+		require.Equal(t, "PodCrashLooping: cart is crash looping", pod.Body["message"])
+		require.Equal(t, "2022-06-01T10:00:00Z", pod.Body["firstTimestamp"])
+		require.True(t, strings.HasPrefix(pod.Body["metadata"].(map[string]interface{})["name"].(string), "cart-7d9f."))
+
+		rule := server.requests[1]
+		require.Equal(t, "/api/v1/namespaces/monitoring/events", rule.Path)
+		require.Equal(t, map[string]interface{}{"apiVersion": "alerting.grafana.com/v1alpha1", "kind": "AlertRule", "namespace": "monitoring", "name": "rule-1"}, rule.Body["involvedObject"])
+
+		resolved := &types.Alert{Alert: podAlert.Alert}
+		resolved.EndsAt = startsAt.Add(time.Hour)
+		_, err = NewKubernetesNotifier(cfg, tmpl).Notify(context.Background(), resolved)
+		require.NoError(t, err)
+		require.Equal(t, "AlertResolved", server.requests[2].Body["reason"])
+		require.Equal(t, "Normal", server.requests[2].Body["type"])
+		require.Equal(t, "2022-06-01T11:00:00Z", server.requests[2].Body["lastTimestamp"])
+	})
+
+	t.Run("dry runs are recorded instead of sent", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		settings := simplejson.NewFromAny(map[string]interface{}{"kubeconfig": server.kubeconfig("token"), "mode": "resource"})
+		cfg, err := NewKubernetesConfig(&NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		d := &DryRun{}
+		ok, err := NewKubernetesNotifier(cfg, tmpl).Notify(WithDryRun(context.Background(), d), podAlert, ruleAlert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, server.requests)
+		require.Len(t, d.Requests(), 2)
+		require.Equal(t, server.URL, d.Requests()[0].Target)
+		require.Contains(t, d.Requests()[0].Body, `"status":"firing"`)
+	})
+
+	t.Run("requests are sent through the egress proxy of the contact point", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		settings := simplejson.NewFromAny(map[string]interface{}{"kubeconfig": server.kubeconfig("token"), "proxyUrl": "http://egress.example.com:3128"})
+		config := &NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: settings}
+		config.Proxy, err = proxyFromSettings(settings)
+		require.NoError(t, err)
+		cfg, err := NewKubernetesConfig(config, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		transport := NewKubernetesNotifier(cfg, tmpl).client.Transport.(*http.Transport)
+		// Requests to localhost are never proxied.
+		req, err := http.NewRequest(http.MethodPost, "https://kubernetes.example.com:6443", nil)
+		require.NoError(t, err)
+		proxy, err := transport.Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, "http://egress.example.com:3128", proxy.String())
+		require.Equal(t, cfg.Cluster.TLS, transport.TLSClientConfig)
+	})
+
+	t.Run("AlertNotification resources created, then updated", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"kubeconfig":        server.kubeconfig("token"),
+			"mode":              "resource",
+			"namespace":         "alerts",
+			"useAlertNamespace": false,
+		})
+		cfg, err := NewKubernetesConfig(&NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+		n := NewKubernetesNotifier(cfg, tmpl)
+
+		_, err = n.Notify(context.Background(), podAlert)
+		require.NoError(t, err)
+		resolved := &types.Alert{Alert: podAlert.Alert}
+		resolved.EndsAt = startsAt.Add(time.Hour)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		path := "/apis/alerting.grafana.com/v1alpha1/namespaces/alerts/alertnotifications"
+		name := "grafana-" + podAlert.Fingerprint().String()
+		require.Len(t, server.requests, 3)
+		require.Equal(t, "PATCH", server.requests[0].Method)
+		require.Equal(t, path+"/"+name, server.requests[0].Path)
+		require.Equal(t, "application/merge-patch+json", server.requests[0].ContentType)
+
+		created := server.requests[1]
+		require.Equal(t, "POST", created.Method)
+		require.Equal(t, path, created.Path)
+		require.Equal(t, "AlertNotification", created.Body["kind"])
+		spec := created.Body["spec"].(map[string]interface{})
+		require.Equal(t, "firing", spec["status"])
+		require.Equal(t, "cart-7d9f", spec["labels"].(map[string]interface{})["pod"])
+
+		updated := server.requests[2]
+		require.Equal(t, "PATCH", updated.Method)
+		spec = updated.Body["spec"].(map[string]interface{})
+		require.Equal(t, "resolved", spec["status"])
+		require.Equal(t, "2022-06-01T11:00:00Z", spec["endsAt"])
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		settings := simplejson.NewFromAny(map[string]interface{}{"kubeconfig": server.kubeconfig("expired")})
+		cfg, err := NewKubernetesConfig(&NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewKubernetesNotifier(cfg, tmpl).Notify(context.Background(), podAlert)
+		require.EqualError(t, err, "the Kubernetes API returned status 401: Unauthorized")
+		require.False(t, ok)
+	})
+
+	t.Run("in-cluster credentials", func(t *testing.T) {
+		server := newFakeKubernetes(t)
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("token\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("grafana"), 0600))
+		oldDir := kubernetesServiceAccountDir
+		kubernetesServiceAccountDir = dir
+		t.Cleanup(func() { kubernetesServiceAccountDir = oldDir })
+		t.Setenv("KUBERNETES_SERVICE_HOST", u.Hostname())
+		t.Setenv("KUBERNETES_SERVICE_PORT", u.Port())
+
+		cfg, err := NewKubernetesConfig(&NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: simplejson.New()}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+		require.Equal(t, "grafana", cfg.Namespace)
+
+		_, err = NewKubernetesNotifier(cfg, tmpl).Notify(context.Background(), ruleAlert)
+		require.NoError(t, err)
+		require.Equal(t, "/api/v1/namespaces/grafana/events", server.requests[0].Path)
+		require.Equal(t, "Bearer token", server.requests[0].Authorization)
+	})
+}
+
+func TestNewKubernetesConfig(t *testing.T) {
+	kubeconfig := func(user string) string {
+		return `
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://k8s.example.com:6443
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: grafana
+users:
+- name: grafana
+  user:
+` + user
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Kubeconfig with a token",
+			settings: map[string]interface{}{"kubeconfig": kubeconfig("    token: abc")},
+		}, {
+			name:         "Error without kubeconfig outside of a cluster",
+			settings:     map[string]interface{}{},
+			expInitError: "could not find kubeconfig in settings, and Grafana does not run in a Kubernetes cluster",
+		}, {
+			name:         "Error when the context is missing",
+			settings:     map[string]interface{}{"kubeconfig": kubeconfig("    token: abc"), "context": "staging"},
+			expInitError: `could not find context "staging" in the kubeconfig`,
+		}, {
+			name:         "Error when the credentials are not embedded",
+			settings:     map[string]interface{}{"kubeconfig": kubeconfig("    exec:\n      command: aws")},
+			expInitError: "the credentials of the user must be a token, a password or a client certificate embedded in the kubeconfig",
+		}, {
+			name:         "Error when the mode is invalid",
+			settings:     map[string]interface{}{"kubeconfig": kubeconfig("    token: abc"), "mode": "crd"},
+			expInitError: `invalid mode "crd", must be event or resource`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("KUBERNETES_SERVICE_HOST", "")
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "kubernetes_testing", Type: "kubernetes", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewKubernetesConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "default", cfg.Namespace)
+			require.Equal(t, "https://k8s.example.com:6443", cfg.Cluster.Server)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package channels
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const defaultLarkMentionLabel = "lark_open_id"
+
+type LarkConfig struct {
+	*NotificationChannelConfig
+	URL          string
+	Secret       string
+	MentionLabel string
+	Title        string
+	Message      string
+}
+
+func LarkFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewLarkConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewLarkNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewLarkConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*LarkConfig, error) {
+	url := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if url == "" {
+		return nil, errors.New("could not find webhook URL in settings")
+	}
+	return &LarkConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		Secret:                    decryptFunc(context.Background(), config.SecureSettings, "secret", config.Settings.Get("secret").MustString()),
+		MentionLabel:              config.Settings.Get("mentionLabel").MustString(defaultLarkMentionLabel),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewLarkNotifier is the constructor for the Lark notifier.
+func NewLarkNotifier(config *LarkConfig, ns notifications.WebhookSender, t *template.Template) *LarkNotifier {
+	return &LarkNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:          config.URL,
+		Secret:       config.Secret,
+		MentionLabel: config.MentionLabel,
+		Title:        config.Title,
+		Message:      config.Message,
+		log:          log.New("alerting.notifier.lark"),
+		ns:           ns,
+		tmpl:         t,
+	}
+}
+
+// LarkNotifier is responsible for sending alert notifications to a Lark, or Feishu, group through
+// a custom bot.
+type LarkNotifier struct {
+	*Base
+	URL          string
+	Secret       string
+	MentionLabel string
+	Title        string
+	Message      string
+	log          log.Logger
+	ns           notifications.WebhookSender
+	tmpl         *template.Template
+}
+
+type larkMessage struct {
+	Timestamp string   `json:"timestamp,omitempty"`
+	Sign      string   `json:"sign,omitempty"`
+	MsgType   string   `json:"msg_type"`
+	Card      larkCard `json:"card"`
+}
+
+type larkCard struct {
+	Config   larkCardConfig    `json:"config"`
+	Header   larkCardHeader    `json:"header"`
+	Elements []larkCardElement `json:"elements"`
+}
+
+type larkCardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+type larkCardHeader struct {
+	Title    larkText `json:"title"`
+	Template string   `json:"template"`
+}
+
+type larkText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+type larkCardElement struct {
+	Tag     string           `json:"tag"`
+	Content string           `json:"content,omitempty"`
+	Actions []larkCardButton `json:"actions,omitempty"`
+}
+
+type larkCardButton struct {
+	Tag  string   `json:"tag"`
+	Text larkText `json:"text"`
+	Type string   `json:"type"`
+	URL  string   `json:"url"`
+}
+
+// Notify sends an interactive card with the message, which mentions the users of the label of the
+// alerts, and a button to the alert rules. The request is signed if the bot has a secret.
+func (ln *LarkNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	ln.log.Debug("executing Lark notification", "notification", ln.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, ln.tmpl, as, ln.log, &tmplErr)
+
+	headerTemplate := "red"
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		headerTemplate = "green"
+	}
+	content := strings.TrimSpace(tmpl(ln.Message))
+	if mentions := ln.mentions(as); len(mentions) > 0 {
+		content += "\n" + strings.Join(mentions, " ")
+	}
+	if tmplErr != nil {
+		ln.log.Warn("failed to template Lark message", "err", tmplErr.Error())
+	}
+
+	msg := larkMessage{
+		MsgType: "interactive",
+		Card: larkCard{
+			Config: larkCardConfig{WideScreenMode: true},
+			Header: larkCardHeader{
+				Title:    larkText{Tag: "plain_text", Content: strings.TrimSpace(tmpl(ln.Title))},
+				Template: headerTemplate,
+			},
+			Elements: []larkCardElement{{Tag: "markdown", Content: content}},
+		},
+	}
+	if ruleURL := ln.RuleListURL(ln.tmpl.ExternalURL); ruleURL != "" {
+		msg.Card.Elements = append(msg.Card.Elements, larkCardElement{
+			Tag: "action",
+			Actions: []larkCardButton{{
+				Tag:  "button",
+				Text: larkText{Tag: "plain_text", Content: "View alert rules"},
+				Type: "primary",
+				URL:  ruleURL,
+			}},
+		})
+	}
+	if ln.Secret != "" {
+		timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+		msg.Timestamp, msg.Sign = timestamp, larkSign(timestamp, ln.Secret)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         ln.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+		Validation:  larkValidation,
+	}
+	if err := ln.ns.SendWebhookSync(ctx, cmd); err != nil {
+		ln.log.Error("failed to send notification to Lark", "err", err, "notification", ln.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// mentions returns the mentions of the open_ids in the mention label of the alerts, which can
+// have several open_ids separated by commas.
+func (ln *LarkNotifier) mentions(as []*types.Alert) []string {
+	if ln.MentionLabel == "" {
+		return nil
+	}
+	seen := map[string]struct{}{}
+	for _, a := range as {
+		for _, id := range strings.Split(string(a.Labels[model.LabelName(ln.MentionLabel)]), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				seen[id] = struct{}{}
+			}
+		}
+	}
+	mentions := make([]string, 0, len(seen))
+	for id := range seen {
+		mentions = append(mentions, fmt.Sprintf("<at id=%s></at>", id))
+	}
+	sort.Strings(mentions)
+	return mentions
+}
+
+// larkSign returns the signature of a request to a custom bot, which is the HMAC-SHA256 of an
+// empty message with the timestamp and the secret as key.
+func larkSign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// larkValidation checks the code of the responses of the custom bots, which return errors, such as
+// an invalid signature, with the status 200.
+func larkValidation(body []byte, statusCode int) error {
+	if statusCode/100 != 2 {
+		return fmt.Errorf("the Lark API returned status %d", statusCode)
+	}
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Code != 0 {
+		return fmt.Errorf("the Lark API returned code %d: %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+func (ln *LarkNotifier) SendResolved() bool {
+	return !ln.GetDisableResolveMessage()
+}
@@ -0,0 +1,147 @@
+package channels
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestLarkNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	defer mockTimeNow(time.Unix(1700000000, 0))()
+	mac := hmac.New(sha256.New, []byte("1700000000\nsecret"))
+	expSign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "lark_open_id": "ou_b, ou_a"},
+			Annotations: model.LabelSet{"summary": "p99 > 2s"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "HighLatency", "lark_open_id": "ou_a", "team": "payments"},
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	button := map[string]interface{}{
+		"tag": "action",
+		"actions": []interface{}{map[string]interface{}{
+			"tag":  "button",
+			"text": map[string]interface{}{"tag": "plain_text", "content": "View alert rules"},
+			"type": "primary",
+			"url":  "http://localhost/alerting/list",
+		}},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Signed card that mentions the users of the alerts",
+			settings: `{"url": "https://open.feishu.cn/open-apis/bot/v2/hook/abc", "secret": "secret", "title": "{{ .CommonLabels.alertname }}", "message": "{{ range .Alerts }}{{ .Annotations.summary }}{{ end }}"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expMsg: map[string]interface{}{
+				"timestamp": "1700000000",
+				"sign":      expSign,
+				"msg_type":  "interactive",
+				"card": map[string]interface{}{
+					"config": map[string]interface{}{"wide_screen_mode": true},
+					"header": map[string]interface{}{
+						"title":    map[string]interface{}{"tag": "plain_text", "content": "HighLatency"},
+						"template": "red",
+					},
+					"elements": []interface{}{
+						map[string]interface{}{"tag": "markdown", "content": "p99 > 2s\n<at id=ou_a></at> <at id=ou_b></at>"},
+						button,
+					},
+				},
+			},
+		}, {
+			name:     "Unsigned card with a custom mention label",
+			settings: `{"url": "https://open.larksuite.com/open-apis/bot/v2/hook/abc", "mentionLabel": "team", "title": "resolved", "message": "all good"}`,
+			alerts:   []*types.Alert{resolved},
+			expMsg: map[string]interface{}{
+				"msg_type": "interactive",
+				"card": map[string]interface{}{
+					"config": map[string]interface{}{"wide_screen_mode": true},
+					"header": map[string]interface{}{
+						"title":    map[string]interface{}{"tag": "plain_text", "content": "resolved"},
+						"template": "green",
+					},
+					"elements": []interface{}{
+						map[string]interface{}{"tag": "markdown", "content": "all good\n<at id=payments></at>"},
+						button,
+					},
+				},
+			},
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find webhook URL in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "lark_testing",
+				Type:     "lark",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewLarkConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewLarkNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, cfg.URL, webhookSender.Webhook.Url)
+			var sent map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &sent))
+			require.Equal(t, c.expMsg, sent)
+		})
+	}
+}
+
+func TestLarkValidation(t *testing.T) {
+	require.NoError(t, larkValidation([]byte(`{"code": 0, "msg": "success", "data": {}}`), 200))
+	require.NoError(t, larkValidation([]byte(`ok`), 200))
+	require.EqualError(t, larkValidation([]byte(`{"code": 19021, "msg": "sign match fail"}`), 200), "the Lark API returned code 19021: sign match fail")
+	require.EqualError(t, larkValidation([]byte(`bad gateway`), 502), "the Lark API returned status 502")
+}
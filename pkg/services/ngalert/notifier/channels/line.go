@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"path"
 
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
@@ -77,17 +76,22 @@ type LineNotifier struct {
 func (ln *LineNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	ln.log.Debug("executing line notification", "notification", ln.Name)
 
-	ruleURL := path.Join(ln.tmpl.ExternalURL.String(), "/alerting/list")
+	ruleURL := ln.RuleListURL(ln.tmpl.ExternalURL)
 
 	var tmplErr error
 	tmpl, _ := TmplText(ctx, ln.tmpl, as, ln.log, &tmplErr)
 
-	body := fmt.Sprintf(
-		"%s\n%s\n\n%s",
-		tmpl(DefaultMessageTitleEmbed),
-		ruleURL,
-		tmpl(`{{ template "default.message" . }}`),
-	)
+	// The title always refers to all the alerts of the group, even if only some of them fit in the message.
+	title := tmpl(DefaultMessageTitleEmbed)
+	body, _ := ln.FitAlerts(ctx, ln.tmpl.ExternalURL, as, func(alerts []*types.Alert) string {
+		tmpl, _ := TmplText(ctx, ln.tmpl, alerts, ln.log, &tmplErr)
+		return fmt.Sprintf(
+			"%s\n%s\n\n%s",
+			title,
+			ruleURL,
+			tmpl(`{{ template "default.message" . }}`),
+		)
+	})
 	if tmplErr != nil {
 		ln.log.Warn("failed to template Line message", "err", tmplErr.Error())
 	}
@@ -0,0 +1,261 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	mqttConnectTimeout = 10 * time.Second
+	// mqttDisconnectQuiesce is the time in milliseconds given to in-flight work before disconnecting.
+	mqttDisconnectQuiesce = 250
+)
+
+// mqttClient is the subset of mqtt.Client used by the MQTT notifier.
+type mqttClient interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// mqttConnect connects to the broker. Can be overwritten in tests.
+var mqttConnect = func(ctx context.Context, opts *mqtt.ClientOptions) (mqttClient, error) {
+	client := mqtt.NewClient(opts)
+	if err := waitMQTTToken(ctx, client.Connect()); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+type MQTTConfig struct {
+	*NotificationChannelConfig
+	BrokerURL     string
+	ClientID      string
+	Topic         string
+	QoS           byte
+	Retain        bool
+	Username      string
+	Password      string
+	TLSSkipVerify bool
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+}
+
+func MQTTFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewMQTTConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	n, err := NewMQTTNotifier(cfg, fc.ImageStore, fc.Template)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: "invalid TLS settings",
+			Err:    err,
+			Cfg:    *fc.Config,
+		}
+	}
+	return n, nil
+}
+
+func NewMQTTConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*MQTTConfig, error) {
+	brokerURL := config.Settings.Get("brokerUrl").MustString()
+	if brokerURL == "" {
+		return nil, errors.New("could not find broker URL in settings")
+	}
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+	default:
+		return nil, fmt.Errorf("unsupported broker URL scheme %q", u.Scheme)
+	}
+
+	topic := config.Settings.Get("topic").MustString()
+	if topic == "" {
+		return nil, errors.New("could not find topic in settings")
+	}
+
+	// The QoS is a string when set from the UI and a number when provisioned.
+	qos, err := config.Settings.Get("qos").Int()
+	if err != nil {
+		qos, err = strconv.Atoi(config.Settings.Get("qos").MustString("0"))
+		if err != nil {
+			return nil, errors.New("invalid QoS, must be 0, 1 or 2")
+		}
+	}
+	if qos < 0 || qos > 2 {
+		return nil, fmt.Errorf("invalid QoS %d, must be 0, 1 or 2", qos)
+	}
+
+	return &MQTTConfig{
+		NotificationChannelConfig: config,
+		BrokerURL:                 brokerURL,
+		ClientID:                  config.Settings.Get("clientId").MustString(),
+		Topic:                     topic,
+		QoS:                       byte(qos),
+		Retain:                    config.Settings.Get("retain").MustBool(false),
+		Username:                  config.Settings.Get("username").MustString(),
+		Password:                  decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString()),
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSCACert:                 config.Settings.Get("tlsCACert").MustString(),
+		TLSClientCert:             config.Settings.Get("tlsClientCert").MustString(),
+		TLSClientKey:              decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString()),
+	}, nil
+}
+
+// NewMQTTNotifier is the constructor for the MQTT notifier.
+func NewMQTTNotifier(config *MQTTConfig, images ImageStore, t *template.Template) (*MQTTNotifier, error) {
+	tlsConfig, err := brokerTLSConfig(config.TLSSkipVerify, config.TLSCACert, config.TLSClientCert, config.TLSClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MQTTNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:     config.OrgID,
+		BrokerURL: config.BrokerURL,
+		ClientID:  config.ClientID,
+		Topic:     config.Topic,
+		QoS:       config.QoS,
+		Retain:    config.Retain,
+		Username:  config.Username,
+		Password:  config.Password,
+		tlsConfig: tlsConfig,
+		log:       log.New("alerting.notifier.mqtt"),
+		images:    images,
+		tmpl:      t,
+	}, nil
+}
+
+// MQTTNotifier is responsible for publishing alert notifications to an MQTT broker.
+type MQTTNotifier struct {
+	*Base
+	BrokerURL string
+	ClientID  string
+	Topic     string
+	QoS       byte
+	Retain    bool
+	Username  string
+	Password  string
+	orgID     int64
+	tlsConfig *tls.Config
+	log       log.Logger
+	images    ImageStore
+	tmpl      *template.Template
+}
+
+// Notify publishes the alerts to the configured topic. With QoS 1 and 2 it waits
+// for the broker to acknowledge the message.
+func (mn *MQTTNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	mn.log.Debug("publishing to MQTT", "notification", mn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, mn.tmpl, as, mn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, mn.log, mn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	topic := strings.TrimSpace(tmpl(mn.Topic))
+	msg := newBrokerMessage(ctx, mn.PayloadVersion(), tmpl, data, groupKey.String(), mn.orgID, as...)
+
+	if tmplErr != nil {
+		mn.log.Warn("failed to template MQTT message", "err", tmplErr.Error())
+	}
+	if topic == "" || strings.ContainsAny(topic, "+#") {
+		return false, fmt.Errorf("invalid MQTT topic %q", topic)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if recordDryRun(ctx, topic, string(body)) {
+		return true, nil
+	}
+
+	client, err := mqttConnect(ctx, mn.clientOptions())
+	if err != nil {
+		mn.log.Error("failed to connect to MQTT broker", "err", err, "notification", mn.Name)
+		return false, err
+	}
+	defer client.Disconnect(mqttDisconnectQuiesce)
+
+	if err := waitMQTTToken(ctx, client.Publish(topic, mn.QoS, mn.Retain, body)); err != nil {
+		mn.log.Error("failed to publish to MQTT", "err", err, "topic", topic, "notification", mn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (mn *MQTTNotifier) clientOptions() *mqtt.ClientOptions {
+	clientID := mn.ClientID
+	if clientID == "" {
+		// Brokers disconnect clients with a duplicate ID, so concurrent notifications need their own.
+		clientID = "grafana-" + util.GenerateShortUID()
+	}
+
+	return mqtt.NewClientOptions().
+		AddBroker(mn.BrokerURL).
+		SetClientID(clientID).
+		SetUsername(mn.Username).
+		SetPassword(mn.Password).
+		SetTLSConfig(mn.tlsConfig).
+		SetConnectTimeout(mqttConnectTimeout).
+		SetWriteTimeout(mqttConnectTimeout).
+		// The connection only lives for a single notification, retries are handled by the notification pipeline.
+		SetAutoReconnect(false).
+		SetConnectRetry(false)
+}
+
+func (mn *MQTTNotifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
+
+func waitMQTTToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
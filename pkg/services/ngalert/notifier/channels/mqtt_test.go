@@ -0,0 +1,218 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeMQTTToken struct {
+	err error
+}
+
+func (t *fakeMQTTToken) Wait() bool                     { return true }
+func (t *fakeMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t *fakeMQTTToken) Error() error                   { return t.err }
+func (t *fakeMQTTToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+type fakeMQTTClient struct {
+	topic        string
+	qos          byte
+	retained     bool
+	payload      []byte
+	publishErr   error
+	disconnected bool
+}
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.topic = topic
+	c.qos = qos
+	c.retained = retained
+	c.payload = payload.([]byte)
+	return &fakeMQTTToken{err: c.publishErr}
+}
+
+func (c *fakeMQTTClient) Disconnect(uint) {
+	c.disconnected = true
+}
+
+func TestMQTTNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations: model.LabelSet{"ann1": "annv1"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		publishErr   error
+		expTopic     string
+		expQoS       byte
+		expRetained  bool
+		expClientID  string
+		expUsername  string
+		expInitError string
+		expMsgError  string
+	}{
+		{
+			name: "Publishes to templated topic",
+			settings: map[string]interface{}{
+				"brokerUrl": "tcp://localhost:1883",
+				"topic":     "grafana/alerts/{{ .CommonLabels.lbl1 }}",
+			},
+			expTopic: "grafana/alerts/val1",
+		}, {
+			name: "QoS from the UI, retain and credentials",
+			settings: map[string]interface{}{
+				"brokerUrl": "ssl://localhost:8883",
+				"topic":     "grafana/alerts",
+				"clientId":  "my-client",
+				"qos":       "2",
+				"retain":    true,
+				"username":  "grafana",
+				"password":  "secret",
+			},
+			expTopic:    "grafana/alerts",
+			expQoS:      2,
+			expRetained: true,
+			expClientID: "my-client",
+			expUsername: "grafana",
+		}, {
+			name: "QoS from provisioning",
+			settings: map[string]interface{}{
+				"brokerUrl": "tcp://localhost:1883",
+				"topic":     "grafana/alerts",
+				"qos":       1,
+			},
+			expTopic: "grafana/alerts",
+			expQoS:   1,
+		}, {
+			name: "Error when the broker rejects the message",
+			settings: map[string]interface{}{
+				"brokerUrl": "tcp://localhost:1883",
+				"topic":     "grafana/alerts",
+				"qos":       1,
+			},
+			publishErr:  errors.New("not authorized"),
+			expMsgError: "not authorized",
+		}, {
+			name: "Error on topic with wildcards",
+			settings: map[string]interface{}{
+				"brokerUrl": "tcp://localhost:1883",
+				"topic":     "grafana/+/alerts",
+			},
+			expMsgError: `invalid MQTT topic "grafana/+/alerts"`,
+		}, {
+			name:         "Error when broker URL is missing",
+			settings:     map[string]interface{}{"topic": "grafana/alerts"},
+			expInitError: "could not find broker URL in settings",
+		}, {
+			name:         "Error on unsupported scheme",
+			settings:     map[string]interface{}{"brokerUrl": "http://localhost:1883", "topic": "grafana/alerts"},
+			expInitError: `unsupported broker URL scheme "http"`,
+		}, {
+			name:         "Error when topic is missing",
+			settings:     map[string]interface{}{"brokerUrl": "tcp://localhost:1883"},
+			expInitError: "could not find topic in settings",
+		}, {
+			name:         "Error on invalid QoS",
+			settings:     map[string]interface{}{"brokerUrl": "tcp://localhost:1883", "topic": "grafana/alerts", "qos": 3},
+			expInitError: "invalid QoS 3, must be 0, 1 or 2",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "mqtt_testing",
+				Type:     "mqtt",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewMQTTConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			client := &fakeMQTTClient{publishErr: c.publishErr}
+			var connectOpts *mqtt.ClientOptions
+			origConnect := mqttConnect
+			mqttConnect = func(_ context.Context, opts *mqtt.ClientOptions) (mqttClient, error) {
+				connectOpts = opts
+				return client, nil
+			}
+			t.Cleanup(func() { mqttConnect = origConnect })
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			n, err := NewMQTTNotifier(cfg, &UnavailableImageStore{}, tmpl)
+			require.NoError(t, err)
+
+			ok, err := n.Notify(ctx, alerts...)
+			if c.expMsgError != "" {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.True(t, client.disconnected)
+
+			require.Equal(t, c.settings["brokerUrl"], connectOpts.Servers[0].String())
+			require.Equal(t, c.expUsername, connectOpts.Username)
+			require.False(t, connectOpts.AutoReconnect)
+			if c.expClientID != "" {
+				require.Equal(t, c.expClientID, connectOpts.ClientID)
+			} else {
+				require.True(t, strings.HasPrefix(connectOpts.ClientID, "grafana-"))
+			}
+
+			require.Equal(t, c.expTopic, client.topic)
+			require.Equal(t, c.expQoS, client.qos)
+			require.Equal(t, c.expRetained, client.retained)
+
+			var msg brokerMessage
+			require.NoError(t, json.Unmarshal(client.payload, &msg))
+			require.Equal(t, "alertname", msg.GroupKey)
+			require.Equal(t, int64(1), msg.OrgID)
+			require.Equal(t, "alerting", msg.State)
+			require.Len(t, msg.Alerts, 1)
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	msGraphScope = "https://graph.microsoft.com/.default"
+	// msGraphMaxAttachmentBytes is the size of the base64 encoded attachments of an email, which
+	// keeps the requests under the limit of 4MB of the sendMail method.
+	msGraphMaxAttachmentBytes = 3 * 1024 * 1024
+)
+
+var (
+	// MSGraphTokenURL is the Azure AD token endpoint of the tenant. Can be overwritten in tests.
+	MSGraphTokenURL = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	// MSGraphURL is the endpoint of the Microsoft Graph API. Can be overwritten in tests.
+	MSGraphURL = "https://graph.microsoft.com/v1.0"
+)
+
+type MSGraphMailConfig struct {
+	*NotificationChannelConfig
+	TenantID        string
+	ClientID        string
+	ClientSecret    string
+	Sender          string
+	Addresses       []string
+	SingleEmail     bool
+	Subject         string
+	Message         string
+	Priority        string
+	SaveToSentItems bool
+}
+
+func MSGraphMailFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewMSGraphMailConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewMSGraphMailNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewMSGraphMailConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*MSGraphMailConfig, error) {
+	cfg := &MSGraphMailConfig{
+		NotificationChannelConfig: config,
+		TenantID:                  config.Settings.Get("tenantId").MustString(),
+		ClientID:                  config.Settings.Get("clientId").MustString(),
+		ClientSecret:              decryptFunc(context.Background(), config.SecureSettings, "clientSecret", config.Settings.Get("clientSecret").MustString()),
+		Sender:                    config.Settings.Get("sender").MustString(),
+		Addresses:                 util.SplitEmails(config.Settings.Get("addresses").MustString()),
+		SingleEmail:               config.Settings.Get("singleEmail").MustBool(false),
+		Subject:                   config.Settings.Get("subject").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(),
+		Priority:                  config.Settings.Get("priority").MustString(emailPriorityNormal),
+		SaveToSentItems:           config.Settings.Get("saveToSentItems").MustBool(false),
+	}
+	if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("tenant ID, client ID and client secret are required")
+	}
+	if cfg.Sender == "" {
+		return nil, errors.New("could not find sender in settings")
+	}
+	if len(cfg.Addresses) == 0 {
+		return nil, errors.New("could not find addresses in settings")
+	}
+	if _, ok := emailPriorityHeaders[cfg.Priority]; !ok {
+		return nil, fmt.Errorf("invalid priority %q, must be one of high, normal or low", cfg.Priority)
+	}
+	return cfg, nil
+}
+
+// NewMSGraphMailNotifier is the constructor for the Microsoft Graph mail notifier.
+func NewMSGraphMailNotifier(config *MSGraphMailConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *MSGraphMailNotifier {
+	cc := clientcredentials.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		TokenURL:     fmt.Sprintf(MSGraphTokenURL, url.PathEscape(config.TenantID)),
+		Scopes:       []string{msGraphScope},
+	}
+	return &MSGraphMailNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Sender:          config.Sender,
+		Addresses:       config.Addresses,
+		SingleEmail:     config.SingleEmail,
+		Subject:         config.Subject,
+		Message:         config.Message,
+		Priority:        config.Priority,
+		SaveToSentItems: config.SaveToSentItems,
+		// The token source caches the token until it expires.
+		tokenSource: cc.TokenSource(context.Background()),
+		log:         log.New("alerting.notifier.msgraphmail"),
+		images:      images,
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// MSGraphMailNotifier is responsible for sending alert notifications as emails with the sendMail
+// method of the Microsoft Graph API, for the Microsoft 365 tenants that disable SMTP.
+type MSGraphMailNotifier struct {
+	*Base
+	Sender          string
+	Addresses       []string
+	SingleEmail     bool
+	Subject         string
+	Message         string
+	Priority        string
+	SaveToSentItems bool
+	tokenSource     oauth2.TokenSource
+	log             log.Logger
+	images          ImageStore
+	ns              notifications.WebhookSender
+	tmpl            *template.Template
+}
+
+type msGraphRecipient struct {
+	EmailAddress struct {
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+type msGraphAttachment struct {
+	ODataType    string `json:"@odata.type"`
+	Name         string `json:"name"`
+	ContentType  string `json:"contentType"`
+	ContentBytes string `json:"contentBytes"`
+	ContentID    string `json:"contentId,omitempty"`
+	IsInline     bool   `json:"isInline"`
+}
+
+type msGraphMessage struct {
+	Subject string `json:"subject"`
+	Body    struct {
+		ContentType string `json:"contentType"`
+		Content     string `json:"content"`
+	} `json:"body"`
+	ToRecipients []msGraphRecipient  `json:"toRecipients"`
+	Importance   string              `json:"importance"`
+	Attachments  []msGraphAttachment `json:"attachments,omitempty"`
+}
+
+type msGraphSendMail struct {
+	Message         msGraphMessage `json:"message"`
+	SaveToSentItems bool           `json:"saveToSentItems"`
+}
+
+// msGraphMailBody is the HTML body of the emails. The images of the alerts are attached to the
+// email and shown inline, or linked when they are uploaded.
+var msGraphMailBody = htmltemplate.Must(htmltemplate.New("body").Funcs(htmltemplate.FuncMap{
+	"cid": func(contentID string) htmltemplate.URL {
+		// #nosec G203 -- the content ID is the escaped name of the attachment.
+		return htmltemplate.URL("cid:" + contentID)
+	},
+}).Parse(`<html><body style="font-family: sans-serif">
+<h2>{{ .Title }}</h2>
+{{ with .Message }}<p style="white-space: pre-wrap">{{ . }}</p>{{ end }}
+{{ range .Alerts }}<div style="border-left: 4px solid {{ if eq .Status "firing" }}#D63232{{ else }}#36a64f{{ end }}; padding-left: 12px; margin-bottom: 16px">
+<h3>{{ if eq .Status "firing" }}Firing{{ else }}Resolved{{ end }}: {{ .Labels.alertname }}</h3>
+{{ with .ValueString }}<p>Value: {{ . }}</p>{{ end }}
+<p><b>Labels</b><br>{{ range .Labels.SortedPairs }}{{ .Name }} = {{ .Value }}<br>{{ end }}</p>
+{{ with .Annotations }}<p><b>Annotations</b><br>{{ range .SortedPairs }}{{ .Name }} = {{ .Value }}<br>{{ end }}</p>{{ end }}
+{{ if .EmbeddedImage }}<p><img src="{{ cid .EmbeddedImage }}" style="max-width: 100%"></p>{{ else if .ImageURL }}<p><img src="{{ .ImageURL }}" style="max-width: 100%"></p>{{ end }}
+<p>{{ with .GeneratorURL }}<a href="{{ . }}">View alert rule</a> {{ end }}{{ with .DashboardURL }}<a href="{{ . }}">View dashboard</a> {{ end }}{{ with .PanelURL }}<a href="{{ . }}">View panel</a> {{ end }}{{ with .SilenceURL }}<a href="{{ . }}">Silence</a>{{ end }}</p>
+</div>
+{{ end }}<p style="color: #8e8e8e; font-size: 12px">Sent by <a href="{{ .ExternalURL }}">Grafana</a></p>
+</body></html>`))
+
+// Notify sends the email with the sendMail method of the mailbox of the sender, a single email to
+// all the addresses or one email per address.
+func (mn *MSGraphMailNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	mn.log.Debug("sending Microsoft Graph email", "notification", mn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, mn.tmpl, as, mn.log, &tmplErr)
+	subject := tmpl(mn.Subject)
+	message := tmpl(mn.Message)
+	if tmplErr != nil {
+		mn.log.Warn("failed to template Microsoft Graph email", "err", tmplErr.Error())
+	}
+
+	var attachments []msGraphAttachment
+	size := 0
+	_ = withStoredImages(ctx, mn.log, mn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			} else if len(image.Path) != 0 {
+				attachment, err := msGraphImageAttachment(image.Path)
+				if err != nil {
+					mn.log.Warn("failed to read image file for email attachment", "file", image.Path, "err", err)
+					return nil
+				}
+				if size += len(attachment.ContentBytes); size > msGraphMaxAttachmentBytes {
+					mn.log.Warn("not attaching the image, the email would be too large", "file", image.Path)
+					return ErrImagesDone
+				}
+				data.Alerts[index].EmbeddedImage = attachment.ContentID
+				attachments = append(attachments, attachment)
+			}
+			return nil
+		}, as...)
+
+	var body bytes.Buffer
+	if err := msGraphMailBody.Execute(&body, struct {
+		*ExtendedData
+		Title   string
+		Message string
+	}{data, subject, message}); err != nil {
+		return false, fmt.Errorf("failed to render the email: %w", err)
+	}
+
+	msg := msGraphMessage{
+		Subject:     subject,
+		Importance:  mn.Priority,
+		Attachments: attachments,
+	}
+	msg.Body.ContentType = "HTML"
+	msg.Body.Content = body.String()
+
+	recipients := [][]string{mn.Addresses}
+	if !mn.SingleEmail {
+		recipients = nil
+		for _, address := range mn.Addresses {
+			recipients = append(recipients, []string{address})
+		}
+	}
+
+	token, err := mn.tokenSource.Token()
+	if err != nil {
+		return false, fmt.Errorf("failed to get Azure AD token: %w", err)
+	}
+	for _, to := range recipients {
+		msg.ToRecipients = make([]msGraphRecipient, len(to))
+		for i, address := range to {
+			msg.ToRecipients[i].EmailAddress.Address = address
+		}
+		b, err := json.Marshal(msGraphSendMail{Message: msg, SaveToSentItems: mn.SaveToSentItems})
+		if err != nil {
+			return false, err
+		}
+		cmd := &models.SendWebhookSync{
+			Url:         fmt.Sprintf("%s/users/%s/sendMail", MSGraphURL, url.PathEscape(mn.Sender)),
+			Body:        string(b),
+			HttpMethod:  "POST",
+			HttpHeader:  map[string]string{"Authorization": "Bearer " + token.AccessToken},
+			ContentType: "application/json",
+			Validation:  msGraphValidation,
+		}
+		if err := mn.ns.SendWebhookSync(ctx, cmd); err != nil {
+			mn.log.Error("failed to send Microsoft Graph email", "err", err, "notification", mn.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// msGraphImageAttachment returns the image file as an inline attachment, identified by its escaped
+// name.
+func msGraphImageAttachment(file string) (msGraphAttachment, error) {
+	f, err := openImage(file)
+	if err != nil {
+		return msGraphAttachment{}, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(f); err != nil {
+		return msGraphAttachment{}, err
+	}
+	name := path.Base(filepath.ToSlash(file))
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "image/png"
+	}
+	return msGraphAttachment{
+		ODataType:    "#microsoft.graph.fileAttachment",
+		Name:         name,
+		ContentType:  contentType,
+		ContentBytes: base64.StdEncoding.EncodeToString(b.Bytes()),
+		ContentID:    url.PathEscape(name),
+		IsInline:     true,
+	}, nil
+}
+
+// msGraphValidation returns the error of the Microsoft Graph API, if any.
+func msGraphValidation(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var resp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error.Code != "" {
+		return fmt.Errorf("the Microsoft Graph API returned status %d: %s: %s", statusCode, resp.Error.Code, resp.Error.Message)
+	}
+	return fmt.Errorf("the Microsoft Graph API returned status %d", statusCode)
+}
+
+func (mn *MSGraphMailNotifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
@@ -0,0 +1,160 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeMSGraph records the requests to the Microsoft Graph API.
+type fakeMSGraph struct {
+	notificationServiceMock
+	requests []*models.SendWebhookSync
+}
+
+func (f *fakeMSGraph) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, cmd)
+	return nil
+}
+
+func TestMSGraphMailNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/contoso.onmicrosoft.com/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "https://graph.microsoft.com/.default", r.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "graph-token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenServer.Close()
+	origTokenURL := MSGraphTokenURL
+	MSGraphTokenURL = tokenServer.URL + "/%s/token"
+	defer func() { MSGraphTokenURL = origTokenURL }()
+
+	// The images are only on disk, as when no external image storage is configured.
+	images := newFakeImageStoreWithFile(t, 1).(*fakeImageStore)
+	images.Images[0].URL = ""
+	imageName := filepath.Base(images.Images[0].Path)
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			Annotations: model.LabelSet{"summary": "Disk <full>", "__alertImageToken__": "test-image-1"},
+		},
+	}, {
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DiskFull", "instance": "db-2"},
+		},
+	}}
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{
+		"tenantId": "contoso.onmicrosoft.com",
+		"clientId": "client-id",
+		"clientSecret": "secret",
+		"sender": "alerts@contoso.com",
+		"addresses": "ops@contoso.com;dba@contoso.com",
+		"priority": "high",
+		"message": "{{ len .Alerts }} disks are full"
+	}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewMSGraphMailConfig(&NotificationChannelConfig{Name: "graph_testing", Type: "msgraphmail", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+
+	graph := &fakeMSGraph{}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "DiskFull"})
+	ok, err := NewMSGraphMailNotifier(cfg, images, graph, tmpl).Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// An email is sent to each address.
+	require.Len(t, graph.requests, 2)
+	for i, to := range []string{"ops@contoso.com", "dba@contoso.com"} {
+		cmd := graph.requests[i]
+		require.Equal(t, "https://graph.microsoft.com/v1.0/users/alerts@contoso.com/sendMail", cmd.Url)
+		require.Equal(t, "POST", cmd.HttpMethod)
+		require.Equal(t, map[string]string{"Authorization": "Bearer graph-token"}, cmd.HttpHeader)
+
+		var req msGraphSendMail
+		require.NoError(t, json.Unmarshal([]byte(cmd.Body), &req))
+		require.False(t, req.SaveToSentItems)
+		require.Equal(t, "[FIRING:2] DiskFull ", req.Message.Subject)
+		require.Equal(t, "high", req.Message.Importance)
+		require.Len(t, req.Message.ToRecipients, 1)
+		require.Equal(t, to, req.Message.ToRecipients[0].EmailAddress.Address)
+		require.Equal(t, "HTML", req.Message.Body.ContentType)
+		require.Contains(t, req.Message.Body.Content, "2 disks are full")
+		require.Contains(t, req.Message.Body.Content, "summary = Disk &lt;full&gt;")
+		require.Contains(t, req.Message.Body.Content, `<img src="cid:`+imageName+`"`)
+		require.Equal(t, []msGraphAttachment{{
+			ODataType:    "#microsoft.graph.fileAttachment",
+			Name:         imageName,
+			ContentType:  "image/png",
+			ContentBytes: "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=",
+			ContentID:    imageName,
+			IsInline:     true,
+		}}, req.Message.Attachments)
+	}
+
+	// The errors of the API are returned.
+	require.NoError(t, msGraphValidation(nil, 202))
+	require.EqualError(t, msGraphValidation([]byte(`{"error": {"code": "ErrorAccessDenied", "message": "Access is denied."}}`), 403),
+		"the Microsoft Graph API returned status 403: ErrorAccessDenied: Access is denied.")
+	require.EqualError(t, msGraphValidation([]byte(`Bad Gateway`), 502), "the Microsoft Graph API returned status 502")
+}
+
+func TestNewMSGraphMailConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expError string
+	}{
+		{
+			name:     "Error when the credentials are missing",
+			settings: `{"tenantId": "contoso.onmicrosoft.com", "sender": "alerts@contoso.com", "addresses": "ops@contoso.com"}`,
+			expError: "tenant ID, client ID and client secret are required",
+		}, {
+			name:     "Error when the sender is missing",
+			settings: `{"tenantId": "contoso.onmicrosoft.com", "clientId": "id", "clientSecret": "secret", "addresses": "ops@contoso.com"}`,
+			expError: "could not find sender in settings",
+		}, {
+			name:     "Error when the addresses are missing",
+			settings: `{"tenantId": "contoso.onmicrosoft.com", "clientId": "id", "clientSecret": "secret", "sender": "alerts@contoso.com"}`,
+			expError: "could not find addresses in settings",
+		}, {
+			name:     "Error when the priority is invalid",
+			settings: `{"tenantId": "contoso.onmicrosoft.com", "clientId": "id", "clientSecret": "secret", "sender": "alerts@contoso.com", "addresses": "ops@contoso.com", "priority": "urgent"}`,
+			expError: `invalid priority "urgent", must be one of high, normal or low`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewMSGraphMailConfig(&NotificationChannelConfig{Name: "graph_testing", Type: "msgraphmail", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expError)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	natsConnectTimeout = 10 * time.Second
+	// natsJetStreamExpectedStreamHeader makes JetStream reject the message if the subject is not bound to the given stream.
+	natsJetStreamExpectedStreamHeader = "Nats-Expected-Stream"
+)
+
+// natsConn is the subset of *nats.Conn used by the NATS notifier.
+type natsConn interface {
+	PublishMsg(m *nats.Msg) error
+	RequestMsgWithContext(ctx context.Context, m *nats.Msg) (*nats.Msg, error)
+	FlushWithContext(ctx context.Context) error
+	Close()
+}
+
+// natsConnect connects to the NATS servers. Can be overwritten in tests.
+var natsConnect = func(servers string, opts ...nats.Option) (natsConn, error) {
+	return nats.Connect(servers, opts...)
+}
+
+type NATSConfig struct {
+	*NotificationChannelConfig
+	URL       string
+	Subject   string
+	Username  string
+	Password  string
+	Token     string
+	NKeySeed  string
+	UserJWT   string
+	JetStream bool
+	Stream    string
+}
+
+func NATSFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewNATSConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewNATSNotifier(cfg, fc.ImageStore, fc.Template), nil
+}
+
+func NewNATSConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*NATSConfig, error) {
+	url := config.Settings.Get("url").MustString()
+	if url == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	subject := config.Settings.Get("subject").MustString()
+	if subject == "" {
+		return nil, errors.New("could not find subject property in settings")
+	}
+
+	cfg := &NATSConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		Subject:                   subject,
+		Username:                  config.Settings.Get("username").MustString(),
+		Password:                  decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString()),
+		Token:                     decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString()),
+		NKeySeed:                  decryptFunc(context.Background(), config.SecureSettings, "nkeySeed", config.Settings.Get("nkeySeed").MustString()),
+		UserJWT:                   decryptFunc(context.Background(), config.SecureSettings, "userJwt", config.Settings.Get("userJwt").MustString()),
+		JetStream:                 config.Settings.Get("jetStream").MustBool(false),
+		Stream:                    config.Settings.Get("stream").MustString(),
+	}
+
+	methods := 0
+	for _, set := range []bool{cfg.Username != "", cfg.Token != "", cfg.NKeySeed != ""} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		return nil, errors.New("only one of username/password, token or NKey seed can be set")
+	}
+	if cfg.UserJWT != "" && cfg.NKeySeed == "" {
+		return nil, errors.New("NKey seed is required to sign the user JWT")
+	}
+	if cfg.NKeySeed != "" {
+		if _, err := nkeys.FromSeed([]byte(cfg.NKeySeed)); err != nil {
+			return nil, fmt.Errorf("invalid NKey seed: %w", err)
+		}
+	}
+	if cfg.Stream != "" && !cfg.JetStream {
+		return nil, errors.New("stream can only be set when JetStream is enabled")
+	}
+
+	return cfg, nil
+}
+
+// NewNATSNotifier is the constructor for the NATS notifier.
+func NewNATSNotifier(config *NATSConfig, images ImageStore, t *template.Template) *NATSNotifier {
+	return &NATSNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:     config.OrgID,
+		URL:       config.URL,
+		Subject:   config.Subject,
+		Username:  config.Username,
+		Password:  config.Password,
+		Token:     config.Token,
+		NKeySeed:  config.NKeySeed,
+		UserJWT:   config.UserJWT,
+		JetStream: config.JetStream,
+		Stream:    config.Stream,
+		log:       log.New("alerting.notifier.nats"),
+		images:    images,
+		tmpl:      t,
+	}
+}
+
+// NATSNotifier is responsible for publishing alert notifications to a NATS subject.
+type NATSNotifier struct {
+	*Base
+	URL       string
+	Subject   string
+	Username  string
+	Password  string
+	Token     string
+	NKeySeed  string
+	UserJWT   string
+	JetStream bool
+	Stream    string
+	orgID     int64
+	log       log.Logger
+	images    ImageStore
+	tmpl      *template.Template
+}
+
+// natsPubAck is the response of JetStream to a published message.
+type natsPubAck struct {
+	Stream   string `json:"stream"`
+	Sequence uint64 `json:"seq"`
+	Error    *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+// Notify publishes the alerts to the configured subject. When JetStream is enabled
+// it waits for the server to acknowledge that the message was persisted.
+func (nn *NATSNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	nn.log.Debug("publishing to NATS", "notification", nn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, nn.tmpl, as, nn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, nn.log, nn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	subject := strings.TrimSpace(tmpl(nn.Subject))
+	msg := newBrokerMessage(ctx, nn.PayloadVersion(), tmpl, data, groupKey.String(), nn.orgID, as...)
+
+	if tmplErr != nil {
+		nn.log.Warn("failed to template NATS message", "err", tmplErr.Error())
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return false, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if recordDryRun(ctx, subject, string(body)) {
+		return true, nil
+	}
+
+	opts, err := nn.connectOptions()
+	if err != nil {
+		return false, err
+	}
+	nc, err := natsConnect(nn.URL, opts...)
+	if err != nil {
+		nn.log.Error("failed to connect to NATS", "err", err, "notification", nn.Name)
+		return false, err
+	}
+	defer nc.Close()
+
+	if err := nn.publish(ctx, nc, &nats.Msg{Subject: subject, Data: body}); err != nil {
+		nn.log.Error("failed to publish to NATS", "err", err, "subject", subject, "notification", nn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (nn *NATSNotifier) publish(ctx context.Context, nc natsConn, m *nats.Msg) error {
+	if !nn.JetStream {
+		if err := nc.PublishMsg(m); err != nil {
+			return err
+		}
+		// Flush to make sure the server received the message before closing the connection.
+		return nc.FlushWithContext(ctx)
+	}
+
+	if nn.Stream != "" {
+		m.Header = nats.Header{}
+		m.Header.Set(natsJetStreamExpectedStreamHeader, nn.Stream)
+	}
+
+	resp, err := nc.RequestMsgWithContext(ctx, m)
+	if err != nil {
+		if errors.Is(err, nats.ErrNoResponders) {
+			return fmt.Errorf("no JetStream stream is bound to subject %q", m.Subject)
+		}
+		return err
+	}
+
+	var ack natsPubAck
+	if err := json.Unmarshal(resp.Data, &ack); err != nil {
+		return fmt.Errorf("invalid JetStream publish acknowledgement: %w", err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("JetStream rejected the message: %s (code %d)", ack.Error.Description, ack.Error.Code)
+	}
+	if ack.Stream == "" {
+		return errors.New("invalid JetStream publish acknowledgement: missing stream")
+	}
+
+	nn.log.Debug("message persisted by JetStream", "stream", ack.Stream, "seq", ack.Sequence, "notification", nn.Name)
+	return nil
+}
+
+func (nn *NATSNotifier) connectOptions() ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.Name("Grafana Alerting"),
+		nats.Timeout(natsConnectTimeout),
+		// Reconnecting is pointless for a connection that lives for a single notification.
+		nats.NoReconnect(),
+	}
+
+	switch {
+	case nn.Username != "":
+		opts = append(opts, nats.UserInfo(nn.Username, nn.Password))
+	case nn.Token != "":
+		opts = append(opts, nats.Token(nn.Token))
+	case nn.NKeySeed != "":
+		kp, err := nkeys.FromSeed([]byte(nn.NKeySeed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid NKey seed: %w", err)
+		}
+		sign := func(nonce []byte) ([]byte, error) {
+			return kp.Sign(nonce)
+		}
+		if nn.UserJWT != "" {
+			opts = append(opts, nats.UserJWT(func() (string, error) { return nn.UserJWT, nil }, sign))
+		} else {
+			pub, err := kp.PublicKey()
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, nats.Nkey(pub, sign))
+		}
+	}
+
+	return opts, nil
+}
+
+func (nn *NATSNotifier) SendResolved() bool {
+	return !nn.GetDisableResolveMessage()
+}
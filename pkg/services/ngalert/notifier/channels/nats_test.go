@@ -0,0 +1,220 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeNATSConn struct {
+	published []*nats.Msg
+	requested []*nats.Msg
+	ack       string
+	closed    bool
+}
+
+func (c *fakeNATSConn) PublishMsg(m *nats.Msg) error {
+	c.published = append(c.published, m)
+	return nil
+}
+
+func (c *fakeNATSConn) RequestMsgWithContext(_ context.Context, m *nats.Msg) (*nats.Msg, error) {
+	c.requested = append(c.requested, m)
+	return &nats.Msg{Data: []byte(c.ack)}, nil
+}
+
+func (c *fakeNATSConn) FlushWithContext(_ context.Context) error {
+	return nil
+}
+
+func (c *fakeNATSConn) Close() {
+	c.closed = true
+}
+
+func TestNATSNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	seed, err := user.Seed()
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations: model.LabelSet{"ann1": "annv1"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		ack          string
+		expSubject   string
+		expStreamHdr string
+		expOptions   func(t *testing.T, o nats.Options)
+		expInitError string
+		expMsgError  string
+	}{
+		{
+			name: "Core NATS with templated subject and user/password",
+			settings: map[string]interface{}{
+				"url":      "nats://localhost:4222",
+				"subject":  "alerts.{{ .CommonLabels.lbl1 }}",
+				"username": "grafana",
+				"password": "secret",
+			},
+			expSubject: "alerts.val1",
+			expOptions: func(t *testing.T, o nats.Options) {
+				require.Equal(t, "grafana", o.User)
+				require.Equal(t, "secret", o.Password)
+			},
+		}, {
+			name: "JetStream with expected stream and NKey",
+			settings: map[string]interface{}{
+				"url":       "nats://localhost:4222",
+				"subject":   "alerts",
+				"nkeySeed":  string(seed),
+				"jetStream": true,
+				"stream":    "ALERTS",
+			},
+			ack:          `{"stream": "ALERTS", "seq": 1}`,
+			expSubject:   "alerts",
+			expStreamHdr: "ALERTS",
+			expOptions: func(t *testing.T, o nats.Options) {
+				pub, err := user.PublicKey()
+				require.NoError(t, err)
+				require.Equal(t, pub, o.Nkey)
+				require.NotNil(t, o.SignatureCB)
+			},
+		}, {
+			name: "JetStream error is returned",
+			settings: map[string]interface{}{
+				"url":       "nats://localhost:4222",
+				"subject":   "alerts",
+				"jetStream": true,
+			},
+			ack:         `{"error": {"code": 503, "description": "jetstream not enabled"}}`,
+			expMsgError: "JetStream rejected the message: jetstream not enabled (code 503)",
+		}, {
+			name: "Error on invalid subject",
+			settings: map[string]interface{}{
+				"url":     "nats://localhost:4222",
+				"subject": "alerts {{ .CommonLabels.lbl1 }}",
+			},
+			expMsgError: `invalid NATS subject "alerts val1"`,
+		}, {
+			name:         "Error when url is missing",
+			settings:     map[string]interface{}{"subject": "alerts"},
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when subject is missing",
+			settings:     map[string]interface{}{"url": "nats://localhost:4222"},
+			expInitError: "could not find subject property in settings",
+		}, {
+			name:         "Error when several authentication methods are set",
+			settings:     map[string]interface{}{"url": "nats://localhost:4222", "subject": "alerts", "username": "grafana", "token": "token"},
+			expInitError: "only one of username/password, token or NKey seed can be set",
+		}, {
+			name:         "Error when user JWT is set without NKey seed",
+			settings:     map[string]interface{}{"url": "nats://localhost:4222", "subject": "alerts", "userJwt": "jwt"},
+			expInitError: "NKey seed is required to sign the user JWT",
+		}, {
+			name:         "Error when stream is set without JetStream",
+			settings:     map[string]interface{}{"url": "nats://localhost:4222", "subject": "alerts", "stream": "ALERTS"},
+			expInitError: "stream can only be set when JetStream is enabled",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "nats_testing",
+				Type:     "nats",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewNATSConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			conn := &fakeNATSConn{ack: c.ack}
+			var connectOpts nats.Options
+			origConnect := natsConnect
+			natsConnect = func(servers string, opts ...nats.Option) (natsConn, error) {
+				require.Equal(t, "nats://localhost:4222", servers)
+				connectOpts = nats.GetDefaultOptions()
+				for _, opt := range opts {
+					require.NoError(t, opt(&connectOpts))
+				}
+				return conn, nil
+			}
+			t.Cleanup(func() { natsConnect = origConnect })
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			n := NewNATSNotifier(cfg, &UnavailableImageStore{}, tmpl)
+			ok, err := n.Notify(ctx, alerts...)
+			if c.expMsgError != "" {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.True(t, conn.closed)
+			c.expOptions(t, connectOpts)
+
+			var sent *nats.Msg
+			if cfg.JetStream {
+				require.Empty(t, conn.published)
+				require.Len(t, conn.requested, 1)
+				sent = conn.requested[0]
+			} else {
+				require.Empty(t, conn.requested)
+				require.Len(t, conn.published, 1)
+				sent = conn.published[0]
+			}
+			require.Equal(t, c.expSubject, sent.Subject)
+			if c.expStreamHdr != "" {
+				require.Equal(t, c.expStreamHdr, sent.Header.Get("Nats-Expected-Stream"))
+			}
+
+			var msg brokerMessage
+			require.NoError(t, json.Unmarshal(sent.Data, &msg))
+			require.Equal(t, "1", msg.Version)
+			require.Equal(t, "alertname", msg.GroupKey)
+			require.Equal(t, int64(1), msg.OrgID)
+			require.Equal(t, "alerting", msg.State)
+			require.Equal(t, "my_receiver", msg.Receiver)
+			require.Len(t, msg.Alerts, 1)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultNRDPHostname    = `{{ or .CommonLabels.hostname .CommonLabels.instance "grafana" }}`
+	defaultNRDPServiceName = `{{ .CommonLabels.alertname }}`
+	defaultNRDPOutput      = `{{ template "default.title" . }}`
+
+	// nrdpCheckTypePassive is the check type of the results of passive checks.
+	nrdpCheckTypePassive = "1"
+)
+
+// The states of the service and host checks.
+const (
+	nrdpServiceOK       = "0"
+	nrdpServiceCritical = "2"
+	nrdpHostUp          = "0"
+	nrdpHostDown        = "1"
+)
+
+type NRDPConfig struct {
+	*NotificationChannelConfig
+	URL         string
+	Token       string
+	Hostname    string
+	ServiceName string
+	Output      string
+}
+
+func NRDPFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewNRDPConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewNRDPNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewNRDPConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*NRDPConfig, error) {
+	rawURL := strings.TrimSpace(config.Settings.Get("url").MustString())
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL %q", rawURL)
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	return &NRDPConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		Token:                     token,
+		Hostname:                  config.Settings.Get("hostname").MustString(defaultNRDPHostname),
+		ServiceName:               config.Settings.Get("serviceName").MustString(defaultNRDPServiceName),
+		Output:                    config.Settings.Get("output").MustString(defaultNRDPOutput),
+	}, nil
+}
+
+// NewNRDPNotifier is the constructor for the Nagios NRDP notifier.
+func NewNRDPNotifier(config *NRDPConfig, ns notifications.WebhookSender, t *template.Template) *NRDPNotifier {
+	return &NRDPNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:         config.URL,
+		Token:       config.Token,
+		Hostname:    config.Hostname,
+		ServiceName: config.ServiceName,
+		Output:      config.Output,
+		log:         log.New("alerting.notifier.nrdp"),
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// NRDPNotifier is responsible for submitting the alerts as the results of passive checks to the
+// Nagios Remote Data Processor.
+type NRDPNotifier struct {
+	*Base
+	URL         string
+	Token       string
+	Hostname    string
+	ServiceName string
+	Output      string
+	log         log.Logger
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+type nrdpCheckResults struct {
+	CheckResults []nrdpCheckResult `json:"checkresults"`
+}
+
+type nrdpCheckResult struct {
+	CheckResult struct {
+		Type      string `json:"type"`
+		CheckType string `json:"checktype"`
+	} `json:"checkresult"`
+	Hostname    string `json:"hostname"`
+	ServiceName string `json:"servicename,omitempty"`
+	State       string `json:"state"`
+	Output      string `json:"output"`
+}
+
+// Notify submits a check result per alert, with the host and service names templated from the
+// labels of the alert. Firing alerts are CRITICAL and resolved alerts OK. When the service name is
+// empty, the result is the one of a host check instead, which is DOWN or UP.
+func (nn *NRDPNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	nn.log.Debug("submitting NRDP check results", "notification", nn.Name)
+
+	results := nrdpCheckResults{CheckResults: make([]nrdpCheckResult, 0, len(as))}
+	for _, a := range as {
+		var tmplErr error
+		tmpl, _ := TmplText(ctx, nn.tmpl, []*types.Alert{a}, nn.log, &tmplErr)
+		result := nrdpCheckResult{
+			Hostname:    strings.TrimSpace(tmpl(nn.Hostname)),
+			ServiceName: strings.TrimSpace(tmpl(nn.ServiceName)),
+			// The text after a pipe is the performance data of the check.
+			Output: strings.ReplaceAll(strings.TrimSpace(tmpl(nn.Output)), "|", "/"),
+		}
+		if tmplErr != nil {
+			nn.log.Warn("failed to template NRDP check result", "err", tmplErr.Error())
+		}
+		if result.Hostname == "" {
+			return false, fmt.Errorf("the host name of the alert %s is empty", a.Name())
+		}
+		result.CheckResult.CheckType = nrdpCheckTypePassive
+		if result.ServiceName != "" {
+			result.CheckResult.Type = "service"
+			result.State = nrdpServiceCritical
+			if a.Resolved() {
+				result.State = nrdpServiceOK
+			}
+		} else {
+			result.CheckResult.Type = "host"
+			result.State = nrdpHostDown
+			if a.Resolved() {
+				result.State = nrdpHostUp
+			}
+		}
+		results.CheckResults = append(results.CheckResults, result)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return false, err
+	}
+	form := url.Values{}
+	form.Set("token", nn.Token)
+	form.Set("cmd", "submitcheck")
+	form.Set("JSONDATA", string(data))
+	cmd := &models.SendWebhookSync{
+		Url:         nn.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/x-www-form-urlencoded",
+		Body:        form.Encode(),
+		Validation:  nrdpValidation,
+	}
+	if err := nn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		nn.log.Error("failed to submit NRDP check results", "err", err, "notification", nn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// nrdpValidation checks the message of the responses of NRDP, which answers in JSON or XML
+// depending on its version. The status is OK when the check results are processed.
+func nrdpValidation(body []byte, statusCode int) error {
+	var resp struct {
+		Result struct {
+			Message string `json:"message" xml:"message"`
+		} `json:"result"`
+	}
+	message := ""
+	if err := json.Unmarshal(body, &resp); err == nil {
+		message = resp.Result.Message
+	} else if err := xml.Unmarshal(body, &resp.Result); err == nil {
+		message = resp.Result.Message
+	}
+	if message != "" && message != "OK" {
+		return fmt.Errorf("NRDP rejected the check results: %s", message)
+	}
+	if statusCode/100 != 2 {
+		return fmt.Errorf("NRDP returned status %d", statusCode)
+	}
+	return nil
+}
+
+func (nn *NRDPNotifier) SendResolved() bool {
+	return !nn.GetDisableResolveMessage()
+}
@@ -0,0 +1,129 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestNRDPNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db1:9100"},
+			Annotations: model.LabelSet{"summary": "disk | full"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "HighCPU", "hostname": "web1"},
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expResults   string
+		expError     string
+		expInitError string
+	}{
+		{
+			name:     "A service check result per alert",
+			settings: `{"url": "https://nagios.example.com/nrdp/", "token": "secret", "output": "{{ .CommonAnnotations.summary }}"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expResults: `{"checkresults": [
+				{"checkresult": {"type": "service", "checktype": "1"}, "hostname": "db1:9100", "servicename": "DiskFull", "state": "2", "output": "disk / full"},
+				{"checkresult": {"type": "service", "checktype": "1"}, "hostname": "web1", "servicename": "HighCPU", "state": "0", "output": ""}
+			]}`,
+		}, {
+			name:     "Host check results without a service name",
+			settings: `{"url": "https://nagios.example.com/nrdp/", "token": "secret", "hostname": "{{ .CommonLabels.alertname }}", "serviceName": "", "output": "down"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expResults: `{"checkresults": [
+				{"checkresult": {"type": "host", "checktype": "1"}, "hostname": "DiskFull", "state": "1", "output": "down"},
+				{"checkresult": {"type": "host", "checktype": "1"}, "hostname": "HighCPU", "state": "0", "output": "down"}
+			]}`,
+		}, {
+			name:     "Error when the host name is empty",
+			settings: `{"url": "https://nagios.example.com/nrdp/", "token": "secret", "hostname": "{{ .CommonLabels.host }}"}`,
+			alerts:   []*types.Alert{firing},
+			expError: "the host name of the alert DiskFull is empty",
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{"token": "secret"}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when the URL is invalid",
+			settings:     `{"url": "nagios.example.com/nrdp", "token": "secret"}`,
+			expInitError: `invalid URL "nagios.example.com/nrdp"`,
+		}, {
+			name:         "Error when the token is missing",
+			settings:     `{"url": "https://nagios.example.com/nrdp/"}`,
+			expInitError: "could not find token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "nrdp_testing",
+				Type:     "nrdp",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewNRDPConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewNRDPNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			if c.expError != "" {
+				require.EqualError(t, err, c.expError)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://nagios.example.com/nrdp/", webhookSender.Webhook.Url)
+			require.Equal(t, "application/x-www-form-urlencoded", webhookSender.Webhook.ContentType)
+			form, err := url.ParseQuery(webhookSender.Webhook.Body)
+			require.NoError(t, err)
+			require.Equal(t, "secret", form.Get("token"))
+			require.Equal(t, "submitcheck", form.Get("cmd"))
+			require.JSONEq(t, c.expResults, form.Get("JSONDATA"))
+		})
+	}
+}
+
+func TestNRDPValidation(t *testing.T) {
+	require.NoError(t, nrdpValidation([]byte(`{"result": {"status": 0, "message": "OK", "meta": {"output": "2 checks processed."}}}`), 200))
+	require.NoError(t, nrdpValidation([]byte(`<?xml version="1.0" ?><result><status>0</status><message>OK</message></result>`), 200))
+	require.EqualError(t, nrdpValidation([]byte(`{"result": {"status": -1, "message": "BAD TOKEN"}}`), 200), "NRDP rejected the check results: BAD TOKEN")
+	require.EqualError(t, nrdpValidation([]byte(`<result><status>-1</status><message>NO DATA</message></result>`), 200), "NRDP rejected the check results: NO DATA")
+	require.EqualError(t, nrdpValidation([]byte(`bad gateway`), 502), "NRDP returned status 502")
+}
@@ -0,0 +1,242 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultNtfyURL           = "https://ntfy.sh"
+	defaultNtfySeverityLabel = "severity"
+	defaultNtfyPriority      = 3
+)
+
+// ntfyPriorities maps common values of the severity label to ntfy priorities, from 1 (min) to 5 (max).
+var ntfyPriorities = map[string]int{
+	"critical": 5,
+	"urgent":   5,
+	"page":     5,
+	"high":     4,
+	"error":    4,
+	"major":    4,
+	"warning":  3,
+	"medium":   3,
+	"low":      2,
+	"minor":    2,
+	"info":     2,
+	"none":     1,
+	"debug":    1,
+}
+
+type NtfyConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	Topic         string
+	Priority      int
+	SeverityLabel string
+	AccessToken   string
+	Title         string
+	Message       string
+}
+
+func NtfyFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewNtfyConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewNtfyNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewNtfyConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*NtfyConfig, error) {
+	topic := config.Settings.Get("topic").MustString()
+	if topic == "" {
+		return nil, errors.New("could not find topic in settings")
+	}
+
+	// The priority is a string when set from the UI and a number when provisioned.
+	priority, err := config.Settings.Get("priority").Int()
+	if err != nil {
+		priority, err = strconv.Atoi(config.Settings.Get("priority").MustString(strconv.Itoa(defaultNtfyPriority)))
+		if err != nil {
+			return nil, errors.New("invalid priority, must be between 1 and 5")
+		}
+	}
+	if priority < 1 || priority > 5 {
+		return nil, fmt.Errorf("invalid priority %d, must be between 1 and 5", priority)
+	}
+
+	return &NtfyConfig{
+		NotificationChannelConfig: config,
+		URL:                       strings.TrimSuffix(config.Settings.Get("url").MustString(defaultNtfyURL), "/"),
+		Topic:                     topic,
+		Priority:                  priority,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultNtfySeverityLabel),
+		AccessToken:               decryptFunc(context.Background(), config.SecureSettings, "accessToken", config.Settings.Get("accessToken").MustString()),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewNtfyNotifier is the constructor for the ntfy notifier.
+func NewNtfyNotifier(config *NtfyConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *NtfyNotifier {
+	return &NtfyNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:           config.URL,
+		Topic:         config.Topic,
+		Priority:      config.Priority,
+		SeverityLabel: config.SeverityLabel,
+		AccessToken:   config.AccessToken,
+		Title:         config.Title,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.ntfy"),
+		images:        images,
+		ns:            ns,
+		tmpl:          t,
+	}
+}
+
+// NtfyNotifier is responsible for sending alert notifications to ntfy.
+type NtfyNotifier struct {
+	*Base
+	URL           string
+	Topic         string
+	Priority      int
+	SeverityLabel string
+	AccessToken   string
+	Title         string
+	Message       string
+	log           log.Logger
+	images        ImageStore
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+}
+
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Attach   string   `json:"attach,omitempty"`
+}
+
+// Notify publishes the alerts to the ntfy topic.
+func (nn *NtfyNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	nn.log.Debug("sending ntfy notification", "notification", nn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, nn.tmpl, as, nn.log, &tmplErr)
+
+	message, truncated := nn.TruncateMessage(tmpl(nn.Message))
+	if truncated {
+		nn.log.Warn("truncated message", "notification", nn.Name)
+	}
+
+	msg := ntfyMessage{
+		Topic:    strings.TrimSpace(tmpl(nn.Topic)),
+		Title:    tmpl(nn.Title),
+		Message:  message,
+		Priority: nn.priority(as...),
+		Tags:     []string{"rotating_light"},
+		Click:    nn.clickURL(ctx, data),
+	}
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		msg.Tags = []string{"white_check_mark"}
+	}
+
+	_ = withStoredImages(ctx, nn.log, nn.images,
+		func(_ int, image ngmodels.Image) error {
+			if image.URL != "" {
+				msg.Attach = image.URL
+				return ErrImagesDone
+			}
+			return nil
+		}, as...)
+
+	if tmplErr != nil {
+		nn.log.Warn("failed to template ntfy message", "err", tmplErr.Error())
+	}
+	if msg.Topic == "" {
+		return false, errors.New("topic is empty after templating")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        nn.URL,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+	}
+	if nn.AccessToken != "" {
+		cmd.HttpHeader["Authorization"] = "Bearer " + nn.AccessToken
+	}
+	if err := nn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		nn.log.Error("failed to send ntfy notification", "err", err, "notification", nn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// priority returns the highest priority of the severities of the firing alerts, or the
+// configured priority if none of them has a known severity.
+func (nn *NtfyNotifier) priority(as ...*types.Alert) int {
+	priority := 0
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(nn.SeverityLabel)]))
+		if p, ok := ntfyPriorities[severity]; ok && p > priority {
+			priority = p
+		}
+	}
+	if priority == 0 {
+		return nn.Priority
+	}
+	return priority
+}
+
+// clickURL links to the alert rule when the notification has a single alert, otherwise to the
+// alert list filtered by the group.
+func (nn *NtfyNotifier) clickURL(ctx context.Context, data *ExtendedData) string {
+	if len(data.Alerts) == 1 && data.Alerts[0].GeneratorURL != "" {
+		return data.Alerts[0].GeneratorURL
+	}
+	groupLabels, _ := notify.GroupLabels(ctx)
+	return nn.AlertListURL(nn.tmpl.ExternalURL, groupLabels)
+}
+
+func (nn *NtfyNotifier) SendResolved() bool {
+	return !nn.GetDisableResolveMessage()
+}
@@ -0,0 +1,161 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestNtfyNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			Annotations:  model.LabelSet{"ann1": "annv1"},
+			GeneratorURL: "http://localhost/alerting/grafana/rule1/view",
+		},
+	}
+	warning := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "severity": "Warning"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			StartsAt: time.Now().Add(-time.Hour),
+			EndsAt:   time.Now().Add(-time.Minute),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		alerts       []*types.Alert
+		expURL       string
+		expAuth      string
+		expMsg       ntfyMessage
+		expInitError string
+	}{
+		{
+			name:     "Single alert links to the rule",
+			settings: map[string]interface{}{"topic": "grafana"},
+			alerts:   []*types.Alert{firing},
+			expURL:   "https://ntfy.sh",
+			expMsg: ntfyMessage{
+				Topic:    "grafana",
+				Title:    "[FIRING:1] alert1 (critical)",
+				Message:  "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\n - ann1 = annv1\nSource: http://localhost/alerting/grafana/rule1/view\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=severity%3Dcritical\n",
+				Priority: 5,
+				Tags:     []string{"rotating_light"},
+				Click:    "http://localhost/alerting/grafana/rule1/view",
+			},
+		}, {
+			name: "Multiple alerts link to the alert list, with server, token and custom templates",
+			settings: map[string]interface{}{
+				"url":         "https://ntfy.example.com/",
+				"topic":       "{{ .CommonLabels.alertname }}",
+				"accessToken": "tk_secret",
+				"title":       "{{ len .Alerts.Firing }} alerts",
+				"message":     "see Grafana",
+			},
+			alerts:  []*types.Alert{warning, {Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}}},
+			expURL:  "https://ntfy.example.com",
+			expAuth: "Bearer tk_secret",
+			expMsg: ntfyMessage{
+				Topic:    "alert2",
+				Title:    "2 alerts",
+				Message:  "see Grafana",
+				Priority: 3,
+				Tags:     []string{"rotating_light"},
+				Click:    "http://localhost/alerting/list?queryString=alertname%3D%22alert2%22",
+			},
+		}, {
+			name:     "Default priority without known severity",
+			settings: map[string]interface{}{"topic": "grafana", "priority": "4", "severityLabel": "level", "message": "msg"},
+			alerts:   []*types.Alert{firing},
+			expURL:   "https://ntfy.sh",
+			expMsg: ntfyMessage{
+				Topic:    "grafana",
+				Title:    "[FIRING:1] alert1 (critical)",
+				Message:  "msg",
+				Priority: 4,
+				Tags:     []string{"rotating_light"},
+				Click:    "http://localhost/alerting/grafana/rule1/view",
+			},
+		}, {
+			name:     "Resolved alerts use the default priority",
+			settings: map[string]interface{}{"topic": "grafana", "priority": 2, "message": "msg"},
+			alerts:   []*types.Alert{resolved},
+			expURL:   "https://ntfy.sh",
+			expMsg: ntfyMessage{
+				Topic:    "grafana",
+				Title:    "[RESOLVED] alert1 (critical)",
+				Message:  "msg",
+				Priority: 2,
+				Tags:     []string{"white_check_mark"},
+				Click:    "http://localhost/alerting/list?queryString=alertname%3D%22alert1%22",
+			},
+		}, {
+			name:         "Error when topic is missing",
+			settings:     map[string]interface{}{},
+			expInitError: "could not find topic in settings",
+		}, {
+			name:         "Error on invalid priority",
+			settings:     map[string]interface{}{"topic": "grafana", "priority": "6"},
+			expInitError: "invalid priority 6, must be between 1 and 5",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "ntfy_testing",
+				Type:     "ntfy",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewNtfyConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": c.alerts[0].Labels["alertname"]})
+			ns := mockNotificationService()
+			n := NewNtfyNotifier(cfg, &UnavailableImageStore{}, ns, tmpl)
+
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, ns.Webhook.Url)
+			require.Equal(t, c.expAuth, ns.Webhook.HttpHeader["Authorization"])
+
+			var msg ntfyMessage
+			require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &msg))
+			require.Equal(t, c.expMsg, msg)
+		})
+	}
+}
@@ -184,7 +184,7 @@ func (on *OpsgenieNotifier) buildOpsgenieMessage(ctx context.Context, alerts mod
 		return nil, "", nil
 	}
 
-	ruleURL := joinUrlPath(on.tmpl.ExternalURL.String(), "/alerting/list", on.log)
+	ruleURL := on.RuleListURL(on.tmpl.ExternalURL)
 
 	var tmplErr error
 	tmpl, data := TmplText(ctx, on.tmpl, as, on.log, &tmplErr)
@@ -194,10 +194,7 @@ func (on *OpsgenieNotifier) buildOpsgenieMessage(ctx context.Context, alerts mod
 		titleTmpl = `{{ template "default.title" . }}`
 	}
 
-	title := tmpl(titleTmpl)
-	if len(title) > 130 {
-		title = title[:127] + "..."
-	}
+	title, _ := on.Truncate(tmpl(titleTmpl), 130)
 
 	description := tmpl(on.Description)
 	if strings.TrimSpace(description) == "" {
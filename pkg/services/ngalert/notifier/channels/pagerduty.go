@@ -198,10 +198,8 @@ func (pn *PagerdutyNotifier) buildPagerdutyMessage(ctx context.Context, alerts m
 		},
 		as...)
 
-	if len(msg.Payload.Summary) > 1024 {
-		// This is the Pagerduty limit.
-		msg.Payload.Summary = msg.Payload.Summary[:1021] + "..."
-	}
+	// This is the Pagerduty limit.
+	msg.Payload.Summary, _ = pn.Truncate(msg.Payload.Summary, 1024)
 
 	if hostname, err := os.Hostname(); err == nil {
 		// TODO: should this be configured like in Prometheus AM?
@@ -0,0 +1,104 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const paginationSeparator = "\n\n"
+
+// AlertListURL returns the URL of the alert list page pre-filtered with label matchers
+// for the given labels, usually the group labels of the notification.
+func (n *Base) AlertListURL(externalURL *url.URL, labels model.LabelSet) string {
+	listURL := n.RuleListURL(externalURL)
+	if listURL == "" || len(labels) == 0 {
+		return listURL
+	}
+
+	u, err := url.Parse(listURL)
+	if err != nil {
+		n.log.Warn("failed to parse alert list URL", "url", listURL, "err", err)
+		return listURL
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", name, string(labels[model.LabelName(name)])))
+	}
+
+	q := u.Query()
+	q.Set("queryString", strings.Join(matchers, ","))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// PaginationFooter returns the line added to messages that only show some of the alerts of the group.
+func PaginationFooter(shown, total int, viewAllURL string) string {
+	if viewAllURL == "" {
+		return fmt.Sprintf("Showing %d of %d alerts", shown, total)
+	}
+	return fmt.Sprintf("Showing %d of %d alerts — view all: %s", shown, total, viewAllURL)
+}
+
+// FitAlerts renders the message for as many alerts as fit in the maximum message length of
+// the notifier type. When some of the alerts are left out, a pagination footer that links to
+// the alert list filtered by the group labels is appended to the message. If not even one
+// alert fits, the message is truncated. It returns the message and the number of alerts shown.
+func (n *Base) FitAlerts(ctx context.Context, externalURL *url.URL, as []*types.Alert, render func(alerts []*types.Alert) string) (string, int) {
+	maxLength := n.capabilities.MaxMessageLength
+	msg := render(as)
+	if maxLength <= 0 || utf8.RuneCountInString(msg) <= maxLength {
+		return msg, len(as)
+	}
+	if len(as) <= 1 {
+		msg, _ = n.TruncateMessage(msg)
+		return msg, len(as)
+	}
+
+	groupLabels, _ := notify.GroupLabels(ctx)
+	viewAllURL := n.AlertListURL(externalURL, groupLabels)
+	withFooter := func(shown int) string {
+		return render(as[:shown]) + paginationSeparator + PaginationFooter(shown, len(as), viewAllURL)
+	}
+
+	// Messages grow with the number of alerts, so we can look for the largest page that fits.
+	shown, best := 0, ""
+	lo, hi := 1, len(as)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		if m := withFooter(mid); utf8.RuneCountInString(m) <= maxLength {
+			shown, best = mid, m
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if shown > 0 {
+		n.log.Debug("message shows a subset of the alerts", "shown", shown, "total", len(as))
+		return best, shown
+	}
+
+	// Not even a single alert fits: truncate its message but keep the footer.
+	footer := paginationSeparator + PaginationFooter(1, len(as), viewAllURL)
+	available := maxLength - utf8.RuneCountInString(footer)
+	if available <= len(truncationSuffix) {
+		msg, _ = n.TruncateMessage(render(as[:1]))
+		return msg, 1
+	}
+	msg, _ = n.Truncate(render(as[:1]), available)
+	return msg + footer, 1
+}
@@ -0,0 +1,87 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestBase_AlertListURL(t *testing.T) {
+	b := NewBase(&models.AlertNotification{Name: "pagination_testing"})
+
+	externalURL, err := url.Parse("http://localhost:3000/grafana")
+	require.NoError(t, err)
+
+	require.Equal(t, "http://localhost:3000/grafana/alerting/list", b.AlertListURL(externalURL, nil))
+	require.Equal(t,
+		"http://localhost:3000/grafana/alerting/list?queryString=alertname%3D%22High+CPU%22%2Cteam%3D%22ops%22",
+		b.AlertListURL(externalURL, model.LabelSet{"team": "ops", "alertname": "High CPU"}),
+	)
+	require.Equal(t, "", b.AlertListURL(nil, model.LabelSet{"team": "ops"}))
+}
+
+func TestPaginationFooter(t *testing.T) {
+	require.Equal(t, "Showing 5 of 83 alerts — view all: http://localhost/alerting/list", PaginationFooter(5, 83, "http://localhost/alerting/list"))
+	require.Equal(t, "Showing 5 of 83 alerts", PaginationFooter(5, 83, ""))
+}
+
+func TestBase_FitAlerts(t *testing.T) {
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	ctx := notify.WithGroupLabels(context.Background(), model.LabelSet{"team": "ops"})
+	viewAllURL := "http://localhost/alerting/list?queryString=team%3D%22ops%22"
+
+	alerts := make([]*types.Alert, 0, 83)
+	for i := 0; i < 83; i++ {
+		alerts = append(alerts, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))}}})
+	}
+	render := func(lineLength int) func(as []*types.Alert) string {
+		return func(as []*types.Alert) string {
+			var sb strings.Builder
+			for _, a := range as {
+				sb.WriteString(fmt.Sprintf("%-*s\n", lineLength-1, a.Labels["alertname"]))
+			}
+			return sb.String()
+		}
+	}
+
+	t.Run("all alerts are shown if they fit", func(t *testing.T) {
+		b := NewBase(&models.AlertNotification{Name: "pagination_testing", Type: "line"})
+		msg, shown := b.FitAlerts(ctx, externalURL, alerts[:5], render(10))
+		require.Equal(t, 5, shown)
+		require.Equal(t, render(10)(alerts[:5]), msg)
+	})
+
+	t.Run("only alerts that fit are shown with a footer", func(t *testing.T) {
+		b := NewBase(&models.AlertNotification{Name: "pagination_testing", Type: "line"})
+		msg, shown := b.FitAlerts(ctx, externalURL, alerts, render(100))
+		require.Equal(t, 9, shown)
+		require.Equal(t, render(100)(alerts[:9])+"\n\n"+PaginationFooter(9, 83, viewAllURL), msg)
+		require.LessOrEqual(t, utf8.RuneCountInString(msg), 1000)
+	})
+
+	t.Run("a single alert that does not fit is truncated", func(t *testing.T) {
+		b := NewBase(&models.AlertNotification{Name: "pagination_testing", Type: "line"})
+		msg, shown := b.FitAlerts(ctx, externalURL, alerts, render(2000))
+		require.Equal(t, 1, shown)
+		require.Equal(t, 1000, utf8.RuneCountInString(msg))
+		require.True(t, strings.HasSuffix(msg, "...\n\n"+PaginationFooter(1, 83, viewAllURL)))
+	})
+
+	t.Run("notifiers without a maximum length show all alerts", func(t *testing.T) {
+		b := NewBase(&models.AlertNotification{Name: "pagination_testing", Type: "webhook"})
+		msg, shown := b.FitAlerts(ctx, externalURL, alerts, render(100))
+		require.Equal(t, 83, shown)
+		require.Equal(t, render(100)(alerts), msg)
+	})
+}
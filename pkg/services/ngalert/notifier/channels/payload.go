@@ -0,0 +1,103 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// payloadVersionSetting is the contact point setting holding the version of the JSON payload.
+	// It is ignored by the contact points whose payload is defined by the service they notify.
+	payloadVersionSetting = "payloadVersion"
+
+	// PayloadVersion1 is the original JSON payload.
+	PayloadVersion1 = "1"
+	// PayloadVersion2 adds a structured grafana block to each alert, so that receivers do not
+	// have to parse the generator URL or the labels to find the rule of an alert.
+	PayloadVersion2 = "2"
+)
+
+// GrafanaMetadata describes where an alert comes from in Grafana.
+type GrafanaMetadata struct {
+	OrgID          int64    `json:"orgId"`
+	OrgName        string   `json:"orgName,omitempty"`
+	FolderUID      string   `json:"folderUid,omitempty"`
+	FolderTitle    string   `json:"folderTitle,omitempty"`
+	RuleUID        string   `json:"ruleUid,omitempty"`
+	RuleURL        string   `json:"ruleUrl,omitempty"`
+	DatasourceUIDs []string `json:"datasourceUids,omitempty"`
+}
+
+type orgNameKey struct{}
+
+// WithOrgName returns a copy of the context with the name of the organization.
+func WithOrgName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, orgNameKey{}, name)
+}
+
+// OrgNameFromContext returns the name of the organization stored in the context, if any.
+func OrgNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(orgNameKey{}).(string)
+	return name
+}
+
+// payloadVersionFromSettings returns the payload version of a contact point, PayloadVersion1
+// if it is not set.
+func payloadVersionFromSettings(settings *simplejson.Json) (string, error) {
+	if settings == nil {
+		return PayloadVersion1, nil
+	}
+	switch v := settings.Get(payloadVersionSetting).MustString(); v {
+	case "", PayloadVersion1:
+		return PayloadVersion1, nil
+	case PayloadVersion2:
+		return PayloadVersion2, nil
+	default:
+		return "", fmt.Errorf("invalid payload version %q, must be %s or %s", v, PayloadVersion1, PayloadVersion2)
+	}
+}
+
+// grafanaMetadata returns the metadata of an alert from its reserved labels and annotations, or
+// nil if the alert has none. It must be called before they are removed.
+func grafanaMetadata(labels, annotations map[string]string, externalURL string) *GrafanaMetadata {
+	m := &GrafanaMetadata{
+		FolderUID:   labels[ngmodels.NamespaceUIDLabel],
+		FolderTitle: labels[ngmodels.FolderTitleLabel],
+		RuleUID:     labels[ngmodels.RuleUIDLabel],
+	}
+	if uids := annotations[ngmodels.DatasourceUIDsAnnotation]; uids != "" {
+		m.DatasourceUIDs = strings.Split(uids, ",")
+	}
+	if m.FolderUID == "" && m.FolderTitle == "" && m.RuleUID == "" && len(m.DatasourceUIDs) == 0 {
+		return nil
+	}
+	if m.RuleUID != "" && externalURL != "" {
+		if u, err := url.Parse(externalURL); err == nil {
+			u.Path = path.Join(u.Path, "/alerting/grafana/", m.RuleUID, "/view")
+			m.RuleURL = u.String()
+		}
+	}
+	return m
+}
+
+// setPayloadVersion adds the grafana block to the alerts of the payload from version 2.
+func (d *ExtendedData) setPayloadVersion(ctx context.Context, version string, orgID int64) {
+	if version != PayloadVersion2 {
+		return
+	}
+	orgName := OrgNameFromContext(ctx)
+	for i := range d.Alerts {
+		m := GrafanaMetadata{OrgID: orgID, OrgName: orgName}
+		if d.Alerts[i].grafana != nil {
+			m = *d.Alerts[i].grafana
+			m.OrgID, m.OrgName = orgID, orgName
+		}
+		d.Alerts[i].Grafana = &m
+	}
+}
@@ -0,0 +1,192 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// PayloadValidator estimates the size and checks the structure of the final payload of a
+// request to a provider against the limits of the provider. It returns the limits exceeded, so
+// that the templates can be fixed, instead of the request being rejected with an opaque error.
+type PayloadValidator func(cmd *models.SendWebhookSync) []string
+
+// payloadValidators are the validators of the providers by integration type.
+var payloadValidators = map[string]PayloadValidator{
+	"discord":    validateDiscordPayload,
+	"googlechat": maxPayloadSizeValidator("Google Chat", 32000),
+	"slack":      validateSlackPayload,
+	"teams":      maxPayloadSizeValidator("Microsoft Teams", 28*1024),
+}
+
+// PayloadLimitError is returned instead of sending a payload that exceeds the limits of the
+// provider.
+type PayloadLimitError struct {
+	Integration string
+	Problems    []string
+}
+
+func (e PayloadLimitError) Error() string {
+	return fmt.Sprintf("the payload exceeds the limits of the %s integration, shorten the templates of the contact point: %s", e.Integration, strings.Join(e.Problems, "; "))
+}
+
+// validatePayload validates the request with the validator of the integration type, if any.
+func validatePayload(integrationType string, cmd *models.SendWebhookSync) error {
+	validate, ok := payloadValidators[integrationType]
+	if !ok {
+		return nil
+	}
+	if problems := validate(cmd); len(problems) > 0 {
+		return PayloadLimitError{Integration: integrationType, Problems: problems}
+	}
+	return nil
+}
+
+// validatingNotificationService validates the webhooks of an integration before sending them.
+type validatingNotificationService struct {
+	notifications.Service
+	integrationType string
+}
+
+func (s *validatingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	if err := validatePayload(s.integrationType, cmd); err != nil {
+		return err
+	}
+	return s.Service.SendWebhookSync(ctx, cmd)
+}
+
+func maxPayloadSizeValidator(provider string, maxBytes int) PayloadValidator {
+	return func(cmd *models.SendWebhookSync) []string {
+		if len(cmd.Body) > maxBytes {
+			return []string{fmt.Sprintf("the payload is %d bytes, %s accepts at most %d bytes", len(cmd.Body), provider, maxBytes)}
+		}
+		return nil
+	}
+}
+
+// checkLength returns the problem of a text field longer than max characters, if any.
+func checkLength(problems []string, field, value string, max int) []string {
+	if n := utf8.RuneCountInString(value); n > max {
+		return append(problems, fmt.Sprintf("%s is %d characters long, the limit is %d", field, n, max))
+	}
+	return problems
+}
+
+// The limits of the messages of Slack.
+const (
+	slackMaxBlocks          = 50
+	slackMaxAttachments     = 100
+	slackMaxSectionText     = 3000
+	slackMaxHeaderText      = 150
+	slackMaxAttachmentTitle = 1024
+)
+
+func validateSlackPayload(cmd *models.SendWebhookSync) []string {
+	var msg struct {
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+		Attachments []struct {
+			Title string `json:"title"`
+		} `json:"attachments"`
+	}
+	// Payloads that are not messages, such as the ones reshaped by a payload transformer, are
+	// left to Slack.
+	if err := json.Unmarshal([]byte(cmd.Body), &msg); err != nil {
+		return nil
+	}
+
+	var problems []string
+	if len(msg.Blocks) > slackMaxBlocks {
+		problems = append(problems, fmt.Sprintf("the message has %d blocks, the limit is %d", len(msg.Blocks), slackMaxBlocks))
+	}
+	for i, b := range msg.Blocks {
+		switch b.Type {
+		case "section":
+			problems = checkLength(problems, fmt.Sprintf("the text of blocks[%d]", i), b.Text.Text, slackMaxSectionText)
+		case "header":
+			problems = checkLength(problems, fmt.Sprintf("the text of blocks[%d]", i), b.Text.Text, slackMaxHeaderText)
+		}
+	}
+	if len(msg.Attachments) > slackMaxAttachments {
+		problems = append(problems, fmt.Sprintf("the message has %d attachments, the limit is %d", len(msg.Attachments), slackMaxAttachments))
+	}
+	for i, a := range msg.Attachments {
+		problems = checkLength(problems, fmt.Sprintf("the title of attachments[%d]", i), a.Title, slackMaxAttachmentTitle)
+	}
+	return problems
+}
+
+// The limits of the messages of Discord.
+const (
+	discordMaxContent          = 2000
+	discordMaxUsername         = 80
+	discordMaxEmbedTitle       = 256
+	discordMaxEmbedDescription = 4096
+	discordMaxEmbedFooter      = 2048
+	// discordMaxEmbedsText is the limit of the text of all the embeds of a message together.
+	discordMaxEmbedsText = 6000
+)
+
+func validateDiscordPayload(cmd *models.SendWebhookSync) []string {
+	body := []byte(cmd.Body)
+	// The messages with uploaded images are multipart forms, with the message in payload_json.
+	if mediaType, params, err := mime.ParseMediaType(cmd.ContentType); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		body = nil
+		r := multipart.NewReader(strings.NewReader(cmd.Body), params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if err != nil {
+				break
+			}
+			if p.FormName() == "payload_json" {
+				body, _ = io.ReadAll(p)
+				break
+			}
+		}
+	}
+
+	var msg struct {
+		Content  string `json:"content"`
+		Username string `json:"username"`
+		Embeds   []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Footer      struct {
+				Text string `json:"text"`
+			} `json:"footer"`
+		} `json:"embeds"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil
+	}
+
+	var problems []string
+	problems = checkLength(problems, "the content", msg.Content, discordMaxContent)
+	problems = checkLength(problems, "the username", msg.Username, discordMaxUsername)
+	if len(msg.Embeds) > DiscordMaxEmbeds {
+		problems = append(problems, fmt.Sprintf("the message has %d embeds, the limit is %d", len(msg.Embeds), DiscordMaxEmbeds))
+	}
+	embedsText := 0
+	for i, e := range msg.Embeds {
+		problems = checkLength(problems, fmt.Sprintf("the title of embeds[%d]", i), e.Title, discordMaxEmbedTitle)
+		problems = checkLength(problems, fmt.Sprintf("the description of embeds[%d]", i), e.Description, discordMaxEmbedDescription)
+		problems = checkLength(problems, fmt.Sprintf("the footer of embeds[%d]", i), e.Footer.Text, discordMaxEmbedFooter)
+		embedsText += utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description) + utf8.RuneCountInString(e.Footer.Text)
+	}
+	if embedsText > discordMaxEmbedsText {
+		problems = append(problems, fmt.Sprintf("the embeds have %d characters, the limit is %d", embedsText, discordMaxEmbedsText))
+	}
+	return problems
+}
@@ -0,0 +1,104 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestValidatePayload(t *testing.T) {
+	jsonBody := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+	block := map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": "text"}}
+	manyBlocks := make([]interface{}, 51)
+	for i := range manyBlocks {
+		manyBlocks[i] = block
+	}
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	require.NoError(t, w.WriteField("payload_json", jsonBody(map[string]interface{}{"content": strings.Repeat("a", 2001)})))
+	require.NoError(t, w.Close())
+
+	cases := []struct {
+		name            string
+		integrationType string
+		cmd             *models.SendWebhookSync
+		expError        string
+	}{
+		{
+			name:            "Slack message within the limits",
+			integrationType: "slack",
+			cmd:             &models.SendWebhookSync{Body: jsonBody(map[string]interface{}{"blocks": []interface{}{block}})},
+		}, {
+			name:            "Slack message with too many blocks and a long section",
+			integrationType: "slack",
+			cmd: &models.SendWebhookSync{Body: jsonBody(map[string]interface{}{"blocks": append(manyBlocks,
+				map[string]interface{}{"type": "section", "text": map[string]string{"text": strings.Repeat("a", 3001)}})})},
+			expError: "the payload exceeds the limits of the slack integration, shorten the templates of the contact point: " +
+				"the message has 52 blocks, the limit is 50; the text of blocks[51] is 3001 characters long, the limit is 3000",
+		}, {
+			name:            "Discord embeds over the limits",
+			integrationType: "discord",
+			cmd: &models.SendWebhookSync{Body: jsonBody(map[string]interface{}{"embeds": []interface{}{
+				map[string]interface{}{"title": strings.Repeat("é", 257), "description": strings.Repeat("a", 4000)},
+				map[string]interface{}{"description": strings.Repeat("a", 2000)},
+			}})},
+			expError: "the payload exceeds the limits of the discord integration, shorten the templates of the contact point: " +
+				"the title of embeds[0] is 257 characters long, the limit is 256; the embeds have 6257 characters, the limit is 6000",
+		}, {
+			name:            "Discord multipart message with a long content",
+			integrationType: "discord",
+			cmd:             &models.SendWebhookSync{Body: form.String(), ContentType: w.FormDataContentType()},
+			expError: "the payload exceeds the limits of the discord integration, shorten the templates of the contact point: " +
+				"the content is 2001 characters long, the limit is 2000",
+		}, {
+			name:            "Teams payload too large",
+			integrationType: "teams",
+			cmd:             &models.SendWebhookSync{Body: strings.Repeat("a", 28*1024+1)},
+			expError: "the payload exceeds the limits of the teams integration, shorten the templates of the contact point: " +
+				"the payload is 28673 bytes, Microsoft Teams accepts at most 28672 bytes",
+		}, {
+			name:            "Payloads that are not JSON are left to the provider",
+			integrationType: "slack",
+			cmd:             &models.SendWebhookSync{Body: "text"},
+		}, {
+			name:            "Integrations without a validator",
+			integrationType: "webhook",
+			cmd:             &models.SendWebhookSync{Body: strings.Repeat("a", 1<<20)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validatePayload(c.integrationType, c.cmd)
+			if c.expError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, c.expError)
+		})
+	}
+}
+
+func TestValidatingNotificationService(t *testing.T) {
+	ns := mockNotificationService()
+	s := &validatingNotificationService{Service: ns, integrationType: "googlechat"}
+
+	err := s.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "http://localhost", Body: strings.Repeat("a", 32001)})
+	require.ErrorAs(t, err, &PayloadLimitError{})
+	require.Empty(t, ns.Webhook.Url)
+
+	require.NoError(t, s.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "http://localhost", Body: "{}"}))
+	require.Equal(t, "http://localhost", ns.Webhook.Url)
+}
@@ -0,0 +1,90 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestPayloadVersion(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{
+				"alertname":                "alert1",
+				ngmodels.RuleUIDLabel:      "rule-uid",
+				ngmodels.NamespaceUIDLabel: "folder-uid",
+				ngmodels.FolderTitleLabel:  "Production",
+			},
+			Annotations: model.LabelSet{ngmodels.DatasourceUIDsAnnotation: "loki,prometheus"},
+		},
+	}
+
+	send := func(t *testing.T, settings map[string]interface{}) map[string]interface{} {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		ns := mockNotificationService()
+		fc, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: simplejson.NewFromAny(settings),
+		}, ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.NoError(t, err)
+		n, err := WebHookFactory(fc)
+		require.NoError(t, err)
+
+		ctx := WithOrgName(notify.WithGroupKey(context.Background(), "alertname"), "Main Org.")
+		ok, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		return body
+	}
+
+	t.Run("version 1 has no grafana block", func(t *testing.T) {
+		body := send(t, map[string]interface{}{"url": "http://localhost/test"})
+		require.Equal(t, "1", body["version"])
+		require.NotContains(t, body["alerts"].([]interface{})[0], "grafana")
+	})
+
+	t.Run("version 2 describes where the alerts come from", func(t *testing.T) {
+		body := send(t, map[string]interface{}{"url": "http://localhost/test", "payloadVersion": "2"})
+		require.Equal(t, "2", body["version"])
+		require.Equal(t, map[string]interface{}{
+			"orgId":          float64(0),
+			"orgName":        "Main Org.",
+			"folderUid":      "folder-uid",
+			"folderTitle":    "Production",
+			"ruleUid":        "rule-uid",
+			"ruleUrl":        "http://localhost/grafana/alerting/grafana/rule-uid/view",
+			"datasourceUids": []interface{}{"loki", "prometheus"},
+		}, body["alerts"].([]interface{})[0].(map[string]interface{})["grafana"])
+	})
+
+	t.Run("error with an invalid version", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		_, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost/test", "payloadVersion": "3"}),
+		}, mockNotificationService(), secretsService.GetDecryptedValue, tmpl, nil)
+		require.EqualError(t, err, `invalid payload version "3", must be 1 or 2`)
+	})
+}
@@ -0,0 +1,173 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The formats of the payloads of the Power Automate notifier.
+	powerAutomateFormatAdaptiveCard = "adaptiveCard"
+	powerAutomateFormatJSON         = "json"
+)
+
+type PowerAutomateConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	PayloadFormat string
+	Title         string
+	Message       string
+}
+
+func PowerAutomateFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewPowerAutomateConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewPowerAutomateNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
+}
+
+func NewPowerAutomateConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*PowerAutomateConfig, error) {
+	rawURL := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString()))
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" {
+		return nil, errors.New("invalid URL of the flow, must be the HTTPS URL of its HTTP trigger")
+	}
+	format := config.Settings.Get("payloadFormat").MustString(powerAutomateFormatAdaptiveCard)
+	switch format {
+	case "":
+		format = powerAutomateFormatAdaptiveCard
+	case powerAutomateFormatAdaptiveCard, powerAutomateFormatJSON:
+	default:
+		return nil, fmt.Errorf("invalid payload format %q, must be adaptiveCard or json", format)
+	}
+	return &PowerAutomateConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		PayloadFormat:             format,
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "teams.default.message" .}}`),
+	}, nil
+}
+
+// NewPowerAutomateNotifier is the constructor for the Power Automate notifier.
+func NewPowerAutomateNotifier(config *PowerAutomateConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *PowerAutomateNotifier {
+	return &PowerAutomateNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:         config.OrgID,
+		URL:           config.URL,
+		PayloadFormat: config.PayloadFormat,
+		Title:         config.Title,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.powerautomate"),
+		ns:            ns,
+		images:        images,
+		tmpl:          t,
+	}
+}
+
+// PowerAutomateNotifier is responsible for triggering the Power Automate flows that start with
+// the "When a HTTP request is received" trigger, such as the Teams workflows that replace the
+// incoming webhooks of Office 365 connectors.
+type PowerAutomateNotifier struct {
+	*Base
+	orgID         int64
+	URL           string
+	PayloadFormat string
+	Title         string
+	Message       string
+	log           log.Logger
+	ns            notifications.WebhookSender
+	images        ImageStore
+	tmpl          *template.Template
+}
+
+// Notify triggers the flow with the notification. In the adaptiveCard format, it is an Adaptive
+// Card in the attachments of a message, like the ones of the incoming webhooks of Teams, which the
+// "Post to a channel when a webhook request is received" template of Teams posts as is. In the
+// json format, it is the JSON message of the notification, for flows that use its fields.
+func (pn *PowerAutomateNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	pn.log.Debug("executing Power Automate notification", "notification", pn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+
+	var payload interface{}
+	if pn.PayloadFormat == powerAutomateFormatJSON {
+		groupKey, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		payload = newBrokerMessage(ctx, pn.PayloadVersion(), tmpl, data, groupKey.String(), pn.orgID, as...)
+	} else {
+		title := tmpl(pn.Title)
+		msg := NewAdaptiveCardsMessage(newAlertAdaptiveCard(ctx, pn.log, pn.images, title, tmpl(pn.Message), pn.RuleListURL(pn.tmpl.ExternalURL), as))
+		msg.Summary = title
+		payload = msg
+	}
+	if tmplErr != nil {
+		pn.log.Warn("failed to template Power Automate message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         pn.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+		Validation:  powerAutomateValidation,
+	}
+	if err := pn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		pn.log.Error("failed to send Power Automate notification", "err", err, "notification", pn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// powerAutomateValidation returns the errors of Power Automate, such as an expired signature of
+// the URL or a disabled flow.
+func powerAutomateValidation(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var resp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error.Code != "" {
+		return fmt.Errorf("Power Automate returned status %d: %s: %s", statusCode, resp.Error.Code, resp.Error.Message)
+	}
+	return fmt.Errorf("Power Automate returned status %d", statusCode)
+}
+
+func (pn *PowerAutomateNotifier) SendResolved() bool {
+	return !pn.GetDisableResolveMessage()
+}
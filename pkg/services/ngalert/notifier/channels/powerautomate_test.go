@@ -0,0 +1,126 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestPowerAutomateNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	flowURL := "https://prod-00.westeurope.logic.azure.com:443/workflows/abc/triggers/manual/paths/invoke?api-version=2016-06-01&sig=secret"
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			Annotations: model.LabelSet{"summary": "db-1 is full"},
+		},
+	}
+
+	cases := []struct {
+		name          string
+		settings      string
+		expBody       string
+		expBodyFields []string
+		expInitError  string
+	}{
+		{
+			name:     "Adaptive Card by default",
+			settings: `{"url": "` + flowURL + `", "title": "{{ .CommonLabels.alertname }}", "message": "{{ .CommonAnnotations.summary }}"}`,
+			expBody: `{
+				"type": "message",
+				"summary": "DiskFull",
+				"attachments": [{
+					"contentType": "application/vnd.microsoft.card.adaptive",
+					"content": {
+						"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+						"type": "AdaptiveCard",
+						"version": "1.4",
+						"msTeams": {"width": "Full"},
+						"body": [
+							{"type": "TextBlock", "text": "DiskFull", "color": "attention", "size": "large", "weight": "bolder", "wrap": true},
+							{"type": "TextBlock", "text": "db-1 is full", "wrap": true},
+							{"type": "ActionSet", "actions": [{"type": "Action.OpenUrl", "title": "View URL", "url": "http://localhost/alerting/list"}]}
+						]
+					}
+				}]
+			}`,
+		}, {
+			name:          "JSON message",
+			settings:      `{"url": "` + flowURL + `", "payloadFormat": "json"}`,
+			expBodyFields: []string{"alerts", "state", "title", "message", "groupKey"},
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when the URL is not HTTPS",
+			settings:     `{"url": "http://prod-00.westeurope.logic.azure.com/workflows/abc"}`,
+			expInitError: "invalid URL of the flow, must be the HTTPS URL of its HTTP trigger",
+		}, {
+			name:         "Error when the payload format is invalid",
+			settings:     `{"url": "` + flowURL + `", "payloadFormat": "messageCard"}`,
+			expInitError: `invalid payload format "messageCard", must be adaptiveCard or json`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "powerautomate_testing",
+				Type:     "powerautomate",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewPowerAutomateConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewPowerAutomateNotifier(cfg, webhookSender, &UnavailableImageStore{}, tmpl).Notify(ctx, alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, flowURL, webhookSender.Webhook.Url)
+			if c.expBody != "" {
+				require.JSONEq(t, c.expBody, webhookSender.Webhook.Body)
+			}
+			if c.expBodyFields != nil {
+				var body map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+				for _, f := range c.expBodyFields {
+					require.Contains(t, body, f)
+				}
+			}
+		})
+	}
+}
+
+func TestPowerAutomateValidation(t *testing.T) {
+	require.NoError(t, powerAutomateValidation(nil, 202))
+	require.EqualError(t, powerAutomateValidation([]byte(`{"error":{"code":"WorkflowTriggerIsNotEnabled","message":"The workflow is disabled."}}`), 409),
+		"Power Automate returned status 409: WorkflowTriggerIsNotEnabled: The workflow is disabled.")
+	require.EqualError(t, powerAutomateValidation([]byte("Bad Gateway"), 502), "Power Automate returned status 502")
+}
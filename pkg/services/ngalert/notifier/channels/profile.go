@@ -0,0 +1,117 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+type dispatchProfileKey int
+
+const dispatchProfileCtxKey dispatchProfileKey = iota
+
+// DispatchProfile records where the time is spent while an integration delivers a notification,
+// including all its retries. Stages that are not recorded, such as publishing to a message
+// broker, are the remainder of the total time of the dispatch.
+type DispatchProfile struct {
+	mtx      sync.Mutex
+	template time.Duration
+	image    time.Duration
+	send     time.Duration
+	attempts int
+	// statusCode is the status code of the response to the last webhook, if any.
+	statusCode int
+}
+
+// DispatchProfileBreakdown is a snapshot of a DispatchProfile.
+type DispatchProfileBreakdown struct {
+	Template   time.Duration
+	Image      time.Duration
+	Send       time.Duration
+	Attempts   int
+	StatusCode int
+}
+
+// WithDispatchProfile returns a context in which the integrations record their time breakdown in the profile.
+func WithDispatchProfile(ctx context.Context, p *DispatchProfile) context.Context {
+	return context.WithValue(ctx, dispatchProfileCtxKey, p)
+}
+
+// DispatchProfileFromContext returns the profile of the dispatch, if any.
+func DispatchProfileFromContext(ctx context.Context) (*DispatchProfile, bool) {
+	p, ok := ctx.Value(dispatchProfileCtxKey).(*DispatchProfile)
+	return p, ok && p != nil
+}
+
+// Attempt records an attempt to deliver the notification.
+func (p *DispatchProfile) Attempt() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.attempts++
+}
+
+// Breakdown returns the time recorded so far.
+func (p *DispatchProfile) Breakdown() DispatchProfileBreakdown {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return DispatchProfileBreakdown{
+		Template:   p.template,
+		Image:      p.image,
+		Send:       p.send,
+		Attempts:   p.attempts,
+		StatusCode: p.statusCode,
+	}
+}
+
+// respond records the status code of the response to a webhook.
+func (p *DispatchProfile) respond(statusCode int) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.statusCode = statusCode
+}
+
+// observe adds the time since start to the stage of the profile in the context, if any.
+func observe(ctx context.Context, stage func(p *DispatchProfile) *time.Duration, start time.Time) {
+	p, ok := DispatchProfileFromContext(ctx)
+	if !ok {
+		return
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	*stage(p) += time.Since(start)
+}
+
+func templateStage(p *DispatchProfile) *time.Duration { return &p.template }
+func imageStage(p *DispatchProfile) *time.Duration    { return &p.image }
+func sendStage(p *DispatchProfile) *time.Duration     { return &p.send }
+
+// profilingNotificationService records the time spent sending webhooks and emails, and the
+// status code of the responses to the webhooks.
+type profilingNotificationService struct {
+	notifications.Service
+}
+
+func (s *profilingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	defer observe(ctx, sendStage, time.Now())
+	p, ok := DispatchProfileFromContext(ctx)
+	if !ok {
+		return s.Service.SendWebhookSync(ctx, cmd)
+	}
+	profiledCmd := *cmd
+	profiledCmd.Validation = func(body []byte, statusCode int) error {
+		p.respond(statusCode)
+		if cmd.Validation != nil {
+			return cmd.Validation(body, statusCode)
+		}
+		return nil
+	}
+	return s.Service.SendWebhookSync(ctx, &profiledCmd)
+}
+
+func (s *profilingNotificationService) SendEmailCommandHandlerSync(ctx context.Context, cmd *models.SendEmailCommandSync) error {
+	defer observe(ctx, sendStage, time.Now())
+	return s.Service.SendEmailCommandHandlerSync(ctx, cmd)
+}
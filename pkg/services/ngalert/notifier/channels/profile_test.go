@@ -0,0 +1,87 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestDispatchProfile(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "test-image-1"},
+		},
+	}}
+
+	t.Run("nothing is recorded without a profile", func(t *testing.T) {
+		var tmplErr error
+		expand, _ := TmplText(context.Background(), tmpl, alerts, log.New("test"), &tmplErr)
+		require.Equal(t, "alert1", expand(`{{ .CommonLabels.alertname }}`))
+		require.NoError(t, tmplErr)
+	})
+
+	t.Run("template, image and send stages are recorded", func(t *testing.T) {
+		p := &DispatchProfile{}
+		ctx := WithDispatchProfile(context.Background(), p)
+		ctx = notify.WithGroupKey(ctx, "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		fc, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost/test"}),
+		}, mockNotificationService(), secretsService.GetDecryptedValue, tmpl, newFakeImageStore(1))
+		require.NoError(t, err)
+		n, err := WebHookFactory(fc)
+		require.NoError(t, err)
+
+		p.Attempt()
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		breakdown := p.Breakdown()
+		require.Equal(t, 1, breakdown.Attempts)
+		require.Greater(t, breakdown.Template.Nanoseconds(), int64(0))
+		require.Greater(t, breakdown.Image.Nanoseconds(), int64(0))
+		require.Greater(t, breakdown.Send.Nanoseconds(), int64(0))
+	})
+	t.Run("the status code of the last response is recorded", func(t *testing.T) {
+		p := &DispatchProfile{}
+		ctx := WithDispatchProfile(context.Background(), p)
+		ns := &responsesNotificationService{notificationServiceMock: mockNotificationService(), statusCodes: []int{503, 502, 200}}
+		s := &profilingNotificationService{Service: &retryingNotificationService{
+			Service: ns,
+			policy:  RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, StatusCodes: []int{5}},
+			log:     log.New("test"),
+		}}
+		var validated []int
+		cmd := &models.SendWebhookSync{Validation: func(_ []byte, statusCode int) error {
+			validated = append(validated, statusCode)
+			return nil
+		}}
+		require.NoError(t, s.SendWebhookSync(ctx, cmd))
+		require.Equal(t, 200, p.Breakdown().StatusCode)
+		require.Equal(t, []int{503, 502, 200}, validated)
+	})
+}
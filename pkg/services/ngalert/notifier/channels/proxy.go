@@ -0,0 +1,105 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The contact point settings of the egress proxy.
+	proxyURLSetting = "proxyUrl"
+	noProxySetting  = "noProxy"
+)
+
+// ProxyConfig is the egress proxy of a contact point. It replaces the proxies of the environment
+// of Grafana for the requests of the contact point.
+type ProxyConfig struct {
+	// URL is the HTTP, HTTPS or SOCKS5 URL of the proxy.
+	URL *url.URL
+	// NoProxy is the comma-separated list of the hosts, domains, IP addresses and CIDR ranges
+	// that are reached directly, in the format of the NO_PROXY environment variable.
+	NoProxy string
+}
+
+// proxyFromSettings returns the egress proxy of a contact point, or nil if it does not set one.
+func proxyFromSettings(settings *simplejson.Json) (*ProxyConfig, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	rawURL := strings.TrimSpace(settings.Get(proxyURLSetting).MustString())
+	noProxy := strings.TrimSpace(settings.Get(noProxySetting).MustString())
+	if rawURL == "" {
+		if noProxy != "" {
+			return nil, fmt.Errorf("%s requires %s", noProxySetting, proxyURLSetting)
+		}
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q, must be an URL such as http://proxy.example.com:3128", proxyURLSetting, rawURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid %s %q, the scheme must be http, https or socks5", proxyURLSetting, rawURL)
+	}
+	return &ProxyConfig{URL: u, NoProxy: noProxy}, nil
+}
+
+// ProxyFunc returns the proxy of each request, or nil for the requests to the hosts of NoProxy
+// and to localhost.
+func (c *ProxyConfig) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  c.URL.String(),
+		HTTPSProxy: c.URL.String(),
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxy(r.URL)
+	}
+}
+
+type proxyContextKey struct{}
+
+// withProxy returns a context that sends the requests of the contact points with their own HTTP
+// client, such as Slack, through the egress proxy of the contact point.
+func withProxy(ctx context.Context, proxy *ProxyConfig) context.Context {
+	if proxy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyContextKey{}, proxy.ProxyFunc())
+}
+
+// proxyFromContext returns the egress proxy of the contact point of the request, or the proxy of
+// the environment if the contact point does not set one.
+func proxyFromContext(r *http.Request) (*url.URL, error) {
+	if proxy, ok := r.Context().Value(proxyContextKey{}).(func(*http.Request) (*url.URL, error)); ok {
+		return proxy(r)
+	}
+	return http.ProxyFromEnvironment(r)
+}
+
+// proxyingNotificationService sends the webhooks of a contact point through its egress proxy,
+// unless the webhook sets its own.
+type proxyingNotificationService struct {
+	notifications.Service
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+func (s *proxyingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	if cmd.ProxyURL != nil || cmd.Proxy != nil {
+		return s.Service.SendWebhookSync(ctx, cmd)
+	}
+	proxied := *cmd
+	proxied.Proxy = s.proxy
+	return s.Service.SendWebhookSync(ctx, &proxied)
+}
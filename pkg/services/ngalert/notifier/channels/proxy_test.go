@@ -0,0 +1,108 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestProxyFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"proxyUrl": "socks5://egress.example.com:1080", "noProxy": "internal.example.com,10.0.0.0/8"}`))
+	require.NoError(t, err)
+	p, err := proxyFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, "socks5://egress.example.com:1080", p.URL.String())
+	require.Equal(t, "internal.example.com,10.0.0.0/8", p.NoProxy)
+
+	p, err = proxyFromSettings(simplejson.New())
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	for settings, expErr := range map[string]string{
+		`{"proxyUrl": "egress.example.com"}`:          `invalid proxyUrl "egress.example.com", must be an URL such as http://proxy.example.com:3128`,
+		`{"proxyUrl": "ftp://egress.example.com:21"}`: `invalid proxyUrl "ftp://egress.example.com:21", the scheme must be http, https or socks5`,
+		`{"noProxy": "internal.example.com"}`:         `noProxy requires proxyUrl`,
+	} {
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		_, err = proxyFromSettings(s)
+		require.EqualError(t, err, expErr, settings)
+	}
+}
+
+func TestProxyConfig_ProxyFunc(t *testing.T) {
+	proxyURL, err := url.Parse("http://egress.example.com:3128")
+	require.NoError(t, err)
+	proxy := (&ProxyConfig{URL: proxyURL, NoProxy: "internal.example.com,10.0.0.0/8"}).ProxyFunc()
+
+	for target, expected := range map[string]*url.URL{
+		"https://hooks.slack.com/services/T0/B0":  proxyURL,
+		"http://alerts.example.com/webhook":       proxyURL,
+		"https://internal.example.com/webhook":    nil,
+		"https://api.internal.example.com/alerts": nil,
+		"http://10.1.2.3:8080/webhook":            nil,
+	} {
+		r, err := http.NewRequest(http.MethodPost, target, nil)
+		require.NoError(t, err)
+		u, err := proxy(r)
+		require.NoError(t, err)
+		require.Equal(t, expected, u, target)
+	}
+}
+
+func TestProxyingNotificationService(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"url": "http://localhost:9093", "proxyUrl": "http://egress.example.com:3128"}`))
+	require.NoError(t, err)
+	mock := &notificationServiceMock{}
+	fc, err := NewFactoryConfig(&NotificationChannelConfig{Type: "webhook", Settings: settings}, mock, nil, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://egress.example.com:3128", fc.Config.Proxy.URL.String())
+
+	t.Run("the webhooks are sent through the proxy of the contact point", func(t *testing.T) {
+		require.NoError(t, fc.NotificationService.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "https://alerts.example.com/hook"}))
+		require.NotNil(t, mock.Webhook.Proxy)
+		r, err := http.NewRequest(http.MethodPost, "https://alerts.example.com/hook", nil)
+		require.NoError(t, err)
+		u, err := mock.Webhook.Proxy(r)
+		require.NoError(t, err)
+		require.Equal(t, "http://egress.example.com:3128", u.String())
+	})
+
+	t.Run("the proxy of a webhook is kept", func(t *testing.T) {
+		own, err := url.Parse("http://own.example.com:3128")
+		require.NoError(t, err)
+		require.NoError(t, fc.NotificationService.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "https://alerts.example.com/hook", ProxyURL: own}))
+		require.Nil(t, mock.Webhook.Proxy)
+		require.Equal(t, own, mock.Webhook.ProxyURL)
+	})
+
+	t.Run("invalid proxy settings", func(t *testing.T) {
+		settings, err := simplejson.NewJson([]byte(`{"url": "http://localhost:9093", "proxyUrl": "ftp://egress.example.com"}`))
+		require.NoError(t, err)
+		_, err = NewFactoryConfig(&NotificationChannelConfig{Type: "webhook", Settings: settings}, mock, nil, nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestProxyFromContext(t *testing.T) {
+	req, err := http.NewRequestWithContext(withProxy(context.Background(), &ProxyConfig{
+		URL:     &url.URL{Scheme: "http", Host: "egress.example.com:3128"},
+		NoProxy: "internal.example.com",
+	}), http.MethodPost, "https://hooks.slack.com/services/T00", nil)
+	require.NoError(t, err)
+	u, err := proxyFromContext(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://egress.example.com:3128", u.String())
+
+	req, err = http.NewRequestWithContext(withProxy(context.Background(), nil), http.MethodPost, "https://internal.example.com", nil)
+	require.NoError(t, err)
+	u, err = proxyFromContext(req)
+	require.NoError(t, err)
+	require.Nil(t, u)
+}
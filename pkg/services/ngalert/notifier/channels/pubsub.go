@@ -0,0 +1,209 @@
+package channels
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const pubSubScope = "https://www.googleapis.com/auth/pubsub"
+
+// PubSubEndpoint is the Pub/Sub REST endpoint to publish messages. Can be overwritten in tests.
+var PubSubEndpoint = "https://pubsub.googleapis.com/v1/projects/%s/topics/%s:publish"
+
+type PubSubConfig struct {
+	*NotificationChannelConfig
+	ProjectID          string
+	Topic              string
+	ServiceAccountJSON string
+}
+
+func PubSubFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewPubSubConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	n, err := NewPubSubNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: "invalid service account credentials",
+			Err:    err,
+			Cfg:    *fc.Config,
+		}
+	}
+	return n, nil
+}
+
+func NewPubSubConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*PubSubConfig, error) {
+	projectID := config.Settings.Get("projectId").MustString()
+	if projectID == "" {
+		return nil, errors.New("could not find project ID in settings")
+	}
+	topic := config.Settings.Get("topic").MustString()
+	if topic == "" {
+		return nil, errors.New("could not find topic in settings")
+	}
+	serviceAccountJSON := decryptFunc(context.Background(), config.SecureSettings, "serviceAccountJson", config.Settings.Get("serviceAccountJson").MustString())
+	if serviceAccountJSON == "" {
+		return nil, errors.New("could not find service account JSON in settings")
+	}
+	return &PubSubConfig{
+		NotificationChannelConfig: config,
+		ProjectID:                 projectID,
+		Topic:                     topic,
+		ServiceAccountJSON:        serviceAccountJSON,
+	}, nil
+}
+
+// NewPubSubNotifier is the constructor for the Google Cloud Pub/Sub notifier.
+func NewPubSubNotifier(config *PubSubConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) (*PubSubNotifier, error) {
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(config.ServiceAccountJSON), pubSubScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PubSubNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:     config.OrgID,
+		ProjectID: config.ProjectID,
+		Topic:     config.Topic,
+		// The token source caches the token until it expires.
+		tokenSource: jwtConfig.TokenSource(context.Background()),
+		log:         log.New("alerting.notifier.pubsub"),
+		images:      images,
+		ns:          ns,
+		tmpl:        t,
+	}, nil
+}
+
+// PubSubNotifier is responsible for publishing alert notifications to a Google Cloud Pub/Sub topic.
+type PubSubNotifier struct {
+	*Base
+	ProjectID   string
+	Topic       string
+	orgID       int64
+	tokenSource oauth2.TokenSource
+	log         log.Logger
+	images      ImageStore
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+// pubSubAlertData is the JSON payload published for every alert.
+type pubSubAlertData struct {
+	Receiver string        `json:"receiver"`
+	GroupKey string        `json:"groupKey"`
+	OrgID    int64         `json:"orgId"`
+	Alert    ExtendedAlert `json:"alert"`
+}
+
+type pubSubMessage struct {
+	Data       string            `json:"data"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type pubSubPublishRequest struct {
+	Messages []pubSubMessage `json:"messages"`
+}
+
+// Notify publishes one message per alert, with the alert labels as message attributes.
+func (pn *PubSubNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	pn.log.Debug("publishing to Google Cloud Pub/Sub", "notification", pn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	_, data := TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, pn.log, pn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	data.setPayloadVersion(ctx, pn.PayloadVersion(), pn.orgID)
+	req := pubSubPublishRequest{Messages: make([]pubSubMessage, 0, len(data.Alerts))}
+	for _, alert := range data.Alerts {
+		b, err := json.Marshal(pubSubAlertData{
+			Receiver: data.Receiver,
+			GroupKey: groupKey.String(),
+			OrgID:    pn.orgID,
+			Alert:    alert,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		attributes := make(map[string]string, len(alert.Labels))
+		for k, v := range alert.Labels {
+			// Attribute keys starting with "goog" are reserved by Pub/Sub.
+			if strings.HasPrefix(strings.ToLower(k), "goog") {
+				continue
+			}
+			attributes[k] = v
+		}
+
+		req.Messages = append(req.Messages, pubSubMessage{
+			Data:       base64.StdEncoding.EncodeToString(b),
+			Attributes: attributes,
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+
+	token, err := pn.tokenSource.Token()
+	if err != nil {
+		return false, fmt.Errorf("failed to get Google Cloud access token: %w", err)
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        fmt.Sprintf(PubSubEndpoint, pn.ProjectID, pn.Topic),
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Authorization": "Bearer " + token.AccessToken,
+		},
+	}
+
+	if err := pn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		pn.log.Error("failed to publish to Google Cloud Pub/Sub", "err", err, "notification", pn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (pn *PubSubNotifier) SendResolved() bool {
+	return !pn.GetDisableResolveMessage()
+}
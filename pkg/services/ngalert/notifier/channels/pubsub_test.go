@@ -0,0 +1,163 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestPubSubNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "gcp-token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenServer.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	serviceAccount, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "grafana@my-project.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		settings      map[string]interface{}
+		alerts        []*types.Alert
+		expAttributes []map[string]string
+		expInitError  string
+	}{
+		{
+			name: "One message per alert with labels as attributes",
+			settings: map[string]interface{}{
+				"projectId":          "my-project",
+				"topic":              "alerts",
+				"serviceAccountJson": string(serviceAccount),
+			},
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2", "google_reserved": "x"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expAttributes: []map[string]string{
+				{"alertname": "alert1", "lbl1": "val1"},
+				{"alertname": "alert1", "lbl1": "val2"},
+			},
+		}, {
+			name:         "Error when project ID is missing",
+			settings:     map[string]interface{}{"topic": "alerts", "serviceAccountJson": string(serviceAccount)},
+			expInitError: "could not find project ID in settings",
+		}, {
+			name:         "Error when topic is missing",
+			settings:     map[string]interface{}{"projectId": "my-project", "serviceAccountJson": string(serviceAccount)},
+			expInitError: "could not find topic in settings",
+		}, {
+			name:         "Error when service account is missing",
+			settings:     map[string]interface{}{"projectId": "my-project", "topic": "alerts"},
+			expInitError: "could not find service account JSON in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "pubsub_testing",
+				Type:     "pubsub",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			webhookSender := mockNotificationService()
+			cfg, err := NewPubSubConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			n, err := NewPubSubNotifier(cfg, &UnavailableImageStore{}, webhookSender, tmpl)
+			require.NoError(t, err)
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://pubsub.googleapis.com/v1/projects/my-project/topics/alerts:publish", webhookSender.Webhook.Url)
+			require.Equal(t, "Bearer gcp-token", webhookSender.Webhook.HttpHeader["Authorization"])
+
+			var req pubSubPublishRequest
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &req))
+			require.Len(t, req.Messages, len(c.alerts))
+			for i, msg := range req.Messages {
+				require.Equal(t, c.expAttributes[i], msg.Attributes)
+
+				b, err := base64.StdEncoding.DecodeString(msg.Data)
+				require.NoError(t, err)
+				var data pubSubAlertData
+				require.NoError(t, json.Unmarshal(b, &data))
+				require.Equal(t, "my_receiver", data.Receiver)
+				require.Equal(t, "alertname", data.GroupKey)
+				require.Equal(t, int64(1), data.OrgID)
+				require.Equal(t, c.alerts[i].Fingerprint().String(), data.Alert.Fingerprint)
+				require.Equal(t, "firing", data.Alert.Status)
+			}
+		})
+	}
+}
+
+func TestPubSubFactory_InvalidCredentials(t *testing.T) {
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	fc, err := NewFactoryConfig(&NotificationChannelConfig{
+		Name: "pubsub_testing",
+		Type: "pubsub",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"projectId":          "my-project",
+			"topic":              "alerts",
+			"serviceAccountJson": "not json",
+		}),
+	}, mockNotificationService(), secretsService.GetDecryptedValue, templateForTests(t), nil)
+	require.NoError(t, err)
+
+	_, err = PubSubFactory(fc)
+	require.ErrorContains(t, err, "invalid service account credentials")
+}
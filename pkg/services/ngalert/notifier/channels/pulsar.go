@@ -0,0 +1,256 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	pulsarlog "github.com/apache/pulsar-client-go/pulsar/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const pulsarTimeout = 10 * time.Second
+
+// pulsarProducer is the subset of pulsar.Producer used by the Pulsar notifier.
+type pulsarProducer interface {
+	Send(ctx context.Context, msg *pulsar.ProducerMessage) (pulsar.MessageID, error)
+}
+
+// pulsarCreateProducer connects to the cluster and creates a producer for the topic. The returned
+// function closes both the producer and the client. Can be overwritten in tests.
+var pulsarCreateProducer = func(opts pulsar.ClientOptions, topic string) (pulsarProducer, func(), error) {
+	client, err := pulsar.NewClient(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{Topic: topic})
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+
+	return producer, func() {
+		producer.Close()
+		client.Close()
+	}, nil
+}
+
+type PulsarConfig struct {
+	*NotificationChannelConfig
+	ServiceURL            string
+	Topic                 string
+	KeyLabel              string
+	AuthToken             string
+	TLSSkipVerify         bool
+	TLSTrustCertsFilePath string
+	TLSClientCert         string
+	TLSClientKey          string
+}
+
+func PulsarFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewPulsarConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	n, err := NewPulsarNotifier(cfg, fc.ImageStore, fc.Template)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: "invalid TLS settings",
+			Err:    err,
+			Cfg:    *fc.Config,
+		}
+	}
+	return n, nil
+}
+
+func NewPulsarConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*PulsarConfig, error) {
+	serviceURL := config.Settings.Get("serviceUrl").MustString()
+	if serviceURL == "" {
+		return nil, errors.New("could not find service URL in settings")
+	}
+	u, err := url.Parse(serviceURL)
+	if err != nil || (u.Scheme != "pulsar" && u.Scheme != "pulsar+ssl") {
+		return nil, errors.New("service URL must be a valid pulsar:// or pulsar+ssl:// URL")
+	}
+
+	topic := config.Settings.Get("topic").MustString()
+	if topic == "" {
+		return nil, errors.New("could not find topic in settings")
+	}
+
+	cfg := &PulsarConfig{
+		NotificationChannelConfig: config,
+		ServiceURL:                serviceURL,
+		Topic:                     topic,
+		KeyLabel:                  config.Settings.Get("keyLabel").MustString(),
+		AuthToken:                 decryptFunc(context.Background(), config.SecureSettings, "authToken", config.Settings.Get("authToken").MustString()),
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSTrustCertsFilePath:     config.Settings.Get("tlsTrustCertsFilePath").MustString(),
+		TLSClientCert:             config.Settings.Get("tlsClientCert").MustString(),
+		TLSClientKey:              decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString()),
+	}
+	if cfg.AuthToken != "" && (cfg.TLSClientCert != "" || cfg.TLSClientKey != "") {
+		return nil, errors.New("token and TLS client certificate authentication cannot be used together")
+	}
+	return cfg, nil
+}
+
+// NewPulsarNotifier is the constructor for the Pulsar notifier.
+func NewPulsarNotifier(config *PulsarConfig, images ImageStore, t *template.Template) (*PulsarNotifier, error) {
+	var auth pulsar.Authentication
+	switch {
+	case config.AuthToken != "":
+		auth = pulsar.NewAuthenticationToken(config.AuthToken)
+	case config.TLSClientCert != "" || config.TLSClientKey != "":
+		if config.TLSClientCert == "" || config.TLSClientKey == "" {
+			return nil, errors.New("both client certificate and client key are required")
+		}
+		cert, err := tls.X509KeyPair([]byte(config.TLSClientCert), []byte(config.TLSClientKey))
+		if err != nil {
+			return nil, err
+		}
+		auth = pulsar.NewAuthenticationFromTLSCertSupplier(func() (*tls.Certificate, error) {
+			return &cert, nil
+		})
+	}
+
+	return &PulsarNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		orgID:                 config.OrgID,
+		ServiceURL:            config.ServiceURL,
+		Topic:                 config.Topic,
+		KeyLabel:              config.KeyLabel,
+		TLSSkipVerify:         config.TLSSkipVerify,
+		TLSTrustCertsFilePath: config.TLSTrustCertsFilePath,
+		auth:                  auth,
+		log:                   log.New("alerting.notifier.pulsar"),
+		images:                images,
+		tmpl:                  t,
+	}, nil
+}
+
+// PulsarNotifier is responsible for producing alert notifications to an Apache Pulsar topic.
+type PulsarNotifier struct {
+	*Base
+	ServiceURL            string
+	Topic                 string
+	KeyLabel              string
+	TLSSkipVerify         bool
+	TLSTrustCertsFilePath string
+	orgID                 int64
+	auth                  pulsar.Authentication
+	log                   log.Logger
+	images                ImageStore
+	tmpl                  *template.Template
+}
+
+// Notify produces the alerts to the configured topic and waits for the broker to persist the message.
+func (pn *PulsarNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	pn.log.Debug("producing to Pulsar", "notification", pn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, pn.log, pn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	topic := strings.TrimSpace(tmpl(pn.Topic))
+	msg := newBrokerMessage(ctx, pn.PayloadVersion(), tmpl, data, groupKey.String(), pn.orgID, as...)
+
+	if tmplErr != nil {
+		pn.log.Warn("failed to template Pulsar message", "err", tmplErr.Error())
+	}
+	if topic == "" {
+		return false, errors.New("topic is empty after templating")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if recordDryRun(ctx, topic, string(body)) {
+		return true, nil
+	}
+
+	producer, closeFn, err := pulsarCreateProducer(pn.clientOptions(), topic)
+	if err != nil {
+		pn.log.Error("failed to create Pulsar producer", "err", err, "topic", topic, "notification", pn.Name)
+		return false, err
+	}
+	defer closeFn()
+
+	if _, err := producer.Send(ctx, &pulsar.ProducerMessage{
+		Payload: body,
+		Key:     pn.messageKey(data, groupKey),
+		Properties: map[string]string{
+			"groupKey": groupKey.String(),
+			"state":    msg.State,
+		},
+	}); err != nil {
+		pn.log.Error("failed to produce to Pulsar", "err", err, "topic", topic, "notification", pn.Name)
+		return false, fmt.Errorf("failed to produce message: %w", err)
+	}
+
+	return true, nil
+}
+
+// messageKey returns the value of the key label if all alerts share it, otherwise the hash of the
+// group key. Pulsar routes messages with the same key to the same partition and consumer.
+func (pn *PulsarNotifier) messageKey(data *ExtendedData, groupKey notify.Key) string {
+	if pn.KeyLabel != "" {
+		if v, ok := data.CommonLabels[pn.KeyLabel]; ok && v != "" {
+			return v
+		}
+	}
+	return groupKey.Hash()
+}
+
+func (pn *PulsarNotifier) clientOptions() pulsar.ClientOptions {
+	return pulsar.ClientOptions{
+		URL:                        pn.ServiceURL,
+		ConnectionTimeout:          pulsarTimeout,
+		OperationTimeout:           pulsarTimeout,
+		Authentication:             pn.auth,
+		TLSAllowInsecureConnection: pn.TLSSkipVerify,
+		TLSTrustCertsFilePath:      pn.TLSTrustCertsFilePath,
+		TLSValidateHostname:        !pn.TLSSkipVerify,
+		Logger:                     pulsarlog.DefaultNopLogger(),
+	}
+}
+
+func (pn *PulsarNotifier) SendResolved() bool {
+	return !pn.GetDisableResolveMessage()
+}
@@ -0,0 +1,195 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakePulsarProducer struct {
+	msg     *pulsar.ProducerMessage
+	sendErr error
+}
+
+func (p *fakePulsarProducer) Send(_ context.Context, msg *pulsar.ProducerMessage) (pulsar.MessageID, error) {
+	p.msg = msg
+	return nil, p.sendErr
+}
+
+func TestPulsarNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "team": "ops"},
+				Annotations: model.LabelSet{"ann1": "annv1"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		sendErr      error
+		expTopic     string
+		expKey       string
+		expAuth      bool
+		expInitError string
+		expMsgError  string
+	}{
+		{
+			name: "Produces to templated topic keyed by group",
+			settings: map[string]interface{}{
+				"serviceUrl": "pulsar://localhost:6650",
+				"topic":      "persistent://public/default/{{ .CommonLabels.team }}",
+			},
+			expTopic: "persistent://public/default/ops",
+			expKey:   notify.Key("alertname").Hash(),
+		}, {
+			name: "Key from label and token authentication",
+			settings: map[string]interface{}{
+				"serviceUrl": "pulsar+ssl://localhost:6651",
+				"topic":      "alerts",
+				"keyLabel":   "team",
+				"authToken":  "my-token",
+			},
+			expTopic: "alerts",
+			expKey:   "ops",
+			expAuth:  true,
+		}, {
+			name: "Falls back to the group when the key label is missing",
+			settings: map[string]interface{}{
+				"serviceUrl": "pulsar://localhost:6650",
+				"topic":      "alerts",
+				"keyLabel":   "service",
+			},
+			expTopic: "alerts",
+			expKey:   notify.Key("alertname").Hash(),
+		}, {
+			name: "Error when the broker fails to persist the message",
+			settings: map[string]interface{}{
+				"serviceUrl": "pulsar://localhost:6650",
+				"topic":      "alerts",
+			},
+			sendErr:     errors.New("topic was deleted"),
+			expMsgError: "failed to produce message: topic was deleted",
+		}, {
+			name:         "Error when service URL is missing",
+			settings:     map[string]interface{}{"topic": "alerts"},
+			expInitError: "could not find service URL in settings",
+		}, {
+			name:         "Error on invalid service URL scheme",
+			settings:     map[string]interface{}{"serviceUrl": "http://localhost:8080", "topic": "alerts"},
+			expInitError: "service URL must be a valid pulsar:// or pulsar+ssl:// URL",
+		}, {
+			name:         "Error when topic is missing",
+			settings:     map[string]interface{}{"serviceUrl": "pulsar://localhost:6650"},
+			expInitError: "could not find topic in settings",
+		}, {
+			name: "Error when token and client certificate are both set",
+			settings: map[string]interface{}{
+				"serviceUrl":    "pulsar+ssl://localhost:6651",
+				"topic":         "alerts",
+				"authToken":     "my-token",
+				"tlsClientCert": "cert",
+			},
+			expInitError: "token and TLS client certificate authentication cannot be used together",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "pulsar_testing",
+				Type:     "pulsar",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewPulsarConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			producer := &fakePulsarProducer{sendErr: c.sendErr}
+			closed := false
+			var clientOpts pulsar.ClientOptions
+			var producerTopic string
+			origCreateProducer := pulsarCreateProducer
+			pulsarCreateProducer = func(opts pulsar.ClientOptions, topic string) (pulsarProducer, func(), error) {
+				clientOpts, producerTopic = opts, topic
+				return producer, func() { closed = true }, nil
+			}
+			t.Cleanup(func() { pulsarCreateProducer = origCreateProducer })
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			n, err := NewPulsarNotifier(cfg, &UnavailableImageStore{}, tmpl)
+			require.NoError(t, err)
+
+			ok, err := n.Notify(ctx, alerts...)
+			require.True(t, closed)
+			if c.expMsgError != "" {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.settings["serviceUrl"], clientOpts.URL)
+			require.Equal(t, c.expAuth, clientOpts.Authentication != nil)
+			require.Equal(t, c.expTopic, producerTopic)
+			require.Equal(t, c.expKey, producer.msg.Key)
+			require.Equal(t, "alerting", producer.msg.Properties["state"])
+
+			var msg brokerMessage
+			require.NoError(t, json.Unmarshal(producer.msg.Payload, &msg))
+			require.Equal(t, "alertname", msg.GroupKey)
+			require.Equal(t, int64(1), msg.OrgID)
+			require.Equal(t, "alerting", msg.State)
+			require.Len(t, msg.Alerts, 1)
+		})
+	}
+}
+
+func TestPulsarFactory_InvalidTLSSettings(t *testing.T) {
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	fc, err := NewFactoryConfig(&NotificationChannelConfig{
+		Name: "pulsar_testing",
+		Type: "pulsar",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"serviceUrl":    "pulsar+ssl://localhost:6651",
+			"topic":         "alerts",
+			"tlsClientCert": "not a certificate",
+		}),
+	}, mockNotificationService(), secretsService.GetDecryptedValue, templateForTests(t), nil)
+	require.NoError(t, err)
+
+	_, err = PulsarFactory(fc)
+	require.ErrorContains(t, err, "invalid TLS settings: both client certificate and client key are required")
+}
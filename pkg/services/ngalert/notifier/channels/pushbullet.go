@@ -0,0 +1,157 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+var PushbulletAPIURL = "https://api.pushbullet.com/v2/pushes"
+
+type PushbulletConfig struct {
+	*NotificationChannelConfig
+	AccessToken string
+	DeviceIden  string
+	ChannelTag  string
+	Title       string
+	Message     string
+}
+
+func PushbulletFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewPushbulletConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewPushbulletNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewPushbulletConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*PushbulletConfig, error) {
+	accessToken := decryptFunc(context.Background(), config.SecureSettings, "accessToken", config.Settings.Get("accessToken").MustString())
+	if accessToken == "" {
+		return nil, errors.New("could not find access token in settings")
+	}
+	deviceIden := config.Settings.Get("deviceIden").MustString()
+	channelTag := config.Settings.Get("channelTag").MustString()
+	if deviceIden != "" && channelTag != "" {
+		return nil, errors.New("either a device or a channel can be set, not both")
+	}
+
+	return &PushbulletConfig{
+		NotificationChannelConfig: config,
+		AccessToken:               accessToken,
+		DeviceIden:                deviceIden,
+		ChannelTag:                channelTag,
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewPushbulletNotifier is the constructor for the Pushbullet notifier.
+func NewPushbulletNotifier(config *PushbulletConfig, ns notifications.WebhookSender, t *template.Template) *PushbulletNotifier {
+	return &PushbulletNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		AccessToken: config.AccessToken,
+		DeviceIden:  config.DeviceIden,
+		ChannelTag:  config.ChannelTag,
+		Title:       config.Title,
+		Message:     config.Message,
+		log:         log.New("alerting.notifier.pushbullet"),
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// PushbulletNotifier is responsible for sending alert notifications as Pushbullet link pushes.
+type PushbulletNotifier struct {
+	*Base
+	AccessToken string
+	DeviceIden  string
+	ChannelTag  string
+	Title       string
+	Message     string
+	log         log.Logger
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+type pushbulletPush struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	URL        string `json:"url"`
+	DeviceIden string `json:"device_iden,omitempty"`
+	ChannelTag string `json:"channel_tag,omitempty"`
+}
+
+// Notify sends a link push to all the devices of the user, to a device, or to the subscribers of a channel.
+func (pn *PushbulletNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	pn.log.Debug("sending Pushbullet notification", "notification", pn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+
+	push := pushbulletPush{
+		Type:       "link",
+		Title:      tmpl(pn.Title),
+		Body:       tmpl(pn.Message),
+		URL:        pn.linkURL(ctx, data),
+		DeviceIden: pn.DeviceIden,
+		ChannelTag: pn.ChannelTag,
+	}
+
+	if tmplErr != nil {
+		pn.log.Warn("failed to template Pushbullet message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        PushbulletAPIURL,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+			"Access-Token": pn.AccessToken,
+		},
+	}
+	if err := pn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		pn.log.Error("failed to send Pushbullet notification", "err", err, "notification", pn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// linkURL links to the alert rule when the notification has a single alert, otherwise to the
+// alert list filtered by the group.
+func (pn *PushbulletNotifier) linkURL(ctx context.Context, data *ExtendedData) string {
+	if len(data.Alerts) == 1 && data.Alerts[0].GeneratorURL != "" {
+		return data.Alerts[0].GeneratorURL
+	}
+	groupLabels, _ := notify.GroupLabels(ctx)
+	return pn.AlertListURL(pn.tmpl.ExternalURL, groupLabels)
+}
+
+func (pn *PushbulletNotifier) SendResolved() bool {
+	return !pn.GetDisableResolveMessage()
+}
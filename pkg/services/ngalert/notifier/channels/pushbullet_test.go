@@ -0,0 +1,120 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestPushbulletNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations:  model.LabelSet{"ann1": "annv1"},
+			GeneratorURL: "http://localhost/alerting/grafana/rule1/view",
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		alerts       []*types.Alert
+		expMsg       string
+		expInitError string
+	}{
+		{
+			name:     "Single alert links to the rule",
+			settings: map[string]interface{}{"accessToken": "o.token"},
+			alerts:   []*types.Alert{firing},
+			expMsg: `{
+				"type": "link",
+				"title": "[FIRING:1] alert1 (val1)",
+				"body": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: http://localhost/alerting/grafana/rule1/view\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+				"url": "http://localhost/alerting/grafana/rule1/view"
+			}`,
+		}, {
+			name: "Multiple alerts to a device link to the alert list",
+			settings: map[string]interface{}{
+				"accessToken": "o.token",
+				"deviceIden":  "ujpah72o0",
+				"title":       "{{ len .Alerts.Firing }} alerts",
+				"message":     "see Grafana",
+			},
+			alerts: []*types.Alert{firing, {Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val2"}}}},
+			expMsg: `{
+				"type": "link",
+				"title": "2 alerts",
+				"body": "see Grafana",
+				"url": "http://localhost/alerting/list?queryString=alertname%3D%22alert1%22",
+				"device_iden": "ujpah72o0"
+			}`,
+		}, {
+			name:     "Channel",
+			settings: map[string]interface{}{"accessToken": "o.token", "channelTag": "oncall", "message": "msg"},
+			alerts:   []*types.Alert{firing},
+			expMsg: `{
+				"type": "link",
+				"title": "[FIRING:1] alert1 (val1)",
+				"body": "msg",
+				"url": "http://localhost/alerting/grafana/rule1/view",
+				"channel_tag": "oncall"
+			}`,
+		}, {
+			name:         "Error when access token is missing",
+			settings:     map[string]interface{}{},
+			expInitError: "could not find access token in settings",
+		}, {
+			name:         "Error with both device and channel",
+			settings:     map[string]interface{}{"accessToken": "o.token", "deviceIden": "ujpah72o0", "channelTag": "oncall"},
+			expInitError: "either a device or a channel can be set, not both",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "pushbullet_testing",
+				Type:     "pushbullet",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewPushbulletConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ns := mockNotificationService()
+			n := NewPushbulletNotifier(cfg, ns, tmpl)
+
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, PushbulletAPIURL, ns.Webhook.Url)
+			require.Equal(t, "o.token", ns.Webhook.HttpHeader["Access-Token"])
+			require.JSONEq(t, c.expMsg, ns.Webhook.Body)
+		})
+	}
+}
@@ -218,7 +218,7 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 		return nil, b, fmt.Errorf("failed to write the title: %w", err)
 	}
 
-	ruleURL := joinUrlPath(pn.tmpl.ExternalURL.String(), "/alerting/list", pn.log)
+	ruleURL := pn.RuleListURL(pn.tmpl.ExternalURL)
 	if err := w.WriteField("url", ruleURL); err != nil {
 		return nil, b, fmt.Errorf("failed to write the URL: %w", err)
 	}
@@ -0,0 +1,135 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var (
+	rateLimitedNotifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_rate_limited_total",
+		Help:      "The total number of requests that waited for the rate limit of the API of a provider.",
+	}, []string{"provider"})
+	rateLimitQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_rate_limit_queued",
+		Help:      "The number of requests waiting for the rate limit of the API of a provider.",
+	}, []string{"provider"})
+	rateLimitWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "grafana",
+		Subsystem: "alerting",
+		Name:      "notifier_rate_limit_wait_seconds",
+		Help:      "The time requests waited for the rate limit of the API of a provider.",
+		Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"provider"})
+)
+
+// The rate limits of the providers, shared by all the contact points of the Grafana instance as
+// the quotas apply to the destination of the messages whichever contact point sends them.
+var (
+	// Slack allows one message per second per channel.
+	slackRateLimiter = newProviderRateLimiter("slack", rate.Limit(1), 1)
+	// Telegram allows 30 messages per second per bot.
+	telegramRateLimiter = newProviderRateLimiter("telegram", rate.Limit(30), 30)
+	// Webex allows 10 messages per minute per room.
+	webexRateLimiter = newProviderRateLimiter("webex", rate.Limit(10.0/60), 10)
+)
+
+// providerRateLimiter smooths the bursts of requests to the API of a provider with a quota per
+// destination, such as a Slack channel, by queueing them until the quota allows them instead of
+// having them rejected with 429 Too Many Requests and retried.
+type providerRateLimiter struct {
+	provider string
+	limit    rate.Limit
+	burst    int
+	// idle is how long a limiter is unused before it is removed. Its tokens are refilled by
+	// then, so removing it does not change the rate.
+	idle time.Duration
+
+	mtx       sync.Mutex
+	limiters  map[string]*destinationLimiter
+	lastSweep time.Time
+}
+
+type destinationLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+func newProviderRateLimiter(provider string, limit rate.Limit, burst int) *providerRateLimiter {
+	idle := time.Minute
+	if refill := time.Duration(float64(burst) / float64(limit) * float64(time.Second)); refill > idle {
+		idle = refill
+	}
+	return &providerRateLimiter{
+		provider: provider,
+		limit:    limit,
+		burst:    burst,
+		idle:     idle,
+		limiters: map[string]*destinationLimiter{},
+	}
+}
+
+// wait blocks until the quota of the destination allows a request, or the context is done.
+// Dry runs do not send requests, so they do not wait.
+func (l *providerRateLimiter) wait(ctx context.Context, destination string) error {
+	if _, ok := dryRunFromContext(ctx); ok {
+		return nil
+	}
+
+	r := l.reserve(destination, time.Now())
+	if !r.OK() {
+		return fmt.Errorf("the rate limit of %s does not allow the request", l.provider)
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	rateLimitedNotifications.WithLabelValues(l.provider).Inc()
+	rateLimitQueued.WithLabelValues(l.provider).Inc()
+	defer rateLimitQueued.WithLabelValues(l.provider).Dec()
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		rateLimitWait.WithLabelValues(l.provider).Observe(delay.Seconds())
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+func (l *providerRateLimiter) reserve(destination string, now time.Time) *rate.Reservation {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if now.Sub(l.lastSweep) > l.idle {
+		for k, lim := range l.limiters {
+			if now.Sub(lim.lastUsed) > l.idle {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	lim, ok := l.limiters[destination]
+	if !ok {
+		lim = &destinationLimiter{Limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[destination] = lim
+	}
+	r := lim.ReserveN(now, 1)
+	lim.lastUsed = now.Add(r.DelayFrom(now))
+	return r
+}
@@ -0,0 +1,64 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestProviderRateLimiter(t *testing.T) {
+	l := newProviderRateLimiter("test-wait", rate.Every(50*time.Millisecond), 1)
+
+	// The first request of each destination does not wait.
+	start := time.Now()
+	require.NoError(t, l.wait(context.Background(), "a"))
+	require.NoError(t, l.wait(context.Background(), "b"))
+	require.Less(t, time.Since(start), 50*time.Millisecond)
+	require.Zero(t, testutil.ToFloat64(rateLimitedNotifications.WithLabelValues("test-wait")))
+
+	// The next ones are queued until the quota allows them.
+	require.NoError(t, l.wait(context.Background(), "a"))
+	require.NoError(t, l.wait(context.Background(), "a"))
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	require.Equal(t, 2.0, testutil.ToFloat64(rateLimitedNotifications.WithLabelValues("test-wait")))
+	require.Zero(t, testutil.ToFloat64(rateLimitQueued.WithLabelValues("test-wait")))
+
+	// Dry runs do not wait.
+	ctx := WithDryRun(context.Background(), &DryRun{})
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.wait(ctx, "a"))
+	}
+	require.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestProviderRateLimiter_Cancel(t *testing.T) {
+	l := newProviderRateLimiter("test-cancel", rate.Every(time.Hour), 1)
+	require.NoError(t, l.wait(context.Background(), "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.wait(ctx, "a"), context.DeadlineExceeded)
+	require.Zero(t, testutil.ToFloat64(rateLimitQueued.WithLabelValues("test-cancel")))
+}
+
+func TestProviderRateLimiter_Idle(t *testing.T) {
+	l := newProviderRateLimiter("test-idle", rate.Limit(10.0/60), 10)
+	require.Equal(t, time.Minute, l.idle)
+
+	now := time.Now()
+	l.reserve("a", now)
+	l.reserve("b", now.Add(30*time.Second))
+	require.Len(t, l.limiters, 2)
+	b := l.limiters["b"]
+
+	// The limiters that are unused for longer than it takes to refill them are removed.
+	l.reserve("c", now.Add(70*time.Second))
+	require.Len(t, l.limiters, 2)
+	require.NotContains(t, l.limiters, "a")
+	require.Same(t, b, l.limiters["b"])
+}
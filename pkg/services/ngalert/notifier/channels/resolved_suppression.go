@@ -0,0 +1,199 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// The contact point settings controlling the resolved notifications.
+	resolvedMinFiringDurationSetting = "resolvedMinFiringDuration"
+	blipDurationSetting              = "blipDuration"
+
+	// BlipAnnotation is the annotation of the alerts sent as a blip, set to how long they fired.
+	BlipAnnotation = "blip"
+
+	// blipNotifyTimeout is the time available to send the held firing alerts.
+	blipNotifyTimeout = time.Minute
+)
+
+// ResolvedSuppression are the options of a contact point that suppress the resolved
+// notifications of the alerts that only fired briefly. Zero disables an option.
+type ResolvedSuppression struct {
+	// MinFiringDuration is how long an alert must have fired for its resolved notification to
+	// be sent.
+	MinFiringDuration time.Duration
+	// BlipDuration is how long the firing notification of a new alert is held. The alerts
+	// resolved by then are sent once, as resolved with the BlipAnnotation, instead of twice.
+	BlipDuration time.Duration
+}
+
+// resolvedSuppressionFromSettings returns the resolved suppression options of a contact point.
+func resolvedSuppressionFromSettings(settings *simplejson.Json) (ResolvedSuppression, error) {
+	var s ResolvedSuppression
+	if settings == nil {
+		return s, nil
+	}
+	for _, o := range []struct {
+		setting  string
+		duration *time.Duration
+	}{
+		{setting: resolvedMinFiringDurationSetting, duration: &s.MinFiringDuration},
+		{setting: blipDurationSetting, duration: &s.BlipDuration},
+	} {
+		v := strings.TrimSpace(settings.Get(o.setting).MustString())
+		if v == "" {
+			continue
+		}
+		d, err := gtime.ParseDuration(v)
+		if err != nil || d < 0 {
+			return ResolvedSuppression{}, fmt.Errorf("invalid %s %q, must be a duration such as 5m", o.setting, v)
+		}
+		*o.duration = d
+	}
+	return s, nil
+}
+
+// NewResolvedSuppressingNotifier returns the notifier with the resolved suppression options of
+// its settings applied, or the notifier itself if none is set.
+func NewResolvedSuppressingNotifier(n NotificationChannel, settings *simplejson.Json) NotificationChannel {
+	// The settings are validated by NewFactoryConfig.
+	s, _ := resolvedSuppressionFromSettings(settings)
+	if s == (ResolvedSuppression{}) {
+		return n
+	}
+	return &resolvedSuppressingNotifier{
+		NotificationChannel: n,
+		suppression:         s,
+		blips:               map[model.Fingerprint]*pendingBlip{},
+		log:                 log.New("alerting.notifier.resolved_suppression"),
+	}
+}
+
+type resolvedSuppressingNotifier struct {
+	NotificationChannel
+	suppression ResolvedSuppression
+
+	mtx sync.Mutex
+	// blips are the held firing alerts, by fingerprint.
+	blips map[model.Fingerprint]*pendingBlip
+	log   log.Logger
+}
+
+type pendingBlip struct {
+	timer *time.Timer
+}
+
+// Notify holds the new firing alerts until they fired for the blip duration, and drops the
+// resolved alerts that fired for less than the minimum firing duration. The held alerts that
+// resolve are sent as a blip, the others are sent on their own once held for the blip duration.
+// Dry runs are sent as is.
+func (n *resolvedSuppressingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	if _, ok := dryRunFromContext(ctx); ok {
+		return n.NotificationChannel.Notify(ctx, as...)
+	}
+
+	now, ok := notify.Now(ctx)
+	if !ok {
+		now = time.Now()
+	}
+	alerts := make([]*types.Alert, 0, len(as))
+	n.mtx.Lock()
+	for _, a := range as {
+		fp := a.Fingerprint()
+		if !a.ResolvedAt(now) {
+			if n.suppression.BlipDuration > 0 && now.Sub(a.StartsAt) < n.suppression.BlipDuration {
+				if _, ok := n.blips[fp]; !ok {
+					n.hold(ctx, fp, a, a.StartsAt.Add(n.suppression.BlipDuration).Sub(now))
+				}
+				continue
+			}
+			alerts = append(alerts, a)
+			continue
+		}
+
+		firedFor := a.EndsAt.Sub(a.StartsAt)
+		if b, ok := n.blips[fp]; ok {
+			b.timer.Stop()
+			delete(n.blips, fp)
+			alerts = append(alerts, blipAlert(a, firedFor))
+			continue
+		}
+		if firedFor < n.suppression.MinFiringDuration {
+			n.log.Debug("suppressing resolved notification", "alert", a.Name(), "firedFor", firedFor)
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	n.mtx.Unlock()
+
+	if len(alerts) == 0 {
+		return true, nil
+	}
+	return n.NotificationChannel.Notify(ctx, alerts...)
+}
+
+// hold schedules the notification of the firing alert after the delay, unless it resolves
+// before. It must be called with the lock held.
+func (n *resolvedSuppressingNotifier) hold(ctx context.Context, fp model.Fingerprint, a *types.Alert, delay time.Duration) {
+	b := &pendingBlip{}
+	n.blips[fp] = b
+	// The context of the notification is canceled once it is sent, but its values are needed
+	// to send the alert.
+	ctx = detachedContext{ctx}
+	b.timer = time.AfterFunc(delay, func() {
+		n.mtx.Lock()
+		if n.blips[fp] != b {
+			n.mtx.Unlock()
+			return
+		}
+		delete(n.blips, fp)
+		n.mtx.Unlock()
+
+		ctx, cancel := context.WithTimeout(ctx, blipNotifyTimeout)
+		defer cancel()
+		if _, err := n.NotificationChannel.Notify(ctx, a); err != nil {
+			n.log.Error("failed to send held firing alert", "alert", a.Name(), "err", err)
+		}
+	})
+}
+
+// blipAlert returns a copy of the resolved alert with the BlipAnnotation.
+func blipAlert(a *types.Alert, firedFor time.Duration) *types.Alert {
+	blip := *a
+	blip.Annotations = make(model.LabelSet, len(a.Annotations)+1)
+	for k, v := range a.Annotations {
+		blip.Annotations[k] = v
+	}
+	blip.Annotations[BlipAnnotation] = model.LabelValue(firedFor.Round(time.Second).String())
+	return &blip
+}
+
+// WithoutCancel returns a context with the values of its parent, which is never canceled, to
+// send the alerts of a notification after it returns.
+func WithoutCancel(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+// detachedContext has the values of its parent, but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,137 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// recordingNotifier records the alerts of each notification.
+type recordingNotifier struct {
+	mtx           sync.Mutex
+	notifications [][]*types.Alert
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, as ...*types.Alert) (bool, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.notifications = append(r.notifications, as)
+	return true, nil
+}
+
+func (r *recordingNotifier) SendResolved() bool {
+	return true
+}
+
+func (r *recordingNotifier) sent() [][]*types.Alert {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([][]*types.Alert(nil), r.notifications...)
+}
+
+func TestResolvedSuppressingNotifier(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	ctx := notify.WithNow(context.Background(), now)
+	alert := func(name string, startsAt, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": model.LabelValue(name)},
+			Annotations: model.LabelSet{"summary": "summary"},
+			StartsAt:    startsAt,
+			EndsAt:      endsAt,
+		}}
+	}
+	newNotifier := func(t *testing.T, settings string) (NotificationChannel, *recordingNotifier) {
+		t.Helper()
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		r := &recordingNotifier{}
+		return NewResolvedSuppressingNotifier(r, s), r
+	}
+
+	t.Run("returns the notifier without options", func(t *testing.T) {
+		n, r := newNotifier(t, `{"resolvedMinFiringDuration": ""}`)
+		require.Same(t, r, n)
+	})
+
+	t.Run("drops the resolved alerts that fired for less than the minimum duration", func(t *testing.T) {
+		n, r := newNotifier(t, `{"resolvedMinFiringDuration": "5m"}`)
+		short := alert("Short", now.Add(-3*time.Minute), now.Add(-time.Minute))
+		long := alert("Long", now.Add(-20*time.Minute), now.Add(-time.Minute))
+		firing := alert("Firing", now.Add(-time.Minute), now.Add(time.Hour))
+
+		ok, err := n.Notify(ctx, short, long, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = n.Notify(ctx, short)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, [][]*types.Alert{{long, firing}}, r.sent())
+	})
+
+	t.Run("sends the alerts resolved while held as a blip", func(t *testing.T) {
+		n, r := newNotifier(t, `{"blipDuration": "1h", "resolvedMinFiringDuration": "5m"}`)
+		firing := alert("Blip", now.Add(-time.Minute), now.Add(time.Hour))
+		old := alert("Old", now.Add(-2*time.Hour), now.Add(time.Hour))
+
+		_, err := n.Notify(ctx, firing, old)
+		require.NoError(t, err)
+		_, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		resolved := alert("Blip", now.Add(-time.Minute), now.Add(-30*time.Second))
+		_, err = n.Notify(ctx, resolved)
+		require.NoError(t, err)
+
+		sent := r.sent()
+		require.Len(t, sent, 2)
+		require.Equal(t, []*types.Alert{old}, sent[0])
+		require.Len(t, sent[1], 1)
+		require.Equal(t, resolved.Labels, sent[1][0].Labels)
+		require.Equal(t, model.LabelSet{"summary": "summary", BlipAnnotation: "30s"}, sent[1][0].Annotations)
+		// The annotations of the alert of the Alertmanager are unchanged.
+		require.Equal(t, model.LabelSet{"summary": "summary"}, resolved.Annotations)
+	})
+
+	t.Run("sends the held alerts that are still firing after the blip duration", func(t *testing.T) {
+		n, r := newNotifier(t, `{"blipDuration": "1h"}`)
+		firing := alert("Flapping", now.Add(-time.Hour).Add(50*time.Millisecond), now.Add(time.Hour))
+
+		_, err := n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.Empty(t, r.sent())
+		require.Eventually(t, func() bool {
+			return len(r.sent()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, [][]*types.Alert{{firing}}, r.sent())
+	})
+
+	t.Run("sends dry runs as is", func(t *testing.T) {
+		n, r := newNotifier(t, `{"blipDuration": "1h", "resolvedMinFiringDuration": "5m"}`)
+		firing := alert("Firing", now.Add(-time.Minute), now.Add(time.Hour))
+		resolved := alert("Resolved", now.Add(-time.Minute), now.Add(-30*time.Second))
+
+		_, err := n.Notify(WithDryRun(ctx, &DryRun{}), firing, resolved)
+		require.NoError(t, err)
+		require.Equal(t, [][]*types.Alert{{firing, resolved}}, r.sent())
+	})
+}
+
+func TestResolvedSuppressionFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"resolvedMinFiringDuration": "5m", "blipDuration": "90s"}`))
+	require.NoError(t, err)
+	s, err := resolvedSuppressionFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, ResolvedSuppression{MinFiringDuration: 5 * time.Minute, BlipDuration: 90 * time.Second}, s)
+
+	settings, err = simplejson.NewJson([]byte(`{"blipDuration": "soon"}`))
+	require.NoError(t, err)
+	_, err = resolvedSuppressionFromSettings(settings)
+	require.EqualError(t, err, `invalid blipDuration "soon", must be a duration such as 5m`)
+}
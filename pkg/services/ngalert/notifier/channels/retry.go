@@ -0,0 +1,230 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The contact point settings of the retries of the webhooks.
+	retryMaxAttemptsSetting    = "retryMaxAttempts"
+	retryInitialBackoffSetting = "retryInitialBackoff"
+	retryMaxBackoffSetting     = "retryMaxBackoff"
+	retryStatusCodesSetting    = "retryStatusCodes"
+
+	// retryMaxAttemptsLimit is the highest number of attempts of a webhook.
+	retryMaxAttemptsLimit = 10
+)
+
+var notifierRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "alerting",
+	Name:      "notifier_retries_total",
+	Help:      "The total number of webhooks of the integrations retried after a transient failure.",
+}, []string{"integration"})
+
+// RetryPolicy is how a contact point retries the webhooks that fail with a transient error, a
+// retryable status code or a connection error, before the notification fails. The notification
+// is still retried by the Alertmanager after that, but only at the next flush of its group.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a webhook, 1 disables the retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled for every following retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the longest delay between two attempts, including the delays requested by
+	// the Retry-After headers of the responses.
+	MaxBackoff time.Duration
+	// StatusCodes are the status codes retried. A code under 10, such as 5, retries all the
+	// status codes of its class.
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy is the retry policy of the contact points that do not set one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    1,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	StatusCodes:    []int{429, 500, 502, 503, 504},
+}
+
+// retryPolicyFromSettings returns the retry policy of a contact point.
+func retryPolicyFromSettings(settings *simplejson.Json) (RetryPolicy, error) {
+	p := DefaultRetryPolicy
+	if settings == nil {
+		return p, nil
+	}
+
+	// The number of attempts is a number in provisioned contact points and a string in the UI.
+	if v := settings.Get(retryMaxAttemptsSetting).Interface(); v != nil && v != "" {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 1 || n > retryMaxAttemptsLimit {
+			return RetryPolicy{}, fmt.Errorf("invalid %s %v, must be between 1 and %d", retryMaxAttemptsSetting, v, retryMaxAttemptsLimit)
+		}
+		p.MaxAttempts = n
+	}
+
+	for _, o := range []struct {
+		setting  string
+		duration *time.Duration
+	}{
+		{setting: retryInitialBackoffSetting, duration: &p.InitialBackoff},
+		{setting: retryMaxBackoffSetting, duration: &p.MaxBackoff},
+	} {
+		v := strings.TrimSpace(settings.Get(o.setting).MustString())
+		if v == "" {
+			continue
+		}
+		d, err := gtime.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return RetryPolicy{}, fmt.Errorf("invalid %s %q, must be a duration such as 5s", o.setting, v)
+		}
+		*o.duration = d
+	}
+	if p.InitialBackoff > p.MaxBackoff {
+		return RetryPolicy{}, fmt.Errorf("invalid %s %s, must not be longer than the %s %s", retryInitialBackoffSetting, p.InitialBackoff, retryMaxBackoffSetting, p.MaxBackoff)
+	}
+
+	if v := strings.TrimSpace(settings.Get(retryStatusCodesSetting).MustString()); v != "" {
+		codes, err := parseRetryStatusCodes(v)
+		if err != nil {
+			return RetryPolicy{}, err
+		}
+		p.StatusCodes = codes
+	}
+	return p, nil
+}
+
+// parseRetryStatusCodes parses a comma-separated list of status codes, such as 429, or classes
+// of status codes, such as 5xx.
+func parseRetryStatusCodes(s string) ([]int, error) {
+	var codes []int
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		var n int
+		var err error
+		if len(c) == 3 && strings.HasSuffix(c, "xx") {
+			n, err = strconv.Atoi(c[:1])
+			if n < 4 {
+				err = fmt.Errorf("not a class of errors")
+			}
+		} else {
+			n, err = strconv.Atoi(c)
+			if n < 400 || n > 599 {
+				err = fmt.Errorf("not an error status code")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q, must be a list of status codes such as 429, 5xx", retryStatusCodesSetting, s)
+		}
+		codes = append(codes, n)
+	}
+	return codes, nil
+}
+
+// retryable returns whether the response with the status code is retried, 0 being a connection
+// error.
+func (p RetryPolicy) retryable(statusCode int) bool {
+	if statusCode == 0 {
+		return true
+	}
+	for _, c := range p.StatusCodes {
+		if c == statusCode || c == statusCode/100 {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the attempt following the attempt-th one, the delay of the
+// Retry-After header of its response taking precedence.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		d = p.InitialBackoff
+		for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+			d *= 2
+		}
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, in seconds or an HTTP date, or 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// retryingNotificationService retries the webhooks that fail with a transient error according to
+// the retry policy of the contact point. The emails are not retried.
+type retryingNotificationService struct {
+	notifications.Service
+	policy          RetryPolicy
+	integrationType string
+	log             log.Logger
+}
+
+func (s *retryingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	if s.policy.MaxAttempts <= 1 {
+		return s.Service.SendWebhookSync(ctx, cmd)
+	}
+
+	for attempt := 1; ; attempt++ {
+		statusCode, retryAfter := 0, time.Duration(0)
+		attemptCmd := *cmd
+		attemptCmd.Validation = func(body []byte, code int) error {
+			statusCode = code
+			if cmd.Validation != nil {
+				return cmd.Validation(body, code)
+			}
+			return nil
+		}
+		attemptCmd.ResponseHeaders = func(header http.Header) {
+			retryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+			if cmd.ResponseHeaders != nil {
+				cmd.ResponseHeaders(header)
+			}
+		}
+
+		err := s.Service.SendWebhookSync(ctx, &attemptCmd)
+		if err == nil || attempt >= s.policy.MaxAttempts || !s.policy.retryable(statusCode) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := s.policy.backoff(attempt, retryAfter)
+		s.log.Debug("Retrying webhook", "integration", s.integrationType, "attempt", attempt, "statuscode", statusCode, "delay", delay, "err", err)
+		notifierRetries.WithLabelValues(s.integrationType).Inc()
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// responsesNotificationService answers the webhooks with the status codes in order, 0 being a
+// connection error.
+type responsesNotificationService struct {
+	*notificationServiceMock
+	statusCodes []int
+	header      http.Header
+	attempts    int
+}
+
+func (ns *responsesNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	code := ns.statusCodes[ns.attempts]
+	ns.attempts++
+	if code == 0 {
+		return errors.New("connection refused")
+	}
+	if cmd.ResponseHeaders != nil {
+		cmd.ResponseHeaders(ns.header)
+	}
+	if cmd.Validation != nil {
+		if err := cmd.Validation(nil, code); err != nil {
+			return fmt.Errorf("webhook failed validation: %w", err)
+		}
+	}
+	if code/100 != 2 {
+		return fmt.Errorf("webhook response status %d", code)
+	}
+	return nil
+}
+
+func TestRetryingNotificationService(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, StatusCodes: []int{429, 5}}
+	newService := func(ns *responsesNotificationService, policy RetryPolicy) *retryingNotificationService {
+		return &retryingNotificationService{Service: ns, policy: policy, integrationType: "webhook", log: log.New("test")}
+	}
+
+	cases := []struct {
+		name        string
+		statusCodes []int
+		policy      RetryPolicy
+		expAttempts int
+		expErr      string
+	}{
+		{name: "retries server errors until success", statusCodes: []int{503, 502, 200}, policy: policy, expAttempts: 3},
+		{name: "retries connection errors", statusCodes: []int{0, 200}, policy: policy, expAttempts: 2},
+		{name: "stops after the max attempts", statusCodes: []int{500, 500, 500}, policy: policy, expAttempts: 3, expErr: "webhook response status 500"},
+		{name: "does not retry other status codes", statusCodes: []int{400}, policy: policy, expAttempts: 1, expErr: "webhook response status 400"},
+		{name: "does not retry by default", statusCodes: []int{503}, policy: DefaultRetryPolicy, expAttempts: 1, expErr: "webhook response status 503"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ns := &responsesNotificationService{notificationServiceMock: mockNotificationService(), statusCodes: c.statusCodes}
+			err := newService(ns, c.policy).SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "http://localhost"})
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expAttempts, ns.attempts)
+		})
+	}
+
+	t.Run("the validation and the response headers of the integration are kept", func(t *testing.T) {
+		ns := &responsesNotificationService{notificationServiceMock: mockNotificationService(), statusCodes: []int{503, 200}, header: http.Header{"X-Request-Id": []string{"1"}}}
+		var codes []int
+		var ids []string
+		err := newService(ns, policy).SendWebhookSync(context.Background(), &models.SendWebhookSync{
+			Url: "http://localhost",
+			Validation: func(body []byte, statusCode int) error {
+				codes = append(codes, statusCode)
+				if statusCode != 200 {
+					return errors.New("unavailable")
+				}
+				return nil
+			},
+			ResponseHeaders: func(header http.Header) { ids = append(ids, header.Get("X-Request-Id")) },
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{503, 200}, codes)
+		require.Equal(t, []string{"1", "1"}, ids)
+	})
+
+	t.Run("the retries stop when the context is done", func(t *testing.T) {
+		ns := &responsesNotificationService{notificationServiceMock: mockNotificationService(), statusCodes: []int{503, 200}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := newService(ns, policy).SendWebhookSync(ctx, &models.SendWebhookSync{Url: "http://localhost"})
+		require.EqualError(t, err, "webhook response status 503")
+		require.Equal(t, 1, ns.attempts)
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, p.backoff(1, 0))
+	require.Equal(t, 2*time.Second, p.backoff(2, 0))
+	require.Equal(t, 4*time.Second, p.backoff(3, 0))
+	require.Equal(t, 5*time.Second, p.backoff(4, 0))
+	require.Equal(t, 3*time.Second, p.backoff(1, 3*time.Second))
+	require.Equal(t, 5*time.Second, p.backoff(1, time.Minute))
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, 7*time.Second, parseRetryAfter("7", now))
+	require.Equal(t, 10*time.Second, parseRetryAfter("Wed, 01 Jun 2022 12:00:10 GMT", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestRetryPolicyFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"retryMaxAttempts": 4, "retryInitialBackoff": "500ms", "retryMaxBackoff": "1m", "retryStatusCodes": "408, 429, 5xx"}`))
+	require.NoError(t, err)
+	p, err := retryPolicyFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, RetryPolicy{MaxAttempts: 4, InitialBackoff: 500 * time.Millisecond, MaxBackoff: time.Minute, StatusCodes: []int{408, 429, 5}}, p)
+	require.True(t, p.retryable(504))
+	require.True(t, p.retryable(0))
+	require.False(t, p.retryable(404))
+
+	p, err = retryPolicyFromSettings(simplejson.New())
+	require.NoError(t, err)
+	require.Equal(t, DefaultRetryPolicy, p)
+
+	for settings, expErr := range map[string]string{
+		`{"retryMaxAttempts": "11"}`:                              `invalid retryMaxAttempts 11, must be between 1 and 10`,
+		`{"retryInitialBackoff": "soon"}`:                         `invalid retryInitialBackoff "soon", must be a duration such as 5s`,
+		`{"retryInitialBackoff": "1m", "retryMaxBackoff": "10s"}`: `invalid retryInitialBackoff 1m0s, must not be longer than the retryMaxBackoff 10s`,
+		`{"retryStatusCodes": "429, 200"}`:                        `invalid retryStatusCodes "429, 200", must be a list of status codes such as 429, 5xx`,
+		`{"retryStatusCodes": "3xx"}`:                             `invalid retryStatusCodes "3xx", must be a list of status codes such as 429, 5xx`,
+	} {
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		_, err = retryPolicyFromSettings(s)
+		require.EqualError(t, err, expErr, settings)
+	}
+}
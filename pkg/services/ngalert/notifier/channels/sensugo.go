@@ -151,7 +151,7 @@ func (sn *SensuGoNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 			return nil
 		}, as...)
 
-	ruleURL := joinUrlPath(sn.tmpl.ExternalURL.String(), "/alerting/list", sn.log)
+	ruleURL := sn.RuleListURL(sn.tmpl.ExternalURL)
 	labels["ruleURL"] = ruleURL
 
 	bodyMsgType := map[string]interface{}{
@@ -0,0 +1,375 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultServiceNowTable         = "incident"
+	defaultServiceNowSeverityLabel = "severity"
+	defaultServiceNowResolveState  = "6"
+	defaultServiceNowCloseCode     = "Resolved by caller"
+	defaultServiceNowCloseNotes    = "The alert is resolved."
+
+	// serviceNowCorrelationPrefix is the prefix of the correlation ID that links an incident to
+	// the hash of its alert group, so that a group only ever has one active incident.
+	serviceNowCorrelationPrefix = "grafana-"
+
+	serviceNowMaxShortDescriptionLength = 160
+	serviceNowMaxDescriptionLength      = 4000
+	// serviceNowLowestLevel is the value of the lowest urgency and impact.
+	serviceNowLowestLevel = "3"
+)
+
+// serviceNowLevels maps common values of the severity label to the urgency and the impact of
+// incidents, from 1 (high) to 3 (low).
+var serviceNowLevels = map[string]string{
+	"critical": "1",
+	"urgent":   "1",
+	"page":     "1",
+	"high":     "2",
+	"error":    "2",
+	"major":    "2",
+	"warning":  "2",
+	"medium":   "2",
+	"low":      "3",
+	"minor":    "3",
+	"info":     "3",
+	"none":     "3",
+	"debug":    "3",
+}
+
+type ServiceNowConfig struct {
+	*NotificationChannelConfig
+	URL              string
+	User             string
+	Password         string
+	Table            string
+	AssignmentGroup  string
+	Category         string
+	SeverityLabel    string
+	Urgencies        map[string]string
+	Impacts          map[string]string
+	ShortDescription string
+	Description      string
+	ResolveState     string
+	CloseCode        string
+	CloseNotes       string
+}
+
+func ServiceNowFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewServiceNowConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewServiceNowNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewServiceNowConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ServiceNowConfig, error) {
+	instanceURL := strings.TrimSuffix(config.Settings.Get("url").MustString(), "/")
+	if instanceURL == "" {
+		return nil, errors.New("could not find url in settings")
+	}
+	if _, err := url.Parse(instanceURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	user := config.Settings.Get("user").MustString()
+	if user == "" {
+		return nil, errors.New("could not find user in settings")
+	}
+	password := decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString())
+	if password == "" {
+		return nil, errors.New("could not find password in settings")
+	}
+	table := config.Settings.Get("table").MustString(defaultServiceNowTable)
+	if table == "" || strings.ContainsAny(table, "/?#") {
+		return nil, fmt.Errorf("invalid table %q", table)
+	}
+
+	urgencies, err := serviceNowLevelsFromSettings(config.Settings.Get("urgencies"), "urgency")
+	if err != nil {
+		return nil, err
+	}
+	impacts, err := serviceNowLevelsFromSettings(config.Settings.Get("impacts"), "impact")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServiceNowConfig{
+		NotificationChannelConfig: config,
+		URL:                       instanceURL,
+		User:                      user,
+		Password:                  password,
+		Table:                     table,
+		AssignmentGroup:           config.Settings.Get("assignmentGroup").MustString(),
+		Category:                  config.Settings.Get("category").MustString(),
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultServiceNowSeverityLabel),
+		Urgencies:                 urgencies,
+		Impacts:                   impacts,
+		ShortDescription:          config.Settings.Get("shortDescription").MustString(`{{ template "default.title" . }}`),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		ResolveState:              config.Settings.Get("resolveState").MustString(defaultServiceNowResolveState),
+		CloseCode:                 config.Settings.Get("closeCode").MustString(defaultServiceNowCloseCode),
+		CloseNotes:                config.Settings.Get("closeNotes").MustString(defaultServiceNowCloseNotes),
+	}, nil
+}
+
+// serviceNowLevelsFromSettings returns the urgencies or the impacts of the setting.
+func serviceNowLevelsFromSettings(setting *simplejson.Json, name string) (map[string]string, error) {
+	levels, err := severityMapFromSettings(setting, name, serviceNowLevels)
+	if err != nil {
+		return nil, err
+	}
+	for severity, level := range levels {
+		if level != "1" && level != "2" && level != "3" {
+			return nil, fmt.Errorf("invalid %s %q for severity %q, must be 1, 2 or 3", name, level, severity)
+		}
+	}
+	return levels, nil
+}
+
+// NewServiceNowNotifier is the constructor for the ServiceNow notifier.
+func NewServiceNowNotifier(config *ServiceNowConfig, ns notifications.WebhookSender, t *template.Template) *ServiceNowNotifier {
+	return &ServiceNowNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:              config.URL,
+		User:             config.User,
+		Password:         config.Password,
+		Table:            config.Table,
+		AssignmentGroup:  config.AssignmentGroup,
+		Category:         config.Category,
+		SeverityLabel:    config.SeverityLabel,
+		Urgencies:        config.Urgencies,
+		Impacts:          config.Impacts,
+		ShortDescription: config.ShortDescription,
+		Description:      config.Description,
+		ResolveState:     config.ResolveState,
+		CloseCode:        config.CloseCode,
+		CloseNotes:       config.CloseNotes,
+		log:              log.New("alerting.notifier.servicenow"),
+		ns:               ns,
+		tmpl:             t,
+	}
+}
+
+// ServiceNowNotifier is responsible for opening a ServiceNow incident per alert group with the
+// Table API, updating it while the group is firing and closing it when the group is resolved.
+type ServiceNowNotifier struct {
+	*Base
+	URL              string
+	User             string
+	Password         string
+	Table            string
+	AssignmentGroup  string
+	Category         string
+	SeverityLabel    string
+	Urgencies        map[string]string
+	Impacts          map[string]string
+	ShortDescription string
+	Description      string
+	ResolveState     string
+	CloseCode        string
+	CloseNotes       string
+	log              log.Logger
+	ns               notifications.WebhookSender
+	tmpl             *template.Template
+}
+
+type serviceNowRecord struct {
+	SysID  string `json:"sys_id"`
+	Number string `json:"number"`
+}
+
+type serviceNowErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	} `json:"error"`
+}
+
+// Notify opens an incident when the alert group starts firing, adds the updated description to
+// the work notes of the incident while the group keeps firing, and closes the incident when the
+// group is resolved. The active incident of the group is found by its correlation ID, so
+// notifications that are retried or repeated do not open duplicates.
+func (sn *ServiceNowNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("sending ServiceNow notification", "notification", sn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	correlationID := serviceNowCorrelationPrefix + groupKey.Hash()
+
+	incident, err := sn.findActiveIncident(ctx, correlationID)
+	if err != nil {
+		sn.log.Error("failed to search ServiceNow incidents", "err", err, "notification", sn.Name)
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+	description, _ := sn.Truncate(tmpl(sn.Description), serviceNowMaxDescriptionLength)
+
+	resolved := types.Alerts(as...).Status() == model.AlertResolved
+	var fields map[string]string
+	switch {
+	case !resolved && incident == nil:
+		shortDescription, _ := sn.Truncate(strings.Join(strings.Fields(tmpl(sn.ShortDescription)), " "), serviceNowMaxShortDescriptionLength)
+		fields = map[string]string{
+			"short_description":   shortDescription,
+			"description":         description,
+			"correlation_id":      correlationID,
+			"correlation_display": "Grafana",
+			"urgency":             sn.level(sn.Urgencies, as),
+			"impact":              sn.level(sn.Impacts, as),
+		}
+		if sn.AssignmentGroup != "" {
+			fields["assignment_group"] = sn.AssignmentGroup
+		}
+		if sn.Category != "" {
+			fields["category"] = sn.Category
+		}
+	case !resolved:
+		fields = map[string]string{
+			"work_notes": description,
+			"urgency":    sn.level(sn.Urgencies, as),
+			"impact":     sn.level(sn.Impacts, as),
+		}
+	case incident != nil:
+		closeNotes, _ := sn.Truncate(tmpl(sn.CloseNotes), serviceNowMaxDescriptionLength)
+		fields = map[string]string{"work_notes": closeNotes}
+		if sn.ResolveState != "" {
+			fields["state"] = sn.ResolveState
+			fields["close_code"] = sn.CloseCode
+			fields["close_notes"] = closeNotes
+		}
+	default:
+		// The incident was already closed.
+		return true, nil
+	}
+
+	if tmplErr != nil {
+		sn.log.Warn("failed to template ServiceNow incident", "err", tmplErr.Error())
+	}
+
+	path := "/api/now/table/" + url.PathEscape(sn.Table)
+	method := "POST"
+	if incident != nil {
+		path += "/" + url.PathEscape(incident.SysID)
+		method = "PATCH"
+	}
+	var result struct {
+		Result serviceNowRecord `json:"result"`
+	}
+	if err := sn.request(ctx, method, path, fields, &result); err != nil {
+		sn.log.Error("failed to send ServiceNow notification", "err", err, "notification", sn.Name)
+		return false, err
+	}
+	sn.log.Debug("sent ServiceNow notification", "incident", result.Result.Number, "correlationId", correlationID)
+
+	return true, nil
+}
+
+// level returns the highest urgency or impact of the firing alerts, or the lowest one if their
+// severity is unknown.
+func (sn *ServiceNowNotifier) level(levels map[string]string, as []*types.Alert) string {
+	result := serviceNowLowestLevel
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(sn.SeverityLabel)]))
+		if level, ok := levels[severity]; ok && level < result {
+			result = level
+		}
+	}
+	return result
+}
+
+func (sn *ServiceNowNotifier) findActiveIncident(ctx context.Context, correlationID string) (*serviceNowRecord, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", "correlation_id="+correlationID+"^active=true^ORDERBYDESCsys_created_on")
+	query.Set("sysparm_fields", "sys_id,number")
+	query.Set("sysparm_limit", "1")
+
+	var result struct {
+		Result []serviceNowRecord `json:"result"`
+	}
+	if err := sn.request(ctx, "GET", "/api/now/table/"+url.PathEscape(sn.Table)+"?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Result) == 0 {
+		return nil, nil
+	}
+	return &result.Result[0], nil
+}
+
+// request sends a request to the ServiceNow Table API and decodes the response into out.
+func (sn *ServiceNowNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        sn.URL + path,
+		User:       sn.User,
+		Password:   sn.Password,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Accept":       "application/json",
+			"Content-Type": "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return serviceNowError(body, statusCode)
+			}
+			if len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return sn.ns.SendWebhookSync(ctx, cmd)
+}
+
+func serviceNowError(body []byte, statusCode int) error {
+	var resp serviceNowErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error.Message == "" {
+		return fmt.Errorf("the ServiceNow API returned status %d", statusCode)
+	}
+	if resp.Error.Detail != "" {
+		return fmt.Errorf("the ServiceNow API returned status %d: %s: %s", statusCode, resp.Error.Message, resp.Error.Detail)
+	}
+	return fmt.Errorf("the ServiceNow API returned status %d: %s", statusCode, resp.Error.Message)
+}
+
+func (sn *ServiceNowNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
@@ -0,0 +1,272 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeServiceNowIncident struct {
+	SysID     string
+	Fields    map[string]string
+	WorkNotes []string
+}
+
+// fakeServiceNow implements the parts of the ServiceNow Table API used by the notifier.
+type fakeServiceNow struct {
+	incidents []*fakeServiceNowIncident
+	requests  []*models.SendWebhookSync
+}
+
+func (s *fakeServiceNow) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	s.requests = append(s.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in map[string]string
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case cmd.HttpMethod == "GET" && u.Path == "/api/now/table/incident":
+		query := u.Query().Get("sysparm_query")
+		result := []map[string]string{}
+		for _, incident := range s.incidents {
+			if incident.Fields["state"] != "6" && strings.HasPrefix(query, "correlation_id="+incident.Fields["correlation_id"]+"^") {
+				result = append(result, map[string]string{"sys_id": incident.SysID, "number": "INC" + incident.SysID})
+			}
+		}
+		return respond(200, map[string]interface{}{"result": result})
+	case cmd.HttpMethod == "POST" && u.Path == "/api/now/table/incident":
+		if in["category"] == "unknown" {
+			return respond(403, map[string]interface{}{"error": map[string]string{"message": "Operation Failed", "detail": "ACL Exception Insert Failed due to security constraints"}})
+		}
+		incident := &fakeServiceNowIncident{SysID: fmt.Sprint(len(s.incidents) + 1), Fields: in}
+		s.incidents = append(s.incidents, incident)
+		return respond(201, map[string]interface{}{"result": map[string]string{"sys_id": incident.SysID}})
+	case cmd.HttpMethod == "PATCH" && strings.HasPrefix(u.Path, "/api/now/table/incident/"):
+		incident := s.incidents[0]
+		for _, i := range s.incidents {
+			if i.SysID == strings.TrimPrefix(u.Path, "/api/now/table/incident/") {
+				incident = i
+			}
+		}
+		for k, v := range in {
+			if k == "work_notes" {
+				incident.WorkNotes = append(incident.WorkNotes, v)
+				continue
+			}
+			incident.Fields[k] = v
+		}
+		return respond(200, map[string]interface{}{"result": map[string]string{"sys_id": incident.SysID}})
+	}
+	return respond(400, map[string]interface{}{"error": map[string]string{"message": "Invalid table"}})
+}
+
+func TestServiceNowNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	critical := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	warning := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "severity": "warning"},
+		},
+	}
+	resolve := func(a *types.Alert) *types.Alert {
+		r := &types.Alert{Alert: a.Alert}
+		r.EndsAt = r.StartsAt.Add(1)
+		return r
+	}
+
+	t.Run("one incident per group, updated and closed when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":             "https://example.service-now.com/",
+			"user":            "grafana",
+			"password":        "secret",
+			"assignmentGroup": "Operations",
+			"impacts":         "critical=2",
+		})
+		cfg, err := NewServiceNowConfig(&NotificationChannelConfig{Name: "servicenow_testing", Type: "servicenow", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		sn := &fakeServiceNow{}
+		n := NewServiceNowNotifier(cfg, sn, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		ok, err := n.Notify(ctx, warning)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sn.incidents, 1)
+
+		incident := sn.incidents[0]
+		require.Equal(t, "[FIRING:1] (alert2 warning)", incident.Fields["short_description"])
+		require.Equal(t, "2", incident.Fields["urgency"])
+		require.Equal(t, "2", incident.Fields["impact"])
+		require.Equal(t, "Operations", incident.Fields["assignment_group"])
+		require.Equal(t, "Grafana", incident.Fields["correlation_display"])
+		require.Equal(t, "grafana-"+notify.Key("alertname").Hash(), incident.Fields["correlation_id"])
+
+		req := sn.requests[0]
+		require.Equal(t, "GET", req.HttpMethod)
+		require.True(t, strings.HasPrefix(req.Url, "https://example.service-now.com/api/now/table/incident?"))
+		require.Equal(t, "grafana", req.User)
+		require.Equal(t, "secret", req.Password)
+
+		// The incident is updated with the most severe alert.
+		ok, err = n.Notify(ctx, warning, critical)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sn.incidents, 1)
+		require.Equal(t, "1", incident.Fields["urgency"])
+		require.Equal(t, "2", incident.Fields["impact"])
+		require.Len(t, incident.WorkNotes, 1)
+		require.Contains(t, incident.WorkNotes[0], "CPU is high")
+
+		ok, err = n.Notify(ctx, resolve(warning), resolve(critical))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "6", incident.Fields["state"])
+		require.Equal(t, "Resolved by caller", incident.Fields["close_code"])
+		require.Equal(t, "The alert is resolved.", incident.Fields["close_notes"])
+
+		// Nothing is left to close.
+		ok, err = n.Notify(ctx, resolve(warning))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// The group fires again after its incident was closed.
+		ok, err = n.Notify(ctx, warning)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sn.incidents, 2)
+	})
+
+	t.Run("resolved alerts only add work notes without a resolve state", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":          "https://example.service-now.com",
+			"user":         "grafana",
+			"password":     "secret",
+			"resolveState": "",
+		})
+		cfg, err := NewServiceNowConfig(&NotificationChannelConfig{Name: "servicenow_testing", Type: "servicenow", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		sn := &fakeServiceNow{}
+		n := NewServiceNowNotifier(cfg, sn, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		_, err = n.Notify(ctx, critical)
+		require.NoError(t, err)
+		_, err = n.Notify(ctx, resolve(critical))
+		require.NoError(t, err)
+		require.Empty(t, sn.incidents[0].Fields["state"])
+		require.Equal(t, []string{"The alert is resolved."}, sn.incidents[0].WorkNotes)
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"url":      "https://example.service-now.com",
+			"user":     "grafana",
+			"password": "secret",
+			"category": "unknown",
+		})
+		cfg, err := NewServiceNowConfig(&NotificationChannelConfig{Name: "servicenow_testing", Type: "servicenow", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewServiceNowNotifier(cfg, &fakeServiceNow{}, tmpl).Notify(notify.WithGroupKey(context.Background(), "alertname"), critical)
+		require.EqualError(t, err, "the ServiceNow API returned status 403: Operation Failed: ACL Exception Insert Failed due to security constraints")
+		require.False(t, ok)
+	})
+}
+
+func TestNewServiceNowConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expUrgencies map[string]string
+		expInitError string
+	}{
+		{
+			name:         "Provisioned urgencies",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "user": "grafana", "password": "secret", "urgencies": map[string]interface{}{"Warning": 3}},
+			expUrgencies: map[string]string{"warning": "3", "critical": "1"},
+		}, {
+			name:         "Error when the url is missing",
+			settings:     map[string]interface{}{"user": "grafana", "password": "secret"},
+			expInitError: "could not find url in settings",
+		}, {
+			name:         "Error when the user is missing",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "password": "secret"},
+			expInitError: "could not find user in settings",
+		}, {
+			name:         "Error when the password is missing",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "user": "grafana"},
+			expInitError: "could not find password in settings",
+		}, {
+			name:         "Error with an invalid table",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "user": "grafana", "password": "secret", "table": "incident/1"},
+			expInitError: `invalid table "incident/1"`,
+		}, {
+			name:         "Error with an invalid impact",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "user": "grafana", "password": "secret", "impacts": "critical=high"},
+			expInitError: `invalid impact "high" for severity "critical", must be 1, 2 or 3`,
+		}, {
+			name:         "Error with an invalid urgency mapping",
+			settings:     map[string]interface{}{"url": "https://example.service-now.com", "user": "grafana", "password": "secret", "urgencies": "critical"},
+			expInitError: `invalid urgency mapping "critical", must be severity=urgency`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "servicenow_testing", Type: "servicenow", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewServiceNowConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			for severity, urgency := range c.expUrgencies {
+				require.Equal(t, urgency, cfg.Urgencies[severity])
+			}
+		})
+	}
+}
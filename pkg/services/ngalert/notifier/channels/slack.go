@@ -47,6 +47,8 @@ type SlackNotifier struct {
 	MentionGroups  []string
 	MentionChannel string
 	Token          string
+	StatusMessage  bool
+	Proxy          *ProxyConfig
 }
 
 type SlackConfig struct {
@@ -62,6 +64,7 @@ type SlackConfig struct {
 	MentionGroups  []string
 	MentionChannel string
 	Token          string
+	StatusMessage  bool
 }
 
 func SlackFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -99,6 +102,10 @@ func NewSlackConfig(factoryConfig FactoryConfig) (*SlackConfig, error) {
 	if token == "" && apiURL.String() == SlackAPIEndpoint {
 		return nil, errors.New("token must be specified when using the Slack chat API")
 	}
+	statusMessage := channelConfig.Settings.Get("statusMessage").MustBool(false)
+	if statusMessage && (token == "" || !strings.HasSuffix(apiURL.Path, "/chat.postMessage")) {
+		return nil, errors.New("the status message requires the Slack chat API and a token")
+	}
 	mentionUsersStr := channelConfig.Settings.Get("mentionUsers").MustString()
 	mentionUsers := []string{}
 	for _, u := range strings.Split(mentionUsersStr, ",") {
@@ -128,6 +135,7 @@ func NewSlackConfig(factoryConfig FactoryConfig) (*SlackConfig, error) {
 		Token:                     token,
 		Text:                      channelConfig.Settings.Get("text").MustString(`{{ template "default.message" . }}`),
 		Title:                     channelConfig.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		StatusMessage:             statusMessage,
 	}, nil
 }
 
@@ -156,6 +164,8 @@ func NewSlackNotifier(config *SlackConfig,
 		Token:          config.Token,
 		Text:           config.Text,
 		Title:          config.Title,
+		StatusMessage:  config.StatusMessage,
+		Proxy:          config.Proxy,
 		images:         images,
 		webhookSender:  webhookSender,
 		log:            log.New("alerting.notifier.slack"),
@@ -188,8 +198,11 @@ type attachment struct {
 	Ts         int64               `json:"ts,omitempty"`
 }
 
-// Notify sends an alert notification to Slack.
+// Notify sends an alert notification to Slack, or updates the status message of the route.
 func (sn *SlackNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	if sn.StatusMessage {
+		return sn.notifyStatus(ctx, alerts)
+	}
 	sn.log.Debug("building slack message", "alerts", len(alerts))
 	msg, err := sn.buildSlackMessage(ctx, alerts)
 	if err != nil {
@@ -202,7 +215,7 @@ func (sn *SlackNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 	}
 
 	sn.log.Debug("sending Slack API request", "url", sn.URL.String(), "data", string(b))
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, sn.URL.String(), bytes.NewReader(b))
+	request, err := http.NewRequestWithContext(withProxy(ctx, sn.Proxy), http.MethodPost, sn.URL.String(), bytes.NewReader(b))
 	if err != nil {
 		return false, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -218,6 +231,16 @@ func (sn *SlackNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sn.Token))
 	}
 
+	if err := validatePayload(sn.Type, &models.SendWebhookSync{Url: sn.URL.String(), Body: string(b), ContentType: "application/json"}); err != nil {
+		return false, err
+	}
+	if recordDryRun(ctx, urlTarget(sn.URL.String()), string(b)) {
+		return true, nil
+	}
+
+	if err := slackRateLimiter.wait(ctx, sn.URL.String()+"#"+sn.Recipient); err != nil {
+		return false, err
+	}
 	if err := sendSlackRequest(request, sn.log); err != nil {
 		return false, err
 	}
@@ -227,7 +250,23 @@ func (sn *SlackNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 
 // sendSlackRequest sends a request to the Slack API.
 // Stubbable by tests.
-var sendSlackRequest = func(request *http.Request, logger log.Logger) (retErr error) {
+var sendSlackRequest = func(request *http.Request, logger log.Logger) error {
+	_, err := sendSlackAPIRequest(request, logger)
+	return err
+}
+
+// slackAPIError is an error returned by the Slack API, such as message_not_found.
+type slackAPIError struct {
+	Err string
+}
+
+func (e slackAPIError) Error() string {
+	return fmt.Sprintf("failed to make Slack API request: %s", e.Err)
+}
+
+// sendSlackAPIRequest sends a request to the Slack API and returns the body of the response.
+// Stubbable by tests.
+var sendSlackAPIRequest = func(request *http.Request, logger log.Logger) (_ []byte, retErr error) {
 	defer func() {
 		if retErr != nil {
 			logger.Warn("failed to send slack request", "err", retErr)
@@ -238,7 +277,7 @@ var sendSlackRequest = func(request *http.Request, logger log.Logger) (retErr er
 		TLSClientConfig: &tls.Config{
 			Renegotiation: tls.RenegotiateFreelyAsClient,
 		},
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxyFromContext,
 		DialContext: (&net.Dialer{
 			Timeout: 30 * time.Second,
 		}).DialContext,
@@ -250,7 +289,7 @@ var sendSlackRequest = func(request *http.Request, logger log.Logger) (retErr er
 	}
 	resp, err := netClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -260,12 +299,12 @@ var sendSlackRequest = func(request *http.Request, logger log.Logger) (retErr er
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		logger.Error("Slack API request failed", "url", request.URL.String(), "statusCode", resp.Status, "body", string(body))
-		return fmt.Errorf("request to Slack API failed with status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("request to Slack API failed with status code %d", resp.StatusCode)
 	}
 
 	// Slack responds to some requests with a JSON document, that might contain an error.
@@ -278,17 +317,17 @@ var sendSlackRequest = func(request *http.Request, logger log.Logger) (retErr er
 	if err := json.Unmarshal(body, &rslt); err != nil && json.Valid(body) {
 		logger.Error("Failed to unmarshal Slack API response", "url", request.URL.String(), "statusCode", resp.Status,
 			"body", string(body))
-		return fmt.Errorf("failed to unmarshal Slack API response: %s", err)
+		return nil, fmt.Errorf("failed to unmarshal Slack API response: %s", err)
 	}
 
 	if !rslt.Ok && rslt.Err != "" {
 		logger.Error("Sending Slack API request failed", "url", request.URL.String(), "statusCode", resp.Status,
 			"err", rslt.Err)
-		return fmt.Errorf("failed to make Slack API request: %s", rslt.Err)
+		return nil, slackAPIError{Err: rslt.Err}
 	}
 
 	logger.Debug("sending Slack API request succeeded", "url", request.URL.String(), "statusCode", resp.Status)
-	return nil
+	return body, nil
 }
 
 func (sn *SlackNotifier) buildSlackMessage(ctx context.Context, alrts []*types.Alert) (*slackMessage, error) {
@@ -296,7 +335,7 @@ func (sn *SlackNotifier) buildSlackMessage(ctx context.Context, alrts []*types.A
 	var tmplErr error
 	tmpl, _ := TmplText(ctx, sn.tmpl, alrts, sn.log, &tmplErr)
 
-	ruleURL := joinUrlPath(sn.tmpl.ExternalURL.String(), "/alerting/list", sn.log)
+	ruleURL := sn.RuleListURL(sn.tmpl.ExternalURL)
 
 	req := &slackMessage{
 		Channel:   tmpl(sn.Recipient),
@@ -369,5 +408,6 @@ func (sn *SlackNotifier) buildSlackMessage(ctx context.Context, alrts []*types.A
 }
 
 func (sn *SlackNotifier) SendResolved() bool {
-	return !sn.GetDisableResolveMessage()
+	// The status message must be updated when the alerts of a group are resolved.
+	return sn.StatusMessage || !sn.GetDisableResolveMessage()
 }
@@ -0,0 +1,242 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// slackMaxStatusLength is the maximum length of the text of a Slack message.
+const slackMaxStatusLength = 40000
+
+// slackStatusBoards are the status messages of the Slack contact points. They outlive the
+// notifiers, which are created again when the configuration is applied, so that the same message
+// keeps being updated.
+var slackStatusBoards = newStatusBoards()
+
+// statusBoards are the status messages of the contact points, by contact point and route.
+type statusBoards struct {
+	mtx    sync.Mutex
+	boards map[statusBoardKey]*statusBoard
+}
+
+type statusBoardKey struct {
+	uid   string
+	route string
+}
+
+func newStatusBoards() *statusBoards {
+	return &statusBoards{boards: map[statusBoardKey]*statusBoard{}}
+}
+
+// get returns the status message of the route of a contact point.
+func (b *statusBoards) get(uid, route string) *statusBoard {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	key := statusBoardKey{uid: uid, route: route}
+	board, ok := b.boards[key]
+	if !ok {
+		board = &statusBoard{groups: map[string]statusBoardGroup{}}
+		b.boards[key] = board
+	}
+	return board
+}
+
+// statusBoard is the status message of a route, which lists the firing alerts of all the alert
+// groups of the route. Its mutex serializes the updates of the message.
+type statusBoard struct {
+	mtx sync.Mutex
+	// channel and ts identify the message once it is posted.
+	channel string
+	ts      string
+	groups  map[string]statusBoardGroup
+}
+
+// statusBoardGroup is the section of an alert group in the status message, rendered when the
+// group was last flushed.
+type statusBoardGroup struct {
+	Title  string `json:"title"`
+	Text   string `json:"text,omitempty"`
+	Firing int    `json:"firing"`
+}
+
+// update replaces the section of the alert group, or removes it if none of its alerts is firing.
+func (b *statusBoard) update(groupKey string, group statusBoardGroup) {
+	if group.Firing == 0 {
+		delete(b.groups, groupKey)
+		return
+	}
+	b.groups[groupKey] = group
+}
+
+// statusBoardFormat is the markup of the status messages of a provider.
+type statusBoardFormat struct {
+	// noneFiring is the header without firing alerts, with the update time.
+	noneFiring string
+	// firing is the header with the number of firing alerts, "alert is" or "alerts are", and the
+	// update time.
+	firing string
+	// groupTitle is the title of the section of an alert group.
+	groupTitle string
+}
+
+var slackStatusFormat = statusBoardFormat{
+	noneFiring: ":white_check_mark: *No alerts are firing* (updated %s)",
+	firing:     ":rotating_light: *%d %s firing* (updated %s)",
+	groupTitle: "*%s*",
+}
+
+// render returns the text of the status message, with the sections of the alert groups sorted by
+// group key so that they do not move between updates.
+func (b *statusBoard) render(format statusBoardFormat, updatedAt string) string {
+	if len(b.groups) == 0 {
+		return fmt.Sprintf(format.noneFiring, updatedAt)
+	}
+	keys := make([]string, 0, len(b.groups))
+	firing := 0
+	for k, g := range b.groups {
+		keys = append(keys, k)
+		firing += g.Firing
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	alerts := "alerts are"
+	if firing == 1 {
+		alerts = "alert is"
+	}
+	fmt.Fprintf(&sb, format.firing, firing, alerts, updatedAt)
+	for _, k := range keys {
+		g := b.groups[k]
+		fmt.Fprintf(&sb, "\n\n"+format.groupTitle, strings.TrimSpace(g.Title))
+		if text := strings.TrimSpace(g.Text); text != "" {
+			sb.WriteString("\n" + text)
+		}
+	}
+	return sb.String()
+}
+
+// routeFromGroupKey returns the route of an alert group, which is the beginning of its key,
+// before the labels of the group.
+func routeFromGroupKey(groupKey string) string {
+	if i := strings.Index(groupKey, "}:{"); i >= 0 {
+		return groupKey[:i+1]
+	}
+	return groupKey
+}
+
+type slackAPIResponse struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// notifyStatus updates the status message of the route of the alerts instead of posting a
+// message per notification. The message is posted and pinned in the channel the first time, and
+// posted again if it was deleted.
+func (sn *SlackNotifier) notifyStatus(ctx context.Context, alerts []*types.Alert) (bool, error) {
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	var firing []*types.Alert
+	for _, a := range alerts {
+		if !a.Resolved() {
+			firing = append(firing, a)
+		}
+	}
+
+	board := slackStatusBoards.get(sn.UID, routeFromGroupKey(groupKey.String()))
+	board.mtx.Lock()
+	defer board.mtx.Unlock()
+
+	group := statusBoardGroup{Firing: len(firing)}
+	if len(firing) > 0 {
+		var tmplErr error
+		tmpl, _ := TmplText(ctx, sn.tmpl, firing, sn.log, &tmplErr)
+		group.Title, group.Text = tmpl(sn.Title), tmpl(sn.Text)
+		if tmplErr != nil {
+			sn.log.Warn("failed to template Slack status message", "err", tmplErr.Error())
+		}
+	}
+	board.update(groupKey.String(), group)
+
+	now := timeNow()
+	text, _ := sn.Truncate(board.render(slackStatusFormat, fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", now.Unix(), now.UTC().Format("2006-01-02 15:04 UTC"))), slackMaxStatusLength)
+	msg := map[string]interface{}{
+		"text":   text,
+		"mrkdwn": true,
+	}
+
+	if board.ts != "" {
+		msg["channel"], msg["ts"] = board.channel, board.ts
+		_, err := sn.callSlackAPI(ctx, "chat.update", msg)
+		var apiErr slackAPIError
+		if err == nil {
+			return true, nil
+		} else if !errors.As(err, &apiErr) || (apiErr.Err != "message_not_found" && apiErr.Err != "channel_not_found") {
+			return false, err
+		}
+		sn.log.Info("the Slack status message was deleted, posting it again", "channel", board.channel)
+		delete(msg, "ts")
+	}
+
+	msg["channel"] = sn.Recipient
+	msg["username"], msg["icon_emoji"], msg["icon_url"] = sn.Username, sn.IconEmoji, sn.IconURL
+	resp, err := sn.callSlackAPI(ctx, "chat.postMessage", msg)
+	if err != nil {
+		return false, err
+	}
+	board.channel, board.ts = resp.Channel, resp.TS
+	if board.ts == "" {
+		// The message was not posted, as in a dry run.
+		return true, nil
+	}
+	if _, err := sn.callSlackAPI(ctx, "pins.add", map[string]string{"channel": board.channel, "timestamp": board.ts}); err != nil {
+		// The message is updated even if it cannot be pinned, for example without the pins:write scope.
+		sn.log.Warn("failed to pin the Slack status message", "err", err, "channel", board.channel)
+	}
+	return true, nil
+}
+
+// callSlackAPI calls a method of the Slack Web API, whose URL is next to the chat API endpoint.
+func (sn *SlackNotifier) callSlackAPI(ctx context.Context, method string, payload interface{}) (slackAPIResponse, error) {
+	var resp slackAPIResponse
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return resp, fmt.Errorf("marshal json: %w", err)
+	}
+	endpoint := strings.TrimSuffix(sn.URL.String(), "chat.postMessage") + method
+
+	if recordDryRun(ctx, urlTarget(endpoint), string(b)) {
+		return resp, nil
+	}
+
+	request, err := http.NewRequestWithContext(withProxy(ctx, sn.Proxy), http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return resp, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	request.Header.Set("User-Agent", "Grafana")
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sn.Token))
+
+	if err := slackRateLimiter.wait(ctx, sn.URL.String()+"#"+sn.Recipient); err != nil {
+		return resp, err
+	}
+	body, err := sendSlackAPIRequest(request, sn.log)
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return resp, fmt.Errorf("failed to unmarshal Slack API response: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,143 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeSlackAPI answers the calls of the Slack Web API, and records them.
+type fakeSlackAPI struct {
+	t        *testing.T
+	calls    []string
+	messages map[string]string
+	nextTS   int
+}
+
+func (f *fakeSlackAPI) send(request *http.Request, _ log.Logger) ([]byte, error) {
+	require.Equal(f.t, "Bearer xoxb-token", request.Header.Get("Authorization"))
+	b, err := io.ReadAll(request.Body)
+	require.NoError(f.t, err)
+	var msg map[string]interface{}
+	require.NoError(f.t, json.Unmarshal(b, &msg))
+
+	method := strings.TrimPrefix(request.URL.Path, "/api/")
+	f.calls = append(f.calls, method)
+	switch method {
+	case "chat.postMessage":
+		require.Equal(f.t, "#alerts", msg["channel"])
+		f.nextTS++
+		ts := "1700000000.00000" + string(rune('0'+f.nextTS))
+		f.messages[ts] = msg["text"].(string)
+		return []byte(`{"ok": true, "channel": "C123", "ts": "` + ts + `"}`), nil
+	case "chat.update":
+		require.Equal(f.t, "C123", msg["channel"])
+		ts := msg["ts"].(string)
+		if _, ok := f.messages[ts]; !ok {
+			return nil, slackAPIError{Err: "message_not_found"}
+		}
+		f.messages[ts] = msg["text"].(string)
+		return []byte(`{"ok": true, "channel": "C123", "ts": "` + ts + `"}`), nil
+	case "pins.add":
+		require.Equal(f.t, "C123", msg["channel"])
+		return []byte(`{"ok": true}`), nil
+	}
+	f.t.Fatalf("unexpected Slack API method %s", method)
+	return nil, nil
+}
+
+func TestSlackStatusMessage(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	api := &fakeSlackAPI{t: t, messages: map[string]string{}}
+	origSendSlackAPIRequest := sendSlackAPIRequest
+	t.Cleanup(func() {
+		sendSlackAPIRequest = origSendSlackAPIRequest
+	})
+	sendSlackAPIRequest = api.send
+	defer mockTimeNow(time.Unix(1700000000, 0))()
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"recipient": "#alerts", "token": "xoxb-token", "statusMessage": true, "title": "{{ .CommonLabels.alertname }}", "text": "{{ range .Alerts }}{{ .Labels.instance }} {{ end }}"}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewSlackConfig(FactoryConfig{
+		Config:      &NotificationChannelConfig{UID: "status-uid", Name: "slack_testing", Type: "slack", Settings: settingsJSON},
+		DecryptFunc: secretsService.GetDecryptedValue,
+	})
+	require.NoError(t, err)
+	n := NewSlackNotifier(cfg, &UnavailableImageStore{}, mockNotificationService(), tmpl)
+	require.True(t, n.SendResolved())
+
+	notifyGroup := func(labels model.LabelSet, alerts ...*types.Alert) {
+		t.Helper()
+		ctx := notify.WithGroupKey(context.Background(), `{}/{team="a"}:`+labels.String())
+		ctx = notify.WithGroupLabels(ctx, labels)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	firing := func(name, instance string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name), "instance": model.LabelValue(instance)}}}
+	}
+	resolved := func(a *types.Alert) *types.Alert {
+		r := *a
+		r.StartsAt, r.EndsAt = time.Unix(1600000000, 0), time.Unix(1600000001, 0)
+		return &r
+	}
+	const updated = "(updated <!date^1700000000^{date_short_pretty} {time}|2023-11-14 22:13 UTC>)"
+
+	// The first notification posts and pins the message.
+	diskFull := firing("DiskFull", "db-1")
+	notifyGroup(model.LabelSet{"alertname": "DiskFull"}, diskFull)
+	require.Equal(t, []string{"chat.postMessage", "pins.add"}, api.calls)
+
+	// The notifications of the other groups of the route update it.
+	notifyGroup(model.LabelSet{"alertname": "HighLatency"}, firing("HighLatency", "api-1"), firing("HighLatency", "api-2"))
+	require.Equal(t, []string{"chat.postMessage", "pins.add", "chat.update"}, api.calls)
+	require.Equal(t, ":rotating_light: *3 alerts are firing* "+updated+"\n\n*DiskFull*\ndb-1\n\n*HighLatency*\napi-1 api-2", api.messages["1700000000.000001"])
+
+	// Resolved groups are removed from the message.
+	notifyGroup(model.LabelSet{"alertname": "DiskFull"}, resolved(diskFull))
+	require.Equal(t, ":rotating_light: *2 alerts are firing* "+updated+"\n\n*HighLatency*\napi-1 api-2", api.messages["1700000000.000001"])
+
+	// The message is posted again if it was deleted.
+	delete(api.messages, "1700000000.000001")
+	notifyGroup(model.LabelSet{"alertname": "HighLatency"}, resolved(firing("HighLatency", "api-1")))
+	require.Equal(t, []string{"chat.postMessage", "pins.add", "chat.update", "chat.update", "chat.update", "chat.postMessage", "pins.add"}, api.calls)
+	require.Equal(t, ":white_check_mark: *No alerts are firing* "+updated, api.messages["1700000000.000002"])
+}
+
+func TestSlackStatusMessageConfig(t *testing.T) {
+	settingsJSON, err := simplejson.NewJson([]byte(`{"url": "https://hooks.slack.com/services/T/B/X", "statusMessage": true}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	_, err = NewSlackConfig(FactoryConfig{
+		Config:      &NotificationChannelConfig{Name: "slack_testing", Type: "slack", Settings: settingsJSON},
+		DecryptFunc: secretsService.GetDecryptedValue,
+	})
+	require.EqualError(t, err, "the status message requires the Slack chat API and a token")
+}
+
+func TestRouteFromGroupKey(t *testing.T) {
+	require.Equal(t, `{}/{team="a"}`, routeFromGroupKey(`{}/{team="a"}:{alertname="DiskFull"}`))
+	require.Equal(t, `{}`, routeFromGroupKey(`{}:{}`))
+}
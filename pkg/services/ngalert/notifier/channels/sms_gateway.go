@@ -0,0 +1,282 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultSMSGatewayContentType = "application/json"
+	defaultSMSGatewayBody        = `{"to": "{{ .To }}", "text": {{ .MessageJSON }}}`
+	defaultSMSGatewayMessage     = `{{ template "default.title" . }}`
+	// defaultSMSGatewayMaxLength is the length of a single SMS in the GSM-7 alphabet.
+	defaultSMSGatewayMaxLength = 160
+
+	// gsm7TruncationSuffix ends the truncated messages, as the ellipsis is not in the GSM-7 alphabet.
+	gsm7TruncationSuffix = "..."
+)
+
+type SMSGatewayConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	HTTPMethod    string
+	ContentType   string
+	Body          string
+	Authorization string
+	Recipients    []string
+	Message       string
+	MaxLength     int
+	GSM7          bool
+}
+
+func SMSGatewayFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewSMSGatewayConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewSMSGatewayNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewSMSGatewayConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*SMSGatewayConfig, error) {
+	url := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString()))
+	if url == "" {
+		return nil, errors.New("could not find URL in settings")
+	}
+	method := strings.ToUpper(config.Settings.Get("httpMethod").MustString("POST"))
+	switch method {
+	case "POST", "PUT", "GET":
+	default:
+		return nil, fmt.Errorf("invalid HTTP method %q, must be POST, PUT or GET", method)
+	}
+
+	// The maximum length is a string when set from the UI and a number when provisioned.
+	maxLength := defaultSMSGatewayMaxLength
+	if v := config.Settings.Get("maxLength").Interface(); v != nil && v != "" {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid maximum length %v, must be a positive number of characters", v)
+		}
+		maxLength = n
+	}
+
+	var recipients []string
+	for _, r := range strings.Split(config.Settings.Get("recipients").MustString(), ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+
+	return &SMSGatewayConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		HTTPMethod:                method,
+		ContentType:               config.Settings.Get("contentType").MustString(defaultSMSGatewayContentType),
+		Body:                      config.Settings.Get("body").MustString(defaultSMSGatewayBody),
+		Authorization:             decryptFunc(context.Background(), config.SecureSettings, "authorization", config.Settings.Get("authorization").MustString()),
+		Recipients:                recipients,
+		Message:                   config.Settings.Get("message").MustString(defaultSMSGatewayMessage),
+		MaxLength:                 maxLength,
+		GSM7:                      config.Settings.Get("gsm7").MustBool(false),
+	}, nil
+}
+
+// NewSMSGatewayNotifier is the constructor for the SMS gateway notifier.
+func NewSMSGatewayNotifier(config *SMSGatewayConfig, ns notifications.WebhookSender, t *template.Template) *SMSGatewayNotifier {
+	return &SMSGatewayNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:           config.URL,
+		HTTPMethod:    config.HTTPMethod,
+		ContentType:   config.ContentType,
+		Body:          config.Body,
+		Authorization: config.Authorization,
+		Recipients:    config.Recipients,
+		Message:       config.Message,
+		MaxLength:     config.MaxLength,
+		GSM7:          config.GSM7,
+		log:           log.New("alerting.notifier.smsgateway"),
+		ns:            ns,
+		tmpl:          t,
+	}
+}
+
+// SMSGatewayNotifier is responsible for sending alert notifications as SMS through the HTTP API
+// of a carrier gateway or of an SMPP to HTTP bridge.
+type SMSGatewayNotifier struct {
+	*Base
+	URL           string
+	HTTPMethod    string
+	ContentType   string
+	Body          string
+	Authorization string
+	Recipients    []string
+	Message       string
+	MaxLength     int
+	GSM7          bool
+	log           log.Logger
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+}
+
+// smsGatewayTemplateData is the data of the templates of the URL and of the body of the requests,
+// which have the text of the SMS in addition to the data of the notification.
+type smsGatewayTemplateData struct {
+	*ExtendedData
+	// To is the recipient of the request, empty if the recipients are not set.
+	To string
+	// Message is the text of the SMS.
+	Message string
+	// MessageJSON is the text of the SMS as a JSON string, quotes included.
+	MessageJSON string
+}
+
+// Notify sends a request per recipient, or a single request if the recipients are set in the URL
+// or the body. The message is truncated to the maximum length, and its characters are replaced
+// by characters of the GSM-7 alphabet if the GSM-7 option is set.
+func (sn *SMSGatewayNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("executing SMS gateway notification", "notification", sn.Name)
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+	message := sn.text(strings.TrimSpace(tmpl(sn.Message)))
+	if tmplErr != nil {
+		sn.log.Warn("failed to template SMS message", "err", tmplErr.Error())
+	}
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return false, err
+	}
+
+	recipients := sn.Recipients
+	if len(recipients) == 0 {
+		recipients = []string{""}
+	}
+	for _, to := range recipients {
+		d := smsGatewayTemplateData{ExtendedData: data, To: to, Message: message, MessageJSON: string(messageJSON)}
+		url, err := sn.tmpl.ExecuteTextString(sn.URL, d)
+		if err != nil {
+			return false, fmt.Errorf("failed to template the URL: %w", err)
+		}
+		cmd := &models.SendWebhookSync{
+			Url:        strings.TrimSpace(url),
+			HttpMethod: sn.HTTPMethod,
+		}
+		if sn.HTTPMethod != "GET" {
+			body, err := sn.tmpl.ExecuteTextString(sn.Body, d)
+			if err != nil {
+				return false, fmt.Errorf("failed to template the body: %w", err)
+			}
+			cmd.Body, cmd.ContentType = body, sn.ContentType
+		}
+		if sn.Authorization != "" {
+			cmd.HttpHeader = map[string]string{"Authorization": sn.Authorization}
+		}
+		if err := sn.ns.SendWebhookSync(ctx, cmd); err != nil {
+			sn.log.Error("failed to send SMS", "err", err, "notification", sn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// text returns the text of the SMS, in the GSM-7 alphabet if the option is set, and truncated to
+// the maximum length. In the GSM-7 alphabet, the length is the number of septets.
+func (sn *SMSGatewayNotifier) text(s string) string {
+	if !sn.GSM7 {
+		truncated, _ := sn.Truncate(s, sn.MaxLength)
+		return truncated
+	}
+	s = toGSM7(s)
+	if sn.MaxLength <= 0 || gsm7Length(s) <= sn.MaxLength {
+		return s
+	}
+	limit, suffix := sn.MaxLength-len(gsm7TruncationSuffix), gsm7TruncationSuffix
+	if limit <= 0 {
+		limit, suffix = sn.MaxLength, ""
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n += gsm7Length(string(r)); n > limit {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + suffix
+}
+
+const (
+	// gsm7Basic is the basic character set of the GSM-7 alphabet, without the escape character.
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	// gsm7Extension are the characters of the extension table, which take two septets.
+	gsm7Extension = "^{}\\[~]|€"
+)
+
+// gsm7Transliterations replaces common characters that are not in the GSM-7 alphabet.
+var gsm7Transliterations = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '′': "'", '“': `"`, '”': `"`, '„': `"`, '″': `"`,
+	'–': "-", '—': "-", '−': "-", '…': "...", '•': "*", '·': ".", '×': "x", '÷': "/",
+	'\t': " ", '\u00a0': " ", '«': `"`, '»': `"`, '`': "'", '°': "o",
+	'á': "a", 'â': "a", 'ã': "a", 'ā': "a", 'ą': "a", 'Á': "A", 'À': "A", 'Â': "A", 'Ã': "A", 'Ā': "A", 'Ą': "A",
+	'ç': "Ç", 'ć': "c", 'č': "c", 'Ć': "C", 'Č': "C", 'ď': "d", 'Ď': "D", 'đ': "d", 'Đ': "D",
+	'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e", 'È': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ę': "E", 'Ě': "E",
+	'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'Í': "I", 'Ì': "I", 'Î': "I", 'Ï': "I", 'Ī': "I",
+	'ł': "l", 'Ł': "L", 'ń': "n", 'ň': "n", 'Ń': "N", 'Ň': "N",
+	'ó': "o", 'ô': "o", 'õ': "o", 'ō': "o", 'ő': "ö", 'Ó': "O", 'Ò': "O", 'Ô': "O", 'Õ': "O", 'Ō': "O", 'Ő': "Ö",
+	'œ': "oe", 'Œ': "OE", 'ř': "r", 'Ř': "R", 'ś': "s", 'š': "s", 'Ś': "S", 'Š': "S", 'ť': "t", 'Ť': "T",
+	'ú': "u", 'û': "u", 'ū': "u", 'ů': "u", 'ű': "ü", 'Ú': "U", 'Ù': "U", 'Û': "U", 'Ū': "U", 'Ů': "U", 'Ű': "Ü",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y", 'ź': "z", 'ż': "z", 'ž': "z", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+}
+
+// toGSM7 replaces the characters that are not in the GSM-7 alphabet by their transliteration, or
+// by a question mark, so that gateways do not send the SMS in UCS-2, which has 70 characters.
+func toGSM7(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r) || strings.ContainsRune(gsm7Extension, r):
+			b.WriteRune(r)
+		case gsm7Transliterations[r] != "":
+			b.WriteString(gsm7Transliterations[r])
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
+
+// gsm7Length returns the number of septets of a string of the GSM-7 alphabet.
+func gsm7Length(s string) int {
+	n := utf8.RuneCountInString(s)
+	for _, r := range s {
+		if strings.ContainsRune(gsm7Extension, r) {
+			n++
+		}
+	}
+	return n
+}
+
+func (sn *SMSGatewayNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
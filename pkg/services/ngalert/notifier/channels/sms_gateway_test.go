@@ -0,0 +1,136 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeSMSGateway records the requests to the gateway.
+type fakeSMSGateway struct {
+	notificationServiceMock
+	requests []*models.SendWebhookSync
+}
+
+func (f *fakeSMSGateway) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, cmd)
+	return nil
+}
+
+func TestSMSGatewayNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			Annotations: model.LabelSet{"summary": "Disk “full” on db-1 – 99%"},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		expRequests  []*models.SendWebhookSync
+		expInitError string
+	}{
+		{
+			name:     "A JSON request per recipient",
+			settings: `{"url": "https://sms.example.com/send", "recipients": "+15550001, +15550002", "authorization": "Bearer token", "message": "{{ .CommonAnnotations.summary }}"}`,
+			expRequests: []*models.SendWebhookSync{{
+				Url:         "https://sms.example.com/send",
+				HttpMethod:  "POST",
+				ContentType: "application/json",
+				Body:        `{"to": "+15550001", "text": "Disk “full” on db-1 – 99%"}`,
+				HttpHeader:  map[string]string{"Authorization": "Bearer token"},
+			}, {
+				Url:         "https://sms.example.com/send",
+				HttpMethod:  "POST",
+				ContentType: "application/json",
+				Body:        `{"to": "+15550002", "text": "Disk “full” on db-1 – 99%"}`,
+				HttpHeader:  map[string]string{"Authorization": "Bearer token"},
+			}},
+		}, {
+			name:     "A GET request with the message in the URL, in the GSM-7 alphabet",
+			settings: `{"url": "https://gw.example.com/sms?key=secret&to=5550001&text={{ .Message | urlquery }}", "httpMethod": "get", "gsm7": true, "message": "{{ .CommonAnnotations.summary }} ✓"}`,
+			expRequests: []*models.SendWebhookSync{{
+				Url:        "https://gw.example.com/sms?key=secret&to=5550001&text=Disk+%22full%22+on+db-1+-+99%25+%3F",
+				HttpMethod: "GET",
+			}},
+		}, {
+			name:     "A form request with the message truncated",
+			settings: `{"url": "https://gw.example.com/sms", "contentType": "application/x-www-form-urlencoded", "body": "to=5550001&text={{ .Message | urlquery }}", "maxLength": "10", "message": "{{ .CommonLabels.alertname }} on {{ .CommonLabels.instance }}"}`,
+			expRequests: []*models.SendWebhookSync{{
+				Url:         "https://gw.example.com/sms",
+				HttpMethod:  "POST",
+				ContentType: "application/x-www-form-urlencoded",
+				Body:        "to=5550001&text=DiskFul...",
+			}},
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find URL in settings",
+		}, {
+			name:         "Error when the method is not supported",
+			settings:     `{"url": "https://sms.example.com/send", "httpMethod": "DELETE"}`,
+			expInitError: `invalid HTTP method "DELETE", must be POST, PUT or GET`,
+		}, {
+			name:         "Error when the maximum length is invalid",
+			settings:     `{"url": "https://sms.example.com/send", "maxLength": "one SMS"}`,
+			expInitError: "invalid maximum length one SMS, must be a positive number of characters",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "sms_testing",
+				Type:     "smsgateway",
+				Settings: settingsJSON,
+			}
+
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			cfg, err := NewSMSGatewayConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			gateway := &fakeSMSGateway{}
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewSMSGatewayNotifier(cfg, gateway, tmpl).Notify(ctx, alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expRequests, gateway.requests)
+		})
+	}
+}
+
+func TestToGSM7(t *testing.T) {
+	require.Equal(t, "Température: 25oC - CPU ~ 90% [critique] ...", toGSM7("Température: 25°C – CPU ~ 90% [critique] …"))
+	require.Equal(t, "Zazolc gesla jazn ?", toGSM7("Zażółć gęślą jaźń 🔥"))
+	require.Equal(t, 13, gsm7Length("{alert} €5"))
+
+	// The characters of the extension table count twice.
+	n := &SMSGatewayNotifier{GSM7: true, MaxLength: 8}
+	require.Equal(t, "{a}...", n.text("{a}{b}{c}"))
+	require.Equal(t, "{a}b", n.text("{a}b"))
+}
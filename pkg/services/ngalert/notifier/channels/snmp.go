@@ -0,0 +1,313 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	defaultSNMPPort      = "162"
+	defaultSNMPCommunity = "public"
+	defaultSNMPMessage   = `{{ template "default.title" . }}`
+	// snmpMaxStringLength is the length of the strings of the traps, which are truncated so that
+	// the traps fit in a datagram.
+	snmpMaxStringLength = 1024
+	snmpTimeout         = 10 * time.Second
+)
+
+var (
+	// snmpEngineStart is the start of the SNMP engine of Grafana, from which the uptime of the
+	// traps is computed. Can be overwritten in tests.
+	snmpEngineStart = time.Now()
+	// snmpEngineBoots is the number of times the engine was started. Grafana does not persist it,
+	// the time of the start is used instead, which increases with each start as required.
+	snmpEngineBoots = int32(snmpEngineStart.Unix() & 0x7fffffff)
+	// snmpCounter is used for the IDs of the messages and the salts of the encryption, which must
+	// not be reused with the same keys.
+	snmpCounter = func() uint64 {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		return binary.BigEndian.Uint64(b)
+	}()
+)
+
+type SNMPConfig struct {
+	*NotificationChannelConfig
+	Target        string
+	Version       string
+	Community     string
+	Username      string
+	AuthProtocol  string
+	AuthPassword  string
+	PrivProtocol  string
+	PrivPassword  string
+	EngineID      []byte
+	EnterpriseOID []uint32
+	Message       string
+}
+
+func SNMPFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewSNMPConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewSNMPNotifier(cfg, fc.Template), nil
+}
+
+func NewSNMPConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*SNMPConfig, error) {
+	target := strings.TrimSpace(config.Settings.Get("target").MustString())
+	if target == "" {
+		return nil, errors.New("could not find target in settings")
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, defaultSNMPPort)
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return nil, fmt.Errorf("invalid target %q, must be host or host:port", config.Settings.Get("target").MustString())
+		}
+	}
+	enterpriseOID := config.Settings.Get("enterpriseOid").MustString()
+	if enterpriseOID == "" {
+		return nil, errors.New("could not find enterprise OID in settings")
+	}
+	oid, err := parseOID(enterpriseOID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &SNMPConfig{
+		NotificationChannelConfig: config,
+		Target:                    target,
+		Version:                   config.Settings.Get("version").MustString("v2c"),
+		EnterpriseOID:             oid,
+		Message:                   config.Settings.Get("message").MustString(defaultSNMPMessage),
+	}
+	switch cfg.Version {
+	case "v2c":
+		cfg.Community = decryptFunc(context.Background(), config.SecureSettings, "community", config.Settings.Get("community").MustString(defaultSNMPCommunity))
+		return cfg, nil
+	case "v3":
+	default:
+		return nil, fmt.Errorf("invalid SNMP version %q, must be v2c or v3", cfg.Version)
+	}
+
+	cfg.Username = config.Settings.Get("username").MustString()
+	if cfg.Username == "" {
+		return nil, errors.New("could not find username in settings")
+	}
+	engineID := config.Settings.Get("engineId").MustString()
+	if engineID == "" {
+		return nil, errors.New("engine ID is required with SNMP v3")
+	}
+	cfg.EngineID, err = hex.DecodeString(strings.TrimPrefix(engineID, "0x"))
+	if err != nil || len(cfg.EngineID) < 5 || len(cfg.EngineID) > 32 {
+		return nil, fmt.Errorf("invalid engine ID %q, must be 5 to 32 bytes in hexadecimal", engineID)
+	}
+	cfg.AuthProtocol = strings.ToUpper(config.Settings.Get("authProtocol").MustString())
+	if cfg.AuthProtocol == "NONE" {
+		cfg.AuthProtocol = ""
+	}
+	if _, ok := snmpAuthProtocols[cfg.AuthProtocol]; !ok && cfg.AuthProtocol != "" {
+		return nil, fmt.Errorf("invalid authentication protocol %q, must be MD5, SHA or SHA256", cfg.AuthProtocol)
+	}
+	cfg.PrivProtocol = strings.ToUpper(config.Settings.Get("privProtocol").MustString())
+	switch cfg.PrivProtocol {
+	case "", "NONE":
+		cfg.PrivProtocol = ""
+	case "DES", "AES":
+		if cfg.AuthProtocol == "" {
+			return nil, errors.New("privacy requires an authentication protocol")
+		}
+	default:
+		return nil, fmt.Errorf("invalid privacy protocol %q, must be DES or AES", cfg.PrivProtocol)
+	}
+	// The passwords are at least 8 characters long (RFC 3414 11.2).
+	if cfg.AuthProtocol != "" {
+		cfg.AuthPassword = decryptFunc(context.Background(), config.SecureSettings, "authPassword", config.Settings.Get("authPassword").MustString())
+		if len(cfg.AuthPassword) < 8 {
+			return nil, errors.New("the authentication password must be at least 8 characters")
+		}
+	}
+	if cfg.PrivProtocol != "" {
+		cfg.PrivPassword = decryptFunc(context.Background(), config.SecureSettings, "privPassword", config.Settings.Get("privPassword").MustString())
+		if len(cfg.PrivPassword) < 8 {
+			return nil, errors.New("the privacy password must be at least 8 characters")
+		}
+	}
+	return cfg, nil
+}
+
+// NewSNMPNotifier is the constructor for the SNMP trap notifier.
+func NewSNMPNotifier(config *SNMPConfig, t *template.Template) *SNMPNotifier {
+	n := &SNMPNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Target:        config.Target,
+		Community:     config.Community,
+		EnterpriseOID: config.EnterpriseOID,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.snmp"),
+		tmpl:          t,
+	}
+	if config.Version == "v3" {
+		// The keys are localized once, as it hashes a megabyte per password.
+		n.usm = newSNMPUSM(config.EngineID, config.Username, config.AuthProtocol, config.AuthPassword, config.PrivProtocol, config.PrivPassword)
+	}
+	return n
+}
+
+// SNMPNotifier is responsible for sending alert notifications as SNMP traps.
+type SNMPNotifier struct {
+	*Base
+	Target        string
+	Community     string
+	EnterpriseOID []uint32
+	Message       string
+	// usm is nil with SNMPv2c.
+	usm  *snmpUSM
+	log  log.Logger
+	tmpl *template.Template
+}
+
+// Notify sends a trap per alert. The traps of the firing alerts are the notification
+// <enterprise OID>.0.1 and the traps of the resolved alerts <enterprise OID>.0.2. The variable
+// bindings of the alert are <enterprise OID>.1.1 to <enterprise OID>.1.7.
+func (sn *SNMPNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("sending SNMP trap", "notification", sn.Name)
+
+	messages := make([][]byte, 0, len(as))
+	dryRun := make([]string, 0, len(as))
+	for _, a := range as {
+		var tmplErr error
+		tmpl, data := TmplText(ctx, sn.tmpl, []*types.Alert{a}, sn.log, &tmplErr)
+		text := strings.TrimSpace(tmpl(sn.Message))
+		if tmplErr != nil {
+			sn.log.Warn("failed to template SNMP trap message", "err", tmplErr.Error())
+		}
+
+		trapOID, varBinds := sn.varBinds(a, data.Alerts[0], text)
+		msg, err := sn.encode(trapOID, varBinds)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode the SNMP trap: %w", err)
+		}
+		messages = append(messages, msg)
+
+		lines := []string{"trap " + formatOID(trapOID)}
+		for _, vb := range varBinds {
+			lines = append(lines, fmt.Sprintf("%s = %q", formatOID(vb.oid), vb.display))
+		}
+		dryRun = append(dryRun, strings.Join(lines, "\n"))
+	}
+
+	if recordDryRun(ctx, "snmp://"+sn.Target, strings.Join(dryRun, "\n\n")) {
+		return true, nil
+	}
+
+	conn, err := (&net.Dialer{Timeout: snmpTimeout}).DialContext(ctx, "udp", sn.Target)
+	if err != nil {
+		sn.log.Error("failed to connect to SNMP manager", "err", err, "notification", sn.Name)
+		return false, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			sn.log.Warn("failed to close SNMP connection", "err", err)
+		}
+	}()
+	deadline := time.Now().Add(snmpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return false, err
+	}
+	for _, msg := range messages {
+		if _, err := conn.Write(msg); err != nil {
+			sn.log.Error("failed to send SNMP trap", "err", err, "notification", sn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// snmpTrapVarBind is a variable binding of an alert, with its value as a string for the dry runs.
+type snmpTrapVarBind struct {
+	snmpVarBind
+	display string
+}
+
+func (sn *SNMPNotifier) varBinds(a *types.Alert, alert ExtendedAlert, text string) ([]uint32, []snmpTrapVarBind) {
+	trapOID := appendOID(sn.EnterpriseOID, 0, 1)
+	if alert.Status != "firing" {
+		trapOID = appendOID(sn.EnterpriseOID, 0, 2)
+	}
+	startsAt := ""
+	if !alert.StartsAt.IsZero() {
+		startsAt = alert.StartsAt.UTC().Format(time.RFC3339)
+	}
+	values := []string{
+		alert.Labels["alertname"],
+		alert.Status,
+		a.Labels.String(),
+		text,
+		alert.Fingerprint,
+		startsAt,
+		alert.GeneratorURL,
+	}
+	varBinds := make([]snmpTrapVarBind, 0, len(values))
+	for i, v := range values {
+		v = truncateBytes(v, snmpMaxStringLength)
+		varBinds = append(varBinds, snmpTrapVarBind{
+			snmpVarBind: snmpVarBind{oid: appendOID(sn.EnterpriseOID, 1, uint32(i+1)), value: berString([]byte(v))},
+			display:     v,
+		})
+	}
+	return trapOID, varBinds
+}
+
+// encode returns the SNMP message of the trap.
+func (sn *SNMPNotifier) encode(trapOID []uint32, trapVarBinds []snmpTrapVarBind) ([]byte, error) {
+	uptime := timeNow().Sub(snmpEngineStart)
+	if uptime < 0 {
+		uptime = 0
+	}
+	varBinds := []snmpVarBind{
+		{oid: snmpSysUpTimeOID, value: berTimeTicks(uint32(uptime / (10 * time.Millisecond)))},
+		{oid: snmpTrapOID, value: berObjectID(trapOID)},
+	}
+	for _, vb := range trapVarBinds {
+		varBinds = append(varBinds, vb.snmpVarBind)
+	}
+
+	counter := atomic.AddUint64(&snmpCounter, 1)
+	id := int32(counter & 0x7fffffff)
+	pdu := snmpTrapPDU(id, varBinds)
+	if sn.usm == nil {
+		return snmpV2cMessage(sn.Community, pdu), nil
+	}
+	return sn.usm.message(id, snmpEngineBoots, int32(uptime/time.Second), counter, pdu)
+}
+
+func (sn *SNMPNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
@@ -0,0 +1,303 @@
+package channels
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// The encoding of the SNMP messages with the basic encoding rules of ASN.1, which only covers
+// the messages of the traps.
+
+const (
+	berTagInteger     = 0x02
+	berTagOctetString = 0x04
+	berTagOID         = 0x06
+	berTagSequence    = 0x30
+	berTagTimeTicks   = 0x43
+	// berTagTrapV2 is the tag of the SNMPv2-Trap-PDU.
+	berTagTrapV2 = 0xa7
+)
+
+var (
+	// snmpSysUpTimeOID and snmpTrapOID are the OIDs of the first two variable bindings of the
+	// traps, the uptime of the agent and the OID of the trap.
+	snmpSysUpTimeOID = []uint32{1, 3, 6, 1, 2, 1, 1, 3, 0}
+	snmpTrapOID      = []uint32{1, 3, 6, 1, 6, 3, 1, 1, 4, 1, 0}
+)
+
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+func berTLV(tag byte, content []byte) []byte {
+	return append(append([]byte{tag}, berLength(len(content))...), content...)
+}
+
+func berSeq(items ...[]byte) []byte {
+	return berTLV(berTagSequence, bytes.Join(items, nil))
+}
+
+func berInt(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	// Integers are encoded with the minimum number of octets in two's complement.
+	for len(b) > 1 && (b[0] == 0x00 && b[1]&0x80 == 0 || b[0] == 0xff && b[1]&0x80 != 0) {
+		b = b[1:]
+	}
+	return berTLV(berTagInteger, b)
+}
+
+func berTimeTicks(v uint32) []byte {
+	b := berInt(int64(v))
+	b[0] = berTagTimeTicks
+	return b
+}
+
+func berString(s []byte) []byte {
+	return berTLV(berTagOctetString, s)
+}
+
+func berObjectID(oid []uint32) []byte {
+	// The first two arcs are encoded as a single subidentifier.
+	b := berSubidentifier(oid[0]*40 + oid[1])
+	for _, arc := range oid[2:] {
+		b = append(b, berSubidentifier(arc)...)
+	}
+	return berTLV(berTagOID, b)
+}
+
+// berSubidentifier encodes the subidentifier in base 128, the most significant group first.
+func berSubidentifier(v uint32) []byte {
+	b := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		b = append([]byte{byte(v&0x7f) | 0x80}, b...)
+	}
+	return b
+}
+
+// parseOID parses an OID in dotted notation, such as 1.3.6.1.4.1.
+func parseOID(s string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	oid := make([]uint32, 0, len(parts))
+	for _, p := range parts {
+		arc, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid = append(oid, uint32(arc))
+	}
+	if oid[0] > 2 || oid[0] < 2 && oid[1] >= 40 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	return oid, nil
+}
+
+func appendOID(oid []uint32, arcs ...uint32) []uint32 {
+	return append(append(make([]uint32, 0, len(oid)+len(arcs)), oid...), arcs...)
+}
+
+func formatOID(oid []uint32) string {
+	parts := make([]string, 0, len(oid))
+	for _, arc := range oid {
+		parts = append(parts, strconv.FormatUint(uint64(arc), 10))
+	}
+	return strings.Join(parts, ".")
+}
+
+// snmpVarBind is a variable binding of a trap, whose value is an encoded value.
+type snmpVarBind struct {
+	oid   []uint32
+	value []byte
+}
+
+// snmpTrapPDU returns the SNMPv2-Trap-PDU with the variable bindings.
+func snmpTrapPDU(requestID int32, varBinds []snmpVarBind) []byte {
+	encoded := make([][]byte, 0, len(varBinds))
+	for _, vb := range varBinds {
+		encoded = append(encoded, berSeq(berObjectID(vb.oid), vb.value))
+	}
+	return berTLV(berTagTrapV2, bytes.Join([][]byte{
+		berInt(int64(requestID)),
+		berInt(0), // error-status
+		berInt(0), // error-index
+		berSeq(encoded...),
+	}, nil))
+}
+
+// snmpV2cMessage returns the SNMPv2c message of the PDU.
+func snmpV2cMessage(community string, pdu []byte) []byte {
+	return berSeq(berInt(1), berString([]byte(community)), pdu)
+}
+
+// snmpAuthProtocol is an authentication protocol of the user-based security model, which signs
+// the messages with an HMAC truncated to macLength bytes.
+type snmpAuthProtocol struct {
+	hash      func() hash.Hash
+	macLength int
+}
+
+var snmpAuthProtocols = map[string]snmpAuthProtocol{
+	"MD5":    {hash: md5.New, macLength: 12},
+	"SHA":    {hash: sha1.New, macLength: 12},
+	"SHA256": {hash: sha256.New, macLength: 24},
+}
+
+// snmpUSM holds the user and the keys of the user-based security model of SNMPv3 (RFC 3414).
+// The engine sending the traps is the authoritative engine, so the keys are localized with its
+// engine ID.
+type snmpUSM struct {
+	engineID     []byte
+	username     string
+	auth         *snmpAuthProtocol
+	authKey      []byte
+	privProtocol string
+	privKey      []byte
+}
+
+func newSNMPUSM(engineID []byte, username, authProtocol, authPassword, privProtocol, privPassword string) *snmpUSM {
+	u := &snmpUSM{engineID: engineID, username: username, privProtocol: privProtocol}
+	if p, ok := snmpAuthProtocols[authProtocol]; ok {
+		u.auth = &p
+		u.authKey = snmpLocalizedKey(p.hash, authPassword, engineID)
+		if privProtocol != "" {
+			// The privacy keys are localized with the hash of the authentication protocol.
+			u.privKey = snmpLocalizedKey(p.hash, privPassword, engineID)
+		}
+	}
+	return u
+}
+
+// snmpLocalizedKey returns the key of the password localized to the engine ID (RFC 3414 A.2).
+func snmpLocalizedKey(newHash func() hash.Hash, password string, engineID []byte) []byte {
+	h := newHash()
+	buf := []byte(password)
+	// The key is the hash of the password repeated over 1MB.
+	for written := 0; written < 1048576; written += len(buf) {
+		n := len(buf)
+		if 1048576-written < n {
+			n = 1048576 - written
+		}
+		h.Write(buf[:n])
+	}
+	ku := h.Sum(nil)
+	h.Reset()
+	h.Write(ku)
+	h.Write(engineID)
+	h.Write(ku)
+	return h.Sum(nil)
+}
+
+// message returns the SNMPv3 message of the PDU, authenticated and encrypted depending on the
+// security level of the user. salt must be unique for each message sent with the same keys.
+func (u *snmpUSM) message(msgID int32, boots, engineTime int32, salt uint64, pdu []byte) ([]byte, error) {
+	var flags byte
+	if u.auth != nil {
+		flags |= 0x01
+	}
+	scopedPDU := berSeq(berString(u.engineID), berString(nil), pdu)
+	var privParams []byte
+	if u.privKey != nil {
+		flags |= 0x02
+		var err error
+		if scopedPDU, privParams, err = u.encrypt(scopedPDU, boots, engineTime, salt); err != nil {
+			return nil, err
+		}
+		scopedPDU = berString(scopedPDU)
+	}
+	var authParams []byte
+	if u.auth != nil {
+		authParams = make([]byte, u.auth.macLength)
+	}
+
+	version := berInt(3)
+	globalData := berSeq(berInt(int64(msgID)), berInt(65507), berString([]byte{flags}), berInt(3))
+	secParamsPrefix := bytes.Join([][]byte{
+		berString(u.engineID),
+		berInt(int64(boots)),
+		berInt(int64(engineTime)),
+		berString([]byte(u.username)),
+	}, nil)
+	authTLV := berString(authParams)
+	secParamsContent := bytes.Join([][]byte{secParamsPrefix, authTLV, berString(privParams)}, nil)
+	secParams := berString(berSeq(secParamsContent))
+	body := bytes.Join([][]byte{version, globalData, secParams, scopedPDU}, nil)
+	msg := append(append([]byte{berTagSequence}, berLength(len(body))...), body...)
+
+	if u.auth != nil {
+		// The MAC is computed over the message with zeroed authentication parameters, then
+		// replaces them.
+		offset := len(msg) - len(body) + len(version) + len(globalData) +
+			len(secParams) - len(secParamsContent) + len(secParamsPrefix) + len(authTLV) - len(authParams)
+		mac := hmac.New(u.auth.hash, u.authKey)
+		mac.Write(msg)
+		copy(msg[offset:], mac.Sum(nil)[:u.auth.macLength])
+	}
+	return msg, nil
+}
+
+// encrypt encrypts the scoped PDU, and returns the privacy parameters of the message.
+func (u *snmpUSM) encrypt(scopedPDU []byte, boots, engineTime int32, salt uint64) ([]byte, []byte, error) {
+	switch u.privProtocol {
+	case "AES":
+		// AES-128 in CFB mode (RFC 3826).
+		block, err := aes.NewCipher(u.privKey[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		privParams := make([]byte, 8)
+		binary.BigEndian.PutUint64(privParams, salt)
+		iv := make([]byte, 16)
+		binary.BigEndian.PutUint32(iv, uint32(boots))
+		binary.BigEndian.PutUint32(iv[4:], uint32(engineTime))
+		copy(iv[8:], privParams)
+		encrypted := make([]byte, len(scopedPDU))
+		cipher.NewCFBEncrypter(block, iv).XORKeyStream(encrypted, scopedPDU)
+		return encrypted, privParams, nil
+	case "DES":
+		// DES in CBC mode (RFC 3414 8.1.1.1), for the systems that do not support AES.
+		if len(u.privKey) < 16 {
+			return nil, nil, errors.New("the DES privacy key is too short")
+		}
+		block, err := des.NewCipher(u.privKey[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		privParams := make([]byte, 8)
+		binary.BigEndian.PutUint32(privParams, uint32(boots))
+		binary.BigEndian.PutUint32(privParams[4:], uint32(salt))
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = u.privKey[8+i] ^ privParams[i]
+		}
+		if r := len(scopedPDU) % 8; r != 0 {
+			scopedPDU = append(scopedPDU, make([]byte, 8-r)...)
+		}
+		encrypted := make([]byte, len(scopedPDU))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, scopedPDU)
+		return encrypted, privParams, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported privacy protocol %q", u.privProtocol)
+	}
+}
@@ -0,0 +1,42 @@
+package channels
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBEREncoding(t *testing.T) {
+	require.Equal(t, "020100", hex.EncodeToString(berInt(0)))
+	require.Equal(t, "02017f", hex.EncodeToString(berInt(127)))
+	require.Equal(t, "02020080", hex.EncodeToString(berInt(128)))
+	require.Equal(t, "0201ff", hex.EncodeToString(berInt(-1)))
+	require.Equal(t, "0202ff7f", hex.EncodeToString(berInt(-129)))
+	require.Equal(t, "430500ffffffff", hex.EncodeToString(berTimeTicks(0xffffffff)))
+	require.Equal(t, "06072b060104018237", hex.EncodeToString(berObjectID([]uint32{1, 3, 6, 1, 4, 1, 311})))
+	require.Equal(t, "81c8", hex.EncodeToString(berLength(200)))
+	require.Equal(t, "820100", hex.EncodeToString(berLength(256)))
+}
+
+func TestParseOID(t *testing.T) {
+	oid, err := parseOID(".1.3.6.1.4.1.311")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 3, 6, 1, 4, 1, 311}, oid)
+	require.Equal(t, "1.3.6.1.4.1.311", formatOID(oid))
+
+	for _, invalid := range []string{"1", "1.3.x", "3.1", "1.40", "1..3"} {
+		_, err := parseOID(invalid)
+		require.EqualError(t, err, `invalid OID "`+invalid+`"`)
+	}
+}
+
+func TestSNMPLocalizedKey(t *testing.T) {
+	// The test vectors of RFC 3414 A.3.
+	engineID, err := hex.DecodeString("000000000000000000000002")
+	require.NoError(t, err)
+	require.Equal(t, "526f5eed9fcce26f8964c2930787d82b", hex.EncodeToString(snmpLocalizedKey(md5.New, "maplesyrup", engineID)))
+	require.Equal(t, "6695febc9288e36282235fc7151f128497b38f3f", hex.EncodeToString(snmpLocalizedKey(sha1.New, "maplesyrup", engineID)))
+}
@@ -0,0 +1,327 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/asn1"
+	"encoding/binary"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// berElements decodes the consecutive elements of b.
+func berElements(t *testing.T, b []byte) []asn1.RawValue {
+	t.Helper()
+	var elements []asn1.RawValue
+	for len(b) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(b, &v)
+		require.NoError(t, err)
+		elements = append(elements, v)
+		b = rest
+	}
+	return elements
+}
+
+// snmpTrapVarBinds returns the variable bindings of a SNMPv2-Trap-PDU, as OID and value pairs.
+func snmpTrapVarBinds(t *testing.T, pdu asn1.RawValue) [][2]string {
+	t.Helper()
+	require.Equal(t, asn1.ClassContextSpecific, pdu.Class)
+	require.Equal(t, 7, pdu.Tag)
+	fields := berElements(t, pdu.Bytes)
+	require.Len(t, fields, 4)
+	var varBinds [][2]string
+	for _, vb := range berElements(t, fields[3].Bytes) {
+		parts := berElements(t, vb.Bytes)
+		var oid asn1.ObjectIdentifier
+		_, err := asn1.Unmarshal(parts[0].FullBytes, &oid)
+		require.NoError(t, err)
+		value := string(parts[1].Bytes)
+		switch {
+		case parts[1].Class == asn1.ClassApplication && parts[1].Tag == 3:
+			var ticks int64
+			for _, b := range parts[1].Bytes {
+				ticks = ticks<<8 | int64(b)
+			}
+			value = time.Duration(ticks * int64(10*time.Millisecond)).String()
+		case parts[1].Tag == asn1.TagOID:
+			var v asn1.ObjectIdentifier
+			_, err := asn1.Unmarshal(parts[1].FullBytes, &v)
+			require.NoError(t, err)
+			value = v.String()
+		}
+		varBinds = append(varBinds, [2]string{oid.String(), value})
+	}
+	return varBinds
+}
+
+func TestSNMPNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+	origStart := snmpEngineStart
+	snmpEngineStart = now.Add(-42 * time.Second)
+	defer func() { snmpEngineStart = origStart }()
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			StartsAt:     time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC),
+			GeneratorURL: "http://localhost/alerting/1",
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "DiskFull", "instance": "db-2"},
+			StartsAt: time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC),
+			EndsAt:   time.Date(2022, 8, 1, 11, 30, 0, 0, time.UTC),
+		},
+	}}
+	expVarBinds := [][][2]string{{
+		{"1.3.6.1.2.1.1.3.0", "42s"},
+		{"1.3.6.1.6.3.1.1.4.1.0", "1.3.6.1.4.1.99999.0.1"},
+		{"1.3.6.1.4.1.99999.1.1", "DiskFull"},
+		{"1.3.6.1.4.1.99999.1.2", "firing"},
+		{"1.3.6.1.4.1.99999.1.3", `{alertname="DiskFull", instance="db-1"}`},
+		{"1.3.6.1.4.1.99999.1.4", "[FIRING:1] DiskFull (db-1)"},
+		{"1.3.6.1.4.1.99999.1.5", alerts[0].Fingerprint().String()},
+		{"1.3.6.1.4.1.99999.1.6", "2022-08-01T11:00:00Z"},
+		{"1.3.6.1.4.1.99999.1.7", "http://localhost/alerting/1"},
+	}, {
+		{"1.3.6.1.2.1.1.3.0", "42s"},
+		{"1.3.6.1.6.3.1.1.4.1.0", "1.3.6.1.4.1.99999.0.2"},
+		{"1.3.6.1.4.1.99999.1.1", "DiskFull"},
+		{"1.3.6.1.4.1.99999.1.2", "resolved"},
+		{"1.3.6.1.4.1.99999.1.3", `{alertname="DiskFull", instance="db-2"}`},
+		{"1.3.6.1.4.1.99999.1.4", "[RESOLVED] DiskFull (db-2)"},
+		{"1.3.6.1.4.1.99999.1.5", alerts[1].Fingerprint().String()},
+		{"1.3.6.1.4.1.99999.1.6", "2022-08-01T11:00:00Z"},
+		{"1.3.6.1.4.1.99999.1.7", ""},
+	}}
+
+	newNotifier := func(t *testing.T, settings string) *SNMPNotifier {
+		settingsJSON, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewSNMPConfig(&NotificationChannelConfig{Name: "snmp_testing", Type: "snmp", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+		return NewSNMPNotifier(cfg, tmpl)
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "DiskFull"})
+
+	receive := func(t *testing.T, conn net.PacketConn) []byte {
+		t.Helper()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		buf := make([]byte, 65535)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return buf[:n]
+	}
+
+	t.Run("SNMPv2c trap per alert", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		n := newNotifier(t, `{"target": "`+conn.LocalAddr().String()+`", "community": "ops", "enterpriseOid": "1.3.6.1.4.1.99999"}`)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		for _, exp := range expVarBinds {
+			msg := berElements(t, receive(t, conn))
+			require.Len(t, msg, 1)
+			fields := berElements(t, msg[0].Bytes)
+			require.Len(t, fields, 3)
+			require.Equal(t, []byte{1}, fields[0].Bytes)
+			require.Equal(t, "ops", string(fields[1].Bytes))
+			require.Equal(t, exp, snmpTrapVarBinds(t, fields[2]))
+		}
+	})
+
+	t.Run("SNMPv3 trap with authentication and privacy", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		n := newNotifier(t, `{
+			"target": "`+conn.LocalAddr().String()+`",
+			"version": "v3",
+			"enterpriseOid": "1.3.6.1.4.1.99999",
+			"username": "grafana",
+			"engineId": "80001f8880aabbccdd",
+			"authProtocol": "SHA",
+			"authPassword": "authpassword",
+			"privProtocol": "AES",
+			"privPassword": "privpassword"
+		}`)
+		ok, err := n.Notify(ctx, alerts[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		packet := receive(t, conn)
+		msg := berElements(t, packet)
+		require.Len(t, msg, 1)
+		fields := berElements(t, msg[0].Bytes)
+		require.Len(t, fields, 4)
+		require.Equal(t, []byte{3}, fields[0].Bytes)
+		globalData := berElements(t, fields[1].Bytes)
+		require.Equal(t, []byte{0x03}, globalData[2].Bytes, "the trap is authenticated and encrypted")
+
+		secParams := berElements(t, berElements(t, fields[2].Bytes)[0].Bytes)
+		require.Len(t, secParams, 6)
+		require.Equal(t, []byte{0x80, 0x00, 0x1f, 0x88, 0x80, 0xaa, 0xbb, 0xcc, 0xdd}, secParams[0].Bytes)
+		require.Equal(t, "grafana", string(secParams[3].Bytes))
+
+		// The MAC is the HMAC of the message with zeroed authentication parameters.
+		engineID := secParams[0].Bytes
+		mac := secParams[4].Bytes
+		require.Len(t, mac, 12)
+		zeroed := bytes.Replace(packet, mac, make([]byte, 12), 1)
+		h := hmac.New(sha1.New, snmpLocalizedKey(sha1.New, "authpassword", engineID))
+		h.Write(zeroed)
+		require.Equal(t, h.Sum(nil)[:12], mac)
+
+		// The scoped PDU is encrypted with AES-128 in CFB mode.
+		block, err := aes.NewCipher(snmpLocalizedKey(sha1.New, "privpassword", engineID)[:16])
+		require.NoError(t, err)
+		iv := make([]byte, 16)
+		binary.BigEndian.PutUint32(iv, uint32(snmpEngineBoots))
+		binary.BigEndian.PutUint32(iv[4:], 42)
+		copy(iv[8:], secParams[5].Bytes)
+		scopedPDU := make([]byte, len(fields[3].Bytes))
+		cipher.NewCFBDecrypter(block, iv).XORKeyStream(scopedPDU, fields[3].Bytes)
+
+		scoped := berElements(t, berElements(t, scopedPDU)[0].Bytes)
+		require.Len(t, scoped, 3)
+		require.Equal(t, engineID, scoped[0].Bytes)
+		require.Equal(t, expVarBinds[0], snmpTrapVarBinds(t, scoped[2]))
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		n := newNotifier(t, `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999"}`)
+		d := &DryRun{}
+		ok, err := n.Notify(WithDryRun(ctx, d), alerts[1])
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []DryRunRequest{{Target: "snmp://nms.example.com:162", Body: `trap 1.3.6.1.4.1.99999.0.2
+1.3.6.1.4.1.99999.1.1 = "DiskFull"
+1.3.6.1.4.1.99999.1.2 = "resolved"
+1.3.6.1.4.1.99999.1.3 = "{alertname=\"DiskFull\", instance=\"db-2\"}"
+1.3.6.1.4.1.99999.1.4 = "[RESOLVED] DiskFull (db-2)"
+1.3.6.1.4.1.99999.1.5 = "` + alerts[1].Fingerprint().String() + `"
+1.3.6.1.4.1.99999.1.6 = "2022-08-01T11:00:00Z"
+1.3.6.1.4.1.99999.1.7 = ""`}}, d.Requests())
+	})
+}
+
+func TestSNMPv3DES(t *testing.T) {
+	engineID := []byte{0x80, 0x00, 0x1f, 0x88, 0x04, 'g', 'f'}
+	u := newSNMPUSM(engineID, "grafana", "MD5", "authpassword", "DES", "privpassword")
+	pdu := snmpTrapPDU(1, []snmpVarBind{{oid: snmpTrapOID, value: berObjectID([]uint32{1, 3, 6, 1, 4, 1, 99999, 0, 1})}})
+	packet, err := u.message(1, 7, 42, 0x0102030405060708, pdu)
+	require.NoError(t, err)
+
+	fields := berElements(t, berElements(t, packet)[0].Bytes)
+	secParams := berElements(t, berElements(t, fields[2].Bytes)[0].Bytes)
+	// The salt is the engine boots followed by the lower bits of the counter.
+	require.Equal(t, []byte{0, 0, 0, 7, 5, 6, 7, 8}, secParams[5].Bytes)
+	encrypted := fields[3].Bytes
+	require.Zero(t, len(encrypted)%8)
+
+	key := snmpLocalizedKey(md5.New, "privpassword", engineID)
+	block, err := des.NewCipher(key[:8])
+	require.NoError(t, err)
+	iv := make([]byte, 8)
+	for i := range iv {
+		iv[i] = key[8+i] ^ secParams[5].Bytes[i]
+	}
+	scopedPDU := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(scopedPDU, encrypted)
+	var scoped asn1.RawValue
+	_, err = asn1.Unmarshal(scopedPDU, &scoped)
+	require.NoError(t, err)
+	require.Equal(t, pdu, berElements(t, scoped.Bytes)[2].FullBytes)
+}
+
+func TestNewSNMPConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expError string
+	}{
+		{
+			name:     "Error when the target is missing",
+			settings: `{"enterpriseOid": "1.3.6.1.4.1.99999"}`,
+			expError: "could not find target in settings",
+		}, {
+			name:     "Error when the enterprise OID is missing",
+			settings: `{"target": "nms.example.com"}`,
+			expError: "could not find enterprise OID in settings",
+		}, {
+			name:     "Error when the enterprise OID is invalid",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "enterprises.99999"}`,
+			expError: `invalid OID "enterprises.99999"`,
+		}, {
+			name:     "Error when the version is invalid",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v1"}`,
+			expError: `invalid SNMP version "v1", must be v2c or v3`,
+		}, {
+			name:     "Error when the username is missing",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3"}`,
+			expError: "could not find username in settings",
+		}, {
+			name:     "Error when the engine ID is missing",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3", "username": "grafana"}`,
+			expError: "engine ID is required with SNMP v3",
+		}, {
+			name:     "Error when the engine ID is invalid",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3", "username": "grafana", "engineId": "8000"}`,
+			expError: `invalid engine ID "8000", must be 5 to 32 bytes in hexadecimal`,
+		}, {
+			name:     "Error when the authentication protocol is invalid",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3", "username": "grafana", "engineId": "80001f888001", "authProtocol": "SHA512"}`,
+			expError: `invalid authentication protocol "SHA512", must be MD5, SHA or SHA256`,
+		}, {
+			name:     "Error when privacy is used without authentication",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3", "username": "grafana", "engineId": "80001f888001", "privProtocol": "AES"}`,
+			expError: "privacy requires an authentication protocol",
+		}, {
+			name:     "Error when the authentication password is too short",
+			settings: `{"target": "nms.example.com", "enterpriseOid": "1.3.6.1.4.1.99999", "version": "v3", "username": "grafana", "engineId": "80001f888001", "authProtocol": "SHA", "authPassword": "short"}`,
+			expError: "the authentication password must be at least 8 characters",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewSNMPConfig(&NotificationChannelConfig{Name: "snmp_testing", Type: "snmp", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expError)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	squadcastStatusTrigger = "trigger"
+	squadcastStatusResolve = "resolve"
+)
+
+type SquadcastConfig struct {
+	*NotificationChannelConfig
+	URL         string
+	Message     string
+	Description string
+}
+
+func SquadcastFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewSquadcastConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewSquadcastNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewSquadcastConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*SquadcastConfig, error) {
+	url := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if url == "" {
+		return nil, errors.New("could not find url in settings")
+	}
+	return &SquadcastConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.title" . }}`),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewSquadcastNotifier is the constructor for the Squadcast notifier.
+func NewSquadcastNotifier(config *SquadcastConfig, ns notifications.WebhookSender, t *template.Template) *SquadcastNotifier {
+	return &SquadcastNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:         config.URL,
+		Message:     config.Message,
+		Description: config.Description,
+		log:         log.New("alerting.notifier.squadcast"),
+		ns:          ns,
+		tmpl:        t,
+	}
+}
+
+// SquadcastNotifier sends alerts to an incident webhook of Squadcast. Each Grafana alert is an
+// event whose ID is the fingerprint of the alert, so that Squadcast resolves the incident it
+// triggered once the alert is resolved.
+type SquadcastNotifier struct {
+	*Base
+	URL         string
+	Message     string
+	Description string
+	log         log.Logger
+	ns          notifications.WebhookSender
+	tmpl        *template.Template
+}
+
+type squadcastEvent struct {
+	Message     string            `json:"message"`
+	Description string            `json:"description"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Status      string            `json:"status"`
+	EventID     string            `json:"event_id"`
+}
+
+// Notify sends an event to Squadcast for each alert.
+func (sn *SquadcastNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("sending Squadcast notification", "notification", sn.Name)
+
+	var tmplErr error
+	_, data := TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+
+	events := make([]squadcastEvent, 0, len(data.Alerts))
+	for i, alert := range data.Alerts {
+		// Events are templated for each alert, as each one is an incident of its own.
+		tmpl, _ := TmplText(ctx, sn.tmpl, as[i:i+1], sn.log, &tmplErr)
+		status := squadcastStatusTrigger
+		if alert.Status == string(model.AlertResolved) {
+			status = squadcastStatusResolve
+		}
+		events = append(events, squadcastEvent{
+			Message:     tmpl(sn.Message),
+			Description: tmpl(sn.Description),
+			Tags:        alert.Labels,
+			Status:      status,
+			EventID:     alert.Fingerprint,
+		})
+	}
+	if tmplErr != nil {
+		sn.log.Warn("failed to template Squadcast message", "err", tmplErr.Error())
+	}
+
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return false, err
+		}
+		cmd := &models.SendWebhookSync{
+			Url:         sn.URL,
+			Body:        string(body),
+			HttpMethod:  "POST",
+			ContentType: "application/json",
+			Validation:  validateSquadcastResponse,
+		}
+		if err := sn.ns.SendWebhookSync(ctx, cmd); err != nil {
+			sn.log.Error("failed to send Squadcast event", "err", err, "notification", sn.Name, "event", event.EventID)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func validateSquadcastResponse(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var res struct {
+		Meta struct {
+			ErrorMessage string `json:"error_message"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(body, &res); err == nil && res.Meta.ErrorMessage != "" {
+		return fmt.Errorf("the Squadcast API returned status %d: %s", statusCode, res.Meta.ErrorMessage)
+	}
+	return fmt.Errorf("the Squadcast API returned status %d", statusCode)
+}
+
+func (sn *SquadcastNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
@@ -0,0 +1,128 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestSquadcastNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighCPU", "instance": "web-1"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+			StartsAt:    time.Now(),
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighCPU", "instance": "web-2"},
+			StartsAt: time.Now().Add(-time.Hour),
+			EndsAt:   time.Now().Add(-time.Minute),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expEvents    []map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "An event for each alert, identified by its fingerprint",
+			settings: `{"url": "https://api.squadcast.com/v2/incidents/api/key"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expEvents: []map[string]interface{}{
+				{
+					"message":     "[FIRING:1]  (HighCPU web-1)",
+					"description": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = HighCPU\n - instance = web-1\nAnnotations:\n - summary = CPU is high\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=instance%3Dweb-1\n",
+					"tags":        map[string]interface{}{"alertname": "HighCPU", "instance": "web-1"},
+					"status":      "trigger",
+					"event_id":    firing.Fingerprint().String(),
+				}, {
+					"message":     "[RESOLVED]  (HighCPU web-2)",
+					"description": "**Resolved**\n\nValue: [no value]\nLabels:\n - alertname = HighCPU\n - instance = web-2\nAnnotations:\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=instance%3Dweb-2\n",
+					"tags":        map[string]interface{}{"alertname": "HighCPU", "instance": "web-2"},
+					"status":      "resolve",
+					"event_id":    resolved.Fingerprint().String(),
+				},
+			},
+		}, {
+			name:     "Custom message and description",
+			settings: `{"url": "https://api.squadcast.com/v2/incidents/api/key", "message": "{{ .CommonLabels.instance }} is on fire", "description": "{{ .CommonAnnotations.summary }}"}`,
+			alerts:   []*types.Alert{firing},
+			expEvents: []map[string]interface{}{
+				{
+					"message":     "web-1 is on fire",
+					"description": "CPU is high",
+					"tags":        map[string]interface{}{"alertname": "HighCPU", "instance": "web-1"},
+					"status":      "trigger",
+					"event_id":    firing.Fingerprint().String(),
+				},
+			},
+		}, {
+			name:         "Error with a missing URL",
+			settings:     `{}`,
+			expInitError: "could not find url in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "squadcast_testing",
+				Type:     "squadcast",
+				Settings: settingsJSON,
+			}
+
+			cfg, err := NewSquadcastConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ns := mockNotificationService()
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := NewSquadcastNotifier(cfg, ns, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Len(t, ns.Webhooks, len(c.expEvents))
+			for i, req := range ns.Webhooks {
+				require.Equal(t, "https://api.squadcast.com/v2/incidents/api/key", req.Url)
+				var event map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(req.Body), &event))
+				require.Equal(t, c.expEvents[i], event)
+			}
+		})
+	}
+}
+
+func TestValidateSquadcastResponse(t *testing.T) {
+	require.NoError(t, validateSquadcastResponse([]byte(`{"status":"ok"}`), 202))
+	require.EqualError(t, validateSquadcastResponse([]byte(`{"meta":{"status":400,"error_message":"invalid api key"}}`), 400), "the Squadcast API returned status 400: invalid api key")
+	require.EqualError(t, validateSquadcastResponse(nil, 502), "the Squadcast API returned status 502")
+}
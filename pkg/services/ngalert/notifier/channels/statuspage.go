@@ -0,0 +1,383 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultStatuspageURL             = "https://api.statuspage.io/v1"
+	defaultStatuspageComponentLabel  = "component"
+	defaultStatuspageComponentStatus = "major_outage"
+	defaultStatuspageName            = `{{ with .CommonAnnotations.summary }}{{ . }}{{ else }}{{ .CommonLabels.alertname }}{{ end }}`
+	defaultStatuspageResolveBody     = "This incident has been resolved."
+
+	// statuspageMetadataKey is the key of the metadata of the incidents that holds the hash of
+	// their alert group, so that a group only ever has one unresolved incident.
+	statuspageMetadataKey = "grafana"
+)
+
+// statuspageComponentStatuses are the statuses of the affected components while the alerts fire.
+var statuspageComponentStatuses = map[string]struct{}{
+	"degraded_performance": {},
+	"partial_outage":       {},
+	"major_outage":         {},
+}
+
+type StatuspageConfig struct {
+	*NotificationChannelConfig
+	URL             string
+	PageID          string
+	APIKey          string
+	ComponentLabel  string
+	Components      map[string]string
+	ComponentStatus string
+	IncidentName    string
+	Body            string
+	ResolveBody     string
+}
+
+func StatuspageFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewStatuspageConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewStatuspageNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewStatuspageConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*StatuspageConfig, error) {
+	apiURL := strings.TrimSuffix(config.Settings.Get("url").MustString(defaultStatuspageURL), "/")
+	if _, err := url.Parse(apiURL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	pageID := strings.TrimSpace(config.Settings.Get("pageId").MustString())
+	if pageID == "" {
+		return nil, errors.New("could not find page ID in settings")
+	}
+	if strings.ContainsAny(pageID, "/?#") {
+		return nil, fmt.Errorf("invalid page ID %q", pageID)
+	}
+	apiKey := decryptFunc(context.Background(), config.SecureSettings, "apiKey", config.Settings.Get("apiKey").MustString())
+	if apiKey == "" {
+		return nil, errors.New("could not find API key in settings")
+	}
+	componentStatus := config.Settings.Get("componentStatus").MustString(defaultStatuspageComponentStatus)
+	if componentStatus == "" {
+		componentStatus = defaultStatuspageComponentStatus
+	}
+	if _, ok := statuspageComponentStatuses[componentStatus]; !ok {
+		return nil, fmt.Errorf("invalid component status %q, must be degraded_performance, partial_outage or major_outage", componentStatus)
+	}
+	components, err := statuspageComponentsFromSettings(config.Settings.Get("components"))
+	if err != nil {
+		return nil, err
+	}
+	return &StatuspageConfig{
+		NotificationChannelConfig: config,
+		URL:                       apiURL,
+		PageID:                    pageID,
+		APIKey:                    apiKey,
+		ComponentLabel:            config.Settings.Get("componentLabel").MustString(defaultStatuspageComponentLabel),
+		Components:                components,
+		ComponentStatus:           componentStatus,
+		IncidentName:              config.Settings.Get("name").MustString(defaultStatuspageName),
+		Body:                      config.Settings.Get("body").MustString(`{{ .CommonAnnotations.description }}`),
+		ResolveBody:               config.Settings.Get("resolveBody").MustString(defaultStatuspageResolveBody),
+	}, nil
+}
+
+// statuspageComponentsFromSettings returns the IDs of the components by the values of the
+// component label. The setting is an object when provisioned and value=id lines when set from
+// the UI. Unlike severities, the values of the label are case-sensitive.
+func statuspageComponentsFromSettings(setting *simplejson.Json) (map[string]string, error) {
+	components := map[string]string{}
+	if m, err := setting.Map(); err == nil {
+		for k, v := range m {
+			s, ok := v.(string)
+			if !ok || s == "" {
+				return nil, fmt.Errorf("invalid component ID for %q, must be a string", k)
+			}
+			components[k] = s
+		}
+		return components, nil
+	}
+	for _, line := range strings.Split(setting.MustString(), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid component mapping %q, must be value=component ID", line)
+		}
+		components[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return components, nil
+}
+
+// NewStatuspageNotifier is the constructor for the Statuspage notifier.
+func NewStatuspageNotifier(config *StatuspageConfig, ns notifications.WebhookSender, t *template.Template) *StatuspageNotifier {
+	return &StatuspageNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:             config.URL,
+		PageID:          config.PageID,
+		APIKey:          config.APIKey,
+		ComponentLabel:  config.ComponentLabel,
+		Components:      config.Components,
+		ComponentStatus: config.ComponentStatus,
+		IncidentName:    config.IncidentName,
+		Body:            config.Body,
+		ResolveBody:     config.ResolveBody,
+		log:             log.New("alerting.notifier.statuspage"),
+		ns:              ns,
+		tmpl:            t,
+	}
+}
+
+// StatuspageNotifier is responsible for opening a Statuspage incident per alert group, marking
+// the components of the alerts as affected, and for resolving the incident and its components
+// when the group is resolved.
+type StatuspageNotifier struct {
+	*Base
+	URL             string
+	PageID          string
+	APIKey          string
+	ComponentLabel  string
+	Components      map[string]string
+	ComponentStatus string
+	IncidentName    string
+	Body            string
+	ResolveBody     string
+	log             log.Logger
+	ns              notifications.WebhookSender
+	tmpl            *template.Template
+}
+
+type statuspageIncident struct {
+	ID string `json:"id"`
+	// Metadata may hold the values of other integrations, which are not always strings.
+	Metadata   map[string]map[string]interface{} `json:"metadata"`
+	Components []struct {
+		ID string `json:"id"`
+	} `json:"components"`
+}
+
+// Notify opens an incident when the alert group starts firing, adds the components of the alerts
+// that fire later to the incident, and resolves the incident when the group is resolved. The
+// unresolved incident of the group is found by the hash of the group in its metadata, so
+// notifications that are retried or repeated do not open duplicates or post updates.
+func (sn *StatuspageNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("sending Statuspage notification", "notification", sn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	groupHash := groupKey.Hash()
+
+	incident, err := sn.findUnresolvedIncident(ctx, groupHash)
+	if err != nil {
+		sn.log.Error("failed to search Statuspage incidents", "err", err, "notification", sn.Name)
+		return false, err
+	}
+
+	var affected []string
+	if incident != nil {
+		for _, c := range incident.Components {
+			affected = append(affected, c.ID)
+		}
+	}
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+
+	resolved := types.Alerts(as...).Status() == model.AlertResolved
+	fields := map[string]interface{}{}
+	switch {
+	case !resolved && incident == nil:
+		ids := sn.componentIDs(as)
+		fields["name"] = strings.Join(strings.Fields(tmpl(sn.IncidentName)), " ")
+		fields["status"] = "investigating"
+		fields["body"] = tmpl(sn.Body)
+		fields["metadata"] = map[string]map[string]string{statuspageMetadataKey: {"group": groupHash}}
+		if len(ids) > 0 {
+			fields["component_ids"] = ids
+			fields["components"] = statuspageComponentStatus(ids, sn.ComponentStatus)
+		}
+	case !resolved:
+		added := difference(sn.componentIDs(as), affected)
+		if len(added) == 0 {
+			// The incident already has all the components of the alerts.
+			return true, nil
+		}
+		fields["component_ids"] = append(affected, added...)
+		fields["components"] = statuspageComponentStatus(added, sn.ComponentStatus)
+	case incident != nil:
+		fields["status"] = "resolved"
+		fields["body"] = tmpl(sn.ResolveBody)
+		if len(affected) > 0 {
+			fields["component_ids"] = affected
+			fields["components"] = statuspageComponentStatus(affected, "operational")
+		}
+	default:
+		// The incident was already resolved.
+		return true, nil
+	}
+
+	if tmplErr != nil {
+		sn.log.Warn("failed to template Statuspage incident", "err", tmplErr.Error())
+	}
+
+	path := "/pages/" + url.PathEscape(sn.PageID) + "/incidents"
+	method := "POST"
+	if incident != nil {
+		path += "/" + url.PathEscape(incident.ID)
+		method = "PATCH"
+	}
+	var result statuspageIncident
+	if err := sn.request(ctx, method, path, map[string]interface{}{"incident": fields}, &result); err != nil {
+		sn.log.Error("failed to send Statuspage notification", "err", err, "notification", sn.Name)
+		return false, err
+	}
+	sn.log.Debug("sent Statuspage notification", "incident", result.ID, "group", groupHash)
+
+	return true, nil
+}
+
+// componentIDs returns the sorted IDs of the components of the firing alerts. Without mapped
+// components, the values of the component label are the IDs of the components. Otherwise, the
+// values that are not mapped are ignored.
+func (sn *StatuspageNotifier) componentIDs(as []*types.Alert) []string {
+	seen := map[string]struct{}{}
+	var ids []string
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		id := string(a.Labels[model.LabelName(sn.ComponentLabel)])
+		if len(sn.Components) > 0 {
+			id = sn.Components[id]
+		}
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (sn *StatuspageNotifier) findUnresolvedIncident(ctx context.Context, groupHash string) (*statuspageIncident, error) {
+	var incidents []statuspageIncident
+	path := "/pages/" + url.PathEscape(sn.PageID) + "/incidents/unresolved?per_page=100"
+	if err := sn.request(ctx, "GET", path, nil, &incidents); err != nil {
+		return nil, err
+	}
+	for i := range incidents {
+		if incidents[i].Metadata[statuspageMetadataKey]["group"] == groupHash {
+			return &incidents[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// request sends a request to the Statuspage REST API and decodes the response into out.
+func (sn *StatuspageNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        sn.URL + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Accept":        "application/json",
+			"Authorization": "OAuth " + sn.APIKey,
+			"Content-Type":  "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return statuspageError(body, statusCode)
+			}
+			if len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return sn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// statuspageError returns the error of the Statuspage API, which is a message or a list of
+// messages of the invalid fields.
+func statuspageError(body []byte, statusCode int) error {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Error) > 0 {
+		var msg string
+		var msgs []string
+		if err := json.Unmarshal(resp.Error, &msg); err == nil && msg != "" {
+			return fmt.Errorf("the Statuspage API returned status %d: %s", statusCode, msg)
+		}
+		if err := json.Unmarshal(resp.Error, &msgs); err == nil && len(msgs) > 0 {
+			return fmt.Errorf("the Statuspage API returned status %d: %s", statusCode, strings.Join(msgs, "; "))
+		}
+	}
+	return fmt.Errorf("the Statuspage API returned status %d", statusCode)
+}
+
+// statuspageComponentStatus returns the status of each component.
+func statuspageComponentStatus(ids []string, status string) map[string]string {
+	components := make(map[string]string, len(ids))
+	for _, id := range ids {
+		components[id] = status
+	}
+	return components
+}
+
+// difference returns the items of a that are not in b.
+func difference(a, b []string) []string {
+	in := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		in[s] = struct{}{}
+	}
+	var result []string
+	for _, s := range a {
+		if _, ok := in[s]; !ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (sn *StatuspageNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
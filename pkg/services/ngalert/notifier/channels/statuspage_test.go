@@ -0,0 +1,282 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeStatuspageIncident struct {
+	ID         string
+	Fields     map[string]interface{}
+	Components map[string]string
+	Updates    []string
+}
+
+// fakeStatuspage implements the parts of the Statuspage API used by the notifier.
+type fakeStatuspage struct {
+	incidents []*fakeStatuspageIncident
+	requests  []*models.SendWebhookSync
+}
+
+func (s *fakeStatuspage) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	s.requests = append(s.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+	view := func(incident *fakeStatuspageIncident) map[string]interface{} {
+		components := []map[string]string{}
+		for id := range incident.Components {
+			components = append(components, map[string]string{"id": id})
+		}
+		return map[string]interface{}{"id": incident.ID, "metadata": incident.Fields["metadata"], "components": components}
+	}
+
+	var in struct {
+		Incident map[string]interface{} `json:"incident"`
+	}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+	update := func(incident *fakeStatuspageIncident) {
+		for k, v := range in.Incident {
+			switch k {
+			case "components":
+				for id, status := range v.(map[string]interface{}) {
+					incident.Components[id] = status.(string)
+				}
+			case "body":
+				incident.Updates = append(incident.Updates, v.(string))
+			default:
+				incident.Fields[k] = v
+			}
+		}
+	}
+
+	switch {
+	case cmd.HttpHeader["Authorization"] != "OAuth secret":
+		return respond(401, map[string]string{"error": "Could not authenticate"})
+	case cmd.HttpMethod == "GET" && u.Path == "/v1/pages/page1/incidents/unresolved":
+		result := []map[string]interface{}{
+			{"id": "other", "metadata": map[string]interface{}{"jira": map[string]interface{}{"issue": 1}}},
+		}
+		for _, incident := range s.incidents {
+			if incident.Fields["status"] != "resolved" {
+				result = append(result, view(incident))
+			}
+		}
+		return respond(200, result)
+	case cmd.HttpMethod == "POST" && u.Path == "/v1/pages/page1/incidents":
+		if in.Incident["name"] == "" {
+			return respond(422, map[string]interface{}{"error": []string{"Name can't be blank"}})
+		}
+		incident := &fakeStatuspageIncident{ID: fmt.Sprint(len(s.incidents) + 1), Fields: map[string]interface{}{}, Components: map[string]string{}}
+		update(incident)
+		s.incidents = append(s.incidents, incident)
+		return respond(201, view(incident))
+	case cmd.HttpMethod == "PATCH" && strings.HasPrefix(u.Path, "/v1/pages/page1/incidents/"):
+		for _, incident := range s.incidents {
+			if incident.ID == strings.TrimPrefix(u.Path, "/v1/pages/page1/incidents/") {
+				update(incident)
+				return respond(200, view(incident))
+			}
+		}
+	}
+	return respond(404, map[string]string{"error": "Not found"})
+}
+
+func TestStatuspageNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	api := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "component": "api"},
+			Annotations: model.LabelSet{"summary": "Requests are slow", "description": "The latency of the API is above 1s."},
+		},
+	}
+	web := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "HighLatency", "component": "web"},
+		},
+	}
+	resolve := func(a *types.Alert) *types.Alert {
+		r := &types.Alert{Alert: a.Alert}
+		r.EndsAt = r.StartsAt.Add(1)
+		return r
+	}
+
+	t.Run("one incident per group, with the components of the alerts", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"pageId":          "page1",
+			"apiKey":          "secret",
+			"components":      "api=cmp-api\nweb=cmp-web",
+			"componentStatus": "partial_outage",
+		})
+		cfg, err := NewStatuspageConfig(&NotificationChannelConfig{Name: "statuspage_testing", Type: "statuspage", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		sp := &fakeStatuspage{}
+		n := NewStatuspageNotifier(cfg, sp, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		ok, err := n.Notify(ctx, api)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sp.incidents, 1)
+
+		incident := sp.incidents[0]
+		require.Equal(t, "Requests are slow", incident.Fields["name"])
+		require.Equal(t, "investigating", incident.Fields["status"])
+		require.Equal(t, []string{"The latency of the API is above 1s."}, incident.Updates)
+		require.Equal(t, map[string]string{"cmp-api": "partial_outage"}, incident.Components)
+		require.Equal(t, "https://api.statuspage.io/v1/pages/page1/incidents/unresolved?per_page=100", sp.requests[0].Url)
+
+		// Repeated notifications do not update the incident.
+		ok, err = n.Notify(ctx, api)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sp.requests, 3)
+
+		// The components of the alerts that fire later are added to the incident.
+		ok, err = n.Notify(ctx, api, web)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sp.incidents, 1)
+		require.Equal(t, map[string]string{"cmp-api": "partial_outage", "cmp-web": "partial_outage"}, incident.Components)
+		require.Len(t, incident.Updates, 1)
+
+		ok, err = n.Notify(ctx, resolve(api), resolve(web))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "resolved", incident.Fields["status"])
+		require.Equal(t, "This incident has been resolved.", incident.Updates[1])
+		require.Equal(t, map[string]string{"cmp-api": "operational", "cmp-web": "operational"}, incident.Components)
+
+		// Nothing is left to resolve.
+		ok, err = n.Notify(ctx, resolve(api))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// The group fires again after its incident was resolved.
+		ok, err = n.Notify(ctx, web)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sp.incidents, 2)
+		require.Equal(t, "HighLatency", sp.incidents[1].Fields["name"])
+	})
+
+	t.Run("label values are the component IDs without mapped components", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"pageId":         "page1",
+			"apiKey":         "secret",
+			"componentLabel": "statuspage_component",
+		})
+		cfg, err := NewStatuspageConfig(&NotificationChannelConfig{Name: "statuspage_testing", Type: "statuspage", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "Down", "statuspage_component": "cmp-db"}}}
+		sp := &fakeStatuspage{}
+		_, err = NewStatuspageNotifier(cfg, sp, tmpl).Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"cmp-db": "major_outage"}, sp.incidents[0].Components)
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"pageId": "page1",
+			"apiKey": "secret",
+			"name":   "{{ .CommonLabels.missing }}",
+		})
+		cfg, err := NewStatuspageConfig(&NotificationChannelConfig{Name: "statuspage_testing", Type: "statuspage", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewStatuspageNotifier(cfg, &fakeStatuspage{}, tmpl).Notify(notify.WithGroupKey(context.Background(), "alertname"), api)
+		require.EqualError(t, err, "the Statuspage API returned status 422: Name can't be blank")
+		require.False(t, ok)
+
+		cfg.APIKey = "wrong"
+		ok, err = NewStatuspageNotifier(cfg, &fakeStatuspage{}, tmpl).Notify(notify.WithGroupKey(context.Background(), "alertname"), api)
+		require.EqualError(t, err, "the Statuspage API returned status 401: Could not authenticate")
+		require.False(t, ok)
+	})
+}
+
+func TestNewStatuspageConfig(t *testing.T) {
+	cases := []struct {
+		name          string
+		settings      map[string]interface{}
+		expComponents map[string]string
+		expInitError  string
+	}{
+		{
+			name:          "Provisioned components",
+			settings:      map[string]interface{}{"pageId": "page1", "apiKey": "secret", "components": map[string]interface{}{"API": "cmp-api"}},
+			expComponents: map[string]string{"API": "cmp-api"},
+		}, {
+			name:         "Error when the page ID is missing",
+			settings:     map[string]interface{}{"apiKey": "secret"},
+			expInitError: "could not find page ID in settings",
+		}, {
+			name:         "Error with an invalid page ID",
+			settings:     map[string]interface{}{"pageId": "page1/incidents", "apiKey": "secret"},
+			expInitError: `invalid page ID "page1/incidents"`,
+		}, {
+			name:         "Error when the API key is missing",
+			settings:     map[string]interface{}{"pageId": "page1"},
+			expInitError: "could not find API key in settings",
+		}, {
+			name:         "Error with an invalid component status",
+			settings:     map[string]interface{}{"pageId": "page1", "apiKey": "secret", "componentStatus": "operational"},
+			expInitError: `invalid component status "operational", must be degraded_performance, partial_outage or major_outage`,
+		}, {
+			name:         "Error with an invalid component mapping",
+			settings:     map[string]interface{}{"pageId": "page1", "apiKey": "secret", "components": "api"},
+			expInitError: `invalid component mapping "api", must be value=component ID`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "statuspage_testing", Type: "statuspage", Settings: simplejson.NewFromAny(c.settings)}
+
+			cfg, err := NewStatuspageConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expComponents, cfg.Components)
+		})
+	}
+}
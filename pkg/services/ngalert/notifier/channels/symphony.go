@@ -0,0 +1,334 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// symphonyJWTLifetime is the lifetime of the JWTs of the bot, Symphony rejects JWTs that expire
+// in more than 5 minutes.
+const symphonyJWTLifetime = 5 * time.Minute
+
+var errSymphonyUnauthorized = errors.New("the session of the Symphony bot is not valid")
+
+type SymphonyConfig struct {
+	*NotificationChannelConfig
+	PodURL        string
+	AgentURL      string
+	KeyManagerURL string
+	BotUsername   string
+	PrivateKey    *rsa.PrivateKey
+	StreamID      string
+	Title         string
+	Message       string
+}
+
+func SymphonyFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewSymphonyConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewSymphonyNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewSymphonyConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*SymphonyConfig, error) {
+	podURL, err := symphonyURL(config, "podUrl", "")
+	if err != nil {
+		return nil, err
+	}
+	if podURL == "" {
+		return nil, errors.New("could not find pod URL in settings")
+	}
+	agentURL, err := symphonyURL(config, "agentUrl", podURL)
+	if err != nil {
+		return nil, err
+	}
+	keyManagerURL, err := symphonyURL(config, "keyManagerUrl", podURL)
+	if err != nil {
+		return nil, err
+	}
+	botUsername := strings.TrimSpace(config.Settings.Get("botUsername").MustString())
+	if botUsername == "" {
+		return nil, errors.New("could not find bot username in settings")
+	}
+	privateKeyPEM := decryptFunc(context.Background(), config.SecureSettings, "privateKey", config.Settings.Get("privateKey").MustString())
+	if privateKeyPEM == "" {
+		return nil, errors.New("could not find private key in settings")
+	}
+	privateKey, err := parseSymphonyPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	streamID := strings.TrimSpace(config.Settings.Get("streamId").MustString())
+	if streamID == "" {
+		return nil, errors.New("could not find stream ID in settings")
+	}
+	return &SymphonyConfig{
+		NotificationChannelConfig: config,
+		PodURL:                    podURL,
+		AgentURL:                  agentURL,
+		KeyManagerURL:             keyManagerURL,
+		BotUsername:               botUsername,
+		PrivateKey:                privateKey,
+		// The stream IDs of the API are URL safe, while the clients show them in standard base64.
+		StreamID: strings.TrimRight(strings.NewReplacer("+", "-", "/", "_").Replace(streamID), "="),
+		Title:    config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:  config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+func symphonyURL(config *NotificationChannelConfig, key, defaultURL string) (string, error) {
+	u := strings.TrimSuffix(strings.TrimSpace(config.Settings.Get(key).MustString()), "/")
+	if u == "" {
+		return defaultURL, nil
+	}
+	if _, err := url.Parse(u); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return u, nil
+}
+
+func parseSymphonyPrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("invalid private key: no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid private key: not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// NewSymphonyNotifier is the constructor for the Symphony notifier.
+func NewSymphonyNotifier(config *SymphonyConfig, ns notifications.WebhookSender, t *template.Template) *SymphonyNotifier {
+	return &SymphonyNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		PodURL:        config.PodURL,
+		AgentURL:      config.AgentURL,
+		KeyManagerURL: config.KeyManagerURL,
+		BotUsername:   config.BotUsername,
+		PrivateKey:    config.PrivateKey,
+		StreamID:      config.StreamID,
+		Title:         config.Title,
+		Message:       config.Message,
+		log:           log.New("alerting.notifier.symphony"),
+		ns:            ns,
+		tmpl:          t,
+	}
+}
+
+// SymphonyNotifier is responsible for sending alert notifications to a Symphony stream, as a bot
+// that authenticates with its RSA key.
+type SymphonyNotifier struct {
+	*Base
+	PodURL        string
+	AgentURL      string
+	KeyManagerURL string
+	BotUsername   string
+	PrivateKey    *rsa.PrivateKey
+	StreamID      string
+	Title         string
+	Message       string
+	log           log.Logger
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+
+	// The tokens of the session of the bot, which are reused until Symphony rejects them.
+	mtx             sync.Mutex
+	sessionToken    string
+	keyManagerToken string
+}
+
+// Notify sends a messageML message to the stream. It authenticates the bot if it has no session,
+// or if the session expired.
+func (sn *SymphonyNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("executing Symphony notification", "notification", sn.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+	message := sn.messageML(tmpl(sn.Title), tmpl(sn.Message), types.Alerts(as...).Status())
+	if tmplErr != nil {
+		sn.log.Warn("failed to template Symphony message", "err", tmplErr.Error())
+	}
+
+	sn.mtx.Lock()
+	defer sn.mtx.Unlock()
+
+	err := sn.send(ctx, message)
+	if errors.Is(err, errSymphonyUnauthorized) {
+		sn.sessionToken, sn.keyManagerToken = "", ""
+		err = sn.send(ctx, message)
+	}
+	if err != nil {
+		sn.log.Error("failed to send notification to Symphony", "err", err, "notification", sn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// messageML returns the message in the markup of Symphony, with the title colored by the state of
+// the alerts and a link to the alert rules.
+func (sn *SymphonyNotifier) messageML(title, message string, status model.AlertStatus) string {
+	color := "tempo-text-color--red"
+	if status == model.AlertResolved {
+		color = "tempo-text-color--green"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<messageML>")
+	fmt.Fprintf(&sb, `<h3 class="%s">%s</h3>`, color, symphonyEscape(strings.TrimSpace(title)))
+	sb.WriteString("<p>" + strings.ReplaceAll(symphonyEscape(strings.TrimSpace(message)), "\n", "<br/>") + "</p>")
+	if ruleURL := sn.RuleListURL(sn.tmpl.ExternalURL); ruleURL != "" {
+		fmt.Fprintf(&sb, `<p><a href="%s">View alert rules</a></p>`, symphonyEscape(ruleURL))
+	}
+	sb.WriteString("</messageML>")
+	return sb.String()
+}
+
+// symphonyEscape escapes text for messageML, which is also a Freemarker template.
+func symphonyEscape(s string) string {
+	return strings.NewReplacer("$", "&#36;", "#", "&#35;").Replace(html.EscapeString(s))
+}
+
+func (sn *SymphonyNotifier) send(ctx context.Context, message string) error {
+	if sn.sessionToken == "" || sn.keyManagerToken == "" {
+		if err := sn.authenticate(ctx); err != nil {
+			return err
+		}
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("message", message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:        fmt.Sprintf("%s/agent/v4/stream/%s/message/create", sn.AgentURL, url.PathEscape(sn.StreamID)),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"sessionToken":    sn.sessionToken,
+			"keyManagerToken": sn.keyManagerToken,
+		},
+		ContentType: w.FormDataContentType(),
+		Body:        body.String(),
+		Validation:  symphonyValidation(nil),
+	}
+	return sn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// authenticate creates the session of the bot on the pod and on the key manager, with a JWT
+// signed by the key of the bot.
+func (sn *SymphonyNotifier) authenticate(ctx context.Context) error {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS512, Key: sn.PrivateKey}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return err
+	}
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Subject: sn.BotUsername,
+		Expiry:  jwt.NewNumericDate(timeNow().Add(symphonyJWTLifetime)),
+	}).CompactSerialize()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return err
+	}
+
+	for _, auth := range []struct {
+		url   string
+		token *string
+	}{
+		{url: sn.PodURL + "/login/pubkey/authenticate", token: &sn.sessionToken},
+		{url: sn.KeyManagerURL + "/relay/pubkey/authenticate", token: &sn.keyManagerToken},
+	} {
+		var resp struct {
+			Token string `json:"token"`
+		}
+		cmd := &models.SendWebhookSync{
+			Url:         auth.url,
+			HttpMethod:  "POST",
+			ContentType: "application/json",
+			Body:        string(body),
+			Validation:  symphonyValidation(&resp),
+		}
+		if err := sn.ns.SendWebhookSync(ctx, cmd); err != nil {
+			// Not wrapped, so that a rejected key does not look like an expired session.
+			if errors.Is(err, errSymphonyUnauthorized) {
+				return fmt.Errorf("the Symphony bot %s could not authenticate, check its username and public key", sn.BotUsername)
+			}
+			return fmt.Errorf("failed to authenticate the Symphony bot: %w", err)
+		}
+		*auth.token = resp.Token
+	}
+	return nil
+}
+
+// symphonyValidation returns the validation of the responses of the Symphony API, which decodes
+// successful responses into out.
+func symphonyValidation(out interface{}) func(body []byte, statusCode int) error {
+	return func(body []byte, statusCode int) error {
+		if statusCode == 401 {
+			return errSymphonyUnauthorized
+		}
+		if statusCode/100 != 2 {
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+				return fmt.Errorf("the Symphony API returned status %d: %s", statusCode, resp.Message)
+			}
+			return fmt.Errorf("the Symphony API returned status %d", statusCode)
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(body, out)
+	}
+}
+
+func (sn *SymphonyNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
@@ -0,0 +1,163 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// fakeSymphony authenticates the bot with its public key and records the messages it sends.
+type fakeSymphony struct {
+	notificationServiceMock
+	t         *testing.T
+	publicKey *rsa.PublicKey
+	sessions  int
+	expired   bool
+	messages  []string
+}
+
+func (f *fakeSymphony) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	switch {
+	case strings.HasSuffix(cmd.Url, "/pubkey/authenticate"):
+		var req struct {
+			Token string `json:"token"`
+		}
+		require.NoError(f.t, json.Unmarshal([]byte(cmd.Body), &req))
+		token, err := jwt.ParseSigned(req.Token)
+		require.NoError(f.t, err)
+		var claims jwt.Claims
+		require.NoError(f.t, token.Claims(f.publicKey, &claims))
+		require.Equal(f.t, "grafana-bot", claims.Subject)
+		if strings.Contains(cmd.Url, "/login/") {
+			f.sessions++
+			f.expired = false
+		}
+		return cmd.Validation([]byte(fmt.Sprintf(`{"name": "sessionToken", "token": "%s-%d"}`, cmd.Url, f.sessions)), 200)
+	case strings.HasSuffix(cmd.Url, "/message/create"):
+		require.Equal(f.t, "https://symphony.example.com/agent/v4/stream/abc-def_ghi/message/create", cmd.Url)
+		require.Equal(f.t, fmt.Sprintf("https://pod.example.com/login/pubkey/authenticate-%d", f.sessions), cmd.HttpHeader["sessionToken"])
+		require.Equal(f.t, fmt.Sprintf("https://km.example.com/relay/pubkey/authenticate-%d", f.sessions), cmd.HttpHeader["keyManagerToken"])
+		if f.expired {
+			return cmd.Validation([]byte(`{"code": 401, "message": "Invalid session"}`), 401)
+		}
+		_, params, err := mime.ParseMediaType(cmd.ContentType)
+		require.NoError(f.t, err)
+		form, err := multipart.NewReader(strings.NewReader(cmd.Body), params["boundary"]).ReadForm(1 << 20)
+		require.NoError(f.t, err)
+		f.messages = append(f.messages, form.Value["message"][0])
+		return cmd.Validation([]byte(`{"messageId": "1"}`), 200)
+	}
+	return fmt.Errorf("unexpected request to %s", cmd.Url)
+}
+
+func TestSymphonyNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	settings, err := json.Marshal(map[string]string{
+		"podUrl":        "https://pod.example.com/",
+		"agentUrl":      "https://symphony.example.com",
+		"keyManagerUrl": "https://km.example.com",
+		"botUsername":   "grafana-bot",
+		"privateKey":    string(keyPEM),
+		"streamId":      "abc+def/ghi==",
+		"title":         "{{ .CommonLabels.alertname }}",
+		"message":       "{{ range .Alerts }}{{ .Annotations.summary }}\n{{ end }}",
+	})
+	require.NoError(t, err)
+	settingsJSON, err := simplejson.NewJson(settings)
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewSymphonyConfig(&NotificationChannelConfig{
+		Name:     "symphony_testing",
+		Type:     "symphony",
+		Settings: settingsJSON,
+	}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+
+	sender := &fakeSymphony{t: t, publicKey: &key.PublicKey}
+	n := NewSymphonyNotifier(cfg, sender, tmpl)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency"},
+			Annotations: model.LabelSet{"summary": "p99 > 2s for <payments> & ${checkout} #prod"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	ok, err := n.Notify(ctx, firing)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, sender.sessions)
+	require.Equal(t, `<messageML><h3 class="tempo-text-color--red">HighLatency</h3><p>p99 &gt; 2s for &lt;payments&gt; &amp; &#36;{checkout} &#35;prod</p><p><a href="http://localhost/alerting/list">View alert rules</a></p></messageML>`, sender.messages[0])
+
+	t.Run("reuses the session of the bot", func(t *testing.T) {
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 1, sender.sessions)
+		require.Contains(t, sender.messages[1], `<h3 class="tempo-text-color--green">HighLatency</h3>`)
+	})
+
+	t.Run("authenticates again when the session expired", func(t *testing.T) {
+		sender.expired = true
+		ok, err := n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 2, sender.sessions)
+		require.Len(t, sender.messages, 3)
+	})
+}
+
+func TestNewSymphonyConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expErr   string
+	}{
+		{name: "Error when the pod URL is missing", settings: `{}`, expErr: "could not find pod URL in settings"},
+		{name: "Error when the bot username is missing", settings: `{"podUrl": "https://pod.example.com"}`, expErr: "could not find bot username in settings"},
+		{name: "Error when the private key is missing", settings: `{"podUrl": "https://pod.example.com", "botUsername": "bot"}`, expErr: "could not find private key in settings"},
+		{name: "Error when the private key is invalid", settings: `{"podUrl": "https://pod.example.com", "botUsername": "bot", "privateKey": "key"}`, expErr: "invalid private key: no PEM block found"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewSymphonyConfig(&NotificationChannelConfig{Type: "symphony", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expErr)
+		})
+	}
+}
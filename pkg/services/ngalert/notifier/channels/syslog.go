@@ -0,0 +1,324 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	defaultSyslogAppName       = "grafana"
+	defaultSyslogFacility      = "local0"
+	defaultSyslogSeverityLabel = "severity"
+	defaultSyslogMessage       = `{{ template "default.title" . }}`
+	// syslogSeverityFiring and syslogSeverityResolved are the severities of the firing alerts
+	// without a mapped severity, and of the resolved alerts.
+	syslogSeverityFiring   = "warning"
+	syslogSeverityResolved = "notice"
+	// syslogMaxUDPLength is the length of the messages that all receivers must accept over UDP
+	// according to RFC 5426. Longer messages are truncated.
+	syslogMaxUDPLength = 2048
+	syslogTimeout      = 10 * time.Second
+	// syslogEnterpriseNumber is the private enterprise number of the structured data, the one
+	// reserved for documentation by RFC 5424.
+	syslogEnterpriseNumber = "32473"
+)
+
+var (
+	// syslogFacilities are the facility codes of RFC 5424.
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+		"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+	// syslogSeverities are the severity codes of RFC 5424.
+	syslogSeverities = map[string]int{
+		"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+	}
+	// syslogSeverityValues maps the common values of the severity label to syslog severities.
+	syslogSeverityValues = map[string]string{
+		"critical": "crit",
+		"high":     "err",
+		"error":    "err",
+		"warning":  "warning",
+		"info":     "info",
+	}
+)
+
+// syslogDial connects to the syslog server. Can be overwritten in tests.
+var syslogDial = func(ctx context.Context, protocol, address string, tlsConfig *tls.Config) (net.Conn, error) {
+	d := &net.Dialer{Timeout: syslogTimeout}
+	if protocol == "tls" {
+		return (&tls.Dialer{NetDialer: d, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	}
+	return d.DialContext(ctx, protocol, address)
+}
+
+type SyslogConfig struct {
+	*NotificationChannelConfig
+	Address       string
+	Protocol      string
+	TLSSkipVerify bool
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+	Facility      string
+	AppName       string
+	Hostname      string
+	SeverityLabel string
+	Severities    map[string]string
+	Message       string
+}
+
+func SyslogFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewSyslogConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	n, err := NewSyslogNotifier(cfg, fc.Template)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: "invalid TLS settings",
+			Err:    err,
+			Cfg:    *fc.Config,
+		}
+	}
+	return n, nil
+}
+
+func NewSyslogConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*SyslogConfig, error) {
+	address := strings.TrimSpace(config.Settings.Get("address").MustString())
+	if address == "" {
+		return nil, errors.New("could not find address in settings")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid address %q, must be host:port", address)
+	}
+	protocol := strings.ToLower(config.Settings.Get("protocol").MustString("udp"))
+	switch protocol {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("invalid protocol %q, must be udp, tcp or tls", protocol)
+	}
+	facility := strings.ToLower(config.Settings.Get("facility").MustString(defaultSyslogFacility))
+	if _, ok := syslogFacilities[facility]; !ok {
+		return nil, fmt.Errorf("invalid facility %q", facility)
+	}
+	severities, err := severityMapFromSettings(config.Settings.Get("severities"), "syslog severity", syslogSeverityValues)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range severities {
+		if _, ok := syslogSeverities[v]; !ok {
+			return nil, fmt.Errorf("invalid syslog severity %q for severity %q, must be one of emerg, alert, crit, err, warning, notice, info or debug", v, k)
+		}
+	}
+
+	hostname := config.Settings.Get("hostname").MustString()
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	return &SyslogConfig{
+		NotificationChannelConfig: config,
+		Address:                   address,
+		Protocol:                  protocol,
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSCACert:                 config.Settings.Get("tlsCACert").MustString(),
+		TLSClientCert:             config.Settings.Get("tlsClientCert").MustString(),
+		TLSClientKey:              decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString()),
+		Facility:                  facility,
+		AppName:                   config.Settings.Get("appName").MustString(defaultSyslogAppName),
+		Hostname:                  hostname,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultSyslogSeverityLabel),
+		Severities:                severities,
+		Message:                   config.Settings.Get("message").MustString(defaultSyslogMessage),
+	}, nil
+}
+
+// NewSyslogNotifier is the constructor for the syslog notifier.
+func NewSyslogNotifier(config *SyslogConfig, t *template.Template) (*SyslogNotifier, error) {
+	var tlsConfig *tls.Config
+	if config.Protocol == "tls" {
+		var err error
+		if tlsConfig, err = brokerTLSConfig(config.TLSSkipVerify, config.TLSCACert, config.TLSClientCert, config.TLSClientKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SyslogNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Address:       config.Address,
+		Protocol:      config.Protocol,
+		Facility:      config.Facility,
+		AppName:       config.AppName,
+		Hostname:      config.Hostname,
+		SeverityLabel: config.SeverityLabel,
+		Severities:    config.Severities,
+		Message:       config.Message,
+		tlsConfig:     tlsConfig,
+		log:           log.New("alerting.notifier.syslog"),
+		tmpl:          t,
+	}, nil
+}
+
+// SyslogNotifier is responsible for sending alert notifications as RFC 5424 syslog messages.
+type SyslogNotifier struct {
+	*Base
+	Address       string
+	Protocol      string
+	Facility      string
+	AppName       string
+	Hostname      string
+	SeverityLabel string
+	Severities    map[string]string
+	Message       string
+	tlsConfig     *tls.Config
+	log           log.Logger
+	tmpl          *template.Template
+}
+
+// Notify sends a syslog message per alert, so that the SIEM receives an event per alert. The
+// labels of the alert are in the structured data of the message.
+func (sn *SyslogNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	sn.log.Debug("sending syslog notification", "notification", sn.Name)
+
+	messages := make([]string, 0, len(as))
+	for _, a := range as {
+		var tmplErr error
+		tmpl, data := TmplText(ctx, sn.tmpl, []*types.Alert{a}, sn.log, &tmplErr)
+		text := strings.TrimSpace(tmpl(sn.Message))
+		if tmplErr != nil {
+			sn.log.Warn("failed to template syslog message", "err", tmplErr.Error())
+		}
+		messages = append(messages, sn.format(data.Alerts[0], text))
+	}
+
+	if recordDryRun(ctx, sn.Protocol+"://"+sn.Address, strings.Join(messages, "\n")) {
+		return true, nil
+	}
+
+	conn, err := syslogDial(ctx, sn.Protocol, sn.Address, sn.tlsConfig)
+	if err != nil {
+		sn.log.Error("failed to connect to syslog server", "err", err, "notification", sn.Name)
+		return false, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			sn.log.Warn("failed to close syslog connection", "err", err)
+		}
+	}()
+	deadline := time.Now().Add(syslogTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return false, err
+	}
+
+	for _, m := range messages {
+		// Stream transports use the octet counting framing of RFC 6587 and RFC 5425.
+		if sn.Protocol != "udp" {
+			m = fmt.Sprintf("%d %s", len(m), m)
+		}
+		if _, err := conn.Write([]byte(m)); err != nil {
+			sn.log.Error("failed to send syslog message", "err", err, "notification", sn.Name)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// format returns the syslog message of an alert.
+func (sn *SyslogNotifier) format(alert ExtendedAlert, text string) string {
+	severity := syslogSeverityResolved
+	if alert.Status == "firing" {
+		severity = syslogSeverityFiring
+		if s, ok := sn.Severities[strings.ToLower(alert.Labels[sn.SeverityLabel])]; ok {
+			severity = s
+		}
+	}
+	pri := syslogFacilities[sn.Facility]*8 + syslogSeverities[severity]
+
+	var sd strings.Builder
+	fmt.Fprintf(&sd, "[alert@%s status=%q fingerprint=%q", syslogEnterpriseNumber, alert.Status, alert.Fingerprint)
+	if !alert.StartsAt.IsZero() {
+		fmt.Fprintf(&sd, " startsAt=%q", alert.StartsAt.UTC().Format(time.RFC3339))
+	}
+	sd.WriteString("]")
+	names := make([]string, 0, len(alert.Labels))
+	for name := range alert.Labels {
+		// The names of the parameters are limited to 32 characters.
+		if len(name) <= 32 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		fmt.Fprintf(&sd, "[labels@%s", syslogEnterpriseNumber)
+		for _, name := range names {
+			fmt.Fprintf(&sd, ` %s="%s"`, name, escapeSyslogParam(alert.Labels[name]))
+		}
+		sd.WriteString("]")
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s - %s %s ", pri, timeNow().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(sn.Hostname, 255), syslogHeaderField(sn.AppName, 48), alert.Status, sd.String())
+	if sn.Protocol == "udp" && len(header)+len(text) > syslogMaxUDPLength {
+		if limit := syslogMaxUDPLength - len(header); limit > 0 {
+			text = truncateBytes(text, limit)
+		} else {
+			text = ""
+		}
+	}
+	return header + text
+}
+
+// escapeSyslogParam escapes the characters that must be escaped in the values of the parameters
+// of the structured data.
+func escapeSyslogParam(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
+
+// syslogHeaderField returns the value of a field of the header, which is printable ASCII without
+// spaces, or the nil value if it is empty.
+func syslogHeaderField(s string, maxLength int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxLength {
+		s = s[:maxLength]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (sn *SyslogNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
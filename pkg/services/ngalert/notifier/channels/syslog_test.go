@@ -0,0 +1,164 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestSyslogNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	defer mockTimeNow(time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC))()
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "DiskFull", "instance": `db-1 "primary"`, "severity": "critical"},
+			StartsAt: time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "DiskFull", "instance": "db-2"},
+			StartsAt: time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC),
+			EndsAt:   time.Date(2022, 8, 1, 11, 30, 0, 0, time.UTC),
+		},
+	}}
+	fp1, fp2 := alerts[0].Fingerprint().String(), alerts[1].Fingerprint().String()
+	expMessages := []string{
+		`<130>1 2022-08-01T12:00:00.000000Z grafana-1 grafana - firing [alert@32473 status="firing" fingerprint="` + fp1 + `" startsAt="2022-08-01T11:00:00Z"][labels@32473 alertname="DiskFull" instance="db-1 \"primary\"" severity="critical"] [FIRING:1] DiskFull (db-1 "primary" critical)`,
+		`<133>1 2022-08-01T12:00:00.000000Z grafana-1 grafana - resolved [alert@32473 status="resolved" fingerprint="` + fp2 + `" startsAt="2022-08-01T11:00:00Z"][labels@32473 alertname="DiskFull" instance="db-2"] [RESOLVED] DiskFull (db-2)`,
+	}
+
+	newNotifier := func(t *testing.T, settings string) *SyslogNotifier {
+		settingsJSON, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewSyslogConfig(&NotificationChannelConfig{Name: "syslog_testing", Type: "syslog", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+		n, err := NewSyslogNotifier(cfg, tmpl)
+		require.NoError(t, err)
+		return n
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "DiskFull"})
+
+	t.Run("UDP datagram per alert", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		n := newNotifier(t, `{"address": "`+conn.LocalAddr().String()+`", "hostname": "grafana-1", "facility": "local0"}`)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		buf := make([]byte, syslogMaxUDPLength)
+		for _, exp := range expMessages {
+			l, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			require.Equal(t, exp, string(buf[:l]))
+		}
+	})
+
+	t.Run("TCP with octet counting", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = lis.Close() }()
+		received := make(chan string, 1)
+		go func() {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			b := new(strings.Builder)
+			_, _ = bufio.NewReader(conn).WriteTo(b)
+			received <- b.String()
+		}()
+
+		n := newNotifier(t, `{"address": "`+lis.Addr().String()+`", "protocol": "tcp", "hostname": "grafana-1", "severities": "critical=emerg"}`)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		msg1 := strings.Replace(expMessages[0], "<130>", "<128>", 1)
+		require.Equal(t, strings.Join([]string{
+			strconv.Itoa(len(msg1)) + " " + msg1,
+			strconv.Itoa(len(expMessages[1])) + " " + expMessages[1],
+		}, ""), <-received)
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		n := newNotifier(t, `{"address": "siem.example.com:514", "hostname": "grafana-1"}`)
+		d := &DryRun{}
+		ok, err := n.Notify(WithDryRun(ctx, d), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []DryRunRequest{{Target: "udp://siem.example.com:514", Body: strings.Join(expMessages, "\n")}}, d.Requests())
+	})
+
+	t.Run("Long UDP messages are truncated", func(t *testing.T) {
+		n := newNotifier(t, `{"address": "siem.example.com:514"}`)
+		m := n.format(ExtendedAlert{Status: "firing", Fingerprint: "fp"}, strings.Repeat("x", 3000))
+		require.Len(t, m, syslogMaxUDPLength)
+	})
+}
+
+func TestNewSyslogConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expError string
+	}{
+		{
+			name:     "Error when the address is missing",
+			settings: `{}`,
+			expError: "could not find address in settings",
+		}, {
+			name:     "Error when the address has no port",
+			settings: `{"address": "siem.example.com"}`,
+			expError: `invalid address "siem.example.com", must be host:port`,
+		}, {
+			name:     "Error when the protocol is invalid",
+			settings: `{"address": "siem.example.com:514", "protocol": "relp"}`,
+			expError: `invalid protocol "relp", must be udp, tcp or tls`,
+		}, {
+			name:     "Error when the facility is invalid",
+			settings: `{"address": "siem.example.com:514", "facility": "local9"}`,
+			expError: `invalid facility "local9"`,
+		}, {
+			name:     "Error when the syslog severity is invalid",
+			settings: `{"address": "siem.example.com:514", "severities": {"critical": "fatal"}}`,
+			expError: `invalid syslog severity "fatal" for severity "critical", must be one of emerg, alert, crit, err, warning, notice, info or debug`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			_, err = NewSyslogConfig(&NotificationChannelConfig{Name: "syslog_testing", Type: "syslog", Settings: settingsJSON}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, c.expError)
+		})
+	}
+}
@@ -294,45 +294,7 @@ func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	var tmplErr error
 	tmpl, _ := TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
 
-	card := NewAdaptiveCard()
-	card.AppendItem(AdaptiveCardTextBlockItem{
-		Color:  getTeamsTextColor(types.Alerts(as...)),
-		Text:   tmpl(tn.Title),
-		Size:   TextSizeLarge,
-		Weight: TextWeightBolder,
-		Wrap:   true,
-	})
-	card.AppendItem(AdaptiveCardTextBlockItem{
-		Text: tmpl(tn.Message),
-		Wrap: true,
-	})
-
-	var s AdaptiveCardImageSetItem
-	_ = withStoredImages(ctx, tn.log, tn.images,
-		func(_ int, image ngmodels.Image) error {
-			if image.URL != "" {
-				s.AppendImage(AdaptiveCardImageItem{URL: image.URL})
-			}
-			return nil
-		},
-		as...)
-
-	if len(s.Images) > 2 {
-		s.Size = ImageSizeMedium
-		card.AppendItem(s)
-	} else if len(s.Images) > 0 {
-		s.Size = ImageSizeLarge
-		card.AppendItem(s)
-	}
-
-	card.AppendItem(AdaptiveCardActionSetItem{
-		Actions: []AdaptiveCardActionItem{
-			AdaptiveCardOpenURLActionItem{
-				Title: "View URL",
-				URL:   joinUrlPath(tn.tmpl.ExternalURL.String(), "/alerting/list", tn.log),
-			},
-		},
-	})
+	card := newAlertAdaptiveCard(ctx, tn.log, tn.images, tmpl(tn.Title), tmpl(tn.Message), tn.RuleListURL(tn.tmpl.ExternalURL), as)
 
 	msg := NewAdaptiveCardsMessage(card)
 	msg.Summary = tmpl(tn.Title)
@@ -373,6 +335,52 @@ func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	return true, nil
 }
 
+// newAlertAdaptiveCard returns the Adaptive Card of a notification, with its title, its message,
+// the images of the alerts and a link to the alert rules.
+func newAlertAdaptiveCard(ctx context.Context, l log.Logger, images ImageStore, title, message, ruleURL string, as []*types.Alert) AdaptiveCard {
+	card := NewAdaptiveCard()
+	card.AppendItem(AdaptiveCardTextBlockItem{
+		Color:  getTeamsTextColor(types.Alerts(as...)),
+		Text:   title,
+		Size:   TextSizeLarge,
+		Weight: TextWeightBolder,
+		Wrap:   true,
+	})
+	card.AppendItem(AdaptiveCardTextBlockItem{
+		Text: message,
+		Wrap: true,
+	})
+
+	var s AdaptiveCardImageSetItem
+	_ = withStoredImages(ctx, l, images,
+		func(_ int, image ngmodels.Image) error {
+			if image.URL != "" {
+				s.AppendImage(AdaptiveCardImageItem{URL: image.URL})
+			}
+			return nil
+		},
+		as...)
+
+	if len(s.Images) > 2 {
+		s.Size = ImageSizeMedium
+		card.AppendItem(s)
+	} else if len(s.Images) > 0 {
+		s.Size = ImageSizeLarge
+		card.AppendItem(s)
+	}
+
+	card.AppendItem(AdaptiveCardActionSetItem{
+		Actions: []AdaptiveCardActionItem{
+			AdaptiveCardOpenURLActionItem{
+				Title: "View URL",
+				URL:   ruleURL,
+			},
+		},
+	})
+
+	return card
+}
+
 func (tn *TeamsNotifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
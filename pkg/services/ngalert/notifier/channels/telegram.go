@@ -112,6 +112,9 @@ func (tn *TelegramNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 	if err != nil {
 		return false, fmt.Errorf("failed to create telegram message: %w", err)
 	}
+	if err := telegramRateLimiter.wait(ctx, tn.BotToken); err != nil {
+		return false, err
+	}
 	if err := tn.ns.SendWebhookSync(ctx, cmd); err != nil {
 		return false, fmt.Errorf("failed to send telegram message: %w", err)
 	}
@@ -140,6 +143,9 @@ func (tn *TelegramNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 		if err != nil {
 			return fmt.Errorf("failed to create image: %w", err)
 		}
+		if err := telegramRateLimiter.wait(ctx, tn.BotToken); err != nil {
+			return err
+		}
 		if err := tn.ns.SendWebhookSync(ctx, cmd); err != nil {
 			return fmt.Errorf("failed to upload image to telegram: %w", err)
 		}
@@ -157,9 +163,11 @@ func (tn *TelegramNotifier) buildTelegramMessage(ctx context.Context, as []*type
 		}
 	}()
 
-	tmpl, _ := TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
 	m := make(map[string]string)
-	m["text"] = tmpl(tn.Message)
+	m["text"], _ = tn.FitAlerts(ctx, tn.tmpl.ExternalURL, as, func(alerts []*types.Alert) string {
+		tmpl, _ := TmplText(ctx, tn.tmpl, alerts, tn.log, &tmplErr)
+		return tmpl(tn.Message)
+	})
 	m["parse_mode"] = "html"
 	return m, nil
 }
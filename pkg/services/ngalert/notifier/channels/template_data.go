@@ -31,6 +31,12 @@ type ExtendedAlert struct {
 	ValueString   string      `json:"valueString"`
 	ImageURL      string      `json:"imageURL,omitempty"`
 	EmbeddedImage string      `json:"embeddedImage,omitempty"`
+
+	// Grafana is only set from version 2 of the JSON payloads.
+	Grafana *GrafanaMetadata `json:"grafana,omitempty"`
+
+	dashboards *dashboardLookup
+	grafana    *GrafanaMetadata
 }
 
 type ExtendedAlerts []ExtendedAlert
@@ -45,6 +51,45 @@ type ExtendedData struct {
 	CommonAnnotations template.KV `json:"commonAnnotations"`
 
 	ExternalURL string `json:"externalURL"`
+
+	OrgTimeZone string `json:"orgTimeZone,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+
+	// Digest is the summary of the alerts of the notifications of receivers in digest mode.
+	Digest *Digest `json:"digest,omitempty"`
+
+	dashboards *dashboardLookup
+}
+
+// OrgPreferences are the preferences of the organization that apply to the rendering of notifications.
+type OrgPreferences struct {
+	TimeZone string
+	Locale   string
+}
+
+type orgPreferencesKey struct{}
+
+// WithOrgPreferences returns a copy of the context with the preferences of the organization.
+func WithOrgPreferences(ctx context.Context, p OrgPreferences) context.Context {
+	return context.WithValue(ctx, orgPreferencesKey{}, p)
+}
+
+// OrgPreferencesFromContext returns the preferences of the organization stored in the context, if any.
+func OrgPreferencesFromContext(ctx context.Context) (OrgPreferences, bool) {
+	p, ok := ctx.Value(orgPreferencesKey{}).(OrgPreferences)
+	return p, ok
+}
+
+// orgLocation returns the location of a timezone preference. The browser timezone (the default)
+// is unknown when sending notifications, in which case nil is returned and times are left in UTC.
+func orgLocation(timeZone string) (*time.Location, error) {
+	switch strings.ToLower(timeZone) {
+	case "", "browser":
+		return nil, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timeZone)
 }
 
 func removePrivateItems(kv template.KV) template.KV {
@@ -66,6 +111,7 @@ func extendAlert(alert template.Alert, externalURL string, logger log.Logger) *E
 		EndsAt:       alert.EndsAt,
 		GeneratorURL: alert.GeneratorURL,
 		Fingerprint:  alert.Fingerprint,
+		grafana:      grafanaMetadata(alert.Labels, alert.Annotations, externalURL),
 	}
 
 	// fill in some grafana-specific urls
@@ -137,18 +183,57 @@ func ExtendData(data *template.Data, logger log.Logger) *ExtendedData {
 }
 
 func TmplText(ctx context.Context, tmpl *template.Template, alerts []*types.Alert, l log.Logger, tmplErr *error) (func(string) string, *ExtendedData) {
+	defer observe(ctx, templateStage, time.Now())
 	promTmplData := notify.GetTemplateData(ctx, tmpl, alerts, l)
 	data := ExtendData(promTmplData, l)
+	if d, ok := DigestFromContext(ctx); ok {
+		data.Digest = d
+	}
+	if p, ok := OrgPreferencesFromContext(ctx); ok {
+		data.localize(p, l)
+	}
+	if fn, ok := dashboardMetadataFromContext(ctx); ok {
+		data.setDashboardLookup(newDashboardLookup(ctx, fn, promTmplData.Alerts, l))
+	}
 
 	return func(name string) (s string) {
 		if *tmplErr != nil {
 			return
 		}
+		defer observe(ctx, templateStage, time.Now())
 		s, *tmplErr = tmpl.ExecuteTextString(name, data)
 		return s
 	}, data
 }
 
+// localize sets the timezone and locale of the organization, or of the notification policy, and
+// converts the start and end times of the alerts and the window of the digest to the timezone, so
+// that templates render them in local time.
+func (d *ExtendedData) localize(p OrgPreferences, logger log.Logger) {
+	d.Locale = p.Locale
+
+	loc, err := orgLocation(p.TimeZone)
+	if err != nil {
+		logger.Warn("failed to load organization timezone, times are rendered in UTC", "timezone", p.TimeZone, "err", err)
+		return
+	}
+	if loc == nil {
+		return
+	}
+
+	d.OrgTimeZone = loc.String()
+	for i := range d.Alerts {
+		d.Alerts[i].StartsAt = d.Alerts[i].StartsAt.In(loc)
+		d.Alerts[i].EndsAt = d.Alerts[i].EndsAt.In(loc)
+	}
+	if d.Digest != nil {
+		// The digest is shared by the integrations of the receiver.
+		digest := *d.Digest
+		digest.Since, digest.Until = digest.Since.In(loc), digest.Until.In(loc)
+		d.Digest = &digest
+	}
+}
+
 // Firing returns the subset of alerts that are firing.
 func (as ExtendedAlerts) Firing() []ExtendedAlert {
 	res := []ExtendedAlert{}
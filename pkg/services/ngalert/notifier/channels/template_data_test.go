@@ -0,0 +1,102 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestTmplText_OrgPreferences(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert1"},
+				StartsAt: startsAt,
+			},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		prefs          *OrgPreferences
+		expTimeZone    string
+		expLocale      string
+		expStartsAtStr string
+	}{
+		{
+			name:           "times are rendered in UTC without preferences",
+			expStartsAtStr: "2022-06-01 12:00:00 +0000 UTC",
+		}, {
+			name:           "times are rendered in the timezone of the organization",
+			prefs:          &OrgPreferences{TimeZone: "Europe/Berlin", Locale: "de-DE"},
+			expTimeZone:    "Europe/Berlin",
+			expLocale:      "de-DE",
+			expStartsAtStr: "2022-06-01 14:00:00 +0200 CEST",
+		}, {
+			name:           "utc timezone",
+			prefs:          &OrgPreferences{TimeZone: "utc"},
+			expTimeZone:    "UTC",
+			expStartsAtStr: "2022-06-01 12:00:00 +0000 UTC",
+		}, {
+			name:           "browser timezone is left in UTC",
+			prefs:          &OrgPreferences{TimeZone: "browser", Locale: "fr-FR"},
+			expLocale:      "fr-FR",
+			expStartsAtStr: "2022-06-01 12:00:00 +0000 UTC",
+		}, {
+			name:           "unknown timezone is left in UTC",
+			prefs:          &OrgPreferences{TimeZone: "Mars/Olympus_Mons"},
+			expStartsAtStr: "2022-06-01 12:00:00 +0000 UTC",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			if c.prefs != nil {
+				ctx = WithOrgPreferences(ctx, *c.prefs)
+			}
+
+			var tmplErr error
+			expand, data := TmplText(ctx, tmpl, alerts, log.New("test"), &tmplErr)
+			require.Equal(t, c.expTimeZone, data.OrgTimeZone)
+			require.Equal(t, c.expLocale, data.Locale)
+			require.True(t, data.Alerts[0].StartsAt.Equal(startsAt))
+
+			require.Equal(t, c.expStartsAtStr, expand(`{{ (index .Alerts 0).StartsAt }}`))
+			require.NoError(t, tmplErr)
+		})
+	}
+}
+
+func TestTmplText_OrgPreferencesDigest(t *testing.T) {
+	since := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	digest := &Digest{Since: since, Until: since.Add(time.Hour), Alerts: 1, Firing: 1}
+	ctx := WithDigest(context.Background(), digest)
+	ctx = WithOrgPreferences(ctx, OrgPreferences{TimeZone: "Europe/Berlin"})
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var tmplErr error
+	expand, _ := TmplText(ctx, tmpl, nil, log.New("test"), &tmplErr)
+	require.Equal(t, "2022-06-01 14:00 CEST", expand(`{{ .Digest.Since.Format "2006-01-02 15:04 MST" }}`))
+	require.NoError(t, tmplErr)
+	// The digest of the context is shared by the integrations of the receiver.
+	require.Equal(t, time.UTC, digest.Since.Location())
+}
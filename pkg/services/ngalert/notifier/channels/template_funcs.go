@@ -0,0 +1,89 @@
+package channels
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+func init() {
+	// The functions are added to those of the templates of the Alertmanager, which are created
+	// with the default functions.
+	template.DefaultFuncs["escapeMarkdown"] = EscapeMarkdown
+	template.DefaultFuncs["stripMarkdown"] = StripMarkdown
+	template.DefaultFuncs["json"] = jsonString
+}
+
+// jsonString returns the value as JSON, such as a quoted string, for the templates that render
+// JSON like the Adaptive Cards of Webex.
+func jsonString(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// markdownEscaper escapes the characters of the inline formatting of markdown: emphasis, code,
+// strikethrough, links, HTML and tables.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`~`, `\~`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`|`, `\|`,
+)
+
+// EscapeMarkdown escapes the markdown formatting of the text, such as the underscores of the names
+// of metrics, so that it is rendered as is by the markdown of Webex, Slack and Teams.
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// The patterns of the markdown formatting removed by StripMarkdown, in order.
+var markdownPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile("(?m)^```[^\n]*\n?"), ""},
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile("`([^`]*)`"), "$1"},
+	{regexp.MustCompile(`\*\*(.+?)\*\*`), "$1"},
+	{regexp.MustCompile(`__(.+?)__`), "$1"},
+	{regexp.MustCompile(`~~(.+?)~~`), "$1"},
+	{regexp.MustCompile(`\*([^*\s][^*]*?)\*`), "$1"},
+	{regexp.MustCompile(`\b_([^_\s][^_]*?)_\b`), "$1"},
+	{regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+`), ""},
+	{regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`), ""},
+}
+
+// markdownEscapedChars are the characters escaped by EscapeMarkdown, which StripMarkdown keeps as
+// is. They are replaced by characters of the private use area while the formatting is removed.
+const (
+	markdownEscapedChars = "\\`*_~[]<>|"
+	markdownPlaceholder  = '\ue000'
+)
+
+var escapedMarkdown = regexp.MustCompile("\\\\[\\\\`*_~\\[\\]<>|]")
+
+// StripMarkdown removes the markdown formatting of the text, keeping the text of the links, for
+// the messages in plain text.
+func StripMarkdown(s string) string {
+	s = escapedMarkdown.ReplaceAllStringFunc(s, func(m string) string {
+		return string(markdownPlaceholder + rune(strings.IndexByte(markdownEscapedChars, m[1])))
+	})
+	for _, p := range markdownPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return strings.Map(func(r rune) rune {
+		if i := int(r - markdownPlaceholder); i >= 0 && i < len(markdownEscapedChars) {
+			return rune(markdownEscapedChars[i])
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,62 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	cases := map[string]string{
+		"node_cpu_seconds_total":         `node\_cpu\_seconds\_total`,
+		"*critical* [runbook](http://x)": `\*critical\* \[runbook\](http://x)`,
+		"a `b` ~c~ <d> e|f \\":           "a \\`b\\` \\~c\\~ \\<d\\> e\\|f \\\\",
+		"no formatting":                  "no formatting",
+	}
+	for in, exp := range cases {
+		require.Equal(t, exp, EscapeMarkdown(in), in)
+		require.Equal(t, in, StripMarkdown(EscapeMarkdown(in)), in)
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	cases := map[string]string{
+		"**Firing** on *db-1*":                        "Firing on db-1",
+		"__bold__ and _italic_ and ~~struck~~":        "bold and italic and struck",
+		"See the [runbook](https://runbooks/disk)":    "See the runbook",
+		"![graph](https://images/graph.png)":          "graph",
+		"`disk_used` is high":                         "disk_used is high",
+		"# Summary\n> quoted":                         "Summary\nquoted",
+		"```\ndf -h\n```\n":                           "df -h\n",
+		"node_cpu_seconds_total and snake_case_names": "node_cpu_seconds_total and snake_case_names",
+	}
+	for in, exp := range cases {
+		require.Equal(t, exp, StripMarkdown(in), in)
+	}
+}
+
+func TestTemplateMarkdownFuncs(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"summary": "*node_load1* is [high](http://x)"},
+		},
+	}}
+
+	var tmplErr error
+	expand, _ := TmplText(context.Background(), tmpl, alerts, log.New("test"), &tmplErr)
+	require.Equal(t, `\*node\_load1\* is \[high\](http://x)`, expand(`{{ .CommonAnnotations.summary | escapeMarkdown }}`))
+	require.Equal(t, "node_load1 is high", expand(`{{ .CommonAnnotations.summary | stripMarkdown }}`))
+	require.Equal(t, `{"text": "*node_load1* is [high](http://x)"}`, expand(`{"text": {{ .CommonAnnotations.summary | json }}}`))
+	require.NoError(t, tmplErr)
+}
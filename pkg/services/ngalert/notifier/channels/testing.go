@@ -132,10 +132,20 @@ type notificationServiceMock struct {
 	EmailSync   models.SendEmailCommandSync
 	Emailx      models.SendEmailCommand
 	ShouldError error
+
+	// Webhooks are all the webhooks sent, in order.
+	Webhooks []models.SendWebhookSync
+	// ResponseBody, if set, returns the body of the response to the n-th webhook, starting at 1,
+	// which the webhook is validated with.
+	ResponseBody func(n int) string
 }
 
 func (ns *notificationServiceMock) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
 	ns.Webhook = *cmd
+	ns.Webhooks = append(ns.Webhooks, *cmd)
+	if ns.ShouldError == nil && ns.ResponseBody != nil && cmd.Validation != nil {
+		return cmd.Validation([]byte(ns.ResponseBody(len(ns.Webhooks))), 200)
+	}
 	return ns.ShouldError
 }
 func (ns *notificationServiceMock) SendEmailCommandHandlerSync(ctx context.Context, cmd *models.SendEmailCommandSync) error {
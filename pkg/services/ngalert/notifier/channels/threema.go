@@ -5,12 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"path"
 	"strings"
 
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
-	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
@@ -116,19 +114,12 @@ func (tn *ThreemaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 	data.Set("to", tn.RecipientID)
 	data.Set("secret", tn.APISecret)
 
-	// Determine emoji
-	stateEmoji := "\u26A0\uFE0F " // Warning sign
-	alerts := types.Alerts(as...)
-	if alerts.Status() == model.AlertResolved {
-		stateEmoji = "\u2705 " // Check Mark Button
-	}
-
 	// Build message
-	message := fmt.Sprintf("%s%s\n\n*Message:*\n%s\n*URL:* %s\n",
-		stateEmoji,
+	message := fmt.Sprintf("%s %s\n\n*Message:*\n%s\n*URL:* %s\n",
+		tn.StateEmoji(as...),
 		tmpl(DefaultMessageTitleEmbed),
 		tmpl(`{{ template "default.message" . }}`),
-		path.Join(tn.tmpl.ExternalURL.String(), "/alerting/list"),
+		tn.RuleListURL(tn.tmpl.ExternalURL),
 	)
 
 	_ = withStoredImages(ctx, tn.log, tn.images,
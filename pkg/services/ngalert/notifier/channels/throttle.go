@@ -0,0 +1,264 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// The contact point settings of the throttling of the notifications.
+	throttleNotificationsSetting = "throttleNotifications"
+	throttleIntervalSetting      = "throttleInterval"
+	throttleOverflowSetting      = "throttleOverflow"
+
+	defaultThrottleInterval = time.Minute
+
+	// throttleNotifyTimeout is the time available to send a summary of the throttled alerts.
+	throttleNotifyTimeout = time.Minute
+)
+
+// ThrottleOverflow is what is done with the notifications over the rate limit of a contact point.
+type ThrottleOverflow string
+
+const (
+	// ThrottleDrop drops the notifications over the rate limit.
+	ThrottleDrop ThrottleOverflow = "drop"
+	// ThrottleQueue delays the notifications over the rate limit until it allows them, or until
+	// they time out.
+	ThrottleQueue ThrottleOverflow = "queue"
+	// ThrottleSummarize sends the alerts of the notifications over the rate limit together, in
+	// a single notification, once the rate limit allows it.
+	ThrottleSummarize ThrottleOverflow = "summarize"
+)
+
+var notifierThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "alerting",
+	Name:      "notifier_throttled_total",
+	Help:      "The total number of notifications over the rate limit of their contact point.",
+}, []string{"integration", "overflow"})
+
+// ThrottleSettings are the options of the rate limit of the notifications of a contact point.
+type ThrottleSettings struct {
+	// Notifications is the number of notifications allowed per interval. Zero disables the
+	// rate limit.
+	Notifications int
+	// Interval is the interval of the rate limit.
+	Interval time.Duration
+	// Overflow is what is done with the notifications over the rate limit.
+	Overflow ThrottleOverflow
+}
+
+// throttleFromSettings returns the rate limit options of a contact point.
+func throttleFromSettings(settings *simplejson.Json) (ThrottleSettings, error) {
+	s := ThrottleSettings{Interval: defaultThrottleInterval, Overflow: ThrottleDrop}
+	if settings == nil {
+		return s, nil
+	}
+	// The number of notifications is a number in provisioned contact points and a string in the UI.
+	if v := settings.Get(throttleNotificationsSetting).Interface(); v != nil && v != "" {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 0 {
+			return ThrottleSettings{}, fmt.Errorf("invalid %s %v, must be a positive number of notifications", throttleNotificationsSetting, v)
+		}
+		s.Notifications = n
+	}
+	if v := strings.TrimSpace(settings.Get(throttleIntervalSetting).MustString()); v != "" {
+		d, err := gtime.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return ThrottleSettings{}, fmt.Errorf("invalid %s %q, must be a duration such as 1m", throttleIntervalSetting, v)
+		}
+		s.Interval = d
+	}
+	if v := strings.TrimSpace(settings.Get(throttleOverflowSetting).MustString()); v != "" {
+		switch o := ThrottleOverflow(strings.ToLower(v)); o {
+		case ThrottleDrop, ThrottleQueue, ThrottleSummarize:
+			s.Overflow = o
+		default:
+			return ThrottleSettings{}, fmt.Errorf("invalid %s %q, must be one of %s, %s or %s", throttleOverflowSetting, v, ThrottleDrop, ThrottleQueue, ThrottleSummarize)
+		}
+	}
+	return s, nil
+}
+
+// ThrottleFromSettings returns the rate limit options of the settings of a contact point, which
+// are validated by NewFactoryConfig.
+func ThrottleFromSettings(settings *simplejson.Json) ThrottleSettings {
+	s, _ := throttleFromSettings(settings)
+	return s
+}
+
+// Throttle is the rate limit of the notifications of a contact point, so that an alert storm
+// does not get the contact point banned by its provider.
+type Throttle struct {
+	integrationType string
+	log             log.Logger
+
+	mtx      sync.Mutex
+	settings ThrottleSettings
+	limiter  *rate.Limiter
+	// channel sends the summaries of the throttled alerts.
+	channel NotificationChannel
+	// pending are the alerts of the summary, in the order they were throttled, and their
+	// indexes by fingerprint.
+	pending    []*types.Alert
+	pendingIdx map[model.Fingerprint]int
+	// pendingCtx is the context of the last throttled notification.
+	pendingCtx context.Context
+	timer      *time.Timer
+}
+
+// NewThrottle returns the rate limit of a contact point of the integration type.
+func NewThrottle(integrationType string, settings ThrottleSettings) *Throttle {
+	t := &Throttle{
+		integrationType: integrationType,
+		log:             log.New("alerting.notifier.throttle"),
+		pendingIdx:      map[model.Fingerprint]int{},
+	}
+	t.Configure(settings)
+	return t
+}
+
+// Configure changes the options of the rate limit, keeping the notifications already sent in
+// the interval.
+func (t *Throttle) Configure(settings ThrottleSettings) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	limit := rate.Limit(0)
+	if settings.Notifications > 0 {
+		limit = rate.Limit(float64(settings.Notifications) / settings.Interval.Seconds())
+	}
+	if t.limiter == nil {
+		t.limiter = rate.NewLimiter(limit, settings.Notifications)
+	} else {
+		t.limiter.SetLimit(limit)
+		t.limiter.SetBurst(settings.Notifications)
+	}
+	t.settings = settings
+}
+
+// Close drops the summary of the throttled alerts of a contact point that is removed.
+func (t *Throttle) Close() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if len(t.pending) > 0 {
+		t.log.Warn("dropping the summary of the throttled alerts of a removed contact point", "integration", t.integrationType, "alerts", len(t.pending))
+	}
+	t.resetPending()
+}
+
+// resetPending empties the summary. It must be called with the lock held.
+func (t *Throttle) resetPending() {
+	t.pending = nil
+	t.pendingIdx = map[model.Fingerprint]int{}
+	t.pendingCtx = nil
+}
+
+// summarize adds the alerts to the summary, which is scheduled for when the rate limit allows it.
+func (t *Throttle) summarize(ctx context.Context, as []*types.Alert) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, a := range as {
+		fp := a.Fingerprint()
+		if i, ok := t.pendingIdx[fp]; ok {
+			t.pending[i] = a
+			continue
+		}
+		t.pendingIdx[fp] = len(t.pending)
+		t.pending = append(t.pending, a)
+	}
+	// The context of the notification is canceled once it is sent, but its values are needed
+	// to send the summary.
+	t.pendingCtx = detachedContext{ctx}
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.limiter.Reserve().Delay(), t.flush)
+	}
+}
+
+// flush sends the summary of the throttled alerts.
+func (t *Throttle) flush() {
+	t.mtx.Lock()
+	alerts, ctx, channel := t.pending, t.pendingCtx, t.channel
+	t.timer = nil
+	t.resetPending()
+	t.mtx.Unlock()
+	if len(alerts) == 0 || channel == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, throttleNotifyTimeout)
+	defer cancel()
+	if _, err := channel.Notify(ctx, alerts...); err != nil {
+		t.log.Error("failed to send the summary of the throttled alerts", "integration", t.integrationType, "alerts", len(alerts), "err", err)
+	}
+}
+
+// NewThrottlingNotifier returns the notifier rate limited by the throttle, or the notifier itself
+// if the throttle is nil.
+func NewThrottlingNotifier(n NotificationChannel, t *Throttle) NotificationChannel {
+	if t == nil {
+		return n
+	}
+	t.mtx.Lock()
+	t.channel = n
+	t.mtx.Unlock()
+	return &throttlingNotifier{NotificationChannel: n, throttle: t}
+}
+
+type throttlingNotifier struct {
+	NotificationChannel
+	throttle *Throttle
+}
+
+// Notify sends the notification if the rate limit allows it, and drops, delays or summarizes it
+// otherwise. Dry runs are sent as is.
+func (n *throttlingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	if _, ok := dryRunFromContext(ctx); ok {
+		return n.NotificationChannel.Notify(ctx, as...)
+	}
+
+	t := n.throttle
+	t.mtx.Lock()
+	overflow, limiter := t.settings.Overflow, t.limiter
+	t.mtx.Unlock()
+
+	if overflow == ThrottleQueue {
+		if !limiter.Allow() {
+			notifierThrottled.WithLabelValues(t.integrationType, string(overflow)).Inc()
+			if err := limiter.Wait(ctx); err != nil {
+				// The notification is not retried, as it would wait again.
+				return false, fmt.Errorf("the notification is over the rate limit of the contact point: %w", err)
+			}
+		}
+		return n.NotificationChannel.Notify(ctx, as...)
+	}
+
+	if limiter.Allow() {
+		return n.NotificationChannel.Notify(ctx, as...)
+	}
+	notifierThrottled.WithLabelValues(t.integrationType, string(overflow)).Inc()
+	if overflow == ThrottleSummarize {
+		t.summarize(ctx, as)
+		return true, nil
+	}
+	t.log.Warn("dropping notification over the rate limit of the contact point", "integration", t.integrationType, "alerts", len(as))
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestThrottlingNotifier(t *testing.T) {
+	alert := func(name string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}}}
+	}
+	a1, a2, a3 := alert("alert1"), alert("alert2"), alert("alert3")
+	ctx := context.Background()
+
+	t.Run("notifications over the rate limit are dropped", func(t *testing.T) {
+		r := &recordingNotifier{}
+		n := NewThrottlingNotifier(r, NewThrottle("slack", ThrottleSettings{Notifications: 2, Interval: time.Hour, Overflow: ThrottleDrop}))
+		for _, a := range []*types.Alert{a1, a2, a3} {
+			ok, err := n.Notify(ctx, a)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Equal(t, [][]*types.Alert{{a1}, {a2}}, r.sent())
+
+		// Dry runs are sent as is.
+		_, err := n.Notify(WithDryRun(ctx, &DryRun{}), a3)
+		require.NoError(t, err)
+		require.Len(t, r.sent(), 3)
+	})
+
+	t.Run("notifications over the rate limit are queued until they time out", func(t *testing.T) {
+		r := &recordingNotifier{}
+		n := NewThrottlingNotifier(r, NewThrottle("slack", ThrottleSettings{Notifications: 1, Interval: 50 * time.Millisecond, Overflow: ThrottleQueue}))
+		_, err := n.Notify(ctx, a1)
+		require.NoError(t, err)
+		_, err = n.Notify(ctx, a2)
+		require.NoError(t, err)
+		require.Equal(t, [][]*types.Alert{{a1}, {a2}}, r.sent())
+
+		tctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		ok, err := n.Notify(tctx, a3)
+		require.ErrorContains(t, err, "the notification is over the rate limit of the contact point")
+		require.False(t, ok)
+		require.Len(t, r.sent(), 2)
+	})
+
+	t.Run("alerts over the rate limit are summarized", func(t *testing.T) {
+		r := &recordingNotifier{}
+		th := NewThrottle("slack", ThrottleSettings{Notifications: 1, Interval: 50 * time.Millisecond, Overflow: ThrottleSummarize})
+		n := NewThrottlingNotifier(r, th)
+		for _, as := range [][]*types.Alert{{a1}, {a2}, {a3, a2}} {
+			ok, err := n.Notify(ctx, as...)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Equal(t, [][]*types.Alert{{a1}}, r.sent())
+		require.Eventually(t, func() bool { return len(r.sent()) == 2 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, []*types.Alert{a2, a3}, r.sent()[1])
+	})
+
+	t.Run("the summary of a removed contact point is dropped", func(t *testing.T) {
+		r := &recordingNotifier{}
+		th := NewThrottle("slack", ThrottleSettings{Notifications: 1, Interval: 50 * time.Millisecond, Overflow: ThrottleSummarize})
+		n := NewThrottlingNotifier(r, th)
+		_, _ = n.Notify(ctx, a1)
+		_, _ = n.Notify(ctx, a2)
+		th.Close()
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, [][]*types.Alert{{a1}}, r.sent())
+	})
+
+	require.Equal(t, &recordingNotifier{}, NewThrottlingNotifier(&recordingNotifier{}, nil))
+}
+
+func TestThrottleFromSettings(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"throttleNotifications": "20", "throttleInterval": "5m", "throttleOverflow": "Summarize"}`))
+	require.NoError(t, err)
+	s, err := throttleFromSettings(settings)
+	require.NoError(t, err)
+	require.Equal(t, ThrottleSettings{Notifications: 20, Interval: 5 * time.Minute, Overflow: ThrottleSummarize}, s)
+
+	s, err = throttleFromSettings(simplejson.New())
+	require.NoError(t, err)
+	require.Equal(t, ThrottleSettings{Interval: time.Minute, Overflow: ThrottleDrop}, s)
+
+	for settings, expErr := range map[string]string{
+		`{"throttleNotifications": -1}`:    `invalid throttleNotifications -1, must be a positive number of notifications`,
+		`{"throttleInterval": "soon"}`:     `invalid throttleInterval "soon", must be a duration such as 1m`,
+		`{"throttleOverflow": "postpone"}`: `invalid throttleOverflow "postpone", must be one of drop, queue or summarize`,
+	} {
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		_, err = throttleFromSettings(s)
+		require.EqualError(t, err, expErr, settings)
+	}
+}
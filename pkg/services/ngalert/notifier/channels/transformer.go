@@ -0,0 +1,155 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// payloadTransformerSetting is the contact point setting holding the Starlark script.
+	payloadTransformerSetting = "payloadTransformer"
+	payloadTransformerFunc    = "transform"
+
+	transformerMaxScriptSize = 64 * 1024
+	transformerMaxOutputSize = 1024 * 1024
+	transformerMaxSteps      = 1000000
+	transformerTimeout       = time.Second
+)
+
+// PayloadTransformer reshapes the payload sent by a contact point with a Starlark script.
+// The script must define a function transform(payload) which receives the payload decoded
+// from JSON, or as a string if it is not JSON, and returns the new payload. Dicts and lists
+// are encoded to JSON, strings are sent as they are.
+//
+// Scripts run in a sandbox without access to the network or file system. They are cancelled
+// after a fixed number of execution steps or when the notification times out, and fail once they
+// exceed their memory budget.
+type PayloadTransformer struct {
+	program *starlark.Program
+}
+
+var transformerPredeclared = transformerBuiltins()
+
+// NewPayloadTransformer compiles the script and checks that it defines the transform function.
+func NewPayloadTransformer(script string) (*PayloadTransformer, error) {
+	if len(script) > transformerMaxScriptSize {
+		return nil, fmt.Errorf("script must not be larger than %d bytes", transformerMaxScriptSize)
+	}
+
+	f, err := syntax.Parse("transformer.star", script, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := guardScript(f); err != nil {
+		return nil, err
+	}
+	program, err := starlark.FileProgram(f, transformerPredeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &PayloadTransformer{program: program}
+	_, _, release, err := t.init(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return t, nil
+}
+
+// init runs the top level statements of the script in a new thread and returns the transform
+// function. The returned function must be called once the thread is no longer used.
+func (t *PayloadTransformer) init(ctx context.Context) (*starlark.Thread, starlark.Callable, func(), error) {
+	thread := &starlark.Thread{
+		Name:  "payload transformer",
+		Print: func(*starlark.Thread, string) {},
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not supported")
+		},
+	}
+	thread.SetMaxExecutionSteps(transformerMaxSteps)
+	thread.SetLocal(transformerBudgetKey, &transformerBudget{left: transformerMaxAllocs})
+
+	ctx, cancel := context.WithTimeout(ctx, transformerTimeout)
+	go func() {
+		<-ctx.Done()
+		thread.Cancel(ctx.Err().Error())
+	}()
+
+	globals, err := t.program.Init(thread, transformerPredeclared)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	fn, ok := globals[payloadTransformerFunc].(starlark.Callable)
+	if !ok {
+		cancel()
+		return nil, nil, nil, fmt.Errorf("script must define a function %s(payload)", payloadTransformerFunc)
+	}
+	return thread, fn, cancel, nil
+}
+
+// Transform runs the script on the payload and returns the new payload.
+func (t *PayloadTransformer) Transform(ctx context.Context, payload string) (string, error) {
+	thread, fn, release, err := t.init(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	var in starlark.Value = starlark.String(payload)
+	if decoded, err := starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{in}, nil); err == nil {
+		in = decoded
+	}
+
+	out, err := starlark.Call(thread, fn, starlark.Tuple{in}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	switch v := out.(type) {
+	case starlark.String:
+		result = string(v)
+	case *starlark.Dict, *starlark.List:
+		encode := transformerPredeclared["json"].(*starlarkstruct.Module).Members["encode"]
+		encoded, err := starlark.Call(thread, encode, starlark.Tuple{v}, nil)
+		if err != nil {
+			return "", err
+		}
+		result = string(encoded.(starlark.String))
+	default:
+		return "", fmt.Errorf("%s must return a dict, a list or a string, got %s", payloadTransformerFunc, out.Type())
+	}
+
+	if len(result) > transformerMaxOutputSize {
+		return "", fmt.Errorf("transformed payload must not be larger than %d bytes", transformerMaxOutputSize)
+	}
+	return result, nil
+}
+
+// transformingNotificationService transforms the body of webhooks before sending them.
+type transformingNotificationService struct {
+	notifications.Service
+	transformer *PayloadTransformer
+}
+
+func (s *transformingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	body, err := s.transformer.Transform(ctx, cmd.Body)
+	if err != nil {
+		return fmt.Errorf("failed to transform payload: %w", err)
+	}
+	transformed := *cmd
+	transformed.Body = body
+	return s.Service.SendWebhookSync(ctx, &transformed)
+}
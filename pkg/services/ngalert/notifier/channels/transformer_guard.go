@@ -0,0 +1,796 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// The Starlark interpreter counts execution steps but not memory, and a single step such as
+// "x" * 1000000000 can allocate gigabytes. Scripts are therefore rewritten before they are compiled
+// so that the operations whose results can be larger than their operands, such as concatenations,
+// repetitions, string formatting, slices and the methods and builtins creating strings and lists,
+// go through guards. The guards charge an estimate of the size of the result to the budget of the
+// thread before computing it, and fail the script once the budget is exhausted.
+
+const (
+	// transformerMaxAllocs is the memory budget of a script in bytes.
+	transformerMaxAllocs = 64 * 1024 * 1024
+	// transformerMaxIntBits bounds the ints, which then need no budget.
+	transformerMaxIntBits = 128
+	// transformerMaxDepth bounds the nesting of the values converted to strings.
+	transformerMaxDepth = 100
+	// transformerElemSize is the estimated size of the elements of lists, tuples and dicts.
+	transformerElemSize = 16
+
+	transformerBudgetKey = "transformer.budget"
+
+	// The guards called by the rewritten scripts. Their names are not valid identifiers, so that
+	// scripts can neither call nor redefine them.
+	guardBinaryName  = "$binary"
+	guardOperandName = "$operand"
+	guardAttrName    = "$attr"
+	guardSliceName   = "$slice"
+	guardSpreadName  = "$spread"
+)
+
+var errTransformerMemory = fmt.Errorf("script must not allocate more than %d bytes", transformerMaxAllocs)
+
+// transformerBudget is the memory left to a script.
+type transformerBudget struct {
+	left int
+}
+
+// chargeAllocs charges n times size bytes to the budget of the thread.
+func chargeAllocs(thread *starlark.Thread, n, size int) error {
+	if n <= 0 || size <= 0 {
+		return nil
+	}
+	budget := thread.Local(transformerBudgetKey).(*transformerBudget)
+	if n > budget.left/size {
+		budget.left = 0
+		return errTransformerMemory
+	}
+	budget.left -= n * size
+	return nil
+}
+
+func allocsLeft(thread *starlark.Thread) int {
+	return thread.Local(transformerBudgetKey).(*transformerBudget).left
+}
+
+// guardedOps are the binary operators whose results can be larger than their operands. The other
+// operators return values at most as large as their operands.
+var guardedOps = map[syntax.Token]bool{
+	syntax.PLUS:    true,
+	syntax.MINUS:   true,
+	syntax.STAR:    true,
+	syntax.PERCENT: true,
+	syntax.PIPE:    true,
+	syntax.LTLT:    true,
+}
+
+var guardedAugmentedOps = map[syntax.Token]syntax.Token{
+	syntax.PLUS_EQ:    syntax.PLUS,
+	syntax.MINUS_EQ:   syntax.MINUS,
+	syntax.STAR_EQ:    syntax.STAR,
+	syntax.PERCENT_EQ: syntax.PERCENT,
+	syntax.PIPE_EQ:    syntax.PIPE,
+	syntax.LTLT_EQ:    syntax.LTLT,
+}
+
+var guardedOpsByName = func() map[string]syntax.Token {
+	ops := make(map[string]syntax.Token, len(guardedOps))
+	for op := range guardedOps {
+		ops[op.String()] = op
+	}
+	return ops
+}()
+
+// scriptGuard rewrites a script to call the guards.
+type scriptGuard struct {
+	err error
+}
+
+// guardScript rewrites the syntax tree of the script in place.
+func guardScript(f *syntax.File) error {
+	g := &scriptGuard{}
+	g.stmts(f.Stmts)
+	return g.err
+}
+
+func (g *scriptGuard) stmts(stmts []syntax.Stmt) {
+	for _, stmt := range stmts {
+		g.stmt(stmt)
+	}
+}
+
+func (g *scriptGuard) stmt(stmt syntax.Stmt) {
+	switch stmt := stmt.(type) {
+	case *syntax.AssignStmt:
+		g.target(stmt.LHS)
+		stmt.RHS = g.expr(stmt.RHS)
+		// x op= y becomes x op= $operand(op, x, y), which charges the result of the operation and
+		// returns y. The operands of an index target, if any, are evaluated twice.
+		if op, ok := guardedAugmentedOps[stmt.Op]; ok {
+			var lhs syntax.Expr
+			switch target := stmt.LHS.(type) {
+			case *syntax.Ident:
+				lhs = &syntax.Ident{NamePos: target.NamePos, Name: target.Name}
+			case *syntax.IndexExpr:
+				lhs = &syntax.IndexExpr{X: target.X, Lbrack: target.Lbrack, Y: target.Y, Rbrack: target.Rbrack}
+			}
+			if lhs != nil {
+				stmt.RHS = guardCall(guardOperandName, stmt.OpPos, guardString(op.String(), stmt.OpPos), lhs, stmt.RHS)
+			}
+		}
+	case *syntax.DefStmt:
+		g.params(stmt.Params)
+		g.stmts(stmt.Body)
+	case *syntax.ExprStmt:
+		stmt.X = g.expr(stmt.X)
+	case *syntax.ForStmt:
+		g.target(stmt.Vars)
+		stmt.X = g.expr(stmt.X)
+		g.stmts(stmt.Body)
+	case *syntax.WhileStmt:
+		stmt.Cond = g.expr(stmt.Cond)
+		g.stmts(stmt.Body)
+	case *syntax.IfStmt:
+		stmt.Cond = g.expr(stmt.Cond)
+		g.stmts(stmt.True)
+		g.stmts(stmt.False)
+	case *syntax.ReturnStmt:
+		if stmt.Result != nil {
+			stmt.Result = g.expr(stmt.Result)
+		}
+	}
+}
+
+// target rewrites the expressions evaluated by an assignment target, but not the target itself.
+func (g *scriptGuard) target(e syntax.Expr) {
+	switch e := e.(type) {
+	case *syntax.IndexExpr:
+		e.X = g.expr(e.X)
+		e.Y = g.expr(e.Y)
+	case *syntax.DotExpr:
+		e.X = g.expr(e.X)
+	case *syntax.ParenExpr:
+		g.target(e.X)
+	case *syntax.ListExpr:
+		for _, x := range e.List {
+			g.target(x)
+		}
+	case *syntax.TupleExpr:
+		for _, x := range e.List {
+			g.target(x)
+		}
+	}
+}
+
+func (g *scriptGuard) params(params []syntax.Expr) {
+	for _, param := range params {
+		if param, ok := param.(*syntax.BinaryExpr); ok && param.Op == syntax.EQ {
+			param.Y = g.expr(param.Y)
+		}
+	}
+}
+
+func (g *scriptGuard) exprs(exprs []syntax.Expr) {
+	for i, e := range exprs {
+		exprs[i] = g.expr(e)
+	}
+}
+
+func (g *scriptGuard) expr(e syntax.Expr) syntax.Expr {
+	switch e := e.(type) {
+	case *syntax.BinaryExpr:
+		e.X = g.expr(e.X)
+		e.Y = g.expr(e.Y)
+		if guardedOps[e.Op] {
+			return guardCall(guardBinaryName, e.OpPos, guardString(e.Op.String(), e.OpPos), e.X, e.Y)
+		}
+	case *syntax.UnaryExpr:
+		if e.X != nil {
+			e.X = g.expr(e.X)
+		}
+	case *syntax.CallExpr:
+		e.Fn = g.expr(e.Fn)
+		for i, arg := range e.Args {
+			switch arg := arg.(type) {
+			case *syntax.BinaryExpr:
+				if arg.Op == syntax.EQ {
+					arg.Y = g.expr(arg.Y)
+					continue
+				}
+			case *syntax.UnaryExpr:
+				if arg.Op == syntax.STAR {
+					arg.X = guardCall(guardSpreadName, arg.OpPos, g.expr(arg.X))
+					continue
+				}
+				if arg.Op == syntax.STARSTAR {
+					arg.X = g.expr(arg.X)
+					continue
+				}
+			}
+			e.Args[i] = g.expr(arg)
+		}
+	case *syntax.DotExpr:
+		return guardCall(guardAttrName, e.Dot, g.expr(e.X), guardString(e.Name.Name, e.NamePos))
+	case *syntax.SliceExpr:
+		// Only the slices of strings with a step copy the string.
+		stepped := int64(0)
+		if e.Step != nil {
+			stepped = 1
+			e.Step = g.expr(e.Step)
+		}
+		if e.Lo != nil {
+			e.Lo = g.expr(e.Lo)
+		}
+		if e.Hi != nil {
+			e.Hi = g.expr(e.Hi)
+		}
+		e.X = guardCall(guardSliceName, e.Lbrack, g.expr(e.X), &syntax.Literal{Token: syntax.INT, TokenPos: e.Lbrack, Raw: strconv.FormatInt(stepped, 10), Value: stepped})
+	case *syntax.IndexExpr:
+		e.X = g.expr(e.X)
+		e.Y = g.expr(e.Y)
+	case *syntax.ParenExpr:
+		e.X = g.expr(e.X)
+	case *syntax.CondExpr:
+		e.Cond = g.expr(e.Cond)
+		e.True = g.expr(e.True)
+		e.False = g.expr(e.False)
+	case *syntax.ListExpr:
+		g.exprs(e.List)
+	case *syntax.TupleExpr:
+		g.exprs(e.List)
+	case *syntax.DictExpr:
+		for _, entry := range e.List {
+			entry := entry.(*syntax.DictEntry)
+			entry.Key = g.expr(entry.Key)
+			entry.Value = g.expr(entry.Value)
+		}
+	case *syntax.Comprehension:
+		for _, clause := range e.Clauses {
+			switch clause := clause.(type) {
+			case *syntax.ForClause:
+				g.target(clause.Vars)
+				clause.X = g.expr(clause.X)
+			case *syntax.IfClause:
+				clause.Cond = g.expr(clause.Cond)
+			}
+		}
+		e.Body = g.expr(e.Body)
+	case *syntax.LambdaExpr:
+		g.params(e.Params)
+		e.Body = g.expr(e.Body)
+	case *syntax.Literal:
+		if i, ok := e.Value.(*big.Int); ok && i.BitLen() > transformerMaxIntBits && g.err == nil {
+			g.err = fmt.Errorf("%s: ints must not be larger than %d bits", e.TokenPos, transformerMaxIntBits)
+		}
+	}
+	return e
+}
+
+func guardCall(name string, pos syntax.Position, args ...syntax.Expr) *syntax.CallExpr {
+	_, end := args[len(args)-1].Span()
+	return &syntax.CallExpr{Fn: &syntax.Ident{NamePos: pos, Name: name}, Lparen: pos, Args: args, Rparen: end}
+}
+
+func guardString(s string, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.STRING, TokenPos: pos, Raw: strconv.Quote(s), Value: s}
+}
+
+// builtinGuard calls fn once it charged the size of its result.
+type builtinGuard func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)
+
+func guardBuiltin(fn *starlark.Builtin, guard builtinGuard) *starlark.Builtin {
+	b := starlark.NewBuiltin(fn.Name(), func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return guard(thread, fn, args, kwargs)
+	})
+	if recv := fn.Receiver(); recv != nil {
+		return b.BindReceiver(recv)
+	}
+	return b
+}
+
+// transformerBuiltinGuards guard the builtins that create strings or lists of any size, or ints.
+var transformerBuiltinGuards = map[string]builtinGuard{
+	"bytes":     chargeIterables,
+	"dict":      chargeIterables,
+	"dir":       chargeResult,
+	"enumerate": chargeIterables,
+	"getattr":   guardGetattr,
+	"int":       checkIntResult,
+	"list":      chargeIterables,
+	"repr":      chargeStrings,
+	"reversed":  chargeIterables,
+	"sorted":    chargeIterables,
+	"str":       chargeStrings,
+	"tuple":     chargeIterables,
+	"zip":       chargeIterables,
+}
+
+// transformerMethodGuards guard the methods that create strings or lists of any size, by type
+// and name.
+var transformerMethodGuards = map[string]builtinGuard{
+	"dict.items":        chargeReceiverElems(3),
+	"dict.keys":         chargeReceiverElems(1),
+	"dict.update":       chargeIterables,
+	"dict.values":       chargeReceiverElems(1),
+	"list.extend":       chargeIterables,
+	"string.capitalize": chargeReceiver,
+	"string.format":     chargeStringFormat,
+	"string.join":       chargeStringJoin,
+	"string.lower":      chargeReceiver,
+	"string.replace":    chargeStringReplace,
+	"string.rsplit":     chargeStringSplit,
+	"string.split":      chargeStringSplit,
+	"string.splitlines": chargeStringSplit,
+	"string.title":      chargeReceiver,
+	"string.upper":      chargeReceiver,
+}
+
+// transformerBuiltins returns the predeclared values of the scripts: the guards, the json module
+// and the guarded builtins, which shadow those of the universe.
+func transformerBuiltins() starlark.StringDict {
+	builtins := starlark.StringDict{
+		guardBinaryName:  starlark.NewBuiltin(guardBinaryName, guardBinary),
+		guardOperandName: starlark.NewBuiltin(guardOperandName, guardOperand),
+		guardAttrName:    starlark.NewBuiltin(guardAttrName, guardAttr),
+		guardSliceName:   starlark.NewBuiltin(guardSliceName, guardSlice),
+		guardSpreadName:  starlark.NewBuiltin(guardSpreadName, guardSpread),
+		"json": &starlarkstruct.Module{
+			Name: "json",
+			Members: starlark.StringDict{
+				"decode": guardBuiltin(json.Module.Members["decode"].(*starlark.Builtin), guardJSONDecode),
+				"encode": guardBuiltin(json.Module.Members["encode"].(*starlark.Builtin), chargeStrings),
+				"indent": guardBuiltin(json.Module.Members["indent"].(*starlark.Builtin), chargeJSONIndent),
+			},
+		},
+	}
+	for name, guard := range transformerBuiltinGuards {
+		builtins[name] = guardBuiltin(starlark.Universe[name].(*starlark.Builtin), guard)
+	}
+	return builtins
+}
+
+func guardBinary(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	op := guardedOpsByName[string(args[0].(starlark.String))]
+	return binaryWithinBudget(thread, op, args[1], args[2])
+}
+
+// guardOperand charges the result of an augmented assignment and returns its operand, as the
+// assignment itself is done by the interpreter.
+func guardOperand(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	op := guardedOpsByName[string(args[0].(starlark.String))]
+	x, y := args[1], args[2]
+	if _, ok := x.(starlark.Int); ok {
+		if _, err := binaryWithinBudget(thread, op, x, y); err != nil {
+			return nil, err
+		}
+		return y, nil
+	}
+	return y, chargeBinary(thread, op, x, y)
+}
+
+func binaryWithinBudget(thread *starlark.Thread, op syntax.Token, x, y starlark.Value) (starlark.Value, error) {
+	if err := chargeBinary(thread, op, x, y); err != nil {
+		return nil, err
+	}
+	z, err := starlark.Binary(op, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return z, checkInt(z)
+}
+
+func chargeBinary(thread *starlark.Thread, op syntax.Token, x, y starlark.Value) error {
+	switch op {
+	case syntax.PLUS:
+		switch x.(type) {
+		case starlark.String, starlark.Bytes:
+			return chargeAllocs(thread, starlark.Len(x)+starlark.Len(y), 1)
+		case *starlark.List, starlark.Tuple:
+			return chargeAllocs(thread, starlark.Len(x)+starlark.Len(y), transformerElemSize)
+		}
+	case syntax.STAR:
+		if n, ok := y.(starlark.Int); ok {
+			return chargeRepeat(thread, x, n)
+		}
+		if n, ok := x.(starlark.Int); ok {
+			return chargeRepeat(thread, y, n)
+		}
+	case syntax.PERCENT:
+		if format, ok := x.(starlark.String); ok {
+			var args []starlark.Value
+			switch y := y.(type) {
+			case starlark.Tuple:
+				args = y
+			case *starlark.Dict:
+				for _, item := range y.Items() {
+					args = append(args, item[1])
+				}
+			default:
+				args = []starlark.Value{y}
+			}
+			return chargeFormat(thread, string(format), "%", args)
+		}
+	case syntax.PIPE:
+		if _, ok := x.(*starlark.Dict); ok {
+			return chargeAllocs(thread, starlark.Len(x)+starlark.Len(y), 2*transformerElemSize)
+		}
+	}
+	return nil
+}
+
+func chargeRepeat(thread *starlark.Thread, x starlark.Value, n starlark.Int) error {
+	size := 0
+	switch x.(type) {
+	case starlark.String, starlark.Bytes:
+		size = starlark.Len(x)
+	case *starlark.List, starlark.Tuple:
+		size = starlark.Len(x) * transformerElemSize
+	default:
+		return nil
+	}
+	count, ok := n.Int64()
+	if !ok {
+		if n.BigInt().Sign() > 0 && size > 0 {
+			return errTransformerMemory
+		}
+		return nil
+	}
+	if count > int64(transformerMaxAllocs) {
+		count = transformerMaxAllocs + 1
+	}
+	return chargeAllocs(thread, int(count), size)
+}
+
+// chargeFormat charges the result of a format string, in which each of the markers can be
+// replaced by the largest of the arguments.
+func chargeFormat(thread *starlark.Thread, format, marker string, args []starlark.Value) error {
+	largest := 0
+	for _, arg := range args {
+		size := valueSize(arg, allocsLeft(thread), 0)
+		if size > allocsLeft(thread) {
+			return errTransformerMemory
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	if err := chargeAllocs(thread, strings.Count(format, marker), largest); err != nil {
+		return err
+	}
+	return chargeAllocs(thread, len(format), 1)
+}
+
+// guardAttr returns the attribute of a value. The methods that create strings or lists are
+// guarded.
+func guardAttr(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	return guardedAttr(args[0], string(args[1].(starlark.String)))
+}
+
+func guardedAttr(x starlark.Value, name string) (starlark.Value, error) {
+	var v starlark.Value
+	if attrs, ok := x.(starlark.HasAttrs); ok {
+		var err error
+		if v, err = attrs.Attr(name); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	if guard, ok := transformerMethodGuards[x.Type()+"."+name]; ok {
+		if method, ok := v.(*starlark.Builtin); ok {
+			return guardBuiltin(method, guard), nil
+		}
+	}
+	return v, nil
+}
+
+func guardGetattr(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var object, dflt starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &object, &name, &dflt); err != nil {
+		return nil, err
+	}
+	v, err := guardedAttr(object, name)
+	if err != nil {
+		if dflt != nil {
+			return dflt, nil
+		}
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return v, nil
+}
+
+func guardSlice(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	x, stepped := args[0], bool(args[1].Truth())
+	switch x.(type) {
+	case starlark.String, starlark.Bytes:
+		if stepped {
+			return x, chargeAllocs(thread, starlark.Len(x), 1)
+		}
+	case *starlark.List, starlark.Tuple:
+		return x, chargeAllocs(thread, starlark.Len(x), transformerElemSize)
+	}
+	return x, nil
+}
+
+// guardSpread charges the arguments of f(*args), which may be any iterable such as a range.
+func guardSpread(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	return args[0], chargeAllocs(thread, starlark.Len(args[0]), transformerElemSize)
+}
+
+// chargeIterables charges an element for each of the elements of the arguments.
+func chargeIterables(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	for _, arg := range args {
+		if err := chargeAllocs(thread, starlark.Len(arg), transformerElemSize); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+// chargeStrings charges the size of the arguments converted to strings.
+func chargeStrings(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	for _, arg := range args {
+		if err := chargeAllocs(thread, valueSize(arg, allocsLeft(thread), 0), 1); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+func chargeResult(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	v, err := fn.CallInternal(thread, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := chargeAllocs(thread, starlark.Len(v), transformerElemSize); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return v, nil
+}
+
+func checkIntResult(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	v, err := fn.CallInternal(thread, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInt(v); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return v, nil
+}
+
+func chargeReceiver(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := chargeAllocs(thread, starlark.Len(fn.Receiver()), 1); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+func chargeReceiverElems(elems int) builtinGuard {
+	return func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := chargeAllocs(thread, starlark.Len(fn.Receiver()), elems*transformerElemSize); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		return fn.CallInternal(thread, args, kwargs)
+	}
+}
+
+func chargeStringFormat(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	values := append([]starlark.Value(nil), args...)
+	for _, kwarg := range kwargs {
+		values = append(values, kwarg[1])
+	}
+	if err := chargeFormat(thread, string(fn.Receiver().(starlark.String)), "{", values); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+func chargeStringJoin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) == 1 {
+		sep := len(fn.Receiver().(starlark.String))
+		size := 0
+		iter := starlark.Iterate(args[0])
+		if iter != nil {
+			var x starlark.Value
+			for size <= allocsLeft(thread) && iter.Next(&x) {
+				s, ok := x.(starlark.String)
+				if !ok {
+					break
+				}
+				size += len(s) + sep
+			}
+			iter.Done()
+		}
+		if err := chargeAllocs(thread, size, 1); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+func chargeStringReplace(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var old, replacement string
+	count := -1
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &old, &replacement, &count); err != nil {
+		return nil, err
+	}
+	s := string(fn.Receiver().(starlark.String))
+	n := len(s) + 1
+	if old != "" {
+		n = strings.Count(s, old)
+	}
+	if count >= 0 && count < n {
+		n = count
+	}
+	if err := chargeAllocs(thread, n, len(replacement)); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	if err := chargeAllocs(thread, len(s), 1); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+// chargeStringSplit charges an element for each of the fields of the string, at most one for
+// every separator or every other byte.
+func chargeStringSplit(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s := string(fn.Receiver().(starlark.String))
+	fields := len(s)/2 + 1
+	if len(args) > 0 {
+		if sep, ok := args[0].(starlark.String); ok && sep != "" {
+			fields = strings.Count(s, string(sep)) + 1
+		}
+	}
+	if err := chargeAllocs(thread, fields, transformerElemSize); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+// guardJSONDecode charges an element for each byte of the document, and checks the ints it
+// decodes.
+func guardJSONDecode(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	for _, arg := range args {
+		if err := chargeAllocs(thread, starlark.Len(arg), transformerElemSize); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+	v, err := fn.CallInternal(thread, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInts(v, 0); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return v, nil
+}
+
+// chargeJSONIndent charges the document with a new line, the prefix and the indentation of the
+// deepest value for each of its tokens.
+func chargeJSONIndent(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s, prefix string
+	indent := "\t"
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "str", &s, "prefix?", &prefix, "indent?", &indent); err != nil {
+		return nil, err
+	}
+	tokens, depth, maxDepth := 0, 0, 0
+	for _, c := range s {
+		switch c {
+		case '[', '{':
+			tokens++
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		case ']', '}':
+			tokens++
+			depth--
+		case ',':
+			tokens++
+		}
+	}
+	if maxDepth > allocsLeft(thread) {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), errTransformerMemory)
+	}
+	if err := chargeAllocs(thread, tokens, 1+len(prefix)+maxDepth*len(indent)); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	if err := chargeAllocs(thread, len(s), 1); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return fn.CallInternal(thread, args, kwargs)
+}
+
+// valueSize estimates the size of the value converted to a string. It stops as soon as the size
+// is larger than limit.
+func valueSize(v starlark.Value, limit, depth int) int {
+	if depth > transformerMaxDepth {
+		return limit + 1
+	}
+	switch v := v.(type) {
+	case starlark.String:
+		return len(v) + 2
+	case starlark.Bytes:
+		return len(v) + 3
+	case starlark.Int:
+		return intBits(v)/3 + 2
+	case *starlark.List:
+		return elemsSize(v, limit, depth)
+	case starlark.Tuple:
+		return elemsSize(v, limit, depth)
+	case *starlark.Dict:
+		size := 2
+		for _, item := range v.Items() {
+			if size > limit {
+				break
+			}
+			size += valueSize(item[0], limit-size, depth+1) + 2
+			size += valueSize(item[1], limit-size, depth+1) + 2
+		}
+		return size
+	default:
+		return len(v.String())
+	}
+}
+
+func elemsSize(v starlark.Indexable, limit, depth int) int {
+	size := 2
+	for i := 0; i < v.Len() && size <= limit; i++ {
+		size += valueSize(v.Index(i), limit-size, depth+1) + 2
+	}
+	return size
+}
+
+func intBits(i starlark.Int) int {
+	if _, ok := i.Int64(); ok {
+		return 64
+	}
+	return i.BigInt().BitLen()
+}
+
+func checkInt(v starlark.Value) error {
+	if i, ok := v.(starlark.Int); ok && intBits(i) > transformerMaxIntBits {
+		return fmt.Errorf("ints must not be larger than %d bits", transformerMaxIntBits)
+	}
+	return nil
+}
+
+// checkInts checks the ints of a decoded JSON document.
+func checkInts(v starlark.Value, depth int) error {
+	if depth > transformerMaxDepth {
+		return errors.New("value is nested too deeply")
+	}
+	switch v := v.(type) {
+	case *starlark.List:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkInts(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case *starlark.Dict:
+		for _, item := range v.Items() {
+			if err := checkInts(item[1], depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return checkInt(v)
+	}
+	return nil
+}
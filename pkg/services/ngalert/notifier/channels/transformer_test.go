@@ -0,0 +1,230 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestPayloadTransformer(t *testing.T) {
+	cases := []struct {
+		name         string
+		script       string
+		payload      string
+		expPayload   string
+		expInitError string
+		expError     string
+	}{
+		{
+			name: "reshapes a JSON payload",
+			script: `
+def transform(payload):
+    return {"text": payload["title"], "count": len(payload["alerts"])}
+`,
+			payload:    `{"title": "[FIRING:1] alert1", "alerts": [{}]}`,
+			expPayload: `{"count":1,"text":"[FIRING:1] alert1"}`,
+		}, {
+			name: "returns a string as is",
+			script: `
+def transform(payload):
+    return "alert: " + payload.upper()
+`,
+			payload:    "not json",
+			expPayload: "alert: NOT JSON",
+		}, {
+			name: "can use the json module",
+			script: `
+def transform(payload):
+    return json.encode([a["labels"]["alertname"] for a in payload["alerts"]])
+`,
+			payload:    `{"alerts": [{"labels": {"alertname": "a1"}}, {"labels": {"alertname": "a2"}}]}`,
+			expPayload: `["a1","a2"]`,
+		}, {
+			name:         "error when transform is not defined",
+			script:       `x = 1`,
+			expInitError: "script must define a function transform(payload)",
+		}, {
+			name:         "error on syntax errors",
+			script:       `def transform(payload)`,
+			expInitError: "transformer.star:1:23: got end of file, want ':'",
+		}, {
+			name:         "error on load",
+			script:       `load("module.star", "f")`,
+			expInitError: "cannot load module.star: load is not supported",
+		}, {
+			name: "error on unsupported return value",
+			script: `
+def transform(payload):
+    return 1
+`,
+			payload:  `{}`,
+			expError: "transform must return a dict, a list or a string, got int",
+		}, {
+			name: "scripts running for too long are cancelled",
+			script: `
+def transform(payload):
+    for i in range(1000000000):
+        pass
+`,
+			payload:  `{}`,
+			expError: "Starlark computation cancelled: too many steps",
+		}, {
+			name: "methods, slices, formatting and augmented assignments within the memory budget",
+			script: `
+def transform(payload):
+    names = []
+    for a in payload["alerts"]:
+        names += [a["labels"]["alertname"].upper()[::-1]]
+    upper = getattr("firing", "upper")
+    return "%s %d: %s" % (upper(), len(names), ", ".join(names))
+`,
+			payload:    `{"alerts": [{"labels": {"alertname": "a1"}}, {"labels": {"alertname": "a2"}}]}`,
+			expPayload: "FIRING 2: 1A, 2A",
+		}, {
+			name: "scripts repeating a string beyond the memory budget fail",
+			script: `
+def transform(payload):
+    return "x" * 1000000000
+`,
+			payload:  `{}`,
+			expError: "script must not allocate more than 67108864 bytes",
+		}, {
+			name: "scripts doubling a string beyond the memory budget fail",
+			script: `
+def transform(payload):
+    s = "x"
+    for i in range(100):
+        s += s
+    return s
+`,
+			payload:  `{}`,
+			expError: "script must not allocate more than 67108864 bytes",
+		}, {
+			name: "scripts keeping copies of a string beyond the memory budget fail",
+			script: `
+def transform(payload):
+    s = "x" * 1000000
+    upper = s.upper
+    return [upper() for i in range(100)]
+`,
+			payload:  `{}`,
+			expError: "upper: script must not allocate more than 67108864 bytes",
+		}, {
+			name: "scripts creating large lists fail",
+			script: `
+def transform(payload):
+    return list(range(1000000000))
+`,
+			payload:  `{}`,
+			expError: "list: script must not allocate more than 67108864 bytes",
+		}, {
+			name: "scripts returning payloads larger than the memory budget fail",
+			script: `
+def transform(payload):
+    return ["x" * 1000000] * 1000
+`,
+			payload:  `{}`,
+			expError: "json.encode: script must not allocate more than 67108864 bytes",
+		}, {
+			name: "scripts computing large ints fail",
+			script: `
+def transform(payload):
+    x = 2
+    for i in range(10):
+        x = x * x
+    return str(x)
+`,
+			payload:  `{}`,
+			expError: "ints must not be larger than 128 bits",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transformer, err := NewPayloadTransformer(c.script)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			payload, err := transformer.Transform(context.Background(), c.payload)
+			if c.expError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expPayload, payload)
+		})
+	}
+}
+
+func TestNewFactoryConfig_PayloadTransformer(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	ns := mockNotificationService()
+
+	t.Run("webhooks are transformed before they are sent", func(t *testing.T) {
+		fc, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name: "webhook_testing",
+			Type: "webhook",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"url":                     "http://localhost/test",
+				payloadTransformerSetting: "def transform(payload):\n    return {\"status\": payload[\"status\"]}\n",
+			}),
+		}, ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.NoError(t, err)
+
+		n, err := WebHookFactory(fc)
+		require.NoError(t, err)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		ok, err := n.Notify(ctx, &types.Alert{
+			Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.JSONEq(t, `{"status": "firing"}`, ns.Webhook.Body)
+	})
+
+	t.Run("the webhook of the caller is not modified", func(t *testing.T) {
+		transformer, err := NewPayloadTransformer("def transform(payload):\n    return \"transformed\"\n")
+		require.NoError(t, err)
+		s := &transformingNotificationService{Service: ns, transformer: transformer}
+
+		cmd := &models.SendWebhookSync{Url: "http://localhost/test", Body: `{"status": "firing"}`}
+		require.NoError(t, s.SendWebhookSync(context.Background(), cmd))
+		require.Equal(t, "transformed", ns.Webhook.Body)
+		require.Equal(t, `{"status": "firing"}`, cmd.Body)
+	})
+
+	t.Run("error on invalid script", func(t *testing.T) {
+		_, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name: "webhook_testing",
+			Type: "webhook",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"url":                     "http://localhost/test",
+				payloadTransformerSetting: "x = 1",
+			}),
+		}, ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.EqualError(t, err, "invalid payload transformer: script must define a function transform(payload)")
+	})
+}
@@ -0,0 +1,333 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	trelloAPIURL = "https://api.trello.com/1"
+
+	trelloMaxNameLength = 16384
+	trelloMaxDescLength = 16384
+)
+
+type TrelloConfig struct {
+	*NotificationChannelConfig
+	APIKey           string
+	Token            string
+	ListID           string
+	Title            string
+	Description      string
+	Labels           string
+	ResolveComment   string
+	ArchiveOnResolve bool
+}
+
+func TrelloFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewTrelloConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewTrelloNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewTrelloConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*TrelloConfig, error) {
+	apiKey := decryptFunc(context.Background(), config.SecureSettings, "apiKey", config.Settings.Get("apiKey").MustString())
+	if apiKey == "" {
+		return nil, errors.New("could not find API key in settings")
+	}
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find token in settings")
+	}
+	listID := strings.TrimSpace(config.Settings.Get("listId").MustString())
+	if listID == "" {
+		return nil, errors.New("could not find list ID in settings")
+	}
+	return &TrelloConfig{
+		NotificationChannelConfig: config,
+		APIKey:                    apiKey,
+		Token:                     token,
+		ListID:                    listID,
+		Title:                     config.Settings.Get("title").MustString(defaultGitHubTitle),
+		Description:               config.Settings.Get("description").MustString(`{{ template "default.message" . }}`),
+		Labels:                    config.Settings.Get("labels").MustString(),
+		ResolveComment:            config.Settings.Get("resolveComment").MustString(defaultGitHubResolve),
+		ArchiveOnResolve:          config.Settings.Get("archiveOnResolve").MustBool(true),
+	}, nil
+}
+
+// NewTrelloNotifier is the constructor for the Trello notifier.
+func NewTrelloNotifier(config *TrelloConfig, ns notifications.WebhookSender, t *template.Template) *TrelloNotifier {
+	return &TrelloNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:              trelloAPIURL,
+		APIKey:           config.APIKey,
+		Token:            config.Token,
+		ListID:           config.ListID,
+		Title:            config.Title,
+		Description:      config.Description,
+		Labels:           config.Labels,
+		ResolveComment:   config.ResolveComment,
+		ArchiveOnResolve: config.ArchiveOnResolve,
+		log:              log.New("alerting.notifier.trello"),
+		ns:               ns,
+		tmpl:             t,
+	}
+}
+
+// TrelloNotifier is responsible for creating a Trello card per firing alert in a list, and for
+// commenting on and optionally archiving the card when the alert is resolved.
+type TrelloNotifier struct {
+	*Base
+	URL              string
+	APIKey           string
+	Token            string
+	ListID           string
+	Title            string
+	Description      string
+	Labels           string
+	ResolveComment   string
+	ArchiveOnResolve bool
+	log              log.Logger
+	ns               notifications.WebhookSender
+	tmpl             *template.Template
+}
+
+type trelloCard struct {
+	ID   string `json:"id"`
+	Desc string `json:"desc"`
+}
+
+type trelloLabel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// trelloBoard is the board of the list, with its labels fetched when a card is created.
+type trelloBoard struct {
+	ID     string
+	labels map[string]string
+}
+
+// Notify creates a card for each firing alert that does not have an open card on the board yet,
+// and resolves the open card of each resolved alert. Cards are found by the marker with the
+// fingerprint of the alert in their description, so they are still found when they are moved
+// to another list of the board.
+func (tn *TrelloNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	tn.log.Debug("sending Trello notification", "notification", tn.Name)
+
+	board, cards, err := tn.openCards(ctx)
+	if err != nil {
+		tn.log.Error("failed to fetch the Trello cards", "err", err, "notification", tn.Name)
+		return false, err
+	}
+
+	for _, a := range as {
+		marker := gitHubAlertMarkerPrefix + a.Fingerprint().String()
+		card := findTrelloCard(cards, marker)
+		switch {
+		case !a.Resolved() && card == nil:
+			err = tn.createCard(ctx, board, a, marker)
+		case a.Resolved() && card != nil:
+			err = tn.resolveCard(ctx, a, card.ID)
+		}
+		if err != nil {
+			tn.log.Error("failed to send Trello notification", "err", err, "notification", tn.Name)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// openCards returns the board of the list and its open cards.
+func (tn *TrelloNotifier) openCards(ctx context.Context) (*trelloBoard, []trelloCard, error) {
+	var list struct {
+		IDBoard string `json:"idBoard"`
+	}
+	if err := tn.request(ctx, "GET", "/lists/"+url.PathEscape(tn.ListID)+"?fields=idBoard", nil, &list); err != nil {
+		return nil, nil, err
+	}
+	board := &trelloBoard{ID: list.IDBoard}
+	var cards []trelloCard
+	if err := tn.request(ctx, "GET", "/boards/"+url.PathEscape(board.ID)+"/cards/open?fields=id,desc", nil, &cards); err != nil {
+		return nil, nil, err
+	}
+	return board, cards, nil
+}
+
+func findTrelloCard(cards []trelloCard, marker string) *trelloCard {
+	for i := range cards {
+		if strings.Contains(cards[i].Desc, marker) {
+			return &cards[i]
+		}
+	}
+	return nil
+}
+
+func (tn *TrelloNotifier) createCard(ctx context.Context, board *trelloBoard, a *types.Alert, marker string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, tn.tmpl, []*types.Alert{a}, tn.log, &tmplErr)
+
+	name, _ := tn.Truncate(strings.Join(strings.Fields(tmpl(tn.Title)), " "), trelloMaxNameLength)
+	footer := "\n\n---\n" + marker
+	desc, _ := tn.Truncate(tmpl(tn.Description), trelloMaxDescLength-utf8.RuneCountInString(footer))
+	if tmplErr != nil {
+		tn.log.Warn("failed to template Trello card", "err", tmplErr.Error())
+	}
+
+	labelIDs, err := tn.labelIDs(ctx, board, a)
+	if err != nil {
+		return err
+	}
+
+	card := map[string]interface{}{
+		"idList": tn.ListID,
+		"name":   name,
+		"desc":   desc + footer,
+		"pos":    "top",
+	}
+	if len(labelIDs) > 0 {
+		card["idLabels"] = strings.Join(labelIDs, ",")
+	}
+	var created trelloCard
+	if err := tn.request(ctx, "POST", "/cards", card, &created); err != nil {
+		return err
+	}
+	tn.log.Debug("created Trello card", "card", created.ID, "alert", a.Fingerprint().String())
+	return nil
+}
+
+// labelIDs returns the IDs of the labels of the board named after the values of the alert labels
+// in the settings. The missing labels are created without color.
+func (tn *TrelloNotifier) labelIDs(ctx context.Context, board *trelloBoard, a *types.Alert) ([]string, error) {
+	var names []string
+	for _, key := range splitCommaList(tn.Labels) {
+		if v := strings.TrimSpace(string(a.Labels[model.LabelName(key)])); v != "" {
+			names = append(names, v)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	if board.labels == nil {
+		var labels []trelloLabel
+		if err := tn.request(ctx, "GET", "/boards/"+url.PathEscape(board.ID)+"/labels?fields=id,name&limit=1000", nil, &labels); err != nil {
+			return nil, err
+		}
+		board.labels = make(map[string]string, len(labels))
+		for _, l := range labels {
+			board.labels[l.Name] = l.ID
+		}
+	}
+
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := board.labels[name]
+		if !ok {
+			var created trelloLabel
+			if err := tn.request(ctx, "POST", "/boards/"+url.PathEscape(board.ID)+"/labels", map[string]interface{}{"name": name, "color": nil}, &created); err != nil {
+				return nil, err
+			}
+			id = created.ID
+			board.labels[name] = id
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (tn *TrelloNotifier) resolveCard(ctx context.Context, a *types.Alert, id string) error {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, tn.tmpl, []*types.Alert{a}, tn.log, &tmplErr)
+	comment, _ := tn.Truncate(tmpl(tn.ResolveComment), trelloMaxDescLength)
+	if tmplErr != nil {
+		tn.log.Warn("failed to template Trello comment", "err", tmplErr.Error())
+	}
+
+	path := "/cards/" + url.PathEscape(id)
+	if comment != "" {
+		if err := tn.request(ctx, "POST", path+"/actions/comments", map[string]string{"text": comment}, nil); err != nil {
+			return err
+		}
+	}
+	if !tn.ArchiveOnResolve {
+		return nil
+	}
+	return tn.request(ctx, "PUT", path, map[string]bool{"closed": true}, nil)
+}
+
+// request sends a request to the Trello REST API and decodes the response into out, if not nil.
+// The credentials are sent in a header rather than in the query, so that they are not logged
+// with the URL.
+func (tn *TrelloNotifier) request(ctx context.Context, method, path string, in, out interface{}) error {
+	cmd := &models.SendWebhookSync{
+		Url:        tn.URL + path,
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Authorization": fmt.Sprintf(`OAuth oauth_consumer_key="%s", oauth_token="%s"`, tn.APIKey, tn.Token),
+			"Content-Type":  "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return trelloError(body, statusCode)
+			}
+			if out == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, out)
+		},
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		cmd.Body = string(body)
+	}
+	return tn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// trelloError returns the error of a response of the Trello API. Its body is either a JSON
+// object with a message, or a short plain text message such as "invalid id".
+func trelloError(body []byte, statusCode int) error {
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+		return fmt.Errorf("the Trello API returned status %d: %s", statusCode, resp.Message)
+	}
+	if msg := strings.TrimSpace(string(body)); msg != "" && len(msg) <= 200 && !strings.ContainsAny(msg, "<{") {
+		return fmt.Errorf("the Trello API returned status %d: %s", statusCode, msg)
+	}
+	return fmt.Errorf("the Trello API returned status %d", statusCode)
+}
+
+func (tn *TrelloNotifier) SendResolved() bool {
+	return !tn.GetDisableResolveMessage()
+}
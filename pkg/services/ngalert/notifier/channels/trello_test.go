@@ -0,0 +1,261 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeTrelloCard struct {
+	ID       string
+	Fields   map[string]interface{}
+	Comments []string
+	Closed   bool
+}
+
+// fakeTrello implements the parts of the Trello REST API used by the notifier, for the list
+// "todo" of the board "ops".
+type fakeTrello struct {
+	cards    []*fakeTrelloCard
+	labels   []trelloLabel
+	requests []*models.SendWebhookSync
+}
+
+func (f *fakeTrello) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	f.requests = append(f.requests, cmd)
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return err
+	}
+
+	respond := func(statusCode int, v interface{}) error {
+		b, _ := json.Marshal(v)
+		return cmd.Validation(b, statusCode)
+	}
+
+	var in map[string]interface{}
+	if cmd.Body != "" {
+		if err := json.Unmarshal([]byte(cmd.Body), &in); err != nil {
+			return err
+		}
+	}
+
+	path := strings.TrimPrefix(u.Path, "/1")
+	switch {
+	case path == "/lists/todo":
+		return respond(200, map[string]string{"id": "todo", "idBoard": "ops"})
+	case strings.HasPrefix(path, "/lists/"):
+		return cmd.Validation([]byte("invalid id"), 400)
+	case path == "/boards/ops/cards/open":
+		cards := []trelloCard{}
+		for _, c := range f.cards {
+			if !c.Closed {
+				cards = append(cards, trelloCard{ID: c.ID, Desc: c.Fields["desc"].(string)})
+			}
+		}
+		return respond(200, cards)
+	case path == "/boards/ops/labels" && cmd.HttpMethod == "GET":
+		return respond(200, f.labels)
+	case path == "/boards/ops/labels":
+		label := trelloLabel{ID: "label" + strconv.Itoa(len(f.labels)+1), Name: in["name"].(string)}
+		f.labels = append(f.labels, label)
+		return respond(200, label)
+	case path == "/cards":
+		card := &fakeTrelloCard{ID: "card" + strconv.Itoa(len(f.cards)+1), Fields: in}
+		f.cards = append(f.cards, card)
+		return respond(200, map[string]string{"id": card.ID})
+	case strings.HasSuffix(path, "/actions/comments"):
+		card := f.card(strings.TrimSuffix(strings.TrimPrefix(path, "/cards/"), "/actions/comments"))
+		card.Comments = append(card.Comments, in["text"].(string))
+		return respond(200, map[string]string{})
+	case strings.HasPrefix(path, "/cards/") && cmd.HttpMethod == "PUT":
+		card := f.card(strings.TrimPrefix(path, "/cards/"))
+		card.Closed = in["closed"].(bool)
+		return respond(200, map[string]string{"id": card.ID})
+	}
+	return respond(404, map[string]string{"message": "not found"})
+}
+
+func (f *fakeTrello) card(id string) *fakeTrelloCard {
+	for _, c := range f.cards {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestTrelloNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical", "team": "sre"},
+			Annotations: model.LabelSet{"summary": "CPU is high"},
+		},
+	}
+	other := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert2", "severity": "warning", "team": "sre"},
+		},
+	}
+	resolved := &types.Alert{Alert: firing.Alert}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	t.Run("one card per alert, commented and archived when resolved", func(t *testing.T) {
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"apiKey": "key",
+			"token":  "token",
+			"listId": "todo",
+			"labels": "severity, team, missing",
+		})
+		cfg, err := NewTrelloConfig(&NotificationChannelConfig{Name: "trello_testing", Type: "trello", Settings: settings}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		trello := &fakeTrello{labels: []trelloLabel{{ID: "existing", Name: "sre"}}}
+		n := NewTrelloNotifier(cfg, trello, tmpl)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+		// Repeated notifications do not create duplicates.
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, firing, other)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Len(t, trello.cards, 2)
+
+		card := trello.cards[0]
+		require.Equal(t, "todo", card.Fields["idList"])
+		require.Equal(t, "alert1: CPU is high", card.Fields["name"])
+		require.Equal(t, "top", card.Fields["pos"])
+		require.True(t, strings.HasSuffix(card.Fields["desc"].(string), "\n\n---\ngrafana-alert-"+firing.Fingerprint().String()))
+		require.Contains(t, card.Fields["desc"], "CPU is high")
+
+		// The labels are created once, and existing labels are reused.
+		require.Equal(t, []trelloLabel{{ID: "existing", Name: "sre"}, {ID: "label2", Name: "critical"}, {ID: "label3", Name: "warning"}}, trello.labels)
+		require.Equal(t, "label2,existing", card.Fields["idLabels"])
+		require.Equal(t, "label3,existing", trello.cards[1].Fields["idLabels"])
+
+		req := trello.requests[0]
+		require.Equal(t, "https://api.trello.com/1/lists/todo?fields=idBoard", req.Url)
+		require.Equal(t, `OAuth oauth_consumer_key="key", oauth_token="token"`, req.HttpHeader["Authorization"])
+
+		ok, err := n.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"The alert is resolved."}, card.Comments)
+		require.True(t, card.Closed)
+		require.False(t, trello.cards[1].Closed)
+
+		// The alert fires again after its card was archived.
+		ok, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, trello.cards, 3)
+	})
+
+	t.Run("comment without archiving when resolved", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"apiKey":           "key",
+			"token":            "token",
+			"listId":           "todo",
+			"resolveComment":   "{{ .CommonLabels.alertname }} is resolved",
+			"archiveOnResolve": false,
+		})
+		cfg, err := NewTrelloConfig(&NotificationChannelConfig{Name: "trello_testing", Type: "trello", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		trello := &fakeTrello{}
+		n := NewTrelloNotifier(cfg, trello, tmpl)
+		_, err = n.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		_, err = n.Notify(context.Background(), resolved)
+		require.NoError(t, err)
+
+		card := trello.cards[0]
+		require.NotContains(t, card.Fields, "idLabels")
+		require.Equal(t, []string{"alert1 is resolved"}, card.Comments)
+		require.False(t, card.Closed)
+	})
+
+	t.Run("errors of the API", func(t *testing.T) {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"apiKey": "key",
+			"token":  "token",
+			"listId": "unknown",
+		})
+		cfg, err := NewTrelloConfig(&NotificationChannelConfig{Name: "trello_testing", Type: "trello", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+			return fallback
+		})
+		require.NoError(t, err)
+
+		ok, err := NewTrelloNotifier(cfg, &fakeTrello{}, tmpl).Notify(context.Background(), firing)
+		require.EqualError(t, err, "the Trello API returned status 400: invalid id")
+		require.False(t, ok)
+	})
+}
+
+func TestNewTrelloConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Minimal settings",
+			settings: map[string]interface{}{"apiKey": "key", "token": "token", "listId": "todo"},
+		}, {
+			name:         "Error when the API key is missing",
+			settings:     map[string]interface{}{"token": "token", "listId": "todo"},
+			expInitError: "could not find API key in settings",
+		}, {
+			name:         "Error when the token is missing",
+			settings:     map[string]interface{}{"apiKey": "key", "listId": "todo"},
+			expInitError: "could not find token in settings",
+		}, {
+			name:         "Error when the list ID is missing",
+			settings:     map[string]interface{}{"apiKey": "key", "token": "token"},
+			expInitError: "could not find list ID in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			m := &NotificationChannelConfig{Name: "trello_testing", Type: "trello", Settings: simplejson.NewFromAny(c.settings)}
+
+			_, err := NewTrelloConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTrelloError(t *testing.T) {
+	require.EqualError(t, trelloError([]byte(`{"message":"Board not found","error":"NOT_FOUND"}`), 404), "the Trello API returned status 404: Board not found")
+	require.EqualError(t, trelloError([]byte("unauthorized card permission requested\n"), 401), "the Trello API returned status 401: unauthorized card permission requested")
+	require.EqualError(t, trelloError([]byte(`<html>Bad Gateway</html>`), 502), "the Trello API returned status 502")
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/prometheus/alertmanager/notify"
@@ -55,7 +57,12 @@ func getImage(ctx context.Context, l log.Logger, imageStore ImageStore, alert ty
 	if token == "" {
 		return nil, nil
 	}
+	// Do not wait for the image store once the notification is cancelled or has timed out.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
+	defer observe(ctx, imageStage, time.Now())
 	ctx, cancelFunc := context.WithTimeout(ctx, ImageStoreTimeout)
 	defer cancelFunc()
 
@@ -167,12 +174,42 @@ type NotificationChannelConfig struct {
 	DisableResolveMessage bool              `json:"disableResolveMessage"`
 	Settings              *simplejson.Json  `json:"settings"`
 	SecureSettings        map[string][]byte `json:"secureSettings"`
+	// Proxy is the egress proxy of the contact point, set by NewFactoryConfig from the settings.
+	// It is nil if the contact point uses the proxies of the environment.
+	Proxy *ProxyConfig `json:"-"`
 }
 
 type httpCfg struct {
 	body     []byte
 	user     string
 	password string
+	// proxy replaces the proxies of the environment when set.
+	proxy func(*http.Request) (*url.URL, error)
+	// tlsConfig replaces the default TLS configuration when set.
+	tlsConfig *tls.Config
+}
+
+// newHTTPTransport returns the transport of the integrations that send their requests with their
+// own client. tlsConfig and proxy replace the default TLS configuration and the proxies of the
+// environment when set, such as with the egress proxy of the contact point.
+func newHTTPTransport(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			Renegotiation: tls.RenegotiateFreelyAsClient,
+		},
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if proxy != nil {
+		transport.Proxy = proxy
+	}
+	return transport
 }
 
 // sendHTTPRequest sends an HTTP request.
@@ -192,19 +229,9 @@ var sendHTTPRequest = func(ctx context.Context, url *url.URL, cfg httpCfg, logge
 
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("User-Agent", "Grafana")
-	netTransport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Renegotiation: tls.RenegotiateFreelyAsClient,
-		},
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout: 5 * time.Second,
-	}
 	netClient := &http.Client{
 		Timeout:   time.Second * 30,
-		Transport: netTransport,
+		Transport: newHTTPTransport(cfg.tlsConfig, cfg.proxy),
 	}
 	resp, err := netClient.Do(request)
 	if err != nil {
@@ -248,3 +275,41 @@ func joinUrlPath(base, additionalPath string, logger log.Logger) string {
 var GetBoundary = func() string {
 	return ""
 }
+
+// severityMapFromSettings returns the defaults overridden by the values of the setting, which maps
+// the severities of the alerts to a value of the integration such as a priority. The setting is an
+// object when provisioned and severity=value lines when set from the UI. Severities are lowercase.
+func severityMapFromSettings(setting *simplejson.Json, name string, defaults map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		values[k] = v
+	}
+
+	if m, err := setting.Map(); err == nil {
+		for k, v := range m {
+			var s string
+			switch v := v.(type) {
+			case string:
+				s = v
+			case json.Number, float64, int, int64:
+				s = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("invalid %s for severity %q, must be a string", name, k)
+			}
+			values[strings.ToLower(k)] = s
+		}
+		return values, nil
+	}
+
+	for _, line := range strings.FieldsFunc(setting.MustString(), func(r rune) bool { return r == '\n' || r == ',' }) {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid %s mapping %q, must be severity=%s", name, line, name)
+		}
+		values[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+	return values, nil
+}
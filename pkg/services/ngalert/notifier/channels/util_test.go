@@ -60,4 +60,15 @@ func TestWithStoredImages(t *testing.T) {
 	}, alerts...)
 	require.NoError(t, err)
 	assert.Equal(t, 1, i)
+
+	// should stop once the notification is cancelled
+	i = 0
+	cancelled, cancel := context.WithCancel(ctx)
+	err = withStoredImages(cancelled, log.New(ctx), imageStore, func(index int, image models.Image) error {
+		i += 1
+		cancel()
+		return nil
+	}, alerts...)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, i)
 }
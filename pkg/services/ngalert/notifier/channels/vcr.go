@@ -0,0 +1,281 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// VCRMode is the mode of the cassettes, which record the requests of the integrations and
+// their responses, or replay the responses instead of sending the requests.
+type VCRMode string
+
+const (
+	VCRModeRecord VCRMode = "record"
+	VCRModeReplay VCRMode = "replay"
+)
+
+// vcrRedacted replaces the secrets in the recorded requests and responses.
+const vcrRedacted = "[REDACTED]"
+
+type vcrKey int
+
+const vcrCtxKey vcrKey = iota
+
+// VCRInteraction is a request of an integration and its response.
+type VCRInteraction struct {
+	Request  VCRRequest  `json:"request"`
+	Response VCRResponse `json:"response"`
+}
+
+type VCRRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// VCRResponse is the response to a request, or the error if the request could not be sent.
+type VCRResponse struct {
+	StatusCode int    `json:"statusCode,omitempty"`
+	Body       string `json:"body,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Cassette records the webhooks sent by an integration to a fixture file, or replays the
+// responses of a fixture file in the order of the recording. The secrets of the integration
+// are redacted from the fixtures, which can be used in the tests of the integration.
+type Cassette struct {
+	mtx          sync.Mutex
+	path         string
+	mode         VCRMode
+	interactions []VCRInteraction
+	next         int
+}
+
+// NewCassette returns a cassette that records to the fixture file at path, or replays it.
+// Recording overwrites the fixture file.
+func NewCassette(path string, mode VCRMode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	switch mode {
+	case VCRModeRecord:
+	case VCRModeReplay:
+		// nolint:gosec
+		// The path is set by the configuration or by the tests.
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(b, &c.interactions); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid VCR mode %q, must be record or replay", mode)
+	}
+	return c, nil
+}
+
+// WithCassette returns a context in which the webhooks of the integrations are recorded to c or
+// replayed from c.
+func WithCassette(ctx context.Context, c *Cassette) context.Context {
+	return context.WithValue(ctx, vcrCtxKey, c)
+}
+
+func cassetteFromContext(ctx context.Context) (*Cassette, bool) {
+	c, ok := ctx.Value(vcrCtxKey).(*Cassette)
+	return c, ok && c != nil
+}
+
+// Interactions returns the interactions of the cassette.
+func (c *Cassette) Interactions() []VCRInteraction {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]VCRInteraction(nil), c.interactions...)
+}
+
+func (c *Cassette) record(i VCRInteraction) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.interactions = append(c.interactions, i)
+	b, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(b, '\n'), 0640)
+}
+
+// replay returns the response to the next request of the recording, which must be req.
+func (c *Cassette) replay(req VCRRequest) (VCRResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.next >= len(c.interactions) {
+		return VCRResponse{}, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	i := c.interactions[c.next]
+	c.next++
+	if i.Request.Method != req.Method || i.Request.URL != req.URL {
+		return VCRResponse{}, fmt.Errorf("unexpected request %s %s, the recording has %s %s", req.Method, req.URL, i.Request.Method, i.Request.URL)
+	}
+	if i.Request.Body != req.Body {
+		return VCRResponse{}, fmt.Errorf("the body of the request %s %s does not match the recording", req.Method, req.URL)
+	}
+	return i.Response, nil
+}
+
+// vcrNotificationService records the webhooks and their responses to the cassette of the
+// context, or replays the responses of the cassette instead of sending the webhooks. Emails are
+// sent as usual.
+type vcrNotificationService struct {
+	notifications.Service
+	config      *NotificationChannelConfig
+	decryptFunc GetDecryptedValueFn
+
+	secretsOnce sync.Once
+	// secrets are the decrypted secure settings of the integration, longest first. They are only
+	// decrypted once a webhook is recorded or replayed.
+	secrets []string
+}
+
+func (s *vcrNotificationService) decryptSecrets() {
+	if s.decryptFunc == nil {
+		return
+	}
+	for key := range s.config.SecureSettings {
+		if v := s.decryptFunc(context.Background(), s.config.SecureSettings, key, ""); v != "" {
+			s.secrets = append(s.secrets, v)
+		}
+	}
+	sort.Slice(s.secrets, func(i, j int) bool { return len(s.secrets[i]) > len(s.secrets[j]) })
+}
+
+func (s *vcrNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	c, ok := cassetteFromContext(ctx)
+	if !ok {
+		return s.Service.SendWebhookSync(ctx, cmd)
+	}
+	s.secretsOnce.Do(s.decryptSecrets)
+	req := s.request(cmd)
+
+	if c.mode == VCRModeReplay {
+		resp, err := c.replay(req)
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if cmd.Validation != nil {
+			if err := cmd.Validation([]byte(resp.Body), resp.StatusCode); err != nil {
+				return fmt.Errorf("webhook failed validation: %w", err)
+			}
+		}
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook response status %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return nil
+	}
+
+	var resp VCRResponse
+	validation := cmd.Validation
+	cmd.Validation = func(body []byte, statusCode int) error {
+		resp.StatusCode = statusCode
+		resp.Body = s.redact(string(body))
+		if validation != nil {
+			return validation(body, statusCode)
+		}
+		return nil
+	}
+	err := s.Service.SendWebhookSync(ctx, cmd)
+	cmd.Validation = validation
+	if err != nil && resp.StatusCode == 0 {
+		resp.Error = s.redact(err.Error())
+	}
+	if recordErr := c.record(VCRInteraction{Request: req, Response: resp}); recordErr != nil {
+		return fmt.Errorf("failed to record the webhook: %w", recordErr)
+	}
+	return err
+}
+
+// request returns the request of the webhook, without its secrets.
+func (s *vcrNotificationService) request(cmd *models.SendWebhookSync) VCRRequest {
+	method := cmd.HttpMethod
+	if method == "" {
+		method = http.MethodPost
+	}
+	req := VCRRequest{
+		Method: method,
+		URL:    s.redactURL(cmd.Url),
+		Body:   s.redact(cmd.Body),
+	}
+	if cmd.User != "" || len(cmd.HttpHeader) > 0 || cmd.ContentType != "" {
+		req.Header = make(map[string]string, len(cmd.HttpHeader)+2)
+	}
+	if cmd.ContentType != "" {
+		req.Header["Content-Type"] = cmd.ContentType
+	}
+	if cmd.User != "" {
+		req.Header["Authorization"] = vcrRedacted
+	}
+	for k, v := range cmd.HttpHeader {
+		if isVCRSensitive(k) {
+			v = vcrRedacted
+		}
+		req.Header[http.CanonicalHeaderKey(k)] = s.redact(v)
+	}
+	return req
+}
+
+// redactURL redacts the credentials of the URL and the query parameters whose name suggests a
+// secret, in addition to the secure settings.
+func (s *vcrNotificationService) redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return s.redact(rawURL)
+	}
+	if u.User != nil {
+		u.User = url.User(vcrRedacted)
+	}
+	if q := u.Query(); len(q) > 0 {
+		for k := range q {
+			if isVCRSensitive(k) {
+				q.Set(k, vcrRedacted)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return s.redact(u.String())
+}
+
+func (s *vcrNotificationService) redact(v string) string {
+	for _, secret := range s.secrets {
+		v = strings.ReplaceAll(v, secret, vcrRedacted)
+		// The secrets can be escaped in URLs.
+		v = strings.ReplaceAll(v, url.QueryEscape(secret), url.QueryEscape(vcrRedacted))
+	}
+	return v
+}
+
+// isVCRSensitive returns whether the header or query parameter is likely to hold a secret.
+func isVCRSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"auth", "token", "key", "secret", "password", "signature", "cookie"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -127,7 +127,7 @@ func (vn *VictoropsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bo
 			return nil
 		}, as...)
 
-	ruleURL := joinUrlPath(vn.tmpl.ExternalURL.String(), "/alerting/list", vn.log)
+	ruleURL := vn.RuleListURL(vn.tmpl.ExternalURL)
 	bodyJSON.Set("alert_url", ruleURL)
 
 	if tmplErr != nil {
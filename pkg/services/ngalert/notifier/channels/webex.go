@@ -0,0 +1,797 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"unicode"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The formats of the Webex messages.
+	webexFormatMarkdown     = "markdown"
+	webexFormatAdaptiveCard = "adaptiveCard"
+
+	webexTLSSkipVerifySetting = "tlsSkipVerify"
+
+	// The retries of the messages rate-limited by Webex.
+	webexMaxRetriesSetting = "maxRetries"
+	webexDefaultMaxRetries = 3
+	webexMaxMaxRetries     = 10
+)
+
+var (
+	// WebexMessagesURL is the endpoint of the Messages API, used with a bot access token.
+	WebexMessagesURL = "https://webexapis.com/v1/messages"
+
+	// webexDefaultAllowedHosts are the hosts of the incoming webhooks of Webex.
+	webexDefaultAllowedHosts = []string{"webexapis.com", "api.ciscospark.com"}
+)
+
+type WebexConfig struct {
+	*NotificationChannelConfig
+	URL           string
+	Routes        []webexRoute
+	BotToken      string
+	RoomID        string
+	ToPersonEmail string
+	MessageFormat string
+	Title         string
+	Message       string
+	CardTemplate  string
+	MentionEmails string
+	MaxRetries    int
+	StatusMessage bool
+	TLSConfig     *tls.Config
+}
+
+// webexRoute is an incoming webhook of the alerts whose labels match its matchers.
+type webexRoute struct {
+	matchers labels.Matchers
+	url      string
+}
+
+func WebexFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewWebexConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewWebexNotifier(cfg, fc.ImageStore, fc.KVStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewWebexConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*WebexConfig, error) {
+	cfg := &WebexConfig{
+		NotificationChannelConfig: config,
+		URL:                       decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString()),
+		BotToken:                  decryptFunc(context.Background(), config.SecureSettings, "botToken", config.Settings.Get("botToken").MustString()),
+		RoomID:                    strings.TrimSpace(config.Settings.Get("roomId").MustString()),
+		ToPersonEmail:             strings.TrimSpace(config.Settings.Get("toPersonEmail").MustString()),
+		MessageFormat:             config.Settings.Get("messageFormat").MustString(webexFormatMarkdown),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "webex.default.message" . }}`),
+		CardTemplate:              strings.TrimSpace(config.Settings.Get("cardTemplate").MustString()),
+		MentionEmails:             strings.TrimSpace(config.Settings.Get("mentionEmails").MustString()),
+		StatusMessage:             config.Settings.Get("statusMessage").MustBool(false),
+	}
+	var err error
+	if cfg.Routes, err = parseWebexRoutes(decryptFunc(context.Background(), config.SecureSettings, "routes", config.Settings.Get("routes").MustString())); err != nil {
+		return nil, err
+	}
+	if cfg.URL == "" && cfg.BotToken == "" && len(cfg.Routes) == 0 {
+		return nil, errors.New("could not find webhook URL, routes or bot access token in settings")
+	}
+	if cfg.BotToken != "" && (cfg.URL != "" || len(cfg.Routes) > 0) {
+		return nil, errors.New("the webhook URL and the routes cannot be used with a bot access token, whose messages are sent to the room ID")
+	}
+
+	allowedHosts := webexDefaultAllowedHosts
+	if hosts := strings.TrimSpace(config.Settings.Get("allowedHosts").MustString()); hosts != "" {
+		allowedHosts = nil
+		for _, h := range strings.Split(hosts, ",") {
+			if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+				allowedHosts = append(allowedHosts, h)
+			}
+		}
+	}
+	if cfg.URL != "" {
+		if err := checkWebexHost(cfg.URL, allowedHosts); err != nil {
+			return nil, err
+		}
+	}
+	for _, route := range cfg.Routes {
+		if err := checkWebexHost(route.url, allowedHosts); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.BotToken != "" && cfg.RoomID == "" && cfg.ToPersonEmail == "" {
+		return nil, errors.New("could not find room ID or person email in settings")
+	}
+	switch cfg.MessageFormat {
+	case webexFormatMarkdown:
+	case webexFormatAdaptiveCard:
+		if cfg.BotToken == "" {
+			return nil, errors.New("the Adaptive Card format requires a bot access token")
+		}
+	default:
+		return nil, fmt.Errorf("invalid message format %q", cfg.MessageFormat)
+	}
+	if cfg.StatusMessage && cfg.BotToken == "" {
+		return nil, errors.New("the status message requires a bot access token")
+	}
+	if cfg.StatusMessage && cfg.MessageFormat != webexFormatMarkdown {
+		return nil, errors.New("the status message cannot be an Adaptive Card, as Webex only edits the markdown of messages")
+	}
+	for _, t := range []struct {
+		name, text string
+	}{
+		{name: "room ID", text: cfg.RoomID},
+		{name: "person email", text: cfg.ToPersonEmail},
+		{name: "title", text: cfg.Title},
+		{name: "message", text: cfg.Message},
+		{name: "card", text: cfg.CardTemplate},
+		{name: "mention emails", text: cfg.MentionEmails},
+	} {
+		if err := checkWebexTemplate(t.text); err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", t.name, err)
+		}
+	}
+
+	if cfg.MaxRetries, err = webexMaxRetriesFromSettings(config.Settings); err != nil {
+		return nil, err
+	}
+
+	if cfg.TLSConfig, err = webexTLSConfig(config, decryptFunc); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// webexMaxRetriesFromSettings returns the number of times the messages rate-limited by Webex are
+// sent again.
+func webexMaxRetriesFromSettings(settings *simplejson.Json) (int, error) {
+	// The number is a number in provisioned contact points and a string in the UI.
+	v := settings.Get(webexMaxRetriesSetting).Interface()
+	if v == nil || v == "" {
+		return webexDefaultMaxRetries, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid max retries %q, must be a number", fmt.Sprint(v))
+	}
+	if n < 0 || n > webexMaxMaxRetries {
+		return 0, fmt.Errorf("invalid max retries, must be between 0 and %d", webexMaxMaxRetries)
+	}
+	return n, nil
+}
+
+// webexRetryPolicy returns the retry policy of a Webex contact point. Unless the retries of the
+// contact point are set, the messages rate-limited by Webex are sent again at most max retries
+// times, after the delay of their Retry-After header.
+func webexRetryPolicy(settings *simplejson.Json) (RetryPolicy, error) {
+	p, err := retryPolicyFromSettings(settings)
+	if err != nil {
+		return RetryPolicy{}, err
+	}
+	maxRetries, err := webexMaxRetriesFromSettings(settings)
+	if err != nil {
+		return RetryPolicy{}, err
+	}
+	if v := settings.Get(retryMaxAttemptsSetting).Interface(); v == nil || v == "" {
+		p.MaxAttempts = maxRetries + 1
+		p.StatusCodes = []int{http.StatusTooManyRequests}
+	}
+	return p, nil
+}
+
+// parseWebexRoutes parses the routes, one per line as matchers => webhook URL. The empty lines and
+// the lines starting with # are ignored.
+func parseWebexRoutes(s string) ([]webexRoute, error) {
+	var routes []webexRoute
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, "=>")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid webhook route %q, must be matchers => webhook URL", line)
+		}
+		matchers, err := labels.ParseMatchers(strings.TrimSpace(line[:i]))
+		if err != nil || len(matchers) == 0 {
+			return nil, fmt.Errorf("invalid matchers of webhook route %q", line)
+		}
+		routes = append(routes, webexRoute{matchers: matchers, url: strings.TrimSpace(line[i+2:])})
+	}
+	return routes, nil
+}
+
+// checkWebexHost returns an error if the host of the webhook URL is not one of the allowed hosts
+// or one of their subdomains, so that the notifications cannot be sent to other services.
+func checkWebexHost(rawURL string, allowedHosts []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("invalid webhook URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the host %q of the webhook URL is not allowed, must be one of %s", host, strings.Join(allowedHosts, ", "))
+}
+
+// checkWebexTemplate parses the template, to return its syntax errors when the contact point is
+// saved instead of when the alerts fire.
+func checkWebexTemplate(text string) error {
+	_, err := texttemplate.New("").Funcs(texttemplate.FuncMap(template.DefaultFuncs)).Parse(text)
+	return err
+}
+
+// webexTLSConfig returns the TLS configuration of the requests to Webex, or nil to use the
+// defaults. Unlike the other contact points, the CA certificate is trusted in addition to the
+// authorities of the system, as it is usually the CA of the proxy of the requests.
+func webexTLSConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*tls.Config, error) {
+	cfg, err := clientTLSFromSettings(config, decryptFunc)
+	if err != nil {
+		return nil, err
+	}
+	skipVerify := config.Settings.Get(webexTLSSkipVerifySetting).MustBool(false)
+	if cfg == nil {
+		if !skipVerify {
+			return nil, nil
+		}
+		cfg = &tls.Config{Renegotiation: tls.RenegotiateFreelyAsClient}
+	}
+	if caCert := strings.TrimSpace(config.Settings.Get(tlsCACertSetting).MustString()); caCert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		// The certificate is validated by clientTLSFromSettings.
+		pool.AppendCertsFromPEM([]byte(caCert))
+		cfg.RootCAs = pool
+	}
+	// #nosec G402 -- skipping the verification is an explicit opt-in of the user.
+	cfg.InsecureSkipVerify = skipVerify
+	return cfg, nil
+}
+
+// NewWebexNotifier is the constructor for the Webex notifier. The resolved notifications are sent as
+// replies to the firing notifications if the key-value store is set.
+func NewWebexNotifier(config *WebexConfig, images ImageStore, kv KVStore, ns notifications.WebhookSender, t *template.Template) *WebexNotifier {
+	wn := &WebexNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:           config.URL,
+		Routes:        config.Routes,
+		BotToken:      config.BotToken,
+		RoomID:        config.RoomID,
+		ToPersonEmail: config.ToPersonEmail,
+		MessageFormat: config.MessageFormat,
+		Title:         config.Title,
+		Message:       config.Message,
+		CardTemplate:  config.CardTemplate,
+		MentionEmails: config.MentionEmails,
+		StatusMessage: config.StatusMessage,
+		TLSConfig:     config.TLSConfig,
+		log:           log.New("alerting.notifier.webex"),
+		images:        images,
+		ns:            ns,
+		tmpl:          t,
+	}
+	if config.StatusMessage {
+		wn.statuses = newWebexStatusBoards(kv, config.UID)
+	} else if kv != nil && config.BotToken != "" {
+		wn.threads = newWebexThreads(kv, config.UID)
+	}
+	return wn
+}
+
+// WebexNotifier is responsible for sending alert notifications to Cisco Webex, with an incoming
+// webhook or a bot.
+type WebexNotifier struct {
+	*Base
+	URL           string
+	Routes        []webexRoute
+	BotToken      string
+	RoomID        string
+	ToPersonEmail string
+	MessageFormat string
+	Title         string
+	Message       string
+	CardTemplate  string
+	MentionEmails string
+	StatusMessage bool
+	TLSConfig     *tls.Config
+	log           log.Logger
+	images        ImageStore
+	ns            notifications.WebhookSender
+	tmpl          *template.Template
+	// threads are the firing notifications the resolved notifications reply to, only with a bot.
+	threads *webexThreads
+	// statuses are the status messages of the routes, with the status message option.
+	statuses *webexStatusBoards
+}
+
+// webexDestination is a webhook, or a room or a person a bot sends a message to, and its alerts.
+type webexDestination struct {
+	url           string
+	roomID        string
+	toPersonEmail string
+	alerts        []*types.Alert
+}
+
+// rateLimitKey returns the destination of the rate limit of Webex: the room or the person of a bot,
+// or the space of a webhook.
+func (d *webexDestination) rateLimitKey() string {
+	switch {
+	case d.roomID != "":
+		return "room:" + d.roomID
+	case d.toPersonEmail != "":
+		return "person:" + d.toPersonEmail
+	}
+	return d.url
+}
+
+type webexMessage struct {
+	RoomID        string            `json:"roomId,omitempty"`
+	ToPersonEmail string            `json:"toPersonEmail,omitempty"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Markdown      string            `json:"markdown"`
+	Files         []string          `json:"files,omitempty"`
+	Attachments   []webexAttachment `json:"attachments,omitempty"`
+}
+
+type webexAttachment struct {
+	ContentType string      `json:"contentType"`
+	Content     interface{} `json:"content"`
+}
+
+// Notify sends a message to each destination of the alerts: the webhook of their route, or the
+// room or the person of their labels with a bot. With the status message option, it updates the
+// status message of the route in each room instead.
+//
+// The messages are sent one destination after the other and the notification fails at the first
+// destination that fails. The Alertmanager then sends the notification again at the next flush of
+// the group, to all the destinations including those that already received it.
+func (wn *WebexNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	wn.log.Debug("executing Webex notification", "notification", wn.Name)
+	if wn.StatusMessage {
+		return wn.notifyStatus(ctx, as)
+	}
+
+	destinations, err := wn.destinations(ctx, as)
+	if err != nil {
+		return false, err
+	}
+	groupKey, _ := notify.ExtractGroupKey(ctx)
+	for _, d := range destinations {
+		msg, image, err := wn.buildMessage(ctx, d)
+		if err != nil {
+			wn.log.Error("failed to build Webex message", "err", err, "notification", wn.Name)
+			return false, err
+		}
+
+		var threadKey, parentID string
+		resolved := types.Alerts(d.alerts...).Status() == model.AlertResolved
+		if wn.threads != nil && groupKey != "" {
+			threadKey = webexThreadKey(string(groupKey), d.roomID, d.toPersonEmail)
+			if parentID, err = wn.threads.get(ctx, threadKey); err != nil {
+				wn.log.Warn("failed to get Webex thread", "err", err, "notification", wn.Name)
+			}
+			if resolved {
+				msg.ParentID = parentID
+			}
+		}
+
+		messageID, err := wn.send(ctx, d, msg, image)
+		if err != nil {
+			wn.log.Error("failed to send notification to Webex", "err", err, "notification", wn.Name)
+			return false, err
+		}
+
+		// The thread starts with the first firing notification of the group, and ends when the
+		// group is resolved.
+		switch {
+		case threadKey == "":
+		case resolved:
+			if err := wn.threads.delete(ctx, threadKey); err != nil {
+				wn.log.Warn("failed to delete Webex thread", "err", err, "notification", wn.Name)
+			}
+		case parentID == "" && messageID != "":
+			if err := wn.threads.set(ctx, threadKey, messageID); err != nil {
+				wn.log.Warn("failed to save Webex thread", "err", err, "notification", wn.Name)
+			}
+		}
+	}
+	return true, nil
+}
+
+// destinations groups the alerts by destination, in the order of the alerts.
+func (wn *WebexNotifier) destinations(ctx context.Context, as []*types.Alert) ([]*webexDestination, error) {
+	var destinations []*webexDestination
+	byKey := map[string]*webexDestination{}
+	for _, a := range as {
+		d := webexDestination{url: wn.url(a)}
+		if wn.BotToken != "" {
+			var err error
+			if d.roomID, d.toPersonEmail, err = wn.target(ctx, a); err != nil {
+				return nil, err
+			}
+		} else if d.url == "" {
+			wn.log.Debug("no Webex webhook route matches the labels of the alert", "notification", wn.Name, "alert", a.Name())
+			continue
+		}
+		key := d.url + "\n" + d.roomID + "\n" + d.toPersonEmail
+		if existing, ok := byKey[key]; ok {
+			existing.alerts = append(existing.alerts, a)
+			continue
+		}
+		d.alerts = []*types.Alert{a}
+		byKey[key] = &d
+		destinations = append(destinations, &d)
+	}
+	return destinations, nil
+}
+
+// url returns the webhook of the first route matching the labels of the alert, or the webhook URL
+// if no route matches.
+func (wn *WebexNotifier) url(a *types.Alert) string {
+	for _, route := range wn.Routes {
+		if route.matchers.Matches(a.Labels) {
+			return route.url
+		}
+	}
+	return wn.URL
+}
+
+// target renders the room and the person of the alert. The room takes precedence.
+func (wn *WebexNotifier) target(ctx context.Context, a *types.Alert) (string, string, error) {
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, wn.tmpl, []*types.Alert{a}, wn.log, &tmplErr)
+	if roomID := strings.TrimSpace(tmpl(wn.RoomID)); roomID != "" {
+		return roomID, "", tmplErr
+	}
+	if toPersonEmail := strings.TrimSpace(tmpl(wn.ToPersonEmail)); toPersonEmail != "" {
+		return "", toPersonEmail, tmplErr
+	}
+	if tmplErr != nil {
+		return "", "", fmt.Errorf("failed to template Webex room ID: %w", tmplErr)
+	}
+	return "", "", fmt.Errorf("the room ID and the person email of alert %q are empty", a.Name())
+}
+
+// mentions returns the mentions of the people whose emails are rendered by the mention emails
+// template for the firing alerts.
+func (wn *WebexNotifier) mentions(ctx context.Context, as []*types.Alert, tmplErr *error) string {
+	if wn.MentionEmails == "" {
+		return ""
+	}
+	var mentions []string
+	seen := map[string]struct{}{}
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		tmpl, _ := TmplText(ctx, wn.tmpl, []*types.Alert{a}, wn.log, tmplErr)
+		for _, email := range strings.FieldsFunc(tmpl(wn.MentionEmails), func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			if _, ok := seen[email]; ok {
+				continue
+			}
+			seen[email] = struct{}{}
+			mentions = append(mentions, "<@personEmail:"+email+">")
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// buildMessage returns the message to the destination, and the image uploaded with it if any.
+// The markdown is also the text of the notifications of the cards, and is shown by the clients
+// that do not render cards.
+func (wn *WebexNotifier) buildMessage(ctx context.Context, d *webexDestination) (*webexMessage, *ngmodels.Image, error) {
+	var tmplErr error
+	defer func() {
+		if tmplErr != nil {
+			wn.log.Warn("failed to template Webex message", "err", tmplErr)
+		}
+	}()
+
+	tmpl, data := TmplText(ctx, wn.tmpl, d.alerts, wn.log, &tmplErr)
+	title := strings.TrimSpace(tmpl(wn.Title))
+	mentions := wn.mentions(ctx, d.alerts, &tmplErr)
+	markdown, _ := wn.FitAlerts(ctx, wn.tmpl.ExternalURL, d.alerts, func(alerts []*types.Alert) string {
+		tmpl, _ := TmplText(ctx, wn.tmpl, alerts, wn.log, &tmplErr)
+		var parts []string
+		if title != "" {
+			parts = append(parts, "**"+title+"**")
+		}
+		for _, p := range []string{strings.TrimSpace(tmpl(wn.Message)), mentions} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	})
+	msg := &webexMessage{
+		RoomID:        d.roomID,
+		ToPersonEmail: d.toPersonEmail,
+		Markdown:      markdown,
+	}
+
+	// Webex shows a single file per message. The Messages API uploads the images without a URL,
+	// but not with cards, which show the image from its URL.
+	var image *ngmodels.Image
+	_ = withStoredImages(ctx, wn.log, wn.images, func(_ int, img ngmodels.Image) error {
+		if img.URL == "" && (wn.BotToken == "" || wn.MessageFormat != webexFormatMarkdown || img.Path == "") {
+			return nil
+		}
+		image = &img
+		return ErrImagesDone
+	}, d.alerts...)
+
+	if wn.MessageFormat == webexFormatAdaptiveCard {
+		var imageURL string
+		if image != nil {
+			imageURL = image.URL
+		}
+		card, err := wn.buildCard(tmpl, data, title, tmpl(wn.Message), imageURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		msg.Attachments = []webexAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}}
+		return msg, nil, nil
+	}
+	if image != nil && image.URL != "" {
+		msg.Files = []string{image.URL}
+		image = nil
+	}
+	return msg, image, nil
+}
+
+// buildCard returns the Adaptive Card of the alerts: the card template rendered with the data of
+// the notification, or a card with a bar of the color of the state, the title, the message and
+// the common labels of the alerts, and buttons to view the rule and to silence the alert.
+func (wn *WebexNotifier) buildCard(tmpl func(string) string, data *ExtendedData, title, message, imageURL string) (interface{}, error) {
+	if wn.CardTemplate != "" {
+		var card interface{}
+		if err := json.Unmarshal([]byte(tmpl(wn.CardTemplate)), &card); err != nil {
+			return nil, fmt.Errorf("the card template did not render valid JSON: %w", err)
+		}
+		return card, nil
+	}
+
+	// The style of the bar is the closest to the color of the state, as cards cannot set colors.
+	style := "attention"
+	if data.Status == string(model.AlertResolved) {
+		style = "good"
+	}
+	items := []map[string]interface{}{
+		{"type": "TextBlock", "text": title, "size": "medium", "weight": "bolder", "wrap": true},
+	}
+	if message = strings.TrimSpace(message); message != "" {
+		items = append(items, map[string]interface{}{"type": "TextBlock", "text": message, "wrap": true})
+	}
+	if names := data.CommonLabels.Names(); len(names) > 0 {
+		facts := make([]map[string]string, 0, len(names))
+		for _, name := range names {
+			facts = append(facts, map[string]string{"title": name, "value": data.CommonLabels[name]})
+		}
+		items = append(items, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	if imageURL != "" {
+		items = append(items, map[string]interface{}{"type": "Image", "url": imageURL, "altText": "Panel image"})
+	}
+
+	actions := []map[string]string{}
+	if ruleURL := wn.ruleURL(data); ruleURL != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "View rule", "url": ruleURL})
+	}
+	if len(data.Alerts) == 1 && data.Alerts[0].SilenceURL != "" && data.Status != string(model.AlertResolved) {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "Silence", "url": data.Alerts[0].SilenceURL})
+	}
+
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.3",
+		"body": []map[string]interface{}{
+			{
+				"type": "ColumnSet",
+				"columns": []map[string]interface{}{
+					{"type": "Column", "width": "8px", "style": style, "bleed": true, "items": []interface{}{}},
+					{"type": "Column", "width": "stretch", "items": items},
+				},
+			},
+		},
+		"actions": actions,
+	}, nil
+}
+
+// ruleURL returns the URL of the alert rule of the alerts, or the URL of the list of the alert
+// rules if they come from several rules.
+func (wn *WebexNotifier) ruleURL(data *ExtendedData) string {
+	generatorURL := data.Alerts[0].GeneratorURL
+	for _, a := range data.Alerts[1:] {
+		if a.GeneratorURL != generatorURL {
+			generatorURL = ""
+			break
+		}
+	}
+	if generatorURL != "" {
+		return generatorURL
+	}
+	return wn.RuleListURL(wn.tmpl.ExternalURL)
+}
+
+// send sends the message, with the image as its file if set, and returns its ID, which is only
+// returned by the Messages API.
+func (wn *WebexNotifier) send(ctx context.Context, d *webexDestination, msg *webexMessage, image *ngmodels.Image) (string, error) {
+	cmd := &models.SendWebhookSync{
+		Url:        d.url,
+		HttpMethod: "POST",
+	}
+	if wn.BotToken != "" {
+		cmd.Url = WebexMessagesURL
+	}
+	if image != nil {
+		if err := wn.uploadImage(cmd, msg, image.Path); err != nil {
+			return "", err
+		}
+	}
+	resp, err := wn.do(ctx, cmd, d.rateLimitKey(), msg)
+	return resp.ID, err
+}
+
+// webexResponse is the message returned by the Messages API when it is posted or edited.
+type webexResponse struct {
+	ID     string `json:"id"`
+	RoomID string `json:"roomId"`
+}
+
+// do sends the request with the message as its JSON body, unless the body is already set, once the
+// rate limit of the destination allows it.
+func (wn *WebexNotifier) do(ctx context.Context, cmd *models.SendWebhookSync, destination string, msg *webexMessage) (webexResponse, error) {
+	var resp webexResponse
+	cmd.TLSConfig = wn.TLSConfig
+	if wn.BotToken != "" {
+		cmd.HttpHeader = map[string]string{"Authorization": "Bearer " + wn.BotToken}
+	}
+	if cmd.Body == "" {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return resp, err
+		}
+		cmd.Body = string(body)
+		cmd.ContentType = "application/json"
+	}
+	cmd.Validation = func(body []byte, code int) error {
+		if err := webexValidation(body, code); err != nil {
+			return err
+		}
+		// Webhooks do not return the message.
+		_ = json.Unmarshal(body, &resp)
+		return nil
+	}
+
+	// The messages are queued to stay within the rate limit of Webex. Those still rate-limited by
+	// Webex are sent again by the retry policy of the contact point.
+	if err := webexRateLimiter.wait(ctx, destination); err != nil {
+		return resp, err
+	}
+	return resp, wn.ns.SendWebhookSync(ctx, cmd)
+}
+
+// uploadImage sets the body of the command to a multipart body with the fields of the message and
+// the image as its file. The body is not set if the image does not exist.
+func (wn *WebexNotifier) uploadImage(cmd *models.SendWebhookSync, msg *webexMessage, path string) error {
+	f, err := openImage(path)
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrImageNotFound) {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			wn.log.Warn("failed to close image", "err", err)
+		}
+	}()
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if boundary := GetBoundary(); boundary != "" {
+		if err := w.SetBoundary(boundary); err != nil {
+			return err
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{name: "roomId", value: msg.RoomID},
+		{name: "toPersonEmail", value: msg.ToPersonEmail},
+		{name: "parentId", value: msg.ParentID},
+		{name: "markdown", value: msg.Markdown},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if err := w.WriteField(field.name, field.value); err != nil {
+			return err
+		}
+	}
+	fw, err := w.CreateFormFile("files", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	cmd.Body = b.String()
+	cmd.ContentType = w.FormDataContentType()
+	return nil
+}
+
+// webexAPIError is an error returned by the Webex API, such as a room the bot is not a member of.
+type webexAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e webexAPIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("the Webex API returned status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("the Webex API returned status %d", e.StatusCode)
+}
+
+// webexValidation returns the errors of the Webex API.
+func webexValidation(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &resp)
+	return webexAPIError{StatusCode: statusCode, Message: resp.Message}
+}
+
+func (wn *WebexNotifier) SendResolved() bool {
+	// The status message must be updated when the alerts of a group are resolved.
+	return wn.StatusMessage || !wn.GetDisableResolveMessage()
+}
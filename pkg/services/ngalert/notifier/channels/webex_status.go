@@ -0,0 +1,149 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var webexStatusFormat = statusBoardFormat{
+	noneFiring: "✅ **No alerts are firing** (updated %s)",
+	firing:     "🚨 **%d %s firing** (updated %s)",
+	groupTitle: "**%s**",
+}
+
+// webexStatusBoard is the status message of a route in a room or with a person.
+type webexStatusBoard struct {
+	// RoomID and MessageID identify the message once it is posted.
+	RoomID    string                      `json:"roomId,omitempty"`
+	MessageID string                      `json:"messageId,omitempty"`
+	Groups    map[string]statusBoardGroup `json:"groups"`
+	UpdatedAt time.Time                   `json:"updatedAt"`
+}
+
+// webexStatusBoards holds the status messages of a contact point, persisted in a single key of the
+// key-value store like its threads. Without key-value store, they are not kept between the
+// notifications and each notification posts a new status message.
+type webexStatusBoards struct {
+	kv  KVStore
+	key string
+
+	// mtx serializes the updates of the status messages of the contact point.
+	mtx sync.Mutex
+}
+
+func newWebexStatusBoards(kv KVStore, uid string) *webexStatusBoards {
+	return &webexStatusBoards{kv: kv, key: "webex_status." + uid}
+}
+
+// load returns the status messages that were updated within the TTL.
+func (b *webexStatusBoards) load(ctx context.Context, now time.Time) (map[string]*webexStatusBoard, error) {
+	boards := map[string]*webexStatusBoard{}
+	if b.kv == nil {
+		return boards, nil
+	}
+	if err := loadWebexState(ctx, b.kv, b.key, &boards); err != nil {
+		return nil, err
+	}
+	for key, board := range boards {
+		if now.Sub(board.UpdatedAt) > webexStateTTL {
+			delete(boards, key)
+		}
+	}
+	return boards, nil
+}
+
+func (b *webexStatusBoards) save(ctx context.Context, boards map[string]*webexStatusBoard) error {
+	if b.kv == nil {
+		return nil
+	}
+	return saveWebexState(ctx, b.kv, b.key, boards, len(boards) == 0)
+}
+
+// notifyStatus updates the status message of the route of the alerts in each of their rooms and
+// with each of their persons instead of posting a message per notification. The message is
+// posted the first time, and posted again if it cannot be edited anymore, such as when it was
+// deleted.
+func (wn *WebexNotifier) notifyStatus(ctx context.Context, as []*types.Alert) (bool, error) {
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	destinations, err := wn.destinations(ctx, as)
+	if err != nil {
+		return false, err
+	}
+
+	wn.statuses.mtx.Lock()
+	defer wn.statuses.mtx.Unlock()
+	now := timeNow()
+	boards, err := wn.statuses.load(ctx, now)
+	if err != nil {
+		return false, err
+	}
+	// The boards are saved even if a message fails, so that the messages that were posted are
+	// edited by the next notifications instead of being posted again.
+	defer func() {
+		if err := wn.statuses.save(ctx, boards); err != nil {
+			wn.log.Warn("failed to save the Webex status messages", "err", err, "notification", wn.Name)
+		}
+	}()
+
+	route := routeFromGroupKey(groupKey.String())
+	for _, d := range destinations {
+		var firing []*types.Alert
+		for _, a := range d.alerts {
+			if !a.Resolved() {
+				firing = append(firing, a)
+			}
+		}
+		group := statusBoardGroup{Firing: len(firing)}
+		if len(firing) > 0 {
+			var tmplErr error
+			tmpl, _ := TmplText(ctx, wn.tmpl, firing, wn.log, &tmplErr)
+			group.Title, group.Text = tmpl(wn.Title), tmpl(wn.Message)
+			if tmplErr != nil {
+				wn.log.Warn("failed to template Webex status message", "err", tmplErr.Error())
+			}
+		}
+
+		key := route + "\n" + d.rateLimitKey()
+		board, ok := boards[key]
+		if !ok {
+			board = &webexStatusBoard{Groups: map[string]statusBoardGroup{}}
+			boards[key] = board
+		}
+		sb := &statusBoard{groups: board.Groups}
+		sb.update(groupKey.String(), group)
+		board.UpdatedAt = now
+		markdown, _ := wn.TruncateMessage(sb.render(webexStatusFormat, now.UTC().Format("2006-01-02 15:04 UTC")))
+
+		if board.MessageID != "" {
+			cmd := &models.SendWebhookSync{Url: WebexMessagesURL + "/" + board.MessageID, HttpMethod: http.MethodPut}
+			_, err := wn.do(ctx, cmd, d.rateLimitKey(), &webexMessage{RoomID: board.RoomID, Markdown: markdown})
+			var apiErr webexAPIError
+			if err == nil {
+				continue
+			} else if !errors.As(err, &apiErr) || apiErr.StatusCode/100 != 4 || apiErr.StatusCode == http.StatusTooManyRequests {
+				return false, err
+			}
+			wn.log.Info("the Webex status message cannot be edited, posting it again", "err", err, "notification", wn.Name)
+		}
+
+		cmd := &models.SendWebhookSync{Url: WebexMessagesURL, HttpMethod: http.MethodPost}
+		resp, err := wn.do(ctx, cmd, d.rateLimitKey(), &webexMessage{RoomID: d.roomID, ToPersonEmail: d.toPersonEmail, Markdown: markdown})
+		if err != nil {
+			return false, err
+		}
+		// The message is not posted in a dry run.
+		board.RoomID, board.MessageID = resp.RoomID, resp.ID
+	}
+	return true, nil
+}
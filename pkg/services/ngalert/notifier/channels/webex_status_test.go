@@ -0,0 +1,134 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// fakeWebexMessagesAPI posts and edits the messages of the Messages API of Webex.
+type fakeWebexMessagesAPI struct {
+	t        *testing.T
+	calls    []string
+	posted   int
+	messages map[string]webexMessage
+}
+
+func (f *fakeWebexMessagesAPI) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	var msg webexMessage
+	require.NoError(f.t, json.Unmarshal([]byte(cmd.Body), &msg))
+	switch cmd.HttpMethod {
+	case "POST":
+		require.Equal(f.t, WebexMessagesURL, cmd.Url)
+		f.posted++
+		id := fmt.Sprintf("msg-%d", f.posted)
+		f.calls = append(f.calls, "POST")
+		if msg.RoomID == "" {
+			// The messages to a person are in their direct room.
+			msg.RoomID, msg.ToPersonEmail = "direct-"+msg.ToPersonEmail, ""
+		}
+		f.messages[id] = msg
+		return cmd.Validation([]byte(fmt.Sprintf(`{"id": %q, "roomId": %q}`, id, msg.RoomID)), 200)
+	case "PUT":
+		id := strings.TrimPrefix(cmd.Url, WebexMessagesURL+"/")
+		f.calls = append(f.calls, "PUT "+id)
+		existing, ok := f.messages[id]
+		if !ok {
+			return cmd.Validation([]byte(`{"message": "The requested resource could not be found."}`), 404)
+		}
+		require.Equal(f.t, existing.RoomID, msg.RoomID)
+		f.messages[id] = msg
+		return cmd.Validation([]byte(fmt.Sprintf(`{"id": %q, "roomId": %q}`, id, msg.RoomID)), 200)
+	}
+	f.t.Fatalf("unexpected method %s", cmd.HttpMethod)
+	return nil
+}
+
+func TestWebexStatusMessage(t *testing.T) {
+	defer mockTimeNow(time.Unix(1700000000, 0))()
+	kv := newFakeKVStore()
+	api := &fakeWebexMessagesAPI{t: t, messages: map[string]webexMessage{}}
+	settings := map[string]interface{}{
+		"botToken":              "token",
+		"roomId":                "{{ .CommonLabels.room }}",
+		"toPersonEmail":         "oncall@example.com",
+		"statusMessage":         true,
+		"title":                 "{{ .CommonLabels.alertname }}",
+		"message":               "{{ range .Alerts }}{{ .Labels.instance }} {{ end }}",
+		"disableResolveMessage": true,
+	}
+	newNotifier := func() *WebexNotifier {
+		t.Helper()
+		wn, err := newWebexNotifierForTests(t, settings, nil, api)
+		require.NoError(t, err)
+		wn.statuses = newWebexStatusBoards(kv, "uid")
+		return wn
+	}
+	wn := newNotifier()
+	require.True(t, wn.SendResolved())
+
+	notifyGroup := func(wn *WebexNotifier, labels model.LabelSet, alerts ...*types.Alert) {
+		t.Helper()
+		ctx := notify.WithGroupKey(context.Background(), `{}/{team="a"}:`+labels.String())
+		ctx = notify.WithGroupLabels(ctx, labels)
+		ok, err := wn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	firing := func(name, instance, room string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name), "instance": model.LabelValue(instance), "room": model.LabelValue(room)}}}
+	}
+	resolved := func(a *types.Alert) *types.Alert {
+		r := *a
+		r.StartsAt, r.EndsAt = time.Unix(1600000000, 0), time.Unix(1600000001, 0)
+		return &r
+	}
+	const updated = "(updated 2023-11-14 22:13 UTC)"
+
+	// The first notification posts the message.
+	diskFull := firing("DiskFull", "db-1", "ops")
+	notifyGroup(wn, model.LabelSet{"alertname": "DiskFull"}, diskFull)
+	require.Equal(t, []string{"POST"}, api.calls)
+	require.Equal(t, webexMessage{RoomID: "ops", Markdown: "🚨 **1 alert is firing** " + updated + "\n\n**DiskFull**\ndb-1"}, api.messages["msg-1"])
+
+	// The notifications of the other groups of the route edit it, and the other rooms and persons
+	// have their own message.
+	notifyGroup(wn, model.LabelSet{"alertname": "HighLatency"}, firing("HighLatency", "api-1", "ops"), firing("HighLatency", "api-2", "ops"), firing("HighLatency", "api-3", ""))
+	require.Equal(t, []string{"POST", "PUT msg-1", "POST"}, api.calls)
+	require.Equal(t, "🚨 **3 alerts are firing** "+updated+"\n\n**DiskFull**\ndb-1\n\n**HighLatency**\napi-1 api-2", api.messages["msg-1"].Markdown)
+	require.Equal(t, webexMessage{RoomID: "direct-oncall@example.com", Markdown: "🚨 **1 alert is firing** " + updated + "\n\n**HighLatency**\napi-3"}, api.messages["msg-2"])
+
+	// The messages are kept when the configuration is applied again, and resolved groups are
+	// removed from them.
+	wn = newNotifier()
+	notifyGroup(wn, model.LabelSet{"alertname": "DiskFull"}, resolved(diskFull))
+	require.Equal(t, []string{"POST", "PUT msg-1", "POST", "PUT msg-1"}, api.calls)
+	require.Equal(t, "🚨 **2 alerts are firing** "+updated+"\n\n**HighLatency**\napi-1 api-2", api.messages["msg-1"].Markdown)
+
+	// The message is posted again if it cannot be edited, such as when it was deleted.
+	delete(api.messages, "msg-1")
+	notifyGroup(wn, model.LabelSet{"alertname": "HighLatency"}, resolved(firing("HighLatency", "api-1", "ops")))
+	require.Equal(t, []string{"POST", "PUT msg-1", "POST", "PUT msg-1", "PUT msg-1", "POST"}, api.calls)
+	require.Equal(t, webexMessage{RoomID: "ops", Markdown: "✅ **No alerts are firing** " + updated}, api.messages["msg-3"])
+
+	// The messages that are not updated within the TTL are forgotten.
+	var boards map[string]*webexStatusBoard
+	require.NoError(t, json.Unmarshal([]byte(kv.values["webex_status.uid"]), &boards))
+	require.Len(t, boards, 2)
+	defer mockTimeNow(time.Unix(1700000000, 0).Add(webexStateTTL + time.Minute))()
+	notifyGroup(wn, model.LabelSet{"alertname": "DiskFull"}, firing("DiskFull", "db-2", "ops"))
+	require.Equal(t, "POST", api.calls[len(api.calls)-1])
+	boards = nil
+	require.NoError(t, json.Unmarshal([]byte(kv.values["webex_status.uid"]), &boards))
+	require.Len(t, boards, 1)
+}
@@ -0,0 +1,567 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// mockWebexNotificationService returns a notification service mock that responds to the messages
+// sent to Webex with their IDs.
+func mockWebexNotificationService() *notificationServiceMock {
+	ns := mockNotificationService()
+	ns.ResponseBody = func(n int) string { return fmt.Sprintf(`{"id": "msg-%d"}`, n) }
+	return ns
+}
+
+// webexBodies returns the JSON bodies of the messages sent to Webex.
+func webexBodies(t *testing.T, ns *notificationServiceMock) []map[string]interface{} {
+	t.Helper()
+	var bodies []map[string]interface{}
+	for _, req := range ns.Webhooks {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(req.Body), &body))
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+// webexRateLimitedSender responds with the status codes of its responses in order, with a
+// Retry-After header of 0 seconds for the rate-limited responses.
+type webexRateLimitedSender struct {
+	notificationServiceMock
+	responses []int
+	attempts  int
+}
+
+func (s *webexRateLimitedSender) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	statusCode := s.responses[s.attempts]
+	s.attempts++
+	body := `{"id": "msg"}`
+	if statusCode == http.StatusTooManyRequests {
+		cmd.ResponseHeaders(http.Header{"Retry-After": []string{"0"}})
+		body = `{"message": "rate limited"}`
+	}
+	return cmd.Validation([]byte(body), statusCode)
+}
+
+func newWebexNotifierForTests(t *testing.T, settings map[string]interface{}, images ImageStore, ns notifications.WebhookSender) (*WebexNotifier, error) {
+	t.Helper()
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	m := &NotificationChannelConfig{
+		Name:     "webex_testing",
+		Type:     "webex",
+		Settings: simplejson.NewFromAny(settings),
+	}
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewWebexConfig(m, secretsService.GetDecryptedValue)
+	if err != nil {
+		return nil, err
+	}
+	if images == nil {
+		images = &UnavailableImageStore{}
+	}
+	return NewWebexNotifier(cfg, images, nil, ns, tmpl), nil
+}
+
+func TestWebexConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:         "Error without webhook URL, routes or bot",
+			settings:     map[string]interface{}{},
+			expInitError: "could not find webhook URL, routes or bot access token in settings",
+		}, {
+			name:         "Error with a bot without room ID or person email",
+			settings:     map[string]interface{}{"botToken": "token"},
+			expInitError: "could not find room ID or person email in settings",
+		}, {
+			name:         "Error with a bot and a webhook URL",
+			settings:     map[string]interface{}{"botToken": "token", "roomId": "room", "url": "https://webexapis.com/v1/webhooks/incoming/abcd"},
+			expInitError: "the webhook URL and the routes cannot be used with a bot access token, whose messages are sent to the room ID",
+		}, {
+			name:         "Error with a bot and routes",
+			settings:     map[string]interface{}{"botToken": "token", "roomId": "room", "routes": "severity=critical => https://webexapis.com/v1/webhooks/incoming/abcd"},
+			expInitError: "the webhook URL and the routes cannot be used with a bot access token, whose messages are sent to the room ID",
+		}, {
+			name:         "Error with an invalid route",
+			settings:     map[string]interface{}{"routes": "severity=critical https://webexapis.com/v1/webhooks/incoming/abcd"},
+			expInitError: `invalid webhook route "severity=critical https://webexapis.com/v1/webhooks/incoming/abcd", must be matchers => webhook URL`,
+		}, {
+			name:         "Error with a route without matchers",
+			settings:     map[string]interface{}{"routes": " => https://webexapis.com/v1/webhooks/incoming/abcd"},
+			expInitError: `invalid matchers of webhook route "=> https://webexapis.com/v1/webhooks/incoming/abcd"`,
+		}, {
+			name:         "Error with an Adaptive Card without bot",
+			settings:     map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "messageFormat": "adaptiveCard"},
+			expInitError: "the Adaptive Card format requires a bot access token",
+		}, {
+			name:         "Error with an unknown message format",
+			settings:     map[string]interface{}{"botToken": "token", "roomId": "room", "messageFormat": "card"},
+			expInitError: `invalid message format "card"`,
+		}, {
+			name:         "Error with a status message without bot",
+			settings:     map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "statusMessage": true},
+			expInitError: "the status message requires a bot access token",
+		}, {
+			name:         "Error with a status message as an Adaptive Card",
+			settings:     map[string]interface{}{"botToken": "token", "roomId": "room", "messageFormat": "adaptiveCard", "statusMessage": true},
+			expInitError: "the status message cannot be an Adaptive Card, as Webex only edits the markdown of messages",
+		}, {
+			name:         "Error with a host that is not allowed",
+			settings:     map[string]interface{}{"url": "https://example.com/webhooks/incoming/abcd"},
+			expInitError: `the host "example.com" of the webhook URL is not allowed, must be one of webexapis.com, api.ciscospark.com`,
+		}, {
+			name:     "Custom allowed hosts and their subdomains",
+			settings: map[string]interface{}{"url": "https://api.webexgov.us/v1/webhooks/incoming/abcd", "allowedHosts": "webexgov.us"},
+		}, {
+			name:         "Error with an invalid webhook URL",
+			settings:     map[string]interface{}{"url": "webexapis.com/v1/webhooks/incoming/abcd"},
+			expInitError: "invalid webhook URL",
+		}, {
+			name:         "Error with a route to a host that is not allowed",
+			settings:     map[string]interface{}{"routes": "severity=critical => https://example.com/abcd"},
+			expInitError: `the host "example.com" of the webhook URL is not allowed, must be one of webexapis.com, api.ciscospark.com`,
+		}, {
+			name:         "Error with an invalid template",
+			settings:     map[string]interface{}{"botToken": "token", "roomId": "{{ .CommonLabels.room "},
+			expInitError: "invalid room ID template: template: :1: unclosed action",
+		}, {
+			name:         "Error with too many retries",
+			settings:     map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "maxRetries": "11"},
+			expInitError: "invalid max retries, must be between 0 and 10",
+		}, {
+			name:         "Error with invalid max retries",
+			settings:     map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "maxRetries": "many"},
+			expInitError: `invalid max retries "many", must be a number`,
+		}, {
+			name:         "Error with invalid TLS settings",
+			settings:     map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "tlsCACert": "not a certificate"},
+			expInitError: "invalid TLS settings: failed to parse CA certificate",
+		}, {
+			name:     "Bot with a person email",
+			settings: map[string]interface{}{"botToken": "token", "toPersonEmail": "oncall@example.com"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := newWebexNotifierForTests(t, c.settings, nil, mockNotificationService())
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("The CA certificate is trusted in addition to the system", func(t *testing.T) {
+		decryptFn := func(_ context.Context, _ map[string][]byte, _ string, fallback string) string { return fallback }
+		_, caPEM, _ := newClientCertificate(t, "proxy")
+		cfg, err := webexTLSConfig(&NotificationChannelConfig{
+			Settings: simplejson.NewFromAny(map[string]interface{}{"tlsCACert": caPEM, "tlsSkipVerify": true}),
+		}, decryptFn)
+		require.NoError(t, err)
+		require.True(t, cfg.InsecureSkipVerify)
+		require.NotNil(t, cfg.RootCAs)
+
+		cfg, err = webexTLSConfig(&NotificationChannelConfig{Settings: simplejson.New()}, decryptFn)
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("The client certificate authenticates the requests", func(t *testing.T) {
+		decryptFn := func(_ context.Context, _ map[string][]byte, _ string, fallback string) string { return fallback }
+		_, certPEM, keyPEM := newClientCertificate(t, "grafana")
+		cfg, err := webexTLSConfig(&NotificationChannelConfig{
+			Settings: simplejson.NewFromAny(map[string]interface{}{"tlsClientCert": certPEM, "tlsClientKey": keyPEM}),
+		}, decryptFn)
+		require.NoError(t, err)
+		require.Len(t, cfg.Certificates, 1)
+		require.False(t, cfg.InsecureSkipVerify)
+	})
+}
+
+func TestWebexNotifier(t *testing.T) {
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	newAlert := func(labels model.LabelSet) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:       labels,
+				Annotations:  model.LabelSet{"ann1": "annv1"},
+				GeneratorURL: "http://localhost/alerting/grafana/rule-uid/view",
+			},
+		}
+	}
+
+	t.Run("Webhook with the default message", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}, newFakeImageStore(1), ns)
+		require.NoError(t, err)
+
+		alert := newAlert(model.LabelSet{"alertname": "alert1", "lbl1": "val1"})
+		alert.Annotations["__alertImageToken__"] = "test-image-1"
+		ok, err := wn.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, ns.Webhooks, 1)
+		require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/abcd", ns.Webhooks[0].Url)
+		require.Empty(t, ns.Webhooks[0].HttpHeader)
+		require.Equal(t, []map[string]interface{}{{
+			"markdown": "**[FIRING:1]  (val1)**\n\n**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: http://localhost/alerting/grafana/rule-uid/view\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1",
+			"files":    []interface{}{"https://www.example.com/test-image-1.jpg"},
+		}}, webexBodies(t, ns))
+	})
+
+	t.Run("Webhook routes by the labels of the alerts", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		routes := "# The war room\nseverity=critical => https://webexapis.com/v1/webhooks/incoming/war-room\n\nteam=~\"db|storage\" => https://webexapis.com/v1/webhooks/incoming/storage\n"
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"routes": routes, "message": "{{ range .Alerts }}{{ .Labels.instance }} {{ end }}"}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx,
+			newAlert(model.LabelSet{"instance": "db-1", "severity": "critical", "team": "db"}),
+			newAlert(model.LabelSet{"instance": "db-2", "team": "db"}),
+			newAlert(model.LabelSet{"instance": "web-1", "team": "web"}),
+			newAlert(model.LabelSet{"instance": "db-3", "severity": "critical"}),
+		)
+		require.NoError(t, err)
+
+		// The alerts without route are not sent without webhook URL.
+		require.Len(t, ns.Webhooks, 2)
+		require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/war-room", ns.Webhooks[0].Url)
+		require.True(t, strings.HasSuffix(webexBodies(t, ns)[0]["markdown"].(string), "db-1 db-3"))
+		require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/storage", ns.Webhooks[1].Url)
+		require.True(t, strings.HasSuffix(webexBodies(t, ns)[1]["markdown"].(string), "db-2"))
+	})
+
+	t.Run("Webhook URL receives the alerts without route", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{
+			"url":    "https://webexapis.com/v1/webhooks/incoming/team",
+			"routes": "severity=critical => https://webexapis.com/v1/webhooks/incoming/war-room",
+		}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1", "severity": "warning"}))
+		require.NoError(t, err)
+
+		require.Len(t, ns.Webhooks, 1)
+		require.Equal(t, "https://webexapis.com/v1/webhooks/incoming/team", ns.Webhooks[0].Url)
+	})
+
+	t.Run("Webhook is sent with the TLS settings", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{
+			"url":           "https://webexapis.com/v1/webhooks/incoming/abcd",
+			"tlsSkipVerify": true,
+		}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.NoError(t, err)
+
+		require.Len(t, ns.Webhooks, 1)
+		require.True(t, ns.Webhooks[0].TLSConfig.InsecureSkipVerify)
+	})
+
+	t.Run("Webhook uses the defaults without TLS settings", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.NoError(t, err)
+
+		require.Len(t, ns.Webhooks, 1)
+		require.Nil(t, ns.Webhooks[0].TLSConfig)
+	})
+
+	t.Run("Bot sends the alerts to their rooms and mentions the owners of the firing alerts", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{
+			"botToken":      "token",
+			"roomId":        "{{ .CommonLabels.room }}",
+			"toPersonEmail": "oncall@example.com",
+			"title":         "{{ len .Alerts }} alerts",
+			"message":       "{{ range .Alerts }}{{ .Labels.instance }} {{ end }}",
+			"mentionEmails": "{{ .CommonLabels.owner }}, lead@example.com",
+		}, nil, ns)
+		require.NoError(t, err)
+
+		resolved := newAlert(model.LabelSet{"instance": "db-3", "room": "room-a", "owner": "carol@example.com"})
+		resolved.EndsAt = resolved.StartsAt.Add(1)
+		_, err = wn.Notify(ctx,
+			newAlert(model.LabelSet{"instance": "db-1", "room": "room-a", "owner": "alice@example.com"}),
+			newAlert(model.LabelSet{"instance": "db-2", "room": "room-b", "owner": "bob@example.com"}),
+			resolved,
+			newAlert(model.LabelSet{"instance": "db-4", "owner": "alice@example.com"}),
+		)
+		require.NoError(t, err)
+
+		require.Len(t, ns.Webhooks, 3)
+		for _, req := range ns.Webhooks {
+			require.Equal(t, "https://webexapis.com/v1/messages", req.Url)
+			require.Equal(t, "Bearer token", req.HttpHeader["Authorization"])
+			require.Equal(t, "application/json", req.ContentType)
+		}
+		require.Equal(t, []map[string]interface{}{
+			{"roomId": "room-a", "markdown": "**2 alerts**\n\ndb-1 db-3\n\n<@personEmail:alice@example.com> <@personEmail:lead@example.com>"},
+			{"roomId": "room-b", "markdown": "**1 alerts**\n\ndb-2\n\n<@personEmail:bob@example.com> <@personEmail:lead@example.com>"},
+			{"toPersonEmail": "oncall@example.com", "markdown": "**1 alerts**\n\ndb-4\n\n<@personEmail:alice@example.com> <@personEmail:lead@example.com>"},
+		}, webexBodies(t, ns))
+
+		// The messages are rate limited per room and per person.
+		for _, key := range []string{"room:room-a", "room:room-b", "person:oncall@example.com"} {
+			require.Contains(t, webexRateLimiter.limiters, key)
+		}
+	})
+
+	t.Run("Bot fails without room ID and person email", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"botToken": "token", "roomId": "{{ .CommonLabels.room }}"}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.EqualError(t, err, `the room ID and the person email of alert "alert1" are empty`)
+		require.Empty(t, ns.Webhooks)
+	})
+
+	t.Run("Bot uploads the images without URL", func(t *testing.T) {
+		images := newFakeImageStoreWithFile(t, 1).(*fakeImageStore)
+		images.Images[0].URL = ""
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"botToken": "token", "roomId": "room", "title": "title", "message": "message"}, images, ns)
+		require.NoError(t, err)
+
+		alert := newAlert(model.LabelSet{"alertname": "alert1"})
+		alert.Annotations["__alertImageToken__"] = "test-image-1"
+		_, err = wn.Notify(ctx, alert)
+		require.NoError(t, err)
+
+		require.Len(t, ns.Webhooks, 1)
+		mediaType, params, err := mime.ParseMediaType(ns.Webhooks[0].ContentType)
+		require.NoError(t, err)
+		require.Equal(t, "multipart/form-data", mediaType)
+		form, err := multipart.NewReader(strings.NewReader(ns.Webhooks[0].Body), params["boundary"]).ReadForm(1 << 20)
+		require.NoError(t, err)
+		require.Equal(t, []string{"room"}, form.Value["roomId"])
+		require.Equal(t, []string{"**title**\n\nmessage"}, form.Value["markdown"])
+		require.Len(t, form.File["files"], 1)
+	})
+
+	t.Run("Webhook does not upload the images without URL", func(t *testing.T) {
+		images := newFakeImageStoreWithFile(t, 1).(*fakeImageStore)
+		images.Images[0].URL = ""
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "title": "title", "message": "message"}, images, ns)
+		require.NoError(t, err)
+
+		alert := newAlert(model.LabelSet{"alertname": "alert1"})
+		alert.Annotations["__alertImageToken__"] = "test-image-1"
+		_, err = wn.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"markdown": "**title**\n\nmessage"}}, webexBodies(t, ns))
+	})
+
+	t.Run("Bot sends the default Adaptive Card", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"botToken": "token", "roomId": "room", "messageFormat": "adaptiveCard", "title": "title", "message": "message"}, newFakeImageStore(1), ns)
+		require.NoError(t, err)
+
+		alert := newAlert(model.LabelSet{"alertname": "alert1"})
+		alert.Annotations["__alertImageToken__"] = "test-image-1"
+		_, err = wn.Notify(ctx, alert)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `{
+			"roomId": "room",
+			"markdown": "**title**\n\nmessage",
+			"attachments": [{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": {
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type": "AdaptiveCard",
+					"version": "1.3",
+					"body": [{
+						"type": "ColumnSet",
+						"columns": [
+							{"type": "Column", "width": "8px", "style": "attention", "bleed": true, "items": []},
+							{"type": "Column", "width": "stretch", "items": [
+								{"type": "TextBlock", "text": "title", "size": "medium", "weight": "bolder", "wrap": true},
+								{"type": "TextBlock", "text": "message", "wrap": true},
+								{"type": "FactSet", "facts": [{"title": "alertname", "value": "alert1"}]},
+								{"type": "Image", "url": "https://www.example.com/test-image-1.jpg", "altText": "Panel image"}
+							]}
+						]
+					}],
+					"actions": [
+						{"type": "Action.OpenUrl", "title": "View rule", "url": "http://localhost/alerting/grafana/rule-uid/view"},
+						{"type": "Action.OpenUrl", "title": "Silence", "url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1"}
+					]
+				}
+			}]
+		}`, ns.Webhooks[0].Body)
+	})
+
+	t.Run("Bot renders the card template", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{
+			"botToken":      "token",
+			"roomId":        "room",
+			"messageFormat": "adaptiveCard",
+			"cardTemplate":  `{"type": "AdaptiveCard", "version": "1.3", "body": [{"type": "TextBlock", "text": {{ .CommonLabels.summary | json }}}]}`,
+		}, nil, ns)
+		require.NoError(t, err)
+
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"summary": `"quoted"`}))
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"type": "AdaptiveCard", "version": "1.3", "body": []interface{}{map[string]interface{}{"type": "TextBlock", "text": `"quoted"`}},
+		}, webexBodies(t, ns)[0]["attachments"].([]interface{})[0].(map[string]interface{})["content"])
+
+		// The cards that are not valid JSON are not sent.
+		wn.CardTemplate = `{"text": {{ .CommonLabels.summary }}}`
+		_, err = wn.Notify(ctx, newAlert(model.LabelSet{"summary": "not quoted"}))
+		require.ErrorContains(t, err, "the card template did not render valid JSON")
+		require.Len(t, ns.Webhooks, 1)
+	})
+
+	// The rate-limited messages are retried by the retry policy of the contact point.
+	newRetryingWebexNotifier := func(t *testing.T, settings map[string]interface{}, sender *webexRateLimitedSender) *WebexNotifier {
+		t.Helper()
+		settings["retryInitialBackoff"] = "1ms"
+		policy, err := webexRetryPolicy(simplejson.NewFromAny(settings))
+		require.NoError(t, err)
+		wn, err := newWebexNotifierForTests(t, settings, nil, &retryingNotificationService{Service: sender, policy: policy, integrationType: "webex", log: log.New("test")})
+		require.NoError(t, err)
+		return wn
+	}
+
+	t.Run("Rate-limited messages are sent again after their Retry-After delay", func(t *testing.T) {
+		ns := &webexRateLimitedSender{responses: []int{http.StatusTooManyRequests, http.StatusOK}}
+		wn := newRetryingWebexNotifier(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}, ns)
+
+		ok, err := wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 2, ns.attempts)
+	})
+
+	t.Run("Rate-limited messages fail after the max retries", func(t *testing.T) {
+		ns := &webexRateLimitedSender{responses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}}
+		wn := newRetryingWebexNotifier(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd", "maxRetries": 1}, ns)
+
+		_, err := wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.EqualError(t, err, "the Webex API returned status 429: rate limited")
+		require.Equal(t, 2, ns.attempts)
+	})
+
+	t.Run("Other errors are not retried", func(t *testing.T) {
+		ns := &webexRateLimitedSender{responses: []int{http.StatusBadRequest, http.StatusOK}}
+		wn := newRetryingWebexNotifier(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}, ns)
+
+		_, err := wn.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.Error(t, err)
+		require.Equal(t, 1, ns.attempts)
+	})
+
+	t.Run("A failed destination sends the notification again to all the destinations", func(t *testing.T) {
+		ns := &webexRateLimitedSender{responses: []int{http.StatusOK, http.StatusBadRequest, http.StatusOK, http.StatusOK}}
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{
+			"routes": "team=a => https://webexapis.com/v1/webhooks/incoming/a\nteam=b => https://webexapis.com/v1/webhooks/incoming/b",
+		}, nil, ns)
+		require.NoError(t, err)
+		alerts := []*types.Alert{
+			newAlert(model.LabelSet{"alertname": "alert1", "team": "a"}),
+			newAlert(model.LabelSet{"alertname": "alert1", "team": "b"}),
+		}
+
+		_, err = wn.Notify(ctx, alerts...)
+		require.Error(t, err)
+		require.Equal(t, 2, ns.attempts)
+
+		// The next flush sends the message to the first destination again.
+		_, err = wn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.Equal(t, 4, ns.attempts)
+	})
+
+	t.Run("Messages over the length limit show the alerts that fit", func(t *testing.T) {
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"url": "https://webexapis.com/v1/webhooks/incoming/abcd"}, nil, ns)
+		require.NoError(t, err)
+
+		var alerts []*types.Alert
+		for i := 0; i < 100; i++ {
+			alerts = append(alerts, newAlert(model.LabelSet{"alertname": "alert1", "instance": model.LabelValue(fmt.Sprintf("instance-%03d", i))}))
+		}
+		_, err = wn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+
+		markdown := webexBodies(t, ns)[0]["markdown"].(string)
+		require.LessOrEqual(t, utf8.RuneCountInString(markdown), 7439)
+		require.Contains(t, markdown, "instance-000")
+		require.NotContains(t, markdown, "instance-099")
+		require.Regexp(t, `Showing \d+ of 100 alerts`, markdown)
+	})
+}
+
+func TestWebexRetryPolicy(t *testing.T) {
+	policy := func(settings map[string]interface{}) RetryPolicy {
+		t.Helper()
+		p, err := webexRetryPolicy(simplejson.NewFromAny(settings))
+		require.NoError(t, err)
+		return p
+	}
+
+	p := policy(map[string]interface{}{})
+	require.Equal(t, 4, p.MaxAttempts)
+	require.Equal(t, []int{http.StatusTooManyRequests}, p.StatusCodes)
+
+	require.Equal(t, 1, policy(map[string]interface{}{"maxRetries": "0"}).MaxAttempts)
+	require.Equal(t, 3, policy(map[string]interface{}{"maxRetries": 2}).MaxAttempts)
+
+	// The retries of the contact point take precedence.
+	p = policy(map[string]interface{}{"maxRetries": 2, "retryMaxAttempts": 5})
+	require.Equal(t, 5, p.MaxAttempts)
+	require.Equal(t, DefaultRetryPolicy.StatusCodes, p.StatusCodes)
+
+	_, err := webexRetryPolicy(simplejson.NewFromAny(map[string]interface{}{"maxRetries": "many"}))
+	require.EqualError(t, err, `invalid max retries "many", must be a number`)
+}
+
+func TestWebexValidation(t *testing.T) {
+	require.NoError(t, webexValidation([]byte(`{"id": "msg"}`), 200))
+	require.EqualError(t, webexValidation([]byte(`{"message": "The requested resource could not be found."}`), 404), "the Webex API returned status 404: The requested resource could not be found.")
+	require.EqualError(t, webexValidation([]byte("Bad Gateway"), 502), "the Webex API returned status 502")
+}
@@ -0,0 +1,124 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// webexStateTTL is how long the threads and the status messages of a contact point are kept after
+// they are last updated, so that the ones of the groups that never resolve do not accumulate.
+const webexStateTTL = 7 * 24 * time.Hour
+
+// loadWebexState unmarshals the state of a contact point from its key in the key-value store. It
+// leaves v unchanged if the key is not set.
+func loadWebexState(ctx context.Context, kv KVStore, key string, v interface{}) error {
+	value, ok, err := kv.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return nil
+}
+
+// saveWebexState marshals the state of a contact point to its key in the key-value store, or
+// deletes the key if the state is empty.
+func saveWebexState(ctx context.Context, kv KVStore, key string, v interface{}, empty bool) error {
+	if empty {
+		return kv.Del(ctx, key)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return kv.Set(ctx, key, string(b))
+}
+
+// webexThread is the first message sent to a room or a person for a firing group.
+type webexThread struct {
+	MessageID string    `json:"messageId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// webexThreads holds the threads of a contact point, so that the resolved notifications of a group
+// are sent as replies to its firing notification. They are persisted in a single key of the
+// key-value store, as the notifiers are created again each time the configuration is applied.
+type webexThreads struct {
+	kv  KVStore
+	key string
+	now func() time.Time
+
+	mtx sync.Mutex
+}
+
+func newWebexThreads(kv KVStore, uid string) *webexThreads {
+	return &webexThreads{
+		kv:  kv,
+		key: "webex_threads." + uid,
+		now: time.Now,
+	}
+}
+
+// webexThreadKey returns the key of the thread of the group in the room or with the person.
+func webexThreadKey(groupKey, roomID, toPersonEmail string) string {
+	sum := sha256.Sum256([]byte(groupKey + "\n" + roomID + "\n" + toPersonEmail))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the ID of the message of the thread, or an empty string if there is no thread.
+func (t *webexThreads) get(ctx context.Context, key string) (string, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	threads, err := t.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return threads[key].MessageID, nil
+}
+
+// set starts the thread with the message.
+func (t *webexThreads) set(ctx context.Context, key, messageID string) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	threads, err := t.load(ctx)
+	if err != nil {
+		return err
+	}
+	threads[key] = webexThread{MessageID: messageID, CreatedAt: t.now()}
+	return saveWebexState(ctx, t.kv, t.key, threads, false)
+}
+
+// delete removes the thread, when its group is resolved.
+func (t *webexThreads) delete(ctx context.Context, key string) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	threads, err := t.load(ctx)
+	if err != nil {
+		return err
+	}
+	delete(threads, key)
+	return saveWebexState(ctx, t.kv, t.key, threads, len(threads) == 0)
+}
+
+// load returns the threads that have not expired.
+func (t *webexThreads) load(ctx context.Context) (map[string]webexThread, error) {
+	threads := map[string]webexThread{}
+	if err := loadWebexState(ctx, t.kv, t.key, &threads); err != nil {
+		return nil, err
+	}
+	for key, thread := range threads {
+		if t.now().Sub(thread.CreatedAt) > webexStateTTL {
+			delete(threads, key)
+		}
+	}
+	return threads, nil
+}
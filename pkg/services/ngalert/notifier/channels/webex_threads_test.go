@@ -0,0 +1,122 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKVStore struct {
+	values map[string]string
+}
+
+func newFakeKVStore() *fakeKVStore {
+	return &fakeKVStore{values: map[string]string{}}
+}
+
+func (kv *fakeKVStore) Get(_ context.Context, key string) (string, bool, error) {
+	v, ok := kv.values[key]
+	return v, ok, nil
+}
+
+func (kv *fakeKVStore) Set(_ context.Context, key, value string) error {
+	kv.values[key] = value
+	return nil
+}
+
+func (kv *fakeKVStore) Del(_ context.Context, key string) error {
+	delete(kv.values, key)
+	return nil
+}
+
+func TestWebexThreads(t *testing.T) {
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	newAlert := func(labels model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: labels}}
+	}
+	newNotifier := func(t *testing.T, kv KVStore, uid string) (*WebexNotifier, *notificationServiceMock) {
+		t.Helper()
+		ns := mockWebexNotificationService()
+		wn, err := newWebexNotifierForTests(t, map[string]interface{}{"botToken": "token", "roomId": "room", "title": "{{ .Status }}", "message": ""}, nil, ns)
+		require.NoError(t, err)
+		wn.threads = newWebexThreads(kv, uid)
+		return wn, ns
+	}
+
+	t.Run("Resolved notification replies to the first firing notification of the group", func(t *testing.T) {
+		kv := newFakeKVStore()
+		wn, ns := newNotifier(t, kv, "uid")
+
+		firing := newAlert(model.LabelSet{"alertname": "alert1"})
+		_, err := wn.Notify(ctx, firing)
+		require.NoError(t, err)
+		_, err = wn.Notify(ctx, firing)
+		require.NoError(t, err)
+		resolved := newAlert(model.LabelSet{"alertname": "alert1"})
+		resolved.EndsAt = resolved.StartsAt.Add(1)
+		_, err = wn.Notify(ctx, resolved)
+		require.NoError(t, err)
+
+		require.Equal(t, []map[string]interface{}{
+			{"roomId": "room", "markdown": "**firing**"},
+			{"roomId": "room", "markdown": "**firing**"},
+			{"roomId": "room", "parentId": "msg-1", "markdown": "**resolved**"},
+		}, webexBodies(t, ns))
+		require.Empty(t, kv.values, "the thread must be deleted when the group is resolved")
+	})
+
+	t.Run("Threads are not shared by the contact points", func(t *testing.T) {
+		kv := newFakeKVStore()
+		wn1, _ := newNotifier(t, kv, "uid1")
+		_, err := wn1.Notify(ctx, newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.NoError(t, err)
+		require.Contains(t, kv.values, "webex_threads.uid1")
+
+		wn2, ns2 := newNotifier(t, kv, "uid2")
+		resolved := newAlert(model.LabelSet{"alertname": "alert1"})
+		resolved.EndsAt = resolved.StartsAt.Add(1)
+		_, err = wn2.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.NotContains(t, webexBodies(t, ns2)[0], "parentId")
+		require.Contains(t, kv.values, "webex_threads.uid1")
+	})
+
+	t.Run("Threads expire after the TTL", func(t *testing.T) {
+		kv := newFakeKVStore()
+		threads := newWebexThreads(kv, "uid")
+		now := time.Now()
+		threads.now = func() time.Time { return now }
+		require.NoError(t, threads.set(ctx, "old", "msg-1"))
+
+		now = now.Add(webexStateTTL / 2)
+		require.NoError(t, threads.set(ctx, "new", "msg-2"))
+		id, err := threads.get(ctx, "old")
+		require.NoError(t, err)
+		require.Equal(t, "msg-1", id)
+
+		now = now.Add(webexStateTTL/2 + time.Minute)
+		id, err = threads.get(ctx, "old")
+		require.NoError(t, err)
+		require.Empty(t, id)
+		id, err = threads.get(ctx, "new")
+		require.NoError(t, err)
+		require.Equal(t, "msg-2", id)
+
+		// The expired threads are removed from the store when it is written.
+		require.NoError(t, threads.delete(ctx, "new"))
+		require.Empty(t, kv.values)
+	})
+
+	t.Run("Without group key the notifications are not threaded", func(t *testing.T) {
+		kv := newFakeKVStore()
+		wn, _ := newNotifier(t, kv, "uid")
+		_, err := wn.Notify(context.Background(), newAlert(model.LabelSet{"alertname": "alert1"}))
+		require.NoError(t, err)
+		require.Empty(t, kv.values)
+	})
+}
@@ -2,6 +2,7 @@ package channels
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,6 +36,9 @@ type WebhookNotifier struct {
 
 	AuthorizationScheme      string
 	AuthorizationCredentials string
+
+	TLSConfig *tls.Config
+	oauth2    *webhookOAuth2
 }
 
 type WebhookConfig struct {
@@ -49,6 +53,10 @@ type WebhookConfig struct {
 	// HTTP Basic Authentication.
 	User     string
 	Password string
+	// TLSConfig is the configuration of mutual TLS, nil unless set.
+	TLSConfig *tls.Config
+	// oauth2 authenticates the requests with the OAuth2 client credentials, nil unless set.
+	oauth2 *webhookOAuth2
 }
 
 func WebHookFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -67,7 +75,12 @@ func NewWebHookConfig(config *NotificationChannelConfig, decryptFunc GetDecrypte
 	if url == "" {
 		return nil, errors.New("could not find url property in settings")
 	}
+	return newWebhookConfig(config, decryptFunc, url)
+}
 
+// newWebhookConfig returns the configuration of a webhook to url, with the other settings of the
+// webhook.
+func newWebhookConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn, url string) (*WebhookConfig, error) {
 	user := config.Settings.Get("username").MustString()
 	password := decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString())
 	authorizationScheme := config.Settings.Get("authorization_scheme").MustString("Bearer")
@@ -76,6 +89,17 @@ func NewWebHookConfig(config *NotificationChannelConfig, decryptFunc GetDecrypte
 	if user != "" && password != "" && authorizationScheme != "" && authorizationCredentials != "" {
 		return nil, errors.New("both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted")
 	}
+	tlsConfig, err := clientTLSFromSettings(config, decryptFunc)
+	if err != nil {
+		return nil, err
+	}
+	oauth2, err := webhookOAuth2FromSettings(config, decryptFunc)
+	if err != nil {
+		return nil, err
+	}
+	if oauth2 != nil && ((user != "" && password != "") || authorizationCredentials != "") {
+		return nil, errors.New("OAuth2 cannot be set with HTTP Basic Authentication or Authorization Header")
+	}
 
 	return &WebhookConfig{
 		NotificationChannelConfig: config,
@@ -86,6 +110,8 @@ func NewWebHookConfig(config *NotificationChannelConfig, decryptFunc GetDecrypte
 		AuthorizationCredentials:  authorizationCredentials,
 		HTTPMethod:                config.Settings.Get("httpMethod").MustString("POST"),
 		MaxAlerts:                 config.Settings.Get("maxAlerts").MustInt(0),
+		TLSConfig:                 tlsConfig,
+		oauth2:                    oauth2,
 	}, nil
 }
 
@@ -108,6 +134,8 @@ func NewWebHookNotifier(config *WebhookConfig, ns notifications.WebhookSender, i
 		AuthorizationCredentials: config.AuthorizationCredentials,
 		HTTPMethod:               config.HTTPMethod,
 		MaxAlerts:                config.MaxAlerts,
+		TLSConfig:                config.TLSConfig,
+		oauth2:                   config.oauth2,
 		log:                      log.New("alerting.notifier.webhook"),
 		ns:                       ns,
 		images:                   images,
@@ -153,14 +181,17 @@ func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 		},
 		as...)
 
+	data.setPayloadVersion(ctx, wn.PayloadVersion(), wn.orgID)
+	limits := wn.FieldLimits()
+	limits.ApplyToData(data)
 	msg := &webhookMessage{
-		Version:         "1",
+		Version:         wn.PayloadVersion(),
 		ExtendedData:    data,
 		GroupKey:        groupKey.String(),
 		TruncatedAlerts: numTruncated,
 		OrgID:           wn.orgID,
 		Title:           tmpl(DefaultMessageTitleEmbed),
-		Message:         tmpl(`{{ template "default.message" . }}`),
+		Message:         limits.TruncateMessage(tmpl(`{{ template "default.message" . }}`)),
 	}
 	if types.Alerts(as...).Status() == model.AlertFiring {
 		msg.State = string(models.AlertStateAlerting)
@@ -189,8 +220,15 @@ func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 		Body:       string(body),
 		HttpMethod: wn.HTTPMethod,
 		HttpHeader: headers,
+		TLSConfig:  wn.TLSConfig,
 	}
 
+	if wn.oauth2 != nil {
+		if err := wn.oauth2.sendWebhook(ctx, wn.ns, cmd); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 	if err := wn.ns.SendWebhookSync(ctx, cmd); err != nil {
 		return false, err
 	}
@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The webhook settings of the OAuth2 client credentials grant.
+	oauth2TokenURLSetting     = "oauth2TokenUrl"
+	oauth2ClientIDSetting     = "oauth2ClientId"
+	oauth2ClientSecretSetting = "oauth2ClientSecret"
+	oauth2ScopesSetting       = "oauth2Scopes"
+)
+
+// webhookOAuth2 authenticates the requests of a webhook with the access tokens of the OAuth2 client
+// credentials grant, such as those of Azure AD or Okta. The token is cached until it expires, or
+// until the webhook rejects it.
+type webhookOAuth2 struct {
+	config clientcredentials.Config
+	client *http.Client
+
+	mtx   sync.Mutex
+	token *oauth2.Token
+}
+
+// webhookOAuth2FromSettings returns the OAuth2 client of a webhook, or nil if the webhook does not
+// set a token URL. The token is requested through the egress proxy of the contact point.
+func webhookOAuth2FromSettings(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*webhookOAuth2, error) {
+	tokenURL := strings.TrimSpace(config.Settings.Get(oauth2TokenURLSetting).MustString())
+	clientID := strings.TrimSpace(config.Settings.Get(oauth2ClientIDSetting).MustString())
+	clientSecret := decryptFunc(context.Background(), config.SecureSettings, oauth2ClientSecretSetting, config.Settings.Get(oauth2ClientSecretSetting).MustString())
+	if tokenURL == "" {
+		if clientID != "" || clientSecret != "" {
+			return nil, fmt.Errorf("%s is required with the OAuth2 client credentials", oauth2TokenURLSetting)
+		}
+		return nil, nil
+	}
+	if u, err := url.Parse(tokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q, must be an http or https URL", oauth2TokenURLSetting, tokenURL)
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, errors.New("both the OAuth2 client ID and client secret are required")
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+	if config.Proxy != nil {
+		transport.Proxy = config.Proxy.ProxyFunc()
+	}
+	return &webhookOAuth2{
+		config: clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     tokenURL,
+			Scopes: strings.FieldsFunc(config.Settings.Get(oauth2ScopesSetting).MustString(), func(r rune) bool {
+				return r == ',' || r == ' '
+			}),
+		},
+		client: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+// Token returns the cached access token, or requests a new one if it expired or was invalidated.
+func (o *webhookOAuth2) Token(ctx context.Context) (*oauth2.Token, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.token.Valid() {
+		return o.token, nil
+	}
+	token, err := o.config.Token(context.WithValue(ctx, oauth2.HTTPClient, o.client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth2 token: %w", err)
+	}
+	o.token = token
+	return token, nil
+}
+
+// invalidate drops the token rejected by the webhook, unless it was already replaced.
+func (o *webhookOAuth2) invalidate(token *oauth2.Token) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.token == token {
+		o.token = nil
+	}
+}
+
+// sendWebhook sends the webhook with the access token as bearer token. The webhook is sent again
+// once with a new token if it is rejected with 401 Unauthorized, such as after the token was
+// revoked. No token is requested in dry runs, in which the webhook is recorded instead of sent.
+func (o *webhookOAuth2) sendWebhook(ctx context.Context, ns notifications.WebhookSender, cmd *models.SendWebhookSync) error {
+	if _, ok := dryRunFromContext(ctx); ok {
+		return ns.SendWebhookSync(ctx, cmd)
+	}
+	for attempt := 1; ; attempt++ {
+		token, err := o.Token(ctx)
+		if err != nil {
+			return err
+		}
+		statusCode := 0
+		attemptCmd := *cmd
+		attemptCmd.HttpHeader = make(map[string]string, len(cmd.HttpHeader)+1)
+		for k, v := range cmd.HttpHeader {
+			attemptCmd.HttpHeader[k] = v
+		}
+		attemptCmd.HttpHeader["Authorization"] = "Bearer " + token.AccessToken
+		attemptCmd.Validation = func(body []byte, code int) error {
+			statusCode = code
+			if cmd.Validation != nil {
+				return cmd.Validation(body, code)
+			}
+			return nil
+		}
+
+		err = ns.SendWebhookSync(ctx, &attemptCmd)
+		if err == nil || statusCode != http.StatusUnauthorized || attempt > 1 {
+			return err
+		}
+		o.invalidate(token)
+	}
+}
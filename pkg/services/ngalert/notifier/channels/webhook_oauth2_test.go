@@ -0,0 +1,168 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// webhookSenderFunc is a webhook sender that calls the validation of the webhooks with the status
+// code it returns.
+type webhookSenderFunc func(cmd *models.SendWebhookSync) int
+
+func (f webhookSenderFunc) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	code := f(cmd)
+	if cmd.Validation != nil {
+		if err := cmd.Validation(nil, code); err != nil {
+			return err
+		}
+	}
+	if code/100 != 2 {
+		return fmt.Errorf("webhook response status %d %s", code, http.StatusText(code))
+	}
+	return nil
+}
+
+func TestWebhookOAuth2(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, password, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || user != "grafana" || password != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "api://alerts/.default", r.Form.Get("scope"))
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, n)
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	newConfig := func(settings map[string]interface{}) *NotificationChannelConfig {
+		return &NotificationChannelConfig{Name: "webhook_testing", Type: "webhook", Settings: simplejson.NewFromAny(settings)}
+	}
+	settings := map[string]interface{}{
+		"url":                "http://localhost/test",
+		"oauth2TokenUrl":     tokenServer.URL,
+		"oauth2ClientId":     "grafana",
+		"oauth2ClientSecret": "s3cr3t",
+		"oauth2Scopes":       "api://alerts/.default",
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("the token is cached and renewed once the webhook rejects it", func(t *testing.T) {
+		cfg, err := NewWebHookConfig(newConfig(settings), secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		var authorizations []string
+		revoked := ""
+		n := NewWebHookNotifier(cfg, webhookSenderFunc(func(cmd *models.SendWebhookSync) int {
+			authorizations = append(authorizations, cmd.HttpHeader["Authorization"])
+			if cmd.HttpHeader["Authorization"] == revoked {
+				return http.StatusUnauthorized
+			}
+			return http.StatusOK
+		}), &UnavailableImageStore{}, tmpl)
+
+		ok, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, authorizations)
+
+		revoked = "Bearer token-1"
+		ok, err = n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1", "Bearer token-2"}, authorizations)
+		require.Equal(t, int32(2), atomic.LoadInt32(&issued))
+	})
+
+	t.Run("the webhook fails if the new token is rejected too", func(t *testing.T) {
+		cfg, err := NewWebHookConfig(newConfig(settings), secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		calls := 0
+		n := NewWebHookNotifier(cfg, webhookSenderFunc(func(cmd *models.SendWebhookSync) int {
+			calls++
+			return http.StatusUnauthorized
+		}), &UnavailableImageStore{}, tmpl)
+		_, err = n.Notify(ctx, alert)
+		require.EqualError(t, err, "webhook response status 401 Unauthorized")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("the webhook fails if the token cannot be requested", func(t *testing.T) {
+		invalid := map[string]interface{}{}
+		for k, v := range settings {
+			invalid[k] = v
+		}
+		invalid["oauth2ClientSecret"] = "wrong"
+		cfg, err := NewWebHookConfig(newConfig(invalid), secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		n := NewWebHookNotifier(cfg, webhookSenderFunc(func(cmd *models.SendWebhookSync) int {
+			require.Fail(t, "the webhook must not be sent without token")
+			return http.StatusOK
+		}), &UnavailableImageStore{}, tmpl)
+		_, err = n.Notify(ctx, alert)
+		require.ErrorContains(t, err, "failed to get OAuth2 token")
+	})
+
+	t.Run("no token is requested in dry runs", func(t *testing.T) {
+		before := atomic.LoadInt32(&issued)
+		ns := mockNotificationService()
+		fc, err := NewFactoryConfig(newConfig(settings), ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.NoError(t, err)
+		n, err := WebHookFactory(fc)
+		require.NoError(t, err)
+
+		d := &DryRun{}
+		ok, err := n.Notify(WithDryRun(ctx, d), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, ns.Webhook.Url)
+		require.Len(t, d.Requests(), 1)
+		require.Equal(t, "http://localhost", d.Requests()[0].Target)
+		require.Equal(t, before, atomic.LoadInt32(&issued))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		for settings, expErr := range map[string]string{
+			`{"url": "http://localhost/test", "oauth2ClientId": "grafana"}`:                                                                                                                            `oauth2TokenUrl is required with the OAuth2 client credentials`,
+			`{"url": "http://localhost/test", "oauth2TokenUrl": "login.example.com/token"}`:                                                                                                            `invalid oauth2TokenUrl "login.example.com/token", must be an http or https URL`,
+			`{"url": "http://localhost/test", "oauth2TokenUrl": "https://login.example.com/token", "oauth2ClientId": "grafana"}`:                                                                       `both the OAuth2 client ID and client secret are required`,
+			`{"url": "http://localhost/test", "oauth2TokenUrl": "https://login.example.com/token", "oauth2ClientId": "grafana", "oauth2ClientSecret": "s3cr3t", "authorization_credentials": "token"}`: `OAuth2 cannot be set with HTTP Basic Authentication or Authorization Header`,
+		} {
+			s, err := simplejson.NewJson([]byte(settings))
+			require.NoError(t, err)
+			_, err = NewWebHookConfig(&NotificationChannelConfig{Type: "webhook", Settings: s}, secretsService.GetDecryptedValue)
+			require.EqualError(t, err, expErr, settings)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package channels
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// The webhook settings of the HMAC signature of the requests.
+	hmacSecretSetting          = "hmacSecret"
+	hmacAlgorithmSetting       = "hmacAlgorithm"
+	hmacHeaderSetting          = "hmacHeader"
+	hmacTimestampHeaderSetting = "hmacTimestampHeader"
+
+	defaultHMACAlgorithm       = "sha256"
+	defaultHMACHeader          = "X-Grafana-Signature"
+	defaultHMACTimestampHeader = "X-Grafana-Signature-Timestamp"
+)
+
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// webhookSigner signs the requests of a webhook with a secret shared with the receiver, which
+// authenticates that the payloads come from Grafana.
+type webhookSigner struct {
+	secret          []byte
+	algorithm       string
+	hash            func() hash.Hash
+	header          string
+	timestampHeader string
+}
+
+// webhookSignerFromSettings returns the signer of the webhooks of a contact point, or nil if the
+// contact point has no secret.
+func webhookSignerFromSettings(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*webhookSigner, error) {
+	secret := config.Settings.Get(hmacSecretSetting).MustString()
+	if decryptFunc != nil {
+		secret = decryptFunc(context.Background(), config.SecureSettings, hmacSecretSetting, secret)
+	}
+	if secret == "" {
+		return nil, nil
+	}
+	algorithm := strings.ToLower(strings.TrimSpace(config.Settings.Get(hmacAlgorithmSetting).MustString(defaultHMACAlgorithm)))
+	h, ok := hmacAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q, must be sha256 or sha512", hmacAlgorithmSetting, algorithm)
+	}
+	s := &webhookSigner{
+		secret:          []byte(secret),
+		algorithm:       algorithm,
+		hash:            h,
+		header:          strings.TrimSpace(config.Settings.Get(hmacHeaderSetting).MustString(defaultHMACHeader)),
+		timestampHeader: strings.TrimSpace(config.Settings.Get(hmacTimestampHeaderSetting).MustString(defaultHMACTimestampHeader)),
+	}
+	for _, header := range []string{s.header, s.timestampHeader} {
+		if !httpguts.ValidHeaderFieldName(header) {
+			return nil, fmt.Errorf("invalid HMAC header name %q", header)
+		}
+	}
+	if strings.EqualFold(s.header, s.timestampHeader) {
+		return nil, fmt.Errorf("%s and %s must be different", hmacHeaderSetting, hmacTimestampHeaderSetting)
+	}
+	return s, nil
+}
+
+// sign adds the headers of the signature of the body to headers. The signature is the HMAC of the
+// Unix timestamp in seconds and the body separated by a dot, so that a receiver can reject the
+// requests that are replayed later, and is sent as the algorithm and the hex encoded HMAC, such as
+// sha256=9f86d081884c7d65...
+func (s *webhookSigner) sign(headers map[string]string, body []byte) {
+	timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+	mac := hmac.New(s.hash, s.secret)
+	_, _ = mac.Write([]byte(timestamp + "."))
+	_, _ = mac.Write(body)
+	headers[s.timestampHeader] = timestamp
+	headers[s.header] = s.algorithm + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// signingNotificationService signs the webhooks of a contact point. It signs the body that is
+// sent, after the payload transformer, and signs each retry again with its own timestamp.
+type signingNotificationService struct {
+	notifications.Service
+	signer *webhookSigner
+}
+
+func (s *signingNotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
+	signed := *cmd
+	signed.HttpHeader = make(map[string]string, len(cmd.HttpHeader)+2)
+	for k, v := range cmd.HttpHeader {
+		signed.HttpHeader[k] = v
+	}
+	s.signer.sign(signed.HttpHeader, []byte(cmd.Body))
+	return s.Service.SendWebhookSync(ctx, &signed)
+}
@@ -0,0 +1,99 @@
+package channels
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	defer mockTimeNow(time.Unix(1700000000, 0))()
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	encrypted, err := secretsService.Encrypt(context.Background(), []byte("s3cr3t"), secrets.WithoutScope())
+	require.NoError(t, err)
+
+	notifyWebhook := func(t *testing.T, settings map[string]interface{}) *notificationServiceMock {
+		t.Helper()
+		ns := mockNotificationService()
+		fc, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name:           "webhook_testing",
+			Type:           "webhook",
+			Settings:       simplejson.NewFromAny(settings),
+			SecureSettings: map[string][]byte{hmacSecretSetting: encrypted},
+		}, ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.NoError(t, err)
+		n, err := WebHookFactory(fc)
+		require.NoError(t, err)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		ok, err := n.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		return ns
+	}
+
+	t.Run("the signature covers the timestamp and the transformed body", func(t *testing.T) {
+		ns := notifyWebhook(t, map[string]interface{}{
+			"url":                     "http://localhost/test",
+			"hmacAlgorithm":           "sha512",
+			"hmacHeader":              "X-Hub-Signature",
+			payloadTransformerSetting: "def transform(payload):\n    return {\"status\": payload[\"status\"]}\n",
+		})
+		require.JSONEq(t, `{"status": "firing"}`, ns.Webhook.Body)
+		require.Equal(t, "1700000000", ns.Webhook.HttpHeader[defaultHMACTimestampHeader])
+
+		mac := hmac.New(sha512.New, []byte("s3cr3t"))
+		_, _ = mac.Write([]byte("1700000000." + ns.Webhook.Body))
+		require.Equal(t, "sha512="+hex.EncodeToString(mac.Sum(nil)), ns.Webhook.HttpHeader["X-Hub-Signature"])
+	})
+
+	t.Run("the webhooks are not signed without secret", func(t *testing.T) {
+		ns := mockNotificationService()
+		fc, err := NewFactoryConfig(&NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost/test"}),
+		}, ns, secretsService.GetDecryptedValue, tmpl, nil)
+		require.NoError(t, err)
+		require.NoError(t, fc.NotificationService.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: "http://localhost/test"}))
+		require.NotContains(t, ns.Webhook.HttpHeader, defaultHMACHeader)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		for settings, expErr := range map[string]string{
+			`{"hmacAlgorithm": "md5"}`:                       `invalid hmacAlgorithm "md5", must be sha256 or sha512`,
+			`{"hmacHeader": "X Signature"}`:                  `invalid HMAC header name "X Signature"`,
+			`{"hmacTimestampHeader": "x-grafana-signature"}`: `hmacHeader and hmacTimestampHeader must be different`,
+		} {
+			s, err := simplejson.NewJson([]byte(settings))
+			require.NoError(t, err)
+			_, err = NewFactoryConfig(&NotificationChannelConfig{
+				Type:           "webhook",
+				Settings:       s,
+				SecureSettings: map[string][]byte{hmacSecretSetting: encrypted},
+			}, mockNotificationService(), secretsService.GetDecryptedValue, tmpl, nil)
+			require.EqualError(t, err, expErr, settings)
+		}
+	})
+}
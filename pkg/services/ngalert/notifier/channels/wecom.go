@@ -2,23 +2,42 @@ package channels
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 )
 
+const (
+	weComWebhookURL = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send"
+
+	// The group robots accept images of up to 2MB, and 20 messages per minute.
+	weComMaxImageSize = 2 << 20
+	weComMaxImages    = 3
+)
+
 type WeComConfig struct {
 	*NotificationChannelConfig
-	URL     string
-	Message string
-	Title   string
+	URL                string
+	Message            string
+	Title              string
+	MentionMobiles     []string
+	MentionMobileLabel string
 }
 
 func WeComFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -29,24 +48,38 @@ func WeComFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewWeComNotifier(cfg, fc.NotificationService, fc.Template), nil
+	return NewWeComNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
 }
 
 func NewWeComConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*WeComConfig, error) {
-	url := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
-	if url == "" {
+	webhookURL := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if webhookURL == "" {
+		// The webhook of a group robot can also be set by its key.
+		if key := decryptFunc(context.Background(), config.SecureSettings, "key", config.Settings.Get("key").MustString()); key != "" {
+			webhookURL = weComWebhookURL + "?key=" + url.QueryEscape(key)
+		}
+	}
+	if webhookURL == "" {
 		return nil, errors.New("could not find webhook URL in settings")
 	}
+	var mentionMobiles []string
+	for _, mobile := range strings.Split(config.Settings.Get("mentionMobiles").MustString(), ",") {
+		if mobile = strings.TrimSpace(mobile); mobile != "" {
+			mentionMobiles = append(mentionMobiles, mobile)
+		}
+	}
 	return &WeComConfig{
 		NotificationChannelConfig: config,
-		URL:                       url,
+		URL:                       webhookURL,
 		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" .}}`),
 		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		MentionMobiles:            mentionMobiles,
+		MentionMobileLabel:        strings.TrimSpace(config.Settings.Get("mentionMobileLabel").MustString()),
 	}, nil
 }
 
 // NewWeComNotifier is the constructor for WeCom notifier.
-func NewWeComNotifier(config *WeComConfig, ns notifications.WebhookSender, t *template.Template) *WeComNotifier {
+func NewWeComNotifier(config *WeComConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *WeComNotifier {
 	return &WeComNotifier{
 		Base: NewBase(&models.AlertNotification{
 			Uid:                   config.UID,
@@ -55,27 +88,35 @@ func NewWeComNotifier(config *WeComConfig, ns notifications.WebhookSender, t *te
 			DisableResolveMessage: config.DisableResolveMessage,
 			Settings:              config.Settings,
 		}),
-		URL:     config.URL,
-		Message: config.Message,
-		Title:   config.Title,
-		log:     log.New("alerting.notifier.wecom"),
-		ns:      ns,
-		tmpl:    t,
+		URL:                config.URL,
+		Message:            config.Message,
+		Title:              config.Title,
+		MentionMobiles:     config.MentionMobiles,
+		MentionMobileLabel: config.MentionMobileLabel,
+		log:                log.New("alerting.notifier.wecom"),
+		ns:                 ns,
+		images:             images,
+		tmpl:               t,
 	}
 }
 
 // WeComNotifier is responsible for sending alert notifications to WeCom.
 type WeComNotifier struct {
 	*Base
-	URL     string
-	Message string
-	Title   string
-	tmpl    *template.Template
-	log     log.Logger
-	ns      notifications.WebhookSender
+	URL                string
+	Message            string
+	Title              string
+	MentionMobiles     []string
+	MentionMobileLabel string
+	tmpl               *template.Template
+	log                log.Logger
+	ns                 notifications.WebhookSender
+	images             ImageStore
 }
 
-// Notify send an alert notification to WeCom.
+// Notify send an alert notification to WeCom. The markdown message is followed by a text message
+// that mentions the mobile numbers, as markdown messages cannot mention them, and by the images
+// of the alerts.
 func (w *WeComNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	w.log.Info("executing WeCom notification", "notification", w.Name)
 
@@ -94,24 +135,137 @@ func (w *WeComNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, e
 		"content": content,
 	}
 
-	body, err := json.Marshal(bodyMsg)
-	if err != nil {
+	if err := w.send(ctx, bodyMsg); err != nil {
+		w.log.Error("failed to send WeCom webhook", "err", err, "notification", w.Name)
 		return false, err
 	}
 
-	cmd := &models.SendWebhookSync{
-		Url:  w.URL,
-		Body: string(body),
+	if mobiles := w.mentions(as); len(mobiles) > 0 {
+		if err := w.send(ctx, map[string]interface{}{
+			"msgtype": "text",
+			"text": map[string]interface{}{
+				"content":               tmpl(w.Title),
+				"mentioned_mobile_list": mobiles,
+			},
+		}); err != nil {
+			w.log.Error("failed to send WeCom mentions", "err", err, "notification", w.Name)
+			return false, err
+		}
 	}
 
-	if err := w.ns.SendWebhookSync(ctx, cmd); err != nil {
-		w.log.Error("failed to send WeCom webhook", "err", err, "notification", w.Name)
-		return false, err
+	for _, image := range w.imageMessages(ctx, as) {
+		if err := w.send(ctx, image); err != nil {
+			w.log.Error("failed to send WeCom image", "err", err, "notification", w.Name)
+			return false, err
+		}
 	}
 
 	return true, nil
 }
 
+func (w *WeComNotifier) send(ctx context.Context, msg map[string]interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        w.URL,
+		Body:       string(body),
+		Validation: weComValidation,
+	}
+	return w.ns.SendWebhookSync(ctx, cmd)
+}
+
+// mentions returns the mobile numbers to mention, which are the mobile numbers of the settings
+// and of the mention label of the alerts, separated by commas.
+func (w *WeComNotifier) mentions(as []*types.Alert) []string {
+	mobiles := append([]string{}, w.MentionMobiles...)
+	if w.MentionMobileLabel != "" {
+		seen := map[string]struct{}{}
+		for _, mobile := range mobiles {
+			seen[mobile] = struct{}{}
+		}
+		var fromLabels []string
+		for _, a := range as {
+			for _, mobile := range strings.Split(string(a.Labels[model.LabelName(w.MentionMobileLabel)]), ",") {
+				if mobile = strings.TrimSpace(mobile); mobile == "" {
+					continue
+				}
+				if _, ok := seen[mobile]; !ok {
+					seen[mobile] = struct{}{}
+					fromLabels = append(fromLabels, mobile)
+				}
+			}
+		}
+		sort.Strings(fromLabels)
+		mobiles = append(mobiles, fromLabels...)
+	}
+	return mobiles
+}
+
+// imageMessages returns the image messages of the images of the alerts that are stored on disk,
+// with the base64 encoded image and its MD5 checksum.
+func (w *WeComNotifier) imageMessages(ctx context.Context, as []*types.Alert) []map[string]interface{} {
+	var messages []map[string]interface{}
+	_ = withStoredImages(ctx, w.log, w.images,
+		func(_ int, image ngmodels.Image) error {
+			if len(messages) >= weComMaxImages {
+				return ErrImagesDone
+			}
+			if image.Path == "" {
+				return nil
+			}
+			f, err := openImage(image.Path)
+			if err != nil {
+				if !errors.Is(err, ngmodels.ErrImageNotFound) {
+					w.log.Warn("failed to open image", "err", err)
+				}
+				return nil
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					w.log.Warn("failed to close image", "err", err)
+				}
+			}()
+			data, err := io.ReadAll(io.LimitReader(f, weComMaxImageSize+1))
+			if err != nil {
+				w.log.Warn("failed to read image", "err", err)
+				return nil
+			}
+			if len(data) > weComMaxImageSize {
+				w.log.Warn("image is too large for WeCom", "token", image.Token)
+				return nil
+			}
+			sum := md5.Sum(data)
+			messages = append(messages, map[string]interface{}{
+				"msgtype": "image",
+				"image": map[string]interface{}{
+					"base64": base64.StdEncoding.EncodeToString(data),
+					"md5":    hex.EncodeToString(sum[:]),
+				},
+			})
+			return nil
+		}, as...)
+	return messages
+}
+
+// weComValidation checks the error code of the responses of the group robots, which return their
+// errors with the status 200.
+func weComValidation(body []byte, statusCode int) error {
+	if statusCode/100 != 2 {
+		return fmt.Errorf("the WeCom API returned status %d", statusCode)
+	}
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.ErrCode != 0 {
+		return fmt.Errorf("the WeCom API returned error %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
 func (w *WeComNotifier) SendResolved() bool {
 	return !w.GetDisableResolveMessage()
 }
@@ -2,10 +2,15 @@ package channels
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/url"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 
@@ -133,7 +138,7 @@ func TestWeComNotifier(t *testing.T) {
 
 			ctx := notify.WithGroupKey(context.Background(), "alertname")
 			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
-			pn := NewWeComNotifier(cfg, webhookSender, tmpl)
+			pn := NewWeComNotifier(cfg, webhookSender, &UnavailableImageStore{}, tmpl)
 			ok, err := pn.Notify(ctx, c.alerts...)
 			if c.expMsgError != nil {
 				require.False(t, ok)
@@ -151,3 +156,86 @@ func TestWeComNotifier(t *testing.T) {
 		})
 	}
 }
+
+// fakeWeCom records the messages sent to a group robot.
+type fakeWeCom struct {
+	notificationServiceMock
+	messages []map[string]interface{}
+}
+
+func (f *fakeWeCom) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(cmd.Body), &msg); err != nil {
+		return err
+	}
+	f.messages = append(f.messages, msg)
+	return cmd.Validation([]byte(`{"errcode": 0, "errmsg": "ok"}`), 200)
+}
+
+func TestWeComNotifierMentionsAndImages(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{
+		"key": "robot-key",
+		"title": "{{ .CommonLabels.alertname }}",
+		"message": "{{ len .Alerts }} alerts",
+		"mentionMobiles": "13800000000, @all",
+		"mentionMobileLabel": "oncall_mobile"
+	}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewWeComConfig(&NotificationChannelConfig{
+		Name:     "wecom_testing",
+		Type:     "wecom",
+		Settings: settingsJSON,
+	}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+	require.Equal(t, "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=robot-key", cfg.URL)
+
+	sender := &fakeWeCom{}
+	n := NewWeComNotifier(cfg, sender, newFakeImageStoreWithFile(t, 1), tmpl)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ok, err := n.Notify(ctx,
+		&types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "oncall_mobile": "13900000002,13800000000"},
+			Annotations: model.LabelSet{ngmodels.ImageTokenAnnotation: "test-image-1"},
+		}},
+		&types.Alert{Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DiskFull", "oncall_mobile": "13900000001"},
+		}},
+	)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Len(t, sender.messages, 3)
+	require.Equal(t, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]interface{}{"content": "# DiskFull\n2 alerts\n"},
+	}, sender.messages[0])
+	require.Equal(t, map[string]interface{}{
+		"msgtype": "text",
+		"text": map[string]interface{}{
+			"content":               "DiskFull",
+			"mentioned_mobile_list": []interface{}{"13800000000", "@all", "13900000001", "13900000002"},
+		},
+	}, sender.messages[1])
+
+	image := sender.messages[2]["image"].(map[string]interface{})
+	data, err := base64.StdEncoding.DecodeString(image["base64"].(string))
+	require.NoError(t, err)
+	sum := md5.Sum(data)
+	require.Equal(t, "image", sender.messages[2]["msgtype"])
+	require.Equal(t, hex.EncodeToString(sum[:]), image["md5"])
+}
+
+func TestWeComValidation(t *testing.T) {
+	require.NoError(t, weComValidation([]byte(`{"errcode": 0, "errmsg": "ok"}`), 200))
+	require.EqualError(t, weComValidation([]byte(`{"errcode": 93000, "errmsg": "invalid webhook url"}`), 200), "the WeCom API returned error 93000: invalid webhook url")
+	require.EqualError(t, weComValidation(nil, 500), "the WeCom API returned status 500")
+}
@@ -0,0 +1,356 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// notificationWorkers are the workers connected to the NotificationWorker service of Grafana, which
+// deliver the notifications of the worker notifiers.
+var notificationWorkers = newWorkerHub()
+
+var (
+	errNoWorker           = errors.New("no notification worker is subscribed to the contact point")
+	errWorkerDisconnected = errors.New("the notification worker disconnected before reporting the result of the notification")
+)
+
+// workerKey identifies the contact points the workers subscribe to.
+type workerKey struct {
+	orgID    int64
+	receiver string
+}
+
+// workerHub dispatches the notifications of the worker notifiers to the subscribed workers, in turn.
+type workerHub struct {
+	mtx     sync.Mutex
+	workers map[workerKey][]*workerStream
+	next    map[workerKey]int
+}
+
+func newWorkerHub() *workerHub {
+	return &workerHub{
+		workers: map[workerKey][]*workerStream{},
+		next:    map[workerKey]int{},
+	}
+}
+
+// workerStream is the stream of a connected worker.
+type workerStream struct {
+	name string
+	// notifications are sent to the worker by the stream.
+	notifications chan *alertreceiverv1.Notification
+	// done is closed when the worker disconnects.
+	done chan struct{}
+
+	mtx     sync.Mutex
+	pending map[string]chan *alertreceiverv1.DeliveryResult
+}
+
+func newWorkerStream(name string) *workerStream {
+	return &workerStream{
+		name:          name,
+		notifications: make(chan *alertreceiverv1.Notification),
+		done:          make(chan struct{}),
+		pending:       map[string]chan *alertreceiverv1.DeliveryResult{},
+	}
+}
+
+// resolve passes the result to the notification waiting for it, if any.
+func (w *workerStream) resolve(r *alertreceiverv1.DeliveryResult) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if c, ok := w.pending[r.Id]; ok {
+		c <- r
+		delete(w.pending, r.Id)
+	}
+}
+
+func (h *workerHub) subscribe(keys []workerKey, w *workerStream) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for _, k := range keys {
+		h.workers[k] = append(h.workers[k], w)
+	}
+}
+
+func (h *workerHub) unsubscribe(keys []workerKey, w *workerStream) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for _, k := range keys {
+		workers := h.workers[k][:0]
+		for _, other := range h.workers[k] {
+			if other != w {
+				workers = append(workers, other)
+			}
+		}
+		if len(workers) == 0 {
+			delete(h.workers, k)
+			delete(h.next, k)
+			continue
+		}
+		h.workers[k] = workers
+	}
+}
+
+// pick returns the next worker subscribed to the contact point, or nil if there is none.
+func (h *workerHub) pick(k workerKey) *workerStream {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	workers := h.workers[k]
+	if len(workers) == 0 {
+		return nil
+	}
+	i := h.next[k] % len(workers)
+	h.next[k] = i + 1
+	return workers[i]
+}
+
+// deliver sends the notification to a worker subscribed to the contact point and waits for its
+// result. It returns whether the notification should be retried if it was not delivered.
+func (h *workerHub) deliver(ctx context.Context, k workerKey, req *alertreceiverv1.NotifyRequest) (bool, error) {
+	w := h.pick(k)
+	if w == nil {
+		return true, errNoWorker
+	}
+
+	n := &alertreceiverv1.Notification{Id: util.GenerateShortUID(), Request: req}
+	if deadline, ok := ctx.Deadline(); ok {
+		n.Deadline = timestamppb.New(deadline)
+	}
+	result := make(chan *alertreceiverv1.DeliveryResult, 1)
+	w.mtx.Lock()
+	w.pending[n.Id] = result
+	w.mtx.Unlock()
+	defer func() {
+		w.mtx.Lock()
+		delete(w.pending, n.Id)
+		w.mtx.Unlock()
+	}()
+
+	select {
+	case w.notifications <- n:
+	case <-w.done:
+		return true, errWorkerDisconnected
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+
+	select {
+	case r := <-result:
+		if r.Error != "" {
+			return r.Retry, fmt.Errorf("the notification worker %s failed to deliver the notification: %s", w.name, r.Error)
+		}
+		return false, nil
+	case <-w.done:
+		return true, errWorkerDisconnected
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// NotificationWorkerServer implements the NotificationWorker service, through which workers
+// deliver the notifications of the worker notifiers.
+type NotificationWorkerServer struct {
+	alertreceiverv1.UnimplementedNotificationWorkerServer
+	hub *workerHub
+	log log.Logger
+}
+
+func NewNotificationWorkerServer() *NotificationWorkerServer {
+	return &NotificationWorkerServer{
+		hub: notificationWorkers,
+		log: log.New("alerting.notifier.worker"),
+	}
+}
+
+// Work subscribes the worker to the contact points of its first message, then sends it their
+// notifications and passes the results it reports back to the notifiers, until it disconnects.
+func (s *NotificationWorkerServer) Work(stream alertreceiverv1.NotificationWorker_WorkServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	sub := msg.GetSubscription()
+	if sub == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be a subscription")
+	}
+	if sub.OrgId <= 0 || len(sub.Receivers) == 0 {
+		return status.Error(codes.InvalidArgument, "the subscription must have an organization and receivers")
+	}
+	keys := make([]workerKey, 0, len(sub.Receivers))
+	for _, r := range sub.Receivers {
+		keys = append(keys, workerKey{orgID: sub.OrgId, receiver: r})
+	}
+	name := sub.Worker
+	if name == "" {
+		name = "unnamed"
+	}
+
+	w := newWorkerStream(name)
+	s.hub.subscribe(keys, w)
+	defer s.hub.unsubscribe(keys, w)
+	defer close(w.done)
+	logger := s.log.New("worker", name, "org", sub.OrgId, "receivers", strings.Join(sub.Receivers, ","))
+	logger.Info("notification worker subscribed")
+	defer logger.Info("notification worker disconnected")
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			r := msg.GetResult()
+			if r == nil {
+				recvErr <- status.Error(codes.InvalidArgument, "the worker is already subscribed")
+				return
+			}
+			w.resolve(r)
+		}
+	}()
+
+	for {
+		select {
+		case n := <-w.notifications:
+			if err := stream.Send(n); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			return err
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+type WorkerConfig struct {
+	*NotificationChannelConfig
+	MaxAlerts int
+}
+
+func WorkerFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewWorkerConfig(fc.Config)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewWorkerNotifier(cfg, fc.ImageStore, fc.Template), nil
+}
+
+func NewWorkerConfig(config *NotificationChannelConfig) (*WorkerConfig, error) {
+	// The maximum number of alerts is a string when set from the UI and a number when provisioned.
+	maxAlerts, err := config.Settings.Get("maxAlerts").Int()
+	if err != nil {
+		maxAlerts, err = strconv.Atoi(config.Settings.Get("maxAlerts").MustString("0"))
+		if err != nil {
+			return nil, errors.New("invalid maximum number of alerts, must be a number")
+		}
+	}
+	return &WorkerConfig{
+		NotificationChannelConfig: config,
+		MaxAlerts:                 maxAlerts,
+	}, nil
+}
+
+// NewWorkerNotifier is the constructor for the worker notifier.
+func NewWorkerNotifier(config *WorkerConfig, images ImageStore, t *template.Template) *WorkerNotifier {
+	return &WorkerNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		MaxAlerts: config.MaxAlerts,
+		orgID:     config.OrgID,
+		hub:       notificationWorkers,
+		log:       log.New("alerting.notifier.worker"),
+		images:    images,
+		tmpl:      t,
+	}
+}
+
+// WorkerNotifier is responsible for handing the notifications of the contact point to the workers
+// subscribed to it through the NotificationWorker service, which deliver them, and for waiting for
+// the result of the delivery.
+type WorkerNotifier struct {
+	*Base
+	MaxAlerts int
+	orgID     int64
+	hub       *workerHub
+	log       log.Logger
+	images    ImageStore
+	tmpl      *template.Template
+}
+
+// Notify sends the notification to one of the workers subscribed to the contact point, in turn,
+// and returns the error it reports. The notifications that no worker is subscribed to, or that the
+// worker disconnected before reporting the result of, are retried.
+func (wn *WorkerNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	wn.log.Debug("sending notification to worker", "notification", wn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	as, numTruncated := truncateAlerts(wn.MaxAlerts, as)
+	var tmplErr error
+	tmpl, data := TmplText(ctx, wn.tmpl, as, wn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, wn.log, wn.images,
+		func(index int, image ngmodels.Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	req := newNotifyRequest(groupKey.String(), wn.orgID, tmpl, data, numTruncated)
+
+	if tmplErr != nil {
+		wn.log.Warn("failed to template worker notification", "err", tmplErr.Error())
+	}
+
+	if _, ok := dryRunFromContext(ctx); ok {
+		body, err := protojson.Marshal(req)
+		if err != nil {
+			return false, err
+		}
+		return recordDryRun(ctx, "worker:"+wn.Name, string(body)), nil
+	}
+
+	retry, err := wn.hub.deliver(ctx, workerKey{orgID: wn.orgID, receiver: wn.Name}, req)
+	if err != nil {
+		wn.log.Error("failed to deliver notification through worker", "err", err, "notification", wn.Name)
+		return retry, err
+	}
+	return true, nil
+}
+
+func (wn *WorkerNotifier) SendResolved() bool {
+	return !wn.GetDisableResolveMessage()
+}
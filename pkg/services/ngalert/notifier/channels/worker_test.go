@@ -0,0 +1,183 @@
+package channels
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+)
+
+// subscribed returns the number of workers subscribed to the contact point.
+func (h *workerHub) subscribed(k workerKey) int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.workers[k])
+}
+
+func TestWorkerNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	hub := newWorkerHub()
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	alertreceiverv1.RegisterNotificationWorkerServer(srv, &NotificationWorkerServer{hub: hub, log: log.New("test")})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := alertreceiverv1.NewNotificationWorkerClient(conn)
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"maxAlerts": 1}`))
+	require.NoError(t, err)
+	cfg, err := NewWorkerConfig(&NotificationChannelConfig{OrgID: 1, UID: "worker-uid", Name: "on-prem", Type: "worker", Settings: settingsJSON})
+	require.NoError(t, err)
+	n := NewWorkerNotifier(cfg, &UnavailableImageStore{}, tmpl)
+	n.hub = hub
+	key := workerKey{orgID: 1, receiver: "on-prem"}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "DiskFull"})
+	ctx = notify.WithReceiverName(ctx, "on-prem")
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "DiskFull", "instance": "db-1"},
+			StartsAt: time.Unix(1700000000, 0),
+		},
+	}, {
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "DiskFull", "instance": "db-2"},
+		},
+	}}
+
+	type notifyResult struct {
+		retry bool
+		err   error
+	}
+	notifyAsync := func() <-chan notifyResult {
+		c := make(chan notifyResult, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			retry, err := n.Notify(ctx, alerts...)
+			c <- notifyResult{retry: retry, err: err}
+		}()
+		return c
+	}
+	subscribe := func(t *testing.T, name string) alertreceiverv1.NotificationWorker_WorkClient {
+		t.Helper()
+		stream, err := client.Work(context.Background())
+		require.NoError(t, err)
+		subscribed := hub.subscribed(key)
+		require.NoError(t, stream.Send(&alertreceiverv1.WorkerMessage{Message: &alertreceiverv1.WorkerMessage_Subscription{
+			Subscription: &alertreceiverv1.Subscription{OrgId: 1, Receivers: []string{"on-prem", "other"}, Worker: name},
+		}}))
+		require.Eventually(t, func() bool { return hub.subscribed(key) == subscribed+1 }, 5*time.Second, 10*time.Millisecond)
+		return stream
+	}
+	report := func(t *testing.T, stream alertreceiverv1.NotificationWorker_WorkClient, r *alertreceiverv1.DeliveryResult) {
+		t.Helper()
+		require.NoError(t, stream.Send(&alertreceiverv1.WorkerMessage{Message: &alertreceiverv1.WorkerMessage_Result{Result: r}}))
+	}
+
+	t.Run("retries the notifications without workers", func(t *testing.T) {
+		retry, err := n.Notify(ctx, alerts...)
+		require.ErrorIs(t, err, errNoWorker)
+		require.True(t, retry)
+	})
+
+	t.Run("delivers the notifications through the workers", func(t *testing.T) {
+		stream := subscribe(t, "dc-1")
+		result := notifyAsync()
+		notification, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotEmpty(t, notification.Id)
+		require.NotNil(t, notification.Deadline)
+		req := notification.Request
+		require.Equal(t, "on-prem", req.Receiver)
+		require.Equal(t, int64(1), req.OrgId)
+		require.Equal(t, "[FIRING:1] DiskFull (db-1)", req.Title)
+		require.Equal(t, int32(1), req.TruncatedAlerts)
+		require.Len(t, req.Alerts, 1)
+
+		// The results of other notifications are ignored.
+		report(t, stream, &alertreceiverv1.DeliveryResult{Id: "unknown", Error: "failed"})
+		report(t, stream, &alertreceiverv1.DeliveryResult{Id: notification.Id})
+		r := <-result
+		require.NoError(t, r.err)
+
+		result = notifyAsync()
+		notification, err = stream.Recv()
+		require.NoError(t, err)
+		report(t, stream, &alertreceiverv1.DeliveryResult{Id: notification.Id, Error: "the SMTP relay rejected the message", Retry: false})
+		r = <-result
+		require.EqualError(t, r.err, "the notification worker dc-1 failed to deliver the notification: the SMTP relay rejected the message")
+		require.False(t, r.retry)
+
+		// The notifications are retried when the worker disconnects.
+		result = notifyAsync()
+		_, err = stream.Recv()
+		require.NoError(t, err)
+		require.NoError(t, stream.CloseSend())
+		r = <-result
+		require.ErrorIs(t, r.err, errWorkerDisconnected)
+		require.True(t, r.retry)
+		require.Eventually(t, func() bool { return hub.subscribed(key) == 0 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("hands the notifications to the workers in turn", func(t *testing.T) {
+		streams := []alertreceiverv1.NotificationWorker_WorkClient{subscribe(t, "dc-1"), subscribe(t, "dc-2")}
+		for i := 0; i < 4; i++ {
+			stream := streams[i%2]
+			result := notifyAsync()
+			notification, err := stream.Recv()
+			require.NoError(t, err)
+			report(t, stream, &alertreceiverv1.DeliveryResult{Id: notification.Id})
+			require.NoError(t, (<-result).err)
+		}
+		for _, s := range streams {
+			require.NoError(t, s.CloseSend())
+		}
+		require.Eventually(t, func() bool { return hub.subscribed(key) == 0 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("rejects the workers that do not subscribe first", func(t *testing.T) {
+		stream, err := client.Work(context.Background())
+		require.NoError(t, err)
+		report(t, stream, &alertreceiverv1.DeliveryResult{Id: "id"})
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("records dry runs", func(t *testing.T) {
+		d := &DryRun{}
+		ok, err := n.Notify(WithDryRun(ctx, d), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, d.Requests(), 1)
+		require.Equal(t, "worker:on-prem", d.Requests()[0].Target)
+	})
+}
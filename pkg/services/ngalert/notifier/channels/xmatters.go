@@ -0,0 +1,218 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const defaultXMattersRecipientsLabel = "xmatters_recipients"
+
+type XMattersConfig struct {
+	*NotificationChannelConfig
+	URL             string
+	RecipientsLabel string
+	Recipients      []string
+	Title           string
+	Message         string
+}
+
+func XMattersFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewXMattersConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewXMattersNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewXMattersConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*XMattersConfig, error) {
+	url := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if url == "" {
+		return nil, errors.New("could not find url in settings")
+	}
+	return &XMattersConfig{
+		NotificationChannelConfig: config,
+		URL:                       url,
+		RecipientsLabel:           config.Settings.Get("recipientsLabel").MustString(defaultXMattersRecipientsLabel),
+		Recipients:                splitXMattersRecipients(config.Settings.Get("recipients").MustString()),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewXMattersNotifier is the constructor for the xMatters notifier.
+func NewXMattersNotifier(config *XMattersConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *XMattersNotifier {
+	return &XMattersNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:             config.URL,
+		RecipientsLabel: config.RecipientsLabel,
+		Recipients:      config.Recipients,
+		Title:           config.Title,
+		Message:         config.Message,
+		log:             log.New("alerting.notifier.xmatters"),
+		images:          images,
+		ns:              ns,
+		tmpl:            t,
+	}
+}
+
+// XMattersNotifier triggers the inbound integration of an xMatters workflow. The payload follows
+// the one of the Grafana workflow of xMatters, with the annotations of the alerts as properties
+// of the event and the recipients of the event read from a label of the alerts.
+type XMattersNotifier struct {
+	*Base
+	URL             string
+	RecipientsLabel string
+	Recipients      []string
+	Title           string
+	Message         string
+	log             log.Logger
+	images          ImageStore
+	ns              notifications.WebhookSender
+	tmpl            *template.Template
+}
+
+type xMattersRecipient struct {
+	ID string `json:"id"`
+}
+
+type xMattersEvent struct {
+	Title      string              `json:"title"`
+	RuleURL    string              `json:"ruleUrl"`
+	State      string              `json:"state"`
+	Message    string              `json:"message"`
+	ImageURL   string              `json:"imageUrl,omitempty"`
+	GroupKey   string              `json:"groupKey"`
+	Tags       map[string]string   `json:"tags"`
+	Properties map[string]string   `json:"properties"`
+	Recipients []xMattersRecipient `json:"recipients,omitempty"`
+}
+
+// Notify sends the alert notification to xMatters.
+func (xn *XMattersNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	xn.log.Debug("sending xMatters notification", "notification", xn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, xn.tmpl, as, xn.log, &tmplErr)
+
+	event := xMattersEvent{
+		Title:      tmpl(xn.Title),
+		RuleURL:    xn.RuleListURL(xn.tmpl.ExternalURL),
+		State:      string(models.AlertStateAlerting),
+		Message:    tmpl(xn.Message),
+		GroupKey:   groupKey.String(),
+		Tags:       data.CommonLabels,
+		Properties: data.CommonAnnotations,
+	}
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		event.State = string(models.AlertStateOK)
+	}
+	if tmplErr != nil {
+		xn.log.Warn("failed to template xMatters message", "err", tmplErr.Error())
+	}
+
+	recipients := xn.recipients(as)
+	for _, r := range recipients {
+		event.Recipients = append(event.Recipients, xMattersRecipient{ID: r})
+	}
+
+	_ = withStoredImages(ctx, xn.log, xn.images,
+		func(_ int, image ngmodels.Image) error {
+			if image.URL != "" {
+				event.ImageURL = image.URL
+				return ErrImagesDone
+			}
+			return nil
+		}, as...)
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        xn.URL,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 == 2 {
+				return nil
+			}
+			var res struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(body, &res); err == nil && res.Message != "" {
+				return fmt.Errorf("the xMatters API returned status %d: %s", statusCode, res.Message)
+			}
+			return fmt.Errorf("the xMatters API returned status %d", statusCode)
+		},
+	}
+	if err := xn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		xn.log.Error("failed to send xMatters notification", "err", err, "notification", xn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// recipients returns the recipients set with the recipients label of the alerts, or the default
+// recipients if none of the alerts have the label. Without recipients, the event is sent to the
+// recipients of the xMatters workflow.
+func (xn *XMattersNotifier) recipients(as []*types.Alert) []string {
+	var recipients []string
+	seen := map[string]struct{}{}
+	for _, a := range as {
+		for _, r := range splitXMattersRecipients(string(a.Labels[model.LabelName(xn.RecipientsLabel)])) {
+			if _, ok := seen[r]; !ok {
+				seen[r] = struct{}{}
+				recipients = append(recipients, r)
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		return xn.Recipients
+	}
+	return recipients
+}
+
+// splitXMattersRecipients splits a comma separated list of users or groups of xMatters.
+func splitXMattersRecipients(s string) []string {
+	var recipients []string
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients
+}
+
+func (xn *XMattersNotifier) SendResolved() bool {
+	return !xn.GetDisableResolveMessage()
+}
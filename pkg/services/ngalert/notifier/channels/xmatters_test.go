@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestXMattersNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	images := newFakeImageStore(2)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       map[string]interface{}
+		expInitError string
+		expMsgError  error
+	}{
+		{
+			name:     "A single alert with image and recipients",
+			settings: `{"url": "https://example.xmatters.com/api/integration/1/functions/abc/triggers?apiKey=secret"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "xmatters_recipients": "ops, dba"},
+						Annotations: model.LabelSet{"summary": "CPU is high", "__alertImageToken__": "test-image-1"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"title":      "[FIRING:1]  (alert1 ops, dba)",
+				"ruleUrl":    "http://localhost/alerting/list",
+				"state":      "alerting",
+				"message":    "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - xmatters_recipients = ops, dba\nAnnotations:\n - summary = CPU is high\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=xmatters_recipients%3Dops%2C+dba\n",
+				"imageUrl":   "https://www.example.com/test-image-1.jpg",
+				"groupKey":   "alertname",
+				"tags":       map[string]interface{}{"alertname": "alert1", "xmatters_recipients": "ops, dba"},
+				"properties": map[string]interface{}{"summary": "CPU is high"},
+				"recipients": []interface{}{map[string]interface{}{"id": "ops"}, map[string]interface{}{"id": "dba"}},
+			},
+		}, {
+			name:     "Resolved alerts with the default recipients",
+			settings: `{"url": "https://example.xmatters.com/triggers", "recipients": "on-call", "title": "{{ .CommonLabels.alertname }}", "message": "resolved"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "instance": "a"},
+						StartsAt: time.Now().Add(-time.Hour),
+						EndsAt:   time.Now().Add(-time.Minute),
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "instance": "b"},
+						StartsAt: time.Now().Add(-time.Hour),
+						EndsAt:   time.Now().Add(-time.Minute),
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"title":      "alert1",
+				"ruleUrl":    "http://localhost/alerting/list",
+				"state":      "ok",
+				"message":    "resolved",
+				"groupKey":   "alertname",
+				"tags":       map[string]interface{}{"alertname": "alert1"},
+				"properties": map[string]interface{}{},
+				"recipients": []interface{}{map[string]interface{}{"id": "on-call"}},
+			},
+		}, {
+			name:         "Error in initing",
+			settings:     `{}`,
+			expInitError: `could not find url in settings`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "xmatters_testing",
+				Type:     "xmatters",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			cfg, err := NewXMattersConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			pn := NewXMattersNotifier(cfg, images, webhookSender, tmpl)
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, cfg.URL, webhookSender.Webhook.Url)
+			require.Equal(t, "POST", webhookSender.Webhook.HttpMethod)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+			require.Equal(t, c.expMsg, body)
+		})
+	}
+}
+
+func TestXMattersNotifierValidation(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewXMattersConfig(&NotificationChannelConfig{
+		Name:     "xmatters_testing",
+		Type:     "xmatters",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "https://example.xmatters.com/triggers"}),
+	}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+		return fallback
+	})
+	require.NoError(t, err)
+
+	ns := mockNotificationService()
+	_, err = NewXMattersNotifier(cfg, &UnavailableImageStore{}, ns, tmpl).Notify(notify.WithGroupKey(context.Background(), "alertname"), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+	})
+	require.NoError(t, err)
+
+	validate := ns.Webhook.Validation
+	require.NoError(t, validate([]byte(`{"requestId":"6f8c3a1e"}`), 202))
+	require.EqualError(t, validate([]byte(`{"code":401,"reason":"Unauthorized","message":"Invalid API key"}`), 401), "the xMatters API returned status 401: Invalid API key")
+	require.EqualError(t, validate([]byte(`<html></html>`), 502), "the xMatters API returned status 502")
+}
@@ -0,0 +1,181 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const defaultXMPPNickname = "Grafana"
+
+type XMPPConfig struct {
+	*NotificationChannelConfig
+	JID           string
+	Password      string
+	Server        string
+	TLSMode       string
+	TLSSkipVerify bool
+	TLSCACert     string
+	Recipient     string
+	Room          bool
+	Nickname      string
+	Title         string
+	Message       string
+}
+
+func XMPPFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewXMPPConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewXMPPNotifier(cfg, fc.Template), nil
+}
+
+func NewXMPPConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*XMPPConfig, error) {
+	jid := config.Settings.Get("jid").MustString()
+	if jid == "" {
+		return nil, errors.New("could not find JID in settings")
+	}
+	if _, _, err := splitJID(jid); err != nil {
+		return nil, err
+	}
+	password := decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString())
+	if password == "" {
+		return nil, errors.New("could not find password in settings")
+	}
+	recipient := config.Settings.Get("recipient").MustString()
+	if recipient == "" {
+		return nil, errors.New("could not find recipient in settings")
+	}
+	if _, _, err := splitJID(recipient); err != nil {
+		return nil, err
+	}
+
+	tlsMode := config.Settings.Get("tls").MustString(xmppTLSStart)
+	switch tlsMode {
+	case xmppTLSStart, xmppTLSDirect, xmppTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid TLS mode %q, must be %s, %s or %s", tlsMode, xmppTLSStart, xmppTLSDirect, xmppTLSNone)
+	}
+
+	cfg := &XMPPConfig{
+		NotificationChannelConfig: config,
+		JID:                       jid,
+		Password:                  password,
+		Server:                    config.Settings.Get("server").MustString(),
+		TLSMode:                   tlsMode,
+		TLSSkipVerify:             config.Settings.Get("tlsSkipVerify").MustBool(false),
+		TLSCACert:                 config.Settings.Get("tlsCACert").MustString(),
+		Recipient:                 recipient,
+		Room:                      config.Settings.Get("room").MustBool(false),
+		Nickname:                  config.Settings.Get("nickname").MustString(defaultXMPPNickname),
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}
+	if _, err := brokerTLSConfig(cfg.TLSSkipVerify, cfg.TLSCACert, "", ""); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewXMPPNotifier is the constructor for the XMPP notifier.
+func NewXMPPNotifier(config *XMPPConfig, t *template.Template) *XMPPNotifier {
+	return &XMPPNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		Recipient: config.Recipient,
+		Room:      config.Room,
+		Nickname:  config.Nickname,
+		Title:     config.Title,
+		Message:   config.Message,
+		options: xmppOptions{
+			JID:           config.JID,
+			Password:      config.Password,
+			Server:        config.Server,
+			TLSMode:       config.TLSMode,
+			TLSSkipVerify: config.TLSSkipVerify,
+			TLSCACert:     config.TLSCACert,
+		},
+		log:  log.New("alerting.notifier.xmpp"),
+		tmpl: t,
+	}
+}
+
+// XMPPNotifier is responsible for sending alert notifications as XMPP messages to a user or a
+// multi-user chat room.
+type XMPPNotifier struct {
+	*Base
+	Recipient string
+	Room      bool
+	Nickname  string
+	Title     string
+	Message   string
+	options   xmppOptions
+	log       log.Logger
+	tmpl      *template.Template
+}
+
+// Notify sends the alerts over the connection of the account, which is kept open between
+// notifications. A connection that fails is replaced once before giving up.
+func (xn *XMPPNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	xn.log.Debug("sending XMPP notification", "notification", xn.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, xn.tmpl, as, xn.log, &tmplErr)
+
+	body := strings.TrimSpace(tmpl(xn.Message))
+	if title := strings.TrimSpace(tmpl(xn.Title)); title != "" {
+		body = title + "\n" + body
+	}
+
+	if tmplErr != nil {
+		xn.log.Warn("failed to template XMPP message", "err", tmplErr.Error())
+	}
+
+	if recordDryRun(ctx, xn.Recipient, body) {
+		return true, nil
+	}
+
+	err := xmppConnections.do(ctx, xn.options, xn.connect, func(conn pooledConn) error {
+		return xn.send(ctx, conn.(xmppClient), body)
+	})
+	if err != nil {
+		xn.log.Error("failed to send XMPP notification", "err", err, "notification", xn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (xn *XMPPNotifier) connect(ctx context.Context) (pooledConn, error) {
+	return xmppConnect(ctx, xn.options)
+}
+
+func (xn *XMPPNotifier) send(ctx context.Context, client xmppClient, body string) error {
+	if !xn.Room {
+		return client.SendMessage(ctx, xn.Recipient, "chat", body)
+	}
+	if err := client.JoinRoom(ctx, xn.Recipient, xn.Nickname); err != nil {
+		return err
+	}
+	return client.SendMessage(ctx, xn.Recipient, "groupchat", body)
+}
+
+func (xn *XMPPNotifier) SendResolved() bool {
+	return !xn.GetDisableResolveMessage()
+}
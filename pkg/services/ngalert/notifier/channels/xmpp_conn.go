@@ -0,0 +1,359 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	xmppTimeout     = 10 * time.Second
+	xmppIdleTimeout = 5 * time.Minute
+
+	xmppNSClient  = "jabber:client"
+	xmppNSStream  = "http://etherx.jabber.org/streams"
+	xmppNSTLS     = "urn:ietf:params:xml:ns:xmpp-tls"
+	xmppNSSASL    = "urn:ietf:params:xml:ns:xmpp-sasl"
+	xmppNSBind    = "urn:ietf:params:xml:ns:xmpp-bind"
+	xmppNSMUC     = "http://jabber.org/protocol/muc"
+	xmppTLSStart  = "starttls"
+	xmppTLSDirect = "tls"
+	xmppTLSNone   = "none"
+)
+
+// xmppOptions are the connection settings of an XMPP client. They are comparable so that
+// notifiers with the same account and server share a connection.
+type xmppOptions struct {
+	JID           string
+	Password      string
+	Server        string
+	TLSMode       string
+	TLSSkipVerify bool
+	TLSCACert     string
+}
+
+// xmppClient is a connection to an XMPP server used by the XMPP notifier.
+type xmppClient interface {
+	pooledConn
+	JoinRoom(ctx context.Context, room, nickname string) error
+	SendMessage(ctx context.Context, to, msgType, body string) error
+}
+
+// xmppConnect connects and authenticates to the server. Can be overwritten in tests.
+var xmppConnect = func(ctx context.Context, opts xmppOptions) (xmppClient, error) {
+	return dialXMPP(ctx, opts)
+}
+
+// xmppConnections are shared by all the XMPP notifiers.
+var xmppConnections = newConnPool(xmppIdleTimeout)
+
+type xmppFeatures struct {
+	XMLName    xml.Name  `xml:"http://etherx.jabber.org/streams features"`
+	StartTLS   *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-tls starttls"`
+	Mechanisms []string  `xml:"urn:ietf:params:xml:ns:xmpp-sasl mechanisms>mechanism"`
+	Bind       *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-bind bind"`
+}
+
+type xmppBindResult struct {
+	XMLName xml.Name `xml:"jabber:client iq"`
+	Type    string   `xml:"type,attr"`
+	JID     string   `xml:"urn:ietf:params:xml:ns:xmpp-bind bind>jid"`
+}
+
+// xmppConn is a minimal XMPP client: it authenticates with SASL PLAIN, binds a resource and sends
+// messages. Incoming stanzas are read and discarded to detect when the server closes the stream.
+type xmppConn struct {
+	mtx    sync.Mutex
+	conn   net.Conn
+	dec    *xml.Decoder
+	rooms  map[string]struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func dialXMPP(ctx context.Context, opts xmppOptions) (*xmppConn, error) {
+	local, domain, err := splitJID(opts.JID)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := brokerTLSConfig(opts.TLSSkipVerify, opts.TLSCACert, "", "")
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = domain
+
+	addr := opts.Server
+	if addr == "" {
+		addr = domain
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := "5222"
+		if opts.TLSMode == xmppTLSDirect {
+			port = "5223"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, xmppTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c := &xmppConn{conn: conn, rooms: make(map[string]struct{}), closed: make(chan struct{})}
+	if err := c.handshake(opts, local, domain, tlsConfig); err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+
+	go c.read()
+	return c, nil
+}
+
+func (c *xmppConn) handshake(opts xmppOptions, local, domain string, tlsConfig *tls.Config) error {
+	if opts.TLSMode == xmppTLSDirect {
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		c.conn = tlsConn
+	}
+
+	features, err := c.openStream(domain)
+	if err != nil {
+		return err
+	}
+
+	if opts.TLSMode == xmppTLSStart {
+		if features.StartTLS == nil {
+			return errors.New("the XMPP server does not support STARTTLS")
+		}
+		if _, err := fmt.Fprintf(c.conn, "<starttls xmlns='%s'/>", xmppNSTLS); err != nil {
+			return err
+		}
+		se, err := c.nextElement()
+		if err != nil {
+			return err
+		}
+		if se.Name.Local != "proceed" {
+			return errors.New("the XMPP server refused STARTTLS")
+		}
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		c.conn = tlsConn
+		if features, err = c.openStream(domain); err != nil {
+			return err
+		}
+	}
+
+	if !containsString(features.Mechanisms, "PLAIN") {
+		return fmt.Errorf("the XMPP server does not support PLAIN authentication, supported mechanisms: %s", strings.Join(features.Mechanisms, ", "))
+	}
+	creds := base64.StdEncoding.EncodeToString([]byte("\x00" + local + "\x00" + opts.Password))
+	if _, err := fmt.Fprintf(c.conn, "<auth xmlns='%s' mechanism='PLAIN'>%s</auth>", xmppNSSASL, creds); err != nil {
+		return err
+	}
+	se, err := c.nextElement()
+	if err != nil {
+		return err
+	}
+	if se.Name.Local != "success" {
+		return errors.New("XMPP authentication failed")
+	}
+	if err := c.dec.Skip(); err != nil {
+		return err
+	}
+
+	if _, err := c.openStream(domain); err != nil {
+		return err
+	}
+	resource := "grafana-" + util.GenerateShortUID()
+	if _, err := fmt.Fprintf(c.conn, "<iq type='set' id='bind'><bind xmlns='%s'><resource>%s</resource></bind></iq>", xmppNSBind, resource); err != nil {
+		return err
+	}
+	se, err = c.nextElement()
+	if err != nil {
+		return err
+	}
+	var bind xmppBindResult
+	if err := c.dec.DecodeElement(&bind, &se); err != nil {
+		return err
+	}
+	if bind.Type != "result" {
+		return errors.New("the XMPP server refused to bind the resource")
+	}
+
+	_, err = io.WriteString(c.conn, "<presence/>")
+	return err
+}
+
+// openStream opens a new stream, after connecting or negotiating TLS or authentication, and
+// returns the features offered by the server.
+func (c *xmppConn) openStream(domain string) (*xmppFeatures, error) {
+	if _, err := fmt.Fprintf(c.conn, "<?xml version='1.0'?><stream:stream to='%s' xmlns='%s' xmlns:stream='%s' version='1.0'>",
+		xmlEscape(domain), xmppNSClient, xmppNSStream); err != nil {
+		return nil, err
+	}
+	c.dec = xml.NewDecoder(c.conn)
+
+	se, err := c.nextElement()
+	if err != nil {
+		return nil, err
+	}
+	if se.Name.Space != xmppNSStream || se.Name.Local != "stream" {
+		return nil, fmt.Errorf("unexpected XMPP element %s", se.Name.Local)
+	}
+
+	se, err = c.nextElement()
+	if err != nil {
+		return nil, err
+	}
+	if se.Name.Space != xmppNSStream || se.Name.Local != "features" {
+		return nil, fmt.Errorf("unexpected XMPP element %s", se.Name.Local)
+	}
+	var features xmppFeatures
+	if err := c.dec.DecodeElement(&features, &se); err != nil {
+		return nil, err
+	}
+	return &features, nil
+}
+
+func (c *xmppConn) nextElement() (xml.StartElement, error) {
+	for {
+		t, err := c.dec.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if t.Name.Space == xmppNSStream && t.Name.Local == "error" {
+				return xml.StartElement{}, errors.New("XMPP stream error")
+			}
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, io.EOF
+		}
+	}
+}
+
+// read discards the stanzas sent by the server until the stream is closed.
+func (c *xmppConn) read() {
+	defer c.Close()
+	for {
+		if _, err := c.nextElement(); err != nil {
+			return
+		}
+		if err := c.dec.Skip(); err != nil {
+			return
+		}
+	}
+}
+
+// JoinRoom joins the multi-user chat room, unless the connection is already in the room.
+func (c *xmppConn) JoinRoom(ctx context.Context, room, nickname string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.rooms[room]; ok {
+		return nil
+	}
+	if err := c.write(ctx, fmt.Sprintf("<presence to='%s'><x xmlns='%s'><history maxstanzas='0'/></x></presence>",
+		xmlEscape(room+"/"+nickname), xmppNSMUC)); err != nil {
+		return err
+	}
+	c.rooms[room] = struct{}{}
+	return nil
+}
+
+func (c *xmppConn) SendMessage(ctx context.Context, to, msgType, body string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.write(ctx, fmt.Sprintf("<message to='%s' type='%s' id='%s'><body>%s</body></message>",
+		xmlEscape(to), msgType, util.GenerateShortUID(), xmlEscape(body)))
+}
+
+func (c *xmppConn) write(ctx context.Context, stanza string) error {
+	if c.Closed() {
+		return errors.New("the XMPP connection is closed")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(xmppTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	_, err := io.WriteString(c.conn, stanza)
+	return err
+}
+
+func (c *xmppConn) Closed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *xmppConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.closed)
+		_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_, _ = io.WriteString(c.conn, "</stream:stream>")
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// splitJID returns the local and domain parts of a bare or full JID.
+func splitJID(jid string) (string, string, error) {
+	if i := strings.Index(jid, "/"); i >= 0 {
+		jid = jid[:i]
+	}
+	parts := strings.Split(jid, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid JID %q, must be user@domain", jid)
+	}
+	return parts[0], parts[1], nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
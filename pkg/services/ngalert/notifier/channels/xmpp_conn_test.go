@@ -0,0 +1,151 @@
+package channels
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type xmppTestStanza struct {
+	XMLName xml.Name
+	To      string `xml:"to,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:"body"`
+	MUC     *struct {
+		XMLName xml.Name `xml:"http://jabber.org/protocol/muc x"`
+	}
+}
+
+// fakeXMPPServer accepts a single client without TLS, authenticates it with the password and
+// sends the stanzas it receives afterwards to the returned channel.
+func fakeXMPPServer(t *testing.T, password string) (string, <-chan xmppTestStanza) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	stanzas := make(chan xmppTestStanza, 10)
+	go func() {
+		defer close(stanzas)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		openStream := func(features string) *xml.Decoder {
+			dec := xml.NewDecoder(conn)
+			for {
+				tok, err := dec.Token()
+				if err != nil {
+					return nil
+				}
+				if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "stream" {
+					break
+				}
+			}
+			_, _ = fmt.Fprintf(conn, "<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' from='example.com' id='1' version='1.0'><stream:features>%s</stream:features>", features)
+			return dec
+		}
+
+		dec := openStream("<mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>SCRAM-SHA-1</mechanism><mechanism>PLAIN</mechanism></mechanisms>")
+		var auth struct {
+			Mechanism string `xml:"mechanism,attr"`
+			Value     string `xml:",chardata"`
+		}
+		if dec == nil || dec.Decode(&auth) != nil {
+			return
+		}
+		creds, _ := base64.StdEncoding.DecodeString(auth.Value)
+		if auth.Mechanism != "PLAIN" || string(creds) != "\x00grafana\x00"+password {
+			_, _ = io.WriteString(conn, "<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><not-authorized/></failure></stream:stream>")
+			return
+		}
+		_, _ = io.WriteString(conn, "<success xmlns='urn:ietf:params:xml:ns:xmpp-sasl'/>")
+
+		dec = openStream("<bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'/>")
+		var bind struct {
+			ID       string `xml:"id,attr"`
+			Resource string `xml:"bind>resource"`
+		}
+		if dec == nil || dec.Decode(&bind) != nil {
+			return
+		}
+		_, _ = fmt.Fprintf(conn, "<iq type='result' id='%s'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><jid>grafana@example.com/%s</jid></bind></iq>", bind.ID, bind.Resource)
+
+		for {
+			var s xmppTestStanza
+			if err := dec.Decode(&s); err != nil {
+				return
+			}
+			stanzas <- s
+			if s.Body == "close" {
+				_, _ = io.WriteString(conn, "</stream:stream>")
+			}
+		}
+	}()
+
+	return l.Addr().String(), stanzas
+}
+
+func TestXMPPConn(t *testing.T) {
+	t.Run("sends messages to users and rooms", func(t *testing.T) {
+		addr, stanzas := fakeXMPPServer(t, "secret")
+		c, err := dialXMPP(context.Background(), xmppOptions{JID: "grafana@example.com", Password: "secret", Server: addr, TLSMode: xmppTLSNone})
+		require.NoError(t, err)
+		defer func() { _ = c.Close() }()
+
+		require.Equal(t, "presence", (<-stanzas).XMLName.Local)
+
+		require.NoError(t, c.SendMessage(context.Background(), "oncall@example.com", "chat", "<b>firing</b> & more"))
+		s := <-stanzas
+		require.Equal(t, "message", s.XMLName.Local)
+		require.Equal(t, "oncall@example.com", s.To)
+		require.Equal(t, "chat", s.Type)
+		require.Equal(t, "<b>firing</b> & more", s.Body)
+
+		// Rooms are joined once per connection.
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.JoinRoom(context.Background(), "alerts@conference.example.com", "Grafana"))
+		}
+		require.NoError(t, c.SendMessage(context.Background(), "alerts@conference.example.com", "groupchat", "firing"))
+		s = <-stanzas
+		require.Equal(t, "presence", s.XMLName.Local)
+		require.Equal(t, "alerts@conference.example.com/Grafana", s.To)
+		require.NotNil(t, s.MUC)
+		s = <-stanzas
+		require.Equal(t, "message", s.XMLName.Local)
+		require.Equal(t, "groupchat", s.Type)
+	})
+
+	t.Run("the connection is closed when the server closes the stream", func(t *testing.T) {
+		addr, stanzas := fakeXMPPServer(t, "secret")
+		c, err := dialXMPP(context.Background(), xmppOptions{JID: "grafana@example.com", Password: "secret", Server: addr, TLSMode: xmppTLSNone})
+		require.NoError(t, err)
+
+		require.NoError(t, c.SendMessage(context.Background(), "oncall@example.com", "chat", "close"))
+		for range stanzas {
+		}
+		require.Eventually(t, c.Closed, time.Second, 10*time.Millisecond)
+		require.Error(t, c.SendMessage(context.Background(), "oncall@example.com", "chat", "firing"))
+	})
+
+	t.Run("error when authentication fails", func(t *testing.T) {
+		addr, _ := fakeXMPPServer(t, "secret")
+		_, err := dialXMPP(context.Background(), xmppOptions{JID: "grafana@example.com", Password: "wrong", Server: addr, TLSMode: xmppTLSNone})
+		require.EqualError(t, err, "XMPP authentication failed")
+	})
+
+	t.Run("error when the server does not support STARTTLS", func(t *testing.T) {
+		addr, _ := fakeXMPPServer(t, "secret")
+		_, err := dialXMPP(context.Background(), xmppOptions{JID: "grafana@example.com", Password: "secret", Server: addr, TLSMode: xmppTLSStart})
+		require.EqualError(t, err, "the XMPP server does not support STARTTLS")
+	})
+}
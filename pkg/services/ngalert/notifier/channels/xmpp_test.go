@@ -0,0 +1,227 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeXMPPMessage struct {
+	to, msgType, body string
+}
+
+type fakeXMPPClient struct {
+	rooms    []string
+	messages []fakeXMPPMessage
+	err      error
+	closed   bool
+}
+
+func (c *fakeXMPPClient) JoinRoom(_ context.Context, room, nickname string) error {
+	c.rooms = append(c.rooms, room+"/"+nickname)
+	return c.err
+}
+
+func (c *fakeXMPPClient) SendMessage(_ context.Context, to, msgType, body string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.messages = append(c.messages, fakeXMPPMessage{to: to, msgType: msgType, body: body})
+	return nil
+}
+
+func (c *fakeXMPPClient) Closed() bool {
+	return c.closed
+}
+
+func (c *fakeXMPPClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestXMPPNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1"},
+		},
+	}}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expOptions   xmppOptions
+		expRooms     []string
+		expMsg       fakeXMPPMessage
+		expInitError string
+	}{
+		{
+			name: "Message to a user",
+			settings: map[string]interface{}{
+				"jid":       "grafana@example.com",
+				"password":  "secret",
+				"recipient": "oncall@example.com",
+			},
+			expOptions: xmppOptions{JID: "grafana@example.com", Password: "secret", TLSMode: "starttls"},
+			expMsg: fakeXMPPMessage{
+				to:      "oncall@example.com",
+				msgType: "chat",
+				body:    "[FIRING:1]  (val1)\n**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1",
+			},
+		}, {
+			name: "Message to a room",
+			settings: map[string]interface{}{
+				"jid":       "grafana@example.com",
+				"password":  "secret",
+				"server":    "xmpp.example.com:5223",
+				"tls":       "tls",
+				"recipient": "alerts@conference.example.com",
+				"room":      true,
+				"title":     "",
+				"message":   "{{ len .Alerts.Firing }} firing",
+			},
+			expOptions: xmppOptions{JID: "grafana@example.com", Password: "secret", Server: "xmpp.example.com:5223", TLSMode: "tls"},
+			expRooms:   []string{"alerts@conference.example.com/Grafana"},
+			expMsg:     fakeXMPPMessage{to: "alerts@conference.example.com", msgType: "groupchat", body: "1 firing"},
+		}, {
+			name:         "Error when the JID is invalid",
+			settings:     map[string]interface{}{"jid": "grafana", "password": "secret", "recipient": "oncall@example.com"},
+			expInitError: `invalid JID "grafana", must be user@domain`,
+		}, {
+			name:         "Error when the password is missing",
+			settings:     map[string]interface{}{"jid": "grafana@example.com", "recipient": "oncall@example.com"},
+			expInitError: "could not find password in settings",
+		}, {
+			name:         "Error when the recipient is missing",
+			settings:     map[string]interface{}{"jid": "grafana@example.com", "password": "secret"},
+			expInitError: "could not find recipient in settings",
+		}, {
+			name:         "Error with an invalid TLS mode",
+			settings:     map[string]interface{}{"jid": "grafana@example.com", "password": "secret", "recipient": "oncall@example.com", "tls": "ssl"},
+			expInitError: `invalid TLS mode "ssl", must be starttls, tls or none`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "xmpp_testing",
+				Type:     "xmpp",
+				Settings: simplejson.NewFromAny(c.settings),
+			}
+
+			cfg, err := NewXMPPConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			client := &fakeXMPPClient{}
+			var options xmppOptions
+			restore := setXMPPConnect(func(_ context.Context, opts xmppOptions) (xmppClient, error) {
+				options = opts
+				return client, nil
+			})
+			defer restore()
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			n := NewXMPPNotifier(cfg, tmpl)
+
+			ok, err := n.Notify(ctx, alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expOptions, options)
+			require.Equal(t, c.expRooms, client.rooms)
+			require.Equal(t, []fakeXMPPMessage{c.expMsg}, client.messages)
+		})
+	}
+}
+
+func TestXMPPNotifier_Connections(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settings := simplejson.NewFromAny(map[string]interface{}{
+		"jid":       "grafana@example.com",
+		"password":  "secret",
+		"recipient": "oncall@example.com",
+		"message":   "firing",
+		"title":     "",
+	})
+	cfg, err := NewXMPPConfig(&NotificationChannelConfig{Name: "xmpp_testing", Type: "xmpp", Settings: settings}, func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+		return fallback
+	})
+	require.NoError(t, err)
+
+	var clients []*fakeXMPPClient
+	restore := setXMPPConnect(func(_ context.Context, _ xmppOptions) (xmppClient, error) {
+		c := &fakeXMPPClient{}
+		clients = append(clients, c)
+		return c, nil
+	})
+	defer restore()
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	// The connection is reused by the notifiers of the same account.
+	for i := 0; i < 2; i++ {
+		_, err := NewXMPPNotifier(cfg, tmpl).Notify(ctx, alerts...)
+		require.NoError(t, err)
+	}
+	require.Len(t, clients, 1)
+	require.Len(t, clients[0].messages, 2)
+
+	// A broken connection is replaced.
+	clients[0].err = errors.New("broken pipe")
+	_, err = NewXMPPNotifier(cfg, tmpl).Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, clients[0].closed)
+	require.Len(t, clients, 2)
+	require.Len(t, clients[1].messages, 1)
+
+	// Errors of a new connection are returned.
+	connectErr := errors.New("not authorized")
+	restoreErr := setXMPPConnect(func(_ context.Context, _ xmppOptions) (xmppClient, error) {
+		return nil, connectErr
+	})
+	defer restoreErr()
+	ok, err := NewXMPPNotifier(cfg, tmpl).Notify(ctx, alerts...)
+	require.ErrorIs(t, err, connectErr)
+	require.False(t, ok)
+}
+
+// setXMPPConnect replaces the connect function and the connections for the duration of a test.
+func setXMPPConnect(connect func(context.Context, xmppOptions) (xmppClient, error)) func() {
+	origConnect, origConnections := xmppConnect, xmppConnections
+	xmppConnect = connect
+	xmppConnections = newConnPool(xmppIdleTimeout)
+	return func() {
+		xmppConnect, xmppConnections = origConnect, origConnections
+	}
+}
@@ -0,0 +1,255 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// zapierFieldName matches the names of the mapped fields, which Zapier shows as is.
+var zapierFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// zapierDefaultFields are the fields mapped by default.
+var zapierDefaultFields = map[string]string{
+	"severity":    "labels.severity",
+	"summary":     "annotations.summary",
+	"description": "annotations.description",
+}
+
+// zapierFixedFields are the fields of all the payloads, which cannot be mapped.
+var zapierFixedFields = map[string]struct{}{
+	"status": {}, "title": {}, "message": {}, "receiver": {}, "group_key": {}, "alertname": {},
+	"alert_count": {}, "firing_count": {}, "resolved_count": {}, "starts_at": {}, "rule_url": {},
+	"silence_url": {}, "dashboard_url": {}, "panel_url": {},
+}
+
+type ZapierConfig struct {
+	*NotificationChannelConfig
+	URL     string
+	Title   string
+	Message string
+	Fields  map[string]string
+}
+
+func ZapierFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewZapierConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewZapierNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewZapierConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ZapierConfig, error) {
+	rawURL := strings.TrimSpace(decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString()))
+	if rawURL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.New("invalid URL of the catch hook")
+	}
+	fields, err := zapierFieldsFromSettings(config.Settings.Get("fields"))
+	if err != nil {
+		return nil, err
+	}
+	return &ZapierConfig{
+		NotificationChannelConfig: config,
+		URL:                       rawURL,
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+		Fields:                    fields,
+	}, nil
+}
+
+// zapierFieldsFromSettings returns the mapped fields, by name, to the label or annotation they are
+// mapped from, such as labels.severity. They are a JSON object when provisioned, and one
+// field=source per line when set from the UI. The default fields are replaced when set.
+func zapierFieldsFromSettings(setting *simplejson.Json) (map[string]string, error) {
+	fields := map[string]string{}
+	if m, err := setting.Map(); err == nil {
+		for k, v := range m {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid source of field %q, must be a string", k)
+			}
+			fields[k] = s
+		}
+	} else {
+		for _, line := range strings.Split(setting.MustString(), "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid field mapping %q, must be field=labels.name or field=annotations.name", line)
+			}
+			fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if len(fields) == 0 {
+		return zapierDefaultFields, nil
+	}
+
+	for name, source := range fields {
+		if !zapierFieldName.MatchString(name) {
+			return nil, fmt.Errorf("invalid field name %q, must be lowercase letters, digits and underscores", name)
+		}
+		if _, ok := zapierFixedFields[name]; ok {
+			return nil, fmt.Errorf("field %q is always sent and cannot be mapped", name)
+		}
+		if (!strings.HasPrefix(source, "labels.") && !strings.HasPrefix(source, "annotations.")) || strings.HasSuffix(source, ".") {
+			return nil, fmt.Errorf("invalid source %q of field %q, must be labels.name or annotations.name", source, name)
+		}
+	}
+	return fields, nil
+}
+
+// NewZapierNotifier is the constructor for the Zapier notifier.
+func NewZapierNotifier(config *ZapierConfig, ns notifications.WebhookSender, t *template.Template) *ZapierNotifier {
+	return &ZapierNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:     config.URL,
+		Title:   config.Title,
+		Message: config.Message,
+		Fields:  config.Fields,
+		log:     log.New("alerting.notifier.zapier"),
+		ns:      ns,
+		tmpl:    t,
+	}
+}
+
+// ZapierNotifier is responsible for sending alert notifications to the catch hooks of Zapier, as
+// flat JSON objects with the same fields in all the notifications, which Zaps map to their actions
+// without handling the lists of alerts.
+type ZapierNotifier struct {
+	*Base
+	URL     string
+	Title   string
+	Message string
+	Fields  map[string]string
+	log     log.Logger
+	ns      notifications.WebhookSender
+	tmpl    *template.Template
+}
+
+// Notify posts the notification. The fields that are not the same for all the alerts, such as
+// their URLs or the mapped fields, are the first alert's value or the distinct values of the
+// alerts separated by commas. Missing values are empty strings, so that all the fields are
+// always sent.
+func (zn *ZapierNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	zn.log.Debug("executing Zapier notification", "notification", zn.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, zn.tmpl, as, zn.log, &tmplErr)
+
+	firing := len(data.Alerts.Firing())
+	payload := map[string]interface{}{
+		"status":         data.Status,
+		"title":          strings.TrimSpace(tmpl(zn.Title)),
+		"message":        strings.TrimSpace(tmpl(zn.Message)),
+		"receiver":       data.Receiver,
+		"group_key":      groupKey.String(),
+		"alertname":      zapierValue(data.Alerts, func(a ExtendedAlert) string { return a.Labels["alertname"] }),
+		"alert_count":    len(data.Alerts),
+		"firing_count":   firing,
+		"resolved_count": len(data.Alerts) - firing,
+		"starts_at":      "",
+		"rule_url":       zn.RuleListURL(zn.tmpl.ExternalURL),
+		"silence_url":    "",
+		"dashboard_url":  "",
+		"panel_url":      "",
+	}
+	if len(data.Alerts) > 0 {
+		first := data.Alerts[0]
+		startsAt := first.StartsAt
+		for _, a := range data.Alerts {
+			if a.StartsAt.Before(startsAt) {
+				startsAt = a.StartsAt
+			}
+		}
+		payload["starts_at"] = startsAt.UTC().Format(time.RFC3339)
+		payload["silence_url"] = first.SilenceURL
+		payload["dashboard_url"] = first.DashboardURL
+		payload["panel_url"] = first.PanelURL
+	}
+	for name, source := range zn.Fields {
+		kind, key := zapierSource(source)
+		payload[name] = zapierValue(data.Alerts, func(a ExtendedAlert) string {
+			if kind == "labels" {
+				return a.Labels[key]
+			}
+			return a.Annotations[key]
+		})
+	}
+	if tmplErr != nil {
+		zn.log.Warn("failed to template Zapier message", "err", tmplErr.Error())
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:         zn.URL,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	if err := zn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		zn.log.Error("failed to send notification to Zapier", "err", err, "notification", zn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// zapierSource returns the kind, labels or annotations, and the name of the source of a field.
+func zapierSource(source string) (string, string) {
+	parts := strings.SplitN(source, ".", 2)
+	return parts[0], parts[1]
+}
+
+// zapierValue returns the distinct non-empty values of the alerts, separated by commas.
+func zapierValue(alerts ExtendedAlerts, value func(ExtendedAlert) string) string {
+	var values []string
+	seen := map[string]struct{}{}
+	for _, a := range alerts {
+		v := value(a)
+		if _, ok := seen[v]; ok || v == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	return strings.Join(values, ", ")
+}
+
+func (zn *ZapierNotifier) SendResolved() bool {
+	return !zn.GetDisableResolveMessage()
+}
@@ -0,0 +1,122 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestZapierNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-1", "severity": "critical"},
+			Annotations: model.LabelSet{"summary": "db-1 is full"},
+			StartsAt:    time.Date(2022, 6, 1, 10, 5, 0, 0, time.UTC),
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "DiskFull", "instance": "db-2", "severity": "critical"},
+			Annotations: model.LabelSet{"summary": "db-2 is full"},
+			StartsAt:    time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC),
+			EndsAt:      time.Date(2022, 6, 1, 10, 10, 0, 0, time.UTC),
+		},
+	}}
+
+	cases := []struct {
+		name         string
+		settings     string
+		expBody      string
+		expInitError string
+	}{
+		{
+			name:     "Default fields",
+			settings: `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "title": "{{ .CommonLabels.alertname }}", "message": "{{ len .Alerts.Firing }} firing"}`,
+			expBody: `{"status": "firing", "title": "DiskFull", "message": "1 firing", "receiver": "zapier", "group_key": "alertname",
+				"alertname": "DiskFull", "alert_count": 2, "firing_count": 1, "resolved_count": 1, "starts_at": "2022-06-01T10:00:00Z",
+				"rule_url": "http://localhost/alerting/list", "dashboard_url": "", "panel_url": "",
+				"silence_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DDiskFull&matcher=instance%3Ddb-1&matcher=severity%3Dcritical",
+				"severity": "critical", "summary": "db-1 is full, db-2 is full", "description": ""}`,
+		}, {
+			name: "Mapped fields",
+			settings: `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "title": "{{ .CommonLabels.alertname }}", "message": "message",
+				"fields": "host = labels.instance\nrunbook=annotations.runbook_url"}`,
+			expBody: `{"status": "firing", "title": "DiskFull", "message": "message", "receiver": "zapier", "group_key": "alertname",
+				"alertname": "DiskFull", "alert_count": 2, "firing_count": 1, "resolved_count": 1, "starts_at": "2022-06-01T10:00:00Z",
+				"rule_url": "http://localhost/alerting/list", "dashboard_url": "", "panel_url": "",
+				"silence_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DDiskFull&matcher=instance%3Ddb-1&matcher=severity%3Dcritical",
+				"host": "db-1, db-2", "runbook": ""}`,
+		}, {
+			name:     "Provisioned fields",
+			settings: `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "title": "title", "message": "message", "fields": {"priority": "labels.severity"}}`,
+			expBody: `{"status": "firing", "title": "title", "message": "message", "receiver": "zapier", "group_key": "alertname",
+				"alertname": "DiskFull", "alert_count": 2, "firing_count": 1, "resolved_count": 1, "starts_at": "2022-06-01T10:00:00Z",
+				"rule_url": "http://localhost/alerting/list", "dashboard_url": "", "panel_url": "",
+				"silence_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DDiskFull&matcher=instance%3Ddb-1&matcher=severity%3Dcritical",
+				"priority": "critical"}`,
+		}, {
+			name:         "Error when the URL is missing",
+			settings:     `{}`,
+			expInitError: "could not find url property in settings",
+		}, {
+			name:         "Error when a fixed field is mapped",
+			settings:     `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "fields": "status=labels.status"}`,
+			expInitError: `field "status" is always sent and cannot be mapped`,
+		}, {
+			name:         "Error when the source is invalid",
+			settings:     `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "fields": "host=instance"}`,
+			expInitError: `invalid source "instance" of field "host", must be labels.name or annotations.name`,
+		}, {
+			name:         "Error when the field name is invalid",
+			settings:     `{"url": "https://hooks.zapier.com/hooks/catch/1/abc/", "fields": "Host Name=labels.instance"}`,
+			expInitError: `invalid field name "Host Name", must be lowercase letters, digits and underscores`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "zapier_testing",
+				Type:     "zapier",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewZapierConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "zapier")
+			ctx = notify.WithNow(ctx, time.Date(2022, 6, 1, 10, 15, 0, 0, time.UTC))
+			ok, err := NewZapierNotifier(cfg, webhookSender, tmpl).Notify(ctx, alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://hooks.zapier.com/hooks/catch/1/abc/", webhookSender.Webhook.Url)
+			require.JSONEq(t, c.expBody, webhookSender.Webhook.Body)
+		})
+	}
+}
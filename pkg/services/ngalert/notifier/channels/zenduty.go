@@ -0,0 +1,240 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	defaultZendutyURL           = "https://www.zenduty.com/api/events/"
+	defaultZendutySeverityLabel = "severity"
+
+	zendutyAlertTypeCritical = "critical"
+	zendutyAlertTypeError    = "error"
+	zendutyAlertTypeWarning  = "warning"
+	zendutyAlertTypeInfo     = "info"
+	zendutyAlertTypeResolved = "resolved"
+)
+
+// zendutySeverities maps the common values of severity labels to the alert types of Zenduty.
+var zendutySeverities = map[string]string{
+	"critical": zendutyAlertTypeCritical,
+	"high":     zendutyAlertTypeCritical,
+	"page":     zendutyAlertTypeCritical,
+	"error":    zendutyAlertTypeError,
+	"major":    zendutyAlertTypeError,
+	"warning":  zendutyAlertTypeWarning,
+	"warn":     zendutyAlertTypeWarning,
+	"minor":    zendutyAlertTypeWarning,
+	"info":     zendutyAlertTypeInfo,
+	"low":      zendutyAlertTypeInfo,
+	"none":     zendutyAlertTypeInfo,
+}
+
+type ZendutyConfig struct {
+	*NotificationChannelConfig
+	URL            string
+	IntegrationKey string
+	SeverityLabel  string
+	Message        string
+	Summary        string
+}
+
+func ZendutyFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewZendutyConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewZendutyNotifier(cfg, fc.ImageStore, fc.NotificationService, fc.Template), nil
+}
+
+func NewZendutyConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ZendutyConfig, error) {
+	key := decryptFunc(context.Background(), config.SecureSettings, "integrationKey", config.Settings.Get("integrationKey").MustString())
+	if key == "" {
+		return nil, errors.New("could not find integration key in settings")
+	}
+	return &ZendutyConfig{
+		NotificationChannelConfig: config,
+		URL:                       config.Settings.Get("url").MustString(defaultZendutyURL),
+		IntegrationKey:            key,
+		SeverityLabel:             config.Settings.Get("severityLabel").MustString(defaultZendutySeverityLabel),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.title" . }}`),
+		Summary:                   config.Settings.Get("summary").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewZendutyNotifier is the constructor for the Zenduty notifier.
+func NewZendutyNotifier(config *ZendutyConfig, images ImageStore, ns notifications.WebhookSender, t *template.Template) *ZendutyNotifier {
+	return &ZendutyNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:            config.URL,
+		IntegrationKey: config.IntegrationKey,
+		SeverityLabel:  config.SeverityLabel,
+		Message:        config.Message,
+		Summary:        config.Summary,
+		log:            log.New("alerting.notifier.zenduty"),
+		images:         images,
+		ns:             ns,
+		tmpl:           t,
+	}
+}
+
+// ZendutyNotifier sends alerts to an API integration of Zenduty. Each Grafana alert is an
+// event whose entity ID is the fingerprint of the alert, so that Zenduty resolves the incident
+// it created once the alert is resolved.
+type ZendutyNotifier struct {
+	*Base
+	URL            string
+	IntegrationKey string
+	SeverityLabel  string
+	Message        string
+	Summary        string
+	log            log.Logger
+	images         ImageStore
+	ns             notifications.WebhookSender
+	tmpl           *template.Template
+}
+
+type zendutyEvent struct {
+	AlertType string                 `json:"alert_type"`
+	Message   string                 `json:"message"`
+	Summary   string                 `json:"summary"`
+	EntityID  string                 `json:"entity_id"`
+	Payload   map[string]interface{} `json:"payload"`
+	URLs      []zendutyURL           `json:"urls,omitempty"`
+}
+
+type zendutyURL struct {
+	LinkURL  string `json:"link_url"`
+	LinkText string `json:"link_text"`
+}
+
+// Notify sends an event to Zenduty for each alert.
+func (zn *ZendutyNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	zn.log.Debug("sending Zenduty notification", "notification", zn.Name)
+
+	var tmplErr error
+	_, data := TmplText(ctx, zn.tmpl, as, zn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, zn.log, zn.images,
+		func(index int, image ngmodels.Image) error {
+			if image.URL != "" {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		}, as...)
+
+	events := make([]zendutyEvent, 0, len(data.Alerts))
+	for i, alert := range data.Alerts {
+		// Events are templated for each alert, as each one is an incident of its own.
+		tmpl, _ := TmplText(ctx, zn.tmpl, as[i:i+1], zn.log, &tmplErr)
+		events = append(events, zendutyEvent{
+			AlertType: zn.alertType(alert),
+			Message:   tmpl(zn.Message),
+			Summary:   tmpl(zn.Summary),
+			EntityID:  alert.Fingerprint,
+			Payload: map[string]interface{}{
+				"labels":      alert.Labels,
+				"annotations": alert.Annotations,
+				"startsAt":    alert.StartsAt,
+			},
+			URLs: zendutyURLs(alert),
+		})
+	}
+	if tmplErr != nil {
+		zn.log.Warn("failed to template Zenduty message", "err", tmplErr.Error())
+	}
+
+	u := strings.TrimSuffix(zn.URL, "/") + "/" + zn.IntegrationKey + "/"
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return false, err
+		}
+		cmd := &models.SendWebhookSync{
+			Url:         u,
+			Body:        string(body),
+			HttpMethod:  "POST",
+			ContentType: "application/json",
+			Validation:  validateZendutyResponse,
+		}
+		if err := zn.ns.SendWebhookSync(ctx, cmd); err != nil {
+			zn.log.Error("failed to send Zenduty event", "err", err, "notification", zn.Name, "entity", event.EntityID)
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// alertType returns the alert type of the event of an alert, from the value of its severity
+// label. Alerts without a known severity are critical.
+func (zn *ZendutyNotifier) alertType(alert ExtendedAlert) string {
+	if alert.Status == string(model.AlertResolved) {
+		return zendutyAlertTypeResolved
+	}
+	if t, ok := zendutySeverities[strings.ToLower(alert.Labels[zn.SeverityLabel])]; ok {
+		return t
+	}
+	return zendutyAlertTypeCritical
+}
+
+func zendutyURLs(alert ExtendedAlert) []zendutyURL {
+	var urls []zendutyURL
+	for _, l := range []zendutyURL{
+		{LinkURL: alert.GeneratorURL, LinkText: "Alert rule"},
+		{LinkURL: alert.SilenceURL, LinkText: "Silence"},
+		{LinkURL: alert.DashboardURL, LinkText: "Dashboard"},
+		{LinkURL: alert.PanelURL, LinkText: "Panel"},
+		{LinkURL: alert.ImageURL, LinkText: "Image"},
+	} {
+		if l.LinkURL != "" {
+			urls = append(urls, l)
+		}
+	}
+	return urls
+}
+
+func validateZendutyResponse(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	var res struct {
+		Detail  string `json:"detail"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &res); err == nil {
+		if res.Detail != "" {
+			return fmt.Errorf("the Zenduty API returned status %d: %s", statusCode, res.Detail)
+		}
+		if res.Message != "" {
+			return fmt.Errorf("the Zenduty API returned status %d: %s", statusCode, res.Message)
+		}
+	}
+	return fmt.Errorf("the Zenduty API returned status %d", statusCode)
+}
+
+func (zn *ZendutyNotifier) SendResolved() bool {
+	return !zn.GetDisableResolveMessage()
+}
@@ -0,0 +1,181 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestZendutyNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	images := newFakeImageStore(2)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	warning := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighCPU", "severity": "Warning"},
+			Annotations: model.LabelSet{"summary": "CPU is high", "__alertImageToken__": "test-image-1"},
+			StartsAt:    startsAt,
+		},
+	}
+	unknown := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighCPU", "severity": "p3"},
+			StartsAt: startsAt,
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighCPU", "severity": "info"},
+			StartsAt: startsAt,
+			EndsAt:   startsAt.Add(time.Hour),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expEvents    []map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "An event for each alert with the alert type of its severity",
+			settings: `{"integrationKey": "key", "message": "{{ .CommonLabels.alertname }}", "summary": "{{ .CommonAnnotations.summary }}"}`,
+			alerts:   []*types.Alert{warning, unknown, resolved},
+			expURL:   "https://www.zenduty.com/api/events/key/",
+			expEvents: []map[string]interface{}{
+				{
+					"alert_type": "warning",
+					"message":    "HighCPU",
+					"summary":    "CPU is high",
+					"entity_id":  warning.Fingerprint().String(),
+					"payload": map[string]interface{}{
+						"labels":      map[string]interface{}{"alertname": "HighCPU", "severity": "Warning"},
+						"annotations": map[string]interface{}{"summary": "CPU is high"},
+						"startsAt":    "2022-08-01T10:00:00Z",
+					},
+					"urls": []interface{}{
+						map[string]interface{}{"link_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=severity%3DWarning", "link_text": "Silence"},
+						map[string]interface{}{"link_url": "https://www.example.com/test-image-1.jpg", "link_text": "Image"},
+					},
+				}, {
+					"alert_type": "critical",
+					"message":    "HighCPU",
+					"summary":    "",
+					"entity_id":  unknown.Fingerprint().String(),
+					"payload": map[string]interface{}{
+						"labels":      map[string]interface{}{"alertname": "HighCPU", "severity": "p3"},
+						"annotations": map[string]interface{}{},
+						"startsAt":    "2022-08-01T10:00:00Z",
+					},
+					"urls": []interface{}{
+						map[string]interface{}{"link_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=severity%3Dp3", "link_text": "Silence"},
+					},
+				}, {
+					"alert_type": "resolved",
+					"message":    "HighCPU",
+					"summary":    "",
+					"entity_id":  resolved.Fingerprint().String(),
+					"payload": map[string]interface{}{
+						"labels":      map[string]interface{}{"alertname": "HighCPU", "severity": "info"},
+						"annotations": map[string]interface{}{},
+						"startsAt":    "2022-08-01T10:00:00Z",
+					},
+					"urls": []interface{}{
+						map[string]interface{}{"link_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=severity%3Dinfo", "link_text": "Silence"},
+					},
+				},
+			},
+		}, {
+			name:     "Custom URL and severity label",
+			settings: `{"integrationKey": "key", "url": "https://zenduty.example.com/api/events", "severityLabel": "priority", "message": "{{ .CommonLabels.alertname }}", "summary": ""}`,
+			alerts: []*types.Alert{{
+				Alert: model.Alert{
+					Labels:   model.LabelSet{"alertname": "HighCPU", "priority": "low"},
+					StartsAt: startsAt,
+				},
+			}},
+			expURL: "https://zenduty.example.com/api/events/key/",
+			expEvents: []map[string]interface{}{
+				{
+					"alert_type": "info",
+					"message":    "HighCPU",
+					"summary":    "",
+					"entity_id":  model.LabelSet{"alertname": "HighCPU", "priority": "low"}.Fingerprint().String(),
+					"payload": map[string]interface{}{
+						"labels":      map[string]interface{}{"alertname": "HighCPU", "priority": "low"},
+						"annotations": map[string]interface{}{},
+						"startsAt":    "2022-08-01T10:00:00Z",
+					},
+					"urls": []interface{}{
+						map[string]interface{}{"link_url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHighCPU&matcher=priority%3Dlow", "link_text": "Silence"},
+					},
+				},
+			},
+		}, {
+			name:         "Error with a missing integration key",
+			settings:     `{}`,
+			expInitError: "could not find integration key in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+
+			m := &NotificationChannelConfig{
+				Name:     "zenduty_testing",
+				Type:     "zenduty",
+				Settings: settingsJSON,
+			}
+
+			cfg, err := NewZendutyConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ns := mockNotificationService()
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := NewZendutyNotifier(cfg, images, ns, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Len(t, ns.Webhooks, len(c.expEvents))
+			for i, req := range ns.Webhooks {
+				require.Equal(t, c.expURL, req.Url)
+				var event map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(req.Body), &event))
+				require.Equal(t, c.expEvents[i], event)
+			}
+		})
+	}
+}
+
+func TestValidateZendutyResponse(t *testing.T) {
+	require.NoError(t, validateZendutyResponse([]byte(`{"message":"Event created"}`), 201))
+	require.EqualError(t, validateZendutyResponse([]byte(`{"detail":"Integration not found"}`), 404), "the Zenduty API returned status 404: Integration not found")
+	require.EqualError(t, validateZendutyResponse([]byte(`{"message":"Invalid alert_type"}`), 400), "the Zenduty API returned status 400: Invalid alert_type")
+	require.EqualError(t, validateZendutyResponse(nil, 502), "the Zenduty API returned status 502")
+}
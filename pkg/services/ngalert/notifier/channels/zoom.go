@@ -0,0 +1,197 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+type ZoomConfig struct {
+	*NotificationChannelConfig
+	URL     string
+	Token   string
+	Title   string
+	Message string
+}
+
+func ZoomFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewZoomConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewZoomNotifier(cfg, fc.NotificationService, fc.Template), nil
+}
+
+func NewZoomConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*ZoomConfig, error) {
+	endpoint := decryptFunc(context.Background(), config.SecureSettings, "url", config.Settings.Get("url").MustString())
+	if endpoint == "" {
+		return nil, errors.New("could not find endpoint URL in settings")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+	// Messages are sent in the full format, which has a header and a body.
+	q := u.Query()
+	q.Set("format", "full")
+	u.RawQuery = q.Encode()
+
+	token := decryptFunc(context.Background(), config.SecureSettings, "token", config.Settings.Get("token").MustString())
+	if token == "" {
+		return nil, errors.New("could not find verification token in settings")
+	}
+	return &ZoomConfig{
+		NotificationChannelConfig: config,
+		URL:                       u.String(),
+		Token:                     token,
+		Title:                     config.Settings.Get("title").MustString(DefaultMessageTitleEmbed),
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+	}, nil
+}
+
+// NewZoomNotifier is the constructor for the Zoom Team Chat notifier.
+func NewZoomNotifier(config *ZoomConfig, ns notifications.WebhookSender, t *template.Template) *ZoomNotifier {
+	return &ZoomNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+		}),
+		URL:     config.URL,
+		Token:   config.Token,
+		Title:   config.Title,
+		Message: config.Message,
+		log:     log.New("alerting.notifier.zoom"),
+		ns:      ns,
+		tmpl:    t,
+	}
+}
+
+// ZoomNotifier is responsible for sending alert notifications to a Zoom Team Chat channel
+// through the incoming webhook app.
+type ZoomNotifier struct {
+	*Base
+	URL     string
+	Token   string
+	Title   string
+	Message string
+	log     log.Logger
+	ns      notifications.WebhookSender
+	tmpl    *template.Template
+}
+
+type zoomMessage struct {
+	Head zoomHead          `json:"head"`
+	Body []zoomBodyElement `json:"body"`
+}
+
+type zoomHead struct {
+	Text    string     `json:"text"`
+	Style   *zoomStyle `json:"style,omitempty"`
+	SubHead *zoomText  `json:"sub_head,omitempty"`
+}
+
+type zoomText struct {
+	Text string `json:"text"`
+}
+
+type zoomStyle struct {
+	Color string `json:"color,omitempty"`
+	Bold  bool   `json:"bold,omitempty"`
+}
+
+type zoomBodyElement struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Link string `json:"link,omitempty"`
+}
+
+// Notify sends a message with the title of the notification as its header, colored by the state
+// of the alerts, and a link to the alert rule.
+func (zn *ZoomNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	zn.log.Debug("executing Zoom Team Chat notification", "notification", zn.Name)
+
+	var tmplErr error
+	tmpl, _ := TmplText(ctx, zn.tmpl, as, zn.log, &tmplErr)
+
+	firing := 0
+	for _, a := range as {
+		if !a.Resolved() {
+			firing++
+		}
+	}
+	title, _ := zn.Truncate(strings.TrimSpace(tmpl(zn.Title)), 256)
+	text, _ := zn.FitAlerts(ctx, zn.tmpl.ExternalURL, as, func(alerts []*types.Alert) string {
+		tmpl, _ := TmplText(ctx, zn.tmpl, alerts, zn.log, &tmplErr)
+		return tmpl(zn.Message)
+	})
+	if tmplErr != nil {
+		zn.log.Warn("failed to template Zoom Team Chat message", "err", tmplErr.Error())
+	}
+
+	msg := zoomMessage{
+		Head: zoomHead{
+			Text:    title,
+			Style:   &zoomStyle{Color: getAlertStatusColor(types.Alerts(as...).Status()), Bold: true},
+			SubHead: &zoomText{Text: fmt.Sprintf("Firing: %d, Resolved: %d", firing, len(as)-firing)},
+		},
+		Body: []zoomBodyElement{{Type: "message", Text: text}},
+	}
+	if ruleURL := zn.ruleURL(as); ruleURL != "" {
+		msg.Body = append(msg.Body, zoomBodyElement{Type: "message", Text: "View alert rule", Link: ruleURL})
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+	cmd := &models.SendWebhookSync{
+		Url:        zn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Authorization": zn.Token,
+		},
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	if err := zn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		zn.log.Error("failed to send notification to Zoom Team Chat", "err", err, "notification", zn.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// ruleURL returns the URL of the alert rule of the alerts, or the URL of the list of the alert
+// rules if they come from several rules.
+func (zn *ZoomNotifier) ruleURL(as []*types.Alert) string {
+	generatorURL := as[0].GeneratorURL
+	for _, a := range as[1:] {
+		if a.GeneratorURL != generatorURL {
+			generatorURL = ""
+			break
+		}
+	}
+	if generatorURL != "" {
+		return generatorURL
+	}
+	return zn.RuleListURL(zn.tmpl.ExternalURL)
+}
+
+func (zn *ZoomNotifier) SendResolved() bool {
+	return !zn.GetDisableResolveMessage()
+}
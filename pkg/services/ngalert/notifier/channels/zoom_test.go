@@ -0,0 +1,138 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestZoomNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations:  model.LabelSet{"ann1": "annv1"},
+			GeneratorURL: "http://localhost/alerting/grafana/rule-uid/view",
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert2", "lbl1": "val2"},
+			GeneratorURL: "http://localhost/alerting/grafana/other-rule-uid/view",
+		},
+	}
+	resolved.EndsAt = resolved.StartsAt.Add(1)
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       map[string]interface{}
+		expInitError string
+	}{
+		{
+			name:     "Firing alert with the default message",
+			settings: `{"url": "https://integrations.zoom.us/chat/webhooks/incomingwebhook/abc", "token": "verification"}`,
+			alerts:   []*types.Alert{firing},
+			expURL:   "https://integrations.zoom.us/chat/webhooks/incomingwebhook/abc?format=full",
+			expMsg: map[string]interface{}{
+				"head": map[string]interface{}{
+					"text":     "[FIRING:1]  (val1)",
+					"style":    map[string]interface{}{"color": "#D63232", "bold": true},
+					"sub_head": map[string]interface{}{"text": "Firing: 1, Resolved: 0"},
+				},
+				"body": []interface{}{
+					map[string]interface{}{
+						"type": "message",
+						"text": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: http://localhost/alerting/grafana/rule-uid/view\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+					},
+					map[string]interface{}{
+						"type": "message",
+						"text": "View alert rule",
+						"link": "http://localhost/alerting/grafana/rule-uid/view",
+					},
+				},
+			},
+		}, {
+			name:     "Alerts of several rules link to the list of rules",
+			settings: `{"url": "https://integrations.zoom.us/chat/webhooks/incomingwebhook/abc?format=message", "token": "verification", "title": "{{ len .Alerts }} alerts", "message": "{{ len .Alerts.Resolved }} resolved"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expURL:   "https://integrations.zoom.us/chat/webhooks/incomingwebhook/abc?format=full",
+			expMsg: map[string]interface{}{
+				"head": map[string]interface{}{
+					"text":     "2 alerts",
+					"style":    map[string]interface{}{"color": "#D63232", "bold": true},
+					"sub_head": map[string]interface{}{"text": "Firing: 1, Resolved: 1"},
+				},
+				"body": []interface{}{
+					map[string]interface{}{
+						"type": "message",
+						"text": "1 resolved",
+					},
+					map[string]interface{}{
+						"type": "message",
+						"text": "View alert rule",
+						"link": "http://localhost/alerting/list",
+					},
+				},
+			},
+		}, {
+			name:         "Error when the endpoint is missing",
+			settings:     `{"token": "verification"}`,
+			expInitError: "could not find endpoint URL in settings",
+		}, {
+			name:         "Error when the verification token is missing",
+			settings:     `{"url": "https://integrations.zoom.us/chat/webhooks/incomingwebhook/abc"}`,
+			expInitError: "could not find verification token in settings",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+			m := &NotificationChannelConfig{
+				Name:     "zoom_testing",
+				Type:     "zoom",
+				Settings: settingsJSON,
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewZoomConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.EqualError(t, err, c.expInitError)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := NewZoomNotifier(cfg, webhookSender, tmpl).Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookSender.Webhook.Url)
+			require.Equal(t, "verification", webhookSender.Webhook.HttpHeader["Authorization"])
+			var msg map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+			require.Equal(t, c.expMsg, msg)
+		})
+	}
+}
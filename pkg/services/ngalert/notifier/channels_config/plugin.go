@@ -1,5 +1,9 @@
 package channels_config
 
+import (
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
 // NotifierPlugin holds meta information about a notifier.
 type NotifierPlugin struct {
 	Type        string           `json:"type"`
@@ -8,6 +12,8 @@ type NotifierPlugin struct {
 	Description string           `json:"description"`
 	Info        string           `json:"info"`
 	Options     []NotifierOption `json:"options"`
+
+	Capabilities channels.ChannelCapabilities `json:"capabilities"`
 }
 
 // NotifierOption holds information about options specific for the NotifierPlugin.
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+// contactPointStates are the circuit breakers and the rate limits of the contact points, by UID,
+// kept across the configuration changes so that a contact point that is down stays so, and one
+// that sent its quota of notifications does not get a new one, when the configuration is applied
+// again.
+type contactPointStates struct {
+	mtx    sync.Mutex
+	states map[string]*contactPointState
+}
+
+type contactPointState struct {
+	integrationType string
+	// breaker and throttle are nil if they are disabled.
+	breaker  *channels.CircuitBreaker
+	throttle *channels.Throttle
+}
+
+func (s *contactPointState) close() {
+	if s.breaker != nil {
+		s.breaker.Close()
+	}
+	if s.throttle != nil {
+		s.throttle.Close()
+	}
+}
+
+func newContactPointStates() *contactPointStates {
+	return &contactPointStates{states: map[string]*contactPointState{}}
+}
+
+// state returns the state of the contact point, replacing it if the type of the contact point
+// changed. It must be called with the lock held.
+func (c *contactPointStates) state(uid, integrationType string) *contactPointState {
+	s, ok := c.states[uid]
+	if ok && s.integrationType != integrationType {
+		s.close()
+		ok = false
+	}
+	if !ok {
+		s = &contactPointState{integrationType: integrationType}
+		c.states[uid] = s
+	}
+	return s
+}
+
+// circuitBreaker returns the circuit breaker of the contact point configured with its settings,
+// or nil if it is disabled.
+func (c *contactPointStates) circuitBreaker(uid, integrationType string, settings *simplejson.Json) *channels.CircuitBreaker {
+	cb := channels.CircuitBreakerFromSettings(settings)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	s := c.state(uid, integrationType)
+	if cb.Failures == 0 {
+		if s.breaker != nil {
+			s.breaker.Close()
+			s.breaker = nil
+		}
+		return nil
+	}
+	if s.breaker == nil {
+		s.breaker = channels.NewCircuitBreaker(integrationType, cb)
+	}
+	s.breaker.Configure(cb)
+	return s.breaker
+}
+
+// throttle returns the rate limit of the contact point configured with its settings, or nil if it
+// is disabled.
+func (c *contactPointStates) throttle(uid, integrationType string, settings *simplejson.Json) *channels.Throttle {
+	ts := channels.ThrottleFromSettings(settings)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	s := c.state(uid, integrationType)
+	if ts.Notifications == 0 {
+		if s.throttle != nil {
+			s.throttle.Close()
+			s.throttle = nil
+		}
+		return nil
+	}
+	if s.throttle == nil {
+		s.throttle = channels.NewThrottle(integrationType, ts)
+	}
+	s.throttle.Configure(ts)
+	return s.throttle
+}
+
+// removeExcept closes and removes the states of the contact points that are not in uids.
+func (c *contactPointStates) removeExcept(uids map[string]struct{}) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for uid, s := range c.states {
+		if _, ok := uids[uid]; !ok {
+			s.close()
+			delete(c.states, uid)
+		}
+	}
+}
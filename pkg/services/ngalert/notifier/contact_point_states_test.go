@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestContactPointStates(t *testing.T) {
+	c := newContactPointStates()
+	enabled := simplejson.NewFromAny(map[string]interface{}{"circuitBreakerFailures": 3, "throttleNotifications": 10})
+
+	require.Nil(t, c.circuitBreaker("uid-1", "webhook", simplejson.New()))
+	require.Nil(t, c.throttle("uid-1", "webhook", simplejson.New()))
+
+	b := c.circuitBreaker("uid-1", "webhook", enabled)
+	require.NotNil(t, b)
+	th := c.throttle("uid-1", "webhook", enabled)
+	require.NotNil(t, th)
+	// The states are kept when the configuration is applied again.
+	require.Same(t, b, c.circuitBreaker("uid-1", "webhook", enabled))
+	require.Same(t, th, c.throttle("uid-1", "webhook", enabled))
+	// They are replaced when the type of the contact point changes.
+	other := c.circuitBreaker("uid-1", "slack", enabled)
+	require.NotSame(t, b, other)
+	require.NotSame(t, th, c.throttle("uid-1", "slack", enabled))
+	require.NotSame(t, other, c.circuitBreaker("uid-2", "slack", enabled))
+
+	c.removeExcept(map[string]struct{}{"uid-2": {}})
+	require.Len(t, c.states, 1)
+	require.Contains(t, c.states, "uid-2")
+
+	// They are removed when they are disabled.
+	require.Nil(t, c.circuitBreaker("uid-2", "slack", simplejson.New()))
+	require.Nil(t, c.states["uid-2"].breaker)
+}
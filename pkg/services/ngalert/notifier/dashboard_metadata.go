@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+const (
+	dashboardMetadataTTL        = time.Minute
+	dashboardMetadataMaxEntries = 1000
+	generalFolderTitle          = "General"
+)
+
+// dashboardMetadataStage adds to the context a function that returns the metadata of the
+// dashboards and panels of the organization, so that templates can refer to them. The metadata is
+// cached for dashboardMetadataTTL as notifications of the same rules are usually sent together.
+type dashboardMetadataStage struct {
+	orgID      int64
+	dashboards dashboards.DashboardService
+
+	mtx   sync.Mutex
+	cache map[dashboardMetadataKey]dashboardMetadataEntry
+	now   func() time.Time
+}
+
+type dashboardMetadataKey struct {
+	uid     string
+	panelID int64
+}
+
+type dashboardMetadataEntry struct {
+	metadata *channels.DashboardMetadata
+	expires  time.Time
+}
+
+func newDashboardMetadataStage(orgID int64, dashboards dashboards.DashboardService) *dashboardMetadataStage {
+	return &dashboardMetadataStage{
+		orgID:      orgID,
+		dashboards: dashboards,
+		cache:      make(map[dashboardMetadataKey]dashboardMetadataEntry),
+		now:        time.Now,
+	}
+}
+
+func (s *dashboardMetadataStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if s.dashboards == nil {
+		return ctx, alerts, nil
+	}
+	return channels.WithDashboardMetadata(ctx, s.get), alerts, nil
+}
+
+func (s *dashboardMetadataStage) get(ctx context.Context, uid string, panelID int64) (*channels.DashboardMetadata, error) {
+	key := dashboardMetadataKey{uid: uid, panelID: panelID}
+
+	s.mtx.Lock()
+	entry, ok := s.cache[key]
+	s.mtx.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		return entry.metadata, nil
+	}
+
+	metadata, err := s.fetch(ctx, uid, panelID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := s.now()
+	if len(s.cache) >= dashboardMetadataMaxEntries {
+		for k, e := range s.cache {
+			if !now.Before(e.expires) {
+				delete(s.cache, k)
+			}
+		}
+	}
+	if len(s.cache) < dashboardMetadataMaxEntries {
+		s.cache[key] = dashboardMetadataEntry{metadata: metadata, expires: now.Add(dashboardMetadataTTL)}
+	}
+	return metadata, nil
+}
+
+func (s *dashboardMetadataStage) fetch(ctx context.Context, uid string, panelID int64) (*channels.DashboardMetadata, error) {
+	query := &models.GetDashboardQuery{OrgId: s.orgID, Uid: uid}
+	if err := s.dashboards.GetDashboard(ctx, query); err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	dash := query.Result
+
+	metadata := &channels.DashboardMetadata{
+		UID:     dash.Uid,
+		Title:   dash.Title,
+		Folder:  generalFolderTitle,
+		Tags:    dash.Data.Get("tags").MustStringArray(),
+		PanelID: panelID,
+	}
+
+	if dash.FolderId > 0 {
+		folderQuery := &models.GetDashboardQuery{OrgId: s.orgID, Id: dash.FolderId}
+		if err := s.dashboards.GetDashboard(ctx, folderQuery); err != nil {
+			return nil, err
+		}
+		metadata.Folder = folderQuery.Result.Title
+	}
+
+	if panelID > 0 {
+		if panel := findPanel(dash.Data.Get("panels"), panelID); panel != nil {
+			metadata.PanelTitle = panel.Get("title").MustString()
+			metadata.PanelDescription = panel.Get("description").MustString()
+		}
+	}
+
+	return metadata, nil
+}
+
+// findPanel returns the panel with the ID, including panels of collapsed rows.
+func findPanel(panels *simplejson.Json, id int64) *simplejson.Json {
+	for i := range panels.MustArray() {
+		panel := panels.GetIndex(i)
+		if panel.Get("id").MustInt64() == id {
+			return panel
+		}
+		if nested := findPanel(panel.Get("panels"), id); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
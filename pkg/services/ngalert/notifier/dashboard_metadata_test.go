@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+func TestDashboardMetadataStage(t *testing.T) {
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*models.GetDashboardQuery")).Run(func(args mock.Arguments) {
+		q := args.Get(1).(*models.GetDashboardQuery)
+		require.Equal(t, int64(1), q.OrgId)
+		switch {
+		case q.Uid == "slo":
+			q.Result = &models.Dashboard{Uid: "slo", Title: "SLO dashboard", FolderId: 3, Data: simplejson.NewFromAny(map[string]interface{}{
+				"tags": []interface{}{"checkout"},
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "title": "Availability"},
+					map[string]interface{}{"id": 2, "type": "row", "panels": []interface{}{
+						map[string]interface{}{"id": 4, "title": "Checkout latency", "description": "p99 of the checkout requests"},
+					}},
+				},
+			})}
+		case q.Id == 3:
+			q.Result = &models.Dashboard{Id: 3, Title: "Payments", IsFolder: true, Data: simplejson.New()}
+		case q.Uid == "general":
+			q.Result = &models.Dashboard{Uid: "general", Title: "Overview", Data: simplejson.New()}
+		}
+	}).Return(func(_ context.Context, q *models.GetDashboardQuery) error {
+		switch {
+		case q.Uid == "broken":
+			return errors.New("database is locked")
+		case q.Result == nil:
+			return dashboards.ErrDashboardNotFound
+		}
+		return nil
+	})
+
+	stage := newDashboardMetadataStage(1, dashboardService)
+	ctx, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger())
+	require.NoError(t, err)
+
+	m, err := stage.get(ctx, "slo", 4)
+	require.NoError(t, err)
+	require.Equal(t, &channels.DashboardMetadata{
+		UID:              "slo",
+		Title:            "SLO dashboard",
+		Folder:           "Payments",
+		Tags:             []string{"checkout"},
+		PanelID:          4,
+		PanelTitle:       "Checkout latency",
+		PanelDescription: "p99 of the checkout requests",
+	}, m)
+
+	m, err = stage.get(ctx, "general", 0)
+	require.NoError(t, err)
+	require.Equal(t, &channels.DashboardMetadata{UID: "general", Title: "Overview", Folder: "General"}, m)
+
+	m, err = stage.get(ctx, "missing", 1)
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	_, err = stage.get(ctx, "broken", 1)
+	require.Error(t, err)
+
+	// The metadata is cached until it expires.
+	calls := len(dashboardService.Calls)
+	_, err = stage.get(ctx, "slo", 4)
+	require.NoError(t, err)
+	_, err = stage.get(ctx, "missing", 1)
+	require.NoError(t, err)
+	require.Len(t, dashboardService.Calls, calls)
+
+	stage.now = func() time.Time { return time.Now().Add(dashboardMetadataTTL) }
+	_, err = stage.get(ctx, "slo", 4)
+	require.NoError(t, err)
+	require.Len(t, dashboardService.Calls, calls+2)
+}
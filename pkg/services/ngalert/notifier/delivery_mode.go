@@ -0,0 +1,83 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// firstSuccessStage runs the pipelines of the integrations of a receiver one after the other and
+// stops after the first one that succeeds. A pipeline that finds nothing to send, because its
+// integration already delivered the notification, counts as a success. Each pipeline gets an
+// equal share of the time left for the notification so that an integration that keeps failing
+// and retrying does not prevent the next ones from being tried. The pipelines after the first one
+// are failovers, which their deliveries record in the notification history.
+//
+// Each integration keeps its own entry in the notification log. When a failover delivers the
+// notification, the notifies are also set for the integrations that failed before it so that
+// they do not send it again on the next flush.
+type firstSuccessStage struct {
+	receiver string
+	stages   []notify.Stage
+	// notifies[i] sets the notifies of the integration of stages[i] in the notification log.
+	notifies []notify.Stage
+	logger   log.Logger
+}
+
+func (s firstSuccessStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var me types.MultiError
+	for i, stage := range s.stages {
+		stageCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && i < len(s.stages)-1 {
+			stageCtx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(len(s.stages)-i))
+		}
+		if i > 0 {
+			stageCtx = withFailover(stageCtx)
+		}
+		resCtx, _, err := stage.Exec(stageCtx, l, alerts...)
+		cancel()
+		if err == nil {
+			s.setSkippedNotifies(resCtx, l, i, alerts...)
+			return ctx, alerts, nil
+		}
+		me.Add(err)
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(s.stages)-1 {
+			s.logger.Warn("integration failed to deliver the notification, trying the next one", "receiver", s.receiver, "integration", i, "err", err)
+		}
+	}
+	return ctx, alerts, &me
+}
+
+// setSkippedNotifies sets the notifies of the integrations that were tried before the one at
+// index i. The context is the one returned by the pipeline of that integration, which holds the
+// firing and resolved alerts of the notification.
+func (s firstSuccessStage) setSkippedNotifies(ctx context.Context, l gokit_log.Logger, i int, alerts ...*types.Alert) {
+	for j := 0; j < i && j < len(s.notifies); j++ {
+		if _, _, err := s.notifies[j].Exec(ctx, l, alerts...); err != nil {
+			s.logger.Warn("failed to set the notifies of a skipped integration", "receiver", s.receiver, "integration", j, "err", err)
+		}
+	}
+}
+
+type failoverKey struct{}
+
+// withFailover returns a copy of the context for the pipeline of an integration that is tried
+// because the previous integrations of the receiver failed to deliver the notification.
+func withFailover(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failoverKey{}, true)
+}
+
+// isFailover returns true if the notification is a failover of the previous integrations of the
+// receiver.
+func isFailover(ctx context.Context) bool {
+	v, _ := ctx.Value(failoverKey{}).(bool)
+	return v
+}
@@ -0,0 +1,134 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestFirstSuccessStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	var calls []int
+	var failovers []bool
+	stage := func(i int, err error) notify.Stage {
+		return notify.StageFunc(func(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			calls = append(calls, i)
+			failovers = append(failovers, isFailover(ctx))
+			return ctx, alerts, err
+		})
+	}
+
+	t.Run("stops after the first integration that succeeds", func(t *testing.T) {
+		calls, failovers = nil, nil
+		s := firstSuccessStage{
+			receiver: "tiered",
+			stages:   []notify.Stage{stage(0, errors.New("chat is down")), stage(1, nil), stage(2, nil)},
+			logger:   log.NewNopLogger(),
+		}
+		_, out, err := s.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, out)
+		require.Equal(t, []int{0, 1}, calls)
+		// The integrations tried after the first one are failovers.
+		require.Equal(t, []bool{false, true}, failovers)
+	})
+
+	t.Run("returns the errors of all the integrations when none succeeds", func(t *testing.T) {
+		calls = nil
+		s := firstSuccessStage{
+			receiver: "tiered",
+			stages:   []notify.Stage{stage(0, errors.New("chat is down")), stage(1, errors.New("sms is down"))},
+			logger:   log.NewNopLogger(),
+		}
+		_, _, err := s.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+		require.EqualError(t, err, "chat is down; sms is down")
+		require.Equal(t, []int{0, 1}, calls)
+	})
+
+	t.Run("an integration that keeps retrying leaves time for the next ones", func(t *testing.T) {
+		var sent bool
+		retrying := notify.StageFunc(func(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			<-ctx.Done()
+			return ctx, nil, ctx.Err()
+		})
+		next := notify.StageFunc(func(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			require.NoError(t, ctx.Err())
+			sent = true
+			return ctx, alerts, nil
+		})
+		s := firstSuccessStage{receiver: "tiered", stages: []notify.Stage{retrying, next}, logger: log.NewNopLogger()}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, _, err := s.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.True(t, sent)
+	})
+}
+
+type sendResolved bool
+
+func (s sendResolved) SendResolved() bool { return bool(s) }
+
+func TestFirstSuccessStage_Failover(t *testing.T) {
+	nfl, err := nflog.New(nflog.WithRetention(time.Hour))
+	require.NoError(t, err)
+
+	calls := map[string]int{}
+	primaryErr := errors.New("chat is down")
+	pipeline := func(name string, idx uint32) (notify.Stage, notify.Stage) {
+		recv := &nflogpb.Receiver{GroupName: "tiered", Integration: name, Idx: idx}
+		setNotifies := notify.NewSetNotifiesStage(nfl, recv)
+		return notify.MultiStage{
+			notify.NewDedupStage(sendResolved(false), nfl, recv),
+			notify.StageFunc(func(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+				calls[name]++
+				if name == "primary" {
+					return ctx, nil, primaryErr
+				}
+				return ctx, alerts, nil
+			}),
+			setNotifies,
+		}, setNotifies
+	}
+	primary, primaryNotifies := pipeline("primary", 0)
+	secondary, secondaryNotifies := pipeline("secondary", 1)
+	s := firstSuccessStage{
+		receiver: "tiered",
+		stages:   []notify.Stage{primary, secondary},
+		notifies: []notify.Stage{primaryNotifies, secondaryNotifies},
+		logger:   log.NewNopLogger(),
+	}
+
+	now := time.Now()
+	alerts := []*types.Alert{{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1"},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	}}}
+	ctx := notify.WithGroupKey(context.Background(), `{}:{alertname="alert1"}`)
+	ctx = notify.WithRepeatInterval(ctx, time.Hour)
+
+	_, _, err = s.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"primary": 1, "secondary": 1}, calls)
+
+	// The primary integration is back, but the notification was already delivered by the failover
+	// so the next flush sends nothing.
+	primaryErr = nil
+	_, _, err = s.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"primary": 1, "secondary": 1}, calls)
+}
@@ -0,0 +1,173 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+const (
+	// defaultDigestTopRules is the number of top rules of the digests that do not set it.
+	defaultDigestTopRules = 5
+	// digestGroupKeyPrefix prefixes the group key of the digests in the notification log.
+	digestGroupKeyPrefix = "{digest}:"
+	// digestNotifyTimeout is the time available to send a digest.
+	digestNotifyTimeout = time.Minute
+)
+
+// digests are the digests of the receivers in digest mode, by receiver name, kept across the
+// configuration changes so that the alerts accumulated are not lost when the configuration is
+// applied again.
+type digests struct {
+	mtx     sync.Mutex
+	digests map[string]*digest
+	logger  log.Logger
+}
+
+func newDigests(logger log.Logger) *digests {
+	return &digests{digests: map[string]*digest{}, logger: logger}
+}
+
+// stage returns the stage accumulating the alerts of the receiver into its digest, which is sent
+// through the next stage at the end of its window.
+func (d *digests) stage(receiver string, cfg apimodels.DigestConfig, next notify.Stage) notify.Stage {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	dg, ok := d.digests[receiver]
+	if !ok {
+		dg = &digest{receiver: receiver, pending: map[model.Fingerprint]int{}, logger: d.logger, now: time.Now}
+		d.digests[receiver] = dg
+	}
+	dg.configure(cfg, next)
+	return dg
+}
+
+// removeExcept stops and removes the digests of the receivers that are not in digest mode.
+func (d *digests) removeExcept(receivers map[string]struct{}) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for name, dg := range d.digests {
+		if _, ok := receivers[name]; !ok {
+			dg.stop()
+			delete(d.digests, name)
+		}
+	}
+}
+
+// stop stops all the digests, dropping the alerts accumulated. The alerts still firing are
+// notified again by their groups once the Alertmanager is started again.
+func (d *digests) stop() {
+	d.removeExcept(nil)
+}
+
+// digest accumulates the alerts of a receiver over its window, and sends them in a single
+// notification with their summary, instead of a notification per group.
+type digest struct {
+	receiver string
+	logger   log.Logger
+	now      func() time.Time
+
+	mtx      sync.Mutex
+	window   time.Duration
+	topRules int
+	next     notify.Stage
+	// alerts are the alerts of the window, in the order they were first notified, and pending
+	// their indexes by fingerprint.
+	alerts  []*types.Alert
+	pending map[model.Fingerprint]int
+	// ctx is the context of the last notification of the window.
+	ctx   context.Context
+	since time.Time
+	timer *time.Timer
+}
+
+func (d *digest) configure(cfg apimodels.DigestConfig, next notify.Stage) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.window = time.Duration(cfg.Window)
+	d.topRules = cfg.TopRules
+	if d.topRules == 0 {
+		d.topRules = defaultDigestTopRules
+	}
+	d.next = next
+}
+
+// Exec adds the alerts to the digest, the latest notification of an alert replacing the previous
+// ones, and starts the window if it is not started yet.
+func (d *digest) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, a := range alerts {
+		fp := a.Fingerprint()
+		if i, ok := d.pending[fp]; ok {
+			d.alerts[i] = a
+			continue
+		}
+		d.pending[fp] = len(d.alerts)
+		d.alerts = append(d.alerts, a)
+	}
+	// The context of the notification is canceled once the stage returns, but its values, such
+	// as the preferences of the organization, are needed to send the digest.
+	d.ctx = channels.WithoutCancel(ctx)
+	if d.timer == nil {
+		d.since = d.now()
+		d.timer = time.AfterFunc(d.window, d.flush)
+	}
+	return ctx, nil, nil
+}
+
+// flush sends the digest of the window through the next stage.
+func (d *digest) flush() {
+	d.mtx.Lock()
+	alerts, ctx, since, next, topRules := d.alerts, d.ctx, d.since, d.next, d.topRules
+	d.reset()
+	d.mtx.Unlock()
+	if len(alerts) == 0 {
+		return
+	}
+
+	now := d.now()
+	ctx, cancel := context.WithTimeout(ctx, digestNotifyTimeout)
+	defer cancel()
+	ctx = channels.WithDigest(ctx, channels.NewDigest(alerts, topRules, since, now))
+	// The digest is deduplicated in the notification log on its own, and has no group labels as
+	// it has the alerts of all the groups of the receiver.
+	ctx = notify.WithGroupKey(ctx, digestGroupKeyPrefix+d.receiver)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{})
+	ctx = notify.WithNow(ctx, now)
+	if _, _, err := next.Exec(ctx, gokit_log.NewNopLogger(), alerts...); err != nil {
+		d.logger.Error("failed to send digest", "receiver", d.receiver, "alerts", len(alerts), "err", err)
+		return
+	}
+	d.logger.Debug("digest sent", "receiver", d.receiver, "alerts", len(alerts))
+}
+
+// stop stops the window, dropping the alerts accumulated.
+func (d *digest) stop() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if len(d.alerts) > 0 {
+		d.logger.Warn("dropping the alerts of the digest", "receiver", d.receiver, "alerts", len(d.alerts))
+	}
+	d.reset()
+}
+
+// reset empties the digest and stops its window. It must be called with the lock held.
+func (d *digest) reset() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.alerts = nil
+	d.pending = map[model.Fingerprint]int{}
+	d.ctx = nil
+}
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+// digestRecordingStage records the contexts and the alerts it is executed with.
+type digestRecordingStage struct {
+	mtx    sync.Mutex
+	ctxs   []context.Context
+	alerts [][]*types.Alert
+}
+
+func (s *digestRecordingStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.ctxs = append(s.ctxs, ctx)
+	s.alerts = append(s.alerts, alerts)
+	return ctx, alerts, nil
+}
+
+func (s *digestRecordingStage) calls() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.alerts)
+}
+
+func TestDigest(t *testing.T) {
+	alert := func(rule, severity string, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(rule), "severity": model.LabelValue(severity)},
+			StartsAt: time.Now().Add(-time.Hour),
+			EndsAt:   endsAt,
+		}}
+	}
+	firing := time.Now().Add(time.Hour)
+	resolved := time.Now().Add(-time.Minute)
+	a1 := alert("HighCPU", "critical", firing)
+	a2 := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighCPU", "instance": "b"}, EndsAt: firing}}
+	a3 := alert("DiskFull", "warning", resolved)
+
+	t.Run("the alerts of the window are sent in a single notification", func(t *testing.T) {
+		next := &digestRecordingStage{}
+		d := newDigests(log.New("test"))
+		stage := d.stage("team-a", apimodels.DigestConfig{Window: model.Duration(50 * time.Millisecond), TopRules: 1}, next)
+
+		groupCtx := notify.WithGroupKey(context.Background(), "{}:{alertname=\"HighCPU\"}")
+		groupCtx = notify.WithRepeatInterval(groupCtx, time.Hour)
+		ctx, cancel := context.WithCancel(groupCtx)
+		_, as, err := stage.Exec(ctx, gokit_log.NewNopLogger(), a1, a2)
+		require.NoError(t, err)
+		require.Empty(t, as)
+		cancel()
+		_, _, err = stage.Exec(groupCtx, gokit_log.NewNopLogger(), a3, a1)
+		require.NoError(t, err)
+		require.Zero(t, next.calls())
+
+		require.Eventually(t, func() bool { return next.calls() == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, []*types.Alert{a1, a2, a3}, next.alerts[0])
+
+		ctx = next.ctxs[0]
+		key, _ := notify.GroupKey(ctx)
+		require.Equal(t, "{digest}:team-a", key)
+		repeat, _ := notify.RepeatInterval(ctx)
+		require.Equal(t, time.Hour, repeat)
+		dg, ok := channels.DigestFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, 3, dg.Alerts)
+		require.Equal(t, 2, dg.Firing)
+		require.Equal(t, 1, dg.Resolved)
+		require.Equal(t, []channels.DigestCount{{Name: "critical", Count: 1}, {Name: "none", Count: 1}, {Name: "warning", Count: 1}}, dg.BySeverity)
+		require.Equal(t, []channels.DigestCount{{Name: "HighCPU", Count: 2}}, dg.TopRules)
+
+		// The next window starts with the next notification.
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, 1, next.calls())
+	})
+
+	t.Run("the alerts of the receivers no longer in digest mode are dropped", func(t *testing.T) {
+		next := &digestRecordingStage{}
+		d := newDigests(log.New("test"))
+		stage := d.stage("team-a", apimodels.DigestConfig{Window: model.Duration(50 * time.Millisecond)}, next)
+		_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), a1)
+		require.NoError(t, err)
+
+		// The digest is kept when the configuration is applied again.
+		require.Same(t, stage, d.stage("team-a", apimodels.DigestConfig{Window: model.Duration(50 * time.Millisecond)}, next))
+		d.removeExcept(map[string]struct{}{"team-b": {}})
+		require.Empty(t, d.digests)
+		time.Sleep(100 * time.Millisecond)
+		require.Zero(t, next.calls())
+	})
+}
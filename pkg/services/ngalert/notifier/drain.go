@@ -0,0 +1,208 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const undeliveredAlertsKey = "undelivered_alerts"
+
+var errAlertmanagerStopping = errors.New("the Alertmanager is stopping, the notification is deferred until it is started again")
+
+// drainer lets the notifications that are in flight when the Alertmanager stops be delivered until
+// a deadline instead of cancelling them. The alerts of the notifications that are not delivered are
+// persisted and put back in the Alertmanager when it starts again, the notification log prevents
+// the notifications that were delivered from being sent twice.
+type drainer struct {
+	mtx      sync.Mutex
+	draining bool
+	abort    context.Context
+	abortFn  context.CancelFunc
+
+	store  *undeliveredStore
+	logger log.Logger
+}
+
+func newDrainer(store *undeliveredStore, logger log.Logger) *drainer {
+	abort, abortFn := context.WithCancel(context.Background())
+	return &drainer{
+		abort:   abort,
+		abortFn: abortFn,
+		store:   store,
+		logger:  logger,
+	}
+}
+
+// start stops accepting new notifications and gives the notifications in flight until the timeout
+// to be delivered.
+func (d *drainer) start(timeout time.Duration) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.draining {
+		return
+	}
+	d.draining = true
+	time.AfterFunc(timeout, d.abortFn)
+}
+
+func (d *drainer) isDraining() bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.draining
+}
+
+// detach returns a context that is not cancelled when the parent is cancelled because the
+// Alertmanager is stopping, until the drain timeout. Deadlines of the parent are kept.
+func (d *drainer) detach(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx := &drainContext{Context: parent, done: make(chan struct{})}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-parent.Done():
+		case <-stop:
+			return
+		}
+		if errors.Is(parent.Err(), context.Canceled) && d.isDraining() {
+			select {
+			case <-d.abort.Done():
+				ctx.cancel(context.DeadlineExceeded)
+			case <-stop:
+			}
+			return
+		}
+		ctx.cancel(parent.Err())
+	}()
+	var once sync.Once
+	return ctx, func() { once.Do(func() { close(stop) }) }
+}
+
+// persist stores the alerts so that they are notified when the Alertmanager starts again.
+func (d *drainer) persist(alerts []*types.Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.store.add(ctx, alerts); err != nil {
+		d.logger.Error("failed to persist undelivered alerts", "alerts", len(alerts), "err", err)
+		return
+	}
+	d.logger.Info("persisted undelivered alerts", "alerts", len(alerts))
+}
+
+// drainContext is a context whose cancellation is controlled by the drainer.
+type drainContext struct {
+	context.Context
+	done chan struct{}
+	mtx  sync.Mutex
+	err  error
+}
+
+func (c *drainContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *drainContext) Err() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.err
+}
+
+func (c *drainContext) cancel(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.err = err
+	close(c.done)
+}
+
+// drainingStage runs the notification pipeline of a receiver in a context detached by the
+// drainer, and persists the alerts when the notification is not delivered during the shutdown.
+type drainingStage struct {
+	stage   notify.Stage
+	drainer *drainer
+}
+
+func (s drainingStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if s.drainer.isDraining() {
+		s.drainer.persist(alerts)
+		return ctx, nil, errAlertmanagerStopping
+	}
+
+	detached, cancel := s.drainer.detach(ctx)
+	defer cancel()
+	_, res, err := s.stage.Exec(detached, l, alerts...)
+	if err != nil && s.drainer.isDraining() {
+		s.drainer.persist(alerts)
+	}
+	return ctx, res, err
+}
+
+// undeliveredStore persists the alerts of the notifications that were not delivered because the
+// Alertmanager stopped.
+type undeliveredStore struct {
+	mtx sync.Mutex
+	kv  *kvstore.NamespacedKVStore
+}
+
+func newUndeliveredStore(orgID int64, store kvstore.KVStore) *undeliveredStore {
+	return &undeliveredStore{kv: kvstore.WithNamespace(store, orgID, KVNamespace)}
+}
+
+// add persists the alerts, keeping the most recent version of each alert.
+func (s *undeliveredStore) add(ctx context.Context, alerts []*types.Alert) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	existing, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+	byFingerprint := make(map[model.Fingerprint]*types.Alert, len(existing)+len(alerts))
+	for _, a := range append(existing, alerts...) {
+		if prev, ok := byFingerprint[a.Fingerprint()]; !ok || a.UpdatedAt.After(prev.UpdatedAt) {
+			byFingerprint[a.Fingerprint()] = a
+		}
+	}
+	merged := make([]*types.Alert, 0, len(byFingerprint))
+	for _, a := range byFingerprint {
+		merged = append(merged, a)
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return s.kv.Set(ctx, undeliveredAlertsKey, string(b))
+}
+
+// pop returns the persisted alerts and deletes them.
+func (s *undeliveredStore) pop(ctx context.Context) ([]*types.Alert, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	alerts, err := s.get(ctx)
+	if err != nil || len(alerts) == 0 {
+		return nil, err
+	}
+	return alerts, s.kv.Del(ctx, undeliveredAlertsKey)
+}
+
+func (s *undeliveredStore) get(ctx context.Context) ([]*types.Alert, error) {
+	value, ok, err := s.kv.Get(ctx, undeliveredAlertsKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var alerts []*types.Alert
+	if err := json.Unmarshal([]byte(value), &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
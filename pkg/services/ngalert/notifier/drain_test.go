@@ -0,0 +1,151 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type blockingStage struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s blockingStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	close(s.started)
+	select {
+	case <-s.release:
+		return ctx, alerts, nil
+	case <-ctx.Done():
+		return ctx, nil, ctx.Err()
+	}
+}
+
+func TestDrainingStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}, UpdatedAt: time.Now()}}
+
+	t.Run("notifications in flight are delivered after the Alertmanager is stopped", func(t *testing.T) {
+		d := newDrainer(newUndeliveredStore(1, NewFakeKVStore(t)), log.New("test"))
+		inner := blockingStage{started: make(chan struct{}), release: make(chan struct{})}
+		stage := drainingStage{stage: inner, drainer: d}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error)
+		go func() {
+			_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+			errc <- err
+		}()
+
+		<-inner.started
+		d.start(time.Minute)
+		cancel()
+		close(inner.release)
+		require.NoError(t, <-errc)
+
+		undelivered, err := d.store.pop(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, undelivered)
+	})
+
+	t.Run("notifications in flight after the drain timeout are persisted", func(t *testing.T) {
+		d := newDrainer(newUndeliveredStore(1, NewFakeKVStore(t)), log.New("test"))
+		inner := blockingStage{started: make(chan struct{}), release: make(chan struct{})}
+		stage := drainingStage{stage: inner, drainer: d}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error)
+		go func() {
+			_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+			errc <- err
+		}()
+
+		<-inner.started
+		d.start(10 * time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-errc, context.DeadlineExceeded)
+
+		undelivered, err := d.store.pop(context.Background())
+		require.NoError(t, err)
+		require.Len(t, undelivered, 1)
+		require.Equal(t, alerts[0].Fingerprint(), undelivered[0].Fingerprint())
+	})
+
+	t.Run("new notifications are persisted while draining", func(t *testing.T) {
+		d := newDrainer(newUndeliveredStore(1, NewFakeKVStore(t)), log.New("test"))
+		stage := drainingStage{stage: blockingStage{started: make(chan struct{})}, drainer: d}
+
+		d.start(time.Minute)
+		_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+		require.ErrorIs(t, err, errAlertmanagerStopping)
+
+		// The most recent version of an alert is kept.
+		updated := &types.Alert{Alert: alerts[0].Alert, UpdatedAt: alerts[0].UpdatedAt.Add(time.Minute)}
+		_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), updated)
+		require.ErrorIs(t, err, errAlertmanagerStopping)
+
+		undelivered, err := d.store.pop(context.Background())
+		require.NoError(t, err)
+		require.Len(t, undelivered, 1)
+		require.True(t, updated.UpdatedAt.Equal(undelivered[0].UpdatedAt))
+	})
+
+	t.Run("cancellations are propagated when not draining", func(t *testing.T) {
+		d := newDrainer(newUndeliveredStore(1, NewFakeKVStore(t)), log.New("test"))
+		inner := blockingStage{started: make(chan struct{}), release: make(chan struct{})}
+		stage := drainingStage{stage: inner, drainer: d}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error)
+		go func() {
+			_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+			errc <- err
+		}()
+
+		<-inner.started
+		cancel()
+		require.True(t, errors.Is(<-errc, context.Canceled))
+
+		undelivered, err := d.store.pop(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, undelivered)
+	})
+}
+
+func TestAlertmanager_RestoresUndeliveredAlerts(t *testing.T) {
+	kvStore := NewFakeKVStore(t)
+	now := time.Now()
+	undelivered := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: now,
+			EndsAt:   now.Add(time.Hour),
+		},
+		UpdatedAt: now,
+	}}
+	require.NoError(t, newUndeliveredStore(1, kvStore).add(context.Background(), undelivered))
+
+	cfg := &setting.Cfg{DataPath: t.TempDir()}
+	m := metrics.NewAlertmanagerMetrics(prometheus.NewRegistry())
+	am, err := newAlertmanager(context.Background(), 1, cfg, nil, kvStore, &NilPeer{}, nil, nil, nil, nil, nil, m)
+	require.NoError(t, err)
+
+	restored, err := am.alerts.Get(undelivered[0].Fingerprint())
+	require.NoError(t, err)
+	require.Equal(t, undelivered[0].Labels, restored.Labels)
+
+	// The alerts are restored only once.
+	remaining, err := am.drainer.store.pop(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+}
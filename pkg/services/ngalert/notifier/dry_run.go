@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"context"
+	"strconv"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// dryRunGroupSuffix is appended to the name of the receiver in the notification log of dry runs so
+// that they do not prevent the real notifications from being sent, and the other way around.
+const dryRunGroupSuffix = "/dry-run"
+
+// dryRunStage sends the alerts of the rules in dry run mode, set with the dry run annotation or
+// by the folder of the rule, through a pipeline that renders and records their notifications in
+// the dispatch profiles without sending them. The other alerts go through the live pipeline.
+type dryRunStage struct {
+	live    notify.Stage
+	dryRun  notify.Stage
+	folders map[string]struct{}
+}
+
+func (s dryRunStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var live, dryRun []*types.Alert
+	for _, a := range alerts {
+		if s.isDryRun(a) {
+			dryRun = append(dryRun, a)
+		} else {
+			live = append(live, a)
+		}
+	}
+	if len(dryRun) == 0 {
+		return s.live.Exec(ctx, l, alerts...)
+	}
+
+	var me types.MultiError
+	if len(live) > 0 {
+		if _, _, err := s.live.Exec(ctx, l, live...); err != nil {
+			me.Add(err)
+		}
+	}
+	if _, _, err := s.dryRun.Exec(ctx, l, dryRun...); err != nil {
+		me.Add(err)
+	}
+	if me.Len() > 0 {
+		return ctx, alerts, &me
+	}
+	return ctx, alerts, nil
+}
+
+func (s dryRunStage) isDryRun(a *types.Alert) bool {
+	if v, ok := a.Annotations[ngmodels.DryRunAnnotation]; ok {
+		if dryRun, err := strconv.ParseBool(string(v)); err == nil {
+			return dryRun
+		}
+	}
+	_, ok := s.folders[string(a.Labels[ngmodels.NamespaceUIDLabel])]
+	return ok
+}
+
+// dryRunNotifyStage notifies the integration once, as retrying a notification that is not sent
+// is pointless. Like the retry stage, it leaves out the resolved alerts if the integration does
+// not send them.
+type dryRunNotifyStage struct {
+	integration notify.Integration
+}
+
+func (s dryRunNotifyStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	sent := alerts
+	if !s.integration.SendResolved() {
+		sent = nil
+		for _, a := range alerts {
+			if a.Status() != model.AlertResolved {
+				sent = append(sent, a)
+			}
+		}
+		if len(sent) == 0 {
+			return ctx, alerts, nil
+		}
+	}
+	if _, err := s.integration.Notify(ctx, sent...); err != nil {
+		return ctx, nil, err
+	}
+	return ctx, alerts, nil
+}
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type recordingStage struct {
+	alerts []*types.Alert
+	err    error
+}
+
+func (s *recordingStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.alerts = append(s.alerts, alerts...)
+	return ctx, alerts, s.err
+}
+
+func TestDryRunStage(t *testing.T) {
+	alert := func(name, folder string, annotations model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": model.LabelValue(name), ngmodels.NamespaceUIDLabel: model.LabelValue(folder)},
+			Annotations: annotations,
+		}}
+	}
+	live := alert("live", "prod", nil)
+	annotated := alert("annotated", "prod", model.LabelSet{ngmodels.DryRunAnnotation: "true"})
+	inFolder := alert("in-folder", "staging", nil)
+	optedOut := alert("opted-out", "staging", model.LabelSet{ngmodels.DryRunAnnotation: "false"})
+
+	t.Run("alerts are sent through the pipeline of their mode", func(t *testing.T) {
+		s := dryRunStage{live: &recordingStage{}, dryRun: &recordingStage{}, folders: map[string]struct{}{"staging": {}}}
+		_, out, err := s.Exec(context.Background(), gokit_log.NewNopLogger(), live, annotated, inFolder, optedOut)
+		require.NoError(t, err)
+		require.Len(t, out, 4)
+		require.Equal(t, []*types.Alert{live, optedOut}, s.live.(*recordingStage).alerts)
+		require.Equal(t, []*types.Alert{annotated, inFolder}, s.dryRun.(*recordingStage).alerts)
+	})
+
+	t.Run("the dry run pipeline is not run without alerts in dry run mode", func(t *testing.T) {
+		s := dryRunStage{live: &recordingStage{}, dryRun: &recordingStage{}}
+		_, _, err := s.Exec(context.Background(), gokit_log.NewNopLogger(), live, inFolder)
+		require.NoError(t, err)
+		require.Len(t, s.live.(*recordingStage).alerts, 2)
+		require.Empty(t, s.dryRun.(*recordingStage).alerts)
+	})
+
+	t.Run("errors of both pipelines are returned", func(t *testing.T) {
+		s := dryRunStage{live: &recordingStage{err: errors.New("live failed")}, dryRun: &recordingStage{err: errors.New("dry run failed")}}
+		_, _, err := s.Exec(context.Background(), gokit_log.NewNopLogger(), live, annotated)
+		require.EqualError(t, err, "live failed; dry run failed")
+	})
+}
+
+func TestDryRunProfilingStage(t *testing.T) {
+	profiles := newDispatchProfiles()
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	n := &fakeNotificationChannel{errs: []error{nil}}
+	integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", 0)
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: dryRunNotifyStage{integration}, profiles: profiles, dryRun: true}
+	_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), firing)
+	require.NoError(t, err)
+
+	dispatches := profiles.get(10)[0].Dispatches
+	require.Len(t, dispatches, 1)
+	require.True(t, dispatches[0].DryRun)
+	require.Equal(t, 1, dispatches[0].Attempts)
+	require.Empty(t, dispatches[0].Error)
+}
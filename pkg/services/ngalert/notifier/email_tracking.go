@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+var (
+	// ErrEmailTrackingDisabled is returned when the organization does not track its alert emails.
+	ErrEmailTrackingDisabled = errors.New("email tracking is not enabled for the organization")
+	// ErrInvalidEmailTrackingSignature is returned when a tracking URL was not generated by Grafana.
+	ErrInvalidEmailTrackingSignature = errors.New("invalid email tracking signature")
+)
+
+// emailTracking generates and verifies the tracking URLs of the alert emails of an organization.
+// The URLs are signed with the secret key of Grafana, so that they cannot be forged to record
+// opens and clicks or to redirect to other sites.
+type emailTracking struct {
+	orgID   int64
+	baseURL string
+	secret  []byte
+}
+
+// newEmailTracking returns the tracking of the alert emails of an organization, or nil if the
+// organization does not track them.
+func newEmailTracking(orgID int64, orgs map[int64]struct{}, appURL, secret string) *emailTracking {
+	if _, ok := orgs[orgID]; !ok {
+		return nil
+	}
+	return &emailTracking{
+		orgID:   orgID,
+		baseURL: strings.TrimSuffix(appURL, "/"),
+		secret:  []byte(secret),
+	}
+}
+
+func (t *emailTracking) sign(parts ...string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(fmt.Sprintf("%d", t.orgID)))
+	for _, p := range parts {
+		mac.Write([]byte{0})
+		mac.Write([]byte(p))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *emailTracking) verify(sig string, parts ...string) error {
+	if !hmac.Equal([]byte(sig), []byte(t.sign(parts...))) {
+		return ErrInvalidEmailTrackingSignature
+	}
+	return nil
+}
+
+// forDispatch returns the tracking URLs of the email sent by a dispatch.
+func (t *emailTracking) forDispatch(dispatchID string) dispatchEmailTracking {
+	return dispatchEmailTracking{tracking: t, dispatchID: dispatchID}
+}
+
+// dispatchEmailTracking implements channels.EmailTracking for the email sent by a dispatch.
+type dispatchEmailTracking struct {
+	tracking   *emailTracking
+	dispatchID string
+}
+
+func (d dispatchEmailTracking) path(action string) string {
+	return fmt.Sprintf("%s/api/alerting/email-tracking/%d/%s/%s", d.tracking.baseURL, d.tracking.orgID, url.PathEscape(d.dispatchID), action)
+}
+
+func (d dispatchEmailTracking) PixelURL() string {
+	return d.path("open.gif") + "?" + url.Values{"sig": {d.tracking.sign("open", d.dispatchID)}}.Encode()
+}
+
+func (d dispatchEmailTracking) LinkURL(target string) string {
+	return d.path("click") + "?" + url.Values{
+		"url": {target},
+		"sig": {d.tracking.sign("click", d.dispatchID, target)},
+	}.Encode()
+}
+
+// TrackEmailOpen records in the dispatch history, and in the notification history if it is
+// enabled, that the email sent by a dispatch was opened. Opens of dispatches that are no longer in
+// the history are ignored.
+func (am *Alertmanager) TrackEmailOpen(dispatchID, sig string) error {
+	if am.emailTracking == nil {
+		return ErrEmailTrackingDisabled
+	}
+	if err := am.emailTracking.verify(sig, "open", dispatchID); err != nil {
+		return err
+	}
+	am.trackEmail(dispatchID, false)
+	return nil
+}
+
+// TrackEmailClick records in the dispatch history, and in the notification history if it is
+// enabled, that a link of the email sent by a dispatch was clicked. The caller redirects to target only if it returns no error.
+func (am *Alertmanager) TrackEmailClick(dispatchID, target, sig string) error {
+	if am.emailTracking == nil {
+		return ErrEmailTrackingDisabled
+	}
+	if err := am.emailTracking.verify(sig, "click", dispatchID, target); err != nil {
+		return err
+	}
+	am.trackEmail(dispatchID, true)
+	return nil
+}
+
+func (am *Alertmanager) trackEmail(dispatchID string, click bool) {
+	now := time.Now()
+	am.profiles.trackEmail(dispatchID, now, click)
+	am.history.recordEmail(ngmodels.TrackNotificationDeliveryEmailCommand{
+		OrgID:      am.orgID,
+		DispatchID: dispatchID,
+		At:         now.UnixMilli(),
+		Click:      click,
+	})
+}
+
+// TrackEmailOpen records that the email sent by a dispatch of the organization was opened.
+func (moa *MultiOrgAlertmanager) TrackEmailOpen(orgID int64, dispatchID, sig string) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	return am.TrackEmailOpen(dispatchID, sig)
+}
+
+// TrackEmailClick records that a link of the email sent by a dispatch of the organization was clicked.
+func (moa *MultiOrgAlertmanager) TrackEmailClick(orgID int64, dispatchID, target, sig string) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	return am.TrackEmailClick(dispatchID, target, sig)
+}
@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+// trackingNotificationChannel records the email tracking of the dispatch.
+type trackingNotificationChannel struct {
+	tracking channels.EmailTracking
+}
+
+func (n *trackingNotificationChannel) Notify(ctx context.Context, _ ...*types.Alert) (bool, error) {
+	n.tracking, _ = channels.EmailTrackingFromContext(ctx)
+	return true, nil
+}
+
+func (n *trackingNotificationChannel) SendResolved() bool {
+	return true
+}
+
+func TestEmailTracking(t *testing.T) {
+	require.Nil(t, newEmailTracking(2, map[int64]struct{}{1: {}}, "http://localhost:3000/", "secret"))
+
+	am := &Alertmanager{
+		orgID:         1,
+		profiles:      newDispatchProfiles(),
+		emailTracking: newEmailTracking(1, map[int64]struct{}{1: {}}, "http://localhost:3000/", "secret"),
+		history:       newNotificationHistory(time.Hour, &fakeNotificationHistoryStore{}, log.NewNopLogger()),
+	}
+	n := &trackingNotificationChannel{}
+	integration := notify.NewIntegration(n, n, "email", 0)
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, profiles: am.profiles, emailTracking: am.emailTracking, history: am.history, orgID: 1}
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+	_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.NotNil(t, n.tracking)
+
+	dispatch := am.profiles.get(1)[0].Dispatches[0]
+	require.NotEmpty(t, dispatch.ID)
+	delivery := <-am.history.deliveries
+	require.Equal(t, dispatch.ID, delivery.DispatchID)
+	require.Zero(t, dispatch.EmailOpens)
+	require.Nil(t, dispatch.EmailOpenedAt)
+
+	pixel, err := url.Parse(n.tracking.PixelURL())
+	require.NoError(t, err)
+	require.Equal(t, "/api/alerting/email-tracking/1/"+dispatch.ID+"/open.gif", pixel.Path)
+	link, err := url.Parse(n.tracking.LinkURL("http://localhost:3000/alerting/list"))
+	require.NoError(t, err)
+	require.Equal(t, "/api/alerting/email-tracking/1/"+dispatch.ID+"/click", link.Path)
+	require.Equal(t, "http://localhost:3000/alerting/list", link.Query().Get("url"))
+
+	t.Run("opens and clicks are recorded in the dispatch history", func(t *testing.T) {
+		require.NoError(t, am.TrackEmailOpen(dispatch.ID, pixel.Query().Get("sig")))
+		require.NoError(t, am.TrackEmailOpen(dispatch.ID, pixel.Query().Get("sig")))
+		require.NoError(t, am.TrackEmailClick(dispatch.ID, link.Query().Get("url"), link.Query().Get("sig")))
+
+		tracked := am.profiles.get(1)[0].Dispatches[0]
+		require.Equal(t, 2, tracked.EmailOpens)
+		require.Equal(t, 1, tracked.EmailClicks)
+		require.NotNil(t, tracked.EmailOpenedAt)
+	})
+
+	t.Run("opens and clicks are recorded in the notification history", func(t *testing.T) {
+		require.Len(t, am.history.emails, 3)
+		for _, click := range []bool{false, false, true} {
+			cmd := <-am.history.emails
+			require.Equal(t, int64(1), cmd.OrgID)
+			require.Equal(t, dispatch.ID, cmd.DispatchID)
+			require.Equal(t, click, cmd.Click)
+			require.NotZero(t, cmd.At)
+		}
+	})
+
+	t.Run("forged URLs are rejected", func(t *testing.T) {
+		require.ErrorIs(t, am.TrackEmailOpen(dispatch.ID, "forged"), ErrInvalidEmailTrackingSignature)
+		require.ErrorIs(t, am.TrackEmailOpen("other", pixel.Query().Get("sig")), ErrInvalidEmailTrackingSignature)
+		require.ErrorIs(t, am.TrackEmailClick(dispatch.ID, "https://evil.example.com", link.Query().Get("sig")), ErrInvalidEmailTrackingSignature)
+		// The signature of the pixel cannot be used for a link.
+		require.ErrorIs(t, am.TrackEmailClick(dispatch.ID, "", pixel.Query().Get("sig")), ErrInvalidEmailTrackingSignature)
+
+		// The signatures are specific to the organization.
+		other := newEmailTracking(2, map[int64]struct{}{2: {}}, "http://localhost:3000/", "secret")
+		require.ErrorIs(t, other.verify(pixel.Query().Get("sig"), "open", dispatch.ID), ErrInvalidEmailTrackingSignature)
+	})
+
+	t.Run("organizations that do not track their emails reject tracking", func(t *testing.T) {
+		untracked := &Alertmanager{profiles: newDispatchProfiles()}
+		require.ErrorIs(t, untracked.TrackEmailOpen(dispatch.ID, pixel.Query().Get("sig")), ErrEmailTrackingDisabled)
+	})
+
+	t.Run("dry runs are not tracked", func(t *testing.T) {
+		stage.dryRun = true
+		stage.stage = dryRunNotifyStage{integration: integration}
+		_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Nil(t, n.tracking)
+	})
+}
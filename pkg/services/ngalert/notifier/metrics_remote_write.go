@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// notificationMetricsPrefix is the prefix of the metrics of the notification pipeline that are
+// remote written, see metrics.NewAlertmanagerMetrics.
+const notificationMetricsPrefix = "grafana_alerting_receiver_notification"
+
+// notificationMetricsWriter periodically sends the metrics of the notification pipeline of all
+// the organizations to a Prometheus remote write endpoint, so that the health of the alerting of
+// many Grafana instances can be followed in one place.
+type notificationMetricsWriter struct {
+	cfg       setting.UnifiedAlertingRemoteWriteSettings
+	gatherers func() map[int64]prometheus.Gatherer
+	client    *http.Client
+	logger    log.Logger
+}
+
+// newNotificationMetricsWriter returns nil if the remote write is not configured.
+func newNotificationMetricsWriter(cfg setting.UnifiedAlertingRemoteWriteSettings, gatherers func() map[int64]prometheus.Gatherer, l log.Logger) *notificationMetricsWriter {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &notificationMetricsWriter{
+		cfg:       cfg,
+		gatherers: gatherers,
+		client:    &http.Client{Timeout: cfg.Interval},
+		logger:    l,
+	}
+}
+
+func (w *notificationMetricsWriter) run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := w.write(ctx, now); err != nil {
+				w.logger.Error("failed to remote write the notification metrics", "err", err, "url", w.cfg.URL)
+			}
+		}
+	}
+}
+
+func (w *notificationMetricsWriter) write(ctx context.Context, now time.Time) error {
+	series, err := w.timeSeries(now)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		return nil
+	}
+	data, err := remotewrite.TimeSeriesToBytes(series)
+	if err != nil {
+		return fmt.Errorf("failed to encode the time series: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.cfg.BasicAuthUser != "" {
+		req.SetBasicAuth(w.cfg.BasicAuthUser, w.cfg.BasicAuthPassword)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			w.logger.Warn("failed to close response body", "err", err)
+		}
+	}()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("the remote write endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	w.logger.Debug("remote wrote the notification metrics", "series", len(series))
+	return nil
+}
+
+// timeSeries converts the notification metrics of each organization to the time series of the
+// remote write protocol, labelled with the organization and the configured labels.
+func (w *notificationMetricsWriter) timeSeries(now time.Time) ([]prompb.TimeSeries, error) {
+	gatherers := w.gatherers()
+	orgIDs := make([]int64, 0, len(gatherers))
+	for orgID := range gatherers {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+
+	ts := now.UnixNano() / int64(time.Millisecond)
+	var series []prompb.TimeSeries
+	for _, orgID := range orgIDs {
+		families, err := gatherers[orgID].Gather()
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather the metrics of org %d: %w", orgID, err)
+		}
+		for _, family := range families {
+			name := family.GetName()
+			if !strings.HasPrefix(name, notificationMetricsPrefix) {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				labels := make(map[string]string, len(w.cfg.Labels)+len(m.GetLabel())+1)
+				for k, v := range w.cfg.Labels {
+					labels[k] = v
+				}
+				labels["org"] = strconv.FormatInt(orgID, 10)
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				switch family.GetType() {
+				case dto.MetricType_COUNTER:
+					series = append(series, newTimeSeries(name, labels, m.GetCounter().GetValue(), ts))
+				case dto.MetricType_GAUGE:
+					series = append(series, newTimeSeries(name, labels, m.GetGauge().GetValue(), ts))
+				case dto.MetricType_HISTOGRAM:
+					h := m.GetHistogram()
+					for _, b := range h.GetBucket() {
+						series = append(series, newTimeSeries(name+"_bucket", withLabel(labels, "le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)), float64(b.GetCumulativeCount()), ts))
+					}
+					series = append(series,
+						newTimeSeries(name+"_bucket", withLabel(labels, "le", "+Inf"), float64(h.GetSampleCount()), ts),
+						newTimeSeries(name+"_sum", labels, h.GetSampleSum(), ts),
+						newTimeSeries(name+"_count", labels, float64(h.GetSampleCount()), ts),
+					)
+				}
+			}
+		}
+	}
+	return series, nil
+}
+
+// newTimeSeries returns a time series with a single sample. The labels are sorted by name, as
+// required by the remote write protocol.
+func newTimeSeries(name string, labels map[string]string, value float64, ts int64) prompb.TimeSeries {
+	l := make([]prompb.Label, 0, len(labels)+1)
+	l = append(l, prompb.Label{Name: "__name__", Value: name})
+	for k, v := range labels {
+		l = append(l, prompb.Label{Name: k, Value: v})
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name < l[j].Name })
+	return prompb.TimeSeries{
+		Labels:  l,
+		Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+	}
+}
+
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	l := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	l[name] = value
+	return l
+}
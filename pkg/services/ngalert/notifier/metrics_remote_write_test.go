@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestNotificationMetricsWriter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.NewAlertmanagerMetrics(reg)
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	n := &fakeNotificationChannel{errs: []error{nil}}
+	integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", 0)
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, profiles: newDispatchProfiles(), metrics: m}
+	_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.Error(t, err)
+
+	// Dry runs are not recorded.
+	n.errs = []error{nil}
+	stage.dryRun = true
+	stage.stage = dryRunNotifyStage{integration}
+	_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+
+	var received []prompb.TimeSeries
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		b, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		var req prompb.WriteRequest
+		require.NoError(t, proto.Unmarshal(b, &req))
+		received = req.Timeseries
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := setting.UnifiedAlertingRemoteWriteSettings{
+		URL:               server.URL,
+		BasicAuthUser:     "grafana",
+		BasicAuthPassword: "secret",
+		Interval:          time.Minute,
+		Labels:            map[string]string{"instance": "grafana-1"},
+	}
+	w := newNotificationMetricsWriter(cfg, func() map[int64]prometheus.Gatherer {
+		return map[int64]prometheus.Gatherer{1: reg}
+	}, log.NewNopLogger())
+	now := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, w.write(context.Background(), now))
+	require.Equal(t, "grafana", user)
+	require.Equal(t, "secret", password)
+
+	values := map[string]float64{}
+	for _, s := range received {
+		labels := map[string]string{}
+		for i, l := range s.Labels {
+			labels[l.Name] = l.Value
+			if i > 0 {
+				require.Less(t, s.Labels[i-1].Name, l.Name, "labels must be sorted")
+			}
+		}
+		require.Equal(t, "1", labels["org"])
+		require.Equal(t, "grafana-1", labels["instance"])
+		require.Equal(t, "team-a", labels["receiver"])
+		require.Equal(t, "webhook", labels["integration"])
+		require.Len(t, s.Samples, 1)
+		require.Equal(t, now.UnixNano()/int64(time.Millisecond), s.Samples[0].Timestamp)
+
+		name := labels["__name__"]
+		if le, ok := labels["le"]; ok {
+			name += "{le=" + le + "}"
+		}
+		values[name] = s.Samples[0].Value
+	}
+	require.Equal(t, 2.0, values["grafana_alerting_receiver_notifications_total"])
+	require.Equal(t, 1.0, values["grafana_alerting_receiver_notifications_failed_total"])
+	require.Equal(t, 2.0, values["grafana_alerting_receiver_notification_latency_seconds_count"])
+	require.Equal(t, 2.0, values["grafana_alerting_receiver_notification_latency_seconds_bucket{le=+Inf}"])
+	require.Contains(t, values, "grafana_alerting_receiver_notification_latency_seconds_bucket{le=0.1}")
+	require.NotContains(t, values, "grafana_alerting_alerts")
+}
+
+func TestNotificationMetricsWriter_Errors(t *testing.T) {
+	require.Nil(t, newNotificationMetricsWriter(setting.UnifiedAlertingRemoteWriteSettings{}, nil, log.NewNopLogger()))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	reg := prometheus.NewRegistry()
+	m := metrics.NewAlertmanagerMetrics(reg)
+	cfg := setting.UnifiedAlertingRemoteWriteSettings{URL: server.URL, Interval: time.Minute}
+	w := newNotificationMetricsWriter(cfg, func() map[int64]prometheus.Gatherer {
+		return map[int64]prometheus.Gatherer{1: reg}
+	}, log.NewNopLogger())
+
+	// Nothing is sent until there are notifications.
+	require.NoError(t, w.write(context.Background(), time.Now()))
+
+	m.ReceiverNotifications.WithLabelValues("team-a", "webhook").Inc()
+	require.EqualError(t, w.write(context.Background(), time.Now()), "the remote write endpoint returned status 400: out of order sample")
+}
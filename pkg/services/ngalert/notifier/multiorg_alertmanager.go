@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/notifications"
+	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/services/secrets"
 
 	"github.com/prometheus/alertmanager/cluster"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
@@ -50,13 +52,22 @@ type MultiOrgAlertmanager struct {
 
 	decryptFn channels.GetDecryptedValueFn
 
-	metrics *metrics.MultiOrgAlertmanager
-	ns      notifications.Service
+	metrics    *metrics.MultiOrgAlertmanager
+	ns         notifications.Service
+	prefs      pref.Service
+	dashboards dashboards.DashboardService
+	// history is shared by the Alertmanagers of all the organizations.
+	history *notificationHistory
+	// audit is shared by the Alertmanagers of all the organizations.
+	audit *notificationAudit
+	// deadLetters is shared by the Alertmanagers of all the organizations.
+	deadLetters *notificationDeadLetters
 }
 
 func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgStore store.OrgStore,
 	kvStore kvstore.KVStore, provStore provisioning.ProvisioningStore, decryptFn channels.GetDecryptedValueFn,
-	m *metrics.MultiOrgAlertmanager, ns notifications.Service, l log.Logger, s secrets.Service,
+	m *metrics.MultiOrgAlertmanager, ns notifications.Service, prefs pref.Service, dashboards dashboards.DashboardService,
+	l log.Logger, s secrets.Service,
 ) (*MultiOrgAlertmanager, error) {
 	moa := &MultiOrgAlertmanager{
 		Crypto:    NewCrypto(s, configStore, l),
@@ -71,6 +82,11 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 		decryptFn:     decryptFn,
 		metrics:       m,
 		ns:            ns,
+		prefs:         prefs,
+		dashboards:    dashboards,
+		history:       newNotificationHistory(cfg.UnifiedAlerting.NotificationHistoryRetention, configStore, l.New("component", "notification-history")),
+		audit:         newNotificationAudit(cfg.UnifiedAlerting.NotificationAuditRetention, configStore, l.New("component", "notification-audit")),
+		deadLetters:   newNotificationDeadLetters(cfg.UnifiedAlerting.NotificationDeadLetterRetention, configStore, l.New("component", "notification-dead-letters")),
 	}
 
 	clusterLogger := l.New("component", "cluster")
@@ -114,6 +130,22 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
 	moa.logger.Info("starting MultiOrg Alertmanager")
 
+	if w := newNotificationMetricsWriter(moa.settings.UnifiedAlerting.NotificationMetrics, moa.metrics.OrgGatherers, moa.logger.New("component", "notification-metrics")); w != nil {
+		go w.run(ctx)
+	}
+	if moa.history != nil {
+		go moa.history.run(ctx)
+	}
+	if moa.audit != nil {
+		go moa.audit.run(ctx)
+	}
+	if moa.deadLetters != nil {
+		go moa.deadLetters.run(ctx)
+	}
+	if s := newNotificationWorkersServer(moa.settings.UnifiedAlerting.NotificationWorkers, moa.logger.New("component", "notification-workers")); s != nil {
+		go s.run(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -182,9 +214,13 @@ func (moa *MultiOrgAlertmanager) SyncAlertmanagersForOrgs(ctx context.Context, o
 			// To export them, we need to translate the metrics from each individual registry and,
 			// then aggregate them on the main registry.
 			m := metrics.NewAlertmanagerMetrics(moa.metrics.GetOrCreateOrgRegistry(orgID))
-			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m)
+			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, moa.prefs, moa.dashboards, moa.orgStore, m)
 			if err != nil {
 				moa.logger.Error("unable to create Alertmanager for org", "org", orgID, "err", err)
+			} else {
+				am.history = moa.history
+				am.audit = moa.audit
+				am.deadLetters = moa.deadLetters
 			}
 			moa.alertmanagers[orgID] = am
 			alertmanager = am
@@ -296,6 +332,11 @@ func (moa *MultiOrgAlertmanager) StopAndWait() {
 	moa.alertmanagersMtx.Lock()
 	defer moa.alertmanagersMtx.Unlock()
 
+	// Start draining all the Alertmanagers first so that their notifications in flight share the
+	// same drain timeout.
+	for _, am := range moa.alertmanagers {
+		am.drain()
+	}
 	for _, am := range moa.alertmanagers {
 		am.StopAndWait()
 	}
@@ -0,0 +1,225 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	// notificationAuditBuffer is the number of entries waiting to be saved. Entries are dropped
+	// rather than blocking the notification pipeline when the database is too slow.
+	notificationAuditBuffer = 1000
+	// notificationAuditBatch is the maximum number of entries saved at once.
+	notificationAuditBatch = 500
+
+	notificationAuditFlushInterval   = 10 * time.Second
+	notificationAuditCleanupInterval = time.Hour
+
+	// notificationAuditMaxText is the maximum length of the recorded titles and errors.
+	notificationAuditMaxText = 1024
+)
+
+// notificationAudit records every notification sent by the integrations of all the
+// organizations in the audit log, with its rendered title, its receiver, the fingerprints of its
+// alerts and its result. The entries are saved in batches, and deleted once older than the
+// retention.
+type notificationAudit struct {
+	store     store.NotificationAuditStore
+	retention time.Duration
+	entries   chan ngmodels.NotificationAuditEntry
+	logger    log.Logger
+}
+
+// newNotificationAudit returns nil if the retention is zero, that is if the audit log is
+// disabled.
+func newNotificationAudit(retention time.Duration, s store.NotificationAuditStore, l log.Logger) *notificationAudit {
+	if retention <= 0 {
+		return nil
+	}
+	return &notificationAudit{
+		store:     s,
+		retention: retention,
+		entries:   make(chan ngmodels.NotificationAuditEntry, notificationAuditBuffer),
+		logger:    l,
+	}
+}
+
+// record queues the entry to be saved. It never blocks, and does nothing if the audit log is
+// disabled.
+func (a *notificationAudit) record(e ngmodels.NotificationAuditEntry) {
+	if a == nil {
+		return
+	}
+	if len(e.Title) > notificationAuditMaxText {
+		e.Title = e.Title[:notificationAuditMaxText]
+	}
+	if len(e.Error) > notificationAuditMaxText {
+		e.Error = e.Error[:notificationAuditMaxText]
+	}
+	select {
+	case a.entries <- e:
+	default:
+		a.logger.Warn("dropping the audit entry of a notification, the audit log is full", "org", e.OrgID, "receiver", e.Receiver, "integration", e.Integration)
+	}
+}
+
+func (a *notificationAudit) run(ctx context.Context) {
+	flush := time.NewTicker(notificationAuditFlushInterval)
+	defer flush.Stop()
+	cleanup := time.NewTicker(notificationAuditCleanupInterval)
+	defer cleanup.Stop()
+
+	batch := make([]ngmodels.NotificationAuditEntry, 0, notificationAuditBatch)
+	for {
+		select {
+		case <-ctx.Done():
+			// Save what is left with a fresh context, as the one of the service is done.
+			for len(a.entries) > 0 {
+				batch = append(batch, <-a.entries)
+			}
+			saveCtx, cancel := context.WithTimeout(context.Background(), notificationAuditFlushInterval)
+			a.save(saveCtx, batch)
+			cancel()
+			return
+		case e := <-a.entries:
+			batch = append(batch, e)
+			if len(batch) >= notificationAuditBatch {
+				batch = a.save(ctx, batch)
+			}
+		case <-flush.C:
+			batch = a.save(ctx, batch)
+		case now := <-cleanup.C:
+			a.cleanup(ctx, now)
+		}
+	}
+}
+
+// save saves the batch, and returns it emptied. The entries of a batch that fails to be saved
+// are dropped.
+func (a *notificationAudit) save(ctx context.Context, batch []ngmodels.NotificationAuditEntry) []ngmodels.NotificationAuditEntry {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := a.store.SaveNotificationAuditEntries(ctx, batch); err != nil {
+		a.logger.Error("failed to save the audit entries of the notifications", "err", err, "entries", len(batch))
+	}
+	return batch[:0]
+}
+
+func (a *notificationAudit) cleanup(ctx context.Context, now time.Time) {
+	deleted, err := a.store.DeleteNotificationAuditEntriesBefore(ctx, now.Add(-a.retention))
+	if err != nil {
+		a.logger.Error("failed to delete the old audit entries of the notifications", "err", err)
+		return
+	}
+	a.logger.Debug("deleted the old audit entries of the notifications", "deleted", deleted)
+}
+
+// auditStage records the notifications sent by an integration in the audit log.
+type auditStage struct {
+	receiver    string
+	integration notify.Integration
+	stage       notify.Stage
+	audit       *notificationAudit
+	orgID       int64
+}
+
+func (s auditStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	r := &auditRecord{}
+	sentAt := time.Now()
+	ctx, alerts, err := s.stage.Exec(withAuditRecord(ctx, r), l, alerts...)
+
+	fingerprints := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		fingerprints = append(fingerprints, a.Fingerprint().String())
+	}
+	e := ngmodels.NotificationAuditEntry{
+		OrgID:            s.orgID,
+		Receiver:         s.receiver,
+		Integration:      s.integration.Name(),
+		IntegrationIndex: s.integration.Index(),
+		SentAt:           sentAt.UnixMilli(),
+		Title:            r.getTitle(),
+		Fingerprints:     strings.Join(fingerprints, ","),
+		Alerts:           len(alerts),
+		Result:           ngmodels.NotificationAuditDelivered,
+	}
+	if err != nil {
+		e.Result = ngmodels.NotificationAuditFailed
+		e.Error = err.Error()
+	}
+	s.audit.record(e)
+	return ctx, alerts, err
+}
+
+type auditRecordKey struct{}
+
+// auditRecord is filled by the integration with the rendered title of the notification.
+type auditRecord struct {
+	mtx   sync.Mutex
+	title string
+}
+
+func (r *auditRecord) setTitle(title string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.title = title
+}
+
+func (r *auditRecord) getTitle() string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.title
+}
+
+func withAuditRecord(ctx context.Context, r *auditRecord) context.Context {
+	return context.WithValue(ctx, auditRecordKey{}, r)
+}
+
+func auditRecordFromContext(ctx context.Context) (*auditRecord, bool) {
+	r, ok := ctx.Value(auditRecordKey{}).(*auditRecord)
+	return r, ok && r != nil
+}
+
+// auditingNotifier renders the title of the notifications that are recorded in the audit log,
+// with the title, subject or summary of the integration if it sets one.
+type auditingNotifier struct {
+	channels.NotificationChannel
+	title  string
+	tmpl   *template.Template
+	logger log.Logger
+}
+
+func newAuditingNotifier(n channels.NotificationChannel, settings *simplejson.Json, tmpl *template.Template, logger log.Logger) auditingNotifier {
+	title := channels.DefaultMessageTitleEmbed
+	for _, key := range []string{"title", "subject", "summary"} {
+		if t := settings.Get(key).MustString(); t != "" {
+			title = t
+			break
+		}
+	}
+	return auditingNotifier{NotificationChannel: n, title: title, tmpl: tmpl, logger: logger}
+}
+
+func (n auditingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	// The title is rendered once, on the first attempt.
+	if r, ok := auditRecordFromContext(ctx); ok && r.getTitle() == "" {
+		var tmplErr error
+		expand, _ := channels.TmplText(ctx, n.tmpl, as, n.logger, &tmplErr)
+		r.setTitle(expand(n.title))
+	}
+	return n.NotificationChannel.Notify(ctx, as...)
+}
@@ -0,0 +1,144 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+type fakeNotificationAuditStore struct {
+	mtx     sync.Mutex
+	entries []ngmodels.NotificationAuditEntry
+	deleted []time.Time
+}
+
+func (f *fakeNotificationAuditStore) SaveNotificationAuditEntries(_ context.Context, entries []ngmodels.NotificationAuditEntry) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.entries = append(f.entries, entries...)
+	return nil
+}
+
+func (f *fakeNotificationAuditStore) GetNotificationAuditEntries(context.Context, *ngmodels.GetNotificationAuditQuery) ([]*ngmodels.NotificationAuditEntry, error) {
+	return nil, nil
+}
+
+func (f *fakeNotificationAuditStore) DeleteNotificationAuditEntriesBefore(_ context.Context, t time.Time) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleted = append(f.deleted, t)
+	return 0, nil
+}
+
+func (f *fakeNotificationAuditStore) saved() []ngmodels.NotificationAuditEntry {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]ngmodels.NotificationAuditEntry(nil), f.entries...)
+}
+
+func TestNotificationAudit(t *testing.T) {
+	t.Run("disabled without retention", func(t *testing.T) {
+		a := newNotificationAudit(0, &fakeNotificationAuditStore{}, log.NewNopLogger())
+		require.Nil(t, a)
+		// Recording is a no-op.
+		a.record(ngmodels.NotificationAuditEntry{OrgID: 1})
+	})
+
+	t.Run("entries are saved when the service stops", func(t *testing.T) {
+		s := &fakeNotificationAuditStore{}
+		a := newNotificationAudit(time.Hour, s, log.NewNopLogger())
+		a.record(ngmodels.NotificationAuditEntry{OrgID: 1, Receiver: "ops", Integration: "slack", Title: strings.Repeat("x", 2000)})
+		a.record(ngmodels.NotificationAuditEntry{OrgID: 2, Receiver: "dba", Integration: "email", Error: strings.Repeat("x", 2000)})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		a.run(ctx)
+
+		saved := s.saved()
+		require.Len(t, saved, 2)
+		require.Len(t, saved[0].Title, notificationAuditMaxText)
+		require.Len(t, saved[1].Error, notificationAuditMaxText)
+	})
+
+	t.Run("entries are dropped when the buffer is full", func(t *testing.T) {
+		a := newNotificationAudit(time.Hour, &fakeNotificationAuditStore{}, log.NewNopLogger())
+		for i := 0; i < notificationAuditBuffer+10; i++ {
+			a.record(ngmodels.NotificationAuditEntry{OrgID: 1})
+		}
+		require.Len(t, a.entries, notificationAuditBuffer)
+	})
+
+	t.Run("cleanup deletes the entries older than the retention", func(t *testing.T) {
+		s := &fakeNotificationAuditStore{}
+		a := newNotificationAudit(24*time.Hour, s, log.NewNopLogger())
+		now := time.Unix(1_600_000_000, 0)
+		a.cleanup(context.Background(), now)
+		require.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, s.deleted)
+	})
+}
+
+func TestAuditStage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(channels.DefaultTemplateString), 0600))
+	tmpl, err := template.FromGlobs(path)
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency", "instance": "a"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency", "instance": "b"}}},
+	}
+	exec := func(t *testing.T, settings string, errs ...error) ngmodels.NotificationAuditEntry {
+		t.Helper()
+		a := newNotificationAudit(time.Hour, &fakeNotificationAuditStore{}, log.NewNopLogger())
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		n := &fakeNotificationChannel{errs: errs}
+		integration := notify.NewIntegration(newAuditingNotifier(n, s, tmpl, log.NewNopLogger()), n, "webhook", 1)
+		stage := auditStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, audit: a, orgID: 3}
+		ctx := notify.WithGroupLabels(context.Background(), model.LabelSet{"alertname": "HighLatency"})
+		_, _, _ = stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		require.Len(t, a.entries, 1)
+		return <-a.entries
+	}
+
+	t.Run("delivered notification with the title of the integration", func(t *testing.T) {
+		e := exec(t, `{"title": "{{ .CommonLabels.alertname }} on {{ len .Alerts }} instances"}`, nil)
+		require.Equal(t, int64(3), e.OrgID)
+		require.Equal(t, "team-a", e.Receiver)
+		require.Equal(t, "webhook", e.Integration)
+		require.Equal(t, 1, e.IntegrationIndex)
+		require.Equal(t, "HighLatency on 2 instances", e.Title)
+		require.Equal(t, alerts[0].Fingerprint().String()+","+alerts[1].Fingerprint().String(), e.Fingerprints)
+		require.Equal(t, 2, e.Alerts)
+		require.Equal(t, ngmodels.NotificationAuditDelivered, e.Result)
+		require.Empty(t, e.Error)
+		require.NotZero(t, e.SentAt)
+	})
+
+	t.Run("failed notification with the default title", func(t *testing.T) {
+		e := exec(t, `{}`, errors.New("unavailable"), errors.New("unavailable"), errors.New("unavailable"))
+		require.Equal(t, "[FIRING:2] HighLatency ", e.Title)
+		require.Equal(t, ngmodels.NotificationAuditFailed, e.Result)
+		require.Equal(t, "unavailable", e.Error)
+	})
+}
@@ -0,0 +1,290 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	notificationDeadLetterCleanupInterval = time.Hour
+	// notificationDeadLetterTimeout is the timeout to save a dead letter, as the context of the
+	// notification is usually done when it fails.
+	notificationDeadLetterTimeout = 5 * time.Second
+	// notificationDeadLetterMaxError is the maximum length of the recorded errors.
+	notificationDeadLetterMaxError = 1024
+)
+
+var (
+	ErrNotificationDeadLettersDisabled = errors.New("the dead letters of the notifications are disabled")
+	// ErrDeadLetterIntegrationNotFound is returned when the integration of a dead letter is not in
+	// the configuration anymore.
+	ErrDeadLetterIntegrationNotFound = errors.New("the integration of the dead letter does not exist anymore")
+	ErrDeadLetterReplayFailed        = errors.New("failed to replay the dead letter")
+)
+
+// notificationDeadLetters keeps the notifications of all the organizations that integrations
+// failed to deliver after all their retries, so that they can be replayed once their destination
+// recovers. They are deleted once they have failed for longer than the retention.
+type notificationDeadLetters struct {
+	store     store.NotificationDeadLetterStore
+	retention time.Duration
+	logger    log.Logger
+}
+
+// newNotificationDeadLetters returns nil if the retention is zero, that is if the dead letters are
+// disabled.
+func newNotificationDeadLetters(retention time.Duration, s store.NotificationDeadLetterStore, l log.Logger) *notificationDeadLetters {
+	if retention <= 0 {
+		return nil
+	}
+	return &notificationDeadLetters{store: s, retention: retention, logger: l}
+}
+
+func (d *notificationDeadLetters) save(letter *ngmodels.NotificationDeadLetter) error {
+	if len(letter.Error) > notificationDeadLetterMaxError {
+		letter.Error = letter.Error[:notificationDeadLetterMaxError]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notificationDeadLetterTimeout)
+	defer cancel()
+	return d.store.SaveNotificationDeadLetter(ctx, letter)
+}
+
+func (d *notificationDeadLetters) deleteGroup(cmd *ngmodels.DeleteGroupNotificationDeadLetterCommand) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationDeadLetterTimeout)
+	defer cancel()
+	return d.store.DeleteGroupNotificationDeadLetter(ctx, cmd)
+}
+
+func (d *notificationDeadLetters) run(ctx context.Context) {
+	cleanup := time.NewTicker(notificationDeadLetterCleanupInterval)
+	defer cleanup.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-cleanup.C:
+			d.cleanup(ctx, now)
+		}
+	}
+}
+
+func (d *notificationDeadLetters) cleanup(ctx context.Context, now time.Time) {
+	deleted, err := d.store.DeleteNotificationDeadLettersBefore(ctx, now.Add(-d.retention))
+	if err != nil {
+		d.logger.Error("failed to delete the old dead letters of the notifications", "err", err)
+		return
+	}
+	d.logger.Debug("deleted the old dead letters of the notifications", "deleted", deleted)
+}
+
+// deadLetterStage keeps the notifications that an integration failed to deliver after all its
+// retries. The notifications that fail because the Alertmanager is stopping are not kept, as their
+// alerts are notified again when it starts. The dead letter of a group is deleted once a later
+// notification of the group is delivered, as it is outdated.
+type deadLetterStage struct {
+	receiver    string
+	integration notify.Integration
+	stage       notify.Stage
+	deadLetters *notificationDeadLetters
+	drainer     *drainer
+	orgID       int64
+	logger      log.Logger
+}
+
+func (s deadLetterStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err == nil {
+		s.deleteDelivered(ctx)
+		return ctx, res, err
+	}
+	if s.drainer.isDraining() {
+		return ctx, res, err
+	}
+
+	letter, encErr := s.newLetter(ctx, alerts, err)
+	if encErr != nil {
+		s.logger.Error("failed to encode the dead letter of a notification", "receiver", s.receiver, "integration", s.integration.Name(), "err", encErr)
+		return ctx, res, err
+	}
+	if saveErr := s.deadLetters.save(letter); saveErr != nil {
+		s.logger.Error("failed to save the dead letter of a notification", "receiver", s.receiver, "integration", s.integration.Name(), "err", saveErr)
+	}
+	return ctx, res, err
+}
+
+func (s deadLetterStage) deleteDelivered(ctx context.Context) {
+	groupKey, ok := notify.GroupKey(ctx)
+	if !ok {
+		return
+	}
+	err := s.deadLetters.deleteGroup(&ngmodels.DeleteGroupNotificationDeadLetterCommand{
+		OrgID:            s.orgID,
+		Receiver:         s.receiver,
+		Integration:      s.integration.Name(),
+		IntegrationIndex: s.integration.Index(),
+		GroupKey:         groupKey,
+	})
+	if err != nil {
+		s.logger.Error("failed to delete the dead letter of a delivered notification", "receiver", s.receiver, "integration", s.integration.Name(), "err", err)
+	}
+}
+
+func (s deadLetterStage) newLetter(ctx context.Context, alerts []*types.Alert, err error) (*ngmodels.NotificationDeadLetter, error) {
+	groupKey, _ := notify.GroupKey(ctx)
+	groupLabels, _ := notify.GroupLabels(ctx)
+	encodedLabels, encErr := json.Marshal(groupLabels)
+	if encErr != nil {
+		return nil, encErr
+	}
+	encodedAlerts, encErr := json.Marshal(alerts)
+	if encErr != nil {
+		return nil, encErr
+	}
+	return &ngmodels.NotificationDeadLetter{
+		OrgID:            s.orgID,
+		Receiver:         s.receiver,
+		Integration:      s.integration.Name(),
+		IntegrationIndex: s.integration.Index(),
+		GroupKey:         groupKey,
+		GroupLabels:      string(encodedLabels),
+		Alerts:           string(encodedAlerts),
+		FailedAt:         time.Now().UnixMilli(),
+		Error:            err.Error(),
+	}, nil
+}
+
+// GetDeadLetters returns the notifications of the organization that failed to be delivered, the
+// most recent first.
+func (am *Alertmanager) GetDeadLetters(ctx context.Context, receiver string, limit int) (apimodels.GettableNotificationDeadLetters, error) {
+	if am.deadLetters == nil {
+		return nil, ErrNotificationDeadLettersDisabled
+	}
+	letters, err := am.deadLetters.store.GetNotificationDeadLetters(ctx, &ngmodels.GetNotificationDeadLettersQuery{
+		OrgID:    am.orgID,
+		Receiver: receiver,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(apimodels.GettableNotificationDeadLetters, 0, len(letters))
+	for _, letter := range letters {
+		groupLabels, alerts, err := decodeDeadLetter(letter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the dead letter %d: %w", letter.ID, err)
+		}
+		gettable := apimodels.GettableNotificationDeadLetter{
+			ID:          letter.ID,
+			Receiver:    letter.Receiver,
+			Integration: letter.Integration,
+			Index:       letter.IntegrationIndex,
+			GroupLabels: make(map[string]string, len(groupLabels)),
+			Alerts:      make([]apimodels.DeadLetterAlert, 0, len(alerts)),
+			FailedAt:    time.UnixMilli(letter.FailedAt).UTC(),
+			Error:       letter.Error,
+			Replays:     letter.Replays,
+		}
+		for k, v := range groupLabels {
+			gettable.GroupLabels[string(k)] = string(v)
+		}
+		for _, a := range alerts {
+			alert := apimodels.DeadLetterAlert{
+				Fingerprint: a.Fingerprint().String(),
+				Labels:      make(map[string]string, len(a.Labels)),
+				Annotations: make(map[string]string, len(a.Annotations)),
+				StartsAt:    a.StartsAt,
+				EndsAt:      a.EndsAt,
+			}
+			for k, v := range a.Labels {
+				alert.Labels[string(k)] = string(v)
+			}
+			for k, v := range a.Annotations {
+				alert.Annotations[string(k)] = string(v)
+			}
+			gettable.Alerts = append(gettable.Alerts, alert)
+		}
+		res = append(res, gettable)
+	}
+	return res, nil
+}
+
+// ReplayDeadLetter sends a notification that failed to be delivered again with its integration in
+// the current configuration, as it was when it failed. The dead letter is deleted if the
+// notification is delivered, and its error is updated otherwise. It is sent once, without retries.
+func (am *Alertmanager) ReplayDeadLetter(ctx context.Context, id int64) error {
+	if am.deadLetters == nil {
+		return ErrNotificationDeadLettersDisabled
+	}
+	letter, err := am.deadLetters.store.GetNotificationDeadLetter(ctx, am.orgID, id)
+	if err != nil {
+		return err
+	}
+	groupLabels, alerts, err := decodeDeadLetter(letter)
+	if err != nil {
+		return fmt.Errorf("failed to decode the dead letter %d: %w", letter.ID, err)
+	}
+
+	am.reloadConfigMtx.RLock()
+	var integration *notify.Integration
+	for i, candidate := range am.integrations[letter.Receiver] {
+		if candidate.Name() == letter.Integration && candidate.Index() == letter.IntegrationIndex {
+			integration = &am.integrations[letter.Receiver][i]
+			break
+		}
+	}
+	policies := am.policyPreferences
+	am.reloadConfigMtx.RUnlock()
+	if integration == nil {
+		return ErrDeadLetterIntegrationNotFound
+	}
+
+	ctx = notify.WithGroupKey(ctx, letter.GroupKey)
+	ctx = notify.WithGroupLabels(ctx, groupLabels)
+	ctx = notify.WithReceiverName(ctx, letter.Receiver)
+	ctx = notify.WithNow(ctx, time.Now())
+	enrich := notify.MultiStage{newOrgPreferencesStage(am.orgID, am.PreferenceService, policies, am.logger), am.orgName, am.dashboardMetadata}
+	ctx, alerts, err = enrich.Exec(ctx, am.logger, alerts...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := integration.Notify(ctx, alerts...); err != nil {
+		letter.Replays++
+		letter.FailedAt = time.Now().UnixMilli()
+		letter.Error = err.Error()
+		if saveErr := am.deadLetters.save(letter); saveErr != nil {
+			am.logger.Error("failed to save the dead letter of a notification", "id", letter.ID, "err", saveErr)
+		}
+		return fmt.Errorf("%w: %s", ErrDeadLetterReplayFailed, err)
+	}
+	am.logger.Info("replayed the dead letter of a notification", "id", letter.ID, "receiver", letter.Receiver, "integration", letter.Integration)
+	return am.deadLetters.store.DeleteNotificationDeadLetter(ctx, am.orgID, letter.ID)
+}
+
+func decodeDeadLetter(letter *ngmodels.NotificationDeadLetter) (model.LabelSet, []*types.Alert, error) {
+	var groupLabels model.LabelSet
+	if letter.GroupLabels != "" {
+		if err := json.Unmarshal([]byte(letter.GroupLabels), &groupLabels); err != nil {
+			return nil, nil, err
+		}
+	}
+	var alerts []*types.Alert
+	if err := json.Unmarshal([]byte(letter.Alerts), &alerts); err != nil {
+		return nil, nil, err
+	}
+	return groupLabels, alerts, nil
+}
@@ -0,0 +1,281 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeNotificationDeadLetterStore struct {
+	mtx     sync.Mutex
+	letters map[int64]ngmodels.NotificationDeadLetter
+	lastID  int64
+	deleted []time.Time
+}
+
+func (f *fakeNotificationDeadLetterStore) SaveNotificationDeadLetter(_ context.Context, letter *ngmodels.NotificationDeadLetter) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.letters == nil {
+		f.letters = map[int64]ngmodels.NotificationDeadLetter{}
+	}
+	if letter.ID == 0 {
+		for id, existing := range f.letters {
+			if existing.OrgID == letter.OrgID && existing.Receiver == letter.Receiver && existing.Integration == letter.Integration &&
+				existing.IntegrationIndex == letter.IntegrationIndex && existing.GroupKey == letter.GroupKey {
+				letter.ID = id
+				letter.Replays = existing.Replays
+			}
+		}
+	}
+	if letter.ID == 0 {
+		f.lastID++
+		letter.ID = f.lastID
+	}
+	f.letters[letter.ID] = *letter
+	return nil
+}
+
+func (f *fakeNotificationDeadLetterStore) GetNotificationDeadLetters(_ context.Context, q *ngmodels.GetNotificationDeadLettersQuery) ([]*ngmodels.NotificationDeadLetter, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var res []*ngmodels.NotificationDeadLetter
+	for id := f.lastID; id > 0; id-- {
+		if letter, ok := f.letters[id]; ok && letter.OrgID == q.OrgID {
+			res = append(res, &letter)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeNotificationDeadLetterStore) GetNotificationDeadLetter(_ context.Context, orgID int64, id int64) (*ngmodels.NotificationDeadLetter, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	letter, ok := f.letters[id]
+	if !ok || letter.OrgID != orgID {
+		return nil, ngmodels.ErrNotificationDeadLetterNotFound
+	}
+	return &letter, nil
+}
+
+func (f *fakeNotificationDeadLetterStore) DeleteNotificationDeadLetter(_ context.Context, orgID int64, id int64) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if letter, ok := f.letters[id]; ok && letter.OrgID == orgID {
+		delete(f.letters, id)
+	}
+	return nil
+}
+
+func (f *fakeNotificationDeadLetterStore) DeleteGroupNotificationDeadLetter(_ context.Context, cmd *ngmodels.DeleteGroupNotificationDeadLetterCommand) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for id, letter := range f.letters {
+		if letter.OrgID == cmd.OrgID && letter.Receiver == cmd.Receiver && letter.Integration == cmd.Integration &&
+			letter.IntegrationIndex == cmd.IntegrationIndex && letter.GroupKey == cmd.GroupKey {
+			delete(f.letters, id)
+		}
+	}
+	return nil
+}
+
+func (f *fakeNotificationDeadLetterStore) DeleteNotificationDeadLettersBefore(_ context.Context, t time.Time) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleted = append(f.deleted, t)
+	return 0, nil
+}
+
+func TestNotificationDeadLetters(t *testing.T) {
+	t.Run("disabled without retention", func(t *testing.T) {
+		require.Nil(t, newNotificationDeadLetters(0, &fakeNotificationDeadLetterStore{}, log.NewNopLogger()))
+	})
+
+	t.Run("cleanup deletes the dead letters older than the retention", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		d := newNotificationDeadLetters(24*time.Hour, s, log.NewNopLogger())
+		now := time.Unix(1_600_000_000, 0)
+		d.cleanup(context.Background(), now)
+		require.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, s.deleted)
+	})
+}
+
+func TestDeadLetterStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "HighLatency", "instance": "a"},
+		Annotations: model.LabelSet{"summary": "latency is high"},
+		StartsAt:    time.Unix(1_600_000_000, 0).UTC(),
+	}}}
+	ctx := notify.WithGroupKey(context.Background(), `{}:{alertname="HighLatency"}`)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	execIntegration := func(ctx context.Context, d *notificationDeadLetters, dr *drainer, index int, errs ...error) error {
+		n := &fakeNotificationChannel{errs: errs}
+		integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", index)
+		stage := deadLetterStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, deadLetters: d, drainer: dr, orgID: 3, logger: log.NewNopLogger()}
+		_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		return err
+	}
+	exec := func(d *notificationDeadLetters, dr *drainer, errs ...error) error {
+		return execIntegration(ctx, d, dr, 1, errs...)
+	}
+
+	t.Run("failed notifications are kept", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		d := newNotificationDeadLetters(time.Hour, s, log.NewNopLogger())
+		require.Error(t, exec(d, newDrainer(nil, log.NewNopLogger()), errors.New("unavailable"), errors.New("unavailable")))
+
+		letter, err := s.GetNotificationDeadLetter(context.Background(), 3, 1)
+		require.NoError(t, err)
+		require.Equal(t, "team-a", letter.Receiver)
+		require.Equal(t, "webhook", letter.Integration)
+		require.Equal(t, 1, letter.IntegrationIndex)
+		require.Equal(t, `{}:{alertname="HighLatency"}`, letter.GroupKey)
+		require.Equal(t, "unavailable", letter.Error)
+		require.NotZero(t, letter.FailedAt)
+
+		groupLabels, decoded, err := decodeDeadLetter(letter)
+		require.NoError(t, err)
+		require.Equal(t, model.LabelSet{"alertname": "HighLatency"}, groupLabels)
+		require.Len(t, decoded, 1)
+		require.Equal(t, alerts[0].Fingerprint(), decoded[0].Fingerprint())
+		require.Equal(t, alerts[0].Annotations, decoded[0].Annotations)
+	})
+
+	t.Run("delivered notifications are not kept", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		d := newNotificationDeadLetters(time.Hour, s, log.NewNopLogger())
+		require.NoError(t, exec(d, newDrainer(nil, log.NewNopLogger()), errors.New("unavailable"), nil))
+		require.Empty(t, s.letters)
+	})
+
+	t.Run("the dead letter of a group is deleted once a later notification is delivered", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		d := newNotificationDeadLetters(time.Hour, s, log.NewNopLogger())
+		dr := newDrainer(nil, log.NewNopLogger())
+		otherGroup := notify.WithGroupKey(ctx, `{}:{alertname="HighCPU"}`)
+		require.Error(t, exec(d, dr, errors.New("unavailable"), errors.New("unavailable")))
+		require.Error(t, execIntegration(otherGroup, d, dr, 1, errors.New("unavailable"), errors.New("unavailable")))
+		require.Error(t, execIntegration(ctx, d, dr, 2, errors.New("unavailable"), errors.New("unavailable")))
+		require.Len(t, s.letters, 3)
+
+		require.NoError(t, exec(d, dr, nil))
+		require.Len(t, s.letters, 2)
+		_, err := s.GetNotificationDeadLetter(context.Background(), 3, 1)
+		require.ErrorIs(t, err, ngmodels.ErrNotificationDeadLetterNotFound)
+		for _, letter := range s.letters {
+			require.False(t, letter.GroupKey == `{}:{alertname="HighLatency"}` && letter.IntegrationIndex == 1)
+		}
+	})
+
+	t.Run("notifications failing while the Alertmanager stops are not kept", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		d := newNotificationDeadLetters(time.Hour, s, log.NewNopLogger())
+		dr := newDrainer(nil, log.NewNopLogger())
+		dr.start(time.Minute)
+		require.Error(t, exec(d, dr, errors.New("unavailable"), errors.New("unavailable")))
+		require.Empty(t, s.letters)
+	})
+}
+
+func TestAlertmanager_ReplayDeadLetter(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}}}}
+	// setup keeps a failed notification, and then lets the integration return the errors of the
+	// replays.
+	setup := func(replayErrs ...error) (*Alertmanager, *fakeNotificationDeadLetterStore) {
+		s := &fakeNotificationDeadLetterStore{}
+		n := &fakeNotificationChannel{}
+		am := &Alertmanager{
+			logger:            log.NewNopLogger(),
+			orgID:             3,
+			orgName:           newOrgNameStage(3, nil, log.NewNopLogger()),
+			dashboardMetadata: newDashboardMetadataStage(3, nil),
+			deadLetters:       newNotificationDeadLetters(time.Hour, s, log.NewNopLogger()),
+			integrations: map[string][]notify.Integration{
+				"team-a": {notify.NewIntegration(profilingNotifier{n}, n, "webhook", 1)},
+			},
+		}
+		stage := deadLetterStage{receiver: "team-a", integration: am.integrations["team-a"][0], stage: retryingStage{am.integrations["team-a"][0]}, deadLetters: am.deadLetters, drainer: newDrainer(nil, log.NewNopLogger()), orgID: 3, logger: log.NewNopLogger()}
+		_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+		n.errs = replayErrs
+		return am, s
+	}
+
+	t.Run("the dead letter is deleted once delivered", func(t *testing.T) {
+		am, s := setup(nil)
+		letters, err := am.GetDeadLetters(context.Background(), "", 10)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		require.Equal(t, "team-a", letters[0].Receiver)
+		require.Equal(t, map[string]string{"alertname": "HighLatency"}, letters[0].Alerts[0].Labels)
+
+		require.NoError(t, am.ReplayDeadLetter(context.Background(), letters[0].ID))
+		require.Empty(t, s.letters)
+	})
+
+	t.Run("the dead letter is updated when the replay fails", func(t *testing.T) {
+		am, s := setup(errors.New("still unavailable"))
+		require.ErrorIs(t, am.ReplayDeadLetter(context.Background(), 1), ErrDeadLetterReplayFailed)
+		require.Equal(t, 1, s.letters[1].Replays)
+		require.Equal(t, "still unavailable", s.letters[1].Error)
+	})
+
+	t.Run("a group failing twice has a single dead letter with the latest alerts", func(t *testing.T) {
+		s := &fakeNotificationDeadLetterStore{}
+		n := &fakeNotificationChannel{}
+		am := &Alertmanager{
+			logger:            log.NewNopLogger(),
+			orgID:             3,
+			orgName:           newOrgNameStage(3, nil, log.NewNopLogger()),
+			dashboardMetadata: newDashboardMetadataStage(3, nil),
+			deadLetters:       newNotificationDeadLetters(time.Hour, s, log.NewNopLogger()),
+			integrations: map[string][]notify.Integration{
+				"team-a": {notify.NewIntegration(profilingNotifier{n}, n, "webhook", 1)},
+			},
+		}
+		stage := deadLetterStage{receiver: "team-a", integration: am.integrations["team-a"][0], stage: retryingStage{am.integrations["team-a"][0]}, deadLetters: am.deadLetters, drainer: newDrainer(nil, log.NewNopLogger()), orgID: 3, logger: log.NewNopLogger()}
+		ctx := notify.WithGroupKey(context.Background(), `{}:{alertname="HighLatency"}`)
+		latest := []*types.Alert{alerts[0], {Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency", "instance": "b"}}}}
+		_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+		_, _, err = stage.Exec(ctx, gokit_log.NewNopLogger(), latest...)
+		require.Error(t, err)
+
+		letters, err := am.GetDeadLetters(context.Background(), "", 10)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		require.Len(t, letters[0].Alerts, 2)
+
+		n.errs = []error{nil}
+		n.notified = nil
+		require.NoError(t, am.ReplayDeadLetter(context.Background(), letters[0].ID))
+		require.Len(t, n.notified, 1)
+		require.Len(t, n.notified[0], 2)
+		require.Equal(t, latest[1].Fingerprint(), n.notified[0][1].Fingerprint())
+		require.Empty(t, s.letters)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		am, _ := setup()
+		require.ErrorIs(t, am.ReplayDeadLetter(context.Background(), 2), ngmodels.ErrNotificationDeadLetterNotFound)
+
+		am.integrations = map[string][]notify.Integration{}
+		require.ErrorIs(t, am.ReplayDeadLetter(context.Background(), 1), ErrDeadLetterIntegrationNotFound)
+
+		am.deadLetters = nil
+		require.ErrorIs(t, am.ReplayDeadLetter(context.Background(), 1), ErrNotificationDeadLettersDisabled)
+		_, err := am.GetDeadLetters(context.Background(), "", 10)
+		require.ErrorIs(t, err, ErrNotificationDeadLettersDisabled)
+	})
+}
@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	// notificationHistoryBuffer is the number of deliveries waiting to be saved. Deliveries are
+	// dropped rather than blocking the notification pipeline when the database is too slow.
+	notificationHistoryBuffer = 1000
+	// notificationHistoryBatch is the maximum number of deliveries saved at once.
+	notificationHistoryBatch = 500
+
+	notificationHistoryFlushInterval   = 10 * time.Second
+	notificationHistoryCleanupInterval = time.Hour
+
+	// notificationHistoryMaxError is the maximum length of the recorded errors.
+	notificationHistoryMaxError = 1024
+)
+
+// notificationHistory records the deliveries of the notifications of all the organizations in
+// the database, so that they can be queried with the Grafana data source. The deliveries are
+// saved in batches, and deleted once older than the retention. The opens and clicks of the alert
+// emails are recorded in the delivery that sent the email, after the pending deliveries are saved.
+type notificationHistory struct {
+	store      store.NotificationHistoryStore
+	retention  time.Duration
+	deliveries chan ngmodels.NotificationDelivery
+	emails     chan ngmodels.TrackNotificationDeliveryEmailCommand
+	logger     log.Logger
+}
+
+// newNotificationHistory returns nil if the retention is zero, that is if the history is
+// disabled.
+func newNotificationHistory(retention time.Duration, s store.NotificationHistoryStore, l log.Logger) *notificationHistory {
+	if retention <= 0 {
+		return nil
+	}
+	return &notificationHistory{
+		store:      s,
+		retention:  retention,
+		deliveries: make(chan ngmodels.NotificationDelivery, notificationHistoryBuffer),
+		emails:     make(chan ngmodels.TrackNotificationDeliveryEmailCommand, notificationHistoryBuffer),
+		logger:     l,
+	}
+}
+
+// record queues the delivery to be saved. It never blocks, and does nothing if the history is
+// disabled.
+func (h *notificationHistory) record(d ngmodels.NotificationDelivery) {
+	if h == nil {
+		return
+	}
+	if len(d.Error) > notificationHistoryMaxError {
+		d.Error = d.Error[:notificationHistoryMaxError]
+	}
+	select {
+	case h.deliveries <- d:
+	default:
+		h.logger.Warn("dropping the delivery of a notification, the history is full", "org", d.OrgID, "receiver", d.Receiver, "integration", d.Integration)
+	}
+}
+
+// recordEmail queues an open or a click of the email sent by a delivery to be saved. It never
+// blocks, and does nothing if the history is disabled.
+func (h *notificationHistory) recordEmail(cmd ngmodels.TrackNotificationDeliveryEmailCommand) {
+	if h == nil {
+		return
+	}
+	select {
+	case h.emails <- cmd:
+	default:
+		h.logger.Warn("dropping the tracking of an alert email, the history is full", "org", cmd.OrgID, "dispatch", cmd.DispatchID)
+	}
+}
+
+func (h *notificationHistory) run(ctx context.Context) {
+	flush := time.NewTicker(notificationHistoryFlushInterval)
+	defer flush.Stop()
+	cleanup := time.NewTicker(notificationHistoryCleanupInterval)
+	defer cleanup.Stop()
+
+	batch := make([]ngmodels.NotificationDelivery, 0, notificationHistoryBatch)
+	for {
+		select {
+		case <-ctx.Done():
+			// Save what is left with a fresh context, as the one of the service is done.
+			for len(h.deliveries) > 0 {
+				batch = append(batch, <-h.deliveries)
+			}
+			saveCtx, cancel := context.WithTimeout(context.Background(), notificationHistoryFlushInterval)
+			h.save(saveCtx, batch)
+			for len(h.emails) > 0 {
+				h.saveEmail(saveCtx, <-h.emails)
+			}
+			cancel()
+			return
+		case d := <-h.deliveries:
+			batch = append(batch, d)
+			if len(batch) >= notificationHistoryBatch {
+				batch = h.save(ctx, batch)
+			}
+		case cmd := <-h.emails:
+			// The delivery that sent the email may still be queued or in the batch.
+			for len(h.deliveries) > 0 {
+				batch = append(batch, <-h.deliveries)
+			}
+			batch = h.save(ctx, batch)
+			h.saveEmail(ctx, cmd)
+		case <-flush.C:
+			batch = h.save(ctx, batch)
+		case now := <-cleanup.C:
+			h.cleanup(ctx, now)
+		}
+	}
+}
+
+// save saves the batch, and returns it emptied. The deliveries of a batch that fails to be saved
+// are dropped.
+func (h *notificationHistory) save(ctx context.Context, batch []ngmodels.NotificationDelivery) []ngmodels.NotificationDelivery {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := h.store.SaveNotificationDeliveries(ctx, batch); err != nil {
+		h.logger.Error("failed to save the deliveries of the notifications", "err", err, "deliveries", len(batch))
+	}
+	return batch[:0]
+}
+
+func (h *notificationHistory) saveEmail(ctx context.Context, cmd ngmodels.TrackNotificationDeliveryEmailCommand) {
+	if err := h.store.TrackNotificationDeliveryEmail(ctx, &cmd); err != nil {
+		h.logger.Error("failed to save the tracking of an alert email", "err", err, "org", cmd.OrgID, "dispatch", cmd.DispatchID)
+	}
+}
+
+func (h *notificationHistory) cleanup(ctx context.Context, now time.Time) {
+	deleted, err := h.store.DeleteNotificationDeliveriesBefore(ctx, now.Add(-h.retention))
+	if err != nil {
+		h.logger.Error("failed to delete the old deliveries of the notifications", "err", err)
+		return
+	}
+	h.logger.Debug("deleted the old deliveries of the notifications", "deleted", deleted)
+}
@@ -0,0 +1,186 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeNotificationHistoryStore struct {
+	mtx        sync.Mutex
+	deliveries []ngmodels.NotificationDelivery
+	deleted    []time.Time
+}
+
+// TrackNotificationDeliveryEmail ignores the deliveries that are not saved, like the database.
+func (f *fakeNotificationHistoryStore) TrackNotificationDeliveryEmail(_ context.Context, cmd *ngmodels.TrackNotificationDeliveryEmailCommand) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i := range f.deliveries {
+		d := &f.deliveries[i]
+		if d.OrgID != cmd.OrgID || d.DispatchID != cmd.DispatchID {
+			continue
+		}
+		if cmd.Click {
+			d.EmailClicks++
+		} else {
+			d.EmailOpens++
+		}
+		if d.EmailOpenedAt == 0 {
+			d.EmailOpenedAt = cmd.At
+		}
+	}
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) SaveNotificationDeliveries(_ context.Context, deliveries []ngmodels.NotificationDelivery) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deliveries = append(f.deliveries, deliveries...)
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) GetNotificationDeliveries(context.Context, *ngmodels.GetNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeNotificationHistoryStore) DeleteNotificationDeliveriesBefore(_ context.Context, t time.Time) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleted = append(f.deleted, t)
+	return 0, nil
+}
+
+func (f *fakeNotificationHistoryStore) saved() []ngmodels.NotificationDelivery {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]ngmodels.NotificationDelivery(nil), f.deliveries...)
+}
+
+func TestNotificationHistory(t *testing.T) {
+	t.Run("disabled without retention", func(t *testing.T) {
+		h := newNotificationHistory(0, &fakeNotificationHistoryStore{}, log.NewNopLogger())
+		require.Nil(t, h)
+		// Recording is a no-op.
+		h.record(ngmodels.NotificationDelivery{OrgID: 1})
+	})
+
+	t.Run("deliveries are saved when the service stops", func(t *testing.T) {
+		s := &fakeNotificationHistoryStore{}
+		h := newNotificationHistory(time.Hour, s, log.NewNopLogger())
+		h.record(ngmodels.NotificationDelivery{OrgID: 1, Receiver: "ops", Integration: "slack"})
+		h.record(ngmodels.NotificationDelivery{OrgID: 2, Receiver: "dba", Integration: "email", Error: strings.Repeat("x", 2000)})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		h.run(ctx)
+
+		saved := s.saved()
+		require.Len(t, saved, 2)
+		require.Equal(t, "slack", saved[0].Integration)
+		require.Len(t, saved[1].Error, notificationHistoryMaxError)
+	})
+
+	t.Run("full batches are saved right away", func(t *testing.T) {
+		s := &fakeNotificationHistoryStore{}
+		h := newNotificationHistory(time.Hour, s, log.NewNopLogger())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			h.run(ctx)
+			close(done)
+		}()
+
+		for i := 0; i < notificationHistoryBatch; i++ {
+			h.record(ngmodels.NotificationDelivery{OrgID: 1})
+		}
+		require.Eventually(t, func() bool { return len(s.saved()) == notificationHistoryBatch }, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+	})
+
+	t.Run("deliveries are dropped when the buffer is full", func(t *testing.T) {
+		s := &fakeNotificationHistoryStore{}
+		h := newNotificationHistory(time.Hour, s, log.NewNopLogger())
+		for i := 0; i < notificationHistoryBuffer+10; i++ {
+			h.record(ngmodels.NotificationDelivery{OrgID: 1})
+		}
+		require.Len(t, h.deliveries, notificationHistoryBuffer)
+	})
+
+	t.Run("email opens and clicks are saved after the pending deliveries", func(t *testing.T) {
+		s := &fakeNotificationHistoryStore{}
+		h := newNotificationHistory(time.Hour, s, log.NewNopLogger())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			h.run(ctx)
+			close(done)
+		}()
+
+		h.record(ngmodels.NotificationDelivery{OrgID: 1, Receiver: "ops", Integration: "email", DispatchID: "abc"})
+		h.recordEmail(ngmodels.TrackNotificationDeliveryEmailCommand{OrgID: 1, DispatchID: "abc", At: 1000})
+		h.recordEmail(ngmodels.TrackNotificationDeliveryEmailCommand{OrgID: 1, DispatchID: "abc", At: 2000, Click: true})
+		// Emails of other organizations are not recorded in the delivery.
+		h.recordEmail(ngmodels.TrackNotificationDeliveryEmailCommand{OrgID: 2, DispatchID: "abc", At: 3000})
+		require.Eventually(t, func() bool {
+			saved := s.saved()
+			return len(saved) == 1 && saved[0].EmailClicks == 1
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		saved := s.saved()
+		require.Equal(t, 1, saved[0].EmailOpens)
+		require.Equal(t, int64(1000), saved[0].EmailOpenedAt)
+	})
+
+	t.Run("cleanup deletes the deliveries older than the retention", func(t *testing.T) {
+		s := &fakeNotificationHistoryStore{}
+		h := newNotificationHistory(24*time.Hour, s, log.NewNopLogger())
+		now := time.Unix(1_600_000_000, 0)
+		h.cleanup(context.Background(), now)
+		require.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, s.deleted)
+	})
+}
+
+func TestProfilingStage_NotificationHistory(t *testing.T) {
+	s := &fakeNotificationHistoryStore{}
+	h := newNotificationHistory(time.Hour, s, log.NewNopLogger())
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	n := &fakeNotificationChannel{errs: []error{errors.New("unavailable"), errors.New("unavailable")}}
+	integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", 1)
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, profiles: newDispatchProfiles(), history: h, orgID: 3}
+	_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.Error(t, err)
+
+	// Dry runs are not recorded.
+	dryRun := stage
+	dryRun.dryRun = true
+	dryRun.stage = dryRunNotifyStage{integration: integration}
+	_, _, _ = dryRun.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+
+	require.Len(t, h.deliveries, 1)
+	d := <-h.deliveries
+	require.Equal(t, int64(3), d.OrgID)
+	require.Equal(t, "team-a", d.Receiver)
+	require.Equal(t, "webhook", d.Integration)
+	require.Equal(t, 1, d.IntegrationIndex)
+	require.Equal(t, 1, d.Alerts)
+	require.Equal(t, 3, d.Attempts)
+	require.Equal(t, "unavailable", d.Error)
+	require.NotZero(t, d.StartedAt)
+}
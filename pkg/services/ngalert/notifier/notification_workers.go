@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// notificationWorkersKeepalive is how often the connections of the workers are checked, so that
+// the notifications are not handed to the workers that are gone.
+const notificationWorkersKeepalive = time.Minute
+
+// notificationWorkersServer serves the NotificationWorker service, through which external workers
+// deliver the notifications of the worker contact points of all the organizations.
+type notificationWorkersServer struct {
+	cfg    setting.UnifiedAlertingNotificationWorkerSettings
+	logger log.Logger
+}
+
+// newNotificationWorkersServer returns nil if the service is not configured.
+func newNotificationWorkersServer(cfg setting.UnifiedAlertingNotificationWorkerSettings, l log.Logger) *notificationWorkersServer {
+	if cfg.ListenAddr == "" {
+		return nil
+	}
+	return &notificationWorkersServer{cfg: cfg, logger: l}
+}
+
+func (s *notificationWorkersServer) run(ctx context.Context) {
+	lis, err := net.Listen("tcp", s.cfg.ListenAddr)
+	if err != nil {
+		s.logger.Error("failed to listen for notification workers", "err", err, "address", s.cfg.ListenAddr)
+		return
+	}
+	if err := s.serve(ctx, lis); err != nil {
+		s.logger.Error("failed to serve notification workers", "err", err, "address", s.cfg.ListenAddr)
+	}
+}
+
+// serve serves the service on the listener until the context is canceled.
+func (s *notificationWorkersServer) serve(ctx context.Context, lis net.Listener) error {
+	opts := []grpc.ServerOption{
+		grpc.StreamInterceptor(s.authenticate),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: notificationWorkersKeepalive}),
+	}
+	if s.cfg.CertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.cfg.CertFile, s.cfg.KeyFile)
+		if err != nil {
+			_ = lis.Close()
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	srv := grpc.NewServer(opts...)
+	alertreceiverv1.RegisterNotificationWorkerServer(srv, channels.NewNotificationWorkerServer())
+
+	go func() {
+		<-ctx.Done()
+		srv.Stop()
+	}()
+	s.logger.Info("serving notification workers", "address", lis.Addr().String())
+	if err := srv.Serve(lis); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// authenticate rejects the calls without the token of the workers.
+func (s *notificationWorkersServer) authenticate(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	for _, v := range md.Get("authorization") {
+		token := strings.TrimPrefix(v, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) == 1 {
+			return handler(srv, ss)
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid notification worker token")
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels/alertreceiverv1"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestNotificationWorkersServer(t *testing.T) {
+	require.Nil(t, newNotificationWorkersServer(setting.UnifiedAlertingNotificationWorkerSettings{}, log.New("test")))
+
+	s := newNotificationWorkersServer(setting.UnifiedAlertingNotificationWorkerSettings{ListenAddr: "127.0.0.1:0", Token: "secret"}, log.New("test"))
+	require.NotNil(t, s)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.serve(ctx, lis)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-served)
+	})
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := alertreceiverv1.NewNotificationWorkerClient(conn)
+
+	// work sends an invalid subscription, which the server rejects once the worker is authenticated.
+	work := func(ctx context.Context) error {
+		stream, err := client.Work(ctx)
+		require.NoError(t, err)
+		// The server may have rejected the call already, in which case Recv returns its status.
+		_ = stream.Send(&alertreceiverv1.WorkerMessage{Message: &alertreceiverv1.WorkerMessage_Subscription{
+			Subscription: &alertreceiverv1.Subscription{},
+		}})
+		_, err = stream.Recv()
+		return err
+	}
+
+	err = work(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	err = work(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong"))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	err = work(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret"))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
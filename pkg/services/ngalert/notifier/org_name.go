@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"context"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// orgNameStage adds the name of the organization to the context for the grafana block of
+// the JSON payloads.
+type orgNameStage struct {
+	orgID  int64
+	orgs   store.OrgStore
+	logger log.Logger
+}
+
+func newOrgNameStage(orgID int64, orgs store.OrgStore, logger log.Logger) notify.Stage {
+	return &orgNameStage{orgID: orgID, orgs: orgs, logger: logger}
+}
+
+func (s *orgNameStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if s.orgs == nil {
+		return ctx, alerts, nil
+	}
+
+	name, err := s.orgs.GetOrgName(ctx, s.orgID)
+	if err != nil {
+		// Notifications are still sent, without the name of the organization.
+		s.logger.Warn("failed to get organization name", "err", err)
+		return ctx, alerts, nil
+	}
+	return channels.WithOrgName(ctx, name), alerts, nil
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+func TestOrgNameStage(t *testing.T) {
+	t.Run("adds the name of the organization to the context", func(t *testing.T) {
+		orgs := NewFakeOrgStore(t, []int64{1})
+		ctx, _, err := newOrgNameStage(1, &orgs, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, "Org 1", channels.OrgNameFromContext(ctx))
+	})
+
+	t.Run("notifications are not blocked by failing to get the name", func(t *testing.T) {
+		orgs := NewFakeOrgStore(t, []int64{1})
+		ctx, _, err := newOrgNameStage(2, &orgs, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, channels.OrgNameFromContext(ctx))
+	})
+
+	t.Run("no name without an org store", func(t *testing.T) {
+		ctx, _, err := newOrgNameStage(1, nil, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, channels.OrgNameFromContext(ctx))
+	})
+}
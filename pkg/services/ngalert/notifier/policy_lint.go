@@ -0,0 +1,260 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// severityLabel is the label the linter expects the notification policies to route on.
+const severityLabel = "severity"
+
+// LintPolicies statically analyzes the policy tree and the contact points. receivers is the
+// number of integrations of each contact point, and folderTitles the titles of the folders of
+// the rules by UID.
+//
+// The labels of the alerts are only partially known before evaluation: the labels of the query
+// results and the templated labels of the rules can take any value. The matchers are only
+// checked against the labels whose values are known, so that a route is never reported as
+// unmatched while an alert could still match it.
+func LintPolicies(route *apimodels.Route, receivers map[string]int, rules []*ngmodels.AlertRule, folderTitles map[string]string) ([]apimodels.PolicyLintWarning, error) {
+	l := policyLinter{rules: rules, folderTitles: folderTitles, warnings: []apimodels.PolicyLintWarning{}}
+
+	names := make([]string, 0, len(receivers))
+	for name := range receivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if receivers[name] == 0 {
+			l.warn(apimodels.PolicyLintWarning{
+				Code:     apimodels.PolicyLintEmptyReceiver,
+				Message:  fmt.Sprintf("contact point %q has no integrations, the notifications routed to it are dropped", name),
+				Receiver: name,
+			})
+		}
+	}
+
+	if route == nil {
+		return l.warnings, nil
+	}
+	if err := l.lintRoute(route, "route", nil); err != nil {
+		return nil, err
+	}
+	l.lintSeverities()
+	return l.warnings, nil
+}
+
+type policyLinter struct {
+	rules        []*ngmodels.AlertRule
+	folderTitles map[string]string
+	// severityMatchers are the matchers on the severity label of the reachable routes.
+	severityMatchers []*labels.Matcher
+	warnings         []apimodels.PolicyLintWarning
+}
+
+func (l *policyLinter) warn(w apimodels.PolicyLintWarning) {
+	l.warnings = append(l.warnings, w)
+}
+
+// lintRoute checks the children of the route, whose matchers and the matchers of its parents
+// are inherited. The routes below an unreachable or unmatched route are not reported.
+func (l *policyLinter) lintRoute(route *apimodels.Route, path string, inherited []*labels.Matcher) error {
+	children := make([][]*labels.Matcher, 0, len(route.Routes))
+	for _, child := range route.Routes {
+		matchers, err := routeMatchers(child)
+		if err != nil {
+			return err
+		}
+		children = append(children, matchers)
+	}
+
+	for i, child := range route.Routes {
+		childPath := fmt.Sprintf("%s.routes[%d]", path, i)
+		if j := shadowingRoute(route.Routes, children, i); j >= 0 {
+			l.warn(apimodels.PolicyLintWarning{
+				Code:     apimodels.PolicyLintUnreachableRoute,
+				Message:  fmt.Sprintf("the route is unreachable, %s.routes[%d] matches all its alerts first and does not continue", path, j),
+				Path:     childPath,
+				Receiver: child.Receiver,
+			})
+			continue
+		}
+
+		matchers := append(append(make([]*labels.Matcher, 0, len(inherited)+len(children[i])), inherited...), children[i]...)
+		if len(l.rules) > 0 && !l.anyRuleCanMatch(matchers) {
+			l.warn(apimodels.PolicyLintWarning{
+				Code:     apimodels.PolicyLintUnmatchedRoute,
+				Message:  fmt.Sprintf("the matchers %s cannot match the labels of any alert rule", formatMatchers(children[i])),
+				Path:     childPath,
+				Receiver: child.Receiver,
+			})
+			continue
+		}
+
+		for _, m := range children[i] {
+			if m.Name == severityLabel {
+				l.severityMatchers = append(l.severityMatchers, m)
+			}
+		}
+		if err := l.lintRoute(child, childPath, matchers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lintSeverities checks that the alerts are routed by severity, and that every severity of the
+// rules has a route.
+func (l *policyLinter) lintSeverities() {
+	if len(l.severityMatchers) == 0 {
+		l.warn(apimodels.PolicyLintWarning{
+			Code:    apimodels.PolicyLintMissingSeverityRoute,
+			Message: fmt.Sprintf("no notification policy matches on the %s label, alerts of all severities are handled alike", severityLabel),
+		})
+		return
+	}
+
+	seen := make(map[string]struct{})
+	var severities []string
+	for _, rule := range l.rules {
+		severity, ok := rule.Labels[severityLabel]
+		if !ok || severity == "" || isTemplatedLabel(severity) {
+			continue
+		}
+		if _, ok := seen[severity]; ok {
+			continue
+		}
+		seen[severity] = struct{}{}
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+
+severities:
+	for _, severity := range severities {
+		for _, m := range l.severityMatchers {
+			if m.Matches(severity) {
+				continue severities
+			}
+		}
+		l.warn(apimodels.PolicyLintWarning{
+			Code:    apimodels.PolicyLintMissingSeverityRoute,
+			Message: fmt.Sprintf("no notification policy matches the alerts with %s=%q", severityLabel, severity),
+		})
+	}
+}
+
+func (l *policyLinter) anyRuleCanMatch(matchers []*labels.Matcher) bool {
+	for _, rule := range l.rules {
+		if l.ruleCanMatch(rule, matchers) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *policyLinter) ruleCanMatch(rule *ngmodels.AlertRule, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if value, ok := l.ruleLabel(rule, m.Name); ok && !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleLabel returns the value of the label of the alerts of the rule, if it is known before the
+// evaluation of the rule.
+func (l *policyLinter) ruleLabel(rule *ngmodels.AlertRule, name string) (string, bool) {
+	switch name {
+	case model.AlertNameLabel:
+		return rule.Title, true
+	case ngmodels.RuleUIDLabel:
+		return rule.UID, true
+	case ngmodels.FolderTitleLabel:
+		title, ok := l.folderTitles[rule.NamespaceUID]
+		return title, ok
+	}
+	value, ok := rule.Labels[name]
+	if !ok || isTemplatedLabel(value) {
+		return "", false
+	}
+	return value, true
+}
+
+func isTemplatedLabel(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// shadowingRoute returns the index of the first sibling before the route that does not continue
+// and matches all the alerts of the route, or -1.
+func shadowingRoute(routes []*apimodels.Route, matchers [][]*labels.Matcher, i int) int {
+	for j := 0; j < i; j++ {
+		if !routes[j].Continue && matchersCover(matchers[j], matchers[i]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// matchersCover returns whether every alert matching b also matches a, which is the case when
+// each matcher of a is implied by a matcher of b on the same label.
+func matchersCover(a, b []*labels.Matcher) bool {
+outer:
+	for _, m := range a {
+		for _, n := range b {
+			if n.Name != m.Name {
+				continue
+			}
+			if n.Type == m.Type && n.Value == m.Value || n.Type == labels.MatchEqual && m.Matches(n.Value) {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// routeMatchers returns all the matchers of the route, including the deprecated ones.
+func routeMatchers(route *apimodels.Route) ([]*labels.Matcher, error) {
+	matchers := make([]*labels.Matcher, 0, len(route.Match)+len(route.MatchRE)+len(route.Matchers)+len(route.ObjectMatchers))
+	names := make([]string, 0, len(route.Match))
+	for name := range route.Match {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m, err := labels.NewMatcher(labels.MatchEqual, name, route.Match[name])
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	names = names[:0]
+	for name := range route.MatchRE {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m, err := labels.NewMatcher(labels.MatchRegexp, name, route.MatchRE[name].String())
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	matchers = append(matchers, route.Matchers...)
+	return append(matchers, route.ObjectMatchers...), nil
+}
+
+func formatMatchers(matchers []*labels.Matcher) string {
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		parts = append(parts, m.String())
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestLintPolicies(t *testing.T) {
+	matcher := func(typ labels.MatchType, name, value string) *labels.Matcher {
+		m, err := labels.NewMatcher(typ, name, value)
+		require.NoError(t, err)
+		return m
+	}
+	rules := []*ngmodels.AlertRule{
+		{UID: "rule1", Title: "HighCPU", NamespaceUID: "folder1", Labels: map[string]string{"severity": "critical", "team": "ops"}},
+		{UID: "rule2", Title: "DiskFull", NamespaceUID: "folder1", Labels: map[string]string{"severity": "warning", "team": "{{ $labels.team }}"}},
+		{UID: "rule3", Title: "Latency", NamespaceUID: "folder2", Labels: map[string]string{"severity": "info"}},
+	}
+	folderTitles := map[string]string{"folder1": "Infrastructure", "folder2": "Web"}
+
+	t.Run("reports the problems of the policy tree and contact points", func(t *testing.T) {
+		route := &apimodels.Route{
+			Receiver: "default",
+			Routes: []*apimodels.Route{
+				{Receiver: "critical", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "severity", "critical")}},
+				{Receiver: "ops", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "severity", "critical"), matcher(labels.MatchEqual, "team", "ops")}},
+				{Receiver: "warning", Match: map[string]string{"severity": "warning"}},
+				{Receiver: "dba", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "alertname", "DatabaseDown")}},
+				{Receiver: "infra", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "grafana_folder", "Infrastructure")}, Routes: []*apimodels.Route{
+					{Receiver: "latency", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "alertname", "Latency")}},
+				}},
+				// Any team can match the templated label of rule2.
+				{Receiver: "web", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "team", "web")}},
+				{Receiver: "default"},
+				{Receiver: "ops", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "env", "prod")}},
+			},
+		}
+		receivers := map[string]int{"default": 1, "critical": 1, "ops": 2, "warning": 1, "dba": 1, "infra": 0, "latency": 1, "web": 1}
+
+		warnings, err := LintPolicies(route, receivers, rules, folderTitles)
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.PolicyLintWarning{
+			{Code: apimodels.PolicyLintEmptyReceiver, Message: `contact point "infra" has no integrations, the notifications routed to it are dropped`, Receiver: "infra"},
+			{Code: apimodels.PolicyLintUnreachableRoute, Message: "the route is unreachable, route.routes[0] matches all its alerts first and does not continue", Path: "route.routes[1]", Receiver: "ops"},
+			{Code: apimodels.PolicyLintUnmatchedRoute, Message: `the matchers {alertname="DatabaseDown"} cannot match the labels of any alert rule`, Path: "route.routes[3]", Receiver: "dba"},
+			{Code: apimodels.PolicyLintUnmatchedRoute, Message: `the matchers {alertname="Latency"} cannot match the labels of any alert rule`, Path: "route.routes[4].routes[0]", Receiver: "latency"},
+			{Code: apimodels.PolicyLintUnreachableRoute, Message: "the route is unreachable, route.routes[6] matches all its alerts first and does not continue", Path: "route.routes[7]", Receiver: "ops"},
+			{Code: apimodels.PolicyLintMissingSeverityRoute, Message: `no notification policy matches the alerts with severity="info"`},
+		}, warnings)
+	})
+
+	t.Run("continue keeps the next routes reachable", func(t *testing.T) {
+		route := &apimodels.Route{
+			Receiver: "default",
+			Routes: []*apimodels.Route{
+				{Receiver: "default", Continue: true, MatchRE: mustMatchRegexps(t, "severity", "critical|warning|info")},
+				{Receiver: "default", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "severity", "critical")}},
+			},
+		}
+
+		warnings, err := LintPolicies(route, map[string]int{"default": 1}, rules, folderTitles)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+
+	t.Run("regular expressions cover the matching values", func(t *testing.T) {
+		route := &apimodels.Route{
+			Receiver: "default",
+			Routes: []*apimodels.Route{
+				{Receiver: "default", MatchRE: mustMatchRegexps(t, "severity", "critical|warning|info")},
+				{Receiver: "default", ObjectMatchers: apimodels.ObjectMatchers{matcher(labels.MatchEqual, "severity", "warning")}},
+			},
+		}
+
+		warnings, err := LintPolicies(route, map[string]int{"default": 1}, rules, folderTitles)
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, apimodels.PolicyLintUnreachableRoute, warnings[0].Code)
+		require.Equal(t, "route.routes[1]", warnings[0].Path)
+	})
+
+	t.Run("reports the missing severity routes without rules", func(t *testing.T) {
+		warnings, err := LintPolicies(&apimodels.Route{Receiver: "default"}, map[string]int{"default": 1}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.PolicyLintWarning{
+			{Code: apimodels.PolicyLintMissingSeverityRoute, Message: "no notification policy matches on the severity label, alerts of all severities are handled alike"},
+		}, warnings)
+	})
+}
+
+func mustMatchRegexps(t *testing.T, name, re string) config.MatchRegexps {
+	t.Helper()
+	var r config.Regexp
+	require.NoError(t, r.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*string)) = re
+		return nil
+	}))
+	return config.MatchRegexps{name: r}
+}
@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+)
+
+// policyPreferences are the timezone and locale set by the notification policies, by the key of
+// their route. Nested policies inherit the timezone and locale of their parent when they do not
+// set them.
+type policyPreferences map[string]channels.OrgPreferences
+
+func newPolicyPreferences(r *apimodels.Route, route *dispatch.Route) policyPreferences {
+	p := policyPreferences{}
+	p.add(r, route, channels.OrgPreferences{})
+	return p
+}
+
+func (p policyPreferences) add(r *apimodels.Route, route *dispatch.Route, parent channels.OrgPreferences) {
+	if r == nil || route == nil {
+		return
+	}
+	prefs := parent
+	if r.TimeZone != "" {
+		prefs.TimeZone = r.TimeZone
+	}
+	if r.Locale != "" {
+		prefs.Locale = r.Locale
+	}
+	if prefs != (channels.OrgPreferences{}) {
+		if _, ok := p[route.Key()]; !ok {
+			p[route.Key()] = prefs
+		}
+	}
+	for i, child := range route.Routes {
+		if i < len(r.Routes) {
+			p.add(r.Routes[i], child, prefs)
+		}
+	}
+}
+
+// forGroup returns the preferences of the policy of the aggregation group, whose key is the key
+// of the route followed by the labels of the group.
+func (p policyPreferences) forGroup(groupKey string) (channels.OrgPreferences, bool) {
+	var (
+		match string
+		prefs channels.OrgPreferences
+		found bool
+	)
+	for key, candidate := range p {
+		if len(key) >= len(match) && strings.HasPrefix(groupKey, key+":") {
+			match, prefs, found = key, candidate, true
+		}
+	}
+	return prefs, found
+}
+
+// orgPreferencesStage adds the timezone and locale of the notification policy, or else of the
+// organization, to the context so that every notifier renders its notifications with them.
+type orgPreferencesStage struct {
+	orgID    int64
+	prefs    pref.Service
+	policies policyPreferences
+	logger   log.Logger
+}
+
+func newOrgPreferencesStage(orgID int64, prefs pref.Service, policies policyPreferences, logger log.Logger) notify.Stage {
+	return &orgPreferencesStage{orgID: orgID, prefs: prefs, policies: policies, logger: logger}
+}
+
+func (s *orgPreferencesStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var orgPrefs channels.OrgPreferences
+	if s.prefs != nil {
+		p, err := s.prefs.GetWithDefaults(ctx, &pref.GetPreferenceWithDefaultsQuery{OrgID: s.orgID})
+		if err != nil {
+			// Notifications are still sent, with times in UTC unless the policy sets a timezone.
+			s.logger.Warn("failed to get organization preferences", "err", err)
+		} else {
+			orgPrefs.TimeZone = p.Timezone
+			if p.JSONData != nil {
+				orgPrefs.Locale = p.JSONData.Locale
+			}
+		}
+	}
+
+	if groupKey, ok := notify.GroupKey(ctx); ok {
+		if policy, ok := s.policies.forGroup(groupKey); ok {
+			if policy.TimeZone != "" {
+				orgPrefs.TimeZone = policy.TimeZone
+			}
+			if policy.Locale != "" {
+				orgPrefs.Locale = policy.Locale
+			}
+		}
+	}
+
+	if orgPrefs == (channels.OrgPreferences{}) {
+		return ctx, alerts, nil
+	}
+	return channels.WithOrgPreferences(ctx, orgPrefs), alerts, nil
+}
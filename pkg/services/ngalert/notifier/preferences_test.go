@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/preference/preftest"
+)
+
+func TestOrgPreferencesStage(t *testing.T) {
+	t.Run("adds the preferences of the organization to the context", func(t *testing.T) {
+		prefs := preftest.NewPreferenceServiceFake()
+		prefs.ExpectedPreference = &pref.Preference{
+			Timezone: "Europe/Berlin",
+			JSONData: &pref.PreferenceJSONData{Locale: "de-DE"},
+		}
+
+		ctx, _, err := newOrgPreferencesStage(1, prefs, nil, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+
+		p, ok := channels.OrgPreferencesFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "Europe/Berlin", Locale: "de-DE"}, p)
+	})
+
+	t.Run("notifications are not blocked by failing to get the preferences", func(t *testing.T) {
+		prefs := preftest.NewPreferenceServiceFake()
+		prefs.ExpectedError = errors.New("database is locked")
+
+		ctx, _, err := newOrgPreferencesStage(1, prefs, nil, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, ok := channels.OrgPreferencesFromContext(ctx)
+		require.False(t, ok)
+	})
+
+	t.Run("no preferences without a preference service", func(t *testing.T) {
+		ctx, _, err := newOrgPreferencesStage(1, nil, nil, log.New("test")).Exec(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, ok := channels.OrgPreferencesFromContext(ctx)
+		require.False(t, ok)
+	})
+}
+
+func TestOrgPreferencesStage_Policies(t *testing.T) {
+	cfg := &apimodels.Route{
+		Receiver: "default",
+		Routes: []*apimodels.Route{{
+			Receiver:       "team-a",
+			ObjectMatchers: apimodels.ObjectMatchers{{Type: labels.MatchEqual, Name: "team", Value: "a"}},
+			TimeZone:       "America/New_York",
+			Routes: []*apimodels.Route{{
+				Receiver:       "team-a-db",
+				ObjectMatchers: apimodels.ObjectMatchers{{Type: labels.MatchEqual, Name: "service", Value: "db"}},
+				Locale:         "fr-FR",
+			}},
+		}, {
+			Receiver:       "team-b",
+			ObjectMatchers: apimodels.ObjectMatchers{{Type: labels.MatchEqual, Name: "team", Value: "b"}},
+		}},
+	}
+	route := dispatch.NewRoute(cfg.AsAMRoute(), nil)
+	policies := newPolicyPreferences(cfg, route)
+
+	orgPrefs := preftest.NewPreferenceServiceFake()
+	orgPrefs.ExpectedPreference = &pref.Preference{
+		Timezone: "Europe/Berlin",
+		JSONData: &pref.PreferenceJSONData{Locale: "de-DE"},
+	}
+	failing := preftest.NewPreferenceServiceFake()
+	failing.ExpectedError = errors.New("database is locked")
+
+	// exec returns the preferences of the notifications of the alert with the labels.
+	exec := func(t *testing.T, prefs pref.Service, lbls model.LabelSet) (channels.OrgPreferences, bool) {
+		t.Helper()
+		routes := route.Match(lbls)
+		require.Len(t, routes, 1)
+		ctx := notify.WithGroupKey(context.Background(), fmt.Sprintf("%s:%s", routes[0].Key(), lbls))
+		ctx, _, err := newOrgPreferencesStage(1, prefs, policies, log.New("test")).Exec(ctx, nil)
+		require.NoError(t, err)
+		return channels.OrgPreferencesFromContext(ctx)
+	}
+
+	t.Run("the timezone of the policy overrides the one of the organization", func(t *testing.T) {
+		p, ok := exec(t, orgPrefs, model.LabelSet{"team": "a"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "America/New_York", Locale: "de-DE"}, p)
+	})
+
+	t.Run("nested policies inherit the timezone of their parent", func(t *testing.T) {
+		p, ok := exec(t, orgPrefs, model.LabelSet{"team": "a", "service": "db"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "America/New_York", Locale: "fr-FR"}, p)
+	})
+
+	t.Run("policies without preferences use the ones of the organization", func(t *testing.T) {
+		p, ok := exec(t, orgPrefs, model.LabelSet{"team": "b"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "Europe/Berlin", Locale: "de-DE"}, p)
+
+		p, ok = exec(t, orgPrefs, model.LabelSet{"team": "c"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "Europe/Berlin", Locale: "de-DE"}, p)
+	})
+
+	t.Run("the preferences of the policy are used without the ones of the organization", func(t *testing.T) {
+		p, ok := exec(t, failing, model.LabelSet{"team": "a", "service": "db"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "America/New_York", Locale: "fr-FR"}, p)
+
+		p, ok = exec(t, nil, model.LabelSet{"team": "a"})
+		require.True(t, ok)
+		require.Equal(t, channels.OrgPreferences{TimeZone: "America/New_York"}, p)
+
+		_, ok = exec(t, nil, model.LabelSet{"team": "b"})
+		require.False(t, ok)
+	})
+}
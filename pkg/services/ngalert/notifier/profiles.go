@@ -0,0 +1,218 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// maxDispatchProfiles is the number of dispatches kept per receiver.
+const maxDispatchProfiles = 50
+
+// dispatchProfiles keeps the time breakdown of the last dispatches of each receiver, newest first.
+type dispatchProfiles struct {
+	mtx       sync.Mutex
+	receivers map[string][]apimodels.DispatchProfile
+}
+
+func newDispatchProfiles() *dispatchProfiles {
+	return &dispatchProfiles{receivers: map[string][]apimodels.DispatchProfile{}}
+}
+
+func (p *dispatchProfiles) add(receiver string, profile apimodels.DispatchProfile) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	profiles := append([]apimodels.DispatchProfile{profile}, p.receivers[receiver]...)
+	if len(profiles) > maxDispatchProfiles {
+		profiles = profiles[:maxDispatchProfiles]
+	}
+	p.receivers[receiver] = profiles
+}
+
+// get returns up to limit dispatches per receiver. Receivers are sorted by name.
+func (p *dispatchProfiles) get(limit int) apimodels.ReceiverProfiles {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	result := make(apimodels.ReceiverProfiles, 0, len(p.receivers))
+	for name, profiles := range p.receivers {
+		if limit < len(profiles) {
+			profiles = profiles[:limit]
+		}
+		result = append(result, apimodels.ReceiverProfile{
+			Name:       name,
+			Dispatches: append([]apimodels.DispatchProfile(nil), profiles...),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// trackEmail records that the email sent by a dispatch was opened, or that one of its links was clicked.
+func (p *dispatchProfiles) trackEmail(dispatchID string, at time.Time, click bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, profiles := range p.receivers {
+		for i := range profiles {
+			if profiles[i].ID != dispatchID {
+				continue
+			}
+			if click {
+				profiles[i].EmailClicks++
+			} else {
+				profiles[i].EmailOpens++
+			}
+			// A click also means the email was opened, even if its images were not loaded.
+			if profiles[i].EmailOpenedAt == nil {
+				profiles[i].EmailOpenedAt = &at
+			}
+			return
+		}
+	}
+}
+
+// removeExcept forgets the receivers that are not in the configuration anymore.
+func (p *dispatchProfiles) removeExcept(receivers map[string][]notify.Integration) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for name := range p.receivers {
+		if _, ok := receivers[name]; !ok {
+			delete(p.receivers, name)
+		}
+	}
+}
+
+// profilingStage records the time breakdown of the delivery of notifications by an integration.
+// In a dry run, it also records the notifications the integration would have sent.
+type profilingStage struct {
+	receiver    string
+	integration notify.Integration
+	stage       notify.Stage
+	profiles    *dispatchProfiles
+	dryRun      bool
+	// emailTracking is nil if the organization does not track its alert emails.
+	emailTracking *emailTracking
+	// metrics records the notifications of the receiver, dry runs excluded.
+	metrics *metrics.Alertmanager
+	// history records the deliveries of the notifications, dry runs excluded. It is nil if the
+	// history is disabled.
+	history *notificationHistory
+	// cassettes record or replay the webhooks of the integration, dry runs excluded. It is nil
+	// unless the development VCR mode is enabled.
+	cassettes *cassettes
+	orgID     int64
+}
+
+func (s profilingStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	p := &channels.DispatchProfile{}
+	d := &channels.DryRun{}
+	id := util.GenerateShortUID()
+	if s.dryRun {
+		ctx = channels.WithDryRun(ctx, d)
+	} else if s.emailTracking != nil {
+		ctx = channels.WithEmailTracking(ctx, s.emailTracking.forDispatch(id))
+	}
+	if !s.dryRun && s.cassettes != nil {
+		cassette, err := s.cassettes.forIntegration(s.receiver, s.integration)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("failed to load the cassette of the integration: %w", err)
+		}
+		ctx = channels.WithCassette(ctx, cassette)
+	}
+	start := time.Now()
+	ctx, alerts, err := s.stage.Exec(channels.WithDispatchProfile(ctx, p), l, alerts...)
+	if !s.dryRun && s.metrics != nil {
+		s.observe(time.Since(start), err)
+	}
+
+	breakdown := p.Breakdown()
+	profile := apimodels.DispatchProfile{
+		ID:          id,
+		Integration: s.integration.Name(),
+		Index:       s.integration.Index(),
+		StartedAt:   start,
+		Alerts:      len(alerts),
+		Attempts:    breakdown.Attempts,
+		StatusCode:  breakdown.StatusCode,
+		DurationMs:  milliseconds(time.Since(start)),
+		TemplateMs:  milliseconds(breakdown.Template),
+		ImageMs:     milliseconds(breakdown.Image),
+		SendMs:      milliseconds(breakdown.Send),
+		Failover:    isFailover(ctx),
+	}
+	if err != nil {
+		profile.Error = err.Error()
+		// The retries of the integration give up when the context of the notification is done, the
+		// error can be any error of the last attempt.
+		profile.Canceled = ctx.Err() != nil
+	}
+	if s.dryRun {
+		profile.DryRun = true
+		for _, r := range d.Requests() {
+			profile.Requests = append(profile.Requests, apimodels.DryRunRequest{Target: r.Target, Body: r.Body})
+		}
+	}
+	s.profiles.add(s.receiver, profile)
+	if !s.dryRun {
+		s.history.record(ngmodels.NotificationDelivery{
+			OrgID:            s.orgID,
+			Receiver:         s.receiver,
+			Integration:      profile.Integration,
+			IntegrationIndex: profile.Index,
+			StartedAt:        start.UnixMilli(),
+			DurationMs:       profile.DurationMs,
+			Alerts:           profile.Alerts,
+			Attempts:         profile.Attempts,
+			StatusCode:       profile.StatusCode,
+			Error:            profile.Error,
+			Canceled:         profile.Canceled,
+			Failover:         profile.Failover,
+			DispatchID:       id,
+		})
+	}
+
+	return ctx, alerts, err
+}
+
+func (s profilingStage) observe(d time.Duration, err error) {
+	integration := s.integration.Name()
+	s.metrics.ReceiverNotifications.WithLabelValues(s.receiver, integration).Inc()
+	if err != nil {
+		s.metrics.ReceiverNotificationsFailed.WithLabelValues(s.receiver, integration).Inc()
+	}
+	s.metrics.ReceiverNotificationLatency.WithLabelValues(s.receiver, integration).Observe(d.Seconds())
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// profilingNotifier counts the attempts to deliver a notification in the profile of the dispatch.
+type profilingNotifier struct {
+	channels.NotificationChannel
+}
+
+func (n profilingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	if p, ok := channels.DispatchProfileFromContext(ctx); ok {
+		p.Attempt()
+	}
+	return n.NotificationChannel.Notify(ctx, as...)
+}
+
+// GetReceiverProfiles returns the time breakdown of the last dispatches of each receiver.
+func (am *Alertmanager) GetReceiverProfiles(limit int) apimodels.ReceiverProfiles {
+	return am.profiles.get(limit)
+}
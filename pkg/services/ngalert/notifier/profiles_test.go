@@ -0,0 +1,118 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+type fakeNotificationChannel struct {
+	errs []error
+	// notified are the alerts of the notifications.
+	notified [][]*types.Alert
+}
+
+func (f *fakeNotificationChannel) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	f.notified = append(f.notified, alerts)
+	if len(f.errs) == 0 {
+		return false, errors.New("unavailable")
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err != nil, err
+}
+
+func (f *fakeNotificationChannel) SendResolved() bool {
+	return true
+}
+
+// retryingStage retries the integration like the retry stage of the Alertmanager, without backoff.
+type retryingStage struct {
+	integration notify.Integration
+}
+
+func (s retryingStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	for {
+		retry, err := s.integration.Notify(ctx, alerts...)
+		if err == nil || !retry {
+			return ctx, alerts, err
+		}
+	}
+}
+
+func TestProfilingStage(t *testing.T) {
+	profiles := newDispatchProfiles()
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	n := &fakeNotificationChannel{errs: []error{errors.New("unavailable"), nil}}
+	integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", 1)
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, profiles: profiles}
+	_, _, err := stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+
+	n.errs = []error{errors.New("unavailable"), errors.New("unavailable")}
+	_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.Error(t, err)
+
+	result := profiles.get(10)
+	require.Len(t, result, 1)
+	require.Equal(t, "team-a", result[0].Name)
+	require.Len(t, result[0].Dispatches, 2)
+
+	// Most recent dispatch first.
+	failed, succeeded := result[0].Dispatches[0], result[0].Dispatches[1]
+	require.Equal(t, "webhook", succeeded.Integration)
+	require.Equal(t, 1, succeeded.Index)
+	require.Equal(t, 1, succeeded.Alerts)
+	require.Equal(t, 2, succeeded.Attempts)
+	require.Empty(t, succeeded.Error)
+	require.Equal(t, 3, failed.Attempts)
+	require.Equal(t, "unavailable", failed.Error)
+	require.False(t, failed.Canceled)
+	require.False(t, failed.Failover)
+
+	// The failures of the notifications whose context is done are cancellations.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n.errs = []error{context.Canceled}
+	_, _, err = stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+	require.Error(t, err)
+	require.True(t, profiles.get(1)[0].Dispatches[0].Canceled)
+}
+
+func TestDispatchProfiles(t *testing.T) {
+	profiles := newDispatchProfiles()
+	for i := 0; i < maxDispatchProfiles+5; i++ {
+		profiles.add("b", apimodels.DispatchProfile{Integration: fmt.Sprint(i)})
+	}
+	profiles.add("a", apimodels.DispatchProfile{Integration: "0"})
+
+	result := profiles.get(3)
+	require.Len(t, result, 2)
+	require.Equal(t, "a", result[0].Name)
+	require.Equal(t, "b", result[1].Name)
+	require.Len(t, result[1].Dispatches, 3)
+	require.Equal(t, fmt.Sprint(maxDispatchProfiles+4), result[1].Dispatches[0].Integration)
+
+	result = profiles.get(maxDispatchProfiles * 2)
+	require.Len(t, result[1].Dispatches, maxDispatchProfiles)
+
+	profiles.removeExcept(map[string][]notify.Integration{"a": nil})
+	result = profiles.get(3)
+	require.Len(t, result, 1)
+	require.Equal(t, "a", result[0].Name)
+
+	// Profiles are not recorded outside of a dispatch.
+	_, ok := channels.DispatchProfileFromContext(context.Background())
+	require.False(t, ok)
+}
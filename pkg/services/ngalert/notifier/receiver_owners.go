@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+const (
+	receiverOwnersNamespace = "ngalert.receiver-integration-owners"
+	// receiverOwnersTimeout is the timeout to forget the owners of the deleted integrations when
+	// a configuration is applied.
+	receiverOwnersTimeout = 5 * time.Second
+)
+
+// ReceiverOwnerStore keeps track of the teams that own contact points. Contact points are owned
+// by a team when they were created by a user that is only allowed to manage the contact points
+// of the team. The owners are kept by UID of the integrations of the contact points rather than
+// by name, so that a contact point keeps its owner when it is renamed, and that a new contact
+// point with the name of a deleted one is not owned by its team.
+type ReceiverOwnerStore struct {
+	kv kvstore.KVStore
+}
+
+func NewReceiverOwnerStore(kv kvstore.KVStore) *ReceiverOwnerStore {
+	return &ReceiverOwnerStore{kv: kv}
+}
+
+// GetOwners returns the ID of the owning team by integration UID.
+func (s *ReceiverOwnerStore) GetOwners(ctx context.Context, orgID int64) (map[string]int64, error) {
+	all, err := kvstore.WithNamespace(s.kv, orgID, receiverOwnersNamespace).GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string]int64, len(all[orgID]))
+	for uid, value := range all[orgID] {
+		teamID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner of integration %s: %w", uid, err)
+		}
+		owners[uid] = teamID
+	}
+	return owners, nil
+}
+
+// SetOwner sets the team that owns the integration of a contact point.
+func (s *ReceiverOwnerStore) SetOwner(ctx context.Context, orgID int64, uid string, teamID int64) error {
+	return kvstore.WithNamespace(s.kv, orgID, receiverOwnersNamespace).Set(ctx, uid, strconv.FormatInt(teamID, 10))
+}
+
+// DeleteOwner removes the owner of the integration of a contact point.
+func (s *ReceiverOwnerStore) DeleteOwner(ctx context.Context, orgID int64, uid string) error {
+	return kvstore.WithNamespace(s.kv, orgID, receiverOwnersNamespace).Del(ctx, uid)
+}
+
+// DeleteOwnersExcept removes the owners of the integrations that are not in uids, that is of the
+// integrations that are not in the configuration anymore.
+func (s *ReceiverOwnerStore) DeleteOwnersExcept(ctx context.Context, orgID int64, uids map[string]struct{}) error {
+	owners, err := s.GetOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for uid := range owners {
+		if _, ok := uids[uid]; ok {
+			continue
+		}
+		if err := s.DeleteOwner(ctx, orgID, uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileReceiverOwners forgets the owners of the integrations that are not in the applied
+// configuration anymore, whoever changed it, so that an integration created later with the same
+// UID is not owned by their team.
+func (am *Alertmanager) reconcileReceiverOwners(cfg *apimodels.PostableUserConfig) {
+	if am.receiverOwners == nil {
+		return
+	}
+	uids := map[string]struct{}{}
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		for _, integration := range r.GrafanaManagedReceivers {
+			uids[integration.UID] = struct{}{}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), receiverOwnersTimeout)
+	defer cancel()
+	if err := am.receiverOwners.DeleteOwnersExcept(ctx, am.orgID, uids); err != nil {
+		am.logger.Error("failed to delete the owners of the deleted contact points", "err", err)
+	}
+}
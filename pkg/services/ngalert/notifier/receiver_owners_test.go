@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestReceiverOwnerStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewReceiverOwnerStore(NewFakeKVStore(t))
+
+	owners, err := s.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, owners)
+
+	require.NoError(t, s.SetOwner(ctx, 1, "uid-a", 10))
+	require.NoError(t, s.SetOwner(ctx, 1, "uid-b", 20))
+	require.NoError(t, s.SetOwner(ctx, 1, "uid-c", 20))
+	require.NoError(t, s.SetOwner(ctx, 2, "uid-d", 30))
+
+	owners, err = s.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"uid-a": 10, "uid-b": 20, "uid-c": 20}, owners)
+
+	require.NoError(t, s.DeleteOwner(ctx, 1, "uid-a"))
+	owners, err = s.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"uid-b": 20, "uid-c": 20}, owners)
+
+	require.NoError(t, s.DeleteOwnersExcept(ctx, 1, map[string]struct{}{"uid-c": {}, "uid-d": {}}))
+	owners, err = s.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"uid-c": 20}, owners)
+	owners, err = s.GetOwners(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"uid-d": 30}, owners)
+}
+
+func TestAlertmanager_ReconcileReceiverOwners(t *testing.T) {
+	ctx := context.Background()
+	s := NewReceiverOwnerStore(NewFakeKVStore(t))
+	require.NoError(t, s.SetOwner(ctx, 1, "uid-a", 10))
+	require.NoError(t, s.SetOwner(ctx, 1, "uid-b", 10))
+	am := &Alertmanager{orgID: 1, receiverOwners: s, logger: log.NewNopLogger()}
+
+	cfg, err := Load([]byte(`{
+		"alertmanager_config": {
+			"route": {"receiver": "team-a"},
+			"receivers": [{"name": "team-a", "grafana_managed_receiver_configs": [{"uid": "uid-a", "name": "team-a", "type": "email", "settings": {"addresses": "a@example.com"}}]}]
+		}
+	}`))
+	require.NoError(t, err)
+	am.reconcileReceiverOwners(cfg)
+
+	owners, err := s.GetOwners(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"uid-a": 10}, owners)
+}
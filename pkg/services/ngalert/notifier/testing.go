@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -97,6 +98,58 @@ func (f *FakeConfigStore) UpdateAlertmanagerConfiguration(_ context.Context, cmd
 	return errors.New("config not found or hash not valid")
 }
 
+func (f *FakeConfigStore) SaveNotificationDeliveries(context.Context, []models.NotificationDelivery) error {
+	return nil
+}
+
+func (f *FakeConfigStore) GetNotificationDeliveries(context.Context, *models.GetNotificationDeliveriesQuery) ([]*models.NotificationDelivery, error) {
+	return nil, nil
+}
+
+func (f *FakeConfigStore) TrackNotificationDeliveryEmail(context.Context, *models.TrackNotificationDeliveryEmailCommand) error {
+	return nil
+}
+
+func (f *FakeConfigStore) DeleteNotificationDeliveriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *FakeConfigStore) SaveNotificationAuditEntries(context.Context, []models.NotificationAuditEntry) error {
+	return nil
+}
+
+func (f *FakeConfigStore) GetNotificationAuditEntries(context.Context, *models.GetNotificationAuditQuery) ([]*models.NotificationAuditEntry, error) {
+	return nil, nil
+}
+
+func (f *FakeConfigStore) DeleteNotificationAuditEntriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *FakeConfigStore) SaveNotificationDeadLetter(context.Context, *models.NotificationDeadLetter) error {
+	return nil
+}
+
+func (f *FakeConfigStore) GetNotificationDeadLetters(context.Context, *models.GetNotificationDeadLettersQuery) ([]*models.NotificationDeadLetter, error) {
+	return nil, nil
+}
+
+func (f *FakeConfigStore) GetNotificationDeadLetter(context.Context, int64, int64) (*models.NotificationDeadLetter, error) {
+	return nil, models.ErrNotificationDeadLetterNotFound
+}
+
+func (f *FakeConfigStore) DeleteNotificationDeadLetter(context.Context, int64, int64) error {
+	return nil
+}
+
+func (f *FakeConfigStore) DeleteGroupNotificationDeadLetter(context.Context, *models.DeleteGroupNotificationDeadLetterCommand) error {
+	return nil
+}
+
+func (f *FakeConfigStore) DeleteNotificationDeadLettersBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
 type FakeOrgStore struct {
 	orgs []int64
 }
@@ -113,6 +166,15 @@ func (f *FakeOrgStore) GetOrgs(_ context.Context) ([]int64, error) {
 	return f.orgs, nil
 }
 
+func (f *FakeOrgStore) GetOrgName(_ context.Context, orgID int64) (string, error) {
+	for _, id := range f.orgs {
+		if id == orgID {
+			return fmt.Sprintf("Org %d", orgID), nil
+		}
+	}
+	return "", fmt.Errorf("could not find org %d", orgID)
+}
+
 type FakeKVStore struct {
 	mtx   sync.Mutex
 	store map[int64]map[string]map[string]string
@@ -202,7 +264,21 @@ func (fkv *FakeKVStore) Keys(ctx context.Context, orgID int64, namespace string,
 }
 
 func (fkv *FakeKVStore) GetAll(ctx context.Context, orgId int64, namespace string) (map[int64]map[string]string, error) {
-	return nil, nil
+	fkv.mtx.Lock()
+	defer fkv.mtx.Unlock()
+	all := map[int64]map[string]string{}
+	for orgIDFromStore, namespaceMap := range fkv.store {
+		if orgId != kvstore.AllOrganizations && orgId != orgIDFromStore {
+			continue
+		}
+		if keyMap, exists := namespaceMap[namespace]; exists {
+			all[orgIDFromStore] = map[string]string{}
+			for k, v := range keyMap {
+				all[orgIDFromStore][k] = v
+			}
+		}
+	}
+	return all, nil
 }
 
 type fakeState struct {
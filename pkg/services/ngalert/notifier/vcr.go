@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/alertmanager/notify"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+var cassetteNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// cassettes are the cassettes of the integrations of an organization in the development VCR mode,
+// which records the webhooks of the integrations to a fixture file per integration, or replays
+// the fixtures.
+type cassettes struct {
+	mode channels.VCRMode
+	dir  string
+
+	mtx    sync.Mutex
+	byPath map[string]*channels.Cassette
+}
+
+// newCassettes returns nil if the VCR mode is disabled.
+func newCassettes(orgID int64, mode, dir string) *cassettes {
+	if mode == "" {
+		return nil
+	}
+	return &cassettes{
+		mode:   channels.VCRMode(mode),
+		dir:    filepath.Join(dir, strconv.FormatInt(orgID, 10)),
+		byPath: map[string]*channels.Cassette{},
+	}
+}
+
+// forIntegration returns the cassette of the integration of the receiver. The cassettes are kept
+// when the configuration is reloaded, so that a recording or a replay goes on.
+func (c *cassettes) forIntegration(receiver string, integration notify.Integration) (*channels.Cassette, error) {
+	name := fmt.Sprintf("%s_%s_%d.json", cassetteNameReplacer.ReplaceAllString(receiver, "_"), integration.Name(), integration.Index())
+	path := filepath.Join(c.dir, name)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if cassette, ok := c.byPath[path]; ok {
+		return cassette, nil
+	}
+	cassette, err := channels.NewCassette(path, c.mode)
+	if err != nil {
+		return nil, err
+	}
+	c.byPath[path] = cassette
+	return cassette, nil
+}
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassettes(t *testing.T) {
+	require.Nil(t, newCassettes(1, "", t.TempDir()))
+
+	dir := t.TempDir()
+	n := &fakeNotificationChannel{}
+	integration := notify.NewIntegration(profilingNotifier{n}, n, "webhook", 2)
+
+	// The cassettes are kept per integration.
+	c := newCassettes(3, "record", dir)
+	cassette, err := c.forIntegration("team a/ops", integration)
+	require.NoError(t, err)
+	again, err := c.forIntegration("team a/ops", integration)
+	require.NoError(t, err)
+	require.Same(t, cassette, again)
+	require.Len(t, c.byPath, 1)
+	require.Contains(t, c.byPath, filepath.Join(dir, "3", "team_a_ops_webhook_2.json"))
+
+	// The fixture of the integration must exist to replay it.
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+	stage := profilingStage{receiver: "team-a", integration: integration, stage: retryingStage{integration}, profiles: newDispatchProfiles(), cassettes: newCassettes(3, "replay", dir)}
+	_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Dry runs are not recorded.
+	n.errs = []error{nil}
+	stage.dryRun = true
+	stage.stage = dryRunNotifyStage{integration: integration}
+	_, _, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+}
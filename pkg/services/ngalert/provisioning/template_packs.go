@@ -0,0 +1,211 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ErrTemplatePackConflict is returned when the templates of a pack conflict with the existing
+// templates, and the conflicts must not be resolved.
+var ErrTemplatePackConflict = fmt.Errorf("the template pack conflicts with existing templates")
+
+var templatePackNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type TemplatePackService struct {
+	config        AMConfigStore
+	prov          ProvisioningStore
+	xact          TransactionManager
+	trustedKeys   map[string]ed25519.PublicKey
+	allowUnsigned bool
+	log           log.Logger
+}
+
+func NewTemplatePackService(config AMConfigStore, prov ProvisioningStore, xact TransactionManager, settings setting.UnifiedAlertingSettings, log log.Logger) *TemplatePackService {
+	return &TemplatePackService{
+		config:        config,
+		prov:          prov,
+		xact:          xact,
+		trustedKeys:   settings.TemplatePackTrustedKeys,
+		allowUnsigned: settings.TemplatePackAllowUnsigned,
+		log:           log,
+	}
+}
+
+// ImportTemplatePack verifies the signature of a pack of templates and adds its templates to the
+// templates of the organization, resolving the conflicts with the existing templates as requested.
+// It only returns what the import does if dryRun is true. The result is also returned with
+// ErrTemplatePackConflict, so that the conflicts can be shown.
+func (t *TemplatePackService) ImportTemplatePack(ctx context.Context, orgID int64, imp definitions.TemplatePackImport, dryRun bool) (definitions.TemplatePackImportResult, error) {
+	conflicts := imp.Conflicts
+	if conflicts == "" {
+		conflicts = definitions.TemplatePackConflictsFail
+	}
+	switch conflicts {
+	case definitions.TemplatePackConflictsFail, definitions.TemplatePackConflictsSkip,
+		definitions.TemplatePackConflictsOverwrite, definitions.TemplatePackConflictsRename:
+	default:
+		return definitions.TemplatePackImportResult{}, fmt.Errorf("%w: unknown conflict resolution %q", ErrValidation, conflicts)
+	}
+
+	pack, err := t.verify(imp.Bundle)
+	if err != nil {
+		return definitions.TemplatePackImportResult{}, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+
+	revision, err := getLastConfiguration(ctx, orgID, t.config)
+	if err != nil {
+		return definitions.TemplatePackImportResult{}, err
+	}
+	if revision.cfg.TemplateFiles == nil {
+		revision.cfg.TemplateFiles = map[string]string{}
+	}
+
+	result := definitions.TemplatePackImportResult{
+		Name:        pack.Name,
+		Version:     pack.Version,
+		Author:      pack.Author,
+		Description: pack.Description,
+		KeyID:       imp.Bundle.KeyID,
+		Templates:   make([]definitions.TemplatePackImportTemplate, 0, len(pack.Templates)),
+	}
+	if imp.Bundle.Signature == "" {
+		result.KeyID = ""
+	}
+
+	var changed []definitions.MessageTemplate
+	conflicted := false
+	for _, pt := range pack.Templates {
+		tmpl := definitions.MessageTemplate{Name: pt.Name, Template: pt.Template, Provenance: models.ProvenanceAPI}
+		if err := tmpl.Validate(); err != nil {
+			return definitions.TemplatePackImportResult{}, fmt.Errorf("%w: template %q: %s", ErrValidation, pt.Name, err.Error())
+		}
+		item := definitions.TemplatePackImportTemplate{Name: tmpl.Name, Template: tmpl.Template}
+
+		existing, ok := revision.cfg.TemplateFiles[tmpl.Name]
+		switch {
+		case !ok:
+			item.Action = definitions.TemplatePackImportCreate
+		case existing == tmpl.Template:
+			item.Action = definitions.TemplatePackImportUnchanged
+		case conflicts == definitions.TemplatePackConflictsSkip:
+			item.Action, item.Existing = definitions.TemplatePackImportSkip, existing
+		case conflicts == definitions.TemplatePackConflictsOverwrite:
+			item.Action, item.Existing = definitions.TemplatePackImportUpdate, existing
+		case conflicts == definitions.TemplatePackConflictsRename:
+			tmpl = definitions.MessageTemplate{Name: t.rename(revision.cfg.TemplateFiles, pack.Name, pt.Name), Template: pt.Template, Provenance: models.ProvenanceAPI}
+			if err := tmpl.Validate(); err != nil {
+				return definitions.TemplatePackImportResult{}, fmt.Errorf("%w: template %q: %s", ErrValidation, pt.Name, err.Error())
+			}
+			item = definitions.TemplatePackImportTemplate{Name: tmpl.Name, PackName: pt.Name, Template: tmpl.Template, Action: definitions.TemplatePackImportCreate}
+		default:
+			item.Action, item.Existing = definitions.TemplatePackImportConflict, existing
+			conflicted = true
+		}
+		result.Templates = append(result.Templates, item)
+
+		if item.Action == definitions.TemplatePackImportCreate || item.Action == definitions.TemplatePackImportUpdate {
+			// Renamed templates are added now, so that the next templates are not renamed to the same name.
+			revision.cfg.TemplateFiles[tmpl.Name] = tmpl.Template
+			changed = append(changed, tmpl)
+		}
+	}
+
+	if conflicted {
+		return result, ErrTemplatePackConflict
+	}
+	if dryRun || len(changed) == 0 {
+		return result, nil
+	}
+
+	serialized, err := serializeAlertmanagerConfig(*revision.cfg)
+	if err != nil {
+		return definitions.TemplatePackImportResult{}, err
+	}
+	cmd := models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(serialized),
+		ConfigurationVersion:      revision.version,
+		FetchedConfigurationHash:  revision.concurrencyToken,
+		Default:                   false,
+		OrgID:                     orgID,
+	}
+	err = t.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := t.config.UpdateAlertmanagerConfiguration(ctx, &cmd); err != nil {
+			return err
+		}
+		for i := range changed {
+			if err := t.prov.SetProvenance(ctx, &changed[i], orgID, changed[i].Provenance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return definitions.TemplatePackImportResult{}, err
+	}
+
+	t.log.Info("imported template pack", "org", orgID, "pack", pack.Name, "version", pack.Version, "key", result.KeyID, "templates", len(changed))
+	return result, nil
+}
+
+// verify checks the signature of the bundle and decodes its pack.
+func (t *TemplatePackService) verify(bundle definitions.TemplatePackBundle) (definitions.TemplatePack, error) {
+	var pack definitions.TemplatePack
+	if bundle.Signature == "" {
+		if !t.allowUnsigned {
+			return pack, fmt.Errorf("the template pack is not signed")
+		}
+	} else {
+		key, ok := t.trustedKeys[bundle.KeyID]
+		if !ok {
+			return pack, fmt.Errorf("the template pack is signed by the key %q, which is not trusted", bundle.KeyID)
+		}
+		signature, err := base64.StdEncoding.DecodeString(bundle.Signature)
+		if err != nil {
+			return pack, fmt.Errorf("invalid signature: %w", err)
+		}
+		if !ed25519.Verify(key, []byte(bundle.Payload), signature) {
+			return pack, fmt.Errorf("the signature of the template pack is not valid")
+		}
+	}
+
+	if err := json.Unmarshal([]byte(bundle.Payload), &pack); err != nil {
+		return pack, fmt.Errorf("invalid template pack: %w", err)
+	}
+	if pack.Name == "" {
+		return pack, fmt.Errorf("the template pack must have a name")
+	}
+	if len(pack.Templates) == 0 {
+		return pack, fmt.Errorf("the template pack has no templates")
+	}
+	seen := make(map[string]struct{}, len(pack.Templates))
+	for _, tmpl := range pack.Templates {
+		if _, ok := seen[tmpl.Name]; ok {
+			return pack, fmt.Errorf("the template pack has several templates named %q", tmpl.Name)
+		}
+		seen[tmpl.Name] = struct{}{}
+	}
+	return pack, nil
+}
+
+// rename returns a name for a template of a pack that conflicts with an existing template, which is
+// the name of the template prefixed by the name of the pack, and numbered if it is also taken.
+func (t *TemplatePackService) rename(existing map[string]string, packName, name string) string {
+	prefix := strings.Trim(templatePackNameSanitizer.ReplaceAllString(packName, "-"), "-")
+	renamed := prefix + "." + name
+	for i := 2; ; i++ {
+		if _, ok := existing[renamed]; !ok {
+			return renamed
+		}
+		renamed = fmt.Sprintf("%s.%s-%d", prefix, name, i)
+	}
+}
@@ -0,0 +1,182 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTemplatePackService(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pack := definitions.TemplatePack{
+		Name:    "Slack layouts",
+		Version: "1.0.0",
+		Author:  "community",
+		Templates: []definitions.TemplatePackTemplate{
+			{Name: "slack.title", Template: `{{ define "slack.title" }}{{ .CommonLabels.alertname }}{{ end }}`},
+			{Name: "slack.text", Template: `{{ define "slack.text" }}{{ range .Alerts }}{{ .Annotations.summary }}{{ end }}{{ end }}`},
+			{Name: "slack.color", Template: `{{ define "slack.color" }}{{ if eq .Status "firing" }}danger{{ else }}good{{ end }}{{ end }}`},
+		},
+	}
+	signed := func(t *testing.T, pack definitions.TemplatePack) definitions.TemplatePackBundle {
+		payload, err := json.Marshal(pack)
+		require.NoError(t, err)
+		return definitions.TemplatePackBundle{
+			Payload:   string(payload),
+			KeyID:     "community",
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload)),
+		}
+	}
+
+	// The organization has a different slack.title, and the same slack.color.
+	existing := map[string]string{
+		"slack.title": `{{ define "slack.title" }}custom{{ end }}`,
+		"slack.color": pack.Templates[2].Template,
+	}
+	newService := func(t *testing.T, settings setting.UnifiedAlertingSettings) (*TemplatePackService, *fakeAMConfigStore, *fakeProvisioningStore) {
+		config := newFakeAMConfigStore()
+		cfg, err := deserializeAlertmanagerConfig([]byte(config.config.AlertmanagerConfiguration))
+		require.NoError(t, err)
+		cfg.TemplateFiles = map[string]string{}
+		for k, v := range existing {
+			cfg.TemplateFiles[k] = v
+		}
+		serialized, err := serializeAlertmanagerConfig(*cfg)
+		require.NoError(t, err)
+		config.config.AlertmanagerConfiguration = string(serialized)
+
+		if settings.TemplatePackTrustedKeys == nil {
+			settings.TemplatePackTrustedKeys = map[string]ed25519.PublicKey{"community": publicKey}
+		}
+		prov := NewFakeProvisioningStore()
+		return NewTemplatePackService(config, prov, newNopTransactionManager(), settings, log.NewNopLogger()), config, prov
+	}
+	savedTemplates := func(t *testing.T, config *fakeAMConfigStore) map[string]string {
+		cfg, err := deserializeAlertmanagerConfig([]byte(config.config.AlertmanagerConfiguration))
+		require.NoError(t, err)
+		return cfg.TemplateFiles
+	}
+	actions := func(result definitions.TemplatePackImportResult) map[string]definitions.TemplatePackImportAction {
+		res := map[string]definitions.TemplatePackImportAction{}
+		for _, tmpl := range result.Templates {
+			res[tmpl.Name] = tmpl.Action
+		}
+		return res
+	}
+
+	t.Run("previews the import without changing the templates", func(t *testing.T) {
+		sut, config, _ := newService(t, setting.UnifiedAlertingSettings{})
+
+		result, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: signed(t, pack), Conflicts: definitions.TemplatePackConflictsOverwrite}, true)
+		require.NoError(t, err)
+		require.Equal(t, "Slack layouts", result.Name)
+		require.Equal(t, "community", result.KeyID)
+		require.Equal(t, map[string]definitions.TemplatePackImportAction{
+			"slack.title": definitions.TemplatePackImportUpdate,
+			"slack.text":  definitions.TemplatePackImportCreate,
+			"slack.color": definitions.TemplatePackImportUnchanged,
+		}, actions(result))
+		require.Equal(t, existing["slack.title"], result.Templates[0].Existing)
+		require.Nil(t, config.lastSaveCommand)
+	})
+
+	t.Run("fails on conflicts by default", func(t *testing.T) {
+		sut, config, _ := newService(t, setting.UnifiedAlertingSettings{})
+
+		result, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: signed(t, pack)}, false)
+		require.ErrorIs(t, err, ErrTemplatePackConflict)
+		require.Equal(t, definitions.TemplatePackImportConflict, actions(result)["slack.title"])
+		require.Nil(t, config.lastSaveCommand)
+	})
+
+	t.Run("skips conflicting templates", func(t *testing.T) {
+		sut, config, prov := newService(t, setting.UnifiedAlertingSettings{})
+
+		result, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: signed(t, pack), Conflicts: definitions.TemplatePackConflictsSkip}, false)
+		require.NoError(t, err)
+		require.Equal(t, definitions.TemplatePackImportSkip, actions(result)["slack.title"])
+
+		templates := savedTemplates(t, config)
+		require.Equal(t, existing["slack.title"], templates["slack.title"])
+		require.Equal(t, pack.Templates[1].Template, templates["slack.text"])
+		p, err := prov.GetProvenance(context.Background(), &definitions.MessageTemplate{Name: "slack.text"}, 1)
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceAPI, p)
+	})
+
+	t.Run("overwrites conflicting templates", func(t *testing.T) {
+		sut, config, _ := newService(t, setting.UnifiedAlertingSettings{})
+
+		_, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: signed(t, pack), Conflicts: definitions.TemplatePackConflictsOverwrite}, false)
+		require.NoError(t, err)
+		require.Equal(t, pack.Templates[0].Template, savedTemplates(t, config)["slack.title"])
+	})
+
+	t.Run("renames conflicting templates", func(t *testing.T) {
+		sut, config, _ := newService(t, setting.UnifiedAlertingSettings{})
+
+		result, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: signed(t, pack), Conflicts: definitions.TemplatePackConflictsRename}, false)
+		require.NoError(t, err)
+		require.Equal(t, "Slack-layouts.slack.title", result.Templates[0].Name)
+		require.Equal(t, "slack.title", result.Templates[0].PackName)
+
+		templates := savedTemplates(t, config)
+		require.Equal(t, existing["slack.title"], templates["slack.title"])
+		require.Equal(t, pack.Templates[0].Template, templates["Slack-layouts.slack.title"])
+	})
+
+	t.Run("rejects packs", func(t *testing.T) {
+		tampered := signed(t, pack)
+		tampered.Payload = `{"name": "evil", "templates": [{"name": "a", "template": "b"}]}`
+		untrusted := signed(t, pack)
+		untrusted.KeyID = "someone"
+		unsigned := signed(t, pack)
+		unsigned.Signature = ""
+		duplicated := pack
+		duplicated.Templates = append([]definitions.TemplatePackTemplate{}, pack.Templates[0], pack.Templates[0])
+		invalid := pack
+		invalid.Templates = []definitions.TemplatePackTemplate{{Name: "broken", Template: "{{ .Unclosed "}}
+
+		cases := []struct {
+			name   string
+			bundle definitions.TemplatePackBundle
+			expErr string
+		}{
+			{name: "with an invalid signature", bundle: tampered, expErr: "invalid object specification: the signature of the template pack is not valid"},
+			{name: "signed by an untrusted key", bundle: untrusted, expErr: `invalid object specification: the template pack is signed by the key "someone", which is not trusted`},
+			{name: "without signature", bundle: unsigned, expErr: "invalid object specification: the template pack is not signed"},
+			{name: "with duplicated templates", bundle: signed(t, duplicated), expErr: `invalid object specification: the template pack has several templates named "slack.title"`},
+			{name: "with invalid templates", bundle: signed(t, invalid), expErr: `invalid object specification: template "broken": invalid template: template: :1: unclosed action`},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				sut, _, _ := newService(t, setting.UnifiedAlertingSettings{})
+				_, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: c.bundle}, true)
+				require.ErrorIs(t, err, ErrValidation)
+				require.EqualError(t, err, c.expErr)
+			})
+		}
+	})
+
+	t.Run("imports unsigned packs if allowed", func(t *testing.T) {
+		sut, _, _ := newService(t, setting.UnifiedAlertingSettings{TemplatePackAllowUnsigned: true})
+		bundle := signed(t, pack)
+		bundle.Signature = ""
+
+		result, err := sut.ImportTemplatePack(context.Background(), 1, definitions.TemplatePackImport{Bundle: bundle, Conflicts: definitions.TemplatePackConflictsSkip}, true)
+		require.NoError(t, err)
+		require.Empty(t, result.KeyID)
+	})
+}
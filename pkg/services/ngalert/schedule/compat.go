@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -131,6 +132,21 @@ func FromAlertStateToPostableAlerts(firingStates []*state.State, stateManager *s
 	return alerts
 }
 
+// withDatasourceUIDs adds the UIDs of the data sources queried by the rule to the annotations of
+// the alerts, so that notifiers can describe where the alerts come from.
+func withDatasourceUIDs(alerts apimodels.PostableAlerts, uids []string) {
+	if len(uids) == 0 {
+		return
+	}
+	value := strings.Join(uids, ",")
+	for i := range alerts.PostableAlerts {
+		if alerts.PostableAlerts[i].Annotations == nil {
+			alerts.PostableAlerts[i].Annotations = models.LabelSet{}
+		}
+		alerts.PostableAlerts[i].Annotations[ngModels.DatasourceUIDsAnnotation] = value
+	}
+}
+
 // FromAlertsStateToStoppedAlert converts firingStates that have evaluation state either eval.Alerting or eval.NoData or eval.Error to models.PostableAlert that are accepted by notifiers.
 // Returns a list of alert instances that have expiration time.Now
 func FromAlertsStateToStoppedAlert(firingStates []*state.State, appURL *url.URL, clock clock.Clock) apimodels.PostableAlerts {
@@ -13,6 +13,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
@@ -237,6 +238,16 @@ func Test_FromAlertsStateToStoppedAlert(t *testing.T) {
 	require.Equal(t, expected, result.PostableAlerts)
 }
 
+func Test_withDatasourceUIDs(t *testing.T) {
+	alerts := apimodels.PostableAlerts{PostableAlerts: []models.PostableAlert{
+		{Annotations: models.LabelSet{"summary": "CPU is high"}},
+		{},
+	}}
+	withDatasourceUIDs(alerts, []string{"loki", "prometheus"})
+	require.Equal(t, models.LabelSet{"summary": "CPU is high", ngModels.DatasourceUIDsAnnotation: "loki,prometheus"}, alerts.PostableAlerts[0].Annotations)
+	require.Equal(t, models.LabelSet{ngModels.DatasourceUIDsAnnotation: "loki,prometheus"}, alerts.PostableAlerts[1].Annotations)
+}
+
 func randomMapOfStrings() map[string]string {
 	max := 5
 	result := make(map[string]string, max)
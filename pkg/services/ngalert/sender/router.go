@@ -30,6 +30,7 @@ type AlertsRouter struct {
 	logger           log.Logger
 	clock            clock.Clock
 	adminConfigStore store.AdminConfigurationStore
+	credentialsStore store.ExternalAlertmanagerCredentialsStore
 
 	// externalAlertmanagers help us send alerts to external Alertmanagers.
 	adminConfigMtx               sync.RWMutex
@@ -48,12 +49,14 @@ type AlertsRouter struct {
 }
 
 func NewAlertsRouter(multiOrgNotifier *notifier.MultiOrgAlertmanager, store store.AdminConfigurationStore,
-	clk clock.Clock, appURL *url.URL, disabledOrgs map[int64]struct{}, configPollInterval time.Duration,
+	credentialsStore store.ExternalAlertmanagerCredentialsStore, clk clock.Clock, appURL *url.URL,
+	disabledOrgs map[int64]struct{}, configPollInterval time.Duration,
 	datasourceService datasources.DataSourceService, secretService secrets.Service) *AlertsRouter {
 	d := &AlertsRouter{
 		logger:           log.New("alerts-router"),
 		clock:            clk,
 		adminConfigStore: store,
+		credentialsStore: credentialsStore,
 
 		adminConfigMtx:               sync.RWMutex{},
 		externalAlertmanagers:        map[int64]*ExternalAlertmanager{},
@@ -105,7 +108,7 @@ func (d *AlertsRouter) SyncAndApplyConfigFromDatabase() error {
 			continue
 		}
 
-		externalAlertmanagers, err := d.alertmanagersFromDatasources(cfg.OrgID)
+		externalAlertmanagers, credentials, err := d.alertmanagersFromDatasources(cfg.OrgID)
 		if err != nil {
 			d.logger.Error("failed to get alertmanagers from datasources",
 				"org", cfg.OrgID,
@@ -113,6 +116,7 @@ func (d *AlertsRouter) SyncAndApplyConfigFromDatabase() error {
 			continue
 		}
 		cfg.Alertmanagers = append(cfg.Alertmanagers, externalAlertmanagers...)
+		cfg.Credentials = credentials
 
 		// We have no running sender and no Alertmanager(s) configured, no-op.
 		if !ok && len(cfg.Alertmanagers) == 0 {
@@ -203,8 +207,11 @@ func (d *AlertsRouter) SyncAndApplyConfigFromDatabase() error {
 	return nil
 }
 
-func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]string, error) {
+// alertmanagersFromDatasources returns the URLs of the Alertmanager data sources that handle
+// Grafana managed alerts, and the credentials of the ones that reference credentials by URL.
+func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]string, map[string]*models.AlertmanagerHTTPCredentials, error) {
 	var alertmanagers []string
+	credentials := map[string]*models.AlertmanagerHTTPCredentials{}
 	// We might have alertmanager datasources that are acting as external
 	// alertmanager, let's fetch them.
 	query := &datasources.GetDataSourcesByTypeQuery{
@@ -215,12 +222,26 @@ func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]string, erro
 	defer cancel()
 	err := d.datasourceService.GetDataSourcesByType(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch datasources for org: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch datasources for org: %w", err)
 	}
 	for _, ds := range query.Result {
 		if !ds.JsonData.Get(definitions.HandleGrafanaManagedAlerts).MustBool(false) {
 			continue
 		}
+		if uid := ds.JsonData.Get(definitions.AlertmanagerCredentialsUID).MustString(); uid != "" {
+			amURL, creds, err := d.credentialsFor(ctx, ds, uid)
+			if err != nil {
+				d.logger.Error("failed to get the credentials of the external alertmanager",
+					"org", ds.OrgId,
+					"uid", ds.Uid,
+					"credentials", uid,
+					"err", err)
+				continue
+			}
+			alertmanagers = append(alertmanagers, amURL)
+			credentials[amURL] = creds
+			continue
+		}
 		amURL, err := d.buildExternalURL(ds)
 		if err != nil {
 			d.logger.Error("failed to build external alertmanager URL",
@@ -231,7 +252,25 @@ func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]string, erro
 		}
 		alertmanagers = append(alertmanagers, amURL)
 	}
-	return alertmanagers, nil
+	return alertmanagers, credentials, nil
+}
+
+// credentialsFor returns the URL of the data source and the decrypted credentials it references.
+// The basic auth of the data source is not used.
+func (d *AlertsRouter) credentialsFor(ctx context.Context, ds *datasources.DataSource, uid string) (string, *models.AlertmanagerHTTPCredentials, error) {
+	parsed, err := datasource.ValidateURL(datasources.DS_ALERTMANAGER, ds.Url)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse alertmanager datasource url: %w", err)
+	}
+	creds, err := d.credentialsStore.GetExternalAlertmanagerCredentials(ctx, ds.OrgId, uid)
+	if err != nil {
+		return "", nil, err
+	}
+	secrets, err := d.secretService.DecryptJsonData(ctx, creds.SecureSettings)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt the credentials: %w", err)
+	}
+	return parsed.String(), creds.HTTPCredentials(secrets), nil
 }
 
 func (d *AlertsRouter) buildExternalURL(ds *datasources.DataSource) (string, error) {
@@ -2,9 +2,13 @@ package sender
 
 import (
 	"context"
+	"encoding/pem"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fake_ds "github.com/grafana/grafana/pkg/services/datasources/fakes"
@@ -47,7 +52,7 @@ func TestSendingToExternalAlertmanager(t *testing.T) {
 		Host:   "localhost",
 	}
 
-	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
+	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, nil, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
 		&fake_ds.FakeDataSourceService{}, fake_secrets.NewFakeSecretsService())
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
@@ -105,7 +110,7 @@ func TestSendingToExternalAlertmanager_WithMultipleOrgs(t *testing.T) {
 		Host:   "localhost",
 	}
 
-	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
+	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, nil, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
 		&fake_ds.FakeDataSourceService{}, fake_secrets.NewFakeSecretsService())
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
@@ -232,7 +237,7 @@ func TestChangingAlertmanagersChoice(t *testing.T) {
 		Host:   "localhost",
 	}
 
-	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{},
+	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, nil, mockedClock, appUrl, map[int64]struct{}{},
 		10*time.Minute, &fake_ds.FakeDataSourceService{}, fake_secrets.NewFakeSecretsService())
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
@@ -296,6 +301,90 @@ func TestChangingAlertmanagersChoice(t *testing.T) {
 	require.Len(t, actualAlerts, len(expected))
 }
 
+func TestSendingToExternalAlertmanager_WithCredentials(t *testing.T) {
+	ruleKey := models.GenerateRuleKey(1)
+
+	type received struct {
+		path, user, password, tenant string
+	}
+	var mtx sync.Mutex
+	var requests []received
+	fakeAM := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		mtx.Lock()
+		requests = append(requests, received{path: r.URL.Path, user: user, password: password, tenant: r.Header.Get("X-Scope-OrgID")})
+		mtx.Unlock()
+	}))
+	defer fakeAM.Close()
+	lastRequest := func() received {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if len(requests) == 0 {
+			return received{}
+		}
+		return requests[len(requests)-1]
+	}
+
+	credentialsStore := store.NewFakeExternalAlertmanagerCredentialsStore(t)
+	creds := &models.ExternalAlertmanagerCredentials{
+		OrgID:         ruleKey.OrgID,
+		UID:           "mimir",
+		Name:          "Mimir",
+		BasicAuthUser: "grafana",
+		SecureSettings: map[string][]byte{
+			models.SecureBasicAuthPassword:              []byte("secret"),
+			models.SecureHeaderPrefix + "X-Scope-OrgID": []byte("tenant-1"),
+			models.SecureTLSCACert:                      pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: fakeAM.Certificate().Raw}),
+		},
+	}
+	require.NoError(t, credentialsStore.SaveExternalAlertmanagerCredentials(context.Background(), creds))
+
+	fakeDs := &fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{{
+		OrgId: ruleKey.OrgID,
+		Uid:   "am",
+		Type:  datasources.DS_ALERTMANAGER,
+		Url:   fakeAM.URL + "/am/",
+		JsonData: simplejson.NewFromAny(map[string]interface{}{
+			definitions.HandleGrafanaManagedAlerts: true,
+			definitions.AlertmanagerCredentialsUID: "mimir",
+		}),
+	}}}
+
+	fakeAdminConfigStore := &store.AdminConfigurationStoreMock{}
+	mockedGetAdminConfigurations := fakeAdminConfigStore.EXPECT().GetAdminConfigurations()
+	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
+		{OrgID: ruleKey.OrgID, SendAlertsTo: models.ExternalAlertmanagers},
+	}, nil)
+
+	mockedClock := clock.NewMock()
+	moa := createMultiOrgAlertmanager(t, []int64{1})
+	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, credentialsStore, mockedClock, &url.URL{Scheme: "http", Host: "localhost"},
+		map[int64]struct{}{}, 10*time.Minute, fakeDs, fake_secrets.NewFakeSecretsService())
+
+	require.NoError(t, alertsRouter.SyncAndApplyConfigFromDatabase())
+	assertAlertmanagersStatusForOrg(t, alertsRouter, ruleKey.OrgID, 1, 0)
+	hash := alertsRouter.externalAlertmanagersCfgHash[ruleKey.OrgID]
+
+	// The alerts are sent with the credentials, and the TLS certificate of the Alertmanager is
+	// verified with the CA certificate of the credentials.
+	alertsRouter.Send(ruleKey, definitions.PostableAlerts{PostableAlerts: []models2.PostableAlert{generatePostableAlert(t, mockedClock)}})
+	require.Eventually(t, func() bool { return lastRequest().path != "" }, 10*time.Second, 100*time.Millisecond)
+	require.Equal(t, received{path: "/am/api/v2/alerts", user: "grafana", password: "secret", tenant: "tenant-1"}, lastRequest())
+
+	// The rotated secrets are applied at the next synchronization.
+	creds.SecureSettings[models.SecureBasicAuthPassword] = []byte("rotated")
+	require.NoError(t, credentialsStore.SaveExternalAlertmanagerCredentials(context.Background(), creds))
+	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
+		{OrgID: ruleKey.OrgID, SendAlertsTo: models.ExternalAlertmanagers},
+	}, nil)
+	require.NoError(t, alertsRouter.SyncAndApplyConfigFromDatabase())
+	require.NotEqual(t, hash, alertsRouter.externalAlertmanagersCfgHash[ruleKey.OrgID])
+	assertAlertmanagersStatusForOrg(t, alertsRouter, ruleKey.OrgID, 1, 0)
+
+	alertsRouter.Send(ruleKey, definitions.PostableAlerts{PostableAlerts: []models2.PostableAlert{generatePostableAlert(t, mockedClock)}})
+	require.Eventually(t, func() bool { return lastRequest().password == "rotated" }, 10*time.Second, 100*time.Millisecond)
+}
+
 func assertAlertmanagersStatusForOrg(t *testing.T, alertsRouter *AlertsRouter, orgID int64, active, dropped int) {
 	t.Helper()
 	require.Eventuallyf(t, func() bool {
@@ -351,7 +440,7 @@ func createMultiOrgAlertmanager(t *testing.T, orgs []int64) *notifier.MultiOrgAl
 	m := metrics.NewNGAlert(registry)
 	secretsService := secretsManager.SetupTestService(t, fake_secrets.NewFakeSecretsStore())
 	decryptFn := secretsService.GetDecryptedValue
-	moa, err := notifier.NewMultiOrgAlertmanager(cfg, &cfgStore, &orgStore, kvStore, provisioning.NewFakeProvisioningStore(), decryptFn, m.GetMultiOrgAlertmanagerMetrics(), nil, log.New("testlogger"), secretsService)
+	moa, err := notifier.NewMultiOrgAlertmanager(cfg, &cfgStore, &orgStore, kvStore, provisioning.NewFakeProvisioningStore(), decryptFn, m.GetMultiOrgAlertmanagerMetrics(), nil, nil, nil, log.New("testlogger"), secretsService)
 	require.NoError(t, err)
 	require.NoError(t, moa.LoadAndSyncAlertmanagersForOrgs(context.Background()))
 	require.Eventually(t, func() bool {
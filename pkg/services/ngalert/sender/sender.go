@@ -2,11 +2,16 @@ package sender
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"sync"
 	"time"
 
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -24,6 +29,10 @@ import (
 const (
 	defaultMaxQueueCapacity = 10000
 	defaultTimeout          = 10 * time.Second
+
+	// alertsPath is the path of the API the alerts are sent to, after the prefix of the
+	// Alertmanager.
+	alertsPath = "/api/v2/alerts"
 )
 
 // ExternalAlertmanager is responsible for dispatching alert notifications to an external Alertmanager service.
@@ -35,6 +44,13 @@ type ExternalAlertmanager struct {
 
 	sdCancel  context.CancelFunc
 	sdManager *discovery.Manager
+
+	// clients are the HTTP clients of the Alertmanager(s) whose credentials are not in their URL,
+	// by their URL.
+	clientsMtx sync.RWMutex
+	clients    map[string]*http.Client
+	// appliedAlertmanagers are the Alertmanager(s) of the last configuration applied.
+	appliedAlertmanagers string
 }
 
 func NewExternalAlertmanagerSender() (*ExternalAlertmanager, error) {
@@ -48,7 +64,7 @@ func NewExternalAlertmanagerSender() (*ExternalAlertmanager, error) {
 	s.manager = notifier.NewManager(
 		// Injecting a new registry here means these metrics are not exported.
 		// Once we fix the individual Alertmanager metrics we should fix this scenario too.
-		&notifier.Options{QueueCapacity: defaultMaxQueueCapacity, Registerer: prometheus.NewRegistry()},
+		&notifier.Options{QueueCapacity: defaultMaxQueueCapacity, Registerer: prometheus.NewRegistry(), Do: s.do},
 		s.logger,
 	)
 
@@ -64,6 +80,22 @@ func (s *ExternalAlertmanager) ApplyConfig(cfg *ngmodels.AdminConfiguration) err
 		return err
 	}
 
+	clients, err := buildClients(cfg)
+	if err != nil {
+		return err
+	}
+	s.clientsMtx.Lock()
+	s.clients = clients
+	s.clientsMtx.Unlock()
+
+	// When only the credentials that are not in the URLs change, the clients apply them. The
+	// same Alertmanager(s) are not applied again: they would be dropped, and the service
+	// discovery does not find them again as its configuration does not change.
+	alertmanagers := fmt.Sprintf("%v", cfg.Alertmanagers)
+	if alertmanagers == s.appliedAlertmanagers {
+		return nil
+	}
+
 	if err := s.manager.ApplyConfig(notifierCfg); err != nil {
 		return err
 	}
@@ -73,7 +105,11 @@ func (s *ExternalAlertmanager) ApplyConfig(cfg *ngmodels.AdminConfiguration) err
 		sdCfgs[k] = v.ServiceDiscoveryConfigs
 	}
 
-	return s.sdManager.ApplyConfig(sdCfgs)
+	if err := s.sdManager.ApplyConfig(sdCfgs); err != nil {
+		return err
+	}
+	s.appliedAlertmanagers = alertmanagers
+	return nil
 }
 
 func (s *ExternalAlertmanager) Run() {
@@ -171,6 +207,65 @@ func buildNotifierConfig(cfg *ngmodels.AdminConfiguration) (*config.Config, erro
 	return notifierConfig, nil
 }
 
+// buildClients returns the HTTP clients of the Alertmanager(s) whose credentials are not in their
+// URL, by their URL.
+func buildClients(cfg *ngmodels.AdminConfiguration) (map[string]*http.Client, error) {
+	clients := make(map[string]*http.Client, len(cfg.Credentials))
+	for amURL, creds := range cfg.Credentials {
+		u, err := url.Parse(amURL)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := sdkhttpclient.Options{Headers: creds.Headers}
+		if creds.BasicAuthUser != "" {
+			opts.BasicAuth = &sdkhttpclient.BasicAuthOptions{User: creds.BasicAuthUser, Password: creds.BasicAuthPassword}
+		}
+		if creds.TLSCACert != "" || creds.TLSClientCert != "" || creds.TLSServerName != "" || creds.TLSSkipVerify {
+			opts.TLS = &sdkhttpclient.TLSOptions{
+				CACertificate:      creds.TLSCACert,
+				ClientCertificate:  creds.TLSClientCert,
+				ClientKey:          creds.TLSClientKey,
+				ServerName:         creds.TLSServerName,
+				InsecureSkipVerify: creds.TLSSkipVerify,
+			}
+		}
+		client, err := sdkhttpclient.New(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the HTTP client of the credentials %s: %w", creds.UID, err)
+		}
+		clients[clientKey(u)] = client
+	}
+	return clients, nil
+}
+
+// do sends the requests to the Alertmanager(s) with their credentials, when they are not in
+// their URL.
+func (s *ExternalAlertmanager) do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	if c := s.clientFor(req.URL); c != nil {
+		client = c
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req.WithContext(ctx))
+}
+
+// clientFor returns the client of the Alertmanager the request is sent to, if it has one.
+func (s *ExternalAlertmanager) clientFor(u *url.URL) *http.Client {
+	s.clientsMtx.RLock()
+	defer s.clientsMtx.RUnlock()
+	amURL := *u
+	amURL.Path = strings.TrimSuffix(u.Path, alertsPath)
+	return s.clients[clientKey(&amURL)]
+}
+
+// clientKey returns the URL of an Alertmanager without its credentials, query and fragment, in
+// the same form as in the URLs of the requests sent to its API.
+func clientKey(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + path.Join("/", u.Path)
+}
+
 func alertToNotifierAlert(alert models.PostableAlert) *notifier.Alert {
 	ls := make(labels.Labels, 0, len(alert.Alert.Labels))
 	a := make(labels.Labels, 0, len(alert.Annotations))
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// ExternalAlertmanagerCredentialsStore stores the credentials of the external Alertmanagers of
+// the organizations. The secure settings are stored as they are given, encrypted.
+type ExternalAlertmanagerCredentialsStore interface {
+	GetExternalAlertmanagerCredentials(ctx context.Context, orgID int64, uid string) (*ngmodels.ExternalAlertmanagerCredentials, error)
+	ListExternalAlertmanagerCredentials(ctx context.Context, orgID int64) ([]*ngmodels.ExternalAlertmanagerCredentials, error)
+	// SaveExternalAlertmanagerCredentials creates the credentials if their ID is zero, and
+	// updates them otherwise. Credentials without UID get one.
+	SaveExternalAlertmanagerCredentials(ctx context.Context, creds *ngmodels.ExternalAlertmanagerCredentials) error
+	DeleteExternalAlertmanagerCredentials(ctx context.Context, orgID int64, uid string) error
+}
+
+func (st DBstore) GetExternalAlertmanagerCredentials(ctx context.Context, orgID int64, uid string) (*ngmodels.ExternalAlertmanagerCredentials, error) {
+	creds := &ngmodels.ExternalAlertmanagerCredentials{}
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		ok, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(creds)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ngmodels.ErrExternalAlertmanagerCredentialsNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (st DBstore) ListExternalAlertmanagerCredentials(ctx context.Context, orgID int64) ([]*ngmodels.ExternalAlertmanagerCredentials, error) {
+	var creds []*ngmodels.ExternalAlertmanagerCredentials
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&creds)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (st DBstore) SaveExternalAlertmanagerCredentials(ctx context.Context, creds *ngmodels.ExternalAlertmanagerCredentials) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		if creds.ID == 0 {
+			if creds.UID == "" {
+				creds.UID = util.GenerateShortUID()
+			}
+			_, err = sess.Insert(creds)
+		} else {
+			var affected int64
+			affected, err = sess.ID(creds.ID).Where("org_id = ?", creds.OrgID).AllCols().Update(creds)
+			if err == nil && affected == 0 {
+				return ngmodels.ErrExternalAlertmanagerCredentialsNotFound
+			}
+		}
+		if err != nil && st.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+			return ngmodels.ErrExternalAlertmanagerCredentialsConflict
+		}
+		return err
+	})
+}
+
+func (st DBstore) DeleteExternalAlertmanagerCredentials(ctx context.Context, orgID int64, uid string) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Delete(&ngmodels.ExternalAlertmanagerCredentials{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ngmodels.ErrExternalAlertmanagerCredentialsNotFound
+		}
+		return nil
+	})
+}
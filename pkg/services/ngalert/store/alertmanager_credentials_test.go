@@ -0,0 +1,76 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationExternalAlertmanagerCredentials(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	creds := &models.ExternalAlertmanagerCredentials{
+		OrgID:          1,
+		Name:           "mimir",
+		BasicAuthUser:  "grafana",
+		SecureSettings: map[string][]byte{models.SecureBasicAuthPassword: []byte("encrypted")},
+	}
+	require.NoError(t, dbstore.SaveExternalAlertmanagerCredentials(ctx, creds))
+	require.NotZero(t, creds.ID)
+	require.NotEmpty(t, creds.UID)
+
+	other := &models.ExternalAlertmanagerCredentials{OrgID: 2, Name: "mimir"}
+	require.NoError(t, dbstore.SaveExternalAlertmanagerCredentials(ctx, other))
+
+	t.Run("credentials are scoped to their organization", func(t *testing.T) {
+		saved, err := dbstore.GetExternalAlertmanagerCredentials(ctx, 1, creds.UID)
+		require.NoError(t, err)
+		require.Equal(t, "grafana", saved.BasicAuthUser)
+		require.Equal(t, []byte("encrypted"), saved.SecureSettings[models.SecureBasicAuthPassword])
+
+		_, err = dbstore.GetExternalAlertmanagerCredentials(ctx, 2, creds.UID)
+		require.ErrorIs(t, err, models.ErrExternalAlertmanagerCredentialsNotFound)
+
+		list, err := dbstore.ListExternalAlertmanagerCredentials(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, creds.UID, list[0].UID)
+	})
+
+	t.Run("names are unique in an organization", func(t *testing.T) {
+		err := dbstore.SaveExternalAlertmanagerCredentials(ctx, &models.ExternalAlertmanagerCredentials{OrgID: 1, Name: "mimir"})
+		require.ErrorIs(t, err, models.ErrExternalAlertmanagerCredentialsConflict)
+	})
+
+	t.Run("update the credentials", func(t *testing.T) {
+		creds.SecureSettings = map[string][]byte{models.SecureHeaderPrefix + "X-Scope-OrgID": []byte("encrypted")}
+		creds.Version++
+		require.NoError(t, dbstore.SaveExternalAlertmanagerCredentials(ctx, creds))
+
+		saved, err := dbstore.GetExternalAlertmanagerCredentials(ctx, 1, creds.UID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), saved.Version)
+		require.Equal(t, map[string]bool{models.SecureHeaderPrefix + "X-Scope-OrgID": true}, saved.SecureFields())
+
+		// Credentials cannot be moved to another organization.
+		moved := *saved
+		moved.OrgID = 2
+		require.ErrorIs(t, dbstore.SaveExternalAlertmanagerCredentials(ctx, &moved), models.ErrExternalAlertmanagerCredentialsNotFound)
+	})
+
+	t.Run("delete the credentials", func(t *testing.T) {
+		require.ErrorIs(t, dbstore.DeleteExternalAlertmanagerCredentials(ctx, 2, creds.UID), models.ErrExternalAlertmanagerCredentialsNotFound)
+		require.NoError(t, dbstore.DeleteExternalAlertmanagerCredentials(ctx, 1, creds.UID))
+		_, err := dbstore.GetExternalAlertmanagerCredentials(ctx, 1, creds.UID)
+		require.ErrorIs(t, err, models.ErrExternalAlertmanagerCredentialsNotFound)
+	})
+}
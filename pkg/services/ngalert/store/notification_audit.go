@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// NotificationAuditStore stores the audit log of the notifications of all the organizations.
+type NotificationAuditStore interface {
+	SaveNotificationAuditEntries(ctx context.Context, entries []ngmodels.NotificationAuditEntry) error
+	GetNotificationAuditEntries(ctx context.Context, query *ngmodels.GetNotificationAuditQuery) ([]*ngmodels.NotificationAuditEntry, error)
+	// DeleteNotificationAuditEntriesBefore deletes the entries of the notifications sent before t,
+	// and returns their number.
+	DeleteNotificationAuditEntriesBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+func (st DBstore) SaveNotificationAuditEntries(ctx context.Context, entries []ngmodels.NotificationAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Table(&ngmodels.NotificationAuditEntry{}).Insert(&entries)
+		return err
+	})
+}
+
+func (st DBstore) GetNotificationAuditEntries(ctx context.Context, query *ngmodels.GetNotificationAuditQuery) ([]*ngmodels.NotificationAuditEntry, error) {
+	var entries []*ngmodels.NotificationAuditEntry
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := sess.Where("org_id = ? AND sent_at >= ? AND sent_at < ?", query.OrgID, query.From.UnixMilli(), query.To.UnixMilli())
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.Fingerprint != "" {
+			// The fingerprints have the same length, so that one cannot match the end of a fingerprint
+			// and the start of the next one, which are separated by a comma.
+			q = q.And("fingerprints LIKE ?", "%"+query.Fingerprint+"%")
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Desc("sent_at", "id").Find(&entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (st DBstore) DeleteNotificationAuditEntriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		deleted, err = sess.Where("sent_at < ?", t.UnixMilli()).Delete(&ngmodels.NotificationAuditEntry{})
+		return err
+	})
+	return deleted, err
+}
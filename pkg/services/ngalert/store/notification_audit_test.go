@@ -0,0 +1,68 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationNotificationAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	start := time.Unix(1_600_000_000, 0)
+	at := func(d time.Duration) int64 { return start.Add(d).UnixMilli() }
+	require.NoError(t, dbstore.SaveNotificationAuditEntries(ctx, []models.NotificationAuditEntry{
+		{OrgID: 1, Receiver: "ops", Integration: "slack", SentAt: at(time.Minute), Title: "[FIRING:2] HighLatency", Fingerprints: "0000000000000001,0000000000000002", Alerts: 2, Result: models.NotificationAuditDelivered},
+		{OrgID: 1, Receiver: "ops", Integration: "email", SentAt: at(2 * time.Minute), Title: "[FIRING:1] HighLatency", Fingerprints: "0000000000000002", Alerts: 1, Result: models.NotificationAuditFailed, Error: "connection refused"},
+		{OrgID: 1, Receiver: "dba", Integration: "slack", SentAt: at(3 * time.Minute), Title: "[FIRING:1] DiskFull", Fingerprints: "0000000000000003", Alerts: 1, Result: models.NotificationAuditDelivered},
+		{OrgID: 2, Receiver: "ops", Integration: "slack", SentAt: at(time.Minute), Title: "[FIRING:1] HighLatency", Fingerprints: "0000000000000001", Alerts: 1, Result: models.NotificationAuditDelivered},
+	}))
+
+	t.Run("entries of the organization in the time range, the most recent first", func(t *testing.T) {
+		entries, err := dbstore.GetNotificationAuditEntries(ctx, &models.GetNotificationAuditQuery{OrgID: 1, From: start, To: start.Add(3 * time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, "email", entries[0].Integration)
+		require.Equal(t, models.NotificationAuditFailed, entries[0].Result)
+		require.Equal(t, "connection refused", entries[0].Error)
+		require.Equal(t, "slack", entries[1].Integration)
+		require.Equal(t, "[FIRING:2] HighLatency", entries[1].Title)
+		require.Equal(t, "0000000000000001,0000000000000002", entries[1].Fingerprints)
+		require.Equal(t, 2, entries[1].Alerts)
+	})
+
+	t.Run("filter by receiver and fingerprint", func(t *testing.T) {
+		entries, err := dbstore.GetNotificationAuditEntries(ctx, &models.GetNotificationAuditQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Receiver: "ops"})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		entries, err = dbstore.GetNotificationAuditEntries(ctx, &models.GetNotificationAuditQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Fingerprint: "0000000000000002"})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		entries, err = dbstore.GetNotificationAuditEntries(ctx, &models.GetNotificationAuditQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Fingerprint: "0000000000000001", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "slack", entries[0].Integration)
+	})
+
+	t.Run("delete the entries of all the organizations before a time", func(t *testing.T) {
+		deleted, err := dbstore.DeleteNotificationAuditEntriesBefore(ctx, start.Add(2*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		entries, err := dbstore.GetNotificationAuditEntries(ctx, &models.GetNotificationAuditQuery{OrgID: 1, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+	})
+}
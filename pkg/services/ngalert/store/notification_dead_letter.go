@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// NotificationDeadLetterStore stores the notifications of all the organizations that failed to be
+// delivered.
+type NotificationDeadLetterStore interface {
+	// SaveNotificationDeadLetter inserts the dead letter if its ID is zero, and updates it
+	// otherwise. A new dead letter replaces the one of the same group of alerts and integration
+	// instead of being inserted, so that a group failing repeatedly has a single dead letter with
+	// its latest alerts.
+	SaveNotificationDeadLetter(ctx context.Context, letter *ngmodels.NotificationDeadLetter) error
+	GetNotificationDeadLetters(ctx context.Context, query *ngmodels.GetNotificationDeadLettersQuery) ([]*ngmodels.NotificationDeadLetter, error)
+	// GetNotificationDeadLetter returns ngmodels.ErrNotificationDeadLetterNotFound if the dead
+	// letter does not exist in the organization.
+	GetNotificationDeadLetter(ctx context.Context, orgID int64, id int64) (*ngmodels.NotificationDeadLetter, error)
+	DeleteNotificationDeadLetter(ctx context.Context, orgID int64, id int64) error
+	DeleteGroupNotificationDeadLetter(ctx context.Context, cmd *ngmodels.DeleteGroupNotificationDeadLetterCommand) error
+	// DeleteNotificationDeadLettersBefore deletes the dead letters that last failed before t, and
+	// returns their number.
+	DeleteNotificationDeadLettersBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+func (st DBstore) SaveNotificationDeadLetter(ctx context.Context, letter *ngmodels.NotificationDeadLetter) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if letter.ID == 0 {
+			existing := ngmodels.NotificationDeadLetter{}
+			has, err := sess.Where("org_id = ? AND receiver = ? AND integration = ? AND integration_index = ? AND group_key = ?",
+				letter.OrgID, letter.Receiver, letter.Integration, letter.IntegrationIndex, letter.GroupKey).Get(&existing)
+			if err != nil {
+				return err
+			}
+			if !has {
+				_, err = sess.Insert(letter)
+				return err
+			}
+			letter.ID = existing.ID
+			letter.Replays = existing.Replays
+		}
+		_, err := sess.ID(letter.ID).Where("org_id = ?", letter.OrgID).AllCols().Update(letter)
+		return err
+	})
+}
+
+func (st DBstore) GetNotificationDeadLetters(ctx context.Context, query *ngmodels.GetNotificationDeadLettersQuery) ([]*ngmodels.NotificationDeadLetter, error) {
+	var letters []*ngmodels.NotificationDeadLetter
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := sess.Where("org_id = ?", query.OrgID)
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Desc("failed_at", "id").Find(&letters)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func (st DBstore) GetNotificationDeadLetter(ctx context.Context, orgID int64, id int64) (*ngmodels.NotificationDeadLetter, error) {
+	letter := &ngmodels.NotificationDeadLetter{}
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND id = ?", orgID, id).Get(letter)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ngmodels.ErrNotificationDeadLetterNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return letter, nil
+}
+
+func (st DBstore) DeleteNotificationDeadLetter(ctx context.Context, orgID int64, id int64) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("org_id = ? AND id = ?", orgID, id).Delete(&ngmodels.NotificationDeadLetter{})
+		return err
+	})
+}
+
+func (st DBstore) DeleteGroupNotificationDeadLetter(ctx context.Context, cmd *ngmodels.DeleteGroupNotificationDeadLetterCommand) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("org_id = ? AND receiver = ? AND integration = ? AND integration_index = ? AND group_key = ?",
+			cmd.OrgID, cmd.Receiver, cmd.Integration, cmd.IntegrationIndex, cmd.GroupKey).Delete(&ngmodels.NotificationDeadLetter{})
+		return err
+	})
+}
+
+func (st DBstore) DeleteNotificationDeadLettersBefore(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		deleted, err = sess.Where("failed_at < ?", t.UnixMilli()).Delete(&ngmodels.NotificationDeadLetter{})
+		return err
+	})
+	return deleted, err
+}
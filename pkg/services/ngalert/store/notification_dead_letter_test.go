@@ -0,0 +1,109 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationNotificationDeadLetters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	start := time.Unix(1_600_000_000, 0)
+	at := func(d time.Duration) int64 { return start.Add(d).UnixMilli() }
+	letters := []*models.NotificationDeadLetter{
+		{OrgID: 1, Receiver: "ops", Integration: "slack", GroupKey: "{}:{}", GroupLabels: `{}`, Alerts: `[]`, FailedAt: at(time.Minute), Error: "connection refused"},
+		{OrgID: 1, Receiver: "dba", Integration: "email", IntegrationIndex: 1, Alerts: `[]`, FailedAt: at(2 * time.Minute), Error: "timeout"},
+		{OrgID: 2, Receiver: "ops", Integration: "slack", Alerts: `[]`, FailedAt: at(3 * time.Minute)},
+	}
+	for _, letter := range letters {
+		require.NoError(t, dbstore.SaveNotificationDeadLetter(ctx, letter))
+		require.NotZero(t, letter.ID)
+	}
+
+	t.Run("dead letters of the organization, the most recent first", func(t *testing.T) {
+		res, err := dbstore.GetNotificationDeadLetters(ctx, &models.GetNotificationDeadLettersQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, "dba", res[0].Receiver)
+		require.Equal(t, 1, res[0].IntegrationIndex)
+		require.Equal(t, "ops", res[1].Receiver)
+		require.Equal(t, "{}:{}", res[1].GroupKey)
+
+		res, err = dbstore.GetNotificationDeadLetters(ctx, &models.GetNotificationDeadLettersQuery{OrgID: 1, Receiver: "ops", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, "slack", res[0].Integration)
+	})
+
+	t.Run("get and update a dead letter of the organization", func(t *testing.T) {
+		letter, err := dbstore.GetNotificationDeadLetter(ctx, 1, letters[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "connection refused", letter.Error)
+
+		_, err = dbstore.GetNotificationDeadLetter(ctx, 2, letters[0].ID)
+		require.ErrorIs(t, err, models.ErrNotificationDeadLetterNotFound)
+
+		letter.Replays++
+		letter.Error = "service unavailable"
+		require.NoError(t, dbstore.SaveNotificationDeadLetter(ctx, letter))
+		letter, err = dbstore.GetNotificationDeadLetter(ctx, 1, letters[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, letter.Replays)
+		require.Equal(t, "service unavailable", letter.Error)
+	})
+
+	t.Run("a new dead letter of the same group and integration replaces the previous one", func(t *testing.T) {
+		letter := &models.NotificationDeadLetter{OrgID: 1, Receiver: "ops", Integration: "slack", GroupKey: "{}:{}", GroupLabels: `{}`, Alerts: `[{"labels": {"alertname": "a"}}]`, FailedAt: at(4 * time.Minute), Error: "timeout"}
+		require.NoError(t, dbstore.SaveNotificationDeadLetter(ctx, letter))
+		require.Equal(t, letters[0].ID, letter.ID)
+		require.Equal(t, 1, letter.Replays)
+
+		res, err := dbstore.GetNotificationDeadLetters(ctx, &models.GetNotificationDeadLettersQuery{OrgID: 1, Receiver: "ops"})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, `[{"labels": {"alertname": "a"}}]`, res[0].Alerts)
+		require.Equal(t, "timeout", res[0].Error)
+	})
+
+	t.Run("delete a dead letter of the organization", func(t *testing.T) {
+		require.NoError(t, dbstore.DeleteNotificationDeadLetter(ctx, 2, letters[1].ID))
+		_, err := dbstore.GetNotificationDeadLetter(ctx, 1, letters[1].ID)
+		require.NoError(t, err)
+
+		require.NoError(t, dbstore.DeleteNotificationDeadLetter(ctx, 1, letters[1].ID))
+		_, err = dbstore.GetNotificationDeadLetter(ctx, 1, letters[1].ID)
+		require.ErrorIs(t, err, models.ErrNotificationDeadLetterNotFound)
+	})
+
+	t.Run("delete the dead letter of a group and integration", func(t *testing.T) {
+		letter := &models.NotificationDeadLetter{OrgID: 1, Receiver: "dba", Integration: "email", GroupKey: "{}:{}", Alerts: `[]`, FailedAt: at(4 * time.Minute)}
+		require.NoError(t, dbstore.SaveNotificationDeadLetter(ctx, letter))
+
+		cmd := &models.DeleteGroupNotificationDeadLetterCommand{OrgID: 1, Receiver: "dba", Integration: "email", IntegrationIndex: 1, GroupKey: "{}:{}"}
+		require.NoError(t, dbstore.DeleteGroupNotificationDeadLetter(ctx, cmd))
+		_, err := dbstore.GetNotificationDeadLetter(ctx, 1, letter.ID)
+		require.NoError(t, err)
+
+		cmd.IntegrationIndex = 0
+		require.NoError(t, dbstore.DeleteGroupNotificationDeadLetter(ctx, cmd))
+		_, err = dbstore.GetNotificationDeadLetter(ctx, 1, letter.ID)
+		require.ErrorIs(t, err, models.ErrNotificationDeadLetterNotFound)
+	})
+
+	t.Run("delete the dead letters of all the organizations before a time", func(t *testing.T) {
+		deleted, err := dbstore.DeleteNotificationDeadLettersBefore(ctx, start.Add(5*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+	})
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// NotificationHistoryStore stores the deliveries of the notifications of all the organizations.
+type NotificationHistoryStore interface {
+	SaveNotificationDeliveries(ctx context.Context, deliveries []ngmodels.NotificationDelivery) error
+	GetNotificationDeliveries(ctx context.Context, query *ngmodels.GetNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error)
+	// TrackNotificationDeliveryEmail records an open or a click of the email sent by a delivery.
+	// Deliveries that are not in the history are ignored.
+	TrackNotificationDeliveryEmail(ctx context.Context, cmd *ngmodels.TrackNotificationDeliveryEmailCommand) error
+	// DeleteNotificationDeliveriesBefore deletes the deliveries that started before t, and returns
+	// their number.
+	DeleteNotificationDeliveriesBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+func (st DBstore) SaveNotificationDeliveries(ctx context.Context, deliveries []ngmodels.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Table(&ngmodels.NotificationDelivery{}).Insert(&deliveries)
+		return err
+	})
+}
+
+func (st DBstore) GetNotificationDeliveries(ctx context.Context, query *ngmodels.GetNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error) {
+	var deliveries []*ngmodels.NotificationDelivery
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := sess.Where("org_id = ? AND started_at >= ? AND started_at < ?", query.OrgID, query.From.UnixMilli(), query.To.UnixMilli())
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.Integration != "" {
+			q = q.And("integration = ?", query.Integration)
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		if query.Latest {
+			q = q.Desc("started_at", "id")
+		} else {
+			q = q.Asc("started_at", "id")
+		}
+		return q.Find(&deliveries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (st DBstore) TrackNotificationDeliveryEmail(ctx context.Context, cmd *ngmodels.TrackNotificationDeliveryEmailCommand) error {
+	opens, clicks := 1, 0
+	if cmd.Click {
+		opens, clicks = 0, 1
+	}
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// A click also means the email was opened, even if its images were not loaded.
+		_, err := sess.Exec("UPDATE ngalert_notification_history SET email_opens = email_opens + ?, email_clicks = email_clicks + ?, "+
+			"email_opened_at = CASE WHEN email_opened_at = 0 THEN ? ELSE email_opened_at END WHERE org_id = ? AND dispatch_id = ?",
+			opens, clicks, cmd.At, cmd.OrgID, cmd.DispatchID)
+		return err
+	})
+}
+
+func (st DBstore) DeleteNotificationDeliveriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		deleted, err = sess.Where("started_at < ?", t.UnixMilli()).Delete(&ngmodels.NotificationDelivery{})
+		return err
+	})
+	return deleted, err
+}
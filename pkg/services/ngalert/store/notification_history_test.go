@@ -0,0 +1,96 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationNotificationHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	start := time.Unix(1_600_000_000, 0)
+	at := func(d time.Duration) int64 { return start.Add(d).UnixMilli() }
+	require.NoError(t, dbstore.SaveNotificationDeliveries(ctx, []models.NotificationDelivery{
+		{OrgID: 1, Receiver: "ops", Integration: "slack", StartedAt: at(2 * time.Minute), DurationMs: 120, Alerts: 2, Attempts: 1, StatusCode: 200, Failover: true},
+		{OrgID: 1, Receiver: "ops", Integration: "email", StartedAt: at(time.Minute), DurationMs: 30, Alerts: 2, Attempts: 3, Error: "connection refused", DispatchID: "abc"},
+		{OrgID: 1, Receiver: "dba", Integration: "slack", StartedAt: at(3 * time.Minute), DurationMs: 80, Alerts: 1, Attempts: 1},
+		{OrgID: 2, Receiver: "ops", Integration: "email", StartedAt: at(time.Minute), DurationMs: 10, Alerts: 1, Attempts: 1, DispatchID: "abc"},
+	}))
+
+	t.Run("deliveries of the organization in the time range, the oldest first", func(t *testing.T) {
+		deliveries, err := dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(3 * time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, "email", deliveries[0].Integration)
+		require.Equal(t, "connection refused", deliveries[0].Error)
+		require.Equal(t, 3, deliveries[0].Attempts)
+		require.Equal(t, "slack", deliveries[1].Integration)
+		require.Equal(t, float64(120), deliveries[1].DurationMs)
+		require.Equal(t, 200, deliveries[1].StatusCode)
+		require.True(t, deliveries[1].Failover)
+	})
+
+	t.Run("the most recent deliveries first", func(t *testing.T) {
+		deliveries, err := dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Limit: 2, Latest: true})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, "dba", deliveries[0].Receiver)
+		require.Equal(t, "ops", deliveries[1].Receiver)
+		require.Equal(t, "slack", deliveries[1].Integration)
+	})
+
+	t.Run("filter by receiver and integration", func(t *testing.T) {
+		deliveries, err := dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Integration: "slack"})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+
+		deliveries, err = dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Receiver: "dba", Integration: "slack"})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		deliveries, err = dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, "email", deliveries[0].Integration)
+	})
+
+	t.Run("track the opens and clicks of the email of a delivery", func(t *testing.T) {
+		require.NoError(t, dbstore.TrackNotificationDeliveryEmail(ctx, &models.TrackNotificationDeliveryEmailCommand{OrgID: 1, DispatchID: "abc", At: at(5 * time.Minute)}))
+		require.NoError(t, dbstore.TrackNotificationDeliveryEmail(ctx, &models.TrackNotificationDeliveryEmailCommand{OrgID: 1, DispatchID: "abc", At: at(6 * time.Minute), Click: true}))
+		require.NoError(t, dbstore.TrackNotificationDeliveryEmail(ctx, &models.TrackNotificationDeliveryEmailCommand{OrgID: 1, DispatchID: "unknown", At: at(6 * time.Minute)}))
+
+		deliveries, err := dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour), Integration: "email"})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, "abc", deliveries[0].DispatchID)
+		require.Equal(t, 1, deliveries[0].EmailOpens)
+		require.Equal(t, 1, deliveries[0].EmailClicks)
+		require.Equal(t, at(5*time.Minute), deliveries[0].EmailOpenedAt)
+
+		deliveries, err = dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 2, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Zero(t, deliveries[0].EmailOpens)
+	})
+
+	t.Run("delete the deliveries of all the organizations before a time", func(t *testing.T) {
+		deleted, err := dbstore.DeleteNotificationDeliveriesBefore(ctx, start.Add(2*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		deliveries, err := dbstore.GetNotificationDeliveries(ctx, &models.GetNotificationDeliveriesQuery{OrgID: 1, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+	})
+}
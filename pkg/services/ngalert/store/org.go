@@ -2,12 +2,14 @@ package store
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
 type OrgStore interface {
 	GetOrgs(ctx context.Context) ([]int64, error)
+	GetOrgName(ctx context.Context, orgID int64) (string, error)
 }
 
 func (st DBstore) GetOrgs(ctx context.Context) ([]int64, error) {
@@ -24,3 +26,21 @@ func (st DBstore) GetOrgs(ctx context.Context) ([]int64, error) {
 	}
 	return orgs, nil
 }
+
+func (st DBstore) GetOrgName(ctx context.Context, orgID int64) (string, error) {
+	var name string
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		ok, err := sess.SQL("SELECT name FROM org WHERE id = ?", orgID).Get(&name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("could not find org %d", orgID)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
@@ -449,6 +449,82 @@ func (f *FakeAdminConfigStore) UpdateAdminConfiguration(cmd UpdateAdminConfigura
 	return nil
 }
 
+func NewFakeExternalAlertmanagerCredentialsStore(t *testing.T) *FakeExternalAlertmanagerCredentialsStore {
+	t.Helper()
+	return &FakeExternalAlertmanagerCredentialsStore{}
+}
+
+type FakeExternalAlertmanagerCredentialsStore struct {
+	mtx    sync.Mutex
+	lastID int64
+	Creds  []*models.ExternalAlertmanagerCredentials
+}
+
+func (f *FakeExternalAlertmanagerCredentialsStore) GetExternalAlertmanagerCredentials(_ context.Context, orgID int64, uid string) (*models.ExternalAlertmanagerCredentials, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, c := range f.Creds {
+		if c.OrgID == orgID && c.UID == uid {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, models.ErrExternalAlertmanagerCredentialsNotFound
+}
+
+func (f *FakeExternalAlertmanagerCredentialsStore) ListExternalAlertmanagerCredentials(_ context.Context, orgID int64) ([]*models.ExternalAlertmanagerCredentials, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var result []*models.ExternalAlertmanagerCredentials
+	for _, c := range f.Creds {
+		if c.OrgID == orgID {
+			cp := *c
+			result = append(result, &cp)
+		}
+	}
+	return result, nil
+}
+
+func (f *FakeExternalAlertmanagerCredentialsStore) SaveExternalAlertmanagerCredentials(_ context.Context, creds *models.ExternalAlertmanagerCredentials) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, c := range f.Creds {
+		if c.OrgID == creds.OrgID && c.ID != creds.ID && (c.Name == creds.Name || c.UID == creds.UID) {
+			return models.ErrExternalAlertmanagerCredentialsConflict
+		}
+	}
+	cp := *creds
+	if creds.ID != 0 {
+		for i, c := range f.Creds {
+			if c.ID == creds.ID && c.OrgID == creds.OrgID {
+				f.Creds[i] = &cp
+				return nil
+			}
+		}
+		return models.ErrExternalAlertmanagerCredentialsNotFound
+	}
+	if creds.UID == "" {
+		creds.UID = util.GenerateShortUID()
+	}
+	f.lastID++
+	creds.ID = f.lastID
+	cp = *creds
+	f.Creds = append(f.Creds, &cp)
+	return nil
+}
+
+func (f *FakeExternalAlertmanagerCredentialsStore) DeleteExternalAlertmanagerCredentials(_ context.Context, orgID int64, uid string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, c := range f.Creds {
+		if c.OrgID == orgID && c.UID == uid {
+			f.Creds = append(f.Creds[:i], f.Creds[i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrExternalAlertmanagerCredentialsNotFound
+}
+
 type FakeAnnotationsRepo struct {
 	mtx   sync.Mutex
 	Items []*annotations.Item
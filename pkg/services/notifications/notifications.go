@@ -120,14 +120,18 @@ func (ns *NotificationService) GetMailer() Mailer {
 
 func (ns *NotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
 	return ns.sendWebRequestSync(ctx, &Webhook{
-		Url:         cmd.Url,
-		User:        cmd.User,
-		Password:    cmd.Password,
-		Body:        cmd.Body,
-		HttpMethod:  cmd.HttpMethod,
-		HttpHeader:  cmd.HttpHeader,
-		ContentType: cmd.ContentType,
-		Validation:  cmd.Validation,
+		Url:             cmd.Url,
+		User:            cmd.User,
+		Password:        cmd.Password,
+		Body:            cmd.Body,
+		HttpMethod:      cmd.HttpMethod,
+		HttpHeader:      cmd.HttpHeader,
+		ContentType:     cmd.ContentType,
+		Validation:      cmd.Validation,
+		TLSConfig:       cmd.TLSConfig,
+		ProxyURL:        cmd.ProxyURL,
+		Proxy:           cmd.Proxy,
+		ResponseHeaders: cmd.ResponseHeaders,
 	})
 }
 
@@ -147,6 +151,7 @@ func (ns *NotificationService) SendEmailCommandHandlerSync(ctx context.Context,
 		AttachedFiles: cmd.AttachedFiles,
 		Subject:       cmd.Subject,
 		ReplyTo:       cmd.ReplyTo,
+		Headers:       cmd.Headers,
 	})
 
 	if err != nil {
@@ -7,13 +7,15 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/grafana/grafana/pkg/setting"
 	gomail "gopkg.in/mail.v2"
 )
 
 type SmtpClient struct {
-	cfg setting.SmtpSettings
+	cfg  setting.SmtpSettings
+	pool *smtpPool
 }
 
 func ProvideSmtpService(cfg *setting.Cfg) (Mailer, error) {
@@ -22,40 +24,87 @@ func ProvideSmtpService(cfg *setting.Cfg) (Mailer, error) {
 
 func NewSmtpClient(cfg setting.SmtpSettings) (*SmtpClient, error) {
 	client := &SmtpClient{
-		cfg: cfg,
+		cfg:  cfg,
+		pool: newSmtpPool(cfg.MaxConnections, cfg.IdleTimeout),
 	}
 
 	return client, nil
 }
 
+// Send sends the messages in parallel over the pooled connections to the SMTP server.
 func (sc *SmtpClient) Send(messages ...*Message) (int, error) {
-	sentEmailsCount := 0
 	dialer, err := sc.createDialer()
 	if err != nil {
-		return sentEmailsCount, err
+		return 0, err
 	}
 
-	for _, msg := range messages {
-		m := sc.buildEmail(msg)
-
-		innerError := dialer.DialAndSend(m)
-		emailsSentTotal.Inc()
-		if innerError != nil {
-			// As gomail does not returned typed errors we have to parse the error
-			// to catch invalid error when the address is invalid.
-			// https://github.com/go-gomail/gomail/blob/81ebce5c23dfd25c6c67194b37d3dd3f338c98b1/send.go#L113
-			if !strings.HasPrefix(innerError.Error(), "gomail: invalid address") {
-				emailsSentFailed.Inc()
+	var (
+		mtx             sync.Mutex
+		wg              sync.WaitGroup
+		sentEmailsCount int
+	)
+	queue := make(chan *Message)
+	workers := cap(sc.pool.slots)
+	if len(messages) < workers {
+		workers = len(messages)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range queue {
+				innerError := sc.sendMessage(dialer, msg)
+				emailsSentTotal.Inc()
+
+				mtx.Lock()
+				if innerError != nil {
+					// As gomail does not returned typed errors we have to parse the error
+					// to catch invalid error when the address is invalid.
+					// https://github.com/go-gomail/gomail/blob/81ebce5c23dfd25c6c67194b37d3dd3f338c98b1/send.go#L113
+					if !strings.Contains(innerError.Error(), "gomail: invalid address") {
+						emailsSentFailed.Inc()
+					}
+					err = fmt.Errorf("failed to send notification to email addresses: %s: %w", strings.Join(msg.To, ";"), innerError)
+				} else {
+					sentEmailsCount++
+				}
+				mtx.Unlock()
 			}
+		}()
+	}
+	for _, msg := range messages {
+		queue <- msg
+	}
+	close(queue)
+	wg.Wait()
+
+	return sentEmailsCount, err
+}
+
+// sendMessage sends a message over a pooled connection. A reused connection may have been
+// closed by the server in the meantime, in which case the message is sent again over a new one.
+func (sc *SmtpClient) sendMessage(dialer *gomail.Dialer, msg *Message) error {
+	m := sc.buildEmail(msg)
+	dial := func() (*smtpConn, error) { return dialSmtp(dialer) }
 
-			err = fmt.Errorf("failed to send notification to email addresses: %s: %w", strings.Join(msg.To, ";"), innerError)
-			continue
+	for attempt := 0; ; attempt++ {
+		c, reused, err := sc.pool.get(dial)
+		if err != nil {
+			return err
 		}
 
-		sentEmailsCount++
-	}
+		usable := true
+		err = gomail.Send(gomail.SendFunc(func(from string, to []string, wt io.WriterTo) error {
+			var sendErr error
+			usable, sendErr = c.send(from, to, wt)
+			return sendErr
+		}), m)
+		sc.pool.put(c, usable)
 
-	return sentEmailsCount, err
+		if err == nil || usable || !reused || attempt > 0 {
+			return err
+		}
+	}
 }
 
 // buildEmail converts the Message DTO to a gomail message.
@@ -68,6 +117,9 @@ func (sc *SmtpClient) buildEmail(msg *Message) *gomail.Message {
 	for _, replyTo := range msg.ReplyTo {
 		m.SetAddressHeader("Reply-To", replyTo, "")
 	}
+	for name, value := range msg.Headers {
+		m.SetHeader(name, value)
+	}
 	// loop over content types from settings in reverse order as they are ordered in according to descending
 	// preference while the alternatives should be ordered according to ascending preference
 	for i := len(sc.cfg.ContentTypes) - 1; i >= 0; i-- {
@@ -0,0 +1,358 @@
+package notifications
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	gomail "gopkg.in/mail.v2"
+)
+
+// smtpConn is an authenticated connection to the SMTP server that is kept open between emails.
+type smtpConn struct {
+	conn       net.Conn
+	client     *smtp.Client
+	timeout    time.Duration
+	pipelining bool
+	eightBit   bool
+	lastUsed   time.Time
+}
+
+// dialSmtp connects and authenticates to the SMTP server of the dialer, like gomail does.
+func dialSmtp(d *gomail.Dialer) (*smtpConn, error) {
+	conn, err := gomail.NetDialTimeout("tcp", net.JoinHostPort(d.Host, fmt.Sprint(d.Port)), d.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if d.SSL {
+		conn = tls.Client(conn, d.TLSConfig)
+	}
+	if d.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(d.Timeout)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, d.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := dialSmtpSession(c, d); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	pipelining, _ := c.Extension("PIPELINING")
+	eightBit, _ := c.Extension("8BITMIME")
+	return &smtpConn{conn: conn, client: c, timeout: d.Timeout, pipelining: pipelining, eightBit: eightBit}, nil
+}
+
+func dialSmtpSession(c *smtp.Client, d *gomail.Dialer) error {
+	if d.LocalName != "" {
+		if err := c.Hello(d.LocalName); err != nil {
+			return err
+		}
+	}
+
+	if !d.SSL && d.StartTLSPolicy != gomail.NoStartTLS {
+		ok, _ := c.Extension("STARTTLS")
+		if !ok && d.StartTLSPolicy == gomail.MandatoryStartTLS {
+			return gomail.StartTLSUnsupportedError{Policy: d.StartTLSPolicy}
+		}
+		if ok {
+			if err := c.StartTLS(d.TLSConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.Username == "" {
+		return nil
+	}
+	ok, auths := c.Extension("AUTH")
+	if !ok {
+		return nil
+	}
+	var auth smtp.Auth
+	switch {
+	case strings.Contains(auths, "CRAM-MD5"):
+		auth = smtp.CRAMMD5Auth(d.Username, d.Password)
+	case strings.Contains(auths, "LOGIN") && !strings.Contains(auths, "PLAIN"):
+		auth = &smtpLoginAuth{username: d.Username, password: d.Password}
+	default:
+		auth = smtp.PlainAuth("", d.Username, d.Password, d.Host)
+	}
+	return c.Auth(auth)
+}
+
+// send sends a message in a single mail transaction. When the server supports pipelining, the
+// commands of the transaction are sent together and their replies read afterwards, which saves
+// a round trip per command. It returns whether the connection can still be used.
+func (c *smtpConn) send(from string, to []string, msg io.WriterTo) (bool, error) {
+	if c.timeout > 0 {
+		if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return false, err
+		}
+	}
+
+	if !c.pipelining {
+		if err := c.sendInSteps(from, to, msg); err != nil {
+			return c.reset(err), err
+		}
+		return true, nil
+	}
+
+	text := c.client.Text
+	mailCmd := "MAIL FROM:<%s>"
+	if c.eightBit {
+		mailCmd += " BODY=8BITMIME"
+	}
+	if err := text.PrintfLine(mailCmd, from); err != nil {
+		return false, err
+	}
+	for _, addr := range to {
+		if err := text.PrintfLine("RCPT TO:<%s>", addr); err != nil {
+			return false, err
+		}
+	}
+	if err := text.PrintfLine("DATA"); err != nil {
+		return false, err
+	}
+
+	// Replies come in the order of the commands and must all be read.
+	var txErr error
+	if _, _, err := text.ReadResponse(250); err != nil {
+		if !isSmtpReply(err) {
+			return false, err
+		}
+		txErr = err
+	}
+	for range to {
+		if _, _, err := text.ReadResponse(25); err != nil {
+			if !isSmtpReply(err) {
+				return false, err
+			}
+			if txErr == nil {
+				txErr = err
+			}
+		}
+	}
+	if _, _, err := text.ReadResponse(354); err != nil {
+		if !isSmtpReply(err) {
+			return false, err
+		}
+		if txErr == nil {
+			txErr = err
+		}
+		return c.reset(txErr), txErr
+	}
+	if txErr != nil {
+		// The server is waiting for the message of a failed transaction, which can only be
+		// aborted by closing the connection.
+		return false, txErr
+	}
+
+	w := text.DotWriter()
+	if _, err := msg.WriteTo(w); err != nil {
+		_ = w.Close()
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+	if _, _, err := text.ReadResponse(250); err != nil {
+		return isSmtpReply(err), err
+	}
+	return true, nil
+}
+
+func (c *smtpConn) sendInSteps(from string, to []string, msg io.WriterTo) error {
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// reset aborts the mail transaction after a rejected command. It returns whether the connection
+// can still be used.
+func (c *smtpConn) reset(err error) bool {
+	if !isSmtpReply(err) {
+		return false
+	}
+	return c.client.Reset() == nil
+}
+
+// close ends the session with the server.
+func (c *smtpConn) close() {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err := c.client.Quit(); err != nil {
+		_ = c.client.Close()
+	}
+}
+
+// isSmtpReply returns whether err is a reply of the SMTP server rejecting a command, after which
+// the connection can be used again, rather than a failure of the connection.
+func isSmtpReply(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr)
+}
+
+// smtpPool limits the number of connections to the SMTP server and keeps them open between
+// emails, so that bursts of emails do not dial a connection per email, which SMTP servers
+// often throttle. Connections unused for idleTimeout are closed.
+type smtpPool struct {
+	idleTimeout time.Duration
+	slots       chan struct{}
+
+	mtx   sync.Mutex
+	idle  []*smtpConn
+	timer *time.Timer
+}
+
+func newSmtpPool(maxConnections int, idleTimeout time.Duration) *smtpPool {
+	if maxConnections < 1 {
+		maxConnections = 1
+	}
+	return &smtpPool{
+		idleTimeout: idleTimeout,
+		slots:       make(chan struct{}, maxConnections),
+	}
+}
+
+// get returns the most recently used idle connection, or dials a new one if there is none.
+// It blocks while all the connections are in use. The second return value reports whether
+// the connection was reused.
+func (p *smtpPool) get(dial func() (*smtpConn, error)) (*smtpConn, bool, error) {
+	p.slots <- struct{}{}
+
+	p.mtx.Lock()
+	var c *smtpConn
+	if n := len(p.idle); n > 0 {
+		c = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+	}
+	p.mtx.Unlock()
+
+	if c != nil && time.Since(c.lastUsed) < p.idleTimeout {
+		return c, true, nil
+	}
+	if c != nil {
+		c.close()
+	}
+
+	c, err := dial()
+	if err != nil {
+		<-p.slots
+		return nil, false, err
+	}
+	return c, false, nil
+}
+
+// put returns a connection to the pool, or closes it if it cannot be used anymore.
+func (p *smtpPool) put(c *smtpConn, usable bool) {
+	defer func() { <-p.slots }()
+
+	if !usable {
+		// The state of the session is unknown, so it is not ended gracefully.
+		_ = c.client.Close()
+		return
+	}
+	if p.idleTimeout <= 0 {
+		c.close()
+		return
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	c.lastUsed = time.Now()
+	p.idle = append(p.idle, c)
+	if p.timer == nil {
+		p.timer = time.AfterFunc(p.idleTimeout, p.closeIdle)
+	}
+}
+
+// closeIdle closes the connections unused for idleTimeout.
+func (p *smtpPool) closeIdle() {
+	p.mtx.Lock()
+	p.timer = nil
+	var expired []*smtpConn
+	keep := p.idle[:0]
+	for _, c := range p.idle {
+		if time.Since(c.lastUsed) >= p.idleTimeout {
+			expired = append(expired, c)
+		} else {
+			keep = append(keep, c)
+		}
+	}
+	p.idle = keep
+	// The first connection is the least recently used one.
+	if len(p.idle) > 0 {
+		p.timer = time.AfterFunc(p.idleTimeout-time.Since(p.idle[0].lastUsed), p.closeIdle)
+	}
+	p.mtx.Unlock()
+
+	for _, c := range expired {
+		c.close()
+	}
+}
+
+// smtpLoginAuth implements the LOGIN authentication mechanism, for servers that do not
+// support PLAIN.
+type smtpLoginAuth struct {
+	username string
+	password string
+}
+
+func (a *smtpLoginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		advertised := false
+		for _, mechanism := range server.Auth {
+			if mechanism == "LOGIN" {
+				advertised = true
+				break
+			}
+		}
+		if !advertised {
+			return "", nil, errors.New("unencrypted connection")
+		}
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *smtpLoginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch {
+	case strings.EqualFold(string(fromServer), "Username:"):
+		return []byte(a.username), nil
+	case strings.EqualFold(string(fromServer), "Password:"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
@@ -0,0 +1,246 @@
+package notifications
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeSmtpMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSmtpServer is an SMTP server for tests. With pipelining, it only replies to the commands
+// of a transaction once it has read the DATA command, which fails clients that wait for the
+// replies.
+type fakeSmtpServer struct {
+	ln                net.Listener
+	pipelining        bool
+	closeAfterMessage bool
+
+	mtx         sync.Mutex
+	connections int
+	quits       int
+	messages    []fakeSmtpMessage
+}
+
+func newFakeSmtpServer(t *testing.T, pipelining bool) *fakeSmtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	s := &fakeSmtpServer{ln: ln, pipelining: pipelining}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSmtpServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	s.mtx.Lock()
+	s.connections++
+	s.mtx.Unlock()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP")
+
+	var msg fakeSmtpMessage
+	var queued []string
+	// reply sends the replies to the pipelined commands before the reply to the command.
+	reply := func(line string) {
+		for _, r := range queued {
+			_ = text.PrintfLine("%s", r)
+		}
+		queued = nil
+		_ = text.PrintfLine("%s", line)
+	}
+	// replyInTransaction holds the replies to the commands of a transaction until DATA.
+	replyInTransaction := func(line string) {
+		if s.pipelining {
+			queued = append(queued, line)
+			return
+		}
+		reply(line)
+	}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO":
+			if s.pipelining {
+				_ = text.PrintfLine("250-localhost")
+				_ = text.PrintfLine("250-PIPELINING")
+			}
+			_ = text.PrintfLine("250 localhost")
+		case cmd == "MAIL":
+			msg = fakeSmtpMessage{From: strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")}
+			replyInTransaction("250 OK")
+		case cmd == "RCPT":
+			addr := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if strings.HasPrefix(addr, "unknown@") {
+				replyInTransaction("550 no such user")
+				continue
+			}
+			msg.To = append(msg.To, addr)
+			replyInTransaction("250 OK")
+		case cmd == "DATA":
+			if len(msg.To) == 0 {
+				reply("554 no valid recipients")
+				continue
+			}
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mtx.Lock()
+			s.messages = append(s.messages, msg)
+			s.mtx.Unlock()
+			_ = text.PrintfLine("250 queued")
+			if s.closeAfterMessage {
+				return
+			}
+		case cmd == "RSET":
+			msg = fakeSmtpMessage{}
+			reply("250 OK")
+		case cmd == "QUIT":
+			s.mtx.Lock()
+			s.quits++
+			s.mtx.Unlock()
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func (s *fakeSmtpServer) stats() (int, int, []fakeSmtpMessage) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.connections, s.quits, append([]fakeSmtpMessage(nil), s.messages...)
+}
+
+func newPooledSmtpClient(t *testing.T, s *fakeSmtpServer, maxConnections int, idleTimeout time.Duration) *SmtpClient {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.Smtp.Host = s.ln.Addr().String()
+	cfg.Smtp.ContentTypes = []string{"text/plain"}
+	cfg.Smtp.MaxConnections = maxConnections
+	cfg.Smtp.IdleTimeout = idleTimeout
+	sc, err := NewSmtpClient(cfg.Smtp)
+	require.NoError(t, err)
+	return sc
+}
+
+func testSmtpMessages(to ...string) []*Message {
+	messages := make([]*Message, 0, len(to))
+	for _, addr := range to {
+		messages = append(messages, &Message{
+			To:      []string{addr},
+			From:    "Grafana <from@address.com>",
+			Subject: "subject",
+			Body:    map[string]string{"text/plain": "body of " + addr},
+		})
+	}
+	return messages
+}
+
+func TestSmtpPool(t *testing.T) {
+	for _, pipelining := range []bool{true, false} {
+		t.Run(fmt.Sprintf("pipelining=%t", pipelining), func(t *testing.T) {
+			t.Run("bursts of emails are sent over the pooled connections", func(t *testing.T) {
+				s := newFakeSmtpServer(t, pipelining)
+				sc := newPooledSmtpClient(t, s, 2, time.Minute)
+
+				to := make([]string, 0, 20)
+				for i := 0; i < 20; i++ {
+					to = append(to, fmt.Sprintf("user%d@example.com", i))
+				}
+				count, err := sc.Send(testSmtpMessages(to...)...)
+				require.NoError(t, err)
+				require.Equal(t, 20, count)
+
+				count, err = sc.Send(testSmtpMessages("again@example.com")...)
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+
+				connections, _, messages := s.stats()
+				require.LessOrEqual(t, connections, 2)
+				require.Len(t, messages, 21)
+				for _, m := range messages {
+					require.Equal(t, "from@address.com", m.From)
+					require.Len(t, m.To, 1)
+					require.Contains(t, m.Data, "body of "+m.To[0])
+				}
+			})
+
+			t.Run("the connection is still used after a rejected recipient", func(t *testing.T) {
+				s := newFakeSmtpServer(t, pipelining)
+				sc := newPooledSmtpClient(t, s, 1, time.Minute)
+
+				count, err := sc.Send(testSmtpMessages("user@example.com", "unknown@example.com", "other@example.com")...)
+				require.Equal(t, 2, count)
+				require.ErrorContains(t, err, "failed to send notification to email addresses: unknown@example.com")
+				require.ErrorContains(t, err, "no such user")
+
+				connections, _, messages := s.stats()
+				require.Equal(t, 1, connections)
+				require.Len(t, messages, 2)
+			})
+		})
+	}
+
+	t.Run("connections closed by the server are dialed again", func(t *testing.T) {
+		s := newFakeSmtpServer(t, true)
+		s.closeAfterMessage = true
+		sc := newPooledSmtpClient(t, s, 1, time.Minute)
+
+		count, err := sc.Send(testSmtpMessages("a@example.com", "b@example.com", "c@example.com")...)
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+
+		connections, _, messages := s.stats()
+		require.Equal(t, 3, connections)
+		require.Len(t, messages, 3)
+	})
+
+	t.Run("idle connections are closed", func(t *testing.T) {
+		s := newFakeSmtpServer(t, true)
+		sc := newPooledSmtpClient(t, s, 2, 50*time.Millisecond)
+
+		_, err := sc.Send(testSmtpMessages("a@example.com", "b@example.com")...)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			connections, quits, _ := s.stats()
+			return connections > 0 && quits == connections
+		}, 5*time.Second, 10*time.Millisecond)
+
+		sc.pool.mtx.Lock()
+		defer sc.pool.mtx.Unlock()
+		require.Empty(t, sc.pool.idle)
+	})
+}
@@ -39,6 +39,19 @@ func TestBuildMail(t *testing.T) {
 		assert.Contains(t, buf.String(), "Some plain text body")
 		assert.Less(t, strings.Index(buf.String(), "Some plain text body"), strings.Index(buf.String(), "Some HTML body"))
 	})
+
+	t.Run("When building email with headers", func(t *testing.T) {
+		withHeaders := *message
+		withHeaders.Headers = map[string]string{"X-Priority": "1 (Highest)", "In-Reply-To": "<alert@grafana>"}
+		email := sc.buildEmail(&withHeaders)
+
+		buf := new(bytes.Buffer)
+		_, err := email.WriteTo(buf)
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), "X-Priority: 1 (Highest)\r\n")
+		assert.Contains(t, buf.String(), "In-Reply-To: <alert@grafana>\r\n")
+	})
 }
 
 func TestSmtpDialer(t *testing.T) {
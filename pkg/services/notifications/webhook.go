@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/grafana/grafana/pkg/util"
@@ -25,6 +26,17 @@ type Webhook struct {
 	// Validation is a function that will validate the response body and statusCode of the webhook. Any returned error will cause the webhook request to be considered failed.
 	// This can be useful when a webhook service communicates failures in creative ways, such as using the response body instead of the status code.
 	Validation func(body []byte, statusCode int) error
+
+	// TLSConfig and ProxyURL replace the TLS configuration and the proxies of the environment
+	// of the default client, for the webhooks that need a private CA or a dedicated proxy.
+	TLSConfig *tls.Config
+	ProxyURL  *url.URL
+	// Proxy replaces the proxies of the environment like ProxyURL, but chooses the proxy of each
+	// request, e.g. to send some of them directly.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// ResponseHeaders is called with the headers of the response before its validation, when set.
+	ResponseHeaders func(header http.Header)
 }
 
 // WebhookClient exists to mock the client in tests.
@@ -47,6 +59,22 @@ var netClient WebhookClient = &http.Client{
 	Transport: netTransport,
 }
 
+// newWebhookTransport returns a transport like the default one, with the TLS configuration and
+// the proxy of the webhook.
+func newWebhookTransport(tlsConfig *tls.Config, proxyURL *url.URL, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	transport := netTransport.Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if proxy != nil {
+		transport.Proxy = proxy
+	}
+	return transport
+}
+
 func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *Webhook) error {
 	if webhook.HttpMethod == "" {
 		webhook.HttpMethod = http.MethodPost
@@ -54,8 +82,10 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 
 	ns.log.Debug("Sending webhook", "url", webhook.Url, "http method", webhook.HttpMethod)
 
-	if webhook.HttpMethod != http.MethodPost && webhook.HttpMethod != http.MethodPut {
-		return fmt.Errorf("webhook only supports HTTP methods PUT or POST")
+	switch webhook.HttpMethod {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodGet:
+	default:
+		return fmt.Errorf("webhook only supports HTTP methods GET, PATCH, PUT or POST")
 	}
 
 	request, err := http.NewRequestWithContext(ctx, webhook.HttpMethod, webhook.Url, bytes.NewReader([]byte(webhook.Body)))
@@ -78,7 +108,17 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		request.Header.Set(k, v)
 	}
 
-	resp, err := netClient.Do(request)
+	client := netClient
+	if webhook.TLSConfig != nil || webhook.ProxyURL != nil || webhook.Proxy != nil {
+		transport := newWebhookTransport(webhook.TLSConfig, webhook.ProxyURL, webhook.Proxy)
+		defer transport.CloseIdleConnections()
+		client = &http.Client{
+			Timeout:   time.Second * 30,
+			Transport: transport,
+		}
+	}
+
+	resp, err := client.Do(request)
 	if err != nil {
 		return err
 	}
@@ -93,6 +133,10 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		return err
 	}
 
+	if webhook.ResponseHeaders != nil {
+		webhook.ResponseHeaders(resp.Header)
+	}
+
 	if webhook.Validation != nil {
 		err := webhook.Validation(body, resp.StatusCode)
 		if err != nil {
@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestSendWebRequestSync_TLSConfigAndProxy(t *testing.T) {
+	ns := &NotificationService{log: log.New("notifications.test")}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("the certificate of the server is not trusted by default", func(t *testing.T) {
+		err := ns.sendWebRequestSync(context.Background(), &Webhook{Url: server.URL})
+		require.Error(t, err)
+	})
+
+	t.Run("the CA of the webhook is trusted", func(t *testing.T) {
+		pool := x509.NewCertPool()
+		pool.AddCert(server.Certificate())
+		err := ns.sendWebRequestSync(context.Background(), &Webhook{Url: server.URL, TLSConfig: &tls.Config{RootCAs: pool}})
+		require.NoError(t, err)
+	})
+
+	t.Run("the requests are sent through the proxy of the webhook", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(proxy.Close)
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+
+		err = ns.sendWebRequestSync(context.Background(), &Webhook{Url: "http://webhook.example.com/hook", ProxyURL: proxyURL})
+		require.NoError(t, err)
+		require.Equal(t, "http://webhook.example.com/hook", proxied)
+
+		proxied = ""
+		err = ns.sendWebRequestSync(context.Background(), &Webhook{Url: "http://hooks.example.com/hook", Proxy: func(*http.Request) (*url.URL, error) {
+			return proxyURL, nil
+		}})
+		require.NoError(t, err)
+		require.Equal(t, "http://hooks.example.com/hook", proxied)
+	})
+}
+
+func TestSendWebRequestSync_ResponseHeaders(t *testing.T) {
+	ns := &NotificationService{log: log.New("notifications.test")}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	var retryAfter string
+	err := ns.sendWebRequestSync(context.Background(), &Webhook{
+		Url: server.URL,
+		ResponseHeaders: func(header http.Header) {
+			retryAfter = header.Get("Retry-After")
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, "10", retryAfter)
+}
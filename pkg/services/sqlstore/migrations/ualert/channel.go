@@ -85,16 +85,31 @@ func (m *migration) setupAlertmanagerConfigs(rulesPerOrg map[int64]map[string]da
 
 		// Create routes
 		if rules, ok := rulesPerOrg[orgID]; ok {
+			report := newDefaultChannelsReport(orgID, defaultChannels, defaultRoute.Receiver, m.mg.Cfg.UnifiedAlerting.MigrationExplicitDefaultRoutes)
 			for ruleUid, da := range rules {
 				route, err := m.createRouteForAlert(ruleUid, da, receiversMap, defaultReceivers)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create route for alert %s in orgId %d: %w", da.Name, orgID, err)
 				}
 
+				if route == nil {
+					// The alert relies on the default channels, through the root-level route.
+					report.add(ruleUid, da, defaultRoutingReason(da, receiversMap))
+					if report.ExplicitRoutes {
+						route, err = createRoute(ruleUid, map[string]interface{}{defaultRoute.Receiver: struct{}{}})
+						if err != nil {
+							return nil, fmt.Errorf("failed to create default route for alert %s in orgId %d: %w", da.Name, orgID, err)
+						}
+					}
+				}
+
 				if route != nil {
 					amConfigPerOrg[da.OrgId].AlertmanagerConfig.Route.Routes = append(amConfigPerOrg[da.OrgId].AlertmanagerConfig.Route.Routes, route)
 				}
 			}
+			if len(report.Alerts) > 0 {
+				m.defaultChannelsReports[orgID] = report
+			}
 		}
 
 		// Validate the alertmanager configuration produced, this gives a chance to catch bad configuration at migration time.
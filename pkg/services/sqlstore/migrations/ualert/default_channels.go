@@ -0,0 +1,115 @@
+package ualert
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// The reasons for which a migrated alert relies on the default channels.
+const (
+	// defaultRoutingNoChannels is an alert without notification channels.
+	defaultRoutingNoChannels = "no-channels"
+	// defaultRoutingDefaultChannels is an alert whose notification channels are all default channels.
+	defaultRoutingDefaultChannels = "default-channels-only"
+	// defaultRoutingObsoleteChannels is an alert whose notification channels were all deleted or discontinued.
+	defaultRoutingObsoleteChannels = "obsolete-channels"
+)
+
+// defaultChannelsReport lists the alerts of an organization that rely on the legacy behavior of
+// sending the notifications of all alerts to the default channels. Without explicit routes, their
+// notifications follow the root-level route, so that changing its contact point changes who is
+// notified of them.
+type defaultChannelsReport struct {
+	OrgID               int64                  `json:"orgId"`
+	DefaultChannels     []string               `json:"defaultChannels"`
+	DefaultContactPoint string                 `json:"defaultContactPoint"`
+	ExplicitRoutes      bool                   `json:"explicitRoutes"`
+	Alerts              []defaultChannelsAlert `json:"alerts"`
+}
+
+type defaultChannelsAlert struct {
+	RuleUID      string `json:"ruleUid"`
+	Title        string `json:"title"`
+	DashboardUID string `json:"dashboardUid"`
+	PanelID      int64  `json:"panelId"`
+	Reason       string `json:"reason"`
+}
+
+func newDefaultChannelsReport(orgID int64, defaultChannels []*notificationChannel, defaultContactPoint string, explicitRoutes bool) *defaultChannelsReport {
+	names := make([]string, 0, len(defaultChannels))
+	for _, c := range defaultChannels {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return &defaultChannelsReport{
+		OrgID:               orgID,
+		DefaultChannels:     names,
+		DefaultContactPoint: defaultContactPoint,
+		ExplicitRoutes:      explicitRoutes,
+		Alerts:              []defaultChannelsAlert{},
+	}
+}
+
+func (r *defaultChannelsReport) add(ruleUID string, da dashAlert, reason string) {
+	r.Alerts = append(r.Alerts, defaultChannelsAlert{
+		RuleUID:      ruleUID,
+		Title:        da.Name,
+		DashboardUID: da.DashboardUID,
+		PanelID:      da.PanelId,
+		Reason:       reason,
+	})
+}
+
+// defaultRoutingReason returns why the alert, which has no route of its own, relies on the default channels.
+func defaultRoutingReason(da dashAlert, receivers map[uidOrID]*PostableApiReceiver) string {
+	channelIDs := extractChannelIDs(da)
+	if len(channelIDs) == 0 {
+		return defaultRoutingNoChannels
+	}
+	for _, uidOrId := range channelIDs {
+		if _, ok := receivers[uidOrId]; ok {
+			return defaultRoutingDefaultChannels
+		}
+	}
+	return defaultRoutingObsoleteChannels
+}
+
+// writeDefaultChannelsReport writes the report next to the silences of the organization, and logs a summary.
+func (m *migration) writeDefaultChannelsReport(report *defaultChannelsReport) error {
+	sort.Slice(report.Alerts, func(i, j int) bool {
+		if report.Alerts[i].Title != report.Alerts[j].Title {
+			return report.Alerts[i].Title < report.Alerts[j].Title
+		}
+		return report.Alerts[i].RuleUID < report.Alerts[j].RuleUID
+	})
+
+	filename := defaultChannelsReportFileNameForOrg(m.mg, report.OrgID)
+	m.mg.Logger.Warn("alerts rely on the default notification channels of legacy alerting", "orgId", report.OrgID, "alerts", len(report.Alerts),
+		"defaultContactPoint", report.DefaultContactPoint, "explicitRoutes", report.ExplicitRoutes, "report", filename)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := openReplace(filename)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.File.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func getDefaultChannelsReportFileNamesForAllOrgs(mg *migrator.Migrator) ([]string, error) {
+	return filepath.Glob(filepath.Join(mg.Cfg.DataPath, "alerting", "*", "migration_default_channels.json"))
+}
+
+func defaultChannelsReportFileNameForOrg(mg *migrator.Migrator, orgID int64) string {
+	return filepath.Join(mg.Cfg.DataPath, "alerting", strconv.Itoa(int(orgID)), "migration_default_channels.json")
+}
@@ -0,0 +1,42 @@
+package ualert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRoutingReason(t *testing.T) {
+	receivers := map[uidOrID]*PostableApiReceiver{
+		"uid1":   {Name: "recv1"},
+		int64(2): {Name: "recv2"},
+	}
+
+	tc := []struct {
+		name     string
+		channels []dashAlertNot
+		expected string
+	}{
+		{
+			name:     "when an alert has no channels, it relies on the default channels",
+			expected: defaultRoutingNoChannels,
+		},
+		{
+			name:     "when an alert has migrated channels, they are all default channels",
+			channels: []dashAlertNot{{UID: "uid1"}, {ID: 2}},
+			expected: defaultRoutingDefaultChannels,
+		},
+		{
+			name:     "when none of the channels of an alert were migrated, they are obsolete",
+			channels: []dashAlertNot{{UID: "deleted"}, {ID: 3}},
+			expected: defaultRoutingObsoleteChannels,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			da := dashAlert{ParsedSettings: &dashAlertSettings{Notifications: tt.channels}}
+			require.Equal(t, tt.expected, defaultRoutingReason(da, receivers))
+		})
+	}
+}
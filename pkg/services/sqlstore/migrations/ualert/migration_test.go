@@ -3,6 +3,9 @@ package ualert_test
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -132,9 +135,13 @@ func TestDashAlertMigration(t *testing.T) {
 		name           string
 		legacyChannels []*models.AlertNotification
 		alerts         []*models.Alert
+		// explicitDefaultRoutes enables the explicit routes for the alerts relying on the default channels.
+		explicitDefaultRoutes bool
 
 		expected map[int64]*ualert.PostableUserConfig
-		expErr   error
+		// expDefaultChannelsAlerts are the titles of the alerts in the default channels report of each organization.
+		expDefaultChannelsAlerts map[int64][]string
+		expErr                   error
 	}{
 		{
 			name: "general multi-org, multi-alert, multi-channel migration",
@@ -317,6 +324,39 @@ func TestDashAlertMigration(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "when explicit default routes are enabled, create routes to the default contact point for alerts relying on defaults",
+			legacyChannels: []*models.AlertNotification{
+				createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, true), // default
+				createAlertNotification(t, int64(1), "notifier2", "slack", slackSettings, false),
+			},
+			alerts: []*models.Alert{
+				createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+				createAlert(t, int64(1), int64(2), int64(3), "alert2", []string{}),
+			},
+			explicitDefaultRoutes: true,
+			expected: map[int64]*ualert.PostableUserConfig{
+				int64(1): {
+					AlertmanagerConfig: ualert.PostableApiAlertingConfig{
+						Route: &ualert.Route{
+							Receiver:   "notifier1",
+							GroupByStr: []string{ngModels.FolderTitleLabel, model.AlertNameLabel},
+							Routes: []*ualert.Route{
+								{Receiver: "notifier1", Matchers: createAlertNameMatchers("alert1")},
+								{Receiver: "notifier1", Matchers: createAlertNameMatchers("alert2")},
+							},
+						},
+						Receivers: []*ualert.PostableApiReceiver{
+							{Name: "notifier1", GrafanaManagedReceivers: []*ualert.PostableGrafanaReceiver{{Name: "notifier1", Type: "email"}}},
+							{Name: "notifier2", GrafanaManagedReceivers: []*ualert.PostableGrafanaReceiver{{Name: "notifier2", Type: "slack"}}},
+						},
+					},
+				},
+			},
+			expDefaultChannelsAlerts: map[int64][]string{
+				int64(1): {"alert1", "alert2"},
+			},
+		},
 		{
 			name: "when alerts share channels, only create one receiver per legacy channel",
 			legacyChannels: []*models.AlertNotification{
@@ -431,7 +471,11 @@ func TestDashAlertMigration(t *testing.T) {
 			_, errDeleteMig := x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.MigTitle)
 			require.NoError(t, errDeleteMig)
 
-			alertMigrator := migrator.NewMigrator(x, &setting.Cfg{})
+			cfg := &setting.Cfg{
+				DataPath:        t.TempDir(),
+				UnifiedAlerting: setting.UnifiedAlertingSettings{MigrationExplicitDefaultRoutes: tt.explicitDefaultRoutes},
+			}
+			alertMigrator := migrator.NewMigrator(x, cfg)
 			alertMigrator.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
 			ualert.AddDashAlertMigration(alertMigrator)
 
@@ -469,6 +513,24 @@ func TestDashAlertMigration(t *testing.T) {
 					t.Errorf("Unexpected Route: %v", cmp.Diff(tt.expected[orgId].AlertmanagerConfig.Route, amConfig.AlertmanagerConfig.Route, cOpt...))
 				}
 			}
+
+			for orgId, titles := range tt.expDefaultChannelsAlerts {
+				data, err := ioutil.ReadFile(filepath.Join(cfg.DataPath, "alerting", strconv.FormatInt(orgId, 10), "migration_default_channels.json"))
+				require.NoError(t, err)
+				var report struct {
+					ExplicitRoutes bool `json:"explicitRoutes"`
+					Alerts         []struct {
+						Title string `json:"title"`
+					} `json:"alerts"`
+				}
+				require.NoError(t, json.Unmarshal(data, &report))
+				require.Equal(t, tt.explicitDefaultRoutes, report.ExplicitRoutes)
+				actual := make([]string, 0, len(report.Alerts))
+				for _, a := range report.Alerts {
+					actual = append(actual, a.Title)
+				}
+				require.Equal(t, titles, actual)
+			}
 		})
 	}
 }
@@ -27,6 +27,18 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 	AddProvisioningMigrations(mg)
 
 	AddAlertImageMigrations(mg)
+
+	// Create credentials of external Alertmanagers
+	AddAlertmanagerCredentialsMigrations(mg)
+
+	// Create the delivery history of the notifications
+	AddNotificationHistoryMigrations(mg)
+
+	// Create the audit log of the notifications
+	AddNotificationAuditMigrations(mg)
+
+	// Create the dead letters of the notifications
+	AddNotificationDeadLetterMigrations(mg)
 }
 
 // AddAlertDefinitionMigrations should not be modified.
@@ -362,6 +374,140 @@ func AddAlertAdminConfigMigrations(mg *migrator.Migrator) {
 	}))
 }
 
+func AddAlertmanagerCredentialsMigrations(mg *migrator.Migrator) {
+	credentials := migrator.Table{
+		Name: "ngalert_alertmanager_credentials",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "basic_auth_user", Type: migrator.DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "tls_server_name", Type: migrator.DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "tls_skip_verify", Type: migrator.DB_Bool, Nullable: false, Default: "0"},
+			{Name: "secure_settings", Type: migrator.DB_Text, Nullable: true},
+			{Name: "version", Type: migrator.DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "rotated_at", Type: migrator.DB_Int, Nullable: false, Default: "0"},
+
+			{Name: "created_at", Type: migrator.DB_Int, Nullable: false},
+			{Name: "updated_at", Type: migrator.DB_Int, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create ngalert_alertmanager_credentials table", migrator.NewAddTableMigration(credentials))
+	mg.AddMigration("add unique index in ngalert_alertmanager_credentials on org_id and uid columns", migrator.NewAddIndexMigration(credentials, credentials.Indices[0]))
+	mg.AddMigration("add unique index in ngalert_alertmanager_credentials on org_id and name columns", migrator.NewAddIndexMigration(credentials, credentials.Indices[1]))
+}
+
+func AddNotificationHistoryMigrations(mg *migrator.Migrator) {
+	history := migrator.Table{
+		Name: "ngalert_notification_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "started_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "duration_ms", Type: migrator.DB_Double, Nullable: false},
+			{Name: "alerts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "attempts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "started_at"}, Type: migrator.IndexType},
+			{Cols: []string{"started_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create ngalert_notification_history table", migrator.NewAddTableMigration(history))
+	mg.AddMigration("add index in ngalert_notification_history on org_id and started_at columns", migrator.NewAddIndexMigration(history, history.Indices[0]))
+	mg.AddMigration("add index in ngalert_notification_history on started_at column", migrator.NewAddIndexMigration(history, history.Indices[1]))
+	mg.AddMigration("add column canceled to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "canceled", Type: migrator.DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column failover to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "failover", Type: migrator.DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column status_code to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "status_code", Type: migrator.DB_Int, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column dispatch_id to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "dispatch_id", Type: migrator.DB_NVarchar, Length: 40, Nullable: true,
+	}))
+	mg.AddMigration("add column email_opens to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "email_opens", Type: migrator.DB_Int, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column email_clicks to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "email_clicks", Type: migrator.DB_Int, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column email_opened_at to ngalert_notification_history", migrator.NewAddColumnMigration(history, &migrator.Column{
+		Name: "email_opened_at", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add index in ngalert_notification_history on org_id and dispatch_id columns", migrator.NewAddIndexMigration(history,
+		&migrator.Index{Cols: []string{"org_id", "dispatch_id"}, Type: migrator.IndexType}))
+}
+
+func AddNotificationAuditMigrations(mg *migrator.Migrator) {
+	audit := migrator.Table{
+		Name: "ngalert_notification_audit",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "sent_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "title", Type: migrator.DB_Text, Nullable: true},
+			{Name: "fingerprints", Type: migrator.DB_Text, Nullable: true},
+			{Name: "alerts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "result", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "sent_at"}, Type: migrator.IndexType},
+			{Cols: []string{"sent_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create ngalert_notification_audit table", migrator.NewAddTableMigration(audit))
+	mg.AddMigration("add index in ngalert_notification_audit on org_id and sent_at columns", migrator.NewAddIndexMigration(audit, audit.Indices[0]))
+	mg.AddMigration("add index in ngalert_notification_audit on sent_at column", migrator.NewAddIndexMigration(audit, audit.Indices[1]))
+}
+
+func AddNotificationDeadLetterMigrations(mg *migrator.Migrator) {
+	letters := migrator.Table{
+		Name: "ngalert_notification_dead_letter",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: true},
+			{Name: "group_labels", Type: migrator.DB_Text, Nullable: true},
+			{Name: "alerts", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "failed_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "replays", Type: migrator.DB_Int, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "failed_at"}, Type: migrator.IndexType},
+			{Cols: []string{"failed_at"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "receiver", "integration"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create ngalert_notification_dead_letter table", migrator.NewAddTableMigration(letters))
+	mg.AddMigration("add index in ngalert_notification_dead_letter on org_id and failed_at columns", migrator.NewAddIndexMigration(letters, letters.Indices[0]))
+	mg.AddMigration("add index in ngalert_notification_dead_letter on failed_at column", migrator.NewAddIndexMigration(letters, letters.Indices[1]))
+	mg.AddMigration("add index in ngalert_notification_dead_letter on org_id, receiver and integration columns", migrator.NewAddIndexMigration(letters, letters.Indices[2]))
+}
+
 func AddProvisioningMigrations(mg *migrator.Migrator) {
 	provisioningTable := migrator.Table{
 		Name: "provenance_type",
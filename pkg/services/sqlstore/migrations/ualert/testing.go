@@ -16,6 +16,7 @@ func newTestMigration(t *testing.T) *migration {
 
 			Logger: log.New("test"),
 		},
-		seenChannelUIDs: make(map[string]struct{}),
+		seenChannelUIDs:        make(map[string]struct{}),
+		defaultChannelsReports: make(map[int64]*defaultChannelsReport),
 	}
 }
@@ -72,8 +72,9 @@ func AddDashAlertMigration(mg *migrator.Migrator) {
 			mg.Logger.Error("alert migration error: could not clear alert migration for removing data", "error", err)
 		}
 		mg.AddMigration(migTitle, &migration{
-			seenChannelUIDs: make(map[string]struct{}),
-			silences:        make(map[int64][]*pb.MeshSilence),
+			seenChannelUIDs:        make(map[string]struct{}),
+			silences:               make(map[int64][]*pb.MeshSilence),
+			defaultChannelsReports: make(map[int64]*defaultChannelsReport),
 		})
 	// If unified alerting is disabled and upgrade migration has been run
 	case !mg.Cfg.UnifiedAlerting.IsEnabled() && migrationRun:
@@ -226,6 +227,8 @@ type migration struct {
 
 	seenChannelUIDs map[string]struct{}
 	silences        map[int64][]*pb.MeshSilence
+	// defaultChannelsReports are the reports of the alerts relying on the default channels, by org.
+	defaultChannelsReports map[int64]*defaultChannelsReport
 }
 
 func (m *migration) SQL(dialect migrator.Dialect) string {
@@ -416,6 +419,12 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		}
 	}
 
+	for orgID, report := range m.defaultChannelsReports {
+		if err := m.writeDefaultChannelsReport(report); err != nil {
+			m.mg.Logger.Error("alert migration error: failed to write default channels report", "orgId", orgID, "err", err)
+		}
+	}
+
 	return nil
 }
 
@@ -574,6 +583,16 @@ func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		}
 	}
 
+	files, err = getDefaultChannelsReportFileNamesForAllOrgs(mg)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			mg.Logger.Error("alert migration error: failed to remove default channels report", "file", f, "err", err)
+		}
+	}
+
 	return nil
 }
 
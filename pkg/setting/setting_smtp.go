@@ -1,6 +1,10 @@
 package setting
 
-import "github.com/grafana/grafana/pkg/util"
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
 
 type SmtpSettings struct {
 	Enabled        bool
@@ -14,6 +18,8 @@ type SmtpSettings struct {
 	EhloIdentity   string
 	StartTLSPolicy string
 	SkipVerify     bool
+	MaxConnections int
+	IdleTimeout    time.Duration
 
 	SendWelcomeEmailOnSignUp bool
 	TemplatesPatterns        []string
@@ -33,6 +39,8 @@ func (cfg *Cfg) readSmtpSettings() {
 	cfg.Smtp.EhloIdentity = sec.Key("ehlo_identity").String()
 	cfg.Smtp.StartTLSPolicy = sec.Key("startTLS_policy").String()
 	cfg.Smtp.SkipVerify = sec.Key("skip_verify").MustBool(false)
+	cfg.Smtp.MaxConnections = sec.Key("max_connections").MustInt(2)
+	cfg.Smtp.IdleTimeout = sec.Key("idle_timeout").MustDuration(30 * time.Second)
 
 	emails := cfg.Raw.Section("emails")
 	cfg.Smtp.SendWelcomeEmailOnSignUp = emails.Key("welcome_email_on_sign_up").MustBool(false)
@@ -1,8 +1,11 @@
 package setting
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +24,7 @@ const (
 	alertmanagerDefaultGossipInterval     = cluster.DefaultGossipInterval
 	alertmanagerDefaultPushPullInterval   = cluster.DefaultPushPullInterval
 	alertmanagerDefaultConfigPollInterval = 60 * time.Second
+	alertmanagerDefaultDrainTimeout       = 10 * time.Second
 	// To start, the alertmanager needs at least one route defined.
 	// TODO: we should move this to Grafana settings and define this as the default.
 	alertmanagerDefaultConfiguration = `{
@@ -52,6 +56,7 @@ const (
 	screenshotsDefaultCapture               = false
 	screenshotsDefaultMaxConcurrent         = 5
 	screenshotsDefaultUploadImageStorage    = false
+	remoteWriteDefaultInterval              = time.Minute
 	// SchedulerBaseInterval base interval of the scheduler. Controls how often the scheduler fetches database for new changes as well as schedules evaluation of a rule
 	// changing this value is discouraged because this could cause existing alert definition
 	// with intervals that are not exactly divided by this number not to be evaluated
@@ -69,6 +74,9 @@ type UnifiedAlertingSettings struct {
 	HAPeerTimeout                  time.Duration
 	HAGossipInterval               time.Duration
 	HAPushPullInterval             time.Duration
+	NotificationDrainTimeout       time.Duration
+	DryRunFolders                  map[string]struct{}
+	EmailTrackingOrgs              map[int64]struct{}
 	MaxAttempts                    int64
 	MinInterval                    time.Duration
 	EvaluationTimeout              time.Duration
@@ -83,6 +91,31 @@ type UnifiedAlertingSettings struct {
 	DefaultRuleEvaluationInterval time.Duration
 	Screenshots                   UnifiedAlertingScreenshotSettings
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
+	NotificationMetrics           UnifiedAlertingRemoteWriteSettings
+	NotificationWorkers           UnifiedAlertingNotificationWorkerSettings
+	// NotificationHistoryRetention is how long the deliveries of the notifications are kept in the
+	// notification history. The history is not recorded if it is zero.
+	NotificationHistoryRetention time.Duration
+	// NotificationAuditRetention is how long the notifications are kept in the audit log. The
+	// audit log is not recorded if it is zero.
+	NotificationAuditRetention time.Duration
+	// NotificationDeadLetterRetention is how long the notifications that failed to be delivered are
+	// kept to be replayed. They are not kept if it is zero.
+	NotificationDeadLetterRetention time.Duration
+	// TemplatePackTrustedKeys are the public keys, by ID, that can sign the imported packs of
+	// notification templates.
+	TemplatePackTrustedKeys map[string]ed25519.PublicKey
+	// TemplatePackAllowUnsigned allows importing packs of notification templates without signature.
+	TemplatePackAllowUnsigned bool
+	// NotifierVCRMode records the webhooks of the contact points and their responses to fixture
+	// files in NotifierVCRDir, or replays the responses of the fixtures instead of sending the
+	// webhooks. It is only available in development mode.
+	NotifierVCRMode string
+	NotifierVCRDir  string
+	// MigrationExplicitDefaultRoutes creates a notification policy for each legacy alert that
+	// relies on the default notification channels during the upgrade to unified alerting, instead
+	// of leaving them to the root policy.
+	MigrationExplicitDefaultRoutes bool
 }
 
 type UnifiedAlertingScreenshotSettings struct {
@@ -95,6 +128,26 @@ type UnifiedAlertingReservedLabelSettings struct {
 	DisabledLabels map[string]struct{}
 }
 
+// UnifiedAlertingRemoteWriteSettings configures the remote write of the metrics of the
+// notification pipeline to a Prometheus compatible endpoint. It is disabled if URL is empty.
+type UnifiedAlertingRemoteWriteSettings struct {
+	URL               string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	Interval          time.Duration
+	Labels            map[string]string
+}
+
+// UnifiedAlertingNotificationWorkerSettings configures the gRPC server of the NotificationWorker
+// service, through which external workers deliver the notifications of the worker contact points.
+// It is disabled if ListenAddr is empty.
+type UnifiedAlertingNotificationWorkerSettings struct {
+	ListenAddr string
+	Token      string
+	CertFile   string
+	KeyFile    string
+}
+
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
 // It hides the implementation details of the Enabled and simplifies its usage.
 func (u *UnifiedAlertingSettings) IsEnabled() bool {
@@ -202,6 +255,54 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	if err != nil {
 		return err
 	}
+	uaCfg.NotificationDrainTimeout, err = gtime.ParseDuration(valueAsString(ua, "notification_drain_timeout", (alertmanagerDefaultDrainTimeout).String()))
+	if err != nil {
+		return err
+	}
+	uaCfg.DryRunFolders = make(map[string]struct{})
+	for _, uid := range util.SplitString(valueAsString(ua, "dry_run_folders", "")) {
+		uaCfg.DryRunFolders[uid] = struct{}{}
+	}
+	uaCfg.EmailTrackingOrgs = make(map[int64]struct{})
+	for _, org := range util.SplitString(valueAsString(ua, "email_tracking_orgs", "")) {
+		orgID, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			return err
+		}
+		uaCfg.EmailTrackingOrgs[orgID] = struct{}{}
+	}
+	uaCfg.NotificationHistoryRetention, err = gtime.ParseDuration(valueAsString(ua, "notification_history_retention", "0"))
+	if err != nil {
+		return err
+	}
+	uaCfg.NotificationAuditRetention, err = gtime.ParseDuration(valueAsString(ua, "notification_audit_retention", "0"))
+	if err != nil {
+		return err
+	}
+	uaCfg.NotificationDeadLetterRetention, err = gtime.ParseDuration(valueAsString(ua, "notification_dead_letter_retention", "0"))
+	if err != nil {
+		return err
+	}
+	uaCfg.TemplatePackTrustedKeys, err = parseTemplatePackTrustedKeys(valueAsString(ua, "template_pack_trusted_keys", ""))
+	if err != nil {
+		return err
+	}
+	uaCfg.TemplatePackAllowUnsigned = ua.Key("template_pack_allow_unsigned").MustBool(false)
+	uaCfg.NotifierVCRMode = valueAsString(ua, "notifier_vcr_mode", "")
+	switch uaCfg.NotifierVCRMode {
+	case "":
+	case "record", "replay":
+		if cfg.Env != Dev {
+			return errors.New("notifier_vcr_mode can only be set in development mode")
+		}
+	default:
+		return fmt.Errorf("invalid notifier_vcr_mode %q, must be record or replay", uaCfg.NotifierVCRMode)
+	}
+	uaCfg.NotifierVCRDir = valueAsString(ua, "notifier_vcr_dir", "")
+	if uaCfg.NotifierVCRDir == "" {
+		uaCfg.NotifierVCRDir = filepath.Join(cfg.DataPath, "notifier_cassettes")
+	}
+	uaCfg.MigrationExplicitDefaultRoutes = ua.Key("migration_explicit_default_routes").MustBool(false)
 	uaCfg.HAListenAddr = ua.Key("ha_listen_address").MustString(alertmanagerDefaultClusterAddr)
 	uaCfg.HAAdvertiseAddr = ua.Key("ha_advertise_address").MustString("")
 	peers := ua.Key("ha_peers").MustString("")
@@ -294,6 +395,46 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.ReservedLabels = uaCfgReservedLabels
 
+	remoteWrite := iniFile.Section("unified_alerting.notification_metrics_remote_write")
+	uaCfgRemoteWrite := UnifiedAlertingRemoteWriteSettings{
+		URL:               remoteWrite.Key("url").MustString(""),
+		BasicAuthUser:     remoteWrite.Key("basic_auth_user").MustString(""),
+		BasicAuthPassword: remoteWrite.Key("basic_auth_password").MustString(""),
+		Labels:            make(map[string]string),
+	}
+	uaCfgRemoteWrite.Interval, err = gtime.ParseDuration(valueAsString(remoteWrite, "interval", remoteWriteDefaultInterval.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfgRemoteWrite.Interval <= 0 {
+		return fmt.Errorf("value of setting 'interval' of the notification metrics remote write should be positive")
+	}
+	for _, label := range util.SplitString(remoteWrite.Key("labels").MustString("")) {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid label %q of the notification metrics remote write, must be name=value", label)
+		}
+		uaCfgRemoteWrite.Labels[parts[0]] = parts[1]
+	}
+	if _, ok := uaCfgRemoteWrite.Labels["instance"]; !ok && InstanceName != "" {
+		uaCfgRemoteWrite.Labels["instance"] = InstanceName
+	}
+	uaCfg.NotificationMetrics = uaCfgRemoteWrite
+
+	workers := iniFile.Section("unified_alerting.notification_workers")
+	uaCfg.NotificationWorkers = UnifiedAlertingNotificationWorkerSettings{
+		ListenAddr: workers.Key("listen_address").MustString(""),
+		Token:      workers.Key("token").MustString(""),
+		CertFile:   workers.Key("cert_file").MustString(""),
+		KeyFile:    workers.Key("cert_key").MustString(""),
+	}
+	if uaCfg.NotificationWorkers.ListenAddr != "" && uaCfg.NotificationWorkers.Token == "" {
+		return errors.New("the token of the notification workers is required when their listen address is set")
+	}
+	if (uaCfg.NotificationWorkers.CertFile == "") != (uaCfg.NotificationWorkers.KeyFile == "") {
+		return errors.New("both the certificate and the key of the notification workers server must be set")
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
@@ -301,3 +442,21 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 func GetAlertmanagerDefaultConfiguration() string {
 	return alertmanagerDefaultConfiguration
 }
+
+// parseTemplatePackTrustedKeys parses a comma-separated list of key ID and base64 encoded
+// Ed25519 public key pairs, separated by a colon.
+func parseTemplatePackTrustedKeys(s string) (map[string]ed25519.PublicKey, error) {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, pair := range util.SplitString(s) {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid template pack key %q, must be <key ID>:<base64 public key>", pair)
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid template pack key %q, must be a base64 encoded Ed25519 public key", parts[0])
+		}
+		keys[parts[0]] = key
+	}
+	return keys, nil
+}
@@ -2,6 +2,7 @@ package setting
 
 import (
 	"math/rand"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -25,6 +26,15 @@ func TestCfg_ReadUnifiedAlertingSettings(t *testing.T) {
 		require.Len(t, cfg.UnifiedAlerting.HAPeers, 0)
 		require.Equal(t, 200*time.Millisecond, cfg.UnifiedAlerting.HAGossipInterval)
 		require.Equal(t, 60*time.Second, cfg.UnifiedAlerting.HAPushPullInterval)
+		require.Equal(t, 10*time.Second, cfg.UnifiedAlerting.NotificationDrainTimeout)
+		require.Len(t, cfg.UnifiedAlerting.DryRunFolders, 0)
+		require.Zero(t, cfg.UnifiedAlerting.NotificationHistoryRetention)
+		require.Zero(t, cfg.UnifiedAlerting.NotificationAuditRetention)
+		require.Zero(t, cfg.UnifiedAlerting.NotificationDeadLetterRetention)
+		require.Empty(t, cfg.UnifiedAlerting.TemplatePackTrustedKeys)
+		require.False(t, cfg.UnifiedAlerting.TemplatePackAllowUnsigned)
+		require.Empty(t, cfg.UnifiedAlerting.NotificationMetrics.URL)
+		require.Equal(t, time.Minute, cfg.UnifiedAlerting.NotificationMetrics.Interval)
 	}
 
 	// With peers set, it correctly parses them.
@@ -39,6 +49,67 @@ func TestCfg_ReadUnifiedAlertingSettings(t *testing.T) {
 		require.Len(t, cfg.UnifiedAlerting.HAPeers, 3)
 		require.ElementsMatch(t, []string{"hostname1:9090", "hostname2:9090", "hostname3:9090"}, cfg.UnifiedAlerting.HAPeers)
 	}
+
+	// With template pack keys set, it decodes them.
+	{
+		s, err := cfg.Raw.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = s.NewKey("template_pack_trusted_keys", "community:11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Len(t, cfg.UnifiedAlerting.TemplatePackTrustedKeys, 1)
+		require.Len(t, cfg.UnifiedAlerting.TemplatePackTrustedKeys["community"], 32)
+
+		_, err = s.NewKey("template_pack_trusted_keys", "community:c2hvcnQ=")
+		require.NoError(t, err)
+		require.EqualError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), `invalid template pack key "community", must be a base64 encoded Ed25519 public key`)
+		s.DeleteKey("template_pack_trusted_keys")
+	}
+
+	// The notifier VCR mode is only available in development mode.
+	{
+		s, err := cfg.Raw.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = s.NewKey("notifier_vcr_mode", "replay")
+		require.NoError(t, err)
+
+		env := cfg.Env
+		cfg.Env = Dev
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, "replay", cfg.UnifiedAlerting.NotifierVCRMode)
+		require.Equal(t, filepath.Join(cfg.DataPath, "notifier_cassettes"), cfg.UnifiedAlerting.NotifierVCRDir)
+
+		cfg.Env = Prod
+		require.EqualError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), "notifier_vcr_mode can only be set in development mode")
+		cfg.Env = env
+
+		_, err = s.NewKey("notifier_vcr_mode", "rewind")
+		require.NoError(t, err)
+		require.EqualError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), `invalid notifier_vcr_mode "rewind", must be record or replay`)
+		s.DeleteKey("notifier_vcr_mode")
+	}
+
+	// With the notification metrics remote write set, it parses its labels.
+	{
+		s, err := cfg.Raw.NewSection("unified_alerting.notification_metrics_remote_write")
+		require.NoError(t, err)
+		_, err = s.NewKey("url", "https://prometheus.example.com/api/v1/write")
+		require.NoError(t, err)
+		_, err = s.NewKey("interval", "30s")
+		require.NoError(t, err)
+		_, err = s.NewKey("labels", "cluster=eu-west, env=prod")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, "https://prometheus.example.com/api/v1/write", cfg.UnifiedAlerting.NotificationMetrics.URL)
+		require.Equal(t, 30*time.Second, cfg.UnifiedAlerting.NotificationMetrics.Interval)
+		require.Equal(t, map[string]string{"cluster": "eu-west", "env": "prod", "instance": InstanceName}, cfg.UnifiedAlerting.NotificationMetrics.Labels)
+
+		_, err = s.NewKey("labels", "cluster")
+		require.NoError(t, err)
+		require.EqualError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), `invalid label "cluster" of the notification metrics remote write, must be name=value`)
+	}
 }
 
 func TestUnifiedAlertingSettings(t *testing.T) {
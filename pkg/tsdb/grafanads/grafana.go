@@ -12,6 +12,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/setting"
@@ -38,14 +39,16 @@ var (
 	_ backend.CheckHealthHandler = (*Service)(nil)
 )
 
-func ProvideService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService) *Service {
-	return newService(cfg, search, store)
+func ProvideService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService, history ngstore.NotificationHistoryStore) *Service {
+	return newService(cfg, search, store, history)
 }
 
-func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService) *Service {
+func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService, history ngstore.NotificationHistoryStore) *Service {
 	s := &Service{
-		search: search,
-		store:  store,
+		cfg:     cfg,
+		search:  search,
+		store:   store,
+		history: history,
 	}
 
 	return s
@@ -53,8 +56,10 @@ func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.Sto
 
 // Service exists regardless of user settings
 type Service struct {
-	search searchV2.SearchService
-	store  store.StorageService
+	cfg     *setting.Cfg
+	search  searchV2.SearchService
+	store   store.StorageService
+	history ngstore.NotificationHistoryStore
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -82,6 +87,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeAlertNotificationHistory:
+			response.Responses[q.RefID] = s.doAlertNotificationHistoryQuery(ctx, req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -0,0 +1,219 @@
+package grafanads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// maxNotificationDeliveries is the maximum number of deliveries read by a query.
+	maxNotificationDeliveries = 50000
+	// minNotificationHistoryInterval is the minimum interval of the time series of the deliveries.
+	minNotificationHistoryInterval = time.Minute
+)
+
+func (s *Service) doAlertNotificationHistoryQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	if s.history == nil || s.cfg == nil || s.cfg.UnifiedAlerting.NotificationHistoryRetention <= 0 {
+		return backend.DataResponse{Error: errors.New("the alert notification history is disabled, see notification_history_retention in the unified_alerting section of the configuration")}
+	}
+
+	m := alertNotificationHistoryQueryModel{}
+	if err := json.Unmarshal(query.JSON, &m); err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	deliveries, err := s.history.GetNotificationDeliveries(ctx, &ngmodels.GetNotificationDeliveriesQuery{
+		OrgID:       req.PluginContext.OrgID,
+		From:        query.TimeRange.From,
+		To:          query.TimeRange.To,
+		Receiver:    m.Receiver,
+		Integration: m.Integration,
+		Limit:       maxNotificationDeliveries,
+	})
+	if err != nil {
+		return backend.DataResponse{Error: fmt.Errorf("failed to read the alert notification history: %w", err)}
+	}
+
+	var frames data.Frames
+	switch m.Format {
+	case "", "table":
+		frames = data.Frames{notificationDeliveriesTable(deliveries)}
+	case "timeseries":
+		frames = notificationDeliveriesTimeSeries(deliveries, query.TimeRange, query.Interval)
+	default:
+		return backend.DataResponse{Error: fmt.Errorf("unknown format %q", m.Format)}
+	}
+
+	if len(deliveries) == maxNotificationDeliveries && len(frames) > 0 {
+		frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Only the first %d notifications of the time range are shown.", maxNotificationDeliveries),
+		})
+	}
+	return backend.DataResponse{Frames: frames}
+}
+
+// notificationDeliveriesTable returns a frame with a row per delivery.
+func notificationDeliveriesTable(deliveries []*ngmodels.NotificationDelivery) *data.Frame {
+	times := make([]time.Time, len(deliveries))
+	receivers := make([]string, len(deliveries))
+	integrations := make([]string, len(deliveries))
+	alerts := make([]int64, len(deliveries))
+	attempts := make([]int64, len(deliveries))
+	statusCodes := make([]int64, len(deliveries))
+	durations := make([]float64, len(deliveries))
+	failed := make([]bool, len(deliveries))
+	errs := make([]string, len(deliveries))
+	canceled := make([]bool, len(deliveries))
+	failover := make([]bool, len(deliveries))
+	emailOpens := make([]int64, len(deliveries))
+	emailClicks := make([]int64, len(deliveries))
+	for i, d := range deliveries {
+		times[i] = time.UnixMilli(d.StartedAt)
+		receivers[i] = d.Receiver
+		integrations[i] = d.Integration
+		alerts[i] = int64(d.Alerts)
+		attempts[i] = int64(d.Attempts)
+		statusCodes[i] = int64(d.StatusCode)
+		durations[i] = d.DurationMs
+		failed[i] = d.Error != ""
+		errs[i] = d.Error
+		canceled[i] = d.Canceled
+		failover[i] = d.Failover
+		emailOpens[i] = int64(d.EmailOpens)
+		emailClicks[i] = int64(d.EmailClicks)
+	}
+
+	duration := data.NewField("duration", nil, durations)
+	duration.Config = &data.FieldConfig{Unit: "ms"}
+	return data.NewFrame("notifications",
+		data.NewField("time", nil, times),
+		data.NewField("receiver", nil, receivers),
+		data.NewField("integration", nil, integrations),
+		data.NewField("alerts", nil, alerts),
+		data.NewField("attempts", nil, attempts),
+		duration,
+		data.NewField("failed", nil, failed),
+		data.NewField("error", nil, errs),
+		data.NewField("canceled", nil, canceled),
+		data.NewField("failover", nil, failover),
+		data.NewField("status_code", nil, statusCodes),
+		data.NewField("email_opens", nil, emailOpens),
+		data.NewField("email_clicks", nil, emailClicks),
+	)
+}
+
+type notificationDeliveriesKey struct {
+	receiver    string
+	integration string
+}
+
+// notificationDeliveriesBucket aggregates the deliveries of an interval.
+type notificationDeliveriesBucket struct {
+	count     float64
+	failures  float64
+	canceled  float64
+	durations float64
+	maxMs     float64
+}
+
+// notificationDeliveriesTimeSeries returns a frame per receiver and integration, with the number
+// of notifications, the number of failures, the failure rate, the latency and the number of
+// cancelled notifications of each interval of the time range.
+func notificationDeliveriesTimeSeries(deliveries []*ngmodels.NotificationDelivery, tr backend.TimeRange, interval time.Duration) data.Frames {
+	if interval < minNotificationHistoryInterval {
+		interval = minNotificationHistoryInterval
+	}
+	from := tr.From.Truncate(interval)
+	buckets := int(tr.To.Sub(from)/interval) + 1
+
+	series := map[notificationDeliveriesKey][]notificationDeliveriesBucket{}
+	for _, d := range deliveries {
+		i := int(time.UnixMilli(d.StartedAt).Sub(from) / interval)
+		if i < 0 || i >= buckets {
+			continue
+		}
+		key := notificationDeliveriesKey{receiver: d.Receiver, integration: d.Integration}
+		b, ok := series[key]
+		if !ok {
+			b = make([]notificationDeliveriesBucket, buckets)
+			series[key] = b
+		}
+		b[i].count++
+		if d.Error != "" {
+			b[i].failures++
+		}
+		if d.Canceled {
+			b[i].canceled++
+		}
+		b[i].durations += d.DurationMs
+		if d.DurationMs > b[i].maxMs {
+			b[i].maxMs = d.DurationMs
+		}
+	}
+
+	keys := make([]notificationDeliveriesKey, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].receiver != keys[j].receiver {
+			return keys[i].receiver < keys[j].receiver
+		}
+		return keys[i].integration < keys[j].integration
+	})
+
+	times := make([]time.Time, buckets)
+	for i := range times {
+		times[i] = from.Add(time.Duration(i) * interval)
+	}
+
+	frames := make(data.Frames, 0, len(keys))
+	for _, key := range keys {
+		b := series[key]
+		count := make([]float64, buckets)
+		failures := make([]float64, buckets)
+		cancellations := make([]float64, buckets)
+		rate := make([]*float64, buckets)
+		avgLatency := make([]*float64, buckets)
+		maxLatency := make([]*float64, buckets)
+		for i := range b {
+			count[i] = b[i].count
+			failures[i] = b[i].failures
+			cancellations[i] = b[i].canceled
+			if b[i].count == 0 {
+				// There is no failure rate or latency without notifications.
+				continue
+			}
+			r, a, m := b[i].failures/b[i].count, b[i].durations/b[i].count, b[i].maxMs
+			rate[i], avgLatency[i], maxLatency[i] = &r, &a, &m
+		}
+
+		labels := data.Labels{"receiver": key.receiver, "integration": key.integration}
+		rateField := data.NewField("failure_rate", labels, rate)
+		rateField.Config = &data.FieldConfig{Unit: "percentunit"}
+		avgField := data.NewField("latency_avg", labels, avgLatency)
+		avgField.Config = &data.FieldConfig{Unit: "ms"}
+		maxField := data.NewField("latency_max", labels, maxLatency)
+		maxField.Config = &data.FieldConfig{Unit: "ms"}
+		frames = append(frames, data.NewFrame(key.receiver+" "+key.integration,
+			data.NewField("time", nil, times),
+			data.NewField("notifications", labels, count),
+			data.NewField("failures", labels, failures),
+			rateField,
+			avgField,
+			maxField,
+			data.NewField("cancellations", labels, cancellations),
+		))
+	}
+	return frames
+}
@@ -0,0 +1,138 @@
+package grafanads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeNotificationHistoryStore struct {
+	deliveries []*ngmodels.NotificationDelivery
+	query      *ngmodels.GetNotificationDeliveriesQuery
+}
+
+func (f *fakeNotificationHistoryStore) SaveNotificationDeliveries(context.Context, []ngmodels.NotificationDelivery) error {
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) GetNotificationDeliveries(_ context.Context, query *ngmodels.GetNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error) {
+	f.query = query
+	return f.deliveries, nil
+}
+
+func (f *fakeNotificationHistoryStore) TrackNotificationDeliveryEmail(context.Context, *ngmodels.TrackNotificationDeliveryEmailCommand) error {
+	return nil
+}
+
+func (f *fakeNotificationHistoryStore) DeleteNotificationDeliveriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestAlertNotificationHistoryQuery(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0).UTC().Truncate(time.Minute)
+	at := func(d time.Duration) int64 { return start.Add(d).UnixMilli() }
+	history := &fakeNotificationHistoryStore{deliveries: []*ngmodels.NotificationDelivery{
+		{Receiver: "ops", Integration: "email", StartedAt: at(10 * time.Second), DurationMs: 30, Alerts: 2, Attempts: 3, Error: "connection refused", Canceled: true},
+		{Receiver: "ops", Integration: "email", StartedAt: at(20 * time.Second), DurationMs: 50, Alerts: 1, Attempts: 1, Failover: true, StatusCode: 204, EmailOpens: 2, EmailClicks: 1},
+		{Receiver: "dba", Integration: "slack", StartedAt: at(2 * time.Minute), DurationMs: 80, Alerts: 1, Attempts: 1},
+	}}
+	cfg := setting.NewCfg()
+	cfg.UnifiedAlerting.NotificationHistoryRetention = time.Hour
+	s := newService(cfg, nil, nil, history)
+
+	query := func(model string) backend.DataResponse {
+		req := &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{OrgID: 2},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				QueryType: queryTypeAlertNotificationHistory,
+				JSON:      []byte(model),
+				Interval:  time.Second,
+				TimeRange: backend.TimeRange{From: start, To: start.Add(3 * time.Minute)},
+			}},
+		}
+		resp, err := s.QueryData(context.Background(), req)
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("table", func(t *testing.T) {
+		resp := query(`{"receiver": "ops"}`)
+		require.NoError(t, resp.Error)
+		require.Equal(t, &ngmodels.GetNotificationDeliveriesQuery{
+			OrgID:    2,
+			From:     start,
+			To:       start.Add(3 * time.Minute),
+			Receiver: "ops",
+			Limit:    maxNotificationDeliveries,
+		}, history.query)
+
+		require.Len(t, resp.Frames, 1)
+		frame := resp.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, time.UnixMilli(at(10*time.Second)), frame.Fields[0].At(0))
+		require.Equal(t, "email", frame.Fields[2].At(0))
+		require.Equal(t, int64(3), frame.Fields[4].At(0))
+		require.Equal(t, "ms", frame.Fields[5].Config.Unit)
+		require.Equal(t, true, frame.Fields[6].At(0))
+		require.Equal(t, false, frame.Fields[6].At(1))
+		require.Equal(t, true, frame.Fields[8].At(0))
+		require.Equal(t, false, frame.Fields[8].At(1))
+		require.Equal(t, false, frame.Fields[9].At(0))
+		require.Equal(t, true, frame.Fields[9].At(1))
+		require.Equal(t, int64(0), frame.Fields[10].At(0))
+		require.Equal(t, int64(204), frame.Fields[10].At(1))
+		require.Equal(t, "email_opens", frame.Fields[11].Name)
+		require.Equal(t, int64(2), frame.Fields[11].At(1))
+		require.Equal(t, int64(1), frame.Fields[12].At(1))
+	})
+
+	t.Run("time series per receiver and integration", func(t *testing.T) {
+		resp := query(`{"format": "timeseries"}`)
+		require.NoError(t, resp.Error)
+		require.Len(t, resp.Frames, 2)
+
+		// The interval is at least a minute.
+		dba, ops := resp.Frames[0], resp.Frames[1]
+		require.Equal(t, 4, ops.Rows())
+		require.Equal(t, data.Labels{"receiver": "ops", "integration": "email"}, ops.Fields[1].Labels)
+		require.Equal(t, []interface{}{float64(2), float64(0), float64(0), float64(0)}, values(ops.Fields[1]))
+		require.Equal(t, float64(1), ops.Fields[2].At(0))
+		rate, _ := ops.Fields[3].ConcreteAt(0)
+		require.Equal(t, 0.5, rate)
+		avg, _ := ops.Fields[4].ConcreteAt(0)
+		require.Equal(t, float64(40), avg)
+		maxLatency, _ := ops.Fields[5].ConcreteAt(0)
+		require.Equal(t, float64(50), maxLatency)
+		_, ok := ops.Fields[3].ConcreteAt(1)
+		require.False(t, ok)
+		require.Equal(t, []interface{}{float64(1), float64(0), float64(0), float64(0)}, values(ops.Fields[6]))
+
+		require.Equal(t, []interface{}{float64(0), float64(0), float64(1), float64(0)}, values(dba.Fields[1]))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		resp := query(`{"format": "heatmap"}`)
+		require.EqualError(t, resp.Error, `unknown format "heatmap"`)
+	})
+
+	t.Run("disabled history", func(t *testing.T) {
+		resp := newService(setting.NewCfg(), nil, nil, history).doAlertNotificationHistoryQuery(context.Background(), &backend.QueryDataRequest{}, backend.DataQuery{})
+		require.Error(t, resp.Error)
+	})
+}
+
+func values(f *data.Field) []interface{} {
+	v := make([]interface{}, f.Len())
+	for i := range v {
+		v[i] = f.At(i)
+	}
+	return v
+}
@@ -14,6 +14,10 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// queryTypeAlertNotificationHistory returns the deliveries of the alert notifications,
+	// as a table or as time series of their volume, failure rate and latency
+	queryTypeAlertNotificationHistory = "alertNotificationHistory"
 )
 
 type listQueryModel struct {
@@ -22,3 +26,10 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type alertNotificationHistoryQueryModel struct {
+	Receiver    string `json:"receiver"`
+	Integration string `json:"integration"`
+	// Format is either "table", the default, or "timeseries".
+	Format string `json:"format"`
+}
@@ -109,6 +109,9 @@ export type Route = {
   repeat_interval?: string;
   routes?: Route[];
   mute_time_intervals?: string[];
+  /** overrides the timezone and locale of the organization in the notifications of the policy */
+  time_zone?: string;
+  locale?: string;
   /** only the root policy might have a provenance field defined */
   provenance?: string;
 };
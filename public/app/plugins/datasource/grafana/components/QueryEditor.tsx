@@ -59,6 +59,14 @@ export class QueryEditor extends PureComponent<Props, State> {
         description: 'Search for grafana resources',
       });
     }
+
+    if (config.unifiedAlertingEnabled) {
+      this.queryTypes.push({
+        label: 'Alert notification history',
+        value: GrafanaQueryType.AlertNotificationHistory,
+        description: 'Volume, failure rate and latency of the alert notifications',
+      });
+    }
   }
 
   loadChannelInfo() {
@@ -344,6 +352,52 @@ export class QueryEditor extends PureComponent<Props, State> {
     );
   }
 
+  notificationHistoryFormats: Array<SelectableValue<'table' | 'timeseries'>> = [
+    { label: 'Table', value: 'table', description: 'A row per notification' },
+    { label: 'Time series', value: 'timeseries', description: 'A series per receiver and integration' },
+  ];
+
+  onNotificationHistoryChange = (change: Partial<GrafanaQuery>) => {
+    const { onChange, query, onRunQuery } = this.props;
+    onChange({ ...query, ...change });
+    onRunQuery();
+  };
+
+  renderAlertNotificationHistory() {
+    const { receiver, integration, format } = this.props.query;
+
+    return (
+      <InlineFieldRow>
+        <InlineField label="Receiver" labelWidth={labelWidth}>
+          <Input
+            placeholder="All receivers"
+            width={24}
+            defaultValue={receiver}
+            onBlur={(e) => this.onNotificationHistoryChange({ receiver: e.currentTarget.value || undefined })}
+            spellCheck={false}
+          />
+        </InlineField>
+        <InlineField label="Integration" tooltip="For example slack, email or webhook">
+          <Input
+            placeholder="All integrations"
+            width={24}
+            defaultValue={integration}
+            onBlur={(e) => this.onNotificationHistoryChange({ integration: e.currentTarget.value || undefined })}
+            spellCheck={false}
+          />
+        </InlineField>
+        <InlineField label="Format">
+          <Select
+            width={20}
+            options={this.notificationHistoryFormats}
+            value={format ?? 'table'}
+            onChange={(sel) => this.onNotificationHistoryChange({ format: sel.value })}
+          />
+        </InlineField>
+      </InlineFieldRow>
+    );
+  }
+
   onSearchChange = (search: SearchQuery) => {
     const { query, onChange, onRunQuery } = this.props;
 
@@ -381,6 +435,7 @@ export class QueryEditor extends PureComponent<Props, State> {
         </InlineFieldRow>
         {queryType === GrafanaQueryType.LiveMeasurements && this.renderMeasurementsQuery()}
         {queryType === GrafanaQueryType.List && this.renderListPublicFiles()}
+        {queryType === GrafanaQueryType.AlertNotificationHistory && this.renderAlertNotificationHistory()}
         {queryType === GrafanaQueryType.Search && (
           <SearchEditor value={query.search ?? {}} onChange={this.onSearchChange} />
         )}
@@ -15,6 +15,7 @@ export enum GrafanaQueryType {
   List = 'list',
   Read = 'read',
   Search = 'search',
+  AlertNotificationHistory = 'alertNotificationHistory',
 }
 
 export interface GrafanaQuery extends DataQuery {
@@ -24,6 +25,9 @@ export interface GrafanaQuery extends DataQuery {
   buffer?: number;
   path?: string; // for list and read
   search?: SearchQuery;
+  receiver?: string; // for alert notification history
+  integration?: string; // for alert notification history
+  format?: 'table' | 'timeseries'; // for alert notification history
 }
 
 export const defaultQuery: GrafanaQuery = {
@@ -75,6 +75,17 @@ export interface NotifierDTO {
   options: NotificationChannelOption[];
   info?: string;
   secure?: boolean;
+  capabilities?: NotifierCapabilities;
+}
+
+export interface NotifierCapabilities {
+  imageUpload: boolean;
+  imageUrl: boolean;
+  markdown: boolean;
+  threading: boolean;
+  actions: boolean;
+  maxMessageLength: number;
+  supportsResolved: boolean;
 }
 
 export interface NotificationChannelType {